@@ -0,0 +1,151 @@
+// Command dhis2-mock is a stand-in DHIS2 server for trying the DHIS2 push
+// without a real instance.
+//
+//	go run ./cmd/dhis2-mock -addr :8089 -user admin -password district
+//
+// Point the API at it with DHIS2_URL=http://localhost:8089 and the same
+// credentials. POST /api/dataValueSets imports values into memory and answers
+// with a DHIS2 import summary: malformed UIDs and non-numeric values become
+// conflicts, and values already stored count as updated. GET returns what has
+// been imported.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/service"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+type importCount struct {
+	Imported int `json:"imported"`
+	Updated  int `json:"updated"`
+	Ignored  int `json:"ignored"`
+	Deleted  int `json:"deleted"`
+}
+
+type conflict struct {
+	Object string `json:"object"`
+	Value  string `json:"value"`
+}
+
+type mock struct {
+	user, password string
+
+	mu     sync.Mutex
+	values map[string]models.Dhis2DataValue
+}
+
+func main() {
+	addr := flag.String("addr", ":8089", "address to listen on")
+	user := flag.String("user", "admin", "basic auth user name")
+	password := flag.String("password", "district", "basic auth password")
+	flag.Parse()
+
+	m := &mock{user: *user, password: *password, values: map[string]models.Dhis2DataValue{}}
+	http.HandleFunc("/api/dataValueSets", m.dataValueSets)
+	log.Printf("Mock DHIS2 listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+func (m *mock) dataValueSets(w http.ResponseWriter, r *http.Request) {
+	if user, password, ok := r.BasicAuth(); !ok || user != m.user || password != m.password {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"httpStatus": "Unauthorized", "httpStatusCode": 401, "status": "ERROR", "message": "Unauthorized",
+		})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		m.mu.Lock()
+		values := make([]models.Dhis2DataValue, 0, len(m.values))
+		for _, value := range m.values {
+			values = append(values, value)
+		}
+		m.mu.Unlock()
+		writeJSON(w, http.StatusOK, models.Dhis2DataValueSet{DataValues: values})
+	case http.MethodPost:
+		var set models.Dhis2DataValueSet
+		if err := json.NewDecoder(r.Body).Decode(&set); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"httpStatus": "Bad Request", "httpStatusCode": 400, "status": "ERROR", "message": err.Error(),
+			})
+			return
+		}
+		m.importValues(w, set)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (m *mock) importValues(w http.ResponseWriter, set models.Dhis2DataValueSet) {
+	var count importCount
+	conflicts := []conflict{}
+
+	m.mu.Lock()
+	for _, value := range set.DataValues {
+		if msg := checkValue(value); msg != "" {
+			count.Ignored++
+			conflicts = append(conflicts, conflict{Object: value.DataElement, Value: msg})
+			continue
+		}
+		key := fmt.Sprintf("%s/%s/%s/%s", value.DataElement, value.Period, value.OrgUnit, value.CategoryOptionCombo)
+		if _, ok := m.values[key]; ok {
+			count.Updated++
+		} else {
+			count.Imported++
+		}
+		m.values[key] = value
+	}
+	m.mu.Unlock()
+
+	status := "SUCCESS"
+	if len(conflicts) > 0 {
+		status = "WARNING"
+	}
+	log.Printf("Imported %d, updated %d, ignored %d values", count.Imported, count.Updated, count.Ignored)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"httpStatus":     "OK",
+		"httpStatusCode": 200,
+		"status":         "OK",
+		"message":        "Import was successful.",
+		"response": map[string]interface{}{
+			"responseType":    "ImportSummary",
+			"status":          status,
+			"importCount":     count,
+			"conflicts":       conflicts,
+			"dataSetComplete": "false",
+		},
+	})
+}
+
+// checkValue returns why DHIS2 would reject a value
+func checkValue(value models.Dhis2DataValue) string {
+	switch {
+	case !service.IsDhis2UID(value.DataElement):
+		return "Data element not found or not accessible"
+	case !service.IsDhis2UID(value.OrgUnit):
+		return "Organisation unit not found or not accessible: " + value.OrgUnit
+	case value.CategoryOptionCombo != "" && !service.IsDhis2UID(value.CategoryOptionCombo):
+		return "Category option combo not found or not accessible: " + value.CategoryOptionCombo
+	}
+	if _, _, err := service.ParseDhis2Period(value.Period); err != nil {
+		return "Period not valid: " + value.Period
+	}
+	if _, err := strconv.ParseInt(value.Value, 10, 64); err != nil {
+		return "Value must be an integer: " + value.Value
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,40 @@
+// Command migrate-suspect-media moves suspect photos and fingerprints still
+// stored inline in the suspects table into the blob store.
+//
+//	go run ./cmd/migrate-suspect-media -batch 100
+//
+// It uses the same database and STORAGE_* settings as the API and can be run
+// again safely: each file is moved in one step, suspects already moved are
+// skipped, and suspects that failed are retried.
+package main
+
+import (
+	"context"
+	"flag"
+	"gbvmis/internals/database"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/storage"
+	"log"
+)
+
+func main() {
+	batch := flag.Int("batch", 100, "suspects to load per batch")
+	flag.Parse()
+
+	db := database.NewDatabase()
+	db.Connect()
+	defer db.Close()
+	db.Migrate()
+
+	store, err := storage.NewBlobStore()
+	if err != nil {
+		log.Fatalf("Failed to initialise blob storage: %v", err)
+	}
+
+	moved, err := service.MigrateLegacySuspectMedia(context.Background(), repository.SuspectDbService(db.GetDB()), repository.TrackedBlobStore(db.GetDB(), store), *batch)
+	if err != nil {
+		log.Fatalf("Moved %d files; some could not be moved, run again to retry them: %v", moved, err)
+	}
+	log.Printf("Moved %d suspect photos and fingerprints to %s storage", moved, store.Driver())
+}
@@ -4,6 +4,9 @@ import (
 	"errors"
 	"gbvmis/internals/models"
 	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"log"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,40 +14,59 @@ import (
 )
 
 type ArrestController struct {
-	repo repository.ArrestRepository
+	repo      repository.ArrestRepository
+	watchlist repository.WatchlistRepository
 }
 
-func NewArrestController(repo repository.ArrestRepository) *ArrestController {
-	return &ArrestController{repo: repo}
+func NewArrestController(repo repository.ArrestRepository, watchlist repository.WatchlistRepository) *ArrestController {
+	return &ArrestController{repo: repo, watchlist: watchlist}
 }
 
 type CreateArrestPayload struct {
-	ArrestDate  time.Time `json:"arrest_date"`
-	Location    string    `json:"location"`
-	OfficerName string    `json:"officer_name"`
-	SuspectID   uint      `json:"suspect_id"`
-	Notes       string    `json:"notes"`
+	ArrestDate time.Time `json:"arrest_date" validate:"required"`
+	Location   string    `json:"location"`
+	OfficerID  uint      `json:"officer_id" validate:"required"`
+	CaseID     uint      `json:"case_id" validate:"required"`
+	SuspectID  uint      `json:"suspect_id" validate:"required"`
+	Notes      string    `json:"notes"`
 }
 
 type ArrestResponse struct {
-	ID          uint      `json:"id"`
-	ArrestDate  time.Time `json:"arrest_date"`
-	Location    string    `json:"location"`
-	OfficerName string    `json:"officer_name"`
-	SuspectID   uint      `json:"suspect_id"`
-	Notes       string    `json:"notes"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          uint              `json:"id"`
+	ArrestDate  time.Time         `json:"arrest_date"`
+	Location    string            `json:"location"`
+	OfficerID   uint              `json:"officer_id"`
+	OfficerName string            `json:"officer_name"`
+	CaseID      uint              `json:"case_id"`
+	CaseNumber  string            `json:"case_number"`
+	SuspectID   uint              `json:"suspect_id"`
+	Notes       string            `json:"notes"`
+	Custody     []CustodyResponse `json:"custody"`
+	CreatedAt   time.Time         `json:"created_at"`
 }
 
 func ConvertToArrestResponse(a models.Arrest) ArrestResponse {
+	officerName := a.OfficerName
+	if a.Officer.ID != 0 {
+		officerName = a.Officer.FirstName + " " + a.Officer.LastName
+	}
+
+	custody := make([]CustodyResponse, len(a.Custody))
+	for i, rec := range a.Custody {
+		custody[i] = ConvertToCustodyResponse(rec)
+	}
 
 	return ArrestResponse{
 		ID:          a.ID,
 		ArrestDate:  a.ArrestDate,
 		Location:    a.Location,
-		OfficerName: a.OfficerName,
+		OfficerID:   a.OfficerID,
+		OfficerName: officerName,
+		CaseID:      a.CaseID,
+		CaseNumber:  a.Case.CaseNumber,
 		SuspectID:   a.SuspectID,
 		Notes:       a.Notes,
+		Custody:     custody,
 		CreatedAt:   a.CreatedAt,
 	}
 }
@@ -54,7 +76,7 @@ func ConvertToArrestResponse(a models.Arrest) ArrestResponse {
 // CreateArrest godoc
 //
 //	@Summary		Create a new arrest record
-//	@Description	Creates a new arrest entry in the system and returns the created record.
+//	@Description	Creates a new arrest entry and returns it with watchlist_alerts if the suspect is wanted or watched; the issuing station is notified. The suspect must be linked to the case.
 //	@Tags			Arrests
 //	@Accept			json
 //	@Produce		json
@@ -73,12 +95,47 @@ func (h *ArrestController) CreateArrest(c *fiber.Ctx) error {
 		})
 	}
 
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"data":    errs,
+		})
+	}
+
+	officer, err := h.repo.FindOfficerByID(payload.OfficerID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid officer ID", err))
+	}
+	casee, err := h.repo.FindCaseByID(payload.CaseID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid case ID", err))
+	}
+	if _, err := h.repo.FindSuspectByID(payload.SuspectID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid suspect ID", err))
+	}
+
+	linked, err := h.repo.SuspectInCase(payload.SuspectID, casee.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to check the suspect's cases", err))
+	}
+	if !linked {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Suspect is not linked to the case",
+		})
+	}
+
 	a := &models.Arrest{
 		ArrestDate:  payload.ArrestDate,
 		Location:    payload.Location,
-		OfficerName: payload.OfficerName,
+		OfficerID:   officer.ID,
+		OfficerName: officer.FirstName + " " + officer.LastName,
+		CaseID:      casee.ID,
 		SuspectID:   payload.SuspectID,
 		Notes:       payload.Notes,
+		Officer:     officer,
+		Case:        casee,
 	}
 
 	if err := h.repo.CreateArrest(a); err != nil {
@@ -91,10 +148,26 @@ func (h *ArrestController) CreateArrest(c *fiber.Ctx) error {
 
 	response := ConvertToArrestResponse(*a)
 
+	// Arresting a wanted or watched person notifies the issuing station
+	var alerts []service.WatchlistAlert
+	if suspect, err := h.watchlist.FindSuspectByID(a.SuspectID); err == nil {
+		arrestID := a.ID
+		alerts, err = service.CheckWatchlist(h.watchlist, service.WatchlistCheck{
+			Trigger:   models.WatchlistTriggerArrest,
+			OfficerID: c.Locals("user").(*utils.Claims).UserID,
+			ArrestID:  &arrestID,
+			Suspects:  []models.Suspect{suspect},
+		})
+		if err != nil {
+			log.Println("Error checking watchlist for arrest", a.ID, err)
+		}
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"status":  "success",
-		"message": "Arrest created successfully",
-		"data":    response,
+		"status":           "success",
+		"message":          "Arrest created successfully",
+		"data":             response,
+		"watchlist_alerts": alerts,
 	})
 }
 
@@ -184,11 +257,12 @@ func (h *ArrestController) GetSingleArrest(c *fiber.Ctx) error {
 
 // Define the UpdateArrest struct
 type UpdateArrestPayload struct {
-	ArrestDate  time.Time `json:"arrest_date"`
-	Location    string    `json:"location"`
-	OfficerName string    `json:"officer_name"`
-	SuspectID   uint      `json:"suspect_id"`
-	Notes       string    `json:"notes"`
+	ArrestDate time.Time `json:"arrest_date"`
+	Location   string    `json:"location"`
+	OfficerID  uint      `json:"officer_id"`
+	CaseID     uint      `json:"case_id"`
+	SuspectID  uint      `json:"suspect_id"`
+	Notes      string    `json:"notes"`
 }
 
 // UpdateArrest godoc
@@ -210,7 +284,7 @@ func (h *ArrestController) UpdateArrest(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	// Find the Arrest in the database
-	_, err := h.repo.GetArrestByID(id)
+	arrest, err := h.repo.GetArrestByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(404).JSON(fiber.Map{
@@ -255,16 +329,51 @@ func (h *ArrestController) UpdateArrest(c *fiber.Ctx) error {
 		updates["location"] = payload.Location
 	}
 
-	// Only include OfficerName if it’s non-empty
-	if payload.OfficerName != "" {
-		updates["officer_name"] = payload.OfficerName
+	// Re-link the arresting officer, keeping the denormalised name in step
+	if payload.OfficerID != 0 {
+		officer, err := h.repo.FindOfficerByID(payload.OfficerID)
+		if err != nil {
+			return c.Status(400).JSON(utils.ErrorResponse("Invalid officer ID", err))
+		}
+		updates["officer_id"] = officer.ID
+		updates["officer_name"] = officer.FirstName + " " + officer.LastName
+	}
+
+	if payload.CaseID != 0 {
+		if _, err := h.repo.FindCaseByID(payload.CaseID); err != nil {
+			return c.Status(400).JSON(utils.ErrorResponse("Invalid case ID", err))
+		}
+		updates["case_id"] = payload.CaseID
 	}
 
-	// Only include SuspectID if it’s non-zero
 	if payload.SuspectID != 0 {
+		if _, err := h.repo.FindSuspectByID(payload.SuspectID); err != nil {
+			return c.Status(400).JSON(utils.ErrorResponse("Invalid suspect ID", err))
+		}
 		updates["suspect_id"] = payload.SuspectID
 	}
 
+	// The arrested suspect must stay linked to the arrest's case
+	if payload.CaseID != 0 || payload.SuspectID != 0 {
+		caseID, suspectID := arrest.CaseID, arrest.SuspectID
+		if payload.CaseID != 0 {
+			caseID = payload.CaseID
+		}
+		if payload.SuspectID != 0 {
+			suspectID = payload.SuspectID
+		}
+		linked, err := h.repo.SuspectInCase(suspectID, caseID)
+		if err != nil {
+			return c.Status(500).JSON(utils.ErrorResponse("Failed to check the suspect's cases", err))
+		}
+		if !linked {
+			return c.Status(400).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Suspect is not linked to the case",
+			})
+		}
+	}
+
 	// Only include Notes if it’s non-empty
 	if payload.Notes != "" {
 		updates["notes"] = payload.Notes
@@ -0,0 +1,361 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/storage"
+	"gbvmis/internals/utils"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// AllowedAttachmentTypes lists the sniffed MIME types accepted for upload
+var AllowedAttachmentTypes = []string{
+	"application/pdf",
+	"image/jpeg",
+	"image/png",
+	"image/gif",
+	"image/webp",
+	"image/bmp",
+	"text/plain; charset=utf-8",
+	"audio/mpeg",
+	"audio/wave",
+	"video/mp4",
+}
+
+type AttachmentController struct {
+	repo    repository.AttachmentRepository
+	store   storage.BlobStore
+	scanner storage.Scanner
+}
+
+func NewAttachmentController(repo repository.AttachmentRepository, store storage.BlobStore, scanner storage.Scanner) *AttachmentController {
+	return &AttachmentController{repo: repo, store: store, scanner: scanner}
+}
+
+type AttachmentResponse struct {
+	ID           uint      `json:"id"`
+	OwnerType    string    `json:"owner_type"`
+	OwnerID      uint      `json:"owner_id"`
+	FileName     string    `json:"file_name"`
+	MimeType     string    `json:"mime_type"`
+	Size         int64     `json:"size"`
+	SHA256       string    `json:"sha256"`
+	Category     string    `json:"category"`
+	Description  string    `json:"description"`
+	ScanStatus   string    `json:"scan_status"`
+	UploadedByID uint      `json:"uploaded_by_id"`
+	DownloadURL  string    `json:"download_url"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func ConvertToAttachmentResponse(a models.Attachment) AttachmentResponse {
+	return AttachmentResponse{
+		ID:           a.ID,
+		OwnerType:    a.OwnerType,
+		OwnerID:      a.OwnerID,
+		FileName:     a.FileName,
+		MimeType:     a.MimeType,
+		Size:         a.Size,
+		SHA256:       a.BlobSHA256,
+		Category:     a.Category,
+		Description:  a.Description,
+		ScanStatus:   a.Blob.ScanStatus,
+		UploadedByID: a.UploadedByID,
+		DownloadURL:  fmt.Sprintf("/api/attachment/%d/download", a.ID),
+		CreatedAt:    a.CreatedAt,
+	}
+}
+
+// authorizeOwner checks the parent record exists and that the caller may see it,
+// writing the error response itself when not
+func (h *AttachmentController) authorizeOwner(c *fiber.Ctx, ownerType string, ownerID uint) (bool, error) {
+	scope, err := h.repo.ResolveOwnerScope(ownerType, ownerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Parent record not found",
+			})
+		}
+		return false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to load parent record", err))
+	}
+
+	claims := c.Locals("user").(*utils.Claims)
+	officer, err := h.repo.FindOfficerByID(claims.UserID)
+	if err != nil {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Unknown officer",
+		})
+	}
+
+	if !service.CanAccessOwner(claims, officer, scope) {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "You do not have access to this record",
+		})
+	}
+	return true, nil
+}
+
+// ================================
+
+// UploadAttachment godoc
+//
+//	@Summary		Attach a file to a case, examination, toxicology report or exhibit
+//	@Description	Uploads a file, sniffs its type, runs the virus-scan hook and stores it by SHA-256. Identical content is stored once.
+//	@Tags			Attachments
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			id			path		string		true	"Parent record ID"
+//	@Param			file		formData	file		true	"File to attach"
+//	@Param			category	formData	string		false	"Category, e.g. Medical form, Photo, Statement"
+//	@Param			description	formData	string		false	"Description"
+//	@Success		201			{object}	fiber.Map	"Attachment uploaded successfully"
+//	@Failure		400			{object}	fiber.Map	"Missing file"
+//	@Failure		403			{object}	fiber.Map	"No access to the parent record"
+//	@Failure		404			{object}	fiber.Map	"Parent record not found"
+//	@Failure		415			{object}	fiber.Map	"File type not allowed"
+//	@Failure		422			{object}	fiber.Map	"File rejected by virus scan"
+//	@Failure		500			{object}	fiber.Map	"Server error when storing attachment"
+//	@Router			/case/{id}/attachments [post]
+//	@Router			/examination/{id}/attachments [post]
+//	@Router			/toxicology-report/{id}/attachments [post]
+//	@Router			/exhibit/{id}/attachments [post]
+func (h *AttachmentController) UploadAttachment(ownerType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid record ID", err))
+		}
+		if ok, err := h.authorizeOwner(c, ownerType, uint(ownerID)); !ok {
+			return err
+		}
+
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("file is required", err))
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Failed to open file", err))
+		}
+		defer file.Close()
+
+		staged, err := storage.Stage(file)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to read file", err))
+		}
+		defer staged.Close()
+
+		if !slices.Contains(AllowedAttachmentTypes, staged.MimeType) {
+			return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+				"status":  "error",
+				"message": "File type not allowed",
+				"data":    staged.MimeType,
+			})
+		}
+
+		r, err := staged.Reader()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to read file", err))
+		}
+		scanStatus, signature, err := h.scanner.Scan(c.Context(), r)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Virus scan failed", err))
+		}
+		if scanStatus == storage.ScanInfected {
+			log.Printf("Rejected infected upload %s (%s) for %s %d", fileHeader.Filename, signature, ownerType, ownerID)
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"status":  "error",
+				"message": "File rejected by virus scan",
+				"data":    signature,
+			})
+		}
+
+		if err := staged.Save(c.Context(), h.store); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to store file", err))
+		}
+
+		claims := c.Locals("user").(*utils.Claims)
+		blob := &models.FileBlob{
+			SHA256:        staged.SHA256,
+			Size:          staged.Size,
+			MimeType:      staged.MimeType,
+			Driver:        h.store.Driver(),
+			ScanStatus:    scanStatus,
+			ScanSignature: signature,
+		}
+		attachment := &models.Attachment{
+			OwnerType:    ownerType,
+			OwnerID:      uint(ownerID),
+			BlobSHA256:   staged.SHA256,
+			FileName:     fileHeader.Filename,
+			MimeType:     staged.MimeType,
+			Size:         staged.Size,
+			Category:     c.FormValue("category"),
+			Description:  c.FormValue("description"),
+			UploadedByID: claims.UserID,
+		}
+		if err := h.repo.CreateAttachment(attachment, blob); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to save attachment", err))
+		}
+		attachment.Blob = *blob
+
+		return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Attachment uploaded successfully", ConvertToAttachmentResponse(*attachment)))
+	}
+}
+
+// ===========
+
+// ListAttachments godoc
+//
+//	@Summary		List files attached to a record
+//	@Description	Lists attachments of a case, examination, toxicology report or exhibit, newest first.
+//	@Tags			Attachments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Parent record ID"
+//	@Success		200	{object}	fiber.Map	"Attachments retrieved successfully"
+//	@Failure		403	{object}	fiber.Map	"No access to the parent record"
+//	@Failure		404	{object}	fiber.Map	"Parent record not found"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve attachments"
+//	@Router			/case/{id}/attachments [get]
+//	@Router			/examination/{id}/attachments [get]
+//	@Router			/toxicology-report/{id}/attachments [get]
+//	@Router			/exhibit/{id}/attachments [get]
+func (h *AttachmentController) ListAttachments(ownerType string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ownerID, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid record ID", err))
+		}
+		if ok, err := h.authorizeOwner(c, ownerType, uint(ownerID)); !ok {
+			return err
+		}
+
+		attachments, err := h.repo.ListAttachments(ownerType, uint(ownerID))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve attachments", err))
+		}
+
+		responses := make([]AttachmentResponse, len(attachments))
+		for i, a := range attachments {
+			responses[i] = ConvertToAttachmentResponse(a)
+		}
+
+		return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Attachments retrieved successfully", responses))
+	}
+}
+
+// =========
+
+// loadAttachment fetches an attachment and checks access through its parent record
+func (h *AttachmentController) loadAttachment(c *fiber.Ctx) (models.Attachment, bool, error) {
+	attachment, err := h.repo.GetAttachmentByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return attachment, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Attachment not found",
+			})
+		}
+		return attachment, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve attachment", err))
+	}
+	ok, err := h.authorizeOwner(c, attachment.OwnerType, attachment.OwnerID)
+	return attachment, ok, err
+}
+
+// GetSingleAttachment godoc
+//
+//	@Summary		Retrieve attachment metadata by ID
+//	@Description	Fetches the metadata of an attachment, if the caller can see its parent record.
+//	@Tags			Attachments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Attachment ID"
+//	@Success		200	{object}	fiber.Map	"Attachment retrieved successfully"
+//	@Failure		403	{object}	fiber.Map	"No access to the parent record"
+//	@Failure		404	{object}	fiber.Map	"Attachment not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving attachment"
+//	@Router			/attachment/{id} [get]
+func (h *AttachmentController) GetSingleAttachment(c *fiber.Ctx) error {
+	attachment, ok, err := h.loadAttachment(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Attachment retrieved successfully", ConvertToAttachmentResponse(attachment)))
+}
+
+// DownloadAttachment godoc
+//
+//	@Summary		Download an attachment
+//	@Description	Streams the attachment content with its sniffed content type.
+//	@Tags			Attachments
+//	@Produce		octet-stream
+//	@Param			id	path		string		true	"Attachment ID"
+//	@Success		200	{file}		binary		"Attachment content"
+//	@Failure		403	{object}	fiber.Map	"No access to the parent record"
+//	@Failure		404	{object}	fiber.Map	"Attachment not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when reading attachment"
+//	@Router			/attachment/{id}/download [get]
+func (h *AttachmentController) DownloadAttachment(c *fiber.Ctx) error {
+	attachment, ok, err := h.loadAttachment(c)
+	if !ok {
+		return err
+	}
+
+	content, err := h.store.Get(c.Context(), attachment.BlobSHA256)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Attachment content is missing from storage",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to read attachment", err))
+	}
+
+	c.Set(fiber.HeaderContentType, attachment.MimeType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", strings.ReplaceAll(attachment.FileName, `"`, "")))
+	c.Set(fiber.HeaderETag, `"`+attachment.BlobSHA256+`"`)
+	return c.SendStream(content, int(attachment.Size))
+}
+
+// ==================
+
+// DeleteAttachmentByID godoc
+//
+//	@Summary		Delete an attachment by ID
+//	@Description	Removes an attachment. Stored content is deleted once nothing else references it.
+//	@Tags			Attachments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Attachment ID"
+//	@Success		200	{object}	fiber.Map	"Attachment deleted successfully"
+//	@Failure		403	{object}	fiber.Map	"No access to the parent record"
+//	@Failure		404	{object}	fiber.Map	"Attachment not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting attachment"
+//	@Router			/attachment/{id} [delete]
+func (h *AttachmentController) DeleteAttachmentByID(c *fiber.Ctx) error {
+	attachment, ok, err := h.loadAttachment(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.repo.DeleteAttachment(&attachment); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete attachment", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Attachment deleted successfully", ConvertToAttachmentResponse(attachment)))
+}
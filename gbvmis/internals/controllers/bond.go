@@ -0,0 +1,643 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type BondController struct {
+	repo repository.BondRepository
+}
+
+func NewBondController(repo repository.BondRepository) *BondController {
+	return &BondController{repo: repo}
+}
+
+type SuretyPayload struct {
+	FullName     string `json:"full_name" validate:"required"`
+	PhoneNumber  string `json:"phone_number" validate:"required"`
+	Nin          string `json:"nin"`
+	Address      string `json:"address"`
+	Relationship string `json:"relationship"`
+}
+
+type CreateBondPayload struct {
+	SuspectID       uint            `json:"suspect_id" validate:"required"`
+	CaseID          uint            `json:"case_id" validate:"required"`
+	CustodyRecordID *uint           `json:"custody_record_id"`
+	GrantedAt       time.Time       `json:"granted_at"`
+	ExpiresAt       time.Time       `json:"expires_at" validate:"required"`
+	Conditions      string          `json:"conditions" validate:"required"`
+	Sureties        []SuretyPayload `json:"sureties" validate:"dive"`
+	ReportingDates  []string        `json:"reporting_dates"` // Use `YYYY-MM-DD`
+}
+
+type BondReportingResponse struct {
+	ID         uint       `json:"id"`
+	BondID     uint       `json:"bond_id"`
+	DueDate    time.Time  `json:"due_date"`
+	ReportedAt *time.Time `json:"reported_at"`
+	ReceivedBy string     `json:"received_by"`
+	Notes      string     `json:"notes"`
+	Missed     bool       `json:"missed"`
+
+	SuspectID   uint   `json:"suspect_id,omitempty"`
+	SuspectName string `json:"suspect_name,omitempty"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+	CaseNumber  string `json:"case_number,omitempty"`
+}
+
+type BondResponse struct {
+	ID              uint                    `json:"id"`
+	SuspectID       uint                    `json:"suspect_id"`
+	SuspectName     string                  `json:"suspect_name"`
+	CaseID          uint                    `json:"case_id"`
+	CaseNumber      string                  `json:"case_number"`
+	CustodyRecordID *uint                   `json:"custody_record_id"`
+	GrantedByID     uint                    `json:"granted_by_id"`
+	GrantedAt       time.Time               `json:"granted_at"`
+	ExpiresAt       time.Time               `json:"expires_at"`
+	Conditions      string                  `json:"conditions"`
+	Status          string                  `json:"status"`
+	CancelledAt     *time.Time              `json:"cancelled_at"`
+	CancelReason    string                  `json:"cancel_reason"`
+	Sureties        []models.BondSurety     `json:"sureties"`
+	ReportingDates  []BondReportingResponse `json:"reporting_dates"`
+	CreatedAt       time.Time               `json:"created_at"`
+}
+
+func ConvertToBondReportingResponse(rd models.BondReportingDate) BondReportingResponse {
+	resp := BondReportingResponse{
+		ID:         rd.ID,
+		BondID:     rd.BondID,
+		DueDate:    rd.DueDate,
+		ReportedAt: rd.ReportedAt,
+		ReceivedBy: rd.ReceivedBy,
+		Notes:      rd.Notes,
+		Missed:     rd.ReportedAt == nil && rd.DueDate.Before(startOfDay(time.Now())),
+	}
+	if rd.Bond.ID != 0 {
+		resp.SuspectID = rd.Bond.SuspectID
+		resp.SuspectName = rd.Bond.Suspect.FirstName + " " + rd.Bond.Suspect.LastName
+		resp.PhoneNumber = rd.Bond.Suspect.PhoneNumber
+		resp.CaseNumber = rd.Bond.Case.CaseNumber
+	}
+	return resp
+}
+
+func ConvertToBondResponse(b models.PoliceBond) BondResponse {
+	reportings := make([]BondReportingResponse, len(b.ReportingDates))
+	for i, rd := range b.ReportingDates {
+		reportings[i] = ConvertToBondReportingResponse(rd)
+	}
+
+	suspectName := ""
+	if b.Suspect.ID != 0 {
+		suspectName = b.Suspect.FirstName + " " + b.Suspect.LastName
+	}
+
+	return BondResponse{
+		ID:              b.ID,
+		SuspectID:       b.SuspectID,
+		SuspectName:     suspectName,
+		CaseID:          b.CaseID,
+		CaseNumber:      b.Case.CaseNumber,
+		CustodyRecordID: b.CustodyRecordID,
+		GrantedByID:     b.GrantedByID,
+		GrantedAt:       b.GrantedAt,
+		ExpiresAt:       b.ExpiresAt,
+		Conditions:      b.Conditions,
+		Status:          b.Status,
+		CancelledAt:     b.CancelledAt,
+		CancelReason:    b.CancelReason,
+		Sureties:        b.Sureties,
+		ReportingDates:  reportings,
+		CreatedAt:       b.CreatedAt,
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// ================================
+
+// CreateBond godoc
+//
+//	@Summary		Release a suspect on police bond
+//	@Description	Records a police bond with its conditions, sureties and reporting dates. The suspect must be linked to the case. The suspect's status is set to on_police_bond and, if custody_record_id is given, the custody record is closed.
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Param			bond	body		CreateBondPayload	true	"Bond data to create"
+//	@Success		201		{object}	fiber.Map			"Successfully created bond"
+//	@Failure		400		{object}	fiber.Map			"Bad request due to invalid input"
+//	@Failure		500		{object}	fiber.Map			"Server error when creating bond"
+//	@Router			/bond [post]
+func (h *BondController) CreateBond(c *fiber.Ctx) error {
+	var payload CreateBondPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"data":    errs,
+		})
+	}
+
+	if _, err := h.repo.FindSuspectByID(payload.SuspectID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid suspect ID", err))
+	}
+
+	linked, err := h.repo.SuspectInCase(payload.SuspectID, payload.CaseID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to check the suspect's cases", err))
+	}
+	if !linked {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Suspect is not linked to the case",
+		})
+	}
+
+	if payload.CustodyRecordID != nil {
+		record, err := h.repo.FindCustodyRecordByID(*payload.CustodyRecordID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid custody record ID", err))
+		}
+		if record.SuspectID != payload.SuspectID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Custody record belongs to a different suspect",
+			})
+		}
+	}
+
+	grantedAt := payload.GrantedAt
+	if grantedAt.IsZero() {
+		grantedAt = time.Now()
+	}
+	if !payload.ExpiresAt.After(grantedAt) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "expires_at must be after granted_at",
+		})
+	}
+
+	user := c.Locals("user").(*utils.Claims)
+
+	bond := &models.PoliceBond{
+		SuspectID:       payload.SuspectID,
+		CaseID:          payload.CaseID,
+		CustodyRecordID: payload.CustodyRecordID,
+		GrantedByID:     user.UserID,
+		GrantedAt:       grantedAt,
+		ExpiresAt:       payload.ExpiresAt,
+		Conditions:      payload.Conditions,
+		Status:          models.BondStatusActive,
+	}
+
+	for _, s := range payload.Sureties {
+		bond.Sureties = append(bond.Sureties, models.BondSurety{
+			FullName:     s.FullName,
+			PhoneNumber:  s.PhoneNumber,
+			Nin:          s.Nin,
+			Address:      s.Address,
+			Relationship: s.Relationship,
+		})
+	}
+
+	for _, d := range payload.ReportingDates {
+		due, err := utils.ParseDate(d)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid reporting date; use YYYY-MM-DD", err))
+		}
+		bond.ReportingDates = append(bond.ReportingDates, models.BondReportingDate{DueDate: due})
+	}
+
+	if err := h.repo.CreateBond(bond); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create bond", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Bond created successfully", ConvertToBondResponse(*bond)))
+}
+
+// ===========
+
+// GetAllBonds godoc
+//
+//	@Summary		Retrieve a paginated list of police bonds
+//	@Description	Fetches all police bonds with pagination support.
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Bonds retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve bonds"
+//	@Router			/bonds [get]
+func (h *BondController) GetAllBonds(c *fiber.Ctx) error {
+	pagination, bonds, err := h.repo.GetPaginatedBonds(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve bonds", err))
+	}
+
+	responses := make([]BondResponse, len(bonds))
+	for i, b := range bonds {
+		responses[i] = ConvertToBondResponse(b)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Bonds retrieved successfully",
+		"data":    responses,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// =========
+
+// GetBondsDue godoc
+//
+//	@Summary		List suspects due to report back on police bond
+//	@Description	Returns outstanding reporting dates of active bonds between from and to (YYYY-MM-DD). Both default to today.
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query		string		false	"Start date (YYYY-MM-DD)"
+//	@Param			to		query		string		false	"End date (YYYY-MM-DD)"
+//	@Success		200		{object}	fiber.Map	"Due reporting dates retrieved successfully"
+//	@Failure		400		{object}	fiber.Map	"Invalid date"
+//	@Failure		500		{object}	fiber.Map	"Failed to retrieve due reporting dates"
+//	@Router			/bonds/due [get]
+func (h *BondController) GetBondsDue(c *fiber.Ctx) error {
+	from := startOfDay(time.Now())
+	to := from
+	if v := c.Query("from"); v != "" {
+		parsed, err := utils.ParseDate(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid from date; use YYYY-MM-DD", err))
+		}
+		from = parsed
+	}
+	if v := c.Query("to"); v != "" {
+		parsed, err := utils.ParseDate(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid to date; use YYYY-MM-DD", err))
+		}
+		to = parsed
+	}
+
+	reportings, err := h.repo.FindReportingsDue(from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve due reporting dates", err))
+	}
+
+	responses := make([]BondReportingResponse, len(reportings))
+	for i, rd := range reportings {
+		responses[i] = ConvertToBondReportingResponse(rd)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Due reporting dates retrieved successfully", responses))
+}
+
+// =========
+
+// GetBondBreaches godoc
+//
+//	@Summary		List suspects in breach of police bond
+//	@Description	Returns reporting dates of active bonds that passed without the suspect reporting back.
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Bond breaches retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve bond breaches"
+//	@Router			/bonds/breaches [get]
+func (h *BondController) GetBondBreaches(c *fiber.Ctx) error {
+	reportings, err := h.repo.FindMissedReportings(startOfDay(time.Now()))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve bond breaches", err))
+	}
+
+	responses := make([]BondReportingResponse, len(reportings))
+	for i, rd := range reportings {
+		responses[i] = ConvertToBondReportingResponse(rd)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Bond breaches retrieved successfully", responses))
+}
+
+// =========
+
+// GetSingleBond godoc
+//
+//	@Summary		Retrieve a single police bond by ID
+//	@Description	Fetches a police bond with its sureties and reporting dates.
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Bond ID"
+//	@Success		200	{object}	fiber.Map	"Bond retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Bond not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving bond"
+//	@Router			/bond/{id} [get]
+func (h *BondController) GetSingleBond(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	bond, err := h.repo.GetBondByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Bond not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve bond", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Bond retrieved successfully", ConvertToBondResponse(bond)))
+}
+
+// =======================
+
+type UpdateBondPayload struct {
+	Conditions string    `json:"conditions"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// UpdateBond godoc
+//
+//	@Summary		Update the conditions or expiry of an active police bond
+//	@Description	Updates the conditions or extends the expiry of an active police bond.
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Bond ID"
+//	@Param			bond	body		UpdateBondPayload	true	"Bond data to update"
+//	@Success		200		{object}	fiber.Map			"Bond updated successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input or empty request body"
+//	@Failure		404		{object}	fiber.Map			"Bond not found"
+//	@Failure		409		{object}	fiber.Map			"Bond is no longer active"
+//	@Failure		500		{object}	fiber.Map			"Server error when updating bond"
+//	@Router			/bond/{id} [put]
+func (h *BondController) UpdateBond(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	bond, err := h.repo.GetBondByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Bond not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve bond", err))
+	}
+	if bond.Status != models.BondStatusActive {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Bond is no longer active",
+		})
+	}
+
+	var payload UpdateBondPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	if (UpdateBondPayload{} == payload) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Empty request body",
+		})
+	}
+
+	updates := make(map[string]interface{})
+	if payload.Conditions != "" {
+		updates["conditions"] = payload.Conditions
+	}
+	if !payload.ExpiresAt.IsZero() {
+		if !payload.ExpiresAt.After(bond.GrantedAt) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "expires_at must be after granted_at",
+			})
+		}
+		updates["expires_at"] = payload.ExpiresAt
+	}
+
+	if err := h.repo.UpdateBond(id, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update bond", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Bond updated successfully", updates))
+}
+
+// =======================
+
+type CancelBondPayload struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// CancelBond godoc
+//
+//	@Summary		Cancel a police bond
+//	@Description	Cancels an active police bond and sets the suspect's status to bond_cancelled, unless they hold another active bond.
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Bond ID"
+//	@Param			cancel	body		CancelBondPayload	true	"Reason for cancellation"
+//	@Success		200		{object}	fiber.Map			"Bond cancelled successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Bond not found"
+//	@Failure		409		{object}	fiber.Map			"Bond is no longer active"
+//	@Failure		500		{object}	fiber.Map			"Server error when cancelling bond"
+//	@Router			/bond/{id}/cancel [post]
+func (h *BondController) CancelBond(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	bond, err := h.repo.GetBondByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Bond not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve bond", err))
+	}
+	if bond.Status != models.BondStatusActive {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Bond is no longer active",
+		})
+	}
+
+	var payload CancelBondPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"data":    errs,
+		})
+	}
+
+	now := time.Now()
+	if err := h.repo.CancelBond(&bond, payload.Reason, now); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to cancel bond", err))
+	}
+
+	bond.Status = models.BondStatusCancelled
+	bond.CancelledAt = &now
+	bond.CancelReason = payload.Reason
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Bond cancelled successfully", ConvertToBondResponse(bond)))
+}
+
+// =======================
+
+type BondCheckInPayload struct {
+	ReportedAt time.Time `json:"reported_at"`
+	Notes      string    `json:"notes"`
+}
+
+// RecordBondCheckIn godoc
+//
+//	@Summary		Record a suspect reporting back on police bond
+//	@Description	Marks the earliest outstanding reporting date of the bond as attended.
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Bond ID"
+//	@Param			checkin	body		BondCheckInPayload	true	"Check-in details"
+//	@Success		200		{object}	fiber.Map			"Check-in recorded successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Bond not found"
+//	@Failure		409		{object}	fiber.Map			"Bond is no longer active"
+//	@Failure		500		{object}	fiber.Map			"Server error when recording check-in"
+//	@Router			/bond/{id}/check-in [post]
+func (h *BondController) RecordBondCheckIn(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	bond, err := h.repo.GetBondByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Bond not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve bond", err))
+	}
+	if bond.Status != models.BondStatusActive {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Bond is no longer active",
+		})
+	}
+
+	var payload BondCheckInPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	reportedAt := payload.ReportedAt
+	if reportedAt.IsZero() {
+		reportedAt = time.Now()
+	}
+
+	user := c.Locals("user").(*utils.Claims)
+	reporting, err := h.repo.RecordCheckIn(bond.ID, reportedAt, user.Email, payload.Notes)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record check-in", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Check-in recorded successfully", ConvertToBondReportingResponse(reporting)))
+}
+
+// ==================
+
+// DeleteBondByID godoc
+//
+//	@Summary		Delete a police bond by ID
+//	@Description	Deletes a police bond based on the provided ID and updates the suspect's bond status from the bonds that remain.
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Bond ID"
+//	@Success		200	{object}	fiber.Map	"Bond deleted successfully"
+//	@Failure		404	{object}	fiber.Map	"Bond not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting bond"
+//	@Router			/bond/{id} [delete]
+func (h *BondController) DeleteBondByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	bond, err := h.repo.GetBondByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Bond not found",
+			})
+		}
+		return c.Status(500).JSON(utils.ErrorResponse("Failed to find bond", err))
+	}
+
+	if err := h.repo.DeleteByID(id); err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse("Failed to delete bond", err))
+	}
+
+	return c.Status(200).JSON(utils.SuccessResponse("Bond deleted successfully", ConvertToBondResponse(bond)))
+}
+
+// =================
+
+// SearchBonds godoc
+//
+//	@Summary		Search police bonds with pagination
+//	@Description	Filters police bonds by status, suspect_id, case_id or expires_before (YYYY-MM-DD).
+//	@Tags			Bonds
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Bonds retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve bonds"
+//	@Router			/bonds/search [get]
+func (h *BondController) SearchBonds(c *fiber.Ctx) error {
+	pagination, bonds, err := h.repo.SearchPaginatedBonds(c)
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse("Failed to retrieve bonds", err))
+	}
+
+	responses := make([]BondResponse, len(bonds))
+	for i, b := range bonds {
+		responses[i] = ConvertToBondResponse(b)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Bonds retrieved successfully",
+		"data":    responses,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type CareController struct {
+	repo repository.CareRepository
+}
+
+func NewCareController(repo repository.CareRepository) *CareController {
+	return &CareController{repo: repo}
+}
+
+type CareRecordPayload struct {
+	Status     string     `json:"status" validate:"required"`
+	ProvidedAt *time.Time `json:"provided_at"` // RFC 3339; defaults to now when provided
+	Notes      string     `json:"notes"`
+}
+
+// careChecklist loads the examination named in the route with its care
+// checklist, writing the error response when it cannot
+func (h *CareController) careChecklist(c *fiber.Ctx) (models.CareChecklist, bool, error) {
+	examination, err := h.repo.GetExaminationByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.CareChecklist{}, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Examination not found",
+			})
+		}
+		return models.CareChecklist{}, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve examination", err))
+	}
+
+	incident, err := h.repo.GetCareIncident(examination)
+	if err != nil {
+		return models.CareChecklist{}, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve incident", err))
+	}
+	records, err := h.repo.GetCareRecords(examination.ID)
+	if err != nil {
+		return models.CareChecklist{}, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve care records", err))
+	}
+	return service.BuildCareChecklist(examination, incident, records, time.Now()), true, nil
+}
+
+// ================================
+
+// GetExaminationCare godoc
+//
+//	@Summary		Retrieve an examination's care checklist
+//	@Description	Lists every care service with what was recorded for it. When the case records a sexual assault up to the day of the examination, PEP and emergency contraception are measured against their 72-hour window from it: hours_since_incident is taken when the service was provided, or now while it is open. When only the date of the assault is known the clock runs from the start of that day.
+//	@Tags			Clinical Care
+//	@Produce		json
+//	@Param			id	path		string		true	"Examination ID"
+//	@Success		200	{object}	fiber.Map	"Care checklist retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Examination not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving care checklist"
+//	@Router			/examination/{id}/care [get]
+func (h *CareController) GetExaminationCare(c *fiber.Ctx) error {
+	checklist, ok, err := h.careChecklist(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Care checklist retrieved successfully", checklist))
+}
+
+// ================================
+
+// RecordCare godoc
+//
+//	@Summary		Record a service on an examination's care checklist
+//	@Description	Replaces what was recorded for the service. status is provided, referred, declined or not_indicated; referred services stay open on the at-risk dashboard until provided.
+//	@Tags			Clinical Care
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Examination ID"
+//	@Param			service	path		string				true	"Care service"
+//	@Param			payload	body		CareRecordPayload	true	"What was done"
+//	@Success		200		{object}	fiber.Map			"Care recorded successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Examination not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when recording care"
+//	@Router			/examination/{id}/care/{service} [put]
+func (h *CareController) RecordCare(c *fiber.Ctx) error {
+	careService := c.Params("service")
+	if !slices.Contains(models.CareServices, careService) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "service must be one of " + strings.Join(models.CareServices, ", "),
+		})
+	}
+
+	var payload CareRecordPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if !slices.Contains(models.CareStatuses, payload.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "status must be one of " + strings.Join(models.CareStatuses, ", "),
+		})
+	}
+
+	checklist, ok, err := h.careChecklist(c)
+	if !ok {
+		return err
+	}
+
+	providedAt := payload.ProvidedAt
+	if payload.Status == models.CareStatusProvided && providedAt == nil {
+		now := time.Now()
+		providedAt = &now
+	}
+	if providedAt != nil {
+		if providedAt.After(time.Now()) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "provided_at cannot be in the future",
+			})
+		}
+		// Against the start of the clock, so care given earlier on the day
+		// of an incident recorded without a time is accepted
+		if checklist.IncidentOccurredAt != nil {
+			start := service.CareClockStart(models.Incident{OccurredAt: *checklist.IncidentOccurredAt, TimeKnown: checklist.IncidentTimeKnown})
+			if providedAt.Before(start) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  "error",
+					"message": "provided_at cannot be before the incident",
+				})
+			}
+		}
+	}
+
+	record := models.CareRecord{
+		ExaminationID: checklist.ExaminationID,
+		Service:       careService,
+		Status:        payload.Status,
+		ProvidedAt:    providedAt,
+		Notes:         payload.Notes,
+		RecordedByID:  c.Locals("user").(*utils.Claims).UserID,
+	}
+	if err := h.repo.SaveCareRecord(&record); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record care", err))
+	}
+
+	checklist, ok, err = h.careChecklist(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Care recorded successfully", checklist))
+}
+
+// ================================
+
+// GetCareAtRisk godoc
+//
+//	@Summary		Survivors at risk of missing time-critical care, by facility
+//	@Description	For each health facility, the examinations following a sexual assault where PEP or emergency contraception is still open and its 72-hour window has not yet closed, nearest deadline first. urgent counts those with 24 hours or less remaining. Administrators only, as it lists survivors across facilities.
+//	@Tags			Clinical Care
+//	@Produce		json
+//	@Param			facility_id	query		int			false	"Limit to one health facility"
+//	@Success		200			{object}	fiber.Map	"Care at risk retrieved successfully"
+//	@Failure		403			{object}	fiber.Map	"Not an administrator"
+//	@Failure		500			{object}	fiber.Map	"Server error when retrieving care at risk"
+//	@Router			/health-facilities/care-at-risk [get]
+func (h *CareController) GetCareAtRisk(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "view care at risk across facilities"); !ok {
+		return err
+	}
+
+	var facilityID *uint
+	if id, err := strconv.Atoi(c.Query("facility_id")); err == nil {
+		value := uint(id)
+		facilityID = &value
+	}
+
+	now := time.Now()
+	var pending []models.PendingCare
+	for _, careService := range models.CareServices {
+		if _, ok := service.CareWindows[careService]; !ok {
+			continue
+		}
+		open, err := h.repo.GetPendingCare(careService, service.CareAtRiskSince(careService, now), facilityID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve care at risk", err))
+		}
+		pending = append(pending, open...)
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Care at risk retrieved successfully", service.FacilitiesAtRisk(pending, now)))
+}
@@ -4,7 +4,11 @@ import (
 	"errors"
 	"gbvmis/internals/models"
 	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
 	"gbvmis/internals/utils"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -31,6 +35,37 @@ type CreateCasePayload struct {
 	VictimIDs  []uint `json:"victim_ids"` // For existing victims
 	SuspectIDs []uint `json:"suspect_ids"`
 	ChargeIDs  []uint `json:"charge_ids"`
+
+	// Who offended against whom; each pair must be among victim_ids and suspect_ids
+	Relationships []CaseRelationshipPayload `json:"relationships"`
+}
+
+type CaseRelationshipPayload struct {
+	VictimID         uint   `json:"victim_id" validate:"required"`
+	SuspectID        uint   `json:"suspect_id" validate:"required"`
+	RelationshipType string `json:"relationship_type" validate:"required"`
+	Cohabiting       bool   `json:"cohabiting"`
+	Dependency       string `json:"dependency"`
+	Notes            string `json:"notes"`
+}
+
+type UpdateCaseRelationshipPayload struct {
+	RelationshipType string `json:"relationship_type"`
+	Cohabiting       *bool  `json:"cohabiting"`
+	Dependency       string `json:"dependency"`
+	Notes            string `json:"notes"`
+}
+
+// validateRelationshipValues checks the relationship type and dependency
+// against the accepted lists and returns a message when either is wrong
+func validateRelationshipValues(relationshipType, dependency string) string {
+	if relationshipType != "" && !slices.Contains(models.VictimRelationships, relationshipType) {
+		return "relationship_type must be one of " + strings.Join(models.VictimRelationships, ", ")
+	}
+	if dependency != "" && !slices.Contains(models.VictimDependencies, dependency) {
+		return "dependency must be one of " + strings.Join(models.VictimDependencies, ", ")
+	}
+	return ""
 }
 
 // =======
@@ -50,6 +85,13 @@ type CaseResponse struct {
 	Charges []ChargeResponse `json:"charges"`
 	Victims []VictimResponse `json:"victims"`
 
+	Relationships []CaseRelationshipResponse `json:"relationships"`
+
+	RiskLevel string `json:"risk_level"`
+	Priority  string `json:"priority"`
+
+	Incidents []models.Incident `json:"incidents"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -67,6 +109,23 @@ type VictimResponse struct {
 	LastName    string `json:"last_name"`
 	Gender      string `json:"gender"`
 	PhoneNumber string `json:"phone_number"`
+
+	// Age on the incident date; children are shown redacted
+	AgeAtIncident *int `json:"age_at_incident"`
+	IsMinor       bool `json:"is_minor"`
+	Redacted      bool `json:"redacted,omitempty"`
+}
+
+type CaseRelationshipResponse struct {
+	ID               uint   `json:"id"`
+	VictimID         uint   `json:"victim_id"`
+	VictimName       string `json:"victim_name"`
+	SuspectID        uint   `json:"suspect_id"`
+	SuspectName      string `json:"suspect_name"`
+	RelationshipType string `json:"relationship_type"`
+	Cohabiting       bool   `json:"cohabiting"`
+	Dependency       string `json:"dependency"`
+	Notes            string `json:"notes"`
 }
 
 type SuspectResponse struct {
@@ -87,12 +146,24 @@ func ConvertToCaseResponse(casee models.Case) CaseResponse {
 		})
 	}
 
+	// Redact copies so the caller's case keeps the full records
+	casee.Victims = slices.Clone(casee.Victims)
 	var victims []VictimResponse
-	for _, v := range casee.Victims {
-		victims = append(victims, VictimResponse{
+	for i := range casee.Victims {
+		v := &casee.Victims[i]
+		minor := service.IsMinorInCase(*v, casee)
+		if minor {
+			service.RedactVictim(v)
+		}
+		response := VictimResponse{
 			ID: v.ID, FirstName: v.FirstName, LastName: v.LastName,
 			Gender: v.Gender, PhoneNumber: v.PhoneNumber,
-		})
+			IsMinor: minor, Redacted: v.Redacted,
+		}
+		if age, ok := service.VictimAgeInCase(*v, casee); ok {
+			response.AgeAtIncident = &age
+		}
+		victims = append(victims, response)
 	}
 
 	var suspects []SuspectResponse
@@ -107,6 +178,31 @@ func ConvertToCaseResponse(casee models.Case) CaseResponse {
 		})
 	}
 
+	// Relationships carry only IDs; names come from the case's own parties
+	victimNames := make(map[uint]string, len(casee.Victims))
+	for _, v := range casee.Victims {
+		victimNames[v.ID] = strings.TrimSpace(v.FirstName + " " + v.LastName)
+	}
+	suspectNames := make(map[uint]string, len(casee.Suspects))
+	for _, s := range casee.Suspects {
+		suspectNames[s.ID] = strings.Join(strings.Fields(s.FirstName+" "+s.MiddleName+" "+s.LastName), " ")
+	}
+
+	var relationships []CaseRelationshipResponse
+	for _, r := range casee.Relationships {
+		relationships = append(relationships, CaseRelationshipResponse{
+			ID:               r.ID,
+			VictimID:         r.VictimID,
+			VictimName:       victimNames[r.VictimID],
+			SuspectID:        r.SuspectID,
+			SuspectName:      suspectNames[r.SuspectID],
+			RelationshipType: r.RelationshipType,
+			Cohabiting:       r.Cohabiting,
+			Dependency:       r.Dependency,
+			Notes:            r.Notes,
+		})
+	}
+
 	return CaseResponse{
 		ID:           casee.ID,
 		CaseNumber:   casee.CaseNumber,
@@ -121,6 +217,12 @@ func ConvertToCaseResponse(casee models.Case) CaseResponse {
 		Victims:      victims,
 		CreatedAt:    casee.CreatedAt,
 		UpdatedAt:    casee.UpdatedAt,
+
+		Relationships: relationships,
+		Incidents:     casee.Incidents,
+
+		RiskLevel: casee.RiskLevel,
+		Priority:  casee.Priority,
 	}
 }
 
@@ -129,7 +231,7 @@ func ConvertToCaseResponse(casee models.Case) CaseResponse {
 // CreateCase godoc
 //
 //	@Summary		Create a new case record
-//	@Description	Creates a new case entry in the system and returns the created record.
+//	@Description	Creates a new case entry in the system and returns the created record. relationships records which suspect offended against which victim.
 //	@Tags			Cases
 //	@Accept			json
 //	@Produce		json
@@ -180,6 +282,33 @@ func (h *CaseController) CreateCase(c *fiber.Ctx) error {
 		casee.Suspects = suspects
 	}
 
+	for _, r := range payload.Relationships {
+		if errs := utils.ValidateStruct(r); errs != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Validation failed",
+				"errors":  errs,
+			})
+		}
+		if msg := validateRelationshipValues(r.RelationshipType, r.Dependency); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+		}
+		if !slices.Contains(payload.VictimIDs, r.VictimID) || !slices.Contains(payload.SuspectIDs, r.SuspectID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Relationship victim and suspect must be among victim_ids and suspect_ids",
+			})
+		}
+		casee.Relationships = append(casee.Relationships, models.CaseRelationship{
+			VictimID:         r.VictimID,
+			SuspectID:        r.SuspectID,
+			RelationshipType: r.RelationshipType,
+			Cohabiting:       r.Cohabiting,
+			Dependency:       r.Dependency,
+			Notes:            r.Notes,
+		})
+	}
+
 	// Save case with associations
 	if err := h.repo.CreateCase(casee); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create case", err))
@@ -305,7 +434,7 @@ func (p UpdateCasePayload) IsEmpty() bool {
 // UpdateCase godoc
 //
 //	@Summary		Update an existing case record by ID
-//	@Description	Updates the details of a case record based on the provided ID and request body.
+//	@Description	Updates the details of a case record based on the provided ID and request body. Replacing victims or suspects drops relationships involving parties no longer on the case.
 //	@Tags			Cases
 //	@Accept			json
 //	@Produce		json
@@ -458,6 +587,18 @@ func (h *CaseController) UpdateCase(c *fiber.Ctx) error {
 		}
 	}
 
+	// Relationships only make sense between parties still on the case
+	if len(payload.VictimIDs) > 0 || len(payload.SuspectIDs) > 0 {
+		if err := h.repo.PruneCaseRelationships(tx, caseRecord.ID); err != nil {
+			tx.Rollback()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to update case relationships",
+				"data":    err.Error(),
+			})
+		}
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -529,12 +670,17 @@ func (h *CaseController) DeleteCaseByID(c *fiber.Ctx) error {
 // SearchCases godoc
 //
 //	@Summary		Search for Cases with pagination
-//	@Description	Retrieves a paginated list of cases based on search criteria.
+//	@Description	Retrieves a paginated list of cases based on search criteria. The incident filters match cases with at least one such incident.
 //	@Tags			Cases
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	fiber.Map	"Cases retrieved successfully"
-//	@Failure		500	{object}	fiber.Map	"Failed to retrieve Cases"
+//	@Param			violence_type	query		string		false	"Cases with an incident of this violence type"
+//	@Param			setting			query		string		false	"Cases with an incident in this setting"
+//	@Param			district		query		string		false	"Cases with an incident in this district"
+//	@Param			occurred_from	query		string		false	"Cases with an incident on or after (YYYY-MM-DD)"
+//	@Param			occurred_to		query		string		false	"Cases with an incident on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Cases retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve Cases"
 //	@Router			/cases/search [get]
 func (h *CaseController) SearchCases(c *fiber.Ctx) error {
 	// Call the repository function to get paginated search results
@@ -564,3 +710,206 @@ func (h *CaseController) SearchCases(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// =================
+
+// AddCaseRelationship godoc
+//
+//	@Summary		Record how a suspect is related to a victim in a case
+//	@Description	The victim and suspect must both be linked to the case. Each pair can be recorded once per case.
+//	@Tags			Cases
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		string					true	"Case ID"
+//	@Param			relationship	body		CaseRelationshipPayload	true	"Relationship"
+//	@Success		201				{object}	fiber.Map				"Relationship recorded successfully"
+//	@Failure		400				{object}	fiber.Map				"Invalid input or parties not on the case"
+//	@Failure		404				{object}	fiber.Map				"Case not found"
+//	@Failure		409				{object}	fiber.Map				"Relationship already recorded"
+//	@Failure		500				{object}	fiber.Map				"Server error when recording relationship"
+//	@Router			/case/{id}/relationships [post]
+func (h *CaseController) AddCaseRelationship(c *fiber.Ctx) error {
+	var payload CaseRelationshipPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if msg := validateRelationshipValues(payload.RelationshipType, payload.Dependency); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	casee, err := h.repo.GetCaseByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+
+	linked, err := h.repo.CaseHasParties(casee.ID, payload.VictimID, payload.SuspectID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to check case parties", err))
+	}
+	if !linked {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "The victim and the suspect must both be linked to this case",
+		})
+	}
+	for _, r := range casee.Relationships {
+		if r.VictimID == payload.VictimID && r.SuspectID == payload.SuspectID {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Relationship already recorded for this victim and suspect",
+				"data":    r,
+			})
+		}
+	}
+
+	relationship := models.CaseRelationship{
+		CaseID:           casee.ID,
+		VictimID:         payload.VictimID,
+		SuspectID:        payload.SuspectID,
+		RelationshipType: payload.RelationshipType,
+		Cohabiting:       payload.Cohabiting,
+		Dependency:       payload.Dependency,
+		Notes:            payload.Notes,
+	}
+	if err := h.repo.CreateCaseRelationship(&relationship); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record relationship", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Relationship recorded successfully", relationship))
+}
+
+// UpdateCaseRelationship godoc
+//
+//	@Summary		Update a victim-suspect relationship
+//	@Tags			Cases
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		string							true	"Relationship ID"
+//	@Param			relationship	body		UpdateCaseRelationshipPayload	true	"Fields to update"
+//	@Success		200				{object}	fiber.Map						"Relationship updated successfully"
+//	@Failure		400				{object}	fiber.Map						"Invalid input"
+//	@Failure		404				{object}	fiber.Map						"Relationship not found"
+//	@Failure		500				{object}	fiber.Map						"Server error when updating relationship"
+//	@Router			/case-relationship/{id} [put]
+func (h *CaseController) UpdateCaseRelationship(c *fiber.Ctx) error {
+	relationship, err := h.repo.GetCaseRelationshipByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Relationship not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve relationship", err))
+	}
+
+	var payload UpdateCaseRelationshipPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if msg := validateRelationshipValues(payload.RelationshipType, payload.Dependency); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	updates := map[string]interface{}{}
+	if payload.RelationshipType != "" {
+		updates["relationship_type"] = payload.RelationshipType
+	}
+	if payload.Cohabiting != nil {
+		updates["cohabiting"] = *payload.Cohabiting
+	}
+	if payload.Dependency != "" {
+		updates["dependency"] = payload.Dependency
+	}
+	if payload.Notes != "" {
+		updates["notes"] = payload.Notes
+	}
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Empty request body",
+		})
+	}
+
+	if err := h.repo.UpdateCaseRelationship(relationship.ID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update relationship", err))
+	}
+
+	relationship, err = h.repo.GetCaseRelationshipByID(strconv.Itoa(int(relationship.ID)))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve relationship", err))
+	}
+	casee, err := h.repo.GetCaseByID(strconv.Itoa(int(relationship.CaseID)))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve relationship", err))
+	}
+	if service.IsMinorInAnyCase(relationship.Victim, []models.Case{casee}) {
+		service.RedactVictim(&relationship.Victim)
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Relationship updated successfully", relationship))
+}
+
+// DeleteCaseRelationship godoc
+//
+//	@Summary		Remove a victim-suspect relationship from a case
+//	@Tags			Cases
+//	@Produce		json
+//	@Param			id	path		string		true	"Relationship ID"
+//	@Success		200	{object}	fiber.Map	"Relationship deleted successfully"
+//	@Failure		404	{object}	fiber.Map	"Relationship not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting relationship"
+//	@Router			/case-relationship/{id} [delete]
+func (h *CaseController) DeleteCaseRelationship(c *fiber.Ctx) error {
+	relationship, err := h.repo.GetCaseRelationshipByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Relationship not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve relationship", err))
+	}
+
+	if err := h.repo.DeleteCaseRelationship(relationship.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete relationship", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Relationship deleted successfully", nil))
+}
+
+// GetRelationshipStats godoc
+//
+//	@Summary		Count victim-suspect pairs by relationship type
+//	@Description	Counts recorded pairs per relationship type, with how many live together and how many victims depend on the suspect.
+//	@Tags			Cases
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Relationship statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/cases/relationship-stats [get]
+func (h *CaseController) GetRelationshipStats(c *fiber.Ctx) error {
+	stats, err := h.repo.GetRelationshipStats(c)
+	if errors.Is(err, models.ErrStatsDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid date filter; use YYYY-MM-DD", err))
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to compute relationship statistics", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Relationship statistics retrieved successfully", stats))
+}
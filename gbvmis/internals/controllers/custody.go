@@ -0,0 +1,562 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type CustodyController struct {
+	repo repository.CustodyRepository
+}
+
+func NewCustodyController(repo repository.CustodyRepository) *CustodyController {
+	return &CustodyController{repo: repo}
+}
+
+type CreateCustodyPayload struct {
+	ArrestID       uint      `json:"arrest_id" validate:"required"`
+	PolicePostID   uint      `json:"police_post_id"` // Defaults to the arresting officer's post
+	DetentionStart time.Time `json:"detention_start"`
+	CellNumber     string    `json:"cell_number"`
+	PropertyTaken  []string  `json:"property_taken"`
+}
+
+type CustodyResponse struct {
+	ID                uint       `json:"id"`
+	ArrestID          uint       `json:"arrest_id"`
+	SuspectID         uint       `json:"suspect_id"`
+	SuspectName       string     `json:"suspect_name"`
+	CaseID            uint       `json:"case_id"`
+	CaseNumber        string     `json:"case_number"`
+	PolicePostID      uint       `json:"police_post_id"`
+	PolicePostName    string     `json:"police_post_name"`
+	BookedByID        uint       `json:"booked_by_id"`
+	DetentionStart    time.Time  `json:"detention_start"`
+	CellNumber        string     `json:"cell_number"`
+	PropertyTaken     []string   `json:"property_taken"`
+	PropertyReturned  bool       `json:"property_returned"`
+	Status            string     `json:"status"`
+	ProducedInCourtAt *time.Time `json:"produced_in_court_at"`
+	CourtName         string     `json:"court_name"`
+	ReleasedAt        *time.Time `json:"released_at"`
+	ReleaseReason     string     `json:"release_reason"`
+	RemandPrison      string     `json:"remand_prison"`
+	CourtDeadline     time.Time  `json:"court_deadline"`
+	HoursInCustody    float64    `json:"hours_in_custody"`
+	Warning           string     `json:"warning,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+func ConvertToCustodyResponse(rec models.CustodyRecord) CustodyResponse {
+	now := time.Now()
+	suspectName := ""
+	if rec.Suspect.ID != 0 {
+		suspectName = rec.Suspect.FirstName + " " + rec.Suspect.LastName
+	}
+
+	return CustodyResponse{
+		ID:                rec.ID,
+		ArrestID:          rec.ArrestID,
+		SuspectID:         rec.SuspectID,
+		SuspectName:       suspectName,
+		CaseID:            rec.CaseID,
+		CaseNumber:        rec.Case.CaseNumber,
+		PolicePostID:      rec.PolicePostID,
+		PolicePostName:    rec.PolicePost.Name,
+		BookedByID:        rec.BookedByID,
+		DetentionStart:    rec.DetentionStart,
+		CellNumber:        rec.CellNumber,
+		PropertyTaken:     rec.PropertyTaken,
+		PropertyReturned:  rec.PropertyReturned,
+		Status:            rec.Status,
+		ProducedInCourtAt: rec.ProducedInCourtAt,
+		CourtName:         rec.CourtName,
+		ReleasedAt:        rec.ReleasedAt,
+		ReleaseReason:     rec.ReleaseReason,
+		RemandPrison:      rec.RemandPrison,
+		CourtDeadline:     service.CustodyDeadline(rec),
+		HoursInCustody:    service.HoursInCustody(rec, now),
+		Warning:           service.CustodyWarning(rec, now),
+		CreatedAt:         rec.CreatedAt,
+	}
+}
+
+// ================================
+
+// CreateCustodyRecord godoc
+//
+//	@Summary		Book an arrested suspect into custody
+//	@Description	Opens a detention register entry for an arrest, recording cell and property taken. The 48-hour clock starts at detention_start.
+//	@Tags			Custody
+//	@Accept			json
+//	@Produce		json
+//	@Param			custody	body		CreateCustodyPayload	true	"Custody data to create"
+//	@Success		201		{object}	fiber.Map				"Successfully created custody record"
+//	@Failure		400		{object}	fiber.Map				"Bad request due to invalid input"
+//	@Failure		409		{object}	fiber.Map				"Suspect already in custody for this arrest"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating custody record"
+//	@Router			/custody-record [post]
+func (h *CustodyController) CreateCustodyRecord(c *fiber.Ctx) error {
+	var payload CreateCustodyPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"data":    errs,
+		})
+	}
+
+	arrest, err := h.repo.FindArrestByID(payload.ArrestID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid arrest ID", err))
+	}
+
+	active, err := h.repo.CountActiveForArrest(arrest.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to check custody status", err))
+	}
+	if active > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Suspect is already in custody for this arrest",
+		})
+	}
+
+	postID := payload.PolicePostID
+	if postID == 0 {
+		postID = arrest.Officer.PostID
+	}
+
+	detentionStart := payload.DetentionStart
+	if detentionStart.IsZero() {
+		detentionStart = time.Now()
+	}
+
+	user := c.Locals("user").(*utils.Claims)
+
+	record := &models.CustodyRecord{
+		ArrestID:       arrest.ID,
+		SuspectID:      arrest.SuspectID,
+		CaseID:         arrest.CaseID,
+		PolicePostID:   postID,
+		BookedByID:     user.UserID,
+		DetentionStart: detentionStart,
+		CellNumber:     payload.CellNumber,
+		PropertyTaken:  payload.PropertyTaken,
+		Status:         models.CustodyStatusInCustody,
+	}
+
+	if err := h.repo.CreateCustodyRecord(record); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create custody record", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Custody record created successfully", ConvertToCustodyResponse(*record)))
+}
+
+// ===========
+
+// GetAllCustodyRecords godoc
+//
+//	@Summary		Retrieve a paginated list of custody records
+//	@Description	Fetches the detention register with pagination support, newest first.
+//	@Tags			Custody
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Custody records retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve custody records"
+//	@Router			/custody-records [get]
+func (h *CustodyController) GetAllCustodyRecords(c *fiber.Ctx) error {
+	pagination, records, err := h.repo.GetPaginatedCustodyRecords(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve custody records", err))
+	}
+
+	responses := make([]CustodyResponse, len(records))
+	for i, rec := range records {
+		responses[i] = ConvertToCustodyResponse(rec)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Custody records retrieved successfully",
+		"data":    responses,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// =========
+
+// GetCustodyAlerts godoc
+//
+//	@Summary		List detentions approaching or past the 48-hour limit
+//	@Description	Returns suspects still in custody and not yet produced in court whose detention is within the warning window of, or beyond, 48 hours.
+//	@Tags			Custody
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Custody alerts retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve custody alerts"
+//	@Router			/custody-records/alerts [get]
+func (h *CustodyController) GetCustodyAlerts(c *fiber.Ctx) error {
+	since := time.Now().Add(-(service.CustodyLimit - service.CustodyWarningWindow))
+	records, err := h.repo.FindUnproducedDetainedSince(since)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve custody alerts", err))
+	}
+
+	responses := make([]CustodyResponse, len(records))
+	for i, rec := range records {
+		responses[i] = ConvertToCustodyResponse(rec)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Custody alerts retrieved successfully", responses))
+}
+
+// =========
+
+// GetSingleCustodyRecord godoc
+//
+//	@Summary		Retrieve a single custody record by ID
+//	@Description	Fetches a custody record, including the time remaining before the 48-hour limit.
+//	@Tags			Custody
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Custody record ID"
+//	@Success		200	{object}	fiber.Map	"Custody record retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Custody record not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving custody record"
+//	@Router			/custody-record/{id} [get]
+func (h *CustodyController) GetSingleCustodyRecord(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	record, err := h.repo.GetCustodyRecordByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Custody record not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve custody record", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Custody record retrieved successfully", ConvertToCustodyResponse(record)))
+}
+
+// =======================
+
+type UpdateCustodyPayload struct {
+	CellNumber       string   `json:"cell_number"`
+	PropertyTaken    []string `json:"property_taken"`
+	PropertyReturned *bool    `json:"property_returned"`
+}
+
+// UpdateCustodyRecord godoc
+//
+//	@Summary		Update cell or property details of a custody record
+//	@Description	Updates the cell number and property list of a custody record.
+//	@Tags			Custody
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Custody record ID"
+//	@Param			custody	body		UpdateCustodyPayload	true	"Custody data to update"
+//	@Success		200		{object}	fiber.Map				"Custody record updated successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input or empty request body"
+//	@Failure		404		{object}	fiber.Map				"Custody record not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when updating custody record"
+//	@Router			/custody-record/{id} [put]
+func (h *CustodyController) UpdateCustodyRecord(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.repo.GetCustodyRecordByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Custody record not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve custody record", err))
+	}
+
+	var payload UpdateCustodyPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	updates := make(map[string]interface{})
+	if payload.CellNumber != "" {
+		updates["cell_number"] = payload.CellNumber
+	}
+	if payload.PropertyTaken != nil {
+		updates["property_taken"] = datatypes.JSONSlice[string](payload.PropertyTaken)
+	}
+	if payload.PropertyReturned != nil {
+		updates["property_returned"] = *payload.PropertyReturned
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Empty request body",
+		})
+	}
+
+	if err := h.repo.UpdateCustodyRecord(id, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update custody record", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Custody record updated successfully", updates))
+}
+
+// =======================
+
+type ProduceInCourtPayload struct {
+	CourtName  string    `json:"court_name" validate:"required"`
+	ProducedAt time.Time `json:"produced_at"`
+}
+
+// ProduceInCourt godoc
+//
+//	@Summary		Record production of a detained suspect in court
+//	@Description	Stops the 48-hour clock for a custody record. The suspect remains in custody until released, bonded or remanded.
+//	@Tags			Custody
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Custody record ID"
+//	@Param			court	body		ProduceInCourtPayload	true	"Court production details"
+//	@Success		200		{object}	fiber.Map				"Production in court recorded"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Custody record not found"
+//	@Failure		409		{object}	fiber.Map				"Custody record is closed"
+//	@Failure		500		{object}	fiber.Map				"Server error when updating custody record"
+//	@Router			/custody-record/{id}/court [post]
+func (h *CustodyController) ProduceInCourt(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	record, err := h.repo.GetCustodyRecordByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Custody record not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve custody record", err))
+	}
+
+	if record.Status != models.CustodyStatusInCustody {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Custody record is already closed",
+		})
+	}
+
+	var payload ProduceInCourtPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"data":    errs,
+		})
+	}
+
+	producedAt := payload.ProducedAt
+	if producedAt.IsZero() {
+		producedAt = time.Now()
+	}
+
+	if err := h.repo.UpdateCustodyRecord(id, map[string]interface{}{
+		"produced_in_court_at": producedAt,
+		"court_name":           payload.CourtName,
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update custody record", err))
+	}
+
+	record.ProducedInCourtAt = &producedAt
+	record.CourtName = payload.CourtName
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Production in court recorded", ConvertToCustodyResponse(record)))
+}
+
+// =======================
+
+type ReleaseCustodyPayload struct {
+	Outcome          string    `json:"outcome" validate:"required,oneof=released remanded"`
+	ReleasedAt       time.Time `json:"released_at"`
+	Reason           string    `json:"reason"`
+	RemandPrison     string    `json:"remand_prison"`
+	PropertyReturned bool      `json:"property_returned"`
+}
+
+// ReleaseFromCustody godoc
+//
+//	@Summary		Close a custody record by release or remand
+//	@Description	Records how a suspect left police custody. Remand requires the prison name. Release on police bond is recorded with POST /bond and custody_record_id, which closes the custody record with the bond.
+//	@Tags			Custody
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Custody record ID"
+//	@Param			release	body		ReleaseCustodyPayload	true	"Release details"
+//	@Success		200		{object}	fiber.Map				"Custody record closed"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Custody record not found"
+//	@Failure		409		{object}	fiber.Map				"Custody record is closed"
+//	@Failure		500		{object}	fiber.Map				"Server error when updating custody record"
+//	@Router			/custody-record/{id}/release [post]
+func (h *CustodyController) ReleaseFromCustody(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	record, err := h.repo.GetCustodyRecordByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Custody record not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve custody record", err))
+	}
+
+	if record.Status != models.CustodyStatusInCustody {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Custody record is already closed",
+		})
+	}
+
+	var payload ReleaseCustodyPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if payload.Outcome == models.CustodyStatusBond {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Record the bond with POST /bond and custody_record_id; it closes the custody record",
+		})
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"data":    errs,
+		})
+	}
+	if payload.Outcome == models.CustodyStatusRemanded && payload.RemandPrison == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "remand_prison is required when remanding a suspect",
+		})
+	}
+
+	releasedAt := payload.ReleasedAt
+	if releasedAt.IsZero() {
+		releasedAt = time.Now()
+	}
+
+	updates := map[string]interface{}{
+		"status":            payload.Outcome,
+		"released_at":       releasedAt,
+		"release_reason":    payload.Reason,
+		"remand_prison":     payload.RemandPrison,
+		"property_returned": payload.PropertyReturned,
+	}
+	if err := h.repo.UpdateCustodyRecord(id, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update custody record", err))
+	}
+
+	record.Status = payload.Outcome
+	record.ReleasedAt = &releasedAt
+	record.ReleaseReason = payload.Reason
+	record.RemandPrison = payload.RemandPrison
+	record.PropertyReturned = payload.PropertyReturned
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Custody record closed", ConvertToCustodyResponse(record)))
+}
+
+// ==================
+
+// DeleteCustodyRecordByID godoc
+//
+//	@Summary		Delete a custody record by ID
+//	@Description	Deletes a custody record based on the provided ID.
+//	@Tags			Custody
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Custody record ID"
+//	@Success		200	{object}	fiber.Map	"Custody record deleted successfully"
+//	@Failure		404	{object}	fiber.Map	"Custody record not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting custody record"
+//	@Router			/custody-record/{id} [delete]
+func (h *CustodyController) DeleteCustodyRecordByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	record, err := h.repo.GetCustodyRecordByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Custody record not found",
+			})
+		}
+		return c.Status(500).JSON(utils.ErrorResponse("Failed to find custody record", err))
+	}
+
+	if err := h.repo.DeleteByID(id); err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse("Failed to delete custody record", err))
+	}
+
+	return c.Status(200).JSON(utils.SuccessResponse("Custody record deleted successfully", ConvertToCustodyResponse(record)))
+}
+
+// =================
+
+// SearchCustodyRecords godoc
+//
+//	@Summary		Search the custody register with pagination
+//	@Description	Filters custody records by status, cell_number, police_post_id, suspect_id or case_id.
+//	@Tags			Custody
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Custody records retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve custody records"
+//	@Router			/custody-records/search [get]
+func (h *CustodyController) SearchCustodyRecords(c *fiber.Ctx) error {
+	pagination, records, err := h.repo.SearchPaginatedCustodyRecords(c)
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse("Failed to retrieve custody records", err))
+	}
+
+	responses := make([]CustodyResponse, len(records))
+	for i, rec := range records {
+		responses[i] = ConvertToCustodyResponse(rec)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Custody records retrieved successfully",
+		"data":    responses,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
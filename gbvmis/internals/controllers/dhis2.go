@@ -0,0 +1,476 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type Dhis2Controller struct {
+	repo repository.Dhis2Repository
+}
+
+func NewDhis2Controller(repo repository.Dhis2Repository) *Dhis2Controller {
+	return &Dhis2Controller{repo: repo}
+}
+
+type Dhis2DataElementPayload struct {
+	Indicator           string `json:"indicator" validate:"required"`    // see models.Dhis2Indicators
+	DataElement         string `json:"data_element" validate:"required"` // DHIS2 UID
+	CategoryOptionCombo string `json:"category_option_combo"`            // DHIS2 UID; default combination when empty
+	Description         string `json:"description"`
+}
+
+type UpdateDhis2DataElementPayload struct {
+	Indicator           string  `json:"indicator"`
+	DataElement         string  `json:"data_element"`
+	CategoryOptionCombo *string `json:"category_option_combo"`
+	Description         string  `json:"description"`
+}
+
+type Dhis2OrgUnitPayload struct {
+	OrgUnit string `json:"org_unit" validate:"required"` // DHIS2 UID
+}
+
+type Dhis2PushPayload struct {
+	Period string `json:"period" validate:"required"` // YYYYMM or YYYYQn
+}
+
+// validateDataElementMapping returns a message when the indicator or a UID
+// is not valid
+func validateDataElementMapping(mapping models.Dhis2DataElementMapping) string {
+	if !slices.Contains(models.Dhis2Indicators, mapping.Indicator) {
+		return "indicator must be one of " + strings.Join(models.Dhis2Indicators, ", ")
+	}
+	if !service.IsDhis2UID(mapping.DataElement) {
+		return "data_element must be a DHIS2 UID"
+	}
+	if mapping.CategoryOptionCombo != "" && !service.IsDhis2UID(mapping.CategoryOptionCombo) {
+		return "category_option_combo must be a DHIS2 UID"
+	}
+	return ""
+}
+
+// dataElementMappingFromParam loads the mapping named by the :id parameter,
+// writing a 404 or 500 when it cannot
+func (h *Dhis2Controller) dataElementMappingFromParam(c *fiber.Ctx) (models.Dhis2DataElementMapping, bool, error) {
+	mapping, err := h.repo.GetDataElementMappingByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return mapping, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Data element mapping not found",
+			})
+		}
+		return mapping, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve data element mapping", err))
+	}
+	return mapping, true, nil
+}
+
+// ================================
+
+// GetDhis2Indicators godoc
+//
+//	@Summary		List the indicators that can be sent to DHIS2
+//	@Description	Every indicator counts examinations done in the period at a facility.
+//	@Tags			DHIS2
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Indicators retrieved successfully"
+//	@Router			/dhis2/indicators [get]
+func (h *Dhis2Controller) GetDhis2Indicators(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Indicators retrieved successfully", models.Dhis2Indicators))
+}
+
+// ================================
+
+// CreateDataElementMapping godoc
+//
+//	@Summary		Map an indicator to a DHIS2 data element
+//	@Description	An indicator may feed several data elements or category option combinations. Administrators only.
+//	@Tags			DHIS2
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		Dhis2DataElementPayload	true	"Mapping"
+//	@Success		201		{object}	fiber.Map				"Data element mapping created successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		403		{object}	fiber.Map				"Not an administrator"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating the mapping"
+//	@Router			/dhis2/data-elements [post]
+func (h *Dhis2Controller) CreateDataElementMapping(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "configure DHIS2 mappings"); !ok {
+		return err
+	}
+
+	var payload Dhis2DataElementPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	mapping := models.Dhis2DataElementMapping{
+		Indicator:           payload.Indicator,
+		DataElement:         payload.DataElement,
+		CategoryOptionCombo: payload.CategoryOptionCombo,
+		Description:         payload.Description,
+	}
+	if msg := validateDataElementMapping(mapping); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	if err := h.repo.CreateDataElementMapping(&mapping); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create data element mapping", err))
+	}
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Data element mapping created successfully", mapping))
+}
+
+// ================================
+
+// GetDataElementMappings godoc
+//
+//	@Summary		List DHIS2 data element mappings
+//	@Tags			DHIS2
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Data element mappings retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve data element mappings"
+//	@Router			/dhis2/data-elements [get]
+func (h *Dhis2Controller) GetDataElementMappings(c *fiber.Ctx) error {
+	mappings, err := h.repo.GetDataElementMappings()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve data element mappings", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Data element mappings retrieved successfully", mappings))
+}
+
+// ================================
+
+// UpdateDataElementMapping godoc
+//
+//	@Summary		Update a DHIS2 data element mapping
+//	@Description	Send an empty category_option_combo to use the default combination. Administrators only.
+//	@Tags			DHIS2
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Mapping ID"
+//	@Param			payload	body		UpdateDhis2DataElementPayload	true	"Fields to change"
+//	@Success		200		{object}	fiber.Map						"Data element mapping updated successfully"
+//	@Failure		400		{object}	fiber.Map						"Invalid input"
+//	@Failure		403		{object}	fiber.Map						"Not an administrator"
+//	@Failure		404		{object}	fiber.Map						"Data element mapping not found"
+//	@Failure		500		{object}	fiber.Map						"Server error when updating the mapping"
+//	@Router			/dhis2/data-element/{id} [put]
+func (h *Dhis2Controller) UpdateDataElementMapping(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "configure DHIS2 mappings"); !ok {
+		return err
+	}
+	mapping, ok, err := h.dataElementMappingFromParam(c)
+	if !ok {
+		return err
+	}
+
+	var payload UpdateDhis2DataElementPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	updates := map[string]interface{}{}
+	if payload.Indicator != "" {
+		mapping.Indicator = payload.Indicator
+		updates["indicator"] = payload.Indicator
+	}
+	if payload.DataElement != "" {
+		mapping.DataElement = payload.DataElement
+		updates["data_element"] = payload.DataElement
+	}
+	if payload.CategoryOptionCombo != nil {
+		mapping.CategoryOptionCombo = *payload.CategoryOptionCombo
+		updates["category_option_combo"] = *payload.CategoryOptionCombo
+	}
+	if payload.Description != "" {
+		mapping.Description = payload.Description
+		updates["description"] = payload.Description
+	}
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Empty request body",
+		})
+	}
+	if msg := validateDataElementMapping(mapping); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	if err := h.repo.UpdateDataElementMapping(mapping.ID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update data element mapping", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Data element mapping updated successfully", mapping))
+}
+
+// ================================
+
+// DeleteDataElementMapping godoc
+//
+//	@Summary		Delete a DHIS2 data element mapping
+//	@Description	Administrators only.
+//	@Tags			DHIS2
+//	@Produce		json
+//	@Param			id	path		string		true	"Mapping ID"
+//	@Success		200	{object}	fiber.Map	"Data element mapping deleted successfully"
+//	@Failure		403	{object}	fiber.Map	"Not an administrator"
+//	@Failure		404	{object}	fiber.Map	"Data element mapping not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting the mapping"
+//	@Router			/dhis2/data-element/{id} [delete]
+func (h *Dhis2Controller) DeleteDataElementMapping(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "configure DHIS2 mappings"); !ok {
+		return err
+	}
+	mapping, ok, err := h.dataElementMappingFromParam(c)
+	if !ok {
+		return err
+	}
+	if err := h.repo.DeleteDataElementMapping(mapping.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete data element mapping", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Data element mapping deleted successfully", nil))
+}
+
+// ================================
+
+// GetOrgUnitMappings godoc
+//
+//	@Summary		List the DHIS2 organisation units of health facilities
+//	@Tags			DHIS2
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Organisation unit mappings retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve organisation unit mappings"
+//	@Router			/dhis2/org-units [get]
+func (h *Dhis2Controller) GetOrgUnitMappings(c *fiber.Ctx) error {
+	mappings, err := h.repo.GetOrgUnitMappings()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve organisation unit mappings", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Organisation unit mappings retrieved successfully", mappings))
+}
+
+// ================================
+
+// SetOrgUnitMapping godoc
+//
+//	@Summary		Set a health facility's DHIS2 organisation unit
+//	@Description	Replaces any earlier organisation unit of the facility. Several facilities may share a unit; their counts are added together in exports. Administrators only.
+//	@Tags			DHIS2
+//	@Accept			json
+//	@Produce		json
+//	@Param			facility_id	path		int					true	"Health facility ID"
+//	@Param			payload		body		Dhis2OrgUnitPayload	true	"Organisation unit"
+//	@Success		200			{object}	fiber.Map			"Organisation unit mapping saved successfully"
+//	@Failure		400			{object}	fiber.Map			"Invalid input"
+//	@Failure		403			{object}	fiber.Map			"Not an administrator"
+//	@Failure		404			{object}	fiber.Map			"Health facility not found"
+//	@Failure		500			{object}	fiber.Map			"Server error when saving the mapping"
+//	@Router			/dhis2/org-unit/{facility_id} [put]
+func (h *Dhis2Controller) SetOrgUnitMapping(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "configure DHIS2 mappings"); !ok {
+		return err
+	}
+	facilityID, err := strconv.Atoi(c.Params("facility_id"))
+	if err != nil || facilityID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid facility_id",
+		})
+	}
+
+	var payload Dhis2OrgUnitPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if !service.IsDhis2UID(payload.OrgUnit) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "org_unit must be a DHIS2 UID",
+		})
+	}
+
+	facility, err := h.repo.FindHealthFacilityByID(uint(facilityID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Health facility not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve health facility", err))
+	}
+
+	mapping := models.Dhis2OrgUnitMapping{FacilityID: facility.ID, OrgUnit: payload.OrgUnit}
+	if err := h.repo.SetOrgUnitMapping(&mapping); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to save organisation unit mapping", err))
+	}
+	mapping.Facility = facility
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Organisation unit mapping saved successfully", mapping))
+}
+
+// ================================
+
+// DeleteOrgUnitMapping godoc
+//
+//	@Summary		Remove a health facility's DHIS2 organisation unit
+//	@Description	The facility is left out of later exports. Administrators only.
+//	@Tags			DHIS2
+//	@Produce		json
+//	@Param			facility_id	path		int			true	"Health facility ID"
+//	@Success		200			{object}	fiber.Map	"Organisation unit mapping deleted successfully"
+//	@Failure		403			{object}	fiber.Map	"Not an administrator"
+//	@Failure		404			{object}	fiber.Map	"Organisation unit mapping not found"
+//	@Failure		500			{object}	fiber.Map	"Server error when deleting the mapping"
+//	@Router			/dhis2/org-unit/{facility_id} [delete]
+func (h *Dhis2Controller) DeleteOrgUnitMapping(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "configure DHIS2 mappings"); !ok {
+		return err
+	}
+	deleted, err := h.repo.DeleteOrgUnitMapping(c.Params("facility_id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete organisation unit mapping", err))
+	}
+	if deleted == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Organisation unit mapping not found",
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Organisation unit mapping deleted successfully", nil))
+}
+
+// ================================
+
+// GetDataValueSet godoc
+//
+//	@Summary		Build the DHIS2 data value set for a period
+//	@Description	Returns dataValueSets JSON ready for import into DHIS2: every mapped indicator for every mapped organisation unit, summed over the facilities sharing it, zeros included so re-imports overwrite stale values. unmapped_facilities lists facilities with examinations in the period but no organisation unit.
+//	@Tags			DHIS2
+//	@Produce		json
+//	@Param			period	query		string		true	"DHIS2 period, YYYYMM or YYYYQn"
+//	@Success		200		{object}	fiber.Map	"Data value set built successfully"
+//	@Failure		400		{object}	fiber.Map	"Invalid period"
+//	@Failure		500		{object}	fiber.Map	"Server error when building the data value set"
+//	@Router			/dhis2/data-value-sets [get]
+func (h *Dhis2Controller) GetDataValueSet(c *fiber.Ctx) error {
+	if _, _, err := service.ParseDhis2Period(c.Query("period")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid period", err))
+	}
+
+	set, unmapped, err := service.BuildDataValueSet(h.repo, c.Query("period"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to build data value set", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":              "success",
+		"message":             "Data value set built successfully",
+		"data":                set,
+		"unmapped_facilities": unmapped,
+	})
+}
+
+// ================================
+
+// PushDataValueSet godoc
+//
+//	@Summary		Push a period's values to DHIS2
+//	@Description	Builds the data value set for the period and posts it to the DHIS2 server in DHIS2_URL. The push and DHIS2's import summary are recorded whatever the outcome. Administrators only.
+//	@Tags			DHIS2
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		Dhis2PushPayload	true	"Period"
+//	@Success		201		{object}	fiber.Map			"Push recorded"
+//	@Failure		400		{object}	fiber.Map			"Invalid period or nothing mapped"
+//	@Failure		403		{object}	fiber.Map			"Not an administrator"
+//	@Failure		500		{object}	fiber.Map			"Server error when pushing"
+//	@Failure		503		{object}	fiber.Map			"DHIS2 is not configured"
+//	@Router			/dhis2/push [post]
+func (h *Dhis2Controller) PushDataValueSet(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "push to DHIS2"); !ok {
+		return err
+	}
+
+	var payload Dhis2PushPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if _, _, err := service.ParseDhis2Period(payload.Period); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid period", err))
+	}
+
+	client, err := service.NewDhis2ClientFromEnv()
+	if err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(utils.ErrorResponse("Cannot push to DHIS2", err))
+	}
+	userID := c.Locals("user").(*utils.Claims).UserID
+	push, err := service.PushDhis2Period(c.Context(), h.repo, client, payload.Period, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrNothingToPush) {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Cannot push to DHIS2", err))
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to push to DHIS2", err))
+	}
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Push recorded", push))
+}
+
+// ================================
+
+// GetDhis2Pushes godoc
+//
+//	@Summary		List pushes to DHIS2
+//	@Tags			DHIS2
+//	@Produce		json
+//	@Param			period	query		string		false	"Only pushes of this period"
+//	@Param			page	query		int			false	"Page number"
+//	@Param			limit	query		int			false	"Items per page"
+//	@Success		200		{object}	fiber.Map	"DHIS2 pushes retrieved successfully"
+//	@Failure		500		{object}	fiber.Map	"Failed to retrieve DHIS2 pushes"
+//	@Router			/dhis2/pushes [get]
+func (h *Dhis2Controller) GetDhis2Pushes(c *fiber.Ctx) error {
+	pagination, pushes, err := h.repo.GetPaginatedDhis2Pushes(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve DHIS2 pushes", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "DHIS2 pushes retrieved successfully",
+		"data":    pushes,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
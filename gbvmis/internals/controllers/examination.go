@@ -4,6 +4,7 @@ import (
 	"errors"
 	"gbvmis/internals/models"
 	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -64,10 +65,20 @@ type ExaminationInitialResponse struct {
 		Profession string `json:"profession"`
 	} `json:"practitioner"`
 
+	// Set where the coded findings are loaded, as in search
+	Injuries      []models.ExaminationInjury  `json:"injuries,omitempty"`
+	CodedFindings *models.ExaminationFindings `json:"coded_findings,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ConvertToExaminationResponse shows a child victim by initials only, as
+// CaseResponse does
 func ConvertToExaminationResponse(e models.Examination) ExaminationInitialResponse {
+	victim := e.Victim
+	if service.IsMinorInAnyCase(victim, []models.Case{e.Case}) {
+		service.RedactVictim(&victim)
+	}
 	return ExaminationInitialResponse{
 		ID:           e.ID,
 		ExamDate:     e.ExamDate,
@@ -81,10 +92,10 @@ func ConvertToExaminationResponse(e models.Examination) ExaminationInitialRespon
 			LastName  string `json:"last_name"`
 			Gender    string `json:"gender"`
 		}{
-			ID:        e.Victim.ID,
-			FirstName: e.Victim.FirstName,
-			LastName:  e.Victim.LastName,
-			Gender:    e.Victim.Gender,
+			ID:        victim.ID,
+			FirstName: victim.FirstName,
+			LastName:  victim.LastName,
+			Gender:    victim.Gender,
 		},
 		Case: struct {
 			ID         uint   `json:"id"`
@@ -115,7 +126,9 @@ func ConvertToExaminationResponse(e models.Examination) ExaminationInitialRespon
 			LastName:   e.Practitioner.LastName,
 			Profession: e.Practitioner.Profession,
 		},
-		CreatedAt: e.CreatedAt,
+		Injuries:      e.Injuries,
+		CodedFindings: e.CodedFindings,
+		CreatedAt:     e.CreatedAt,
 	}
 }
 
@@ -420,12 +433,19 @@ func (h *ExaminationController) DeleteExaminationByID(c *fiber.Ctx) error {
 // SearchExaminations godoc
 //
 //	@Summary		Search for examinations with pagination
-//	@Description	Retrieves a paginated list of examinations based on search criteria.
+//	@Description	Retrieves a paginated list of examinations based on search criteria, each with its injuries and coded findings.
 //	@Tags			Examinations
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	fiber.Map	"Examinations retrieved successfully"
-//	@Failure		500	{object}	fiber.Map	"Failed to retrieve examinations"
+//	@Param			facility_id			query		int			false	"Examined at this facility"
+//	@Param			practitioner_id		query		int			false	"Examined by this practitioner"
+//	@Param			injury_type			query		string		false	"With an injury of this type"
+//	@Param			body_region			query		string		false	"With an injury in this body region; a group such as genital matches all its regions"
+//	@Param			hymen				query		string		false	"With this hymen finding"
+//	@Param			anal_examination	query		string		false	"With this anal examination outcome"
+//	@Param			penetration_opinion	query		string		false	"With this opinion on penetration"
+//	@Success		200					{object}	fiber.Map	"Examinations retrieved successfully"
+//	@Failure		500					{object}	fiber.Map	"Failed to retrieve examinations"
 //	@Router			/examinations/search [get]
 func (h *ExaminationController) SearchExaminations(c *fiber.Ctx) error {
 	// Call the repository function to get paginated search results
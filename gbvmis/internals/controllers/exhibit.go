@@ -0,0 +1,498 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type ExhibitController struct {
+	repo repository.ExhibitRepository
+}
+
+func NewExhibitController(repo repository.ExhibitRepository) *ExhibitController {
+	return &ExhibitController{repo: repo}
+}
+
+type CreateExhibitPayload struct {
+	CaseID          uint      `json:"case_id" validate:"required"`
+	ExhibitNumber   string    `json:"exhibit_number" validate:"required"`
+	Description     string    `json:"description" validate:"required"`
+	Category        string    `json:"category"`
+	SealNumber      string    `json:"seal_number" validate:"required"`
+	StorageLocation string    `json:"storage_location"`
+	Photos          []string  `json:"photos"`
+	CollectedAt     time.Time `json:"collected_at"`
+}
+
+type ExhibitResponse struct {
+	ID              uint      `json:"id"`
+	CaseID          uint      `json:"case_id"`
+	CaseNumber      string    `json:"case_number"`
+	ExhibitNumber   string    `json:"exhibit_number"`
+	Description     string    `json:"description"`
+	Category        string    `json:"category"`
+	SealNumber      string    `json:"seal_number"`
+	StorageLocation string    `json:"storage_location"`
+	Photos          []string  `json:"photos"`
+	CollectedAt     time.Time `json:"collected_at"`
+	CollectedByID   uint      `json:"collected_by_id"`
+	HolderType      string    `json:"holder_type"`
+	HolderName      string    `json:"holder_name"`
+	HolderOfficerID *uint     `json:"holder_officer_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func ConvertToExhibitResponse(e models.Exhibit) ExhibitResponse {
+	return ExhibitResponse{
+		ID:              e.ID,
+		CaseID:          e.CaseID,
+		CaseNumber:      e.Case.CaseNumber,
+		ExhibitNumber:   e.ExhibitNumber,
+		Description:     e.Description,
+		Category:        e.Category,
+		SealNumber:      e.SealNumber,
+		StorageLocation: e.StorageLocation,
+		Photos:          e.Photos,
+		CollectedAt:     e.CollectedAt,
+		CollectedByID:   e.CollectedByID,
+		HolderType:      e.HolderType,
+		HolderName:      e.HolderName,
+		HolderOfficerID: e.HolderOfficerID,
+		CreatedAt:       e.CreatedAt,
+	}
+}
+
+// ================================
+
+// CreateExhibit godoc
+//
+//	@Summary		Register a new exhibit against a case
+//	@Description	Registers an exhibit in the case's evidence register. The collecting officer becomes its first holder.
+//	@Tags			Exhibits
+//	@Accept			json
+//	@Produce		json
+//	@Param			exhibit	body		CreateExhibitPayload	true	"Exhibit data to create"
+//	@Success		201		{object}	fiber.Map				"Successfully created exhibit"
+//	@Failure		400		{object}	fiber.Map				"Bad request due to invalid input"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating exhibit"
+//	@Router			/exhibit [post]
+func (h *ExhibitController) CreateExhibit(c *fiber.Ctx) error {
+	var payload CreateExhibitPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"data":    errs,
+		})
+	}
+
+	casee, err := h.repo.FindCaseByID(payload.CaseID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid case ID", err))
+	}
+
+	user := c.Locals("user").(*utils.Claims)
+	officer, err := h.repo.FindOfficerByID(user.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Unknown officer", err))
+	}
+
+	collectedAt := payload.CollectedAt
+	if collectedAt.IsZero() {
+		collectedAt = time.Now()
+	}
+
+	exhibit := &models.Exhibit{
+		CaseID:          casee.ID,
+		ExhibitNumber:   payload.ExhibitNumber,
+		Description:     payload.Description,
+		Category:        payload.Category,
+		SealNumber:      payload.SealNumber,
+		StorageLocation: payload.StorageLocation,
+		Photos:          payload.Photos,
+		CollectedAt:     collectedAt,
+		CollectedByID:   officer.ID,
+		HolderType:      models.HolderOfficer,
+		HolderName:      officer.FirstName + " " + officer.LastName,
+		HolderOfficerID: &officer.ID,
+		Case:            casee,
+	}
+
+	if err := h.repo.CreateExhibit(exhibit); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create exhibit", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Exhibit created successfully", ConvertToExhibitResponse(*exhibit)))
+}
+
+// ===========
+
+// GetAllExhibits godoc
+//
+//	@Summary		Retrieve a paginated list of exhibits
+//	@Description	Fetches all exhibits with pagination support.
+//	@Tags			Exhibits
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Exhibits retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve exhibits"
+//	@Router			/exhibits [get]
+func (h *ExhibitController) GetAllExhibits(c *fiber.Ctx) error {
+	pagination, exhibits, err := h.repo.GetPaginatedExhibits(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve exhibits", err))
+	}
+
+	responses := make([]ExhibitResponse, len(exhibits))
+	for i, e := range exhibits {
+		responses[i] = ConvertToExhibitResponse(e)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Exhibits retrieved successfully",
+		"data":    responses,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// =========
+
+// GetSingleExhibit godoc
+//
+//	@Summary		Retrieve a single exhibit by ID
+//	@Description	Fetches an exhibit and its current holder.
+//	@Tags			Exhibits
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Exhibit ID"
+//	@Success		200	{object}	fiber.Map	"Exhibit retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Exhibit not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving exhibit"
+//	@Router			/exhibit/{id} [get]
+func (h *ExhibitController) GetSingleExhibit(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	exhibit, err := h.repo.GetExhibitByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Exhibit not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve exhibit", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Exhibit retrieved successfully", ConvertToExhibitResponse(exhibit)))
+}
+
+// =======================
+
+type UpdateExhibitPayload struct {
+	Description     string   `json:"description"`
+	Category        string   `json:"category"`
+	StorageLocation string   `json:"storage_location"`
+	Photos          []string `json:"photos"`
+}
+
+// UpdateExhibit godoc
+//
+//	@Summary		Update descriptive details of an exhibit
+//	@Description	Updates description, category, storage location or photos. Seal number and holder only change through transfers.
+//	@Tags			Exhibits
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Exhibit ID"
+//	@Param			exhibit	body		UpdateExhibitPayload	true	"Exhibit data to update"
+//	@Success		200		{object}	fiber.Map				"Exhibit updated successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input or empty request body"
+//	@Failure		404		{object}	fiber.Map				"Exhibit not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when updating exhibit"
+//	@Router			/exhibit/{id} [put]
+func (h *ExhibitController) UpdateExhibit(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	if _, err := h.repo.GetExhibitByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Exhibit not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve exhibit", err))
+	}
+
+	var payload UpdateExhibitPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	updates := make(map[string]interface{})
+	if payload.Description != "" {
+		updates["description"] = payload.Description
+	}
+	if payload.Category != "" {
+		updates["category"] = payload.Category
+	}
+	if payload.StorageLocation != "" {
+		updates["storage_location"] = payload.StorageLocation
+	}
+	if payload.Photos != nil {
+		updates["photos"] = datatypes.JSONSlice[string](payload.Photos)
+	}
+
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Empty request body",
+		})
+	}
+
+	if err := h.repo.UpdateExhibit(id, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update exhibit", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Exhibit updated successfully", updates))
+}
+
+// =======================
+
+type ExhibitTransferPayload struct {
+	ToType              string    `json:"to_type" validate:"required,oneof=officer exhibit_store lab court"`
+	ToName              string    `json:"to_name"` // Required unless to_officer_id is given
+	ToOfficerID         *uint     `json:"to_officer_id"`
+	Purpose             string    `json:"purpose" validate:"required"`
+	TransferredAt       time.Time `json:"transferred_at"`
+	SealNumber          string    `json:"seal_number"` // New seal, if the exhibit was resealed
+	SealIntact          bool      `json:"seal_intact"`
+	ReleasedBySignature string    `json:"released_by_signature" validate:"required"`
+	ReceivedBySignature string    `json:"received_by_signature" validate:"required"`
+	Notes               string    `json:"notes"`
+}
+
+// TransferExhibit godoc
+//
+//	@Summary		Record a chain-of-custody transfer of an exhibit
+//	@Description	Appends a signed transfer from the exhibit's current holder to a new officer, exhibit store, lab or court. Transfers cannot be edited or deleted.
+//	@Tags			Exhibits
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string					true	"Exhibit ID"
+//	@Param			transfer	body		ExhibitTransferPayload	true	"Transfer details"
+//	@Success		201			{object}	fiber.Map				"Transfer recorded successfully"
+//	@Failure		400			{object}	fiber.Map				"Invalid input"
+//	@Failure		404			{object}	fiber.Map				"Exhibit not found"
+//	@Failure		500			{object}	fiber.Map				"Server error when recording transfer"
+//	@Router			/exhibit/{id}/transfer [post]
+func (h *ExhibitController) TransferExhibit(c *fiber.Ctx) error {
+	exhibitID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid exhibit ID", err))
+	}
+
+	var payload ExhibitTransferPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"data":    errs,
+		})
+	}
+
+	toName := payload.ToName
+	if payload.ToOfficerID != nil {
+		officer, err := h.repo.FindOfficerByID(*payload.ToOfficerID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid receiving officer ID", err))
+		}
+		toName = officer.FirstName + " " + officer.LastName
+	}
+	if toName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "to_name or to_officer_id is required",
+		})
+	}
+
+	transferredAt := payload.TransferredAt
+	if transferredAt.IsZero() {
+		transferredAt = time.Now()
+	}
+
+	user := c.Locals("user").(*utils.Claims)
+
+	transfer, err := h.repo.AppendTransfer(uint(exhibitID), func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error) {
+		t := models.ExhibitTransfer{
+			ExhibitID:           exhibit.ID,
+			Sequence:            1,
+			TransferredAt:       transferredAt,
+			Purpose:             payload.Purpose,
+			FromType:            exhibit.HolderType,
+			FromName:            exhibit.HolderName,
+			FromOfficerID:       exhibit.HolderOfficerID,
+			ToType:              payload.ToType,
+			ToName:              toName,
+			ToOfficerID:         payload.ToOfficerID,
+			SealNumber:          payload.SealNumber,
+			SealIntact:          payload.SealIntact,
+			ReleasedBySignature: payload.ReleasedBySignature,
+			ReceivedBySignature: payload.ReceivedBySignature,
+			RecordedByID:        user.UserID,
+			Notes:               payload.Notes,
+		}
+		if last != nil {
+			t.Sequence = last.Sequence + 1
+			t.PrevHash = last.Hash
+		}
+		t.Hash = service.ExhibitTransferHash(t)
+		return t, nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Exhibit not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record transfer", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Transfer recorded successfully", transfer))
+}
+
+// =========
+
+// GetExhibitChain godoc
+//
+//	@Summary		Chain-of-custody report for an exhibit
+//	@Description	Returns the exhibit with every transfer in order and whether the hash chain is intact.
+//	@Tags			Exhibits
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Exhibit ID"
+//	@Success		200	{object}	fiber.Map	"Chain of custody retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Exhibit not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving chain of custody"
+//	@Router			/exhibit/{id}/chain [get]
+func (h *ExhibitController) GetExhibitChain(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	exhibit, err := h.repo.GetExhibitByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Exhibit not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve exhibit", err))
+	}
+
+	transfers, err := h.repo.GetTransfers(exhibit.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve chain of custody", err))
+	}
+
+	intact, brokenAt := service.VerifyExhibitChain(transfers)
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Chain of custody retrieved successfully", fiber.Map{
+		"exhibit":         ConvertToExhibitResponse(exhibit),
+		"transfers":       transfers,
+		"chain_intact":    intact,
+		"broken_at_seq":   brokenAt,
+		"total_transfers": len(transfers),
+	}))
+}
+
+// ==================
+
+// DeleteExhibitByID godoc
+//
+//	@Summary		Delete an exhibit by ID
+//	@Description	Deletes an exhibit from the register. Its chain of custody is retained.
+//	@Tags			Exhibits
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Exhibit ID"
+//	@Success		200	{object}	fiber.Map	"Exhibit deleted successfully"
+//	@Failure		404	{object}	fiber.Map	"Exhibit not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting exhibit"
+//	@Router			/exhibit/{id} [delete]
+func (h *ExhibitController) DeleteExhibitByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	exhibit, err := h.repo.GetExhibitByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(404).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Exhibit not found",
+			})
+		}
+		return c.Status(500).JSON(utils.ErrorResponse("Failed to find exhibit", err))
+	}
+
+	if err := h.repo.DeleteByID(id); err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse("Failed to delete exhibit", err))
+	}
+
+	return c.Status(200).JSON(utils.SuccessResponse("Exhibit deleted successfully", ConvertToExhibitResponse(exhibit)))
+}
+
+// =================
+
+// SearchExhibits godoc
+//
+//	@Summary		Search exhibits with pagination
+//	@Description	Filters exhibits by case_id, exhibit_number, seal_number, description, category or holder_type.
+//	@Tags			Exhibits
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Exhibits retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve exhibits"
+//	@Router			/exhibits/search [get]
+func (h *ExhibitController) SearchExhibits(c *fiber.Ctx) error {
+	pagination, exhibits, err := h.repo.SearchPaginatedExhibits(c)
+	if err != nil {
+		return c.Status(500).JSON(utils.ErrorResponse("Failed to retrieve exhibits", err))
+	}
+
+	responses := make([]ExhibitResponse, len(exhibits))
+	for i, e := range exhibits {
+		responses[i] = ConvertToExhibitResponse(e)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Exhibits retrieved successfully",
+		"data":    responses,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
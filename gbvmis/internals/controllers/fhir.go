@@ -0,0 +1,416 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Page sizes of FHIR searches and exports
+const (
+	fhirDefaultCount = 50
+	fhirMaxCount     = 200
+)
+
+type FhirController struct {
+	repo repository.FhirRepository
+}
+
+func NewFhirController(repo repository.FhirRepository) *FhirController {
+	return &FhirController{repo: repo}
+}
+
+// fhirBase is the absolute URL of the /fhir endpoints, used in bundle links
+func fhirBase(c *fiber.Ctx) string {
+	return c.BaseURL() + "/api/fhir"
+}
+
+func respondFhir(c *fiber.Ctx, status int, body interface{}) error {
+	return c.Status(status).JSON(body, models.FhirJSON)
+}
+
+// fhirError answers with an OperationOutcome, which FHIR clients expect in
+// place of the usual error body
+func fhirError(c *fiber.Ctx, status int, code, diagnostics string) error {
+	return respondFhir(c, status, service.NewFhirOutcome(code, diagnostics))
+}
+
+// consentedExamination loads an examination and refuses it unless the
+// survivor consented, writing the OperationOutcome when it cannot be served
+func (h *FhirController) consentedExamination(c *fiber.Ctx, id string) (models.Examination, bool, error) {
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return models.Examination{}, false, fhirError(c, fiber.StatusNotFound, "not-found", "Encounter/"+id+" not found")
+	}
+	examination, err := h.repo.GetExaminationByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return examination, false, fhirError(c, fiber.StatusNotFound, "not-found", "Encounter/"+id+" not found")
+		}
+		return examination, false, fhirError(c, fiber.StatusInternalServerError, "exception", "Failed to retrieve examination")
+	}
+	if !examination.ConsentGiven {
+		return examination, false, fhirError(c, fiber.StatusForbidden, "forbidden", "The survivor has not consented to sharing this examination")
+	}
+	return examination, true, nil
+}
+
+// fhirReferenceID reads a search parameter holding a resource id, bare or
+// as a "Type/id" reference
+func fhirReferenceID(value, resourceType string) (*uint, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(value, resourceType+"/"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a %s reference", value, resourceType)
+	}
+	ref := uint(id)
+	return &ref, nil
+}
+
+// examinationFilterFromQuery reads the Encounter search parameters:
+// service-provider, participant, date (repeatable, with ge/le/gt/lt/eq
+// prefixes), _count and _offset
+func examinationFilterFromQuery(c *fiber.Ctx) (models.FhirExaminationFilter, error) {
+	filter := models.FhirExaminationFilter{Count: fhirDefaultCount}
+	var err error
+	if filter.FacilityID, err = fhirReferenceID(c.Query("service-provider"), "Organization"); err != nil {
+		return filter, err
+	}
+	if filter.PractitionerID, err = fhirReferenceID(c.Query("participant"), "Practitioner"); err != nil {
+		return filter, err
+	}
+
+	for _, raw := range c.Context().QueryArgs().PeekMulti("date") {
+		value := string(raw)
+		prefix := "eq"
+		if len(value) > 2 && value[0] >= 'a' && value[0] <= 'z' {
+			prefix, value = value[:2], value[2:]
+		}
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, fmt.Errorf("date %q must be YYYY-MM-DD with an optional ge, le, gt, lt or eq prefix", string(raw))
+		}
+		switch prefix {
+		case "eq":
+			filter.From, filter.To = value, value
+		case "ge":
+			filter.From = value
+		case "gt":
+			filter.From = date.AddDate(0, 0, 1).Format("2006-01-02")
+		case "le":
+			filter.To = value
+		case "lt":
+			filter.To = date.AddDate(0, 0, -1).Format("2006-01-02")
+		default:
+			return filter, fmt.Errorf("date prefix %q is not supported", prefix)
+		}
+	}
+
+	if count := c.QueryInt("_count", fhirDefaultCount); count > 0 {
+		filter.Count = min(count, fhirMaxCount)
+	}
+	filter.Offset = max(c.QueryInt("_offset", 0), 0)
+	return filter, nil
+}
+
+// pageLinks gives a searchset its self link and, when more results remain,
+// a next link
+func pageLinks(c *fiber.Ctx, path string, filter models.FhirExaminationFilter, total int64) []models.FhirBundleLink {
+	link := func(offset int) string {
+		query, _ := url.ParseQuery(string(c.Context().QueryArgs().QueryString()))
+		query.Set("_count", strconv.Itoa(filter.Count))
+		query.Set("_offset", strconv.Itoa(offset))
+		return fhirBase(c) + path + "?" + query.Encode()
+	}
+
+	links := []models.FhirBundleLink{{Relation: "self", URL: link(filter.Offset)}}
+	if int64(filter.Offset+filter.Count) < total {
+		links = append(links, models.FhirBundleLink{Relation: "next", URL: link(filter.Offset + filter.Count)})
+	}
+	return links
+}
+
+// ================================
+
+// GetFhirMetadata godoc
+//
+//	@Summary		FHIR capability statement
+//	@Description	Describes the read-only FHIR R4 interface: Encounter and Observation for medical examinations, Practitioner and Organization for health practitioners and facilities.
+//	@Tags			FHIR
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"CapabilityStatement"
+//	@Router			/fhir/metadata [get]
+func (h *FhirController) GetFhirMetadata(c *fiber.Ctx) error {
+	resource := func(resourceType string, searchParams ...string) fiber.Map {
+		params := []fiber.Map{}
+		for _, param := range searchParams {
+			name, paramType, _ := strings.Cut(param, ":")
+			params = append(params, fiber.Map{"name": name, "type": paramType})
+		}
+		interactions := []fiber.Map{{"code": "read"}}
+		if len(params) > 0 {
+			interactions = append(interactions, fiber.Map{"code": "search-type"})
+		}
+		return fiber.Map{"type": resourceType, "interaction": interactions, "searchParam": params}
+	}
+
+	return respondFhir(c, fiber.StatusOK, fiber.Map{
+		"resourceType": "CapabilityStatement",
+		"status":       "active",
+		"date":         time.Now().UTC().Format("2006-01-02"),
+		"kind":         "instance",
+		"fhirVersion":  "4.0.1",
+		"format":       []string{"json"},
+		"implementation": fiber.Map{
+			"description": "GBV MIS medical examinations. Only examinations the survivor consented to are served.",
+			"url":         fhirBase(c),
+		},
+		"rest": []fiber.Map{{
+			"mode": "server",
+			"resource": []fiber.Map{
+				resource("Encounter", "service-provider:reference", "participant:reference", "date:date"),
+				resource("Observation", "encounter:reference"),
+				resource("Practitioner"),
+				resource("Organization"),
+			},
+		}},
+	})
+}
+
+// ================================
+
+// SearchFhirEncounters godoc
+//
+//	@Summary		Search examinations as FHIR Encounters
+//	@Description	Returns a searchset bundle of encounters, one per examination the survivor consented to, newest first. Examinations without consent are never included.
+//	@Tags			FHIR
+//	@Produce		json
+//	@Param			service-provider	query		string		false	"Organization (health facility) id"
+//	@Param			participant			query		string		false	"Practitioner id"
+//	@Param			date				query		string		false	"Exam date, YYYY-MM-DD, with an optional ge, le, gt, lt or eq prefix; repeat for a range"
+//	@Param			_count				query		int			false	"Page size, at most 200"
+//	@Param			_offset				query		int			false	"Results to skip"
+//	@Success		200					{object}	fiber.Map	"Bundle"
+//	@Failure		400					{object}	fiber.Map	"OperationOutcome for invalid parameters"
+//	@Failure		500					{object}	fiber.Map	"OperationOutcome for server errors"
+//	@Router			/fhir/Encounter [get]
+func (h *FhirController) SearchFhirEncounters(c *fiber.Ctx) error {
+	filter, err := examinationFilterFromQuery(c)
+	if err != nil {
+		return fhirError(c, fiber.StatusBadRequest, "invalid", err.Error())
+	}
+	examinations, total, err := h.repo.SearchConsentedExaminations(filter)
+	if err != nil {
+		return fhirError(c, fiber.StatusInternalServerError, "exception", "Failed to search examinations")
+	}
+
+	resources := make([]models.FhirResource, 0, len(examinations))
+	for _, examination := range examinations {
+		resources = append(resources, service.FhirEncounterFromExamination(examination))
+	}
+	bundle := service.NewFhirBundle(fhirBase(c), "searchset", resources)
+	bundle.Total = &total
+	bundle.Link = pageLinks(c, "/Encounter", filter, total)
+	return respondFhir(c, fiber.StatusOK, bundle)
+}
+
+// ================================
+
+// GetFhirEncounter godoc
+//
+//	@Summary		Read an examination as a FHIR Encounter
+//	@Tags			FHIR
+//	@Produce		json
+//	@Param			id	path		string		true	"Examination ID"
+//	@Success		200	{object}	fiber.Map	"Encounter"
+//	@Failure		403	{object}	fiber.Map	"OperationOutcome: the survivor has not consented"
+//	@Failure		404	{object}	fiber.Map	"OperationOutcome: not found"
+//	@Router			/fhir/Encounter/{id} [get]
+func (h *FhirController) GetFhirEncounter(c *fiber.Ctx) error {
+	examination, ok, err := h.consentedExamination(c, c.Params("id"))
+	if !ok {
+		return err
+	}
+	return respondFhir(c, fiber.StatusOK, service.FhirEncounterFromExamination(examination))
+}
+
+// ================================
+
+// GetFhirEncounterEverything godoc
+//
+//	@Summary		Export an examination as a FHIR bundle
+//	@Description	Returns a collection bundle with the encounter, its observations (findings, treatment and referral notes), the practitioner and the facility: the PF3-equivalent record for import into a hospital system.
+//	@Tags			FHIR
+//	@Produce		json
+//	@Param			id	path		string		true	"Examination ID"
+//	@Success		200	{object}	fiber.Map	"Bundle"
+//	@Failure		403	{object}	fiber.Map	"OperationOutcome: the survivor has not consented"
+//	@Failure		404	{object}	fiber.Map	"OperationOutcome: not found"
+//	@Router			/fhir/Encounter/{id}/$everything [get]
+func (h *FhirController) GetFhirEncounterEverything(c *fiber.Ctx) error {
+	examination, ok, err := h.consentedExamination(c, c.Params("id"))
+	if !ok {
+		return err
+	}
+	resources := service.FhirExaminationResources([]models.Examination{examination}, true)
+	return respondFhir(c, fiber.StatusOK, service.NewFhirBundle(fhirBase(c), "collection", resources))
+}
+
+// ================================
+
+// ExportFhirExaminations godoc
+//
+//	@Summary		Export many examinations as a FHIR bundle
+//	@Description	Takes the Encounter search parameters and returns a collection bundle with each matching consented examination's encounter and observations, plus every practitioner and facility they refer to. Page with _count and _offset; the next link is returned when more remain.
+//	@Tags			FHIR
+//	@Produce		json
+//	@Param			service-provider	query		string		false	"Organization (health facility) id"
+//	@Param			participant			query		string		false	"Practitioner id"
+//	@Param			date				query		string		false	"Exam date with an optional ge, le, gt, lt or eq prefix; repeat for a range"
+//	@Param			_count				query		int			false	"Examinations per bundle, at most 200"
+//	@Param			_offset				query		int			false	"Examinations to skip"
+//	@Success		200					{object}	fiber.Map	"Bundle"
+//	@Failure		400					{object}	fiber.Map	"OperationOutcome for invalid parameters"
+//	@Failure		500					{object}	fiber.Map	"OperationOutcome for server errors"
+//	@Router			/fhir/export [get]
+func (h *FhirController) ExportFhirExaminations(c *fiber.Ctx) error {
+	filter, err := examinationFilterFromQuery(c)
+	if err != nil {
+		return fhirError(c, fiber.StatusBadRequest, "invalid", err.Error())
+	}
+	examinations, total, err := h.repo.SearchConsentedExaminations(filter)
+	if err != nil {
+		return fhirError(c, fiber.StatusInternalServerError, "exception", "Failed to search examinations")
+	}
+
+	bundle := service.NewFhirBundle(fhirBase(c), "collection", service.FhirExaminationResources(examinations, true))
+	bundle.Link = pageLinks(c, "/export", filter, total)
+	return respondFhir(c, fiber.StatusOK, bundle)
+}
+
+// ================================
+
+// SearchFhirObservations godoc
+//
+//	@Summary		Search the observations of an examination
+//	@Tags			FHIR
+//	@Produce		json
+//	@Param			encounter	query		string		true	"Encounter (examination) id"
+//	@Success		200			{object}	fiber.Map	"Bundle"
+//	@Failure		400			{object}	fiber.Map	"OperationOutcome: encounter missing"
+//	@Failure		403			{object}	fiber.Map	"OperationOutcome: the survivor has not consented"
+//	@Failure		404			{object}	fiber.Map	"OperationOutcome: encounter not found"
+//	@Router			/fhir/Observation [get]
+func (h *FhirController) SearchFhirObservations(c *fiber.Ctx) error {
+	encounter := strings.TrimPrefix(c.Query("encounter"), "Encounter/")
+	if encounter == "" {
+		return fhirError(c, fiber.StatusBadRequest, "required", "Search observations by encounter")
+	}
+	examination, ok, err := h.consentedExamination(c, encounter)
+	if !ok {
+		return err
+	}
+
+	resources := []models.FhirResource{}
+	for _, observation := range service.FhirObservationsFromExamination(examination) {
+		resources = append(resources, observation)
+	}
+	bundle := service.NewFhirBundle(fhirBase(c), "searchset", resources)
+	total := int64(len(resources))
+	bundle.Total = &total
+	return respondFhir(c, fiber.StatusOK, bundle)
+}
+
+// ================================
+
+// GetFhirObservation godoc
+//
+//	@Summary		Read one note or coded finding of an examination as a FHIR Observation
+//	@Description	Observation ids are the examination id followed by findings, treatment, referral, age or genital, e.g. 12-findings, or by injury and the injury id, e.g. 12-injury-34.
+//	@Tags			FHIR
+//	@Produce		json
+//	@Param			id	path		string		true	"Observation ID"
+//	@Success		200	{object}	fiber.Map	"Observation"
+//	@Failure		403	{object}	fiber.Map	"OperationOutcome: the survivor has not consented"
+//	@Failure		404	{object}	fiber.Map	"OperationOutcome: not found"
+//	@Router			/fhir/Observation/{id} [get]
+func (h *FhirController) GetFhirObservation(c *fiber.Ctx) error {
+	id := c.Params("id")
+	examinationID, _, valid := service.ParseFhirObservationID(id)
+	if !valid {
+		return fhirError(c, fiber.StatusNotFound, "not-found", "Observation/"+id+" not found")
+	}
+	examination, ok, err := h.consentedExamination(c, examinationID)
+	if !ok {
+		return err
+	}
+
+	for _, observation := range service.FhirObservationsFromExamination(examination) {
+		if observation.ID == id {
+			return respondFhir(c, fiber.StatusOK, observation)
+		}
+	}
+	return fhirError(c, fiber.StatusNotFound, "not-found", "Observation/"+id+" not found")
+}
+
+// ================================
+
+// GetFhirPractitioner godoc
+//
+//	@Summary		Read a health practitioner as a FHIR Practitioner
+//	@Tags			FHIR
+//	@Produce		json
+//	@Param			id	path		string		true	"Health practitioner ID"
+//	@Success		200	{object}	fiber.Map	"Practitioner"
+//	@Failure		404	{object}	fiber.Map	"OperationOutcome: not found"
+//	@Router			/fhir/Practitioner/{id} [get]
+func (h *FhirController) GetFhirPractitioner(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return fhirError(c, fiber.StatusNotFound, "not-found", "Practitioner/"+id+" not found")
+	}
+	practitioner, err := h.repo.GetPractitionerByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fhirError(c, fiber.StatusNotFound, "not-found", "Practitioner/"+id+" not found")
+		}
+		return fhirError(c, fiber.StatusInternalServerError, "exception", "Failed to retrieve practitioner")
+	}
+	return respondFhir(c, fiber.StatusOK, service.FhirPractitionerFromModel(practitioner))
+}
+
+// ================================
+
+// GetFhirOrganization godoc
+//
+//	@Summary		Read a health facility as a FHIR Organization
+//	@Tags			FHIR
+//	@Produce		json
+//	@Param			id	path		string		true	"Health facility ID"
+//	@Success		200	{object}	fiber.Map	"Organization"
+//	@Failure		404	{object}	fiber.Map	"OperationOutcome: not found"
+//	@Router			/fhir/Organization/{id} [get]
+func (h *FhirController) GetFhirOrganization(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		return fhirError(c, fiber.StatusNotFound, "not-found", "Organization/"+id+" not found")
+	}
+	facility, err := h.repo.GetFacilityByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fhirError(c, fiber.StatusNotFound, "not-found", "Organization/"+id+" not found")
+		}
+		return fhirError(c, fiber.StatusInternalServerError, "exception", "Failed to retrieve facility")
+	}
+	return respondFhir(c, fiber.StatusOK, service.FhirOrganizationFromFacility(facility))
+}
@@ -0,0 +1,468 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/utils"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type FindingController struct {
+	repo repository.FindingRepository
+}
+
+func NewFindingController(repo repository.FindingRepository) *FindingController {
+	return &FindingController{repo: repo}
+}
+
+type InjuryPayload struct {
+	InjuryType  string   `json:"injury_type" validate:"required"`
+	BodyRegion  string   `json:"body_region" validate:"required"` // a code from GET /findings/codes
+	Side        string   `json:"side"`                            // required for sided regions
+	LengthCm    *float64 `json:"length_cm"`
+	WidthCm     *float64 `json:"width_cm"`
+	AgeEstimate string   `json:"age_estimate"` // defaults to undetermined
+	Description string   `json:"description"`  // required for other
+}
+
+type UpdateInjuryPayload struct {
+	InjuryType  string   `json:"injury_type"`
+	BodyRegion  string   `json:"body_region"`
+	Side        *string  `json:"side"` // "" clears the side when moving to an unsided region
+	LengthCm    *float64 `json:"length_cm"`
+	WidthCm     *float64 `json:"width_cm"`
+	AgeEstimate string   `json:"age_estimate"`
+	Description string   `json:"description"`
+}
+
+type ExaminationFindingsPayload struct {
+	EstimatedAgeYears  *int   `json:"estimated_age_years"`
+	AgeEstimateMethod  string `json:"age_estimate_method"` // required with estimated_age_years
+	GenitalExamined    bool   `json:"genital_examined"`
+	Hymen              string `json:"hymen"`
+	GenitalTears       *bool  `json:"genital_tears"`
+	Bleeding           *bool  `json:"bleeding"`
+	Discharge          *bool  `json:"discharge"`
+	AnalExamination    string `json:"anal_examination"`
+	PenetrationOpinion string `json:"penetration_opinion"`
+	GenitalNotes       string `json:"genital_notes"`
+}
+
+// validateInjury checks an injury's codes, side and size and returns a
+// message when something is wrong
+func validateInjury(injury models.ExaminationInjury) string {
+	if !slices.Contains(models.InjuryTypes, injury.InjuryType) {
+		return "injury_type must be one of " + strings.Join(models.InjuryTypes, ", ")
+	}
+	region, ok := models.FindBodyRegion(injury.BodyRegion)
+	if !ok {
+		return "body_region must be a code from the body map"
+	}
+	if region.Sided && !slices.Contains(models.BodySides, injury.Side) {
+		return "side must be one of " + strings.Join(models.BodySides, ", ") + " for " + region.Code
+	}
+	if !region.Sided && injury.Side != "" {
+		return "side cannot be given for " + region.Code
+	}
+	for _, size := range []struct {
+		name  string
+		value *float64
+	}{{"length_cm", injury.LengthCm}, {"width_cm", injury.WidthCm}} {
+		if size.value != nil && (*size.value <= 0 || *size.value >= 100) {
+			return size.name + " must be more than 0 and less than 100"
+		}
+	}
+	if !slices.Contains(models.InjuryAges, injury.AgeEstimate) {
+		return "age_estimate must be one of " + strings.Join(models.InjuryAges, ", ")
+	}
+	if injury.InjuryType == models.InjuryOther && strings.TrimSpace(injury.Description) == "" {
+		return "description is required for other injuries"
+	}
+	return ""
+}
+
+// validateFindings checks the estimated age and the genital examination and
+// returns a message when something is wrong
+func validateFindings(findings models.ExaminationFindings) string {
+	if findings.EstimatedAgeYears != nil {
+		if *findings.EstimatedAgeYears < 0 || *findings.EstimatedAgeYears > 120 {
+			return "estimated_age_years must be between 0 and 120"
+		}
+		if !slices.Contains(models.AgeEstimateMethods, findings.AgeEstimateMethod) {
+			return "age_estimate_method must be one of " + strings.Join(models.AgeEstimateMethods, ", ")
+		}
+	} else if findings.AgeEstimateMethod != "" {
+		return "age_estimate_method requires estimated_age_years"
+	}
+
+	if !findings.GenitalExamined {
+		if findings.Hymen != "" || findings.GenitalTears != nil || findings.Bleeding != nil || findings.Discharge != nil ||
+			findings.AnalExamination != "" || findings.PenetrationOpinion != "" || findings.GenitalNotes != "" {
+			return "genital examination findings require genital_examined"
+		}
+		return ""
+	}
+	if !slices.Contains(models.HymenFindings, findings.Hymen) {
+		return "hymen must be one of " + strings.Join(models.HymenFindings, ", ")
+	}
+	if !slices.Contains(models.AnalFindings, findings.AnalExamination) {
+		return "anal_examination must be one of " + strings.Join(models.AnalFindings, ", ")
+	}
+	if !slices.Contains(models.PenetrationOpinions, findings.PenetrationOpinion) {
+		return "penetration_opinion must be one of " + strings.Join(models.PenetrationOpinions, ", ")
+	}
+	return ""
+}
+
+func (h *FindingController) examinationFromParam(c *fiber.Ctx) (models.Examination, bool, error) {
+	examination, err := h.repo.GetExaminationByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return examination, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Examination not found",
+			})
+		}
+		return examination, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve examination", err))
+	}
+	return examination, true, nil
+}
+
+func (h *FindingController) injuryFromParam(c *fiber.Ctx) (models.ExaminationInjury, bool, error) {
+	injury, err := h.repo.GetInjuryByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return injury, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Injury not found",
+			})
+		}
+		return injury, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve injury", err))
+	}
+	return injury, true, nil
+}
+
+// ================================
+
+// GetFindingCodes godoc
+//
+//	@Summary		List the coded values for examination findings
+//	@Description	The injury types, body map, sides, injury ages and genital examination values accepted when recording findings. Body region codes are "group.area"; searches on a group match every area in it.
+//	@Tags			Examination Findings
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Finding codes retrieved successfully"
+//	@Router			/findings/codes [get]
+func (h *FindingController) GetFindingCodes(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Finding codes retrieved successfully", fiber.Map{
+		"injury_types":         models.InjuryTypes,
+		"body_regions":         models.BodyRegions,
+		"sides":                models.BodySides,
+		"injury_ages":          models.InjuryAges,
+		"hymen":                models.HymenFindings,
+		"anal_examination":     models.AnalFindings,
+		"penetration_opinions": models.PenetrationOpinions,
+		"age_estimate_methods": models.AgeEstimateMethods,
+	}))
+}
+
+// ================================
+
+// GetExaminationFindings godoc
+//
+//	@Summary		Retrieve an examination's structured findings
+//	@Description	Returns the coded findings (null when none are recorded) and the injuries on the body map.
+//	@Tags			Examination Findings
+//	@Produce		json
+//	@Param			id	path		string		true	"Examination ID"
+//	@Success		200	{object}	fiber.Map	"Findings retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Examination not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving findings"
+//	@Router			/examination/{id}/findings [get]
+func (h *FindingController) GetExaminationFindings(c *fiber.Ctx) error {
+	examination, ok, err := h.examinationFromParam(c)
+	if !ok {
+		return err
+	}
+
+	findings, err := h.repo.GetExaminationFindings(examination.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve findings", err))
+	}
+	injuries, err := h.repo.GetExaminationInjuries(examination.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve injuries", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Findings retrieved successfully", fiber.Map{
+		"findings": findings,
+		"injuries": injuries,
+	}))
+}
+
+// ================================
+
+// SaveExaminationFindings godoc
+//
+//	@Summary		Record an examination's coded findings
+//	@Description	Replaces the examination's estimated age and genital examination findings. hymen, anal_examination and penetration_opinion are required when genital_examined is true and must be left out when it is false.
+//	@Tags			Examination Findings
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"Examination ID"
+//	@Param			payload	body		ExaminationFindingsPayload	true	"Findings"
+//	@Success		200		{object}	fiber.Map					"Findings recorded successfully"
+//	@Failure		400		{object}	fiber.Map					"Invalid input"
+//	@Failure		404		{object}	fiber.Map					"Examination not found"
+//	@Failure		500		{object}	fiber.Map					"Server error when recording findings"
+//	@Router			/examination/{id}/findings [put]
+func (h *FindingController) SaveExaminationFindings(c *fiber.Ctx) error {
+	var payload ExaminationFindingsPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	examination, ok, err := h.examinationFromParam(c)
+	if !ok {
+		return err
+	}
+
+	findings := models.ExaminationFindings{
+		ExaminationID:      examination.ID,
+		EstimatedAgeYears:  payload.EstimatedAgeYears,
+		AgeEstimateMethod:  payload.AgeEstimateMethod,
+		GenitalExamined:    payload.GenitalExamined,
+		Hymen:              payload.Hymen,
+		GenitalTears:       payload.GenitalTears,
+		Bleeding:           payload.Bleeding,
+		Discharge:          payload.Discharge,
+		AnalExamination:    payload.AnalExamination,
+		PenetrationOpinion: payload.PenetrationOpinion,
+		GenitalNotes:       payload.GenitalNotes,
+		RecordedByID:       c.Locals("user").(*utils.Claims).UserID,
+	}
+	if msg := validateFindings(findings); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	if err := h.repo.SaveExaminationFindings(&findings); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record findings", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Findings recorded successfully", findings))
+}
+
+// ================================
+
+// CreateInjury godoc
+//
+//	@Summary		Record an injury on the body map
+//	@Tags			Examination Findings
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Examination ID"
+//	@Param			payload	body		InjuryPayload	true	"Injury"
+//	@Success		201		{object}	fiber.Map		"Injury recorded successfully"
+//	@Failure		400		{object}	fiber.Map		"Invalid input"
+//	@Failure		404		{object}	fiber.Map		"Examination not found"
+//	@Failure		500		{object}	fiber.Map		"Server error when recording injury"
+//	@Router			/examination/{id}/injuries [post]
+func (h *FindingController) CreateInjury(c *fiber.Ctx) error {
+	var payload InjuryPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	examination, ok, err := h.examinationFromParam(c)
+	if !ok {
+		return err
+	}
+
+	injury := models.ExaminationInjury{
+		ExaminationID: examination.ID,
+		InjuryType:    payload.InjuryType,
+		BodyRegion:    payload.BodyRegion,
+		Side:          payload.Side,
+		LengthCm:      payload.LengthCm,
+		WidthCm:       payload.WidthCm,
+		AgeEstimate:   payload.AgeEstimate,
+		Description:   payload.Description,
+		RecordedByID:  c.Locals("user").(*utils.Claims).UserID,
+	}
+	if injury.AgeEstimate == "" {
+		injury.AgeEstimate = models.InjuryAgeUnknown
+	}
+	if msg := validateInjury(injury); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	if err := h.repo.CreateInjury(&injury); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record injury", err))
+	}
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Injury recorded successfully", injury))
+}
+
+// ================================
+
+// GetAllInjuries godoc
+//
+//	@Summary		Search recorded injuries
+//	@Tags			Examination Findings
+//	@Produce		json
+//	@Param			injury_type		query		string		false	"Limit to one injury type"
+//	@Param			body_region		query		string		false	"A body region code, or a group such as head"
+//	@Param			side			query		string		false	"left, right or bilateral"
+//	@Param			age_estimate	query		string		false	"Limit to one injury age"
+//	@Param			facility_id		query		int			false	"Examined at this facility"
+//	@Param			practitioner_id	query		int			false	"Examined by this practitioner"
+//	@Param			exam_from		query		string		false	"Examined on or after (YYYY-MM-DD)"
+//	@Param			exam_to			query		string		false	"Examined on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Injuries retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve injuries"
+//	@Router			/examination-injuries [get]
+func (h *FindingController) GetAllInjuries(c *fiber.Ctx) error {
+	pagination, injuries, err := h.repo.GetPaginatedInjuries(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve injuries", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Injuries retrieved successfully",
+		"data":    injuries,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetInjuryStats godoc
+//
+//	@Summary		Count injuries by type and part of the body
+//	@Description	Accepts the same filters as the injury search. body_part is the body region's group, e.g. head or genital.
+//	@Tags			Examination Findings
+//	@Produce		json
+//	@Param			injury_type		query		string		false	"Limit to one injury type"
+//	@Param			body_region		query		string		false	"A body region code, or a group such as head"
+//	@Param			facility_id		query		int			false	"Examined at this facility"
+//	@Param			exam_from		query		string		false	"Examined on or after (YYYY-MM-DD)"
+//	@Param			exam_to			query		string		false	"Examined on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Injury statistics retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/examination-injuries/stats [get]
+func (h *FindingController) GetInjuryStats(c *fiber.Ctx) error {
+	stats, err := h.repo.GetInjuryStats(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to compute injury statistics", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Injury statistics retrieved successfully", stats))
+}
+
+// ================================
+
+// UpdateInjury godoc
+//
+//	@Summary		Update an injury
+//	@Tags			Examination Findings
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Injury ID"
+//	@Param			payload	body		UpdateInjuryPayload	true	"Fields to update"
+//	@Success		200		{object}	fiber.Map			"Injury updated successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Injury not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when updating injury"
+//	@Router			/examination-injury/{id} [put]
+func (h *FindingController) UpdateInjury(c *fiber.Ctx) error {
+	injury, ok, err := h.injuryFromParam(c)
+	if !ok {
+		return err
+	}
+
+	var payload UpdateInjuryPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	// Apply the changes to a copy so the result can be validated as a whole
+	updates := make(map[string]interface{})
+	if payload.InjuryType != "" {
+		injury.InjuryType = payload.InjuryType
+		updates["injury_type"] = payload.InjuryType
+	}
+	if payload.BodyRegion != "" {
+		injury.BodyRegion = payload.BodyRegion
+		updates["body_region"] = payload.BodyRegion
+	}
+	if payload.Side != nil {
+		injury.Side = *payload.Side
+		updates["side"] = *payload.Side
+	}
+	if payload.LengthCm != nil {
+		injury.LengthCm = payload.LengthCm
+		updates["length_cm"] = *payload.LengthCm
+	}
+	if payload.WidthCm != nil {
+		injury.WidthCm = payload.WidthCm
+		updates["width_cm"] = *payload.WidthCm
+	}
+	if payload.AgeEstimate != "" {
+		injury.AgeEstimate = payload.AgeEstimate
+		updates["age_estimate"] = payload.AgeEstimate
+	}
+	if payload.Description != "" {
+		injury.Description = payload.Description
+		updates["description"] = payload.Description
+	}
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "No fields to update",
+		})
+	}
+	if msg := validateInjury(injury); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	if err := h.repo.UpdateInjury(injury.ID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update injury", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Injury updated successfully", updates))
+}
+
+// ================================
+
+// DeleteInjury godoc
+//
+//	@Summary		Delete an injury
+//	@Tags			Examination Findings
+//	@Produce		json
+//	@Param			id	path		string		true	"Injury ID"
+//	@Success		200	{object}	fiber.Map	"Injury deleted successfully"
+//	@Failure		404	{object}	fiber.Map	"Injury not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting injury"
+//	@Router			/examination-injury/{id} [delete]
+func (h *FindingController) DeleteInjury(c *fiber.Ctx) error {
+	injury, ok, err := h.injuryFromParam(c)
+	if !ok {
+		return err
+	}
+	if err := h.repo.DeleteInjury(injury.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete injury", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Injury deleted successfully", injury))
+}
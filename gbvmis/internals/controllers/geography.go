@@ -0,0 +1,484 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type GeographyController struct {
+	repo repository.GeographyRepository
+}
+
+func NewGeographyController(repo repository.GeographyRepository) *GeographyController {
+	return &GeographyController{repo: repo}
+}
+
+// importFile returns the uploaded "file" field, or the raw body when the
+// file is posted as text/csv
+func importFile(c *fiber.Ctx) (io.ReadCloser, error) {
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		return fileHeader.Open()
+	}
+	if len(c.Body()) == 0 {
+		return nil, errors.New("upload the file as the multipart field \"file\" or as a text/csv body")
+	}
+	return io.NopCloser(bytes.NewReader(c.Body())), nil
+}
+
+// importHierarchy runs an import for administrators and writes the response
+func (h *GeographyController) importHierarchy(c *fiber.Ctx, name string, run func(repository.GeographyRepository, io.Reader) (models.GeographyImportResult, error)) error {
+	if ok, err := requireAdmin(c, "import the "+name); !ok {
+		return err
+	}
+
+	file, err := importFile(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("file is required", err))
+	}
+	defer file.Close()
+
+	result, err := run(h.repo, file)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidGeographyCSV) || errors.Is(err, models.ErrHierarchyLevels) {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Nothing was imported", err))
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to import the "+name, err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Import completed successfully", result))
+}
+
+// caseWindow reads the from and to dates (YYYY-MM-DD), writing a 400 when
+// either is invalid. For non-administrators the window is widened to whole
+// calendar quarters.
+func caseWindow(c *fiber.Ctx) (repository.CaseWindow, bool, error) {
+	var window repository.CaseWindow
+	if v := c.Query("from"); v != "" {
+		parsed, err := utils.ParseDate(v)
+		if err != nil {
+			return window, false, c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid from date; use YYYY-MM-DD", err))
+		}
+		window.From = parsed
+	}
+	if v := c.Query("to"); v != "" {
+		parsed, err := utils.ParseDate(v)
+		if err != nil {
+			return window, false, c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid to date; use YYYY-MM-DD", err))
+		}
+		window.To = parsed
+	}
+	if !isAdmin(c) {
+		window = service.QuarterWindow(window)
+	}
+	return window, true, nil
+}
+
+// caseCounts validates the level and window and writes the counts computed
+// by count. Areas below the minimum cell size are left out for everyone but
+// administrators.
+func caseCounts(c *fiber.Ctx, levels []string, count func(string, repository.CaseWindow) ([]models.AreaCaseCount, error)) error {
+	level := c.Query("level")
+	if !slices.Contains(levels, level) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "level must be one of " + strings.Join(levels, ", "),
+		})
+	}
+	window, ok, err := caseWindow(c)
+	if !ok {
+		return err
+	}
+	counts, err := count(level, window)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to count cases", err))
+	}
+	if !isAdmin(c) {
+		counts = service.SuppressSmallCells(counts, models.MinGeoCellSize)
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Case counts retrieved successfully", counts))
+}
+
+// checkAdminArea writes a 400 when the area ID is set but unknown
+func checkAdminArea(c *fiber.Ctx, field string, id *uint, find func(uint) (models.AdminArea, error)) (bool, error) {
+	if id == nil {
+		return true, nil
+	}
+	if _, err := find(*id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": field + " is not a known administrative area",
+			})
+		}
+		return false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve administrative area", err))
+	}
+	return true, nil
+}
+
+// checkCoordinates writes a 400 when a pair of optional coordinates is
+// incomplete or out of range
+func checkCoordinates(c *fiber.Ctx, lat, lng *float64) (bool, error) {
+	if msg := service.CheckCoordinates(lat, lng); msg != "" {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+	return true, nil
+}
+
+// setCoordinates adds a complete pair of coordinates to an update
+func setCoordinates(updates map[string]interface{}, lat, lng *float64) {
+	if lat != nil && lng != nil {
+		updates["latitude"] = *lat
+		updates["longitude"] = *lng
+	}
+}
+
+// ================================
+
+// ImportAdminAreas godoc
+//
+//	@Summary		Import the administrative hierarchy from CSV
+//	@Description	Columns: code, name, level (region, district, county, subcounty, parish or village) and parent_code, which must be an area at a higher level in the file or already loaded. Optional latitude and longitude columns give the area's centroid for maps. Areas are matched by code, so a file can be imported again to rename or move areas. The whole file is rejected if any row is wrong or if it would leave a record at or above its parent's level. Administrators only.
+//	@Tags			Geography
+//	@Accept			multipart/form-data
+//	@Accept			text/csv
+//	@Produce		json
+//	@Param			file	formData	file		false	"CSV file; alternatively post the CSV as the body"
+//	@Success		200		{object}	fiber.Map	"Import completed successfully"
+//	@Failure		400		{object}	fiber.Map	"Invalid file; nothing was imported"
+//	@Failure		403		{object}	fiber.Map	"Not an administrator"
+//	@Failure		500		{object}	fiber.Map	"Server error during import"
+//	@Router			/admin-areas/import [post]
+func (h *GeographyController) ImportAdminAreas(c *fiber.Ctx) error {
+	return h.importHierarchy(c, "administrative hierarchy", service.ImportAdminAreas)
+}
+
+// ================================
+
+// GetAllAdminAreas godoc
+//
+//	@Summary		List administrative areas
+//	@Tags			Geography
+//	@Produce		json
+//	@Param			level		query		string		false	"region, district, county, subcounty, parish or village"
+//	@Param			parent_id	query		int			false	"Areas directly under this one"
+//	@Param			code		query		string		false	"Exact code"
+//	@Param			name		query		string		false	"Part of the name"
+//	@Success		200			{object}	fiber.Map	"Administrative areas retrieved successfully"
+//	@Failure		500			{object}	fiber.Map	"Failed to retrieve administrative areas"
+//	@Router			/admin-areas [get]
+func (h *GeographyController) GetAllAdminAreas(c *fiber.Ctx) error {
+	pagination, areas, err := h.repo.GetPaginatedAdminAreas(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve administrative areas", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Administrative areas retrieved successfully",
+		"data":    areas,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetSingleAdminArea godoc
+//
+//	@Summary		Retrieve an administrative area with its ancestors
+//	@Tags			Geography
+//	@Produce		json
+//	@Param			id	path		string		true	"Area ID"
+//	@Success		200	{object}	fiber.Map	"Administrative area retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Administrative area not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving the area"
+//	@Router			/admin-area/{id} [get]
+func (h *GeographyController) GetSingleAdminArea(c *fiber.Ctx) error {
+	area, err := h.repo.GetAdminAreaByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Administrative area not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve administrative area", err))
+	}
+
+	path, err := h.repo.GetAdminAreaPath(area.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve the area's ancestors", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Administrative area retrieved successfully",
+		"data":    area,
+		"path":    path,
+	})
+}
+
+// ================================
+
+// GetAdminAreaCaseCounts godoc
+//
+//	@Summary		Count cases by administrative area
+//	@Description	Places each case by its earliest incident with an area, or else by its police post's area, and counts them at the requested level. Cases that cannot be placed at that level are left out. For non-administrators, areas with fewer cases than the minimum cell size are left out and from and to are widened to whole calendar quarters.
+//	@Tags			Geography
+//	@Produce		json
+//	@Param			level	query		string		true	"region, district, county, subcounty, parish or village"
+//	@Param			from	query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to		query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200		{object}	fiber.Map	"Case counts retrieved successfully"
+//	@Failure		400		{object}	fiber.Map	"Invalid level or date"
+//	@Failure		500		{object}	fiber.Map	"Server error when counting cases"
+//	@Router			/admin-areas/case-counts [get]
+func (h *GeographyController) GetAdminAreaCaseCounts(c *fiber.Ctx) error {
+	return caseCounts(c, models.AdminLevels, h.repo.GetAdminAreaCaseCounts)
+}
+
+// ================================
+
+// ImportPoliceUnits godoc
+//
+//	@Summary		Import the police command hierarchy from CSV
+//	@Description	Columns: code, name, level (region, division, station or post) and parent_code, plus optional latitude and longitude. Posts are matched to police posts by code and created if new. The whole file is rejected if any row is wrong or if it would leave a record at or above its parent's level. Administrators only.
+//	@Tags			Geography
+//	@Accept			multipart/form-data
+//	@Accept			text/csv
+//	@Produce		json
+//	@Param			file	formData	file		false	"CSV file; alternatively post the CSV as the body"
+//	@Success		200		{object}	fiber.Map	"Import completed successfully"
+//	@Failure		400		{object}	fiber.Map	"Invalid file; nothing was imported"
+//	@Failure		403		{object}	fiber.Map	"Not an administrator"
+//	@Failure		500		{object}	fiber.Map	"Server error during import"
+//	@Router			/police-units/import [post]
+func (h *GeographyController) ImportPoliceUnits(c *fiber.Ctx) error {
+	return h.importHierarchy(c, "police command hierarchy", service.ImportPoliceUnits)
+}
+
+// ================================
+
+// GetAllPoliceUnits godoc
+//
+//	@Summary		List police regions, divisions and stations
+//	@Tags			Geography
+//	@Produce		json
+//	@Param			level		query		string		false	"region, division or station"
+//	@Param			parent_id	query		int			false	"Units directly under this one"
+//	@Param			code		query		string		false	"Exact code"
+//	@Param			name		query		string		false	"Part of the name"
+//	@Success		200			{object}	fiber.Map	"Police units retrieved successfully"
+//	@Failure		500			{object}	fiber.Map	"Failed to retrieve police units"
+//	@Router			/police-units [get]
+func (h *GeographyController) GetAllPoliceUnits(c *fiber.Ctx) error {
+	pagination, units, err := h.repo.GetPaginatedPoliceUnits(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve police units", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Police units retrieved successfully",
+		"data":    units,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetSinglePoliceUnit godoc
+//
+//	@Summary		Retrieve a police unit with its ancestors
+//	@Description	Stations also list their police posts.
+//	@Tags			Geography
+//	@Produce		json
+//	@Param			id	path		string		true	"Police unit ID"
+//	@Success		200	{object}	fiber.Map	"Police unit retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Police unit not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving the unit"
+//	@Router			/police-unit/{id} [get]
+func (h *GeographyController) GetSinglePoliceUnit(c *fiber.Ctx) error {
+	unit, err := h.repo.GetPoliceUnitByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Police unit not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve police unit", err))
+	}
+
+	path, err := h.repo.GetPoliceUnitPath(unit.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve the unit's ancestors", err))
+	}
+	response := fiber.Map{
+		"status":  "success",
+		"message": "Police unit retrieved successfully",
+		"data":    unit,
+		"path":    path,
+	}
+
+	if unit.Level == models.PoliceLevelStation {
+		posts, err := h.repo.GetStationPosts(unit.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve the station's posts", err))
+		}
+		response["posts"] = posts
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// ================================
+
+// GetPoliceUnitCaseCounts godoc
+//
+//	@Summary		Count cases by police region, division or station
+//	@Description	Places each case by its police post's station. Cases at posts without a station are left out. For non-administrators, units with fewer cases than the minimum cell size are left out and from and to are widened to whole calendar quarters.
+//	@Tags			Geography
+//	@Produce		json
+//	@Param			level	query		string		true	"region, division or station"
+//	@Param			from	query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to		query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200		{object}	fiber.Map	"Case counts retrieved successfully"
+//	@Failure		400		{object}	fiber.Map	"Invalid level or date"
+//	@Failure		500		{object}	fiber.Map	"Server error when counting cases"
+//	@Router			/police-units/case-counts [get]
+func (h *GeographyController) GetPoliceUnitCaseCounts(c *fiber.Ctx) error {
+	return caseCounts(c, models.PoliceLevels, h.repo.GetPoliceUnitCaseCounts)
+}
+
+// ================================
+
+// queryPoint reads the lat and lng query parameters, writing a 400 when they
+// are missing or out of range
+func queryPoint(c *fiber.Ctx) (float64, float64, bool, error) {
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		return 0, 0, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "lat and lng are required",
+		})
+	}
+	if ok, err := checkCoordinates(c, &lat, &lng); !ok {
+		return 0, 0, false, err
+	}
+	return lat, lng, true, nil
+}
+
+// nearestLimit reads how many places to return, 5 by default and at most 50
+func nearestLimit(c *fiber.Ctx) int {
+	return min(max(c.QueryInt("limit", 5), 1), 50)
+}
+
+// GetNearestPolicePosts godoc
+//
+//	@Summary		Find the police posts nearest a point
+//	@Description	Returns the closest police posts with known coordinates, nearest first, with the straight-line distance in kilometres.
+//	@Tags			Geography
+//	@Produce		json
+//	@Param			lat		query		number		true	"Latitude"
+//	@Param			lng		query		number		true	"Longitude"
+//	@Param			limit	query		int			false	"How many posts to return (default 5, at most 50)"
+//	@Success		200		{object}	fiber.Map	"Nearest police posts retrieved successfully"
+//	@Failure		400		{object}	fiber.Map	"Missing or invalid point"
+//	@Failure		500		{object}	fiber.Map	"Server error when searching"
+//	@Router			/geo/nearest-police-posts [get]
+func (h *GeographyController) GetNearestPolicePosts(c *fiber.Ctx) error {
+	lat, lng, ok, err := queryPoint(c)
+	if !ok {
+		return err
+	}
+	posts, err := h.repo.GetNearestPolicePosts(lat, lng, nearestLimit(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to find police posts", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Nearest police posts retrieved successfully", posts))
+}
+
+// ================================
+
+// GetNearestHealthFacilities godoc
+//
+//	@Summary		Find the GBV health facilities nearest a point
+//	@Description	Returns the closest health facilities offering GBV services (PEP, emergency contraception, forensic examination) with known coordinates, nearest first, with the straight-line distance in kilometres. Pass all=true to include every facility.
+//	@Tags			Geography
+//	@Produce		json
+//	@Param			lat		query		number		true	"Latitude"
+//	@Param			lng		query		number		true	"Longitude"
+//	@Param			limit	query		int			false	"How many facilities to return (default 5, at most 50)"
+//	@Param			all		query		bool		false	"Include facilities without GBV services"
+//	@Success		200		{object}	fiber.Map	"Nearest health facilities retrieved successfully"
+//	@Failure		400		{object}	fiber.Map	"Missing or invalid point"
+//	@Failure		500		{object}	fiber.Map	"Server error when searching"
+//	@Router			/geo/nearest-facilities [get]
+func (h *GeographyController) GetNearestHealthFacilities(c *fiber.Ctx) error {
+	lat, lng, ok, err := queryPoint(c)
+	if !ok {
+		return err
+	}
+	facilities, err := h.repo.GetNearestHealthFacilities(lat, lng, nearestLimit(c), !c.QueryBool("all"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to find health facilities", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Nearest health facilities retrieved successfully", facilities))
+}
+
+// ================================
+
+// GetCasesGeoJSON godoc
+//
+//	@Summary		Map case counts by administrative area as GeoJSON
+//	@Description	Returns one point feature per area with cases, at the area's centroid (null geometry when the centroid is unknown), with the case count. No victim or case details are included. Areas with fewer cases than the minimum cell size are left out. min_cell can raise the threshold but not lower it. For non-administrators from and to are widened to whole calendar quarters.
+//	@Tags			Geography
+//	@Produce		json
+//	@Param			level		query		string		false	"region, district, county, subcounty, parish or village (default district)"
+//	@Param			from		query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to			query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Param			min_cell	query		int			false	"Smallest count to publish (default and minimum 5)"
+//	@Success		200			{object}	models.GeoFeatureCollection
+//	@Failure		400			{object}	fiber.Map	"Invalid level or date"
+//	@Failure		500			{object}	fiber.Map	"Server error when counting cases"
+//	@Router			/geo/cases.geojson [get]
+func (h *GeographyController) GetCasesGeoJSON(c *fiber.Ctx) error {
+	level := c.Query("level", models.AdminLevelDistrict)
+	if !slices.Contains(models.AdminLevels, level) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "level must be one of " + strings.Join(models.AdminLevels, ", "),
+		})
+	}
+	window, ok, err := caseWindow(c)
+	if !ok {
+		return err
+	}
+	counts, err := h.repo.GetAdminAreaCaseCounts(level, window)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to count cases", err))
+	}
+
+	minCell := max(c.QueryInt("min_cell", models.MinGeoCellSize), models.MinGeoCellSize)
+	return c.Status(fiber.StatusOK).JSON(service.CaseCountsGeoJSON(counts, level, minCell), "application/geo+json")
+}
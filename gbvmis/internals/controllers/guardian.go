@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type GuardianPayload struct {
+	FullName       string `json:"full_name" validate:"required"`
+	Relationship   string `json:"relationship" validate:"required"`
+	PhoneNumber    string `json:"phone_number"`
+	Address        string `json:"address"`
+	Nin            string `json:"nin"`
+	IsPrimary      bool   `json:"is_primary"`
+	LivesWithChild bool   `json:"lives_with_child"`
+	SuspectID      *uint  `json:"suspect_id"` // set when the guardian is also a suspect
+	Notes          string `json:"notes"`
+}
+
+type UpdateGuardianPayload struct {
+	FullName       string `json:"full_name"`
+	Relationship   string `json:"relationship"`
+	PhoneNumber    string `json:"phone_number"`
+	Address        string `json:"address"`
+	Nin            string `json:"nin"`
+	IsPrimary      *bool  `json:"is_primary"`
+	LivesWithChild *bool  `json:"lives_with_child"`
+	SuspectID      *uint  `json:"suspect_id"` // 0 clears it
+	Notes          string `json:"notes"`
+}
+
+// canSeeChildDetails reports whether the caller may see the child's full record
+func (h *VictimController) canSeeChildDetails(c *fiber.Ctx, victimID uint) (bool, error) {
+	claims := c.Locals("user").(*utils.Claims)
+	officer, err := h.repo.FindOfficerByID(claims.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	scopes, err := h.repo.GetVictimCaseScopes(victimID)
+	if err != nil {
+		return false, err
+	}
+	return service.CanSeeChildDetails(claims, officer, scopes), nil
+}
+
+// markMinors judges each victim's minor status at their cases' incident dates
+func (h *VictimController) markMinors(victims []models.Victim) error {
+	ids := make([]uint, len(victims))
+	for i, victim := range victims {
+		ids[i] = victim.ID
+	}
+	cases, err := h.repo.GetVictimCases(ids)
+	if err != nil {
+		return err
+	}
+	service.MarkMinors(victims, cases)
+	return nil
+}
+
+// redactMinorMatches redacts the duplicate matches who are or were children
+func (h *VictimController) redactMinorMatches(matches []service.VictimMatch) error {
+	ids := make([]uint, len(matches))
+	for i, match := range matches {
+		ids[i] = match.VictimID
+	}
+	cases, err := h.repo.GetVictimCases(ids)
+	if err != nil {
+		return err
+	}
+	service.RedactMinorMatches(matches, cases)
+	return nil
+}
+
+// redactMinorPairs redacts the children in each queued pair of duplicates
+func (h *VictimController) redactMinorPairs(duplicates []models.VictimDuplicate) error {
+	victims := make([]models.Victim, 0, 2*len(duplicates))
+	for _, duplicate := range duplicates {
+		victims = append(victims, duplicate.Victim, duplicate.Candidate)
+	}
+	if err := h.markMinors(victims); err != nil {
+		return err
+	}
+	service.RedactMinors(victims)
+	for i := range duplicates {
+		duplicates[i].Victim, duplicates[i].Candidate = victims[2*i], victims[2*i+1]
+	}
+	return nil
+}
+
+// redactMergeSnapshots redacts the snapshots of merged children, judged at
+// the incident dates of the cases of the records they were merged into
+func (h *VictimController) redactMergeSnapshots(merges []models.VictimMerge) error {
+	ids := make([]uint, len(merges))
+	for i, merge := range merges {
+		ids[i] = merge.KeptID
+	}
+	cases, err := h.repo.GetVictimCases(ids)
+	if err != nil {
+		return err
+	}
+	for i := range merges {
+		if merges[i].Snapshot, err = service.RedactVictimSnapshot(merges[i].Snapshot, cases[merges[i].KeptID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func invalidGuardianRelationship(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"status":  "error",
+		"message": "relationship must be one of " + strings.Join(models.GuardianRelationships, ", "),
+	})
+}
+
+// ================================
+
+// CreateGuardian godoc
+//
+//	@Summary		Record a guardian of a victim
+//	@Description	Records a parent or caregiver. Set suspect_id when the guardian is also a suspect so they are not counted as a safe contact.
+//	@Tags			Victims
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Victim ID"
+//	@Param			payload	body		GuardianPayload	true	"Guardian"
+//	@Success		201		{object}	fiber.Map		"Guardian recorded successfully"
+//	@Failure		400		{object}	fiber.Map		"Invalid input"
+//	@Failure		403		{object}	fiber.Map		"Not responsible for the child's cases"
+//	@Failure		404		{object}	fiber.Map		"Victim not found"
+//	@Failure		500		{object}	fiber.Map		"Server error when recording guardian"
+//	@Router			/victim/{id}/guardians [post]
+func (h *VictimController) CreateGuardian(c *fiber.Ctx) error {
+	var payload GuardianPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if !slices.Contains(models.GuardianRelationships, payload.Relationship) {
+		return invalidGuardianRelationship(c)
+	}
+
+	victim, ok, err := h.childFromParam(c)
+	if !ok {
+		return err
+	}
+
+	guardian := models.Guardian{
+		VictimID:       victim.ID,
+		FullName:       payload.FullName,
+		Relationship:   payload.Relationship,
+		PhoneNumber:    payload.PhoneNumber,
+		Address:        payload.Address,
+		Nin:            payload.Nin,
+		IsPrimary:      payload.IsPrimary,
+		LivesWithChild: payload.LivesWithChild,
+		SuspectID:      payload.SuspectID,
+		Notes:          payload.Notes,
+	}
+	if err := h.repo.CreateGuardian(&guardian); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record guardian", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Guardian recorded successfully", guardian))
+}
+
+// childFromParam loads the victim in the route and checks the caller may see
+// their full record
+func (h *VictimController) childFromParam(c *fiber.Ctx) (models.Victim, bool, error) {
+	victim, err := h.repo.GetVictimByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return victim, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Victim not found",
+			})
+		}
+		return victim, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victim", err))
+	}
+	ok, err := h.authorizeChild(c, victim)
+	return victim, ok, err
+}
+
+// authorizeChild lets the request through unless the victim is a child the
+// caller is not responsible for, writing the error response itself when not
+func (h *VictimController) authorizeChild(c *fiber.Ctx, victim models.Victim) (bool, error) {
+	victims := []models.Victim{victim}
+	if err := h.markMinors(victims); err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to check access to the victim", err))
+	}
+	if !victims[0].IsMinor {
+		return true, nil
+	}
+	allowed, err := h.canSeeChildDetails(c, victim.ID)
+	if err != nil {
+		return false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to check access to the victim", err))
+	}
+	if !allowed {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "You are not responsible for any of this child's cases",
+		})
+	}
+	return true, nil
+}
+
+// ================================
+
+// GetVictimGuardians godoc
+//
+//	@Summary		List a victim's guardians
+//	@Tags			Victims
+//	@Produce		json
+//	@Param			id	path		string		true	"Victim ID"
+//	@Success		200	{object}	fiber.Map	"Guardians retrieved successfully"
+//	@Failure		403	{object}	fiber.Map	"Not responsible for the child's cases"
+//	@Failure		404	{object}	fiber.Map	"Victim not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving guardians"
+//	@Router			/victim/{id}/guardians [get]
+func (h *VictimController) GetVictimGuardians(c *fiber.Ctx) error {
+	victim, ok, err := h.childFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Guardians retrieved successfully", victim.Guardians))
+}
+
+// ================================
+
+// UpdateGuardian godoc
+//
+//	@Summary		Update a guardian
+//	@Tags			Victims
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Guardian ID"
+//	@Param			payload	body		UpdateGuardianPayload	true	"Fields to update"
+//	@Success		200		{object}	fiber.Map				"Guardian updated successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		403		{object}	fiber.Map				"Not responsible for the child's cases"
+//	@Failure		404		{object}	fiber.Map				"Guardian not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when updating guardian"
+//	@Router			/guardian/{id} [put]
+func (h *VictimController) UpdateGuardian(c *fiber.Ctx) error {
+	guardian, ok, err := h.guardianFromParam(c)
+	if !ok {
+		return err
+	}
+
+	var payload UpdateGuardianPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	updates := make(map[string]interface{})
+	if payload.FullName != "" {
+		updates["full_name"] = payload.FullName
+	}
+	if payload.Relationship != "" {
+		if !slices.Contains(models.GuardianRelationships, payload.Relationship) {
+			return invalidGuardianRelationship(c)
+		}
+		updates["relationship"] = payload.Relationship
+	}
+	if payload.PhoneNumber != "" {
+		updates["phone_number"] = payload.PhoneNumber
+	}
+	if payload.Address != "" {
+		updates["address"] = payload.Address
+	}
+	if payload.Nin != "" {
+		updates["nin"] = payload.Nin
+	}
+	if payload.IsPrimary != nil {
+		updates["is_primary"] = *payload.IsPrimary
+	}
+	if payload.LivesWithChild != nil {
+		updates["lives_with_child"] = *payload.LivesWithChild
+	}
+	if payload.SuspectID != nil {
+		if *payload.SuspectID == 0 {
+			updates["suspect_id"] = nil
+		} else {
+			updates["suspect_id"] = *payload.SuspectID
+		}
+	}
+	if payload.Notes != "" {
+		updates["notes"] = payload.Notes
+	}
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "No fields to update",
+		})
+	}
+
+	if err := h.repo.UpdateGuardian(guardian.ID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update guardian", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Guardian updated successfully", updates))
+}
+
+func (h *VictimController) guardianFromParam(c *fiber.Ctx) (models.Guardian, bool, error) {
+	guardian, err := h.repo.GetGuardianByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return guardian, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Guardian not found",
+			})
+		}
+		return guardian, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve guardian", err))
+	}
+
+	victim, err := h.repo.GetVictimByID(strconv.Itoa(int(guardian.VictimID)))
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return guardian, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victim", err))
+	}
+	ok, err := h.authorizeChild(c, victim)
+	return guardian, ok, err
+}
+
+// ================================
+
+// DeleteGuardian godoc
+//
+//	@Summary		Delete a guardian
+//	@Tags			Victims
+//	@Produce		json
+//	@Param			id	path		string		true	"Guardian ID"
+//	@Success		200	{object}	fiber.Map	"Guardian deleted successfully"
+//	@Failure		403	{object}	fiber.Map	"Not responsible for the child's cases"
+//	@Failure		404	{object}	fiber.Map	"Guardian not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting guardian"
+//	@Router			/guardian/{id} [delete]
+func (h *VictimController) DeleteGuardian(c *fiber.Ctx) error {
+	guardian, ok, err := h.guardianFromParam(c)
+	if !ok {
+		return err
+	}
+	if err := h.repo.DeleteGuardian(guardian.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete guardian", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Guardian deleted successfully", guardian))
+}
+
+// ================================
+
+// GetAgeBandStats godoc
+//
+//	@Summary		Count victims by age band and gender
+//	@Description	Each victim is counted once per case, at their age when the case was opened. Bands: 0-4, 5-9, 10-14, 15-17, 18-24, 25-34, 35-49, 50+ and unknown.
+//	@Tags			Victims
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Cases of this police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Age band statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/victims/age-band-stats [get]
+func (h *VictimController) GetAgeBandStats(c *fiber.Ctx) error {
+	stats, err := h.repo.GetAgeBandStats(c)
+	if errors.Is(err, models.ErrStatsDate) {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid date filter; use YYYY-MM-DD", err))
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to compute age band statistics", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Age band statistics retrieved successfully", stats))
+}
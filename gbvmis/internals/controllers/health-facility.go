@@ -22,6 +22,12 @@ type CreateHealthFacilityPayload struct {
 	Name     string `json:"name" validate:"required"`
 	Location string `json:"location"`
 	Contact  string `json:"contact"`
+
+	AdminAreaID *uint `json:"admin_area_id"` // Administrative area the facility is in
+
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	GbvServices bool     `json:"gbv_services"` // Offers PEP, emergency contraception and forensic examination
 }
 
 type HealthFacilityResponse struct {
@@ -31,6 +37,12 @@ type HealthFacilityResponse struct {
 	Contact       string                       `json:"contact"`
 	Practitioners []HealthPractitionerResponse `json:"practitioners"`
 	CreatedAt     time.Time                    `json:"created_at"`
+
+	AdminAreaID *uint `json:"admin_area_id"`
+
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	GbvServices bool     `json:"gbv_services"`
 }
 
 type HealthPractitionerResponse struct {
@@ -60,6 +72,12 @@ func ConvertToHealthFacilityResponse(hf models.HealthFacility) HealthFacilityRes
 		Contact:       hf.Contact,
 		Practitioners: practitioners,
 		CreatedAt:     hf.CreatedAt,
+
+		AdminAreaID: hf.AdminAreaID,
+
+		Latitude:    hf.Latitude,
+		Longitude:   hf.Longitude,
+		GbvServices: hf.GbvServices,
 	}
 }
 
@@ -87,10 +105,23 @@ func (h *HealthFacilityController) CreateHealthFacility(c *fiber.Ctx) error {
 		})
 	}
 
+	if ok, err := checkAdminArea(c, "admin_area_id", payload.AdminAreaID, h.repo.FindAdminAreaByID); !ok {
+		return err
+	}
+	if ok, err := checkCoordinates(c, payload.Latitude, payload.Longitude); !ok {
+		return err
+	}
+
 	hf := &models.HealthFacility{
 		Name:     payload.Name,
 		Location: payload.Location,
 		Contact:  payload.Contact,
+
+		AdminAreaID: payload.AdminAreaID,
+
+		Latitude:    payload.Latitude,
+		Longitude:   payload.Longitude,
+		GbvServices: payload.GbvServices,
 	}
 
 	if err := h.repo.CreateHealthFacility(hf); err != nil {
@@ -199,6 +230,12 @@ type UpdateHealthFacilityPayload struct {
 	Name     string `json:"name"`
 	Location string `json:"location"`
 	Contact  string `json:"contact"`
+
+	AdminAreaID *uint `json:"admin_area_id"`
+
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	GbvServices *bool    `json:"gbv_services"`
 }
 
 // UpdateHealthFacility godoc
@@ -265,6 +302,19 @@ func (h *HealthFacilityController) UpdateHealthFacility(c *fiber.Ctx) error {
 	if payload.Contact != "" {
 		updates["contact"] = payload.Contact
 	}
+	if payload.AdminAreaID != nil {
+		if ok, err := checkAdminArea(c, "admin_area_id", payload.AdminAreaID, h.repo.FindAdminAreaByID); !ok {
+			return err
+		}
+		updates["admin_area_id"] = *payload.AdminAreaID
+	}
+	if ok, err := checkCoordinates(c, payload.Latitude, payload.Longitude); !ok {
+		return err
+	}
+	setCoordinates(updates, payload.Latitude, payload.Longitude)
+	if payload.GbvServices != nil {
+		updates["gbv_services"] = *payload.GbvServices
+	}
 
 	// Update the HealthFacility in the database
 	if err := h.repo.UpdateHealthFacility(id, updates); err != nil {
@@ -0,0 +1,447 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type IncidentController struct {
+	repo repository.IncidentRepository
+}
+
+func NewIncidentController(repo repository.IncidentRepository) *IncidentController {
+	return &IncidentController{repo: repo}
+}
+
+type IncidentPayload struct {
+	OccurredAt   string   `json:"occurred_at" validate:"required"` // RFC 3339, "YYYY-MM-DDTHH:MM" or just YYYY-MM-DD when the time is unknown
+	ReportedAt   string   `json:"reported_at"`                     // defaults to now
+	District     string   `json:"district"`                        // required unless admin_area_id is given
+	Subcounty    string   `json:"subcounty"`
+	Village      string   `json:"village"`
+	AdminAreaID  *uint    `json:"admin_area_id"` // most specific area known; its names replace the ones above
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+	Setting      string   `json:"setting" validate:"required"`
+	ViolenceType string   `json:"violence_type" validate:"required"`
+	Description  string   `json:"description"`
+}
+
+type UpdateIncidentPayload struct {
+	OccurredAt   string   `json:"occurred_at"`
+	ReportedAt   string   `json:"reported_at"`
+	District     string   `json:"district"`
+	Subcounty    string   `json:"subcounty"`
+	Village      string   `json:"village"`
+	AdminAreaID  *uint    `json:"admin_area_id"`
+	Latitude     *float64 `json:"latitude"`
+	Longitude    *float64 `json:"longitude"`
+	Setting      string   `json:"setting"`
+	ViolenceType string   `json:"violence_type"`
+	Description  string   `json:"description"`
+}
+
+// placeIncident fills the district, subcounty and village names from the
+// incident's administrative area, writing a 400 when the area is unknown or
+// no district is given either way
+func (h *IncidentController) placeIncident(c *fiber.Ctx, incident *models.Incident) (bool, error) {
+	if incident.AdminAreaID != nil {
+		path, err := h.repo.GetAdminAreaPath(*incident.AdminAreaID)
+		if err != nil {
+			return false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve administrative area", err))
+		}
+		if len(path) == 0 {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "admin_area_id is not a known administrative area",
+			})
+		}
+		names := service.AdminAreaNames(path)
+		if name, ok := names[models.AdminLevelDistrict]; ok {
+			incident.District = name
+		}
+		if name, ok := names[models.AdminLevelSubcounty]; ok {
+			incident.Subcounty = name
+		}
+		if name, ok := names[models.AdminLevelVillage]; ok {
+			incident.Village = name
+		}
+	}
+	if incident.District == "" {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "district or admin_area_id is required",
+		})
+	}
+	return true, nil
+}
+
+// parseIncidentTime accepts a full timestamp or a bare date, reporting
+// whether the time of day was given
+func parseIncidentTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	return t, false, err
+}
+
+// validateIncident checks the classification, coordinates and dates and
+// returns a message when something is wrong
+func validateIncident(incident models.Incident) string {
+	if !slices.Contains(models.ViolenceTypes, incident.ViolenceType) {
+		return "violence_type must be one of " + strings.Join(models.ViolenceTypes, ", ")
+	}
+	if !slices.Contains(models.IncidentSettings, incident.Setting) {
+		return "setting must be one of " + strings.Join(models.IncidentSettings, ", ")
+	}
+	if msg := service.CheckCoordinates(incident.Latitude, incident.Longitude); msg != "" {
+		return msg
+	}
+	if incident.OccurredAt.After(time.Now()) {
+		return "occurred_at cannot be in the future"
+	}
+	if incident.ReportedAt.Before(incident.OccurredAt) {
+		return "reported_at cannot be before occurred_at"
+	}
+	return ""
+}
+
+// ================================
+
+// CreateIncident godoc
+//
+//	@Summary		Record an incident in a case
+//	@Description	Records when and where violence happened and classifies it. A case may have several incidents; the earliest is the case's incident date, used for victims' ages.
+//	@Tags			Incidents
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string			true	"Case ID"
+//	@Param			payload	body		IncidentPayload	true	"Incident"
+//	@Success		201		{object}	fiber.Map		"Incident recorded successfully"
+//	@Failure		400		{object}	fiber.Map		"Invalid input"
+//	@Failure		404		{object}	fiber.Map		"Case not found"
+//	@Failure		500		{object}	fiber.Map		"Server error when recording incident"
+//	@Router			/case/{id}/incidents [post]
+func (h *IncidentController) CreateIncident(c *fiber.Ctx) error {
+	var payload IncidentPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	casee, err := h.repo.GetCaseByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+
+	occurredAt, timeKnown, err := parseIncidentTime(payload.OccurredAt)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid occurred_at; use RFC 3339 or YYYY-MM-DD", err))
+	}
+	reportedAt := time.Now()
+	if payload.ReportedAt != "" {
+		if reportedAt, _, err = parseIncidentTime(payload.ReportedAt); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid reported_at; use RFC 3339 or YYYY-MM-DD", err))
+		}
+	}
+
+	incident := models.Incident{
+		CaseID:       casee.ID,
+		OccurredAt:   occurredAt,
+		TimeKnown:    timeKnown,
+		ReportedAt:   reportedAt,
+		District:     payload.District,
+		Subcounty:    payload.Subcounty,
+		Village:      payload.Village,
+		AdminAreaID:  payload.AdminAreaID,
+		Latitude:     payload.Latitude,
+		Longitude:    payload.Longitude,
+		Setting:      payload.Setting,
+		ViolenceType: payload.ViolenceType,
+		Description:  payload.Description,
+		RecordedByID: c.Locals("user").(*utils.Claims).UserID,
+	}
+	if msg := validateIncident(incident); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+	if ok, err := h.placeIncident(c, &incident); !ok {
+		return err
+	}
+
+	if err := h.repo.CreateIncident(&incident); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record incident", err))
+	}
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Incident recorded successfully", incident))
+}
+
+// ================================
+
+// GetCaseIncidents godoc
+//
+//	@Summary		List a case's incidents
+//	@Tags			Incidents
+//	@Produce		json
+//	@Param			id	path		string		true	"Case ID"
+//	@Success		200	{object}	fiber.Map	"Incidents retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Case not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving incidents"
+//	@Router			/case/{id}/incidents [get]
+func (h *IncidentController) GetCaseIncidents(c *fiber.Ctx) error {
+	casee, err := h.repo.GetCaseByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+
+	incidents, err := h.repo.GetCaseIncidents(casee.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve incidents", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Incidents retrieved successfully", incidents))
+}
+
+// ================================
+
+// GetAllIncidents godoc
+//
+//	@Summary		Search incidents
+//	@Tags			Incidents
+//	@Produce		json
+//	@Param			violence_type	query		string		false	"physical, sexual, emotional, economic, fgm or early_marriage"
+//	@Param			setting			query		string		false	"home, school, workplace, public_place, institution, online or other"
+//	@Param			district		query		string		false	"Part of the district"
+//	@Param			subcounty		query		string		false	"Part of the subcounty"
+//	@Param			village			query		string		false	"Part of the village"
+//	@Param			police_post_id	query		int			false	"Incidents in cases of this police post"
+//	@Param			occurred_from	query		string		false	"Occurred on or after (YYYY-MM-DD)"
+//	@Param			occurred_to		query		string		false	"Occurred on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Incidents retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve incidents"
+//	@Router			/incidents [get]
+func (h *IncidentController) GetAllIncidents(c *fiber.Ctx) error {
+	pagination, incidents, err := h.repo.GetPaginatedIncidents(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve incidents", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Incidents retrieved successfully",
+		"data":    incidents,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetIncidentStats godoc
+//
+//	@Summary		Count incidents by violence type and setting
+//	@Description	Accepts the same filters as the incident search.
+//	@Tags			Incidents
+//	@Produce		json
+//	@Param			violence_type	query		string		false	"Limit to one violence type"
+//	@Param			setting			query		string		false	"Limit to one setting"
+//	@Param			district		query		string		false	"Part of the district"
+//	@Param			police_post_id	query		int			false	"Incidents in cases of this police post"
+//	@Param			occurred_from	query		string		false	"Occurred on or after (YYYY-MM-DD)"
+//	@Param			occurred_to		query		string		false	"Occurred on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Incident statistics retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/incidents/stats [get]
+func (h *IncidentController) GetIncidentStats(c *fiber.Ctx) error {
+	stats, err := h.repo.GetIncidentStats(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to compute incident statistics", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Incident statistics retrieved successfully", stats))
+}
+
+// ================================
+
+// GetSingleIncident godoc
+//
+//	@Summary		Retrieve an incident
+//	@Tags			Incidents
+//	@Produce		json
+//	@Param			id	path		string		true	"Incident ID"
+//	@Success		200	{object}	fiber.Map	"Incident retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Incident not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving incident"
+//	@Router			/incident/{id} [get]
+func (h *IncidentController) GetSingleIncident(c *fiber.Ctx) error {
+	incident, ok, err := h.incidentFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Incident retrieved successfully", incident))
+}
+
+func (h *IncidentController) incidentFromParam(c *fiber.Ctx) (models.Incident, bool, error) {
+	incident, err := h.repo.GetIncidentByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return incident, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Incident not found",
+			})
+		}
+		return incident, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve incident", err))
+	}
+	return incident, true, nil
+}
+
+// ================================
+
+// UpdateIncident godoc
+//
+//	@Summary		Update an incident
+//	@Tags			Incidents
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Incident ID"
+//	@Param			payload	body		UpdateIncidentPayload	true	"Fields to update"
+//	@Success		200		{object}	fiber.Map				"Incident updated successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Incident not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when updating incident"
+//	@Router			/incident/{id} [put]
+func (h *IncidentController) UpdateIncident(c *fiber.Ctx) error {
+	incident, ok, err := h.incidentFromParam(c)
+	if !ok {
+		return err
+	}
+
+	var payload UpdateIncidentPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	// Apply the changes to a copy so the result can be validated as a whole
+	updates := make(map[string]interface{})
+	if payload.OccurredAt != "" {
+		occurredAt, timeKnown, err := parseIncidentTime(payload.OccurredAt)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid occurred_at; use RFC 3339 or YYYY-MM-DD", err))
+		}
+		incident.OccurredAt, incident.TimeKnown = occurredAt, timeKnown
+		updates["occurred_at"], updates["time_known"] = occurredAt, timeKnown
+	}
+	if payload.ReportedAt != "" {
+		reportedAt, _, err := parseIncidentTime(payload.ReportedAt)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid reported_at; use RFC 3339 or YYYY-MM-DD", err))
+		}
+		incident.ReportedAt = reportedAt
+		updates["reported_at"] = reportedAt
+	}
+	if payload.District != "" {
+		incident.District = payload.District
+		updates["district"] = payload.District
+	}
+	if payload.Subcounty != "" {
+		incident.Subcounty = payload.Subcounty
+		updates["subcounty"] = payload.Subcounty
+	}
+	if payload.Village != "" {
+		incident.Village = payload.Village
+		updates["village"] = payload.Village
+	}
+	if payload.AdminAreaID != nil {
+		incident.AdminAreaID = payload.AdminAreaID
+		updates["admin_area_id"] = *payload.AdminAreaID
+	}
+	if payload.Latitude != nil || payload.Longitude != nil {
+		incident.Latitude, incident.Longitude = payload.Latitude, payload.Longitude
+		updates["latitude"], updates["longitude"] = payload.Latitude, payload.Longitude
+	}
+	if payload.Setting != "" {
+		incident.Setting = payload.Setting
+		updates["setting"] = payload.Setting
+	}
+	if payload.ViolenceType != "" {
+		incident.ViolenceType = payload.ViolenceType
+		updates["violence_type"] = payload.ViolenceType
+	}
+	if payload.Description != "" {
+		updates["description"] = payload.Description
+	}
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "No fields to update",
+		})
+	}
+	if msg := validateIncident(incident); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+	if payload.AdminAreaID != nil {
+		if ok, err := h.placeIncident(c, &incident); !ok {
+			return err
+		}
+		updates["district"], updates["subcounty"], updates["village"] = incident.District, incident.Subcounty, incident.Village
+	}
+
+	if err := h.repo.UpdateIncident(incident.ID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update incident", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Incident updated successfully", updates))
+}
+
+// ================================
+
+// DeleteIncident godoc
+//
+//	@Summary		Delete an incident
+//	@Tags			Incidents
+//	@Produce		json
+//	@Param			id	path		string		true	"Incident ID"
+//	@Success		200	{object}	fiber.Map	"Incident deleted successfully"
+//	@Failure		404	{object}	fiber.Map	"Incident not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting incident"
+//	@Router			/incident/{id} [delete]
+func (h *IncidentController) DeleteIncident(c *fiber.Ctx) error {
+	incident, ok, err := h.incidentFromParam(c)
+	if !ok {
+		return err
+	}
+	if err := h.repo.DeleteIncident(incident.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete incident", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Incident deleted successfully", incident))
+}
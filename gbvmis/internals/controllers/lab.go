@@ -0,0 +1,834 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type LabController struct {
+	repo repository.LabRepository
+}
+
+func NewLabController(repo repository.LabRepository) *LabController {
+	return &LabController{repo: repo}
+}
+
+type LabSpecimenPayload struct {
+	SpecimenType string    `json:"specimen_type" validate:"required"`
+	BodyRegion   string    `json:"body_region"` // body map code, see GET /findings/codes
+	CollectedAt  time.Time `json:"collected_at"`
+	SealNumber   string    `json:"seal_number" validate:"required"`
+	ExhibitID    *uint     `json:"exhibit_id"` // an exhibit already registered; otherwise one is registered
+	Reseal       bool      `json:"reseal"`     // the exhibit was resealed under seal_number for the lab
+	Notes        string    `json:"notes"`
+}
+
+type CreateLabRequestPayload struct {
+	Analyses      []string             `json:"analyses" validate:"required,min=1"`
+	Urgent        bool                 `json:"urgent"`
+	ClinicalNotes string               `json:"clinical_notes"`
+	Specimens     []LabSpecimenPayload `json:"specimens" validate:"required,min=1,dive"`
+}
+
+type DispatchLabRequestPayload struct {
+	LabName             string    `json:"lab_name" validate:"required"`
+	LabReference        string    `json:"lab_reference"`
+	DispatchedAt        time.Time `json:"dispatched_at"`
+	ReleasedBySignature string    `json:"released_by_signature" validate:"required"`
+	ReceivedBySignature string    `json:"received_by_signature" validate:"required"`
+	SealsIntact         *bool     `json:"seals_intact" validate:"required"` // as handed over
+	Note                string    `json:"note"`
+}
+
+type LabStatusPayload struct {
+	Status       string `json:"status" validate:"required"` // received, in_analysis, rejected or cancelled
+	Note         string `json:"note"`
+	LabReference string `json:"lab_reference"`
+	SealsIntact  *bool  `json:"seals_intact"` // required on receipt
+}
+
+type LabResultPayload struct {
+	SpecimenID     *uint  `json:"specimen_id"`
+	Analysis       string `json:"analysis" validate:"required"`
+	Outcome        string `json:"outcome" validate:"required"`
+	Value          string `json:"value"`
+	Unit           string `json:"unit"`
+	Interpretation string `json:"interpretation"`
+}
+
+type LabResultsPayload struct {
+	AnalystName string             `json:"analyst_name"`
+	ReportedAt  time.Time          `json:"reported_at"`
+	Results     []LabResultPayload `json:"results" validate:"required,min=1,dive"`
+	Note        string             `json:"note"`
+}
+
+// validateLabRequest checks the analyses and specimens of a new request and
+// returns a message when something is wrong
+func validateLabRequest(payload CreateLabRequestPayload) string {
+	for i, analysis := range payload.Analyses {
+		if !slices.Contains(models.LabAnalyses, analysis) {
+			return "analyses must be from " + strings.Join(models.LabAnalyses, ", ")
+		}
+		if slices.Contains(payload.Analyses[:i], analysis) {
+			return analysis + " is requested twice"
+		}
+	}
+	seals := make(map[string]bool)
+	exhibits := make(map[uint]bool)
+	for _, specimen := range payload.Specimens {
+		if !slices.Contains(models.LabSpecimenTypes, specimen.SpecimenType) {
+			return "specimen_type must be one of " + strings.Join(models.LabSpecimenTypes, ", ")
+		}
+		if specimen.BodyRegion != "" {
+			if _, ok := models.FindBodyRegion(specimen.BodyRegion); !ok {
+				return "body_region must be a code from the body map"
+			}
+		}
+		if specimen.CollectedAt.After(time.Now()) {
+			return "collected_at cannot be in the future"
+		}
+		if seals[specimen.SealNumber] {
+			return "seal number " + specimen.SealNumber + " is used twice"
+		}
+		seals[specimen.SealNumber] = true
+		if specimen.ExhibitID != nil {
+			if exhibits[*specimen.ExhibitID] {
+				return fmt.Sprintf("exhibit %d is sent twice", *specimen.ExhibitID)
+			}
+			exhibits[*specimen.ExhibitID] = true
+		}
+	}
+	return ""
+}
+
+// createLabRequest records a request for the case, registering specimens
+// that are not yet exhibits in the exhibit register
+func (h *LabController) createLabRequest(c *fiber.Ctx, caseID uint, examinationID *uint) error {
+	var payload CreateLabRequestPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if msg := validateLabRequest(payload); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+
+	user := c.Locals("user").(*utils.Claims)
+	now := time.Now()
+	request := models.LabRequest{
+		CaseID:        caseID,
+		ExaminationID: examinationID,
+		Analyses:      payload.Analyses,
+		Urgent:        payload.Urgent,
+		ClinicalNotes: payload.ClinicalNotes,
+		Status:        models.LabStatusRequested,
+		RequestedByID: user.UserID,
+		RequestedAt:   now,
+	}
+
+	var officer *models.PoliceOfficer
+	for _, specimen := range payload.Specimens {
+		collectedAt := specimen.CollectedAt
+		if collectedAt.IsZero() {
+			collectedAt = now
+		}
+		record := models.LabSpecimen{
+			SpecimenType:  specimen.SpecimenType,
+			BodyRegion:    specimen.BodyRegion,
+			CollectedAt:   collectedAt,
+			CollectedByID: user.UserID,
+			SealNumber:    specimen.SealNumber,
+			Notes:         specimen.Notes,
+		}
+
+		if specimen.ExhibitID != nil {
+			exhibit, err := h.repo.FindExhibitByID(*specimen.ExhibitID)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid exhibit ID", err))
+			}
+			if exhibit.CaseID != caseID {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  "error",
+					"message": "Exhibit " + exhibit.ExhibitNumber + " belongs to another case",
+				})
+			}
+			if exhibit.HolderType != models.HolderOfficer {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"status":  "error",
+					"message": "Exhibit " + exhibit.ExhibitNumber + " is not held by an officer",
+				})
+			}
+			if exhibit.SealNumber != specimen.SealNumber && !specimen.Reseal {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  "error",
+					"message": "Exhibit " + exhibit.ExhibitNumber + " is under seal " + exhibit.SealNumber + "; give that seal_number, or set reseal to record a new seal",
+				})
+			}
+			record.ExhibitID = exhibit.ID
+		} else if officer == nil {
+			found, err := h.repo.FindOfficerByID(user.UserID)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Unknown officer", err))
+			}
+			officer = &found
+		}
+		request.Specimens = append(request.Specimens, record)
+	}
+
+	err := h.repo.CreateLabRequest(&request, func(request models.LabRequest, specimen models.LabSpecimen, n int) models.Exhibit {
+		return models.Exhibit{
+			CaseID:          request.CaseID,
+			ExhibitNumber:   fmt.Sprintf("LAB%d-%d", request.ID, n),
+			Description:     strings.ReplaceAll(specimen.SpecimenType, "_", " ") + fmt.Sprintf(" for lab request %d", request.ID),
+			Category:        models.LabExhibitCategory,
+			SealNumber:      specimen.SealNumber,
+			CollectedAt:     specimen.CollectedAt,
+			CollectedByID:   officer.ID,
+			HolderType:      models.HolderOfficer,
+			HolderName:      officer.FirstName + " " + officer.LastName,
+			HolderOfficerID: &officer.ID,
+		}
+	}, func(request models.LabRequest, specimen models.LabSpecimen, exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error) {
+		// The exhibit may have been resealed since it was checked
+		if !slices.ContainsFunc(payload.Specimens, func(p LabSpecimenPayload) bool {
+			return p.Reseal && p.ExhibitID != nil && *p.ExhibitID == exhibit.ID
+		}) {
+			return models.ExhibitTransfer{}, models.ErrLabExhibitNotHeld
+		}
+		t := models.ExhibitTransfer{
+			ExhibitID:     exhibit.ID,
+			Sequence:      1,
+			TransferredAt: now,
+			Purpose:       fmt.Sprintf("Resealed for lab request %d", request.ID),
+			FromType:      exhibit.HolderType,
+			FromName:      exhibit.HolderName,
+			FromOfficerID: exhibit.HolderOfficerID,
+			ToType:        exhibit.HolderType,
+			ToName:        exhibit.HolderName,
+			ToOfficerID:   exhibit.HolderOfficerID,
+			SealNumber:    specimen.SealNumber,
+			SealIntact:    true,
+			RecordedByID:  user.UserID,
+			Notes:         "Seal " + exhibit.SealNumber + " replaced by " + specimen.SealNumber,
+		}
+		if last != nil {
+			t.Sequence = last.Sequence + 1
+			t.PrevHash = last.Hash
+		}
+		t.Hash = service.ExhibitTransferHash(t)
+		return t, nil
+	})
+	if err != nil {
+		return labUpdateError(c, err, "Failed to create lab request")
+	}
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Lab request created successfully", request))
+}
+
+func (h *LabController) labRequestFromParam(c *fiber.Ctx) (models.LabRequest, bool, error) {
+	request, err := h.repo.GetLabRequestByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return request, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Lab request not found",
+			})
+		}
+		return request, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve lab request", err))
+	}
+	return request, true, nil
+}
+
+// checkLabTransition writes a 409 when the request cannot move to status
+func checkLabTransition(c *fiber.Ctx, request models.LabRequest, status string) (bool, error) {
+	if slices.Contains(models.LabTransitions[request.Status], status) {
+		return true, nil
+	}
+	return false, c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"status":  "error",
+		"message": "A " + request.Status + " lab request cannot be marked " + status,
+	})
+}
+
+// labUpdateError writes the response for a lab request change that failed:
+// a 409 when the request or one of its exhibits moved on in the meantime
+func labUpdateError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, models.ErrLabStatusChanged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "The lab request was updated by someone else; reload it and try again",
+		})
+	case errors.Is(err, models.ErrLabExhibitInUse), errors.Is(err, models.ErrLabExhibitNotHeld):
+		return c.Status(fiber.StatusConflict).JSON(utils.ErrorResponse(message, err))
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse(message, err))
+}
+
+// checkLabDates writes a 400 when the from or to filter is not a date
+func checkLabDates(c *fiber.Ctx) (bool, error) {
+	for _, name := range []string{"from", "to"} {
+		if v := c.Query(name); v != "" {
+			if _, err := utils.ParseDate(v); err != nil {
+				return false, c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid "+name+" date; use YYYY-MM-DD", err))
+			}
+		}
+	}
+	return true, nil
+}
+
+// respondLabRequest reloads the request and returns it
+func (h *LabController) respondLabRequest(c *fiber.Ctx, message string) error {
+	request, ok, err := h.labRequestFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse(message, request))
+}
+
+// ================================
+
+// GetLabCodes godoc
+//
+//	@Summary		List the coded values for lab requests
+//	@Tags			Lab Requests
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Lab codes retrieved successfully"
+//	@Router			/lab-requests/codes [get]
+func (h *LabController) GetLabCodes(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Lab codes retrieved successfully", fiber.Map{
+		"specimen_types": models.LabSpecimenTypes,
+		"analyses":       models.LabAnalyses,
+		"outcomes":       models.LabOutcomes,
+		"transitions":    models.LabTransitions,
+	}))
+}
+
+// ================================
+
+// CreateCaseLabRequest godoc
+//
+//	@Summary		Request lab analysis of specimens from a case
+//	@Description	Each specimen is tied to the exhibit register: give exhibit_id for an exhibit already registered in the case, or leave it out to register the specimen as a new exhibit held by the requesting officer. A registered exhibit must be held by an officer, not be in another open request, and carry the specimen's seal_number unless reseal is set, which records the new seal in its chain of custody.
+//	@Tags			Lab Requests
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Case ID"
+//	@Param			payload	body		CreateLabRequestPayload	true	"Analyses and specimens"
+//	@Success		201		{object}	fiber.Map				"Lab request created successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Case not found"
+//	@Failure		409		{object}	fiber.Map				"Exhibit not with an officer or already in an open request"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating lab request"
+//	@Router			/case/{id}/lab-requests [post]
+func (h *LabController) CreateCaseLabRequest(c *fiber.Ctx) error {
+	casee, err := h.repo.GetCaseByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+	return h.createLabRequest(c, casee.ID, nil)
+}
+
+// ================================
+
+// CreateExaminationLabRequest godoc
+//
+//	@Summary		Request lab analysis of specimens taken at an examination
+//	@Description	The request belongs to the examination's case. Specimens are tied to the exhibit register as for case requests.
+//	@Tags			Lab Requests
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Examination ID"
+//	@Param			payload	body		CreateLabRequestPayload	true	"Analyses and specimens"
+//	@Success		201		{object}	fiber.Map				"Lab request created successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Examination not found"
+//	@Failure		409		{object}	fiber.Map				"Exhibit not with an officer or already in an open request"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating lab request"
+//	@Router			/examination/{id}/lab-requests [post]
+func (h *LabController) CreateExaminationLabRequest(c *fiber.Ctx) error {
+	examination, err := h.repo.GetExaminationByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Examination not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve examination", err))
+	}
+	return h.createLabRequest(c, examination.CaseID, &examination.ID)
+}
+
+// ================================
+
+// GetCaseLabRequests godoc
+//
+//	@Summary		List a case's lab requests
+//	@Tags			Lab Requests
+//	@Produce		json
+//	@Param			id	path		string		true	"Case ID"
+//	@Success		200	{object}	fiber.Map	"Lab requests retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Case not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving lab requests"
+//	@Router			/case/{id}/lab-requests [get]
+func (h *LabController) GetCaseLabRequests(c *fiber.Ctx) error {
+	casee, err := h.repo.GetCaseByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+	return h.listLabRequestsFor(c, "case_id", casee.ID)
+}
+
+// ================================
+
+// GetExaminationLabRequests godoc
+//
+//	@Summary		List the lab requests made at an examination
+//	@Tags			Lab Requests
+//	@Produce		json
+//	@Param			id	path		string		true	"Examination ID"
+//	@Success		200	{object}	fiber.Map	"Lab requests retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Examination not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving lab requests"
+//	@Router			/examination/{id}/lab-requests [get]
+func (h *LabController) GetExaminationLabRequests(c *fiber.Ctx) error {
+	examination, err := h.repo.GetExaminationByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Examination not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve examination", err))
+	}
+	return h.listLabRequestsFor(c, "examination_id", examination.ID)
+}
+
+func (h *LabController) listLabRequestsFor(c *fiber.Ctx, column string, id uint) error {
+	requests, err := h.repo.GetLabRequestsFor(column, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve lab requests", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Lab requests retrieved successfully", requests))
+}
+
+// ================================
+
+// GetAllLabRequests godoc
+//
+//	@Summary		Search lab requests
+//	@Tags			Lab Requests
+//	@Produce		json
+//	@Param			status			query		string		false	"requested, dispatched, received, in_analysis, resulted, rejected or cancelled"
+//	@Param			lab_name		query		string		false	"Part of the lab's name"
+//	@Param			analysis		query		string		false	"Requests including this analysis"
+//	@Param			urgent			query		bool		false	"Only urgent (or only routine) requests"
+//	@Param			case_id			query		int			false	"Requests in this case"
+//	@Param			examination_id	query		int			false	"Requests from this examination"
+//	@Param			from			query		string		false	"Requested on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Requested on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Lab requests retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve lab requests"
+//	@Router			/lab-requests [get]
+func (h *LabController) GetAllLabRequests(c *fiber.Ctx) error {
+	if ok, err := checkLabDates(c); !ok {
+		return err
+	}
+	pagination, requests, err := h.repo.GetPaginatedLabRequests(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve lab requests", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Lab requests retrieved successfully",
+		"data":    requests,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetSingleLabRequest godoc
+//
+//	@Summary		Retrieve a lab request
+//	@Description	Returns the request with its specimens, results and status history.
+//	@Tags			Lab Requests
+//	@Produce		json
+//	@Param			id	path		string		true	"Lab request ID"
+//	@Success		200	{object}	fiber.Map	"Lab request retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Lab request not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving lab request"
+//	@Router			/lab-request/{id} [get]
+func (h *LabController) GetSingleLabRequest(c *fiber.Ctx) error {
+	return h.respondLabRequest(c, "Lab request retrieved successfully")
+}
+
+// ================================
+
+// DispatchLabRequest godoc
+//
+//	@Summary		Dispatch a lab request to a laboratory
+//	@Description	Marks the request dispatched and records a transfer of every specimen's exhibit to the lab in its chain of custody, with the state of the seals as handed over. Every exhibit must still be with an officer under the specimen's seal, and both the releasing officer and the lab must sign.
+//	@Tags			Lab Requests
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"Lab request ID"
+//	@Param			payload	body		DispatchLabRequestPayload	true	"Lab and hand-over details"
+//	@Success		200		{object}	fiber.Map					"Lab request dispatched"
+//	@Failure		400		{object}	fiber.Map					"Invalid input"
+//	@Failure		404		{object}	fiber.Map					"Lab request not found"
+//	@Failure		409		{object}	fiber.Map					"Lab request already dispatched or closed, or an exhibit has moved"
+//	@Failure		500		{object}	fiber.Map					"Server error when dispatching lab request"
+//	@Router			/lab-request/{id}/dispatch [post]
+func (h *LabController) DispatchLabRequest(c *fiber.Ctx) error {
+	var payload DispatchLabRequestPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	request, ok, err := h.labRequestFromParam(c)
+	if !ok {
+		return err
+	}
+	if ok, err := checkLabTransition(c, request, models.LabStatusDispatched); !ok {
+		return err
+	}
+
+	dispatchedAt := payload.DispatchedAt
+	if dispatchedAt.IsZero() {
+		dispatchedAt = time.Now()
+	}
+	if dispatchedAt.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "dispatched_at cannot be in the future",
+		})
+	}
+
+	userID := c.Locals("user").(*utils.Claims).UserID
+	updates := map[string]interface{}{
+		"status":        models.LabStatusDispatched,
+		"lab_name":      payload.LabName,
+		"lab_reference": payload.LabReference,
+		"dispatched_at": dispatchedAt,
+	}
+	update := models.LabRequestUpdate{
+		FromStatus:  request.Status,
+		ToStatus:    models.LabStatusDispatched,
+		Note:        payload.Note,
+		UpdatedByID: userID,
+	}
+	err = h.repo.DispatchLabRequest(request, updates, &update, func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error) {
+		i := slices.IndexFunc(request.Specimens, func(s models.LabSpecimen) bool { return s.ExhibitID == exhibit.ID })
+		if i < 0 || exhibit.HolderType != models.HolderOfficer || exhibit.SealNumber != request.Specimens[i].SealNumber {
+			return models.ExhibitTransfer{}, models.ErrLabExhibitNotHeld
+		}
+		t := models.ExhibitTransfer{
+			ExhibitID:           exhibit.ID,
+			Sequence:            1,
+			TransferredAt:       dispatchedAt,
+			Purpose:             fmt.Sprintf("Analysis (lab request %d)", request.ID),
+			FromType:            exhibit.HolderType,
+			FromName:            exhibit.HolderName,
+			FromOfficerID:       exhibit.HolderOfficerID,
+			ToType:              models.HolderLab,
+			ToName:              payload.LabName,
+			SealNumber:          exhibit.SealNumber,
+			SealIntact:          *payload.SealsIntact,
+			ReleasedBySignature: payload.ReleasedBySignature,
+			ReceivedBySignature: payload.ReceivedBySignature,
+			RecordedByID:        userID,
+			Notes:               payload.Note,
+		}
+		if last != nil {
+			t.Sequence = last.Sequence + 1
+			t.PrevHash = last.Hash
+		}
+		t.Hash = service.ExhibitTransferHash(t)
+		return t, nil
+	})
+	if err != nil {
+		return labUpdateError(c, err, "Failed to dispatch lab request")
+	}
+	return h.respondLabRequest(c, "Lab request dispatched")
+}
+
+// ================================
+
+// UpdateLabRequestStatus godoc
+//
+//	@Summary		Move a lab request to its next status
+//	@Description	Allowed moves: dispatched to received or rejected; received to in_analysis or rejected; requested to cancelled. Use the dispatch and results endpoints to dispatch or result a request. On receipt seals_intact is required and is recorded in every specimen's chain of custody.
+//	@Tags			Lab Requests
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Lab request ID"
+//	@Param			payload	body		LabStatusPayload	true	"New status"
+//	@Success		200		{object}	fiber.Map			"Lab request status updated"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Lab request not found"
+//	@Failure		409		{object}	fiber.Map			"Status change not allowed"
+//	@Failure		500		{object}	fiber.Map			"Server error when updating lab request"
+//	@Router			/lab-request/{id}/status [post]
+func (h *LabController) UpdateLabRequestStatus(c *fiber.Ctx) error {
+	var payload LabStatusPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if payload.Status == models.LabStatusDispatched || payload.Status == models.LabStatusResulted {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Use the dispatch or results endpoint to mark a lab request " + payload.Status,
+		})
+	}
+
+	request, ok, err := h.labRequestFromParam(c)
+	if !ok {
+		return err
+	}
+	if ok, err := checkLabTransition(c, request, payload.Status); !ok {
+		return err
+	}
+
+	if payload.Status == models.LabStatusReceived && payload.SealsIntact == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "seals_intact is required when the lab receives the request",
+		})
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": payload.Status}
+	switch payload.Status {
+	case models.LabStatusReceived:
+		updates["received_at"] = now
+		updates["seals_intact"] = *payload.SealsIntact
+	case models.LabStatusInAnalysis:
+		updates["analysis_started_at"] = now
+	case models.LabStatusRejected, models.LabStatusCancelled:
+		updates["closed_at"] = now
+	}
+	if payload.LabReference != "" {
+		updates["lab_reference"] = payload.LabReference
+	}
+
+	userID := c.Locals("user").(*utils.Claims).UserID
+	update := models.LabRequestUpdate{
+		FromStatus:  request.Status,
+		ToStatus:    payload.Status,
+		Note:        payload.Note,
+		UpdatedByID: userID,
+	}
+	if payload.Status == models.LabStatusReceived {
+		err = h.repo.ReceiveLabRequest(request, updates, &update, func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error) {
+			t := models.ExhibitTransfer{
+				ExhibitID:     exhibit.ID,
+				Sequence:      1,
+				TransferredAt: now,
+				Purpose:       fmt.Sprintf("Received by the lab (lab request %d)", request.ID),
+				FromType:      exhibit.HolderType,
+				FromName:      exhibit.HolderName,
+				FromOfficerID: exhibit.HolderOfficerID,
+				ToType:        models.HolderLab,
+				ToName:        request.LabName,
+				SealNumber:    exhibit.SealNumber,
+				SealIntact:    *payload.SealsIntact,
+				RecordedByID:  userID,
+				Notes:         payload.Note,
+			}
+			if last != nil {
+				t.Sequence = last.Sequence + 1
+				t.PrevHash = last.Hash
+			}
+			t.Hash = service.ExhibitTransferHash(t)
+			return t, nil
+		})
+	} else {
+		err = h.repo.UpdateLabRequestStatus(request, updates, &update)
+	}
+	if err != nil {
+		return labUpdateError(c, err, "Failed to update lab request")
+	}
+	return h.respondLabRequest(c, "Lab request status updated")
+}
+
+// ================================
+
+// RecordLabResults godoc
+//
+//	@Summary		Record the results of a lab request
+//	@Description	Every requested analysis needs at least one result; use not_tested when an analysis could not be done. specimen_id, when given, must be one of the request's specimens. Marks the request resulted.
+//	@Tags			Lab Requests
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Lab request ID"
+//	@Param			payload	body		LabResultsPayload	true	"Results"
+//	@Success		200		{object}	fiber.Map			"Lab results recorded"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Lab request not found"
+//	@Failure		409		{object}	fiber.Map			"Lab request not received by the lab"
+//	@Failure		500		{object}	fiber.Map			"Server error when recording results"
+//	@Router			/lab-request/{id}/results [post]
+func (h *LabController) RecordLabResults(c *fiber.Ctx) error {
+	var payload LabResultsPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	request, ok, err := h.labRequestFromParam(c)
+	if !ok {
+		return err
+	}
+	if ok, err := checkLabTransition(c, request, models.LabStatusResulted); !ok {
+		return err
+	}
+
+	reportedAt := payload.ReportedAt
+	if reportedAt.IsZero() {
+		reportedAt = time.Now()
+	}
+	userID := c.Locals("user").(*utils.Claims).UserID
+
+	var results []models.LabResult
+	for _, result := range payload.Results {
+		if !slices.Contains([]string(request.Analyses), result.Analysis) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": result.Analysis + " was not requested",
+			})
+		}
+		if !slices.Contains(models.LabOutcomes, result.Outcome) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "outcome must be one of " + strings.Join(models.LabOutcomes, ", "),
+			})
+		}
+		if result.SpecimenID != nil && !slices.ContainsFunc(request.Specimens, func(s models.LabSpecimen) bool { return s.ID == *result.SpecimenID }) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "specimen_id must be one of the request's specimens",
+			})
+		}
+		results = append(results, models.LabResult{
+			LabRequestID:   request.ID,
+			SpecimenID:     result.SpecimenID,
+			Analysis:       result.Analysis,
+			Outcome:        result.Outcome,
+			Value:          result.Value,
+			Unit:           result.Unit,
+			Interpretation: result.Interpretation,
+			AnalystName:    payload.AnalystName,
+			ReportedAt:     reportedAt,
+			RecordedByID:   userID,
+		})
+	}
+	for _, analysis := range request.Analyses {
+		if !slices.ContainsFunc(results, func(r models.LabResult) bool { return r.Analysis == analysis }) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "No result given for " + analysis,
+			})
+		}
+	}
+
+	updates := map[string]interface{}{
+		"status":      models.LabStatusResulted,
+		"resulted_at": reportedAt,
+	}
+	update := models.LabRequestUpdate{
+		FromStatus:  request.Status,
+		ToStatus:    models.LabStatusResulted,
+		Note:        payload.Note,
+		UpdatedByID: userID,
+	}
+	if err := h.repo.RecordLabResults(request, results, updates, &update); err != nil {
+		return labUpdateError(c, err, "Failed to record lab results")
+	}
+	return h.respondLabRequest(c, "Lab results recorded")
+}
+
+// ================================
+
+// GetLabTurnaround godoc
+//
+//	@Summary		Lab turnaround times
+//	@Description	For each lab: requests, how many are resulted, still open or rejected, and the median hours from request to dispatch and days in transit, in the lab and from request to result. Requests not yet dispatched have an empty lab_name. Accepts the same filters as the lab request search.
+//	@Tags			Lab Requests
+//	@Produce		json
+//	@Param			lab_name	query		string		false	"Part of the lab's name"
+//	@Param			analysis	query		string		false	"Requests including this analysis"
+//	@Param			urgent		query		bool		false	"Only urgent (or only routine) requests"
+//	@Param			from		query		string		false	"Requested on or after (YYYY-MM-DD)"
+//	@Param			to			query		string		false	"Requested on or before (YYYY-MM-DD)"
+//	@Success		200			{object}	fiber.Map	"Lab turnaround report generated"
+//	@Failure		400			{object}	fiber.Map	"Invalid date"
+//	@Failure		500			{object}	fiber.Map	"Server error when generating report"
+//	@Router			/lab-requests/turnaround [get]
+func (h *LabController) GetLabTurnaround(c *fiber.Ctx) error {
+	if ok, err := checkLabDates(c); !ok {
+		return err
+	}
+	report, err := h.repo.GetLabTurnaround(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to generate lab turnaround report", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Lab turnaround report generated", report))
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type NotificationController struct {
+	repo repository.NotificationRepository
+}
+
+func NewNotificationController(repo repository.NotificationRepository) *NotificationController {
+	return &NotificationController{repo: repo}
+}
+
+// ================================
+
+// GetMyNotifications godoc
+//
+//	@Summary		List notifications for the current officer
+//	@Description	Returns notifications addressed to the officer or to their police post, newest first.
+//	@Tags			Notifications
+//	@Accept			json
+//	@Produce		json
+//	@Param			unread	query		bool		false	"Only unread notifications"
+//	@Param			type	query		string		false	"Notification type, e.g. watchlist_hit"
+//	@Success		200		{object}	fiber.Map	"Notifications retrieved successfully"
+//	@Failure		403		{object}	fiber.Map	"Unknown officer"
+//	@Failure		500		{object}	fiber.Map	"Failed to retrieve notifications"
+//	@Router			/notifications [get]
+func (h *NotificationController) GetMyNotifications(c *fiber.Ctx) error {
+	claims := c.Locals("user").(*utils.Claims)
+	officer, err := h.repo.FindOfficerByID(claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Unknown officer",
+		})
+	}
+
+	pagination, notifications, err := h.repo.GetPaginatedNotifications(c, officer.ID, officer.PostID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve notifications", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Notifications retrieved successfully",
+		"data":    notifications,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// MarkNotificationRead godoc
+//
+//	@Summary		Mark a notification as read
+//	@Tags			Notifications
+//	@Produce		json
+//	@Param			id	path		string		true	"Notification ID"
+//	@Success		200	{object}	fiber.Map	"Notification marked as read"
+//	@Failure		403	{object}	fiber.Map	"Notification is not addressed to this officer"
+//	@Failure		404	{object}	fiber.Map	"Notification not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when updating notification"
+//	@Router			/notification/{id}/read [post]
+func (h *NotificationController) MarkNotificationRead(c *fiber.Ctx) error {
+	notification, err := h.repo.GetNotificationByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Notification not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve notification", err))
+	}
+
+	claims := c.Locals("user").(*utils.Claims)
+	officer, err := h.repo.FindOfficerByID(claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Unknown officer",
+		})
+	}
+	addressed := (notification.OfficerID != nil && *notification.OfficerID == officer.ID) ||
+		(notification.PolicePostID != nil && *notification.PolicePostID == officer.PostID)
+	if !addressed {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Notification is not addressed to you",
+		})
+	}
+
+	if err := h.repo.MarkRead(notification.ID, time.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update notification", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Notification marked as read", nil))
+}
@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gbvmis/internals/config"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/storage"
+	"gbvmis/internals/utils"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type Pf3Controller struct {
+	repo  repository.Pf3Repository
+	store storage.BlobStore
+}
+
+func NewPf3Controller(repo repository.Pf3Repository, store storage.BlobStore) *Pf3Controller {
+	return &Pf3Controller{repo: repo, store: store}
+}
+
+type GeneratePf3Payload struct {
+	Form string `json:"form"` // pf3 (default) or pf3a
+}
+
+type Pf3TemplatePayload struct {
+	Form   string `json:"form" validate:"required"` // pf3 or pf3a
+	Body   string `json:"body" validate:"required"` // see the markup described in service/pf3Render.go
+	Active *bool  `json:"active"`                   // defaults to true
+}
+
+type UpdatePf3TemplatePayload struct {
+	Active *bool `json:"active" validate:"required"`
+}
+
+// pf3PublicBase is the address printed in verification links:
+// PUBLIC_BASE_URL, or the address the request came in on
+func pf3PublicBase(c *fiber.Ctx) string {
+	if base := config.Config("PUBLIC_BASE_URL"); base != "" {
+		return base
+	}
+	return c.BaseURL()
+}
+
+// checkPf3Form writes a 400 unless form is a known form
+func checkPf3Form(c *fiber.Ctx, form string) (bool, error) {
+	if !slices.Contains(models.Pf3Forms, form) {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "form must be one of " + strings.Join(models.Pf3Forms, ", "),
+		})
+	}
+	return true, nil
+}
+
+// examinationFromParam loads the examination named by the :id parameter,
+// writing a 404 or 500 when it cannot
+func (h *Pf3Controller) examinationFromParam(c *fiber.Ctx) (models.Examination, bool, error) {
+	examination, err := h.repo.GetExaminationForPf3(c.Params("id"))
+	if err == nil && examination.DeletedAt.Valid {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return examination, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Examination not found",
+			})
+		}
+		return examination, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve examination", err))
+	}
+	return examination, true, nil
+}
+
+// ================================
+
+// GeneratePf3 godoc
+//
+//	@Summary		Issue a PF3 or PF3A form for an examination
+//	@Description	Renders the examination, its victim, case, practitioner and facility onto the form's active template as a PDF. Each page carries a QR code linking to the public verification page, a verification code, the SHA-256 digest of the printed incident, victim and examination values and its HMAC signature, all of which are stored with the examination. Requires the survivor's consent to the examination.
+//	@Tags			PF3
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Examination ID"
+//	@Param			payload	body		GeneratePf3Payload	false	"Form"
+//	@Success		201		{object}	fiber.Map			"PF3 issued successfully"
+//	@Failure		400		{object}	fiber.Map			"Unknown form or no consent recorded"
+//	@Failure		404		{object}	fiber.Map			"Examination not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when issuing the form"
+//	@Failure		503		{object}	fiber.Map			"Signing is not configured"
+//	@Router			/examination/{id}/pf3 [post]
+func (h *Pf3Controller) GeneratePf3(c *fiber.Ctx) error {
+	var payload GeneratePf3Payload
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&payload); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+		}
+	}
+	if payload.Form == "" {
+		payload.Form = models.FormPF3
+	}
+	if ok, err := checkPf3Form(c, payload.Form); !ok {
+		return err
+	}
+
+	examination, ok, err := h.examinationFromParam(c)
+	if !ok {
+		return err
+	}
+	if !examination.ConsentGiven {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "The survivor's consent to the examination has not been recorded",
+		})
+	}
+
+	userID := c.Locals("user").(*utils.Claims).UserID
+	document, err := service.GeneratePf3(c.Context(), h.repo, h.store, examination, payload.Form, userID, pf3PublicBase(c))
+	if err != nil {
+		if errors.Is(err, service.ErrPf3SigningNotConfigured) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(utils.ErrorResponse("Cannot issue PF3", err))
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to issue PF3", err))
+	}
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("PF3 issued successfully", document))
+}
+
+// ================================
+
+// GetExaminationPf3s godoc
+//
+//	@Summary		List the forms issued for an examination
+//	@Tags			PF3
+//	@Produce		json
+//	@Param			id	path		string		true	"Examination ID"
+//	@Success		200	{object}	fiber.Map	"PF3 forms retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Examination not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving the forms"
+//	@Router			/examination/{id}/pf3 [get]
+func (h *Pf3Controller) GetExaminationPf3s(c *fiber.Ctx) error {
+	examination, ok, err := h.examinationFromParam(c)
+	if !ok {
+		return err
+	}
+	documents, err := h.repo.GetPf3Documents(examination.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve PF3 forms", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("PF3 forms retrieved successfully", documents))
+}
+
+// ================================
+
+// DownloadPf3 godoc
+//
+//	@Summary		Download an issued form
+//	@Tags			PF3
+//	@Produce		application/pdf
+//	@Param			id	path		string		true	"PF3 document ID"
+//	@Success		200	{file}		binary		"PDF"
+//	@Failure		404	{object}	fiber.Map	"PF3 not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when reading the form"
+//	@Router			/pf3/{id}/download [get]
+func (h *Pf3Controller) DownloadPf3(c *fiber.Ctx) error {
+	document, err := h.repo.GetPf3DocumentByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "PF3 not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve PF3", err))
+	}
+
+	content, err := h.store.Get(c.Context(), document.BlobSHA256)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "PF3 content is missing from storage",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to read PF3", err))
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", document.FileName))
+	return c.SendStream(content, int(document.Size))
+}
+
+// ================================
+
+// VerifyPf3Document godoc
+//
+//	@Summary		Verify an issued form
+//	@Description	Public: the QR code on every form links here. Reports whether the signature is genuine and whether the examination record still matches what was printed, with the case number, exam date, facility and practitioner but not the person examined. Pass the SHA-256 of a PDF copy as sha256 to check it is the file issued.
+//	@Tags			PF3
+//	@Produce		json
+//	@Param			code	path		string		true	"Verification code"
+//	@Param			sha256	query		string		false	"SHA-256 of a PDF copy"
+//	@Success		200		{object}	fiber.Map	"Verification result"
+//	@Failure		404		{object}	fiber.Map	"Unknown verification code"
+//	@Failure		500		{object}	fiber.Map	"Server error when verifying"
+//	@Router			/pf3/verify/{code} [get]
+func VerifyPf3Document(db *gorm.DB) fiber.Handler {
+	repo := repository.Pf3DbService(db)
+	return func(c *fiber.Ctx) error {
+		document, err := repo.GetPf3DocumentByCode(strings.ToUpper(c.Params("code")))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status":  "error",
+					"message": "No form was issued with this verification code",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to verify the form",
+			})
+		}
+
+		verification, err := service.VerifyPf3(repo, document, c.Query("sha256"))
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to verify the form",
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Verification complete", verification))
+	}
+}
+
+// ================================
+
+// GetPf3Templates godoc
+//
+//	@Summary		List form templates
+//	@Description	Lists uploaded template versions, newest first, followed by the built-in template of each form (version 0), which applies when no uploaded version is active.
+//	@Tags			PF3
+//	@Produce		json
+//	@Param			form	query		string		false	"pf3 or pf3a"
+//	@Success		200		{object}	fiber.Map	"PF3 templates retrieved successfully"
+//	@Failure		500		{object}	fiber.Map	"Failed to retrieve PF3 templates"
+//	@Router			/pf3/templates [get]
+func (h *Pf3Controller) GetPf3Templates(c *fiber.Ctx) error {
+	templates, err := h.repo.GetPf3Templates(c.Query("form"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve PF3 templates", err))
+	}
+	for _, form := range models.Pf3Forms {
+		if c.Query("form") == "" || c.Query("form") == form {
+			templates = append(templates, service.BuiltinPf3Template(form))
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("PF3 templates retrieved successfully", templates))
+}
+
+// ================================
+
+// CreatePf3Template godoc
+//
+//	@Summary		Upload a new version of a form template
+//	@Description	The body is a Go text/template over the form data, laid out line by line: "# " and "## " headings, "### " section bands, "Label:: value" fields, "---" rules, "___ " signature lines and paragraphs. The template is checked by rendering it with sample data. It becomes the form's next version and, if active, is used for new forms. Administrators only.
+//	@Tags			PF3
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		Pf3TemplatePayload	true	"Template"
+//	@Success		201		{object}	fiber.Map			"PF3 template created successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid template"
+//	@Failure		403		{object}	fiber.Map			"Not an administrator"
+//	@Failure		500		{object}	fiber.Map			"Server error when saving the template"
+//	@Router			/pf3/templates [post]
+func (h *Pf3Controller) CreatePf3Template(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "change PF3 templates"); !ok {
+		return err
+	}
+
+	var payload Pf3TemplatePayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if ok, err := checkPf3Form(c, payload.Form); !ok {
+		return err
+	}
+	if err := service.CheckPf3Template(payload.Form, payload.Body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid template", err))
+	}
+
+	template := models.Pf3Template{
+		Form:        payload.Form,
+		Body:        payload.Body,
+		Active:      payload.Active == nil || *payload.Active,
+		CreatedByID: c.Locals("user").(*utils.Claims).UserID,
+	}
+	if err := h.repo.CreatePf3Template(&template); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to save PF3 template", err))
+	}
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("PF3 template created successfully", template))
+}
+
+// ================================
+
+// PreviewPf3Template godoc
+//
+//	@Summary		Preview a form template
+//	@Description	Renders the template with sample data and returns the PDF, without saving anything. Administrators only.
+//	@Tags			PF3
+//	@Accept			json
+//	@Produce		application/pdf
+//	@Param			payload	body		Pf3TemplatePayload	true	"Template"
+//	@Success		200		{file}		binary				"PDF"
+//	@Failure		400		{object}	fiber.Map			"Invalid template"
+//	@Failure		403		{object}	fiber.Map			"Not an administrator"
+//	@Router			/pf3/templates/preview [post]
+func (h *Pf3Controller) PreviewPf3Template(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "change PF3 templates"); !ok {
+		return err
+	}
+
+	var payload Pf3TemplatePayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if ok, err := checkPf3Form(c, payload.Form); !ok {
+		return err
+	}
+
+	code := "SAMPLE0000000000"
+	content, err := service.RenderPf3(models.Pf3Template{Form: payload.Form, Body: payload.Body}, service.SamplePf3Data(payload.Form), service.Pf3Footer{
+		VerifyURL:        service.Pf3VerifyURL(pf3PublicBase(c), code),
+		VerificationCode: code,
+		ContentSHA256:    strings.Repeat("0", 64),
+		Signature:        strings.Repeat("0", 64),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid template", err))
+	}
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	return c.Status(fiber.StatusOK).Send(content)
+}
+
+// ================================
+
+// GetSinglePf3Template godoc
+//
+//	@Summary		Retrieve a form template version
+//	@Tags			PF3
+//	@Produce		json
+//	@Param			id	path		string		true	"PF3 template ID"
+//	@Success		200	{object}	fiber.Map	"PF3 template retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"PF3 template not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving the template"
+//	@Router			/pf3/template/{id} [get]
+func (h *Pf3Controller) GetSinglePf3Template(c *fiber.Ctx) error {
+	template, err := h.repo.GetPf3TemplateByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "PF3 template not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve PF3 template", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("PF3 template retrieved successfully", template))
+}
+
+// ================================
+
+// UpdatePf3Template godoc
+//
+//	@Summary		Activate or retire a form template version
+//	@Description	Template bodies cannot be edited, since issued forms record the version they used; upload a new version instead. Deactivating the newest version falls back to the previous active one. Administrators only.
+//	@Tags			PF3
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"PF3 template ID"
+//	@Param			payload	body		UpdatePf3TemplatePayload	true	"Active flag"
+//	@Success		200		{object}	fiber.Map					"PF3 template updated successfully"
+//	@Failure		400		{object}	fiber.Map					"Invalid input"
+//	@Failure		403		{object}	fiber.Map					"Not an administrator"
+//	@Failure		404		{object}	fiber.Map					"PF3 template not found"
+//	@Failure		500		{object}	fiber.Map					"Server error when updating the template"
+//	@Router			/pf3/template/{id} [put]
+func (h *Pf3Controller) UpdatePf3Template(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "change PF3 templates"); !ok {
+		return err
+	}
+
+	var payload UpdatePf3TemplatePayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	template, err := h.repo.GetPf3TemplateByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "PF3 template not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve PF3 template", err))
+	}
+	if err := h.repo.SetPf3TemplateActive(template.ID, *payload.Active); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update PF3 template", err))
+	}
+	template.Active = *payload.Active
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("PF3 template updated successfully", template))
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"gbvmis/internals/models"
 	"gbvmis/internals/repository"
+	"gbvmis/internals/utils"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -22,6 +23,13 @@ type CreatePolicePostPayload struct {
 	Name     string `json:"name" validate:"required"`
 	Location string `json:"location"`
 	Contact  string `json:"contact"`
+
+	Code        string `json:"code"`
+	StationID   *uint  `json:"station_id"`    // Police unit at station level
+	AdminAreaID *uint  `json:"admin_area_id"` // Administrative area the post is in
+
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
 }
 
 type PolicePostResponse struct {
@@ -31,6 +39,13 @@ type PolicePostResponse struct {
 	Contact   string                      `json:"contact"`
 	Officers  []PoliceOfficerPostResponse `json:"officers"`
 	CreatedAt time.Time                   `json:"created_at"`
+
+	Code        string `json:"code"`
+	StationID   *uint  `json:"station_id"`
+	AdminAreaID *uint  `json:"admin_area_id"`
+
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
 }
 
 type PoliceOfficerPostResponse struct {
@@ -66,7 +81,32 @@ func ConvertToPolicePostResponse(post models.PolicePost) PolicePostResponse {
 		Contact:   post.Contact,
 		Officers:  officers,
 		CreatedAt: post.CreatedAt,
+
+		Code:        post.Code,
+		StationID:   post.StationID,
+		AdminAreaID: post.AdminAreaID,
+
+		Latitude:  post.Latitude,
+		Longitude: post.Longitude,
+	}
+}
+
+// checkPlacement checks the station and administrative area a post is being
+// placed in, writing a 400 when either is unknown
+func (h *PolicePostController) checkPlacement(c *fiber.Ctx, stationID, adminAreaID *uint) (bool, error) {
+	if stationID != nil {
+		unit, err := h.repo.FindPoliceUnitByID(*stationID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve station", err))
+		}
+		if err != nil || unit.Level != models.PoliceLevelStation {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "station_id is not a known police station",
+			})
+		}
 	}
+	return checkAdminArea(c, "admin_area_id", adminAreaID, h.repo.FindAdminAreaByID)
 }
 
 // ================================
@@ -93,10 +133,24 @@ func (h *PolicePostController) CreatePolicePost(c *fiber.Ctx) error {
 		})
 	}
 
+	if ok, err := h.checkPlacement(c, payload.StationID, payload.AdminAreaID); !ok {
+		return err
+	}
+	if ok, err := checkCoordinates(c, payload.Latitude, payload.Longitude); !ok {
+		return err
+	}
+
 	post := &models.PolicePost{
 		Name:     payload.Name,
 		Location: payload.Location,
 		Contact:  payload.Contact,
+
+		Code:        payload.Code,
+		StationID:   payload.StationID,
+		AdminAreaID: payload.AdminAreaID,
+
+		Latitude:  payload.Latitude,
+		Longitude: payload.Longitude,
 	}
 
 	if err := h.repo.CreatePolicePost(post); err != nil {
@@ -206,6 +260,13 @@ type UpdatePolicePostPayload struct {
 	Name     string `json:"name"`
 	Location string `json:"location"`
 	Contact  string `json:"contact"`
+
+	Code        string `json:"code"`
+	StationID   *uint  `json:"station_id"`
+	AdminAreaID *uint  `json:"admin_area_id"`
+
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
 }
 
 // UpdatePolicePost godoc
@@ -272,6 +333,22 @@ func (h *PolicePostController) UpdatePolicePost(c *fiber.Ctx) error {
 	if payload.Contact != "" {
 		updates["contact"] = payload.Contact
 	}
+	if ok, err := h.checkPlacement(c, payload.StationID, payload.AdminAreaID); !ok {
+		return err
+	}
+	if payload.Code != "" {
+		updates["code"] = payload.Code
+	}
+	if payload.StationID != nil {
+		updates["station_id"] = *payload.StationID
+	}
+	if payload.AdminAreaID != nil {
+		updates["admin_area_id"] = *payload.AdminAreaID
+	}
+	if ok, err := checkCoordinates(c, payload.Latitude, payload.Longitude); !ok {
+		return err
+	}
+	setCoordinates(updates, payload.Latitude, payload.Longitude)
 
 	// Update the PolicePost in the database
 	if err := h.repo.UpdatePolicePost(id, updates); err != nil {
@@ -0,0 +1,650 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type ProtectionOrderController struct {
+	repo repository.ProtectionOrderRepository
+}
+
+func NewProtectionOrderController(repo repository.ProtectionOrderRepository) *ProtectionOrderController {
+	return &ProtectionOrderController{repo: repo}
+}
+
+type CreateProtectionOrderPayload struct {
+	CaseID              uint     `json:"case_id" validate:"required"`
+	VictimID            uint     `json:"victim_id" validate:"required"`
+	RespondentSuspectID uint     `json:"respondent_suspect_id" validate:"required"`
+	OrderType           string   `json:"order_type" validate:"required"`
+	OrderNumber         string   `json:"order_number" validate:"required"`
+	IssuingCourt        string   `json:"issuing_court" validate:"required"`
+	IssuedOn            string   `json:"issued_on" validate:"required"` // YYYY-MM-DD
+	ValidFrom           string   `json:"valid_from"`                    // YYYY-MM-DD, defaults to issued_on
+	ValidUntil          string   `json:"valid_until"`                   // YYYY-MM-DD, empty until further order
+	Terms               []string `json:"terms" validate:"required,min=1"`
+}
+
+type UpdateProtectionOrderPayload struct {
+	OrderNumber  string   `json:"order_number"`
+	IssuingCourt string   `json:"issuing_court"`
+	ValidUntil   string   `json:"valid_until"` // YYYY-MM-DD, e.g. when the court extends the order
+	Terms        []string `json:"terms"`
+}
+
+type RevokeProtectionOrderPayload struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+type ReportBreachPayload struct {
+	OccurredAt  time.Time `json:"occurred_at"` // defaults to now
+	Location    string    `json:"location"`
+	Description string    `json:"description" validate:"required"`
+}
+
+type SafetyPlanPayload struct {
+	CaseID            *uint    `json:"case_id"`
+	SafePlace         string   `json:"safe_place"`
+	EmergencyContacts []string `json:"emergency_contacts"`
+	CodeWord          string   `json:"code_word"`
+	Measures          []string `json:"measures"`
+	ReviewDate        string   `json:"review_date"` // YYYY-MM-DD
+	Notes             string   `json:"notes"`
+}
+
+type ProtectionOrderResponse struct {
+	ID                  uint       `json:"id"`
+	CaseID              uint       `json:"case_id"`
+	CaseNumber          string     `json:"case_number"`
+	VictimID            uint       `json:"victim_id"`
+	VictimName          string     `json:"victim_name"`
+	RespondentSuspectID uint       `json:"respondent_suspect_id"`
+	RespondentName      string     `json:"respondent_name"`
+	OrderType           string     `json:"order_type"`
+	OrderNumber         string     `json:"order_number"`
+	IssuingCourt        string     `json:"issuing_court"`
+	IssuedOn            time.Time  `json:"issued_on"`
+	ValidFrom           time.Time  `json:"valid_from"`
+	ValidUntil          *time.Time `json:"valid_until"`
+	Terms               []string   `json:"terms"`
+	Status              string     `json:"status"`
+	InForce             bool       `json:"in_force"`
+	RevokedAt           *time.Time `json:"revoked_at"`
+	RevokeReason        string     `json:"revoke_reason"`
+
+	Breaches []models.ProtectionOrderBreach `json:"breaches"`
+}
+
+// ConvertToProtectionOrderResponse shows a child victim by initials only, as
+// CaseResponse does
+func ConvertToProtectionOrderResponse(o models.ProtectionOrder) ProtectionOrderResponse {
+	victimName := strings.TrimSpace(o.Victim.FirstName + " " + o.Victim.LastName)
+	if service.IsMinorInAnyCase(o.Victim, []models.Case{o.Case}) {
+		victimName = service.RedactedName(victimName)
+	}
+	return ProtectionOrderResponse{
+		ID:                  o.ID,
+		CaseID:              o.CaseID,
+		CaseNumber:          o.Case.CaseNumber,
+		VictimID:            o.VictimID,
+		VictimName:          victimName,
+		RespondentSuspectID: o.RespondentSuspectID,
+		RespondentName:      strings.TrimSpace(o.RespondentSuspect.FirstName + " " + o.RespondentSuspect.LastName),
+		OrderType:           o.OrderType,
+		OrderNumber:         o.OrderNumber,
+		IssuingCourt:        o.IssuingCourt,
+		IssuedOn:            o.IssuedOn,
+		ValidFrom:           o.ValidFrom,
+		ValidUntil:          o.ValidUntil,
+		Terms:               o.Terms,
+		Status:              o.Status,
+		InForce:             service.ProtectionOrderInForce(o, time.Now()),
+		RevokedAt:           o.RevokedAt,
+		RevokeReason:        o.RevokeReason,
+		Breaches:            o.Breaches,
+	}
+}
+
+// parseOptionalDate parses a YYYY-MM-DD value, returning nil for an empty one
+func parseOptionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ================================
+
+// CreateProtectionOrder godoc
+//
+//	@Summary		Record a protection or exclusion order
+//	@Description	Records a court order under the Domestic Violence Act. The victim and the respondent suspect must both be linked to the case.
+//	@Tags			Protection Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateProtectionOrderPayload	true	"Protection order"
+//	@Success		201		{object}	fiber.Map						"Protection order recorded successfully"
+//	@Failure		400		{object}	fiber.Map						"Invalid input"
+//	@Failure		404		{object}	fiber.Map						"Case not found"
+//	@Failure		500		{object}	fiber.Map						"Server error when recording order"
+//	@Router			/protection-order [post]
+func (h *ProtectionOrderController) CreateProtectionOrder(c *fiber.Ctx) error {
+	var payload CreateProtectionOrderPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if !slices.Contains(models.ProtectionOrderTypes, payload.OrderType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "order_type must be one of " + strings.Join(models.ProtectionOrderTypes, ", "),
+		})
+	}
+
+	issuedOn, err := time.Parse("2006-01-02", payload.IssuedOn)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid issued_on, expected YYYY-MM-DD", err))
+	}
+	validFrom := issuedOn
+	if payload.ValidFrom != "" {
+		if validFrom, err = time.Parse("2006-01-02", payload.ValidFrom); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid valid_from, expected YYYY-MM-DD", err))
+		}
+	}
+	validUntil, err := parseOptionalDate(payload.ValidUntil)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid valid_until, expected YYYY-MM-DD", err))
+	}
+	if validUntil != nil && validUntil.Before(validFrom) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "valid_until cannot be before valid_from",
+		})
+	}
+
+	casee, err := h.repo.GetCaseByID(strconv.Itoa(int(payload.CaseID)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+	if !slices.ContainsFunc(casee.Victims, func(v models.Victim) bool { return v.ID == payload.VictimID }) ||
+		!slices.ContainsFunc(casee.Suspects, func(s models.Suspect) bool { return s.ID == payload.RespondentSuspectID }) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "The victim and the respondent suspect must both be linked to the case",
+		})
+	}
+
+	order := models.ProtectionOrder{
+		CaseID:              casee.ID,
+		VictimID:            payload.VictimID,
+		RespondentSuspectID: payload.RespondentSuspectID,
+		OrderType:           payload.OrderType,
+		OrderNumber:         payload.OrderNumber,
+		IssuingCourt:        payload.IssuingCourt,
+		IssuedOn:            issuedOn,
+		ValidFrom:           validFrom,
+		ValidUntil:          validUntil,
+		Terms:               payload.Terms,
+		Status:              models.OrderStatusActive,
+		RecordedByID:        c.Locals("user").(*utils.Claims).UserID,
+	}
+	if validUntil != nil && time.Now().After(validUntil.AddDate(0, 0, 1)) {
+		order.Status = models.OrderStatusExpired
+	}
+
+	if err := h.repo.CreateOrder(&order); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record protection order", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Protection order recorded successfully", order))
+}
+
+// ================================
+
+// GetAllProtectionOrders godoc
+//
+//	@Summary		List protection orders
+//	@Tags			Protection Orders
+//	@Produce		json
+//	@Param			case_id			query		int			false	"Orders made in this case"
+//	@Param			victim_id		query		int			false	"Orders protecting this victim"
+//	@Param			suspect_id		query		int			false	"Orders against this respondent suspect"
+//	@Param			status			query		string		false	"active, expired or revoked"
+//	@Param			issuing_court	query		string		false	"Part of the issuing court's name"
+//	@Success		200				{object}	fiber.Map	"Protection orders retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve protection orders"
+//	@Router			/protection-orders [get]
+func (h *ProtectionOrderController) GetAllProtectionOrders(c *fiber.Ctx) error {
+	pagination, orders, err := h.repo.GetPaginatedOrders(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve protection orders", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Protection orders retrieved successfully",
+		"data":    orders,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetSingleProtectionOrder godoc
+//
+//	@Summary		Retrieve a protection order with its breaches
+//	@Tags			Protection Orders
+//	@Produce		json
+//	@Param			id	path		string		true	"Protection order ID"
+//	@Success		200	{object}	fiber.Map	"Protection order retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Protection order not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving order"
+//	@Router			/protection-order/{id} [get]
+func (h *ProtectionOrderController) GetSingleProtectionOrder(c *fiber.Ctx) error {
+	order, ok, err := h.orderFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Protection order retrieved successfully", ConvertToProtectionOrderResponse(order)))
+}
+
+func (h *ProtectionOrderController) orderFromParam(c *fiber.Ctx) (models.ProtectionOrder, bool, error) {
+	order, err := h.repo.GetOrderByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return order, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Protection order not found",
+			})
+		}
+		return order, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve protection order", err))
+	}
+	return order, true, nil
+}
+
+// ================================
+
+// UpdateProtectionOrder godoc
+//
+//	@Summary		Update a protection order
+//	@Description	Records a variation by the court, such as an extension or changed terms. Extending an expired order makes it active again.
+//	@Tags			Protection Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Protection order ID"
+//	@Param			payload	body		UpdateProtectionOrderPayload	true	"Fields to update"
+//	@Success		200		{object}	fiber.Map						"Protection order updated successfully"
+//	@Failure		400		{object}	fiber.Map						"Invalid input"
+//	@Failure		404		{object}	fiber.Map						"Protection order not found"
+//	@Failure		409		{object}	fiber.Map						"Order has been revoked"
+//	@Failure		500		{object}	fiber.Map						"Server error when updating order"
+//	@Router			/protection-order/{id} [put]
+func (h *ProtectionOrderController) UpdateProtectionOrder(c *fiber.Ctx) error {
+	order, ok, err := h.orderFromParam(c)
+	if !ok {
+		return err
+	}
+	if order.Status == models.OrderStatusRevoked {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Protection order has been revoked",
+		})
+	}
+
+	var payload UpdateProtectionOrderPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	updates := map[string]interface{}{}
+	if payload.OrderNumber != "" {
+		updates["order_number"] = payload.OrderNumber
+	}
+	if payload.IssuingCourt != "" {
+		updates["issuing_court"] = payload.IssuingCourt
+	}
+	if len(payload.Terms) > 0 {
+		updates["terms"] = datatypes.JSONSlice[string](payload.Terms)
+	}
+	if payload.ValidUntil != "" {
+		validUntil, err := parseOptionalDate(payload.ValidUntil)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid valid_until, expected YYYY-MM-DD", err))
+		}
+		if validUntil.Before(order.ValidFrom) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "valid_until cannot be before valid_from",
+			})
+		}
+		updates["valid_until"] = *validUntil
+		if !time.Now().After(validUntil.AddDate(0, 0, 1)) {
+			updates["status"] = models.OrderStatusActive
+		}
+	}
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Empty request body",
+		})
+	}
+
+	if err := h.repo.UpdateOrder(order.ID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update protection order", err))
+	}
+
+	order, ok, err = h.orderFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Protection order updated successfully", ConvertToProtectionOrderResponse(order)))
+}
+
+// ================================
+
+// RevokeProtectionOrder godoc
+//
+//	@Summary		Record that a court revoked a protection order
+//	@Tags			Protection Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Protection order ID"
+//	@Param			payload	body		RevokeProtectionOrderPayload	true	"Reason"
+//	@Success		200		{object}	fiber.Map						"Protection order revoked"
+//	@Failure		400		{object}	fiber.Map						"Invalid input"
+//	@Failure		404		{object}	fiber.Map						"Protection order not found"
+//	@Failure		409		{object}	fiber.Map						"Order already revoked"
+//	@Failure		500		{object}	fiber.Map						"Server error when revoking order"
+//	@Router			/protection-order/{id}/revoke [post]
+func (h *ProtectionOrderController) RevokeProtectionOrder(c *fiber.Ctx) error {
+	var payload RevokeProtectionOrderPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	order, ok, err := h.orderFromParam(c)
+	if !ok {
+		return err
+	}
+	if order.Status == models.OrderStatusRevoked {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Protection order is already revoked",
+		})
+	}
+
+	if err := h.repo.RevokeOrder(order.ID, payload.Reason, time.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to revoke protection order", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Protection order revoked", nil))
+}
+
+// ================================
+
+// ReportProtectionOrderBreach godoc
+//
+//	@Summary		Report a breach of a protection order
+//	@Description	Opens a new case for the breach, linked to the order's case through parent_case_id, with the same victim, respondent, relationship, officer and risk. The investigating officer of the original case is notified.
+//	@Tags			Protection Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Protection order ID"
+//	@Param			payload	body		ReportBreachPayload	true	"Breach details"
+//	@Success		201		{object}	fiber.Map			"Breach reported and case opened"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Protection order not found"
+//	@Failure		409		{object}	fiber.Map			"Order was not in force at the time"
+//	@Failure		500		{object}	fiber.Map			"Server error when reporting breach"
+//	@Router			/protection-order/{id}/breaches [post]
+func (h *ProtectionOrderController) ReportProtectionOrderBreach(c *fiber.Ctx) error {
+	var payload ReportBreachPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if payload.OccurredAt.IsZero() {
+		payload.OccurredAt = time.Now()
+	}
+
+	order, ok, err := h.orderFromParam(c)
+	if !ok {
+		return err
+	}
+	if !service.ProtectionOrderInForce(order, payload.OccurredAt) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "The protection order was not in force when the breach occurred",
+		})
+	}
+
+	breach := models.ProtectionOrderBreach{
+		OccurredAt:   payload.OccurredAt,
+		Location:     payload.Location,
+		Description:  payload.Description,
+		ReportedByID: c.Locals("user").(*utils.Claims).UserID,
+	}
+	newCase, err := service.ReportProtectionOrderBreach(h.repo, order, &breach)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to report breach", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Breach reported and case opened",
+		"data":    breach,
+		"case":    ConvertToCaseResponse(newCase),
+	})
+}
+
+// ================================
+
+// CreateSafetyPlan godoc
+//
+//	@Summary		Record a safety plan for a victim
+//	@Description	Each new plan replaces the previous one as the plan in use; earlier plans are kept as history.
+//	@Tags			Protection Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Victim ID"
+//	@Param			payload	body		SafetyPlanPayload	true	"Safety plan"
+//	@Success		201		{object}	fiber.Map			"Safety plan recorded successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Victim not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when recording plan"
+//	@Router			/victim/{id}/safety-plans [post]
+func (h *ProtectionOrderController) CreateSafetyPlan(c *fiber.Ctx) error {
+	victim, err := h.repo.GetVictimByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Victim not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victim", err))
+	}
+
+	var payload SafetyPlanPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if payload.SafePlace == "" && len(payload.Measures) == 0 && len(payload.EmergencyContacts) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "A safety plan needs a safe place, emergency contacts or measures",
+		})
+	}
+	reviewDate, err := parseOptionalDate(payload.ReviewDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid review_date, expected YYYY-MM-DD", err))
+	}
+
+	plan := models.SafetyPlan{
+		VictimID:          victim.ID,
+		CaseID:            payload.CaseID,
+		SafePlace:         payload.SafePlace,
+		EmergencyContacts: payload.EmergencyContacts,
+		CodeWord:          payload.CodeWord,
+		Measures:          payload.Measures,
+		ReviewDate:        reviewDate,
+		Notes:             payload.Notes,
+		CreatedByID:       c.Locals("user").(*utils.Claims).UserID,
+	}
+	if err := h.repo.CreateSafetyPlan(&plan); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record safety plan", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Safety plan recorded successfully", plan))
+}
+
+// ================================
+
+// GetVictimSafetyPlans godoc
+//
+//	@Summary		List a victim's safety plans
+//	@Description	The first plan is the one in use.
+//	@Tags			Protection Orders
+//	@Produce		json
+//	@Param			id	path		string		true	"Victim ID"
+//	@Success		200	{object}	fiber.Map	"Safety plans retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Victim not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving plans"
+//	@Router			/victim/{id}/safety-plans [get]
+func (h *ProtectionOrderController) GetVictimSafetyPlans(c *fiber.Ctx) error {
+	victim, err := h.repo.GetVictimByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Victim not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victim", err))
+	}
+
+	plans, err := h.repo.GetVictimSafetyPlans(victim.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve safety plans", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Safety plans retrieved successfully", plans))
+}
+
+// ================================
+
+// UpdateSafetyPlan godoc
+//
+//	@Summary		Update a safety plan
+//	@Description	For corrections and review dates. Record a new plan when the arrangements change.
+//	@Tags			Protection Orders
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Safety plan ID"
+//	@Param			payload	body		SafetyPlanPayload	true	"Fields to update"
+//	@Success		200		{object}	fiber.Map			"Safety plan updated successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Safety plan not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when updating plan"
+//	@Router			/safety-plan/{id} [put]
+func (h *ProtectionOrderController) UpdateSafetyPlan(c *fiber.Ctx) error {
+	plan, err := h.repo.GetSafetyPlanByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Safety plan not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve safety plan", err))
+	}
+
+	var payload SafetyPlanPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	updates := map[string]interface{}{}
+	if payload.SafePlace != "" {
+		updates["safe_place"] = payload.SafePlace
+	}
+	if len(payload.EmergencyContacts) > 0 {
+		updates["emergency_contacts"] = datatypes.JSONSlice[string](payload.EmergencyContacts)
+	}
+	if payload.CodeWord != "" {
+		updates["code_word"] = payload.CodeWord
+	}
+	if len(payload.Measures) > 0 {
+		updates["measures"] = datatypes.JSONSlice[string](payload.Measures)
+	}
+	if payload.ReviewDate != "" {
+		reviewDate, err := parseOptionalDate(payload.ReviewDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid review_date, expected YYYY-MM-DD", err))
+		}
+		updates["review_date"] = *reviewDate
+	}
+	if payload.Notes != "" {
+		updates["notes"] = payload.Notes
+	}
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Empty request body",
+		})
+	}
+
+	if err := h.repo.UpdateSafetyPlan(plan.ID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update safety plan", err))
+	}
+
+	plan, err = h.repo.GetSafetyPlanByID(strconv.Itoa(int(plan.ID)))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve safety plan", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Safety plan updated successfully", plan))
+}
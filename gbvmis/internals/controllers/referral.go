@@ -0,0 +1,710 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ReferralController struct {
+	repo repository.ReferralRepository
+}
+
+func NewReferralController(repo repository.ReferralRepository) *ReferralController {
+	return &ReferralController{repo: repo}
+}
+
+type ServiceProviderPayload struct {
+	Name         string `json:"name" validate:"required"`
+	ProviderType string `json:"provider_type" validate:"required"`
+	Services     string `json:"services"`
+	Location     string `json:"location"`
+	District     string `json:"district"`
+	Contact      string `json:"contact"`
+	Email        string `json:"email"`
+	ContactName  string `json:"contact_name"`
+}
+
+type UpdateServiceProviderPayload struct {
+	Name         string `json:"name"`
+	ProviderType string `json:"provider_type"`
+	Services     string `json:"services"`
+	Location     string `json:"location"`
+	District     string `json:"district"`
+	Contact      string `json:"contact"`
+	Email        string `json:"email"`
+	ContactName  string `json:"contact_name"`
+	Active       *bool  `json:"active"`
+}
+
+type CreateReferralPayload struct {
+	VictimID   uint   `json:"victim_id"` // required for case referrals; examinations use their own victim
+	ProviderID uint   `json:"provider_id" validate:"required"`
+	Reason     string `json:"reason" validate:"required"`
+	Urgent     bool   `json:"urgent"`
+}
+
+type ReferralStatusPayload struct {
+	Status string `json:"status" validate:"required"`
+	Note   string `json:"note"`
+}
+
+type ReferralFeedbackPayload struct {
+	Feedback string `json:"feedback" validate:"required"`
+	Outcome  string `json:"outcome"`
+}
+
+// ================================
+
+// CreateServiceProvider godoc
+//
+//	@Summary		Add a service provider to the referral directory
+//	@Tags			Referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ServiceProviderPayload	true	"Service provider"
+//	@Success		201		{object}	fiber.Map				"Service provider created successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating service provider"
+//	@Router			/service-provider [post]
+func (h *ReferralController) CreateServiceProvider(c *fiber.Ctx) error {
+	var payload ServiceProviderPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	if !slices.Contains(models.ServiceProviderTypes, payload.ProviderType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "provider_type must be one of " + strings.Join(models.ServiceProviderTypes, ", "),
+		})
+	}
+
+	provider := models.ServiceProvider{
+		Name:         payload.Name,
+		ProviderType: payload.ProviderType,
+		Services:     payload.Services,
+		Location:     payload.Location,
+		District:     payload.District,
+		Contact:      payload.Contact,
+		Email:        payload.Email,
+		ContactName:  payload.ContactName,
+		Active:       true,
+	}
+	if err := h.repo.CreateProvider(&provider); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create service provider", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Service provider created successfully", provider))
+}
+
+// ================================
+
+// GetAllServiceProviders godoc
+//
+//	@Summary		List service providers in the referral directory
+//	@Tags			Referrals
+//	@Produce		json
+//	@Param			name			query		string		false	"Part of the provider name"
+//	@Param			provider_type	query		string		false	"shelter, legal_aid, counselling, child_protection, medical or other"
+//	@Param			district		query		string		false	"Part of the district"
+//	@Param			location		query		string		false	"Part of the location"
+//	@Param			active			query		bool		false	"Only active (true) or inactive (false) providers"
+//	@Success		200				{object}	fiber.Map	"Service providers retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve service providers"
+//	@Router			/service-providers [get]
+func (h *ReferralController) GetAllServiceProviders(c *fiber.Ctx) error {
+	pagination, providers, err := h.repo.GetPaginatedProviders(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve service providers", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Service providers retrieved successfully",
+		"data":    providers,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetSingleServiceProvider godoc
+//
+//	@Summary		Retrieve a service provider
+//	@Tags			Referrals
+//	@Produce		json
+//	@Param			id	path		string		true	"Service provider ID"
+//	@Success		200	{object}	fiber.Map	"Service provider retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Service provider not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving service provider"
+//	@Router			/service-provider/{id} [get]
+func (h *ReferralController) GetSingleServiceProvider(c *fiber.Ctx) error {
+	provider, ok, err := h.providerFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Service provider retrieved successfully", provider))
+}
+
+func (h *ReferralController) providerFromParam(c *fiber.Ctx) (models.ServiceProvider, bool, error) {
+	provider, err := h.repo.GetProviderByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return provider, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Service provider not found",
+			})
+		}
+		return provider, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve service provider", err))
+	}
+	return provider, true, nil
+}
+
+// ================================
+
+// UpdateServiceProvider godoc
+//
+//	@Summary		Update a service provider
+//	@Description	Set active to false to stop new referrals to a provider without losing its history.
+//	@Tags			Referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Service provider ID"
+//	@Param			payload	body		UpdateServiceProviderPayload	true	"Fields to update"
+//	@Success		200		{object}	fiber.Map						"Service provider updated successfully"
+//	@Failure		400		{object}	fiber.Map						"Invalid input"
+//	@Failure		404		{object}	fiber.Map						"Service provider not found"
+//	@Failure		500		{object}	fiber.Map						"Server error when updating service provider"
+//	@Router			/service-provider/{id} [put]
+func (h *ReferralController) UpdateServiceProvider(c *fiber.Ctx) error {
+	provider, ok, err := h.providerFromParam(c)
+	if !ok {
+		return err
+	}
+
+	var payload UpdateServiceProviderPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if payload.ProviderType != "" && !slices.Contains(models.ServiceProviderTypes, payload.ProviderType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "provider_type must be one of " + strings.Join(models.ServiceProviderTypes, ", "),
+		})
+	}
+
+	updates := map[string]interface{}{}
+	for column, value := range map[string]string{
+		"name":          payload.Name,
+		"provider_type": payload.ProviderType,
+		"services":      payload.Services,
+		"location":      payload.Location,
+		"district":      payload.District,
+		"contact":       payload.Contact,
+		"email":         payload.Email,
+		"contact_name":  payload.ContactName,
+	} {
+		if value != "" {
+			updates[column] = value
+		}
+	}
+	if payload.Active != nil {
+		updates["active"] = *payload.Active
+	}
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Empty request body",
+		})
+	}
+
+	if err := h.repo.UpdateProvider(provider.ID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update service provider", err))
+	}
+
+	provider, ok, err = h.providerFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Service provider updated successfully", provider))
+}
+
+// ================================
+
+// DeleteServiceProviderByID godoc
+//
+//	@Summary		Remove a service provider from the directory
+//	@Tags			Referrals
+//	@Produce		json
+//	@Param			id	path		string		true	"Service provider ID"
+//	@Success		200	{object}	fiber.Map	"Service provider deleted successfully"
+//	@Failure		404	{object}	fiber.Map	"Service provider not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting service provider"
+//	@Router			/service-provider/{id} [delete]
+func (h *ReferralController) DeleteServiceProviderByID(c *fiber.Ctx) error {
+	provider, ok, err := h.providerFromParam(c)
+	if !ok {
+		return err
+	}
+	if err := h.repo.DeleteProviderByID(provider.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete service provider", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Service provider deleted successfully", provider))
+}
+
+// ================================
+
+// createReferral validates the payload and provider and stores the referral
+// built by the caller
+func (h *ReferralController) createReferral(c *fiber.Ctx, payload CreateReferralPayload, referral models.Referral) error {
+	provider, err := h.repo.GetProviderByID(strconv.Itoa(int(payload.ProviderID)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Service provider not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve service provider", err))
+	}
+	if !provider.Active {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Service provider is no longer active",
+		})
+	}
+
+	referral.ProviderID = provider.ID
+	referral.ReferredByID = c.Locals("user").(*utils.Claims).UserID
+	referral.Reason = payload.Reason
+	referral.Urgent = payload.Urgent
+	referral.Status = models.ReferralStatusSent
+	referral.SentAt = time.Now()
+	if err := h.repo.CreateReferral(&referral); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create referral", err))
+	}
+	referral.Provider = provider
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Referral sent successfully", referral))
+}
+
+func parseReferralPayload(c *fiber.Ctx) (CreateReferralPayload, bool, error) {
+	var payload CreateReferralPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return payload, false, c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return payload, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	return payload, true, nil
+}
+
+// CreateCaseReferral godoc
+//
+//	@Summary		Refer a victim in a case to a service provider
+//	@Tags			Referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Case ID"
+//	@Param			payload	body		CreateReferralPayload	true	"Referral"
+//	@Success		201		{object}	fiber.Map				"Referral sent successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input or victim not on the case"
+//	@Failure		404		{object}	fiber.Map				"Case or service provider not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating referral"
+//	@Router			/case/{id}/referrals [post]
+func (h *ReferralController) CreateCaseReferral(c *fiber.Ctx) error {
+	payload, ok, err := parseReferralPayload(c)
+	if !ok {
+		return err
+	}
+
+	casee, err := h.repo.GetCaseByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+	if !slices.ContainsFunc(casee.Victims, func(v models.Victim) bool { return v.ID == payload.VictimID }) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "The victim is not linked to this case",
+		})
+	}
+
+	caseID := casee.ID
+	return h.createReferral(c, payload, models.Referral{
+		VictimID:     payload.VictimID,
+		CaseID:       &caseID,
+		PolicePostID: casee.PolicePostID,
+	})
+}
+
+// CreateExaminationReferral godoc
+//
+//	@Summary		Refer the examined victim to a service provider
+//	@Description	The referral is for the examination's victim and case; victim_id is ignored.
+//	@Tags			Referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Examination ID"
+//	@Param			payload	body		CreateReferralPayload	true	"Referral"
+//	@Success		201		{object}	fiber.Map				"Referral sent successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Examination or service provider not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating referral"
+//	@Router			/examination/{id}/referrals [post]
+func (h *ReferralController) CreateExaminationReferral(c *fiber.Ctx) error {
+	payload, ok, err := parseReferralPayload(c)
+	if !ok {
+		return err
+	}
+
+	examination, err := h.repo.GetExaminationByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Examination not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve examination", err))
+	}
+
+	examinationID := examination.ID
+	referral := models.Referral{
+		VictimID:      examination.VictimID,
+		ExaminationID: &examinationID,
+		PolicePostID:  examination.Case.PolicePostID,
+	}
+	if examination.CaseID != 0 {
+		caseID := examination.CaseID
+		referral.CaseID = &caseID
+	}
+	return h.createReferral(c, payload, referral)
+}
+
+// ================================
+
+// GetCaseReferrals godoc
+//
+//	@Summary		List referrals made from a case
+//	@Tags			Referrals
+//	@Produce		json
+//	@Param			id	path		string		true	"Case ID"
+//	@Success		200	{object}	fiber.Map	"Referrals retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving referrals"
+//	@Router			/case/{id}/referrals [get]
+func (h *ReferralController) GetCaseReferrals(c *fiber.Ctx) error {
+	return h.listReferralsFor(c, "case_id")
+}
+
+// GetExaminationReferrals godoc
+//
+//	@Summary		List referrals made from an examination
+//	@Tags			Referrals
+//	@Produce		json
+//	@Param			id	path		string		true	"Examination ID"
+//	@Success		200	{object}	fiber.Map	"Referrals retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving referrals"
+//	@Router			/examination/{id}/referrals [get]
+func (h *ReferralController) GetExaminationReferrals(c *fiber.Ctx) error {
+	return h.listReferralsFor(c, "examination_id")
+}
+
+// GetCaseChildProtection godoc
+//
+//	@Summary		Check child-protection requirements for a case
+//	@Description	For each victim under 18 on the incident date: a guardian is recorded, at least one guardian is not a suspect, and the child has been referred to a child protection provider (a declined referral does not count). Adult-only cases return an empty list.
+//	@Tags			Referrals
+//	@Produce		json
+//	@Param			id	path		string		true	"Case ID"
+//	@Success		200	{object}	fiber.Map	"Child protection status retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Case not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when checking the case"
+//	@Router			/case/{id}/child-protection [get]
+func (h *ReferralController) GetCaseChildProtection(c *fiber.Ctx) error {
+	casee, err := h.repo.GetCaseByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+
+	referrals, err := h.repo.GetReferralsFor("case_id", casee.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve referrals", err))
+	}
+
+	statuses := service.ChildProtectionRequirements(casee, referrals)
+	complete := true
+	for _, s := range statuses {
+		if len(s.Missing) > 0 {
+			complete = false
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":   "success",
+		"message":  "Child protection status retrieved successfully",
+		"data":     statuses,
+		"complete": complete,
+	})
+}
+
+func (h *ReferralController) listReferralsFor(c *fiber.Ctx, column string) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid ID", err))
+	}
+	referrals, err := h.repo.GetReferralsFor(column, uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve referrals", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Referrals retrieved successfully", referrals))
+}
+
+// ================================
+
+// GetAllReferrals godoc
+//
+//	@Summary		List referrals
+//	@Tags			Referrals
+//	@Produce		json
+//	@Param			status			query		string		false	"sent, accepted, declined, attended or closed"
+//	@Param			provider_id		query		int			false	"Referrals to this provider"
+//	@Param			police_post_id	query		int			false	"Referrals from this station"
+//	@Param			victim_id		query		int			false	"Referrals of this victim"
+//	@Param			case_id			query		int			false	"Referrals from this case"
+//	@Success		200				{object}	fiber.Map	"Referrals retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve referrals"
+//	@Router			/referrals [get]
+func (h *ReferralController) GetAllReferrals(c *fiber.Ctx) error {
+	pagination, referrals, err := h.repo.GetPaginatedReferrals(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve referrals", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Referrals retrieved successfully",
+		"data":    referrals,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetSingleReferral godoc
+//
+//	@Summary		Retrieve a referral with its status history
+//	@Tags			Referrals
+//	@Produce		json
+//	@Param			id	path		string		true	"Referral ID"
+//	@Success		200	{object}	fiber.Map	"Referral retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Referral not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving referral"
+//	@Router			/referral/{id} [get]
+func (h *ReferralController) GetSingleReferral(c *fiber.Ctx) error {
+	referral, ok, err := h.referralFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Referral retrieved successfully", referral))
+}
+
+func (h *ReferralController) referralFromParam(c *fiber.Ctx) (models.Referral, bool, error) {
+	referral, err := h.repo.GetReferralByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return referral, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Referral not found",
+			})
+		}
+		return referral, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve referral", err))
+	}
+	return referral, true, nil
+}
+
+// ================================
+
+// UpdateReferralStatus godoc
+//
+//	@Summary		Move a referral to its next status
+//	@Description	Allowed moves: sent to accepted, declined or closed; accepted to attended or closed; declined or attended to closed.
+//	@Tags			Referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Referral ID"
+//	@Param			payload	body		ReferralStatusPayload	true	"New status"
+//	@Success		200		{object}	fiber.Map				"Referral status updated"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Referral not found"
+//	@Failure		409		{object}	fiber.Map				"Status change not allowed, or the referral was updated by someone else"
+//	@Failure		500		{object}	fiber.Map				"Server error when updating referral"
+//	@Router			/referral/{id}/status [post]
+func (h *ReferralController) UpdateReferralStatus(c *fiber.Ctx) error {
+	var payload ReferralStatusPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	referral, ok, err := h.referralFromParam(c)
+	if !ok {
+		return err
+	}
+	if !slices.Contains(models.ReferralTransitions[referral.Status], payload.Status) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "A " + referral.Status + " referral cannot be marked " + payload.Status,
+		})
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"status": payload.Status}
+	switch payload.Status {
+	case models.ReferralStatusAccepted:
+		updates["accepted_at"] = now
+	case models.ReferralStatusAttended:
+		updates["attended_at"] = now
+	case models.ReferralStatusClosed:
+		updates["closed_at"] = now
+	}
+
+	update := models.ReferralUpdate{
+		FromStatus:  referral.Status,
+		ToStatus:    payload.Status,
+		Note:        payload.Note,
+		UpdatedByID: c.Locals("user").(*utils.Claims).UserID,
+	}
+	if err := h.repo.UpdateReferralStatus(referral, updates, &update); err != nil {
+		if errors.Is(err, models.ErrReferralStatusChanged) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  "error",
+				"message": "The referral was updated by someone else; reload it and try again",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update referral", err))
+	}
+
+	referral, ok, err = h.referralFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Referral status updated", referral))
+}
+
+// ================================
+
+// RecordReferralFeedback godoc
+//
+//	@Summary		Record the provider's feedback on a referral
+//	@Tags			Referrals
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Referral ID"
+//	@Param			payload	body		ReferralFeedbackPayload	true	"Feedback"
+//	@Success		200		{object}	fiber.Map				"Referral feedback recorded"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Referral not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when recording feedback"
+//	@Router			/referral/{id}/feedback [post]
+func (h *ReferralController) RecordReferralFeedback(c *fiber.Ctx) error {
+	var payload ReferralFeedbackPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	referral, ok, err := h.referralFromParam(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.repo.UpdateReferral(referral.ID, map[string]interface{}{
+		"provider_feedback": payload.Feedback,
+		"outcome":           payload.Outcome,
+		"feedback_at":       time.Now(),
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record feedback", err))
+	}
+
+	referral, ok, err = h.referralFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Referral feedback recorded", referral))
+}
+
+// ================================
+
+// GetReferralCompletionReport godoc
+//
+//	@Summary		Referral completion rates per station
+//	@Description	For each referring police post: referrals sent, accepted, declined, attended and closed, and the share the survivor attended.
+//	@Tags			Referrals
+//	@Produce		json
+//	@Param			from			query		string		false	"Referrals sent on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Referrals sent on or before (YYYY-MM-DD)"
+//	@Param			provider_type	query		string		false	"Only referrals to this kind of provider"
+//	@Success		200				{object}	fiber.Map	"Referral completion report generated"
+//	@Failure		500				{object}	fiber.Map	"Server error when generating report"
+//	@Router			/referrals/completion-report [get]
+func (h *ReferralController) GetReferralCompletionReport(c *fiber.Ctx) error {
+	report, err := h.repo.GetCompletionReport(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to generate referral report", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Referral completion report generated", report))
+}
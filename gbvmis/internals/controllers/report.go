@@ -0,0 +1,502 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/storage"
+	"gbvmis/internals/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ReportController struct {
+	repo  repository.ReportRepository
+	stats repository.StatsRepository
+	store storage.BlobStore
+}
+
+func NewReportController(repo repository.ReportRepository, stats repository.StatsRepository, store storage.BlobStore) *ReportController {
+	return &ReportController{repo: repo, stats: stats, store: store}
+}
+
+type ReportDefinitionPayload struct {
+	Name         string   `json:"name" validate:"required"`
+	Description  string   `json:"description"`
+	Period       string   `json:"period" validate:"required"`         // monthly or quarterly
+	Sections     []string `json:"sections" validate:"required,min=1"` // see models.ReportSections
+	Formats      []string `json:"formats" validate:"required,min=1"`  // csv, xlsx, pdf
+	Schedule     string   `json:"schedule" validate:"required"`       // cron, e.g. "0 6 1 * *" or @monthly
+	PerStation   bool     `json:"per_station"`
+	PolicePostID *uint    `json:"police_post_id"`
+	Active       *bool    `json:"active"` // defaults to true
+}
+
+type UpdateReportDefinitionPayload struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Period       string   `json:"period"`
+	Sections     []string `json:"sections"`
+	Formats      []string `json:"formats"`
+	Schedule     string   `json:"schedule"`
+	PerStation   *bool    `json:"per_station"`
+	PolicePostID *uint    `json:"police_post_id"`
+	Active       *bool    `json:"active"`
+}
+
+type RunReportPayload struct {
+	PeriodStart string `json:"period_start" validate:"required"` // any date in the period to report on, YYYY-MM-DD
+}
+
+// requireAdmin writes a 403 unless the caller is an administrator
+func requireAdmin(c *fiber.Ctx, action string) (bool, error) {
+	if !isAdmin(c) {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Only administrators can " + action,
+		})
+	}
+	return true, nil
+}
+
+// isAdmin reports whether the caller is an administrator
+func isAdmin(c *fiber.Ctx) bool {
+	claims := c.Locals("user").(*utils.Claims)
+	return slices.Contains(claims.Roles, service.RoleAdmin)
+}
+
+// validateReportDefinition checks the enumerations and schedule and returns
+// a message when something is wrong
+func validateReportDefinition(definition models.ReportDefinition) string {
+	if !slices.Contains(models.ReportPeriods, definition.Period) {
+		return "period must be one of " + strings.Join(models.ReportPeriods, ", ")
+	}
+	for _, section := range definition.Sections {
+		if !slices.Contains(models.ReportSections, section) {
+			return "sections must be among " + strings.Join(models.ReportSections, ", ")
+		}
+	}
+	for _, format := range definition.Formats {
+		if !slices.Contains(models.ReportFormats, format) {
+			return "formats must be among " + strings.Join(models.ReportFormats, ", ")
+		}
+	}
+	if _, err := service.ParseCron(definition.Schedule); err != nil {
+		return "invalid schedule: " + err.Error()
+	}
+	if definition.PerStation && definition.PolicePostID != nil {
+		return "per_station and police_post_id cannot both be set"
+	}
+	return ""
+}
+
+// checkReportPost writes a 400 when the police post is set but unknown
+func (h *ReportController) checkReportPost(c *fiber.Ctx, id *uint) (bool, error) {
+	if id == nil {
+		return true, nil
+	}
+	if _, err := h.repo.FindPolicePostByID(*id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "police_post_id is not a known police post",
+			})
+		}
+		return false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve police post", err))
+	}
+	return true, nil
+}
+
+// definitionFromParam loads the report definition named by the :id parameter,
+// writing a 404 or 500 when it cannot
+func (h *ReportController) definitionFromParam(c *fiber.Ctx) (models.ReportDefinition, bool, error) {
+	definition, err := h.repo.GetReportDefinitionByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return definition, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Report definition not found",
+			})
+		}
+		return definition, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve report definition", err))
+	}
+	return definition, true, nil
+}
+
+// ================================
+
+// CreateReportDefinition godoc
+//
+//	@Summary		Define a scheduled report
+//	@Description	Configures a periodic return built from the statistics sections (cases_by_status, cases_by_charge, cases_by_station, cases_by_district, cases_by_month, victims_by_age_band, relationships, timeliness), rendered to csv, xlsx and/or pdf. Schedule is a five-field cron expression in server time, or @monthly, @quarterly and the like; each run reports on the last complete month or quarter. per_station makes one report per police post. Administrators only.
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		ReportDefinitionPayload	true	"Report definition"
+//	@Success		201		{object}	fiber.Map				"Report definition created successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		403		{object}	fiber.Map				"Not an administrator"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating the definition"
+//	@Router			/report-definitions [post]
+func (h *ReportController) CreateReportDefinition(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "define reports"); !ok {
+		return err
+	}
+
+	var payload ReportDefinitionPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	definition := models.ReportDefinition{
+		Name:         payload.Name,
+		Description:  payload.Description,
+		Period:       payload.Period,
+		Sections:     payload.Sections,
+		Formats:      payload.Formats,
+		Schedule:     payload.Schedule,
+		PerStation:   payload.PerStation,
+		PolicePostID: payload.PolicePostID,
+		Active:       payload.Active == nil || *payload.Active,
+		CreatedByID:  c.Locals("user").(*utils.Claims).UserID,
+	}
+	if msg := validateReportDefinition(definition); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+	if ok, err := h.checkReportPost(c, definition.PolicePostID); !ok {
+		return err
+	}
+	if definition.Active {
+		definition.NextRunAt, _ = service.NextReportRun(definition.Schedule, time.Now())
+	}
+
+	if err := h.repo.CreateReportDefinition(&definition); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create report definition", err))
+	}
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Report definition created successfully", definition))
+}
+
+// ================================
+
+// GetAllReportDefinitions godoc
+//
+//	@Summary		List report definitions
+//	@Tags			Reports
+//	@Produce		json
+//	@Param			active	query		bool		false	"Only active (true) or inactive (false) definitions"
+//	@Success		200		{object}	fiber.Map	"Report definitions retrieved successfully"
+//	@Failure		500		{object}	fiber.Map	"Failed to retrieve report definitions"
+//	@Router			/report-definitions [get]
+func (h *ReportController) GetAllReportDefinitions(c *fiber.Ctx) error {
+	pagination, definitions, err := h.repo.GetPaginatedReportDefinitions(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve report definitions", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Report definitions retrieved successfully",
+		"data":    definitions,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetSingleReportDefinition godoc
+//
+//	@Summary		Retrieve a report definition
+//	@Tags			Reports
+//	@Produce		json
+//	@Param			id	path		string		true	"Report definition ID"
+//	@Success		200	{object}	fiber.Map	"Report definition retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Report definition not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving the definition"
+//	@Router			/report-definition/{id} [get]
+func (h *ReportController) GetSingleReportDefinition(c *fiber.Ctx) error {
+	definition, ok, err := h.definitionFromParam(c)
+	if !ok {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Report definition retrieved successfully", definition))
+}
+
+// ================================
+
+// UpdateReportDefinition godoc
+//
+//	@Summary		Update a report definition
+//	@Description	Changes to the schedule or active flag reset the next run. Administrators only.
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string							true	"Report definition ID"
+//	@Param			payload	body		UpdateReportDefinitionPayload	true	"Fields to change"
+//	@Success		200		{object}	fiber.Map						"Report definition updated successfully"
+//	@Failure		400		{object}	fiber.Map						"Invalid input"
+//	@Failure		403		{object}	fiber.Map						"Not an administrator"
+//	@Failure		404		{object}	fiber.Map						"Report definition not found"
+//	@Failure		500		{object}	fiber.Map						"Server error when updating the definition"
+//	@Router			/report-definition/{id} [put]
+func (h *ReportController) UpdateReportDefinition(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "change reports"); !ok {
+		return err
+	}
+	definition, ok, err := h.definitionFromParam(c)
+	if !ok {
+		return err
+	}
+
+	var payload UpdateReportDefinitionPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+
+	updates := map[string]interface{}{}
+	if payload.Name != "" {
+		definition.Name = payload.Name
+		updates["name"] = payload.Name
+	}
+	if payload.Description != "" {
+		definition.Description = payload.Description
+		updates["description"] = payload.Description
+	}
+	if payload.Period != "" {
+		definition.Period = payload.Period
+		updates["period"] = payload.Period
+	}
+	if len(payload.Sections) > 0 {
+		definition.Sections = payload.Sections
+		updates["sections"] = definition.Sections
+	}
+	if len(payload.Formats) > 0 {
+		definition.Formats = payload.Formats
+		updates["formats"] = definition.Formats
+	}
+	if payload.PerStation != nil {
+		definition.PerStation = *payload.PerStation
+		updates["per_station"] = *payload.PerStation
+	}
+	if payload.PolicePostID != nil {
+		definition.PolicePostID = payload.PolicePostID
+		updates["police_post_id"] = *payload.PolicePostID
+	}
+	if payload.Schedule != "" || payload.Active != nil {
+		if payload.Schedule != "" {
+			definition.Schedule = payload.Schedule
+			updates["schedule"] = payload.Schedule
+		}
+		if payload.Active != nil {
+			definition.Active = *payload.Active
+			updates["active"] = *payload.Active
+		}
+		definition.NextRunAt = nil
+		if definition.Active {
+			definition.NextRunAt, _ = service.NextReportRun(definition.Schedule, time.Now())
+		}
+		updates["next_run_at"] = definition.NextRunAt
+	}
+	if len(updates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Empty request body",
+		})
+	}
+
+	if msg := validateReportDefinition(definition); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": msg})
+	}
+	if ok, err := h.checkReportPost(c, payload.PolicePostID); !ok {
+		return err
+	}
+
+	if err := h.repo.UpdateReportDefinition(definition.ID, updates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to update report definition", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Report definition updated successfully", definition))
+}
+
+// ================================
+
+// DeleteReportDefinition godoc
+//
+//	@Summary		Delete a report definition
+//	@Description	Stops the schedule. Reports already generated remain downloadable. Administrators only.
+//	@Tags			Reports
+//	@Produce		json
+//	@Param			id	path		string		true	"Report definition ID"
+//	@Success		200	{object}	fiber.Map	"Report definition deleted successfully"
+//	@Failure		403	{object}	fiber.Map	"Not an administrator"
+//	@Failure		404	{object}	fiber.Map	"Report definition not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting the definition"
+//	@Router			/report-definition/{id} [delete]
+func (h *ReportController) DeleteReportDefinition(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "delete reports"); !ok {
+		return err
+	}
+	definition, ok, err := h.definitionFromParam(c)
+	if !ok {
+		return err
+	}
+	if err := h.repo.DeleteReportDefinition(definition.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete report definition", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Report definition deleted successfully", nil))
+}
+
+// ================================
+
+// RunReport godoc
+//
+//	@Summary		Generate a report for a past period now
+//	@Description	Renders the definition for the month or quarter containing period_start, which must have ended. Earlier files for the period are kept; the new ones are added to the history. Administrators only.
+//	@Tags			Reports
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Report definition ID"
+//	@Param			payload	body		RunReportPayload	true	"Period"
+//	@Success		201		{object}	fiber.Map			"Report generated"
+//	@Failure		400		{object}	fiber.Map			"Invalid or unfinished period"
+//	@Failure		403		{object}	fiber.Map			"Not an administrator"
+//	@Failure		404		{object}	fiber.Map			"Report definition not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when generating the report"
+//	@Router			/report-definition/{id}/run [post]
+func (h *ReportController) RunReport(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "run reports"); !ok {
+		return err
+	}
+	definition, ok, err := h.definitionFromParam(c)
+	if !ok {
+		return err
+	}
+
+	var payload RunReportPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+	day, err := time.ParseInLocation("2006-01-02", payload.PeriodStart, time.Local)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid period_start; use YYYY-MM-DD", err))
+	}
+	start, end := service.ReportPeriodRange(definition.Period, day)
+	year, month, today := time.Now().Date()
+	if !end.Before(time.Date(year, month, today, 0, 0, 0, 0, time.Local)) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("The %s period starting %s has not ended yet", definition.Period, start.Format("2006-01-02")),
+		})
+	}
+
+	userID := c.Locals("user").(*utils.Claims).UserID
+	reports, err := service.GenerateReports(c.Context(), h.repo, h.stats, h.store, definition, start, models.ReportTriggerManual, &userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to generate report", err))
+	}
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Report generated", reports))
+}
+
+// ================================
+
+// GetAllReports godoc
+//
+//	@Summary		List generated reports
+//	@Description	The history of generated files, newest period first, including failed ones with the reason.
+//	@Tags			Reports
+//	@Produce		json
+//	@Param			definition_id	query		int			false	"Report definition"
+//	@Param			police_post_id	query		int			false	"Police post of per-station reports"
+//	@Param			format			query		string		false	"csv, xlsx or pdf"
+//	@Param			status			query		string		false	"ready or failed"
+//	@Param			period_start	query		string		false	"First day of the period (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Reports retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve reports"
+//	@Router			/reports [get]
+func (h *ReportController) GetAllReports(c *fiber.Ctx) error {
+	pagination, reports, err := h.repo.GetPaginatedGeneratedReports(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve reports", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Reports retrieved successfully",
+		"data":    reports,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// DownloadReport godoc
+//
+//	@Summary		Download a generated report
+//	@Tags			Reports
+//	@Produce		octet-stream
+//	@Param			id	path		string		true	"Generated report ID"
+//	@Success		200	{file}		binary		"Report file"
+//	@Failure		404	{object}	fiber.Map	"Report not found or it failed to generate"
+//	@Failure		500	{object}	fiber.Map	"Server error when reading the report"
+//	@Router			/report/{id}/download [get]
+func (h *ReportController) DownloadReport(c *fiber.Ctx) error {
+	report, err := h.repo.GetGeneratedReportByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Report not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve report", err))
+	}
+	if report.Status != models.ReportStatusReady {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Report failed to generate: " + report.Error,
+		})
+	}
+
+	content, err := h.store.Get(c.Context(), report.BlobSHA256)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Report content is missing from storage",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to read report", err))
+	}
+
+	c.Set(fiber.HeaderContentType, report.MimeType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", report.FileName))
+	return c.SendStream(content, int(report.Size))
+}
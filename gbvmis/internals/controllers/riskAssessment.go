@@ -0,0 +1,429 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type RiskAssessmentController struct {
+	repo repository.RiskAssessmentRepository
+}
+
+func NewRiskAssessmentController(repo repository.RiskAssessmentRepository) *RiskAssessmentController {
+	return &RiskAssessmentController{repo: repo}
+}
+
+type RiskQuestionPayload struct {
+	Code     string  `json:"code" validate:"required"`
+	Text     string  `json:"text" validate:"required"`
+	Weight   float64 `json:"weight" validate:"gt=0"`
+	Critical bool    `json:"critical"`
+}
+
+type CreateRiskQuestionnairePayload struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	Active      bool   `json:"active"`
+
+	// Shares (0-1) of the maximum score; all three or none
+	MediumThreshold  float64 `json:"medium_threshold"`
+	HighThreshold    float64 `json:"high_threshold"`
+	ExtremeThreshold float64 `json:"extreme_threshold"`
+
+	Questions []RiskQuestionPayload `json:"questions" validate:"required,min=1,dive"`
+}
+
+type RiskAnswerPayload struct {
+	QuestionID uint `json:"question_id" validate:"required"`
+	Answer     bool `json:"answer"`
+}
+
+type SubmitRiskAssessmentPayload struct {
+	VictimID        uint                `json:"victim_id" validate:"required"`
+	QuestionnaireID uint                `json:"questionnaire_id"` // defaults to the active questionnaire
+	AssessedAt      string              `json:"assessed_at"`      // YYYY-MM-DD, defaults to now
+	Answers         []RiskAnswerPayload `json:"answers" validate:"required,min=1,dive"`
+	Notes           string              `json:"notes"`
+}
+
+// ================================
+
+// CreateRiskQuestionnaire godoc
+//
+//	@Summary		Create a risk assessment questionnaire version
+//	@Description	Creates the next version of the named questionnaire. Versions are never edited once created; submit a new version instead. If active is true, earlier versions stop being offered. Admin only.
+//	@Tags			Risk Assessment
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateRiskQuestionnairePayload	true	"Questionnaire"
+//	@Success		201		{object}	fiber.Map						"Questionnaire created successfully"
+//	@Failure		400		{object}	fiber.Map						"Invalid input"
+//	@Failure		403		{object}	fiber.Map						"Not an administrator"
+//	@Failure		500		{object}	fiber.Map						"Server error when creating questionnaire"
+//	@Router			/risk-questionnaire [post]
+func (h *RiskAssessmentController) CreateRiskQuestionnaire(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "configure risk questionnaires"); !ok {
+		return err
+	}
+
+	var payload CreateRiskQuestionnairePayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	thresholdsSet := payload.MediumThreshold != 0 || payload.HighThreshold != 0 || payload.ExtremeThreshold != 0
+	if thresholdsSet && !(0 < payload.MediumThreshold && payload.MediumThreshold < payload.HighThreshold &&
+		payload.HighThreshold < payload.ExtremeThreshold && payload.ExtremeThreshold <= 1) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Thresholds must satisfy 0 < medium < high < extreme <= 1",
+		})
+	}
+
+	questionnaire := models.RiskQuestionnaire{
+		Name:             payload.Name,
+		Description:      payload.Description,
+		Active:           payload.Active,
+		MediumThreshold:  payload.MediumThreshold,
+		HighThreshold:    payload.HighThreshold,
+		ExtremeThreshold: payload.ExtremeThreshold,
+		CreatedByID:      c.Locals("user").(*utils.Claims).UserID,
+	}
+	for i, q := range payload.Questions {
+		questionnaire.Questions = append(questionnaire.Questions, models.RiskQuestion{
+			Code:     q.Code,
+			Text:     q.Text,
+			Weight:   q.Weight,
+			Critical: q.Critical,
+			Position: i + 1,
+		})
+	}
+
+	if err := h.repo.CreateQuestionnaire(&questionnaire); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create questionnaire", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Questionnaire created successfully", questionnaire))
+}
+
+// ================================
+
+// GetAllRiskQuestionnaires godoc
+//
+//	@Summary		List risk assessment questionnaires
+//	@Tags			Risk Assessment
+//	@Produce		json
+//	@Param			name	query		string		false	"Part of the questionnaire name"
+//	@Param			active	query		bool		false	"Only active (true) or inactive (false) versions"
+//	@Success		200		{object}	fiber.Map	"Questionnaires retrieved successfully"
+//	@Failure		500		{object}	fiber.Map	"Failed to retrieve questionnaires"
+//	@Router			/risk-questionnaires [get]
+func (h *RiskAssessmentController) GetAllRiskQuestionnaires(c *fiber.Ctx) error {
+	pagination, questionnaires, err := h.repo.GetPaginatedQuestionnaires(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve questionnaires", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Questionnaires retrieved successfully",
+		"data":    questionnaires,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// ================================
+
+// GetSingleRiskQuestionnaire godoc
+//
+//	@Summary		Retrieve a risk assessment questionnaire with its questions
+//	@Tags			Risk Assessment
+//	@Produce		json
+//	@Param			id	path		string		true	"Questionnaire ID, or \"active\" for the questionnaire currently in use"
+//	@Success		200	{object}	fiber.Map	"Questionnaire retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Questionnaire not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving questionnaire"
+//	@Router			/risk-questionnaire/{id} [get]
+func (h *RiskAssessmentController) GetSingleRiskQuestionnaire(c *fiber.Ctx) error {
+	var questionnaire models.RiskQuestionnaire
+	var err error
+	if id := c.Params("id"); id == "active" {
+		questionnaire, err = h.repo.GetActiveQuestionnaire(c.Query("name"))
+	} else {
+		questionnaire, err = h.repo.GetQuestionnaireByID(id)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Questionnaire not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve questionnaire", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Questionnaire retrieved successfully", questionnaire))
+}
+
+// ================================
+
+// ActivateRiskQuestionnaire godoc
+//
+//	@Summary		Make a questionnaire version the one in use
+//	@Description	Deactivates every other version with the same name. Admin only.
+//	@Tags			Risk Assessment
+//	@Produce		json
+//	@Param			id	path		string		true	"Questionnaire ID"
+//	@Success		200	{object}	fiber.Map	"Questionnaire activated successfully"
+//	@Failure		403	{object}	fiber.Map	"Not an administrator"
+//	@Failure		404	{object}	fiber.Map	"Questionnaire not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when activating questionnaire"
+//	@Router			/risk-questionnaire/{id}/activate [post]
+func (h *RiskAssessmentController) ActivateRiskQuestionnaire(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "configure risk questionnaires"); !ok {
+		return err
+	}
+
+	questionnaire, err := h.repo.GetQuestionnaireByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Questionnaire not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve questionnaire", err))
+	}
+
+	if err := h.repo.ActivateQuestionnaire(questionnaire); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to activate questionnaire", err))
+	}
+	questionnaire.Active = true
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Questionnaire activated successfully", questionnaire))
+}
+
+// ================================
+
+// SubmitRiskAssessment godoc
+//
+//	@Summary		Submit a risk assessment for a victim in a case
+//	@Description	Scores the answers against the questionnaire. A yes to a critical question means at least high risk; living with or depending on a partner suspect, or depending on the suspect for care, raises the level a step. The case's risk level and priority are then reset from the latest assessment of each of its victims.
+//	@Tags			Risk Assessment
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string						true	"Case ID"
+//	@Param			payload	body		SubmitRiskAssessmentPayload	true	"Answers"
+//	@Success		201		{object}	fiber.Map					"Risk assessment recorded successfully"
+//	@Failure		400		{object}	fiber.Map					"Invalid input, incomplete answers or victim not on the case"
+//	@Failure		404		{object}	fiber.Map					"Case or questionnaire not found"
+//	@Failure		500		{object}	fiber.Map					"Server error when recording assessment"
+//	@Router			/case/{id}/risk-assessments [post]
+func (h *RiskAssessmentController) SubmitRiskAssessment(c *fiber.Ctx) error {
+	var payload SubmitRiskAssessmentPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	casee, err := h.repo.GetCaseByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+	if !slices.ContainsFunc(casee.Victims, func(v models.Victim) bool { return v.ID == payload.VictimID }) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "The victim is not linked to this case",
+		})
+	}
+
+	var questionnaire models.RiskQuestionnaire
+	if payload.QuestionnaireID != 0 {
+		questionnaire, err = h.repo.GetQuestionnaireByID(strconv.Itoa(int(payload.QuestionnaireID)))
+	} else {
+		questionnaire, err = h.repo.GetActiveQuestionnaire("")
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Questionnaire not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve questionnaire", err))
+	}
+
+	assessedAt := time.Now()
+	if payload.AssessedAt != "" {
+		assessedAt, err = time.Parse("2006-01-02", payload.AssessedAt)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid assessed_at, expected YYYY-MM-DD", err))
+		}
+	}
+
+	answers := make(map[uint]bool, len(payload.Answers))
+	for _, a := range payload.Answers {
+		answers[a.QuestionID] = a.Answer
+	}
+
+	assessment := models.RiskAssessment{
+		CaseID:          casee.ID,
+		VictimID:        payload.VictimID,
+		QuestionnaireID: questionnaire.ID,
+		AssessedByID:    c.Locals("user").(*utils.Claims).UserID,
+		AssessedAt:      assessedAt,
+		Notes:           payload.Notes,
+	}
+	if err := service.ScoreRiskAssessment(questionnaire, answers, casee.Relationships, &assessment); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Answers do not match the questionnaire", err))
+	}
+
+	caseLevel, priority, err := service.SubmitRiskAssessment(h.repo, &assessment)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record risk assessment", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":          "success",
+		"message":         "Risk assessment recorded successfully",
+		"data":            assessment,
+		"case_risk_level": caseLevel,
+		"case_priority":   priority,
+	})
+}
+
+// ================================
+
+// GetCaseRiskAssessments godoc
+//
+//	@Summary		List a case's risk assessments
+//	@Description	Returns every assessment of the case, newest first, so changes in risk over time can be followed.
+//	@Tags			Risk Assessment
+//	@Produce		json
+//	@Param			id			path		string		true	"Case ID"
+//	@Param			victim_id	query		int			false	"Only this victim's assessments"
+//	@Success		200			{object}	fiber.Map	"Risk assessments retrieved successfully"
+//	@Failure		404			{object}	fiber.Map	"Case not found"
+//	@Failure		500			{object}	fiber.Map	"Server error when retrieving assessments"
+//	@Router			/case/{id}/risk-assessments [get]
+func (h *RiskAssessmentController) GetCaseRiskAssessments(c *fiber.Ctx) error {
+	casee, err := h.repo.GetCaseByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Case not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve case", err))
+	}
+
+	assessments, err := h.repo.GetCaseAssessments(casee.ID, c.Query("victim_id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve risk assessments", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Risk assessments retrieved successfully",
+		"data":       assessments,
+		"risk_level": casee.RiskLevel,
+		"priority":   casee.Priority,
+	})
+}
+
+// ================================
+
+// GetSingleRiskAssessment godoc
+//
+//	@Summary		Retrieve a risk assessment with its answers
+//	@Tags			Risk Assessment
+//	@Produce		json
+//	@Param			id	path		string		true	"Risk assessment ID"
+//	@Success		200	{object}	fiber.Map	"Risk assessment retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Risk assessment not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving assessment"
+//	@Router			/risk-assessment/{id} [get]
+func (h *RiskAssessmentController) GetSingleRiskAssessment(c *fiber.Ctx) error {
+	assessment, err := h.repo.GetAssessmentByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Risk assessment not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve risk assessment", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Risk assessment retrieved successfully", assessment))
+}
+
+// ================================
+
+// GetHighRiskCases godoc
+//
+//	@Summary		List open high-risk cases
+//	@Description	Open cases whose current risk level is high or extreme, extreme first and then oldest first, for supervisors to follow up.
+//	@Tags			Risk Assessment
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Only cases of this police post"
+//	@Success		200				{object}	fiber.Map	"High-risk cases retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve high-risk cases"
+//	@Router			/cases/high-risk [get]
+func (h *RiskAssessmentController) GetHighRiskCases(c *fiber.Ctx) error {
+	pagination, cases, err := h.repo.GetPaginatedHighRiskCases(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve high-risk cases", err))
+	}
+
+	caseResponses := make([]CaseResponse, len(cases))
+	for i, c := range cases {
+		caseResponses[i] = ConvertToCaseResponse(c)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "High-risk cases retrieved successfully",
+		"data":    caseResponses,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StatsController struct {
+	repo  repository.StatsRepository
+	cache *service.StatsCache
+}
+
+func NewStatsController(repo repository.StatsRepository) *StatsController {
+	return &StatsController{repo: repo, cache: service.NewStatsCache(service.StatsCacheTTL, service.StatsCacheSize)}
+}
+
+// statsKey identifies a statistic and its filters in the cache. It is built
+// from the parsed filter, so requests that differ only in ignored parameters
+// share an entry.
+func statsKey(c *fiber.Ctx, filter models.StatsFilter) string {
+	key := c.Path() + "?from=" + filter.From + "&to=" + filter.To
+	if filter.PolicePostID != nil {
+		key += "&police_post_id=" + strconv.FormatUint(uint64(*filter.PolicePostID), 10)
+	}
+	return key
+}
+
+// respondStats serves a statistic from the cache, computing it with get
+// when needed
+func respondStats[T any](h *StatsController, c *fiber.Ctx, get func(models.StatsFilter) (T, error)) error {
+	filter, err := repository.StatsFilterFromQuery(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid date filter; use YYYY-MM-DD", err))
+	}
+	data, generatedAt, err := h.cache.Get(statsKey(c, filter), func() (interface{}, error) {
+		return get(filter)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to compute statistics", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":       "success",
+		"message":      "Statistics retrieved successfully",
+		"data":         data,
+		"generated_at": generatedAt,
+	})
+}
+
+// ================================
+
+// GetDashboard godoc
+//
+//	@Summary		All dashboard statistics in one call
+//	@Description	Returns every statistic below under one key each. Figures are cached for five minutes; generated_at says when they were computed.
+//	@Tags			Statistics
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/stats [get]
+func (h *StatsController) GetDashboard(c *fiber.Ctx) error {
+	return respondStats(h, c, func(filter models.StatsFilter) (fiber.Map, error) {
+		sections := []struct {
+			key string
+			get func() (interface{}, error)
+		}{
+			{"cases_by_status", func() (interface{}, error) { return h.repo.GetCasesByStatus(filter) }},
+			{"cases_by_charge", func() (interface{}, error) { return h.repo.GetCasesByCharge(filter) }},
+			{"cases_by_station", func() (interface{}, error) { return h.repo.GetCasesByStation(filter) }},
+			{"cases_by_district", func() (interface{}, error) { return h.repo.GetCasesByDistrict(filter) }},
+			{"cases_by_month", func() (interface{}, error) { return h.repo.GetCasesByMonth(filter) }},
+			{"victims_by_age_band", func() (interface{}, error) { return h.repo.GetVictimsByAgeBand(filter) }},
+			{"relationships", func() (interface{}, error) { return h.repo.GetRelationshipStats(filter) }},
+			{"timeliness", func() (interface{}, error) { return h.repo.GetTimeliness(filter) }},
+		}
+		dashboard := fiber.Map{}
+		for _, section := range sections {
+			data, err := section.get()
+			if err != nil {
+				return nil, err
+			}
+			dashboard[section.key] = data
+		}
+		return dashboard, nil
+	})
+}
+
+// ================================
+
+// GetCasesByStatus godoc
+//
+//	@Summary		Count cases by status
+//	@Tags			Statistics
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/stats/cases/status [get]
+func (h *StatsController) GetCasesByStatus(c *fiber.Ctx) error {
+	return respondStats(h, c, h.repo.GetCasesByStatus)
+}
+
+// ================================
+
+// GetCasesByCharge godoc
+//
+//	@Summary		Count cases by charge
+//	@Description	A case with several charges is counted under each.
+//	@Tags			Statistics
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/stats/cases/charges [get]
+func (h *StatsController) GetCasesByCharge(c *fiber.Ctx) error {
+	return respondStats(h, c, h.repo.GetCasesByCharge)
+}
+
+// ================================
+
+// GetCasesByStation godoc
+//
+//	@Summary		Count cases by police post and station
+//	@Tags			Statistics
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/stats/cases/stations [get]
+func (h *StatsController) GetCasesByStation(c *fiber.Ctx) error {
+	return respondStats(h, c, h.repo.GetCasesByStation)
+}
+
+// ================================
+
+// GetCasesByDistrict godoc
+//
+//	@Summary		Count cases by district
+//	@Description	Places each case in the district of its earliest incident. Cases without incidents are counted as unknown.
+//	@Tags			Statistics
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/stats/cases/districts [get]
+func (h *StatsController) GetCasesByDistrict(c *fiber.Ctx) error {
+	return respondStats(h, c, h.repo.GetCasesByDistrict)
+}
+
+// ================================
+
+// GetCasesByMonth godoc
+//
+//	@Summary		Monthly trend of cases opened, by status
+//	@Tags			Statistics
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/stats/cases/monthly [get]
+func (h *StatsController) GetCasesByMonth(c *fiber.Ctx) error {
+	return respondStats(h, c, h.repo.GetCasesByMonth)
+}
+
+// ================================
+
+// GetVictimsByAgeBand godoc
+//
+//	@Summary		Count victims by age band and gender
+//	@Description	Ages are taken at the case's incident date. Each victim is counted once per case.
+//	@Tags			Statistics
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/stats/victims/age-gender [get]
+func (h *StatsController) GetVictimsByAgeBand(c *fiber.Ctx) error {
+	return respondStats(h, c, h.repo.GetVictimsByAgeBand)
+}
+
+// ================================
+
+// GetRelationshipStats godoc
+//
+//	@Summary		Count victim-suspect pairs by relationship
+//	@Tags			Statistics
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/stats/relationships [get]
+func (h *StatsController) GetRelationshipStats(c *fiber.Ctx) error {
+	return respondStats(h, c, h.repo.GetRelationshipStats)
+}
+
+// ================================
+
+// GetTimeliness godoc
+//
+//	@Summary		Median time to arrest and to court
+//	@Description	report_to_arrest: days from the case being opened to its first arrest. report_to_court: days from the case being opened to the first production of a suspect in court. arrest_to_court: hours from the start of detention to production in court, over custody records. Median is null when nothing can be measured.
+//	@Tags			Statistics
+//	@Produce		json
+//	@Param			police_post_id	query		int			false	"Limit to cases of one police post"
+//	@Param			from			query		string		false	"Cases opened on or after (YYYY-MM-DD)"
+//	@Param			to				query		string		false	"Cases opened on or before (YYYY-MM-DD)"
+//	@Success		200				{object}	fiber.Map	"Statistics retrieved successfully"
+//	@Failure		400				{object}	fiber.Map	"Invalid date filter"
+//	@Failure		500				{object}	fiber.Map	"Server error when computing statistics"
+//	@Router			/stats/timeliness [get]
+func (h *StatsController) GetTimeliness(c *fiber.Ctx) error {
+	return respondStats(h, c, h.repo.GetTimeliness)
+}
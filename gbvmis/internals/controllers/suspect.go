@@ -2,20 +2,140 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"gbvmis/internals/models"
 	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/storage"
 	"gbvmis/internals/utils"
+	"log"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 type SuspectController struct {
-	repo repository.SuspectRepository
+	repo      repository.SuspectRepository
+	store     storage.BlobStore
+	watchlist repository.WatchlistRepository
 }
 
-func NewSuspectController(repo repository.SuspectRepository) *SuspectController {
-	return &SuspectController{repo: repo}
+func NewSuspectController(repo repository.SuspectRepository, store storage.BlobStore, watchlist repository.WatchlistRepository) *SuspectController {
+	return &SuspectController{repo: repo, store: store, watchlist: watchlist}
+}
+
+type PriorCaseResponse struct {
+	CaseID     uint      `json:"case_id"`
+	CaseNumber string    `json:"case_number"`
+	Title      string    `json:"title"`
+	Status     string    `json:"status"`
+	DateOpened time.Time `json:"date_opened"`
+	PolicePost string    `json:"police_post"`
+	Charges    []string  `json:"charges"`
+}
+
+func ConvertToPriorCaseResponse(casee models.Case) PriorCaseResponse {
+	charges := make([]string, len(casee.Charges))
+	for i, charge := range casee.Charges {
+		charges[i] = charge.ChargeTitle
+	}
+	return PriorCaseResponse{
+		CaseID:     casee.ID,
+		CaseNumber: casee.CaseNumber,
+		Title:      casee.Title,
+		Status:     casee.Status,
+		DateOpened: casee.DateOpened,
+		PolicePost: casee.PolicePost.Name,
+		Charges:    charges,
+	}
+}
+
+// stageSuspectFile spools an uploaded photo or fingerprint and checks its format
+func stageSuspectFile(fileHeader *multipart.FileHeader, kind string) (*storage.StagedBlob, *fiber.Error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Failed to open "+kind+" file")
+	}
+	defer file.Close()
+
+	staged, err := storage.Stage(file)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to read "+kind+" file")
+	}
+	if err := service.ValidateSuspectMedia(kind, staged.MimeType); err != nil {
+		staged.Close()
+		return nil, fiber.NewError(fiber.StatusUnsupportedMediaType, err.Error())
+	}
+	return staged, nil
+}
+
+// stageLegacyUploads picks up the photo and fingerprints fields still accepted
+// on create and update, so they can be stored as suspect media
+func stageLegacyUploads(c *fiber.Ctx) (map[string]*storage.StagedBlob, *fiber.Error) {
+	staged := map[string]*storage.StagedBlob{}
+	for field, kind := range map[string]string{
+		"photo":        models.SuspectMediaPhoto,
+		"fingerprints": models.SuspectMediaFingerprint,
+	} {
+		fileHeader, err := c.FormFile(field)
+		if err != nil || fileHeader == nil {
+			continue
+		}
+		blob, ferr := stageSuspectFile(fileHeader, kind)
+		if ferr != nil {
+			closeStaged(staged)
+			return nil, ferr
+		}
+		staged[kind] = blob
+	}
+	return staged, nil
+}
+
+func closeStaged(staged map[string]*storage.StagedBlob) {
+	for _, blob := range staged {
+		blob.Close()
+	}
+}
+
+// legacyUploads pairs what stageLegacyUploads found with the media rows to
+// record for it
+func legacyUploads(c *fiber.Ctx, staged map[string]*storage.StagedBlob) []service.SuspectUpload {
+	var uploads []service.SuspectUpload
+	for kind, blob := range staged {
+		media := models.SuspectMedia{
+			Kind:         kind,
+			View:         models.SuspectViewFront,
+			CapturedAt:   time.Now(),
+			FileName:     kind,
+			UploadedByID: c.Locals("user").(*utils.Claims).UserID,
+		}
+		if kind == models.SuspectMediaFingerprint {
+			media.View = "unspecified"
+			media.TemplateHash = c.FormValue("fingerprint_template_hash")
+		}
+		uploads = append(uploads, service.SuspectUpload{Staged: blob, Media: media})
+	}
+	return uploads
+}
+
+// storeLegacyUploads saves what stageLegacyUploads found as media of an
+// existing suspect
+func (h *SuspectController) storeLegacyUploads(c *fiber.Ctx, suspectID uint, staged map[string]*storage.StagedBlob) ([]models.SuspectMedia, error) {
+	var saved []models.SuspectMedia
+	for _, upload := range legacyUploads(c, staged) {
+		media := upload.Media
+		media.SuspectID = suspectID
+		if err := service.StoreSuspectMedia(c.Context(), h.repo, h.store, upload.Staged, &media); err != nil {
+			return saved, err
+		}
+		media.SetURLs()
+		saved = append(saved, media)
+	}
+	return saved, nil
 }
 
 // ==========================
@@ -23,7 +143,7 @@ func NewSuspectController(repo repository.SuspectRepository) *SuspectController
 // CreateSuspect godoc
 //
 //	@Summary		Create a new suspect record with photo and fingerprints upload
-//	@Description	Creates a new suspect entry with photo and fingerprints files.
+//	@Description	Creates a new suspect entry. Optional photo (JPEG/PNG) and fingerprints (WSQ/JPEG/PNG) files are stored as suspect media, and the suspect is only recorded if they are; more can be added through /suspect/{id}/media.
 //	@Tags			Suspects
 //	@Accept			multipart/form-data
 //	@Produce		json
@@ -40,10 +160,19 @@ func NewSuspectController(repo repository.SuspectRepository) *SuspectController
 //	@Param			status			formData	string		false	"Status"
 //	@Param			created_by		formData	string		true	"Created By"
 //	@Param			updated_by		formData	string		false	"Updated By"
+//	@Param			height_cm		formData	int			false	"Height in centimetres"
+//	@Param			build			formData	string		false	"Build, e.g. slim, medium, heavy"
+//	@Param			complexion		formData	string		false	"Complexion"
+//	@Param			eye_colour		formData	string		false	"Eye colour"
+//	@Param			hair			formData	string		false	"Hair description"
+//	@Param			physical_remarks	formData	string	false	"Other physical description"
+//	@Param			aliases			formData	string		false	"Comma-separated aliases and nicknames"
 //	@Param			photo			formData	file		false	"Photo file upload"
 //	@Param			fingerprints	formData	file		false	"Fingerprints file upload"
-//	@Success		201				{object}	fiber.Map	"Successfully created suspect record"
+//	@Param			fingerprint_template_hash	formData	string	false	"Fingerprint template hash from the capture device"
+//	@Success		201				{object}	fiber.Map	"Successfully created suspect record, with possible_duplicates and watchlist_alerts"
 //	@Failure		400				{object}	fiber.Map	"Bad request due to invalid input or file error"
+//	@Failure		415				{object}	fiber.Map	"Photo or fingerprints in an unsupported format"
 //	@Failure		500				{object}	fiber.Map	"Server error when creating suspect"
 //	@Router			/suspect [post]
 func (h *SuspectController) CreateSuspect(c *fiber.Ctx) error {
@@ -74,6 +203,19 @@ func (h *SuspectController) CreateSuspect(c *fiber.Ctx) error {
 	suspect.Status = c.FormValue("status")
 	suspect.CreatedBy = c.FormValue("created_by")
 	suspect.UpdatedBy = c.FormValue("updated_by")
+	suspect.Build = c.FormValue("build")
+	suspect.Complexion = c.FormValue("complexion")
+	suspect.EyeColour = c.FormValue("eye_colour")
+	suspect.Hair = c.FormValue("hair")
+	suspect.PhysicalRemarks = c.FormValue("physical_remarks")
+	if height := c.FormValue("height_cm"); height != "" {
+		suspect.HeightCm = utils.StrToInt(height)
+	}
+	for _, alias := range strings.Split(c.FormValue("aliases"), ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			suspect.Aliases = append(suspect.Aliases, models.SuspectAlias{Alias: alias, Kind: "alias"})
+		}
+	}
 
 	// Validate required fields
 	if suspect.FirstName == "" || suspect.LastName == "" || suspect.CreatedBy == "" {
@@ -83,69 +225,50 @@ func (h *SuspectController) CreateSuspect(c *fiber.Ctx) error {
 		})
 	}
 
-	// Handle photo file upload
-	photoFile, err := c.FormFile("photo")
-	if err == nil && photoFile != nil {
-		photoOpened, err := photoFile.Open()
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Failed to open photo file",
-				"data":    err.Error(),
-			})
-		}
-		defer photoOpened.Close()
-
-		photoBytes := make([]byte, photoFile.Size)
-		_, err = photoOpened.Read(photoBytes)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Failed to read photo file",
-				"data":    err.Error(),
-			})
-		}
-		suspect.Photo = photoBytes
-	}
-
-	// Handle fingerprints file upload
-	fpFile, err := c.FormFile("fingerprints")
-	if err == nil && fpFile != nil {
-		fpOpened, err := fpFile.Open()
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Failed to open fingerprints file",
-				"data":    err.Error(),
-			})
-		}
-		defer fpOpened.Close()
-
-		fpBytes := make([]byte, fpFile.Size)
-		_, err = fpOpened.Read(fpBytes)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Failed to read fingerprints file",
-				"data":    err.Error(),
-			})
-		}
-		suspect.Fingerprints = fpBytes
+	// Photo and fingerprints go to the blob store as suspect media
+	staged, ferr := stageLegacyUploads(c)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"status":  "error",
+			"message": ferr.Message,
+		})
 	}
+	defer closeStaged(staged)
 
-	// Save suspect to database
-	if err := h.repo.CreateSuspect(&suspect); err != nil {
+	// Save the suspect and their media together, so neither is kept without the other
+	if err := service.CreateSuspectWithMedia(c.Context(), h.repo, h.store, &suspect, legacyUploads(c, staged)); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Failed to create suspect",
 			"data":    err.Error(),
 		})
 	}
+	for i := range suspect.Media {
+		suspect.Media[i].SetURLs()
+	}
+
+	// Flag likely duplicates so the officer can merge instead of splitting history
+	duplicates, err := service.FindSuspectDuplicates(h.repo, suspect)
+	if err != nil {
+		log.Println("Error checking suspect duplicates for", suspect.ID, err)
+	}
+
+	// Warn the officer, and tell the issuing station, if this is a wanted person
+	alerts, err := service.CheckWatchlist(h.watchlist, service.WatchlistCheck{
+		Trigger:   models.WatchlistTriggerSuspectCreate,
+		OfficerID: c.Locals("user").(*utils.Claims).UserID,
+		Suspects:  []models.Suspect{suspect},
+	})
+	if err != nil {
+		log.Println("Error checking watchlist for suspect", suspect.ID, err)
+	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"status":  "success",
-		"message": "Suspect created successfully",
-		"data":    suspect,
+		"status":              "success",
+		"message":             "Suspect created successfully",
+		"data":                suspect,
+		"possible_duplicates": duplicates,
+		"watchlist_alerts":    alerts,
 	})
 }
 
@@ -154,7 +277,7 @@ func (h *SuspectController) CreateSuspect(c *fiber.Ctx) error {
 // UpdateSuspect godoc
 //
 //	@Summary		Update a suspect record by ID
-//	@Description	Partially updates fields of an existing suspect, including photo and fingerprints.
+//	@Description	Partially updates fields of an existing suspect. Photo and fingerprints files are added as new suspect media.
 //	@Tags			Suspects
 //	@Accept			multipart/form-data
 //	@Produce		json
@@ -171,11 +294,19 @@ func (h *SuspectController) CreateSuspect(c *fiber.Ctx) error {
 //	@Param			occupation		formData	string		false	"Occupation"
 //	@Param			status			formData	string		false	"Status"
 //	@Param			updated_by		formData	string		true	"Updated By"
+//	@Param			height_cm		formData	int			false	"Height in centimetres"
+//	@Param			build			formData	string		false	"Build, e.g. slim, medium, heavy"
+//	@Param			complexion		formData	string		false	"Complexion"
+//	@Param			eye_colour		formData	string		false	"Eye colour"
+//	@Param			hair			formData	string		false	"Hair description"
+//	@Param			physical_remarks	formData	string	false	"Other physical description"
 //	@Param			photo			formData	file		false	"Photo file upload"
 //	@Param			fingerprints	formData	file		false	"Fingerprints file upload"
+//	@Param			fingerprint_template_hash	formData	string	false	"Fingerprint template hash from the capture device"
 //	@Success		200				{object}	fiber.Map	"Suspect updated successfully"
 //	@Failure		400				{object}	fiber.Map	"Bad request due to invalid input or file error"
 //	@Failure		404				{object}	fiber.Map	"Suspect not found"
+//	@Failure		415				{object}	fiber.Map	"Photo or fingerprints in an unsupported format"
 //	@Failure		500				{object}	fiber.Map	"Server error when updating suspect"
 //	@Router			/suspect/{id} [put]
 func (h *SuspectController) UpdateSuspect(c *fiber.Ctx) error {
@@ -188,7 +319,7 @@ func (h *SuspectController) UpdateSuspect(c *fiber.Ctx) error {
 	}
 
 	// Verify suspect exists
-	_, err := h.repo.GetSuspectByID(id)
+	suspect, err := h.repo.GetSuspectByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
@@ -223,6 +354,14 @@ func (h *SuspectController) UpdateSuspect(c *fiber.Ctx) error {
 	addUpdate("address")
 	addUpdate("occupation")
 	addUpdate("status")
+	addUpdate("build")
+	addUpdate("complexion")
+	addUpdate("eye_colour")
+	addUpdate("hair")
+	addUpdate("physical_remarks")
+	if height := c.FormValue("height_cm"); height != "" {
+		updates["height_cm"] = utils.StrToInt(height)
+	}
 
 	// UpdatedBy is required for audit
 	updatedBy := c.FormValue("updated_by")
@@ -248,55 +387,15 @@ func (h *SuspectController) UpdateSuspect(c *fiber.Ctx) error {
 		updates["dob"] = parsedDob.Format("2006-01-02")
 	}
 
-	// Handle photo file upload
-	photoFile, err := c.FormFile("photo")
-	if err == nil && photoFile != nil {
-		photoOpened, err := photoFile.Open()
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Failed to open photo file",
-				"data":    err.Error(),
-			})
-		}
-		defer photoOpened.Close()
-
-		photoBytes := make([]byte, photoFile.Size)
-		_, err = photoOpened.Read(photoBytes)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Failed to read photo file",
-				"data":    err.Error(),
-			})
-		}
-		updates["photo"] = photoBytes
-	}
-
-	// Handle fingerprints file upload
-	fpFile, err := c.FormFile("fingerprints")
-	if err == nil && fpFile != nil {
-		fpOpened, err := fpFile.Open()
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Failed to open fingerprints file",
-				"data":    err.Error(),
-			})
-		}
-		defer fpOpened.Close()
-
-		fpBytes := make([]byte, fpFile.Size)
-		_, err = fpOpened.Read(fpBytes)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "error",
-				"message": "Failed to read fingerprints file",
-				"data":    err.Error(),
-			})
-		}
-		updates["fingerprints"] = fpBytes
+	// Photo and fingerprints are added as new suspect media
+	staged, ferr := stageLegacyUploads(c)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"status":  "error",
+			"message": ferr.Message,
+		})
 	}
+	defer closeStaged(staged)
 
 	// Check if anything to update
 	if len(updates) == 0 {
@@ -315,6 +414,18 @@ func (h *SuspectController) UpdateSuspect(c *fiber.Ctx) error {
 		})
 	}
 
+	if len(staged) > 0 {
+		media, err := h.storeLegacyUploads(c, suspect.ID, staged)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Suspect updated but failed to store photo or fingerprints",
+				"data":    err.Error(),
+			})
+		}
+		updates["media"] = media
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"status":  "success",
 		"message": "Suspect updated successfully",
@@ -361,7 +472,7 @@ func (h *SuspectController) GetAllSuspects(c *fiber.Ctx) error {
 // GetSingleSuspect godoc
 //
 //	@Summary		Retrieve a single suspect record by ID
-//	@Description	Fetches a suspect record based on the provided ID.
+//	@Description	Fetches a suspect record with its prior cases across all police posts, a repeat-offender flag and likely duplicate records.
 //	@Tags			Suspects
 //	@Accept			json
 //	@Produce		json
@@ -390,11 +501,32 @@ func (h *SuspectController) GetSingleSuspect(c *fiber.Ctx) error {
 		})
 	}
 
+	priorCases, err := h.repo.GetPriorCases(suspect.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to retrieve prior cases",
+			"data":    err.Error(),
+		})
+	}
+	duplicates, err := service.FindSuspectDuplicates(h.repo, suspect)
+	if err != nil {
+		log.Println("Error checking suspect duplicates for", suspect.ID, err)
+	}
+
+	priorCaseResponses := make([]PriorCaseResponse, len(priorCases))
+	for i, casee := range priorCases {
+		priorCaseResponses[i] = ConvertToPriorCaseResponse(casee)
+	}
+
 	// Return the response
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"status":  "success",
-		"message": "Suspect and associated data retrieved successfully",
-		"data":    suspect,
+		"status":              "success",
+		"message":             "Suspect and associated data retrieved successfully",
+		"data":                suspect,
+		"prior_cases":         priorCaseResponses,
+		"repeat_offender":     len(priorCases) > 1,
+		"possible_duplicates": duplicates,
 	})
 }
 
@@ -454,10 +586,14 @@ func (h *SuspectController) DeleteSuspectByID(c *fiber.Ctx) error {
 // SearchSuspects godoc
 //
 //	@Summary		Search for suspects with pagination
-//	@Description	Retrieves a paginated list of suspects based on search criteria.
+//	@Description	Retrieves a paginated list of suspects based on search criteria. name matches any part of the name or any alias. watchlist_alerts lists wanted or watched persons matching the results or the search terms; their issuing stations are notified.
 //	@Tags			Suspects
 //	@Accept			json
 //	@Produce		json
+//	@Param			name					query		string		false	"Name or alias"
+//	@Param			alias					query		string		false	"Alias or nickname"
+//	@Param			relationship_to_victim	query		string		false	"Relationship to a victim in any of the suspect's cases"
+//	@Param			mark					query		string		false	"Identifying mark description or location"
 //	@Success		200	{object}	fiber.Map	"Suspects retrieved successfully"
 //	@Failure		500	{object}	fiber.Map	"Failed to retrieve suspects"
 //	@Router			/suspects/search [get]
@@ -472,11 +608,613 @@ func (h *SuspectController) SearchSuspects(c *fiber.Ctx) error {
 		})
 	}
 
+	alerts, err := service.CheckWatchlist(h.watchlist, service.WatchlistCheck{
+		Trigger:   models.WatchlistTriggerSuspectSearch,
+		OfficerID: c.Locals("user").(*utils.Claims).UserID,
+		Suspects: service.WatchlistSubjectsFromQuery(
+			c.Query("first_name"), c.Query("last_name"), c.Query("name"),
+			c.Query("phone_number"), c.Query("nin"), suspects),
+	})
+	if err != nil {
+		log.Println("Error checking watchlist for suspect search:", err)
+	}
+
 	// Return the response with pagination details
 	return c.Status(200).JSON(fiber.Map{
-		"status":     "success",
-		"message":    "Suspects retrieved successfully",
-		"pagination": pagination,
-		"data":       suspects,
+		"status":           "success",
+		"message":          "Suspects retrieved successfully",
+		"pagination":       pagination,
+		"data":             suspects,
+		"watchlist_alerts": alerts,
 	})
 }
+
+// ==================
+
+// UploadSuspectMedia godoc
+//
+//	@Summary		Add a photo or fingerprint image to a suspect
+//	@Description	Streams the file to the blob store. Photos must be JPEG or PNG, fingerprints WSQ, JPEG or PNG. JPEG and PNG get a thumbnail.
+//	@Tags			Suspects
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			id			path		string		true	"Suspect ID"
+//	@Param			file		formData	file		true	"Image file"
+//	@Param			kind		formData	string		true	"photo or fingerprint"
+//	@Param			view		formData	string		false	"front, left_profile, right_profile, other; or finger position for fingerprints"
+//	@Param			captured_at	formData	string		false	"Capture date in YYYY-MM-DD format, defaults to today"
+//	@Param			template_hash	formData	string	false	"Fingerprint template hash from the capture device"
+//	@Success		201			{object}	fiber.Map	"Suspect media uploaded successfully"
+//	@Failure		400			{object}	fiber.Map	"Invalid input"
+//	@Failure		404			{object}	fiber.Map	"Suspect not found"
+//	@Failure		415			{object}	fiber.Map	"Unsupported file format"
+//	@Failure		500			{object}	fiber.Map	"Server error when storing media"
+//	@Router			/suspect/{id}/media [post]
+func (h *SuspectController) UploadSuspectMedia(c *fiber.Ctx) error {
+	suspect, err := h.repo.GetSuspectByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Suspect not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve suspect", err))
+	}
+
+	kind := c.FormValue("kind")
+	if kind != models.SuspectMediaPhoto && kind != models.SuspectMediaFingerprint {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "kind must be photo or fingerprint",
+		})
+	}
+	view := c.FormValue("view")
+	if kind == models.SuspectMediaPhoto {
+		switch view {
+		case "":
+			view = models.SuspectViewFront
+		case models.SuspectViewFront, models.SuspectViewLeftProfile, models.SuspectViewRightProfile, models.SuspectViewOther:
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "view must be front, left_profile, right_profile or other",
+			})
+		}
+	} else if view == "" {
+		view = "unspecified"
+	}
+
+	capturedAt := time.Now()
+	if v := c.FormValue("captured_at"); v != "" {
+		capturedAt, err = utils.ParseDate(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid date format for captured_at, expected YYYY-MM-DD",
+				"data":    err.Error(),
+			})
+		}
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("file is required", err))
+	}
+	staged, ferr := stageSuspectFile(fileHeader, kind)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"status":  "error",
+			"message": ferr.Message,
+		})
+	}
+	defer staged.Close()
+
+	media := models.SuspectMedia{
+		SuspectID:    suspect.ID,
+		Kind:         kind,
+		View:         view,
+		CapturedAt:   capturedAt,
+		FileName:     fileHeader.Filename,
+		UploadedByID: c.Locals("user").(*utils.Claims).UserID,
+	}
+	if kind == models.SuspectMediaFingerprint {
+		media.TemplateHash = c.FormValue("template_hash")
+	}
+	if err := service.StoreSuspectMedia(c.Context(), h.repo, h.store, staged, &media); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to store suspect media", err))
+	}
+	media.SetURLs()
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Suspect media uploaded successfully", media))
+}
+
+// GetSuspectMedia godoc
+//
+//	@Summary		List photos and fingerprints of a suspect
+//	@Description	Lists suspect media with download and thumbnail links, photos first.
+//	@Tags			Suspects
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Suspect ID"
+//	@Success		200	{object}	fiber.Map	"Suspect media retrieved successfully"
+//	@Failure		400	{object}	fiber.Map	"Invalid suspect ID"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve suspect media"
+//	@Router			/suspect/{id}/media [get]
+func (h *SuspectController) GetSuspectMedia(c *fiber.Ctx) error {
+	suspectID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid suspect ID", err))
+	}
+
+	media, err := h.repo.ListSuspectMedia(uint(suspectID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve suspect media", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Suspect media retrieved successfully", media))
+}
+
+// streamSuspectBlob loads a suspect media row and streams either its original or its thumbnail
+func (h *SuspectController) streamSuspectBlob(c *fiber.Ctx, thumbnail bool) error {
+	media, err := h.repo.GetSuspectMediaByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Suspect media not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve suspect media", err))
+	}
+
+	key, contentType, size := media.BlobSHA256, media.MimeType, int(media.Size)
+	if thumbnail {
+		if media.ThumbnailSHA256 == "" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "No thumbnail for this media",
+			})
+		}
+		key, contentType, size = media.ThumbnailSHA256, "image/jpeg", -1
+	}
+
+	content, err := h.store.Get(c.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Media content is missing from storage",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to read suspect media", err))
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderETag, `"`+key+`"`)
+	if !thumbnail {
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", strings.ReplaceAll(media.FileName, `"`, "")))
+	}
+	return c.SendStream(content, size)
+}
+
+// DownloadSuspectMedia godoc
+//
+//	@Summary		Download a suspect photo or fingerprint
+//	@Description	Streams the original file.
+//	@Tags			Suspects
+//	@Produce		octet-stream
+//	@Param			id	path		string		true	"Suspect media ID"
+//	@Success		200	{file}		binary		"Media content"
+//	@Failure		404	{object}	fiber.Map	"Suspect media not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when reading media"
+//	@Router			/suspect-media/{id}/download [get]
+func (h *SuspectController) DownloadSuspectMedia(c *fiber.Ctx) error {
+	return h.streamSuspectBlob(c, false)
+}
+
+// GetSuspectMediaThumbnail godoc
+//
+//	@Summary		Get the thumbnail of a suspect photo or fingerprint
+//	@Description	Streams a JPEG thumbnail, at most 200px on its longest side. WSQ fingerprints have none.
+//	@Tags			Suspects
+//	@Produce		jpeg
+//	@Param			id	path		string		true	"Suspect media ID"
+//	@Success		200	{file}		binary		"Thumbnail"
+//	@Failure		404	{object}	fiber.Map	"Suspect media or thumbnail not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when reading thumbnail"
+//	@Router			/suspect-media/{id}/thumbnail [get]
+func (h *SuspectController) GetSuspectMediaThumbnail(c *fiber.Ctx) error {
+	return h.streamSuspectBlob(c, true)
+}
+
+// DeleteSuspectMedia godoc
+//
+//	@Summary		Delete a suspect photo or fingerprint
+//	@Description	Removes the media record. Stored content is deleted once nothing else references it.
+//	@Tags			Suspects
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Suspect media ID"
+//	@Success		200	{object}	fiber.Map	"Suspect media deleted successfully"
+//	@Failure		404	{object}	fiber.Map	"Suspect media not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting media"
+//	@Router			/suspect-media/{id} [delete]
+func (h *SuspectController) DeleteSuspectMedia(c *fiber.Ctx) error {
+	media, err := h.repo.GetSuspectMediaByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Suspect media not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve suspect media", err))
+	}
+
+	if err := h.repo.DeleteSuspectMedia(&media); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete suspect media", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Suspect media deleted successfully", media))
+}
+
+// ==================
+
+// GetSuspectDuplicates godoc
+//
+//	@Summary		List likely duplicates of a suspect
+//	@Description	Matches other suspect records on NIN, normalised phone number, fuzzy name with date of birth and fingerprint hashes.
+//	@Tags			Suspects
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Suspect ID"
+//	@Success		200	{object}	fiber.Map	"Possible duplicates retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Suspect not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when matching"
+//	@Router			/suspect/{id}/duplicates [get]
+func (h *SuspectController) GetSuspectDuplicates(c *fiber.Ctx) error {
+	suspect, err := h.repo.GetSuspectByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Suspect not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve suspect", err))
+	}
+
+	duplicates, err := service.FindSuspectDuplicates(h.repo, suspect)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to match suspect", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Possible duplicates retrieved successfully", duplicates))
+}
+
+type MergeSuspectPayload struct {
+	DuplicateID uint `json:"duplicate_id" validate:"required"`
+}
+
+// MergeSuspect godoc
+//
+//	@Summary		Merge a duplicate suspect into this one
+//	@Description	Moves the duplicate's cases, arrests, custody records, bonds and media onto this suspect, fills in details this record lacks, and retires the duplicate. Administrators only.
+//	@Tags			Suspects
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"ID of the suspect to keep"
+//	@Param			payload	body		MergeSuspectPayload	true	"Duplicate to merge"
+//	@Success		200		{object}	fiber.Map			"Suspects merged successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		403		{object}	fiber.Map			"Not an administrator"
+//	@Failure		404		{object}	fiber.Map			"Suspect not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when merging"
+//	@Router			/suspect/{id}/merge [post]
+func (h *SuspectController) MergeSuspect(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "merge suspects"); !ok {
+		return err
+	}
+
+	var payload MergeSuspectPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	keep, err := h.repo.GetSuspectByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Suspect not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve suspect", err))
+	}
+	if keep.ID == payload.DuplicateID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "A suspect cannot be merged into itself",
+		})
+	}
+	duplicate, err := h.repo.GetSuspectByID(strconv.Itoa(int(payload.DuplicateID)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Duplicate suspect not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve duplicate suspect", err))
+	}
+
+	// Keep what the surviving record has; take from the duplicate only what it lacks
+	fill := map[string]interface{}{}
+	fillIfEmpty := func(column, kept, other string) {
+		if strings.TrimSpace(kept) == "" && strings.TrimSpace(other) != "" {
+			fill[column] = other
+		}
+	}
+	fillIfEmpty("middle_name", keep.MiddleName, duplicate.MiddleName)
+	fillIfEmpty("gender", keep.Gender, duplicate.Gender)
+	fillIfEmpty("phone_number", keep.PhoneNumber, duplicate.PhoneNumber)
+	fillIfEmpty("nin", keep.Nin, duplicate.Nin)
+	fillIfEmpty("nationality", keep.Nationality, duplicate.Nationality)
+	fillIfEmpty("address", keep.Address, duplicate.Address)
+	fillIfEmpty("occupation", keep.Occupation, duplicate.Occupation)
+	if keep.Dob.IsZero() && !duplicate.Dob.IsZero() {
+		fill["dob"] = duplicate.Dob.Format("2006-01-02")
+	}
+	if len(fill) > 0 {
+		fill["updated_by"] = c.Locals("user").(*utils.Claims).Email
+	}
+
+	if err := h.repo.MergeSuspects(keep.ID, duplicate.ID, fill); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to merge suspects", err))
+	}
+	log.Printf("Suspect %d merged into %d by user %d", duplicate.ID, keep.ID, c.Locals("user").(*utils.Claims).UserID)
+
+	merged, err := h.repo.GetSuspectByID(strconv.Itoa(int(keep.ID)))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve merged suspect", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Suspects merged successfully", merged))
+}
+
+// ==================
+
+type SuspectAliasPayload struct {
+	Alias string `json:"alias" validate:"required"`
+	Kind  string `json:"kind" validate:"omitempty,oneof=alias nickname"`
+}
+
+type SuspectMarkPayload struct {
+	MarkType     string `json:"mark_type" validate:"required,oneof=scar tattoo birthmark amputation deformity other"`
+	BodyLocation string `json:"body_location" validate:"required"`
+	Description  string `json:"description"`
+}
+
+type SuspectAssociatePayload struct {
+	AssociateSuspectID *uint  `json:"associate_suspect_id"`
+	FullName           string `json:"full_name"`
+	PhoneNumber        string `json:"phone_number"`
+	Relationship       string `json:"relationship" validate:"required"`
+	Notes              string `json:"notes"`
+}
+
+// suspectForDetail parses a JSON payload and loads the suspect from the route,
+// writing the error response itself when either fails
+func (h *SuspectController) suspectForDetail(c *fiber.Ctx, payload interface{}) (models.Suspect, bool, error) {
+	if err := c.BodyParser(payload); err != nil {
+		return models.Suspect{}, false, c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return models.Suspect{}, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	suspect, err := h.repo.GetSuspectByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return suspect, false, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Suspect not found",
+			})
+		}
+		return suspect, false, c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve suspect", err))
+	}
+	return suspect, true, nil
+}
+
+// AddSuspectAlias godoc
+//
+//	@Summary		Add an alias or nickname to a suspect
+//	@Description	Aliases are matched by the name and alias filters of /suspects/search.
+//	@Tags			Suspects
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Suspect ID"
+//	@Param			payload	body		SuspectAliasPayload	true	"Alias"
+//	@Success		201		{object}	fiber.Map			"Alias added successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Suspect not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when adding alias"
+//	@Router			/suspect/{id}/aliases [post]
+func (h *SuspectController) AddSuspectAlias(c *fiber.Ctx) error {
+	var payload SuspectAliasPayload
+	suspect, ok, err := h.suspectForDetail(c, &payload)
+	if !ok {
+		return err
+	}
+
+	alias := models.SuspectAlias{
+		SuspectID: suspect.ID,
+		Alias:     strings.TrimSpace(payload.Alias),
+		Kind:      payload.Kind,
+	}
+	if alias.Kind == "" {
+		alias.Kind = "alias"
+	}
+	if err := h.repo.CreateSuspectAlias(&alias); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to add alias", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Alias added successfully", alias))
+}
+
+// DeleteSuspectAlias godoc
+//
+//	@Summary		Remove an alias from a suspect
+//	@Tags			Suspects
+//	@Produce		json
+//	@Param			id	path		string		true	"Alias ID"
+//	@Success		200	{object}	fiber.Map	"Alias deleted successfully"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting alias"
+//	@Router			/suspect-alias/{id} [delete]
+func (h *SuspectController) DeleteSuspectAlias(c *fiber.Ctx) error {
+	if err := h.repo.DeleteSuspectAlias(c.Params("id")); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete alias", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Alias deleted successfully", nil))
+}
+
+// AddSuspectMark godoc
+//
+//	@Summary		Record an identifying mark on a suspect
+//	@Description	Scars, tattoos, birthmarks and the like, with where on the body they are.
+//	@Tags			Suspects
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"Suspect ID"
+//	@Param			payload	body		SuspectMarkPayload	true	"Identifying mark"
+//	@Success		201		{object}	fiber.Map			"Identifying mark added successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		404		{object}	fiber.Map			"Suspect not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when adding mark"
+//	@Router			/suspect/{id}/marks [post]
+func (h *SuspectController) AddSuspectMark(c *fiber.Ctx) error {
+	var payload SuspectMarkPayload
+	suspect, ok, err := h.suspectForDetail(c, &payload)
+	if !ok {
+		return err
+	}
+
+	mark := models.SuspectMark{
+		SuspectID:    suspect.ID,
+		MarkType:     payload.MarkType,
+		BodyLocation: payload.BodyLocation,
+		Description:  payload.Description,
+	}
+	if err := h.repo.CreateSuspectMark(&mark); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to add identifying mark", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Identifying mark added successfully", mark))
+}
+
+// DeleteSuspectMark godoc
+//
+//	@Summary		Remove an identifying mark from a suspect
+//	@Tags			Suspects
+//	@Produce		json
+//	@Param			id	path		string		true	"Mark ID"
+//	@Success		200	{object}	fiber.Map	"Identifying mark deleted successfully"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting mark"
+//	@Router			/suspect-mark/{id} [delete]
+func (h *SuspectController) DeleteSuspectMark(c *fiber.Ctx) error {
+	if err := h.repo.DeleteSuspectMark(c.Params("id")); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete identifying mark", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Identifying mark deleted successfully", nil))
+}
+
+// AddSuspectAssociate godoc
+//
+//	@Summary		Record a known associate of a suspect
+//	@Description	Either links another suspect on record through associate_suspect_id or describes the person by name and phone.
+//	@Tags			Suspects
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Suspect ID"
+//	@Param			payload	body		SuspectAssociatePayload	true	"Associate"
+//	@Success		201		{object}	fiber.Map				"Associate added successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Suspect or associate not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when adding associate"
+//	@Router			/suspect/{id}/associates [post]
+func (h *SuspectController) AddSuspectAssociate(c *fiber.Ctx) error {
+	var payload SuspectAssociatePayload
+	suspect, ok, err := h.suspectForDetail(c, &payload)
+	if !ok {
+		return err
+	}
+
+	associate := models.SuspectAssociate{
+		SuspectID:          suspect.ID,
+		AssociateSuspectID: payload.AssociateSuspectID,
+		FullName:           payload.FullName,
+		PhoneNumber:        payload.PhoneNumber,
+		Relationship:       payload.Relationship,
+		Notes:              payload.Notes,
+	}
+	if payload.AssociateSuspectID != nil {
+		if *payload.AssociateSuspectID == suspect.ID {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "A suspect cannot be their own associate",
+			})
+		}
+		other, err := h.repo.GetSuspectByID(strconv.Itoa(int(*payload.AssociateSuspectID)))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"status":  "error",
+					"message": "Associate suspect not found",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve associate suspect", err))
+		}
+		if associate.FullName == "" {
+			associate.FullName = strings.Join(strings.Fields(other.FirstName+" "+other.MiddleName+" "+other.LastName), " ")
+		}
+	} else if strings.TrimSpace(payload.FullName) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Either associate_suspect_id or full_name is required",
+		})
+	}
+
+	if err := h.repo.CreateSuspectAssociate(&associate); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to add associate", err))
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Associate added successfully", associate))
+}
+
+// DeleteSuspectAssociate godoc
+//
+//	@Summary		Remove a known associate from a suspect
+//	@Tags			Suspects
+//	@Produce		json
+//	@Param			id	path		string		true	"Associate ID"
+//	@Success		200	{object}	fiber.Map	"Associate deleted successfully"
+//	@Failure		500	{object}	fiber.Map	"Server error when deleting associate"
+//	@Router			/suspect-associate/{id} [delete]
+func (h *SuspectController) DeleteSuspectAssociate(c *fiber.Ctx) error {
+	if err := h.repo.DeleteSuspectAssociate(c.Params("id")); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to delete associate", err))
+	}
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Associate deleted successfully", nil))
+}
@@ -1,9 +1,15 @@
 package controllers
 
 import (
+	"encoding/json"
 	"errors"
 	"gbvmis/internals/models"
 	"gbvmis/internals/repository"
+	"gbvmis/internals/service"
+	"gbvmis/internals/utils"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -32,12 +38,14 @@ type CreateVictimPayload struct {
 	CreatedBy   string `json:"created_by"`
 	UpdatedBy   string `json:"updated_by,omitempty"`
 	CaseIDs     []uint `json:"case_ids,omitempty"`
+
+	AddressAreaID *uint `json:"address_area_id,omitempty"` // Administrative area of the address
 }
 
 // CreateVictim godoc
 //
 //	@Summary		Create a new victim record
-//	@Description	Creates a new victim entry in the system and returns the created record.
+//	@Description	Creates a new victim entry and returns it with possible_duplicates: existing victims matching on NIN, phone or name with date of birth. Those pairs are added to the duplicate review queue. Children among them are shown redacted.
 //	@Tags			Victims
 //	@Accept			json
 //	@Produce		json
@@ -79,6 +87,11 @@ func (h *VictimController) CreateVictim(c *fiber.Ctx) error {
 		Nin:         payload.Nin,
 		CreatedBy:   payload.CreatedBy,
 		UpdatedBy:   payload.UpdatedBy,
+
+		AddressAreaID: payload.AddressAreaID,
+	}
+	if ok, err := checkAdminArea(c, "address_area_id", payload.AddressAreaID, h.repo.FindAdminAreaByID); !ok {
+		return err
 	}
 
 	// 4️⃣ Optionally associate existing cases
@@ -98,11 +111,21 @@ func (h *VictimController) CreateVictim(c *fiber.Ctx) error {
 		})
 	}
 
-	// 6️⃣ Return
+	// 6️⃣ Flag likely duplicates for review
+	duplicates, err := service.QueueVictimDuplicates(h.repo, victim)
+	if err != nil {
+		log.Println("Error checking victim duplicates for", victim.ID, err)
+	}
+	if err := h.redactMinorMatches(duplicates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Victim created but failed to check duplicates for children", err))
+	}
+
+	// 7️⃣ Return
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"status":  "success",
-		"message": "Victim created successfully",
-		"data":    victim,
+		"status":              "success",
+		"message":             "Victim created successfully",
+		"data":                victim,
+		"possible_duplicates": duplicates,
 	})
 }
 
@@ -111,7 +134,7 @@ func (h *VictimController) CreateVictim(c *fiber.Ctx) error {
 // GetAllVictims godoc
 //
 //	@Summary		Retrieve a paginated list of victims
-//	@Description	Fetches all victim records with pagination support.
+//	@Description	Fetches all victim records with pagination support. Children are shown redacted: initials only, without contact details or NIN.
 //	@Tags			Victims
 //	@Accept			json
 //	@Produce		json
@@ -127,6 +150,10 @@ func (h *VictimController) GetAllVictims(c *fiber.Ctx) error {
 			"data":    err.Error(),
 		})
 	}
+	if err := h.markMinors(victims); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victims", err))
+	}
+	service.RedactMinors(victims)
 
 	// Return the paginated response
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -147,7 +174,7 @@ func (h *VictimController) GetAllVictims(c *fiber.Ctx) error {
 // GetSingleVictim godoc
 //
 //	@Summary		Retrieve a single victim record by ID
-//	@Description	Fetches a victim record based on the provided ID.
+//	@Description	Fetches a victim record based on the provided ID, with guardians. A child's full record is only shown to admins and officers responsible for one of the child's cases; others get it redacted.
 //	@Tags			Victims
 //	@Accept			json
 //	@Produce		json
@@ -176,6 +203,22 @@ func (h *VictimController) GetSingleVictim(c *fiber.Ctx) error {
 		})
 	}
 
+	victims := []models.Victim{victim}
+	if err := h.markMinors(victims); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to check access to the victim", err))
+	}
+	victim = victims[0]
+
+	if victim.IsMinor {
+		allowed, err := h.canSeeChildDetails(c, victim.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to check access to the victim", err))
+		}
+		if !allowed {
+			service.RedactVictim(&victim)
+		}
+	}
+
 	// Return the response
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"status":  "success",
@@ -197,6 +240,8 @@ type UpdateVictimPayload struct {
 	Nationality string `json:"nationality,omitempty"`
 	Nin         string `json:"nin,omitempty"`
 	UpdatedBy   string `json:"updated_by,omitempty"`
+
+	AddressAreaID *uint `json:"address_area_id,omitempty"`
 }
 
 // UpdateVictim godoc
@@ -281,6 +326,12 @@ func (h *VictimController) UpdateVictim(c *fiber.Ctx) error {
 	if payload.UpdatedBy != "" {
 		updates["updated_by"] = payload.UpdatedBy
 	}
+	if payload.AddressAreaID != nil {
+		if ok, err := checkAdminArea(c, "address_area_id", payload.AddressAreaID, h.repo.FindAdminAreaByID); !ok {
+			return err
+		}
+		updates["address_area_id"] = *payload.AddressAreaID
+	}
 
 	// Update the Victim in the database
 	if err := h.repo.UpdateVictim(id, updates); err != nil {
@@ -355,7 +406,7 @@ func (h *VictimController) DeleteVictimByID(c *fiber.Ctx) error {
 // SearchVictims godoc
 //
 //	@Summary		Search for victims with pagination
-//	@Description	Retrieves a paginated list of victims based on search criteria.
+//	@Description	Retrieves a paginated list of victims based on search criteria. Children are shown redacted.
 //	@Tags			Victims
 //	@Accept			json
 //	@Produce		json
@@ -372,6 +423,10 @@ func (h *VictimController) SearchVictims(c *fiber.Ctx) error {
 			"data":    err.Error(),
 		})
 	}
+	if err := h.markMinors(victims); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victims", err))
+	}
+	service.RedactMinors(victims)
 
 	// Return the response with pagination details
 	return c.Status(200).JSON(fiber.Map{
@@ -381,3 +436,288 @@ func (h *VictimController) SearchVictims(c *fiber.Ctx) error {
 		"data":       victims,
 	})
 }
+
+// =================
+
+// GetVictimDuplicates godoc
+//
+//	@Summary		List likely duplicates of a victim
+//	@Description	Matches other victim records on NIN, normalised phone number and fuzzy name with date of birth. Children are shown redacted.
+//	@Tags			Victims
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Victim ID"
+//	@Success		200	{object}	fiber.Map	"Possible duplicates retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Victim not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when matching"
+//	@Router			/victim/{id}/duplicates [get]
+func (h *VictimController) GetVictimDuplicates(c *fiber.Ctx) error {
+	victim, err := h.repo.GetVictimByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Victim not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victim", err))
+	}
+
+	duplicates, err := service.FindVictimDuplicates(h.repo, victim)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to match victim", err))
+	}
+	if err := h.redactMinorMatches(duplicates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to match victim", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Possible duplicates retrieved successfully", duplicates))
+}
+
+// GetVictimDuplicateQueue godoc
+//
+//	@Summary		Review queue of likely duplicate victims
+//	@Description	Lists candidate pairs, highest score first. Filter with status=pending (default), merged, dismissed or all. Children are shown redacted.
+//	@Tags			Victims
+//	@Accept			json
+//	@Produce		json
+//	@Param			status	query		string		false	"pending, merged, dismissed or all"
+//	@Success		200		{object}	fiber.Map	"Duplicate candidates retrieved successfully"
+//	@Failure		500		{object}	fiber.Map	"Failed to retrieve duplicate candidates"
+//	@Router			/victim-duplicates [get]
+func (h *VictimController) GetVictimDuplicateQueue(c *fiber.Ctx) error {
+	pagination, duplicates, err := h.repo.GetPaginatedVictimDuplicates(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve duplicate candidates", err))
+	}
+	if err := h.redactMinorPairs(duplicates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve duplicate candidates", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Duplicate candidates retrieved successfully",
+		"data":    duplicates,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// DismissVictimDuplicate godoc
+//
+//	@Summary		Dismiss a duplicate candidate pair
+//	@Description	Marks the pair as different people so it leaves the review queue and is not queued again. Administrators only.
+//	@Tags			Victims
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Duplicate candidate ID"
+//	@Success		200	{object}	fiber.Map	"Duplicate candidate dismissed"
+//	@Failure		400	{object}	fiber.Map	"Candidate already reviewed"
+//	@Failure		403	{object}	fiber.Map	"Not an administrator"
+//	@Failure		404	{object}	fiber.Map	"Duplicate candidate not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when dismissing"
+//	@Router			/victim-duplicate/{id}/dismiss [post]
+func (h *VictimController) DismissVictimDuplicate(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "review duplicate victims"); !ok {
+		return err
+	}
+
+	duplicate, err := h.repo.GetVictimDuplicateByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Duplicate candidate not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve duplicate candidate", err))
+	}
+	if duplicate.Status != models.DuplicateStatusPending {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Duplicate candidate already " + duplicate.Status,
+		})
+	}
+
+	claims := c.Locals("user").(*utils.Claims)
+	if err := h.repo.DismissVictimDuplicate(duplicate.ID, claims.UserID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to dismiss duplicate candidate", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Duplicate candidate dismissed", nil))
+}
+
+type MergeVictimPayload struct {
+	DuplicateID uint   `json:"duplicate_id" validate:"required"`
+	Reason      string `json:"reason"`
+}
+
+// MergeVictim godoc
+//
+//	@Summary		Merge a duplicate victim into this one
+//	@Description	In one transaction, re-links the duplicate's cases and examinations to this victim, fills in details this record lacks, retires the duplicate and keeps an audit record of the merge. A child's record and snapshot are redacted unless the caller may see the child's full record. Administrators only.
+//	@Tags			Victims
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string				true	"ID of the victim to keep"
+//	@Param			payload	body		MergeVictimPayload	true	"Duplicate to merge"
+//	@Success		200		{object}	fiber.Map			"Victims merged successfully"
+//	@Failure		400		{object}	fiber.Map			"Invalid input"
+//	@Failure		403		{object}	fiber.Map			"Not an administrator"
+//	@Failure		404		{object}	fiber.Map			"Victim not found"
+//	@Failure		500		{object}	fiber.Map			"Server error when merging"
+//	@Router			/victim/{id}/merge [post]
+func (h *VictimController) MergeVictim(c *fiber.Ctx) error {
+	if ok, err := requireAdmin(c, "merge victims"); !ok {
+		return err
+	}
+
+	var payload MergeVictimPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	keep, err := h.repo.GetVictimByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Victim not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victim", err))
+	}
+	if keep.ID == payload.DuplicateID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "A victim cannot be merged into itself",
+		})
+	}
+	duplicate, err := h.repo.GetVictimByID(strconv.Itoa(int(payload.DuplicateID)))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Duplicate victim not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve duplicate victim", err))
+	}
+
+	// Keep what the surviving record has; take from the duplicate only what it lacks
+	fill := map[string]interface{}{}
+	fillIfEmpty := func(column, kept, other string) {
+		if strings.TrimSpace(kept) == "" && strings.TrimSpace(other) != "" {
+			fill[column] = other
+		}
+	}
+	fillIfEmpty("gender", keep.Gender, duplicate.Gender)
+	fillIfEmpty("phone_number", keep.PhoneNumber, duplicate.PhoneNumber)
+	fillIfEmpty("address", keep.Address, duplicate.Address)
+	fillIfEmpty("nationality", keep.Nationality, duplicate.Nationality)
+	fillIfEmpty("nin", keep.Nin, duplicate.Nin)
+	if keep.Dob.IsZero() && !duplicate.Dob.IsZero() {
+		fill["dob"] = duplicate.Dob.Format("2006-01-02")
+	}
+
+	claims := c.Locals("user").(*utils.Claims)
+	snapshot, err := json.Marshal(duplicate)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to snapshot duplicate victim", err))
+	}
+	filled, err := json.Marshal(fill)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to record merged fields", err))
+	}
+	if len(fill) > 0 {
+		fill["updated_by"] = claims.Email
+	}
+
+	merge := &models.VictimMerge{
+		KeptID:       keep.ID,
+		MergedID:     duplicate.ID,
+		MergedByID:   claims.UserID,
+		Reason:       payload.Reason,
+		Snapshot:     snapshot,
+		FilledFields: filled,
+	}
+	if err := h.repo.MergeVictims(merge, fill); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to merge victims", err))
+	}
+
+	merged, err := h.repo.GetVictimByID(strconv.Itoa(int(keep.ID)))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve merged victim", err))
+	}
+
+	// The merge is done; a failure from here on only withholds the records
+	allowed, err := h.canSeeChildDetails(c, merged.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Victims merged but failed to check access to the victim", err))
+	}
+	if !allowed {
+		victims := []models.Victim{merged}
+		if err := h.markMinors(victims); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Victims merged but failed to check access to the victim", err))
+		}
+		service.RedactMinors(victims)
+		merged = victims[0]
+
+		merges := []models.VictimMerge{*merge}
+		if err := h.redactMergeSnapshots(merges); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Victims merged but failed to check access to the victim", err))
+		}
+		merge = &merges[0]
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Victims merged successfully",
+		"data":    merged,
+		"merge":   merge,
+	})
+}
+
+// GetVictimMerges godoc
+//
+//	@Summary		Audit trail of victim merges
+//	@Description	Lists victim merges, newest first, each with a snapshot of the merged record. Filter by victim_id to see merges involving one victim. Snapshots of children are shown redacted.
+//	@Tags			Victims
+//	@Accept			json
+//	@Produce		json
+//	@Param			victim_id	query		string		false	"Kept or merged victim ID"
+//	@Success		200			{object}	fiber.Map	"Victim merges retrieved successfully"
+//	@Failure		500			{object}	fiber.Map	"Failed to retrieve victim merges"
+//	@Router			/victim-merges [get]
+func (h *VictimController) GetVictimMerges(c *fiber.Ctx) error {
+	pagination, merges, err := h.repo.GetPaginatedVictimMerges(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victim merges", err))
+	}
+	if err := h.redactMergeSnapshots(merges); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve victim merges", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Victim merges retrieved successfully",
+		"data":    merges,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
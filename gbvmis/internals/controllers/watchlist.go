@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type WatchlistController struct {
+	repo repository.WatchlistRepository
+}
+
+func NewWatchlistController(repo repository.WatchlistRepository) *WatchlistController {
+	return &WatchlistController{repo: repo}
+}
+
+type CreateWatchlistPayload struct {
+	SuspectID     uint   `json:"suspect_id" validate:"required"`
+	Category      string `json:"category" validate:"required,oneof=wanted watchlist"`
+	Reason        string `json:"reason" validate:"required"`
+	IssuingPostID uint   `json:"issuing_post_id"` // defaults to the issuing officer's post
+	ExpiresAt     string `json:"expires_at"`      // YYYY-MM-DD, optional
+}
+
+type LiftWatchlistPayload struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+type WatchlistResponse struct {
+	ID            uint       `json:"id"`
+	SuspectID     uint       `json:"suspect_id"`
+	SuspectName   string     `json:"suspect_name"`
+	Category      string     `json:"category"`
+	Reason        string     `json:"reason"`
+	IssuingPostID uint       `json:"issuing_post_id"`
+	IssuingPost   string     `json:"issuing_post"`
+	IssuedByID    uint       `json:"issued_by_id"`
+	IssuedAt      time.Time  `json:"issued_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	Status        string     `json:"status"`
+	LiftedAt      *time.Time `json:"lifted_at"`
+	LiftReason    string     `json:"lift_reason"`
+}
+
+func ConvertToWatchlistResponse(e models.WatchlistEntry) WatchlistResponse {
+	return WatchlistResponse{
+		ID:            e.ID,
+		SuspectID:     e.SuspectID,
+		SuspectName:   e.Suspect.FirstName + " " + e.Suspect.LastName,
+		Category:      e.Category,
+		Reason:        e.Reason,
+		IssuingPostID: e.IssuingPostID,
+		IssuingPost:   e.IssuingPost.Name,
+		IssuedByID:    e.IssuedByID,
+		IssuedAt:      e.IssuedAt,
+		ExpiresAt:     e.ExpiresAt,
+		Status:        e.Status,
+		LiftedAt:      e.LiftedAt,
+		LiftReason:    e.LiftReason,
+	}
+}
+
+// ================================
+
+// CreateWatchlistEntry godoc
+//
+//	@Summary		Put a suspect on the wanted list or watchlist
+//	@Description	Flags a suspect, e.g. one who absconded. Creating or searching for a matching suspect, or arresting them, then notifies the issuing station.
+//	@Tags			Watchlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		CreateWatchlistPayload	true	"Watchlist entry"
+//	@Success		201		{object}	fiber.Map				"Watchlist entry created successfully"
+//	@Failure		400		{object}	fiber.Map				"Invalid input"
+//	@Failure		404		{object}	fiber.Map				"Suspect not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when creating entry"
+//	@Router			/watchlist [post]
+func (h *WatchlistController) CreateWatchlistEntry(c *fiber.Ctx) error {
+	var payload CreateWatchlistPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	suspect, err := h.repo.FindSuspectByID(payload.SuspectID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Suspect not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve suspect", err))
+	}
+
+	claims := c.Locals("user").(*utils.Claims)
+	officer, err := h.repo.FindOfficerByID(claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Unknown officer",
+		})
+	}
+
+	postID := payload.IssuingPostID
+	if postID == 0 {
+		postID = officer.PostID
+	}
+	post, err := h.repo.FindPolicePostByID(postID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid issuing_post_id", err))
+	}
+
+	entry := models.WatchlistEntry{
+		SuspectID:     suspect.ID,
+		Category:      payload.Category,
+		Reason:        payload.Reason,
+		IssuingPostID: post.ID,
+		IssuedByID:    officer.ID,
+		IssuedAt:      time.Now(),
+		Status:        models.WatchlistStatusActive,
+	}
+	if payload.ExpiresAt != "" {
+		expires, err := utils.ParseDate(payload.ExpiresAt)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid expires_at, expected YYYY-MM-DD", err))
+		}
+		// Valid through the whole expiry day
+		expires = expires.Add(24*time.Hour - time.Second)
+		if expires.Before(entry.IssuedAt) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "expires_at must be in the future",
+			})
+		}
+		entry.ExpiresAt = &expires
+	}
+
+	if err := h.repo.CreateEntry(&entry); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to create watchlist entry", err))
+	}
+	entry.Suspect = suspect
+	entry.IssuingPost = post
+
+	return c.Status(fiber.StatusCreated).JSON(utils.SuccessResponse("Watchlist entry created successfully", ConvertToWatchlistResponse(entry)))
+}
+
+// GetAllWatchlistEntries godoc
+//
+//	@Summary		Retrieve a paginated list of watchlist entries
+//	@Tags			Watchlist
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	fiber.Map	"Watchlist entries retrieved successfully"
+//	@Failure		500	{object}	fiber.Map	"Failed to retrieve watchlist entries"
+//	@Router			/watchlists [get]
+func (h *WatchlistController) GetAllWatchlistEntries(c *fiber.Ctx) error {
+	pagination, entries, err := h.repo.GetPaginatedEntries(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve watchlist entries", err))
+	}
+
+	responses := make([]WatchlistResponse, len(entries))
+	for i, e := range entries {
+		responses[i] = ConvertToWatchlistResponse(e)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Watchlist entries retrieved successfully",
+		"data":    responses,
+		"pagination": fiber.Map{
+			"total_items":  pagination.TotalItems,
+			"total_pages":  pagination.TotalPages,
+			"current_page": pagination.CurrentPage,
+			"limit":        pagination.ItemsPerPage,
+		},
+	})
+}
+
+// SearchWatchlistEntries godoc
+//
+//	@Summary		Search watchlist entries
+//	@Tags			Watchlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			status			query		string		false	"active, lifted or expired"
+//	@Param			category		query		string		false	"wanted or watchlist"
+//	@Param			suspect_id		query		string		false	"Suspect ID"
+//	@Param			issuing_post_id	query		string		false	"Issuing police post ID"
+//	@Success		200				{object}	fiber.Map	"Watchlist entries retrieved successfully"
+//	@Failure		500				{object}	fiber.Map	"Failed to retrieve watchlist entries"
+//	@Router			/watchlists/search [get]
+func (h *WatchlistController) SearchWatchlistEntries(c *fiber.Ctx) error {
+	pagination, entries, err := h.repo.SearchPaginatedEntries(c)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve watchlist entries", err))
+	}
+
+	responses := make([]WatchlistResponse, len(entries))
+	for i, e := range entries {
+		responses[i] = ConvertToWatchlistResponse(e)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":     "success",
+		"message":    "Watchlist entries retrieved successfully",
+		"pagination": pagination,
+		"data":       responses,
+	})
+}
+
+// GetSingleWatchlistEntry godoc
+//
+//	@Summary		Retrieve a watchlist entry with its hits
+//	@Description	Returns the entry and every time the listed person turned up.
+//	@Tags			Watchlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		string		true	"Watchlist entry ID"
+//	@Success		200	{object}	fiber.Map	"Watchlist entry retrieved successfully"
+//	@Failure		404	{object}	fiber.Map	"Watchlist entry not found"
+//	@Failure		500	{object}	fiber.Map	"Server error when retrieving entry"
+//	@Router			/watchlist/{id} [get]
+func (h *WatchlistController) GetSingleWatchlistEntry(c *fiber.Ctx) error {
+	entry, err := h.repo.GetEntryByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Watchlist entry not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve watchlist entry", err))
+	}
+
+	hits, err := h.repo.GetHits(entry.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve watchlist hits", err))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Watchlist entry retrieved successfully",
+		"data":    ConvertToWatchlistResponse(entry),
+		"hits":    hits,
+	})
+}
+
+// LiftWatchlistEntry godoc
+//
+//	@Summary		Lift a watchlist entry
+//	@Description	Takes the person off the list, e.g. after arrest or surrender.
+//	@Tags			Watchlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string					true	"Watchlist entry ID"
+//	@Param			payload	body		LiftWatchlistPayload	true	"Why the entry is lifted"
+//	@Success		200		{object}	fiber.Map				"Watchlist entry lifted"
+//	@Failure		400		{object}	fiber.Map				"Invalid input or entry not active"
+//	@Failure		404		{object}	fiber.Map				"Watchlist entry not found"
+//	@Failure		500		{object}	fiber.Map				"Server error when lifting entry"
+//	@Router			/watchlist/{id}/lift [post]
+func (h *WatchlistController) LiftWatchlistEntry(c *fiber.Ctx) error {
+	var payload LiftWatchlistPayload
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(utils.ErrorResponse("Invalid input", err))
+	}
+	if errs := utils.ValidateStruct(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Validation failed",
+			"errors":  errs,
+		})
+	}
+
+	entry, err := h.repo.GetEntryByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Watchlist entry not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to retrieve watchlist entry", err))
+	}
+	if entry.Status != models.WatchlistStatusActive {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Watchlist entry is already " + entry.Status,
+		})
+	}
+
+	now := time.Now()
+	if err := h.repo.LiftEntry(entry.ID, payload.Reason, now); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(utils.ErrorResponse("Failed to lift watchlist entry", err))
+	}
+	entry.Status = models.WatchlistStatusLifted
+	entry.LiftedAt = &now
+	entry.LiftReason = payload.Reason
+
+	return c.Status(fiber.StatusOK).JSON(utils.SuccessResponse("Watchlist entry lifted", ConvertToWatchlistResponse(entry)))
+}
@@ -86,6 +86,52 @@ func (d *DBInstance) Migrate() {
 		&models.ToxicologyForensicReport{},
 		&models.PersonSymptom{},
 		&models.PersonSummary{},
+		&models.CustodyRecord{},
+		&models.PoliceBond{},
+		&models.BondSurety{},
+		&models.BondReportingDate{},
+		&models.Exhibit{},
+		&models.ExhibitTransfer{},
+		&models.FileBlob{},
+		&models.Attachment{},
+		&models.SuspectMedia{},
+		&models.VictimDuplicate{},
+		&models.VictimMerge{},
+		&models.SuspectAlias{},
+		&models.SuspectMark{},
+		&models.SuspectAssociate{},
+		&models.Notification{},
+		&models.WatchlistEntry{},
+		&models.WatchlistHit{},
+		&models.CaseRelationship{},
+		&models.RiskQuestionnaire{},
+		&models.RiskQuestion{},
+		&models.RiskAssessment{},
+		&models.RiskAnswer{},
+		&models.ProtectionOrder{},
+		&models.ProtectionOrderBreach{},
+		&models.SafetyPlan{},
+		&models.ServiceProvider{},
+		&models.Referral{},
+		&models.ReferralUpdate{},
+		&models.Guardian{},
+		&models.Incident{},
+		&models.AdminArea{},
+		&models.PoliceUnit{},
+		&models.ReportDefinition{},
+		&models.GeneratedReport{},
+		&models.Dhis2DataElementMapping{},
+		&models.Dhis2OrgUnitMapping{},
+		&models.Dhis2Push{},
+		&models.Pf3Template{},
+		&models.Pf3Document{},
+		&models.ExaminationInjury{},
+		&models.ExaminationFindings{},
+		&models.CareRecord{},
+		&models.LabRequest{},
+		&models.LabSpecimen{},
+		&models.LabResult{},
+		&models.LabRequestUpdate{},
 	)
 	log.Println("Migrations completed")
 }
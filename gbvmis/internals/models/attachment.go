@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Record types that can carry attachments
+const (
+	AttachmentOwnerCase             = "case"
+	AttachmentOwnerExamination      = "examination"
+	AttachmentOwnerToxicologyReport = "toxicology_report"
+	AttachmentOwnerExhibit          = "exhibit"
+)
+
+// FileBlob is stored content, addressed by its SHA-256. Several attachments
+// may point at the same blob.
+type FileBlob struct {
+	SHA256        string    `gorm:"primaryKey;size:64" json:"sha256"`
+	Size          int64     `json:"size"`
+	MimeType      string    `gorm:"size:100" json:"mime_type"`
+	Driver        string    `gorm:"size:20" json:"driver"` // local or s3
+	ScanStatus    string    `gorm:"size:20" json:"scan_status"`
+	ScanSignature string    `json:"scan_signature"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	// Set when the last record using the content goes; the content is
+	// collected once the blob has stayed unused for the grace period
+	OrphanedAt *time.Time `gorm:"index" json:"-"`
+}
+
+type Attachment struct {
+	gorm.Model
+	OwnerType    string `gorm:"size:30;index:idx_attachment_owner" json:"owner_type"`
+	OwnerID      uint   `gorm:"index:idx_attachment_owner" json:"owner_id"`
+	BlobSHA256   string `gorm:"size:64;index" json:"blob_sha256"`
+	FileName     string `json:"file_name"`
+	MimeType     string `gorm:"size:100" json:"mime_type"`
+	Size         int64  `json:"size"`
+	Category     string `json:"category"` // e.g., Medical form, Photo, Statement
+	Description  string `gorm:"type:text" json:"description"`
+	UploadedByID uint   `json:"uploaded_by_id"`
+
+	Blob       FileBlob      `gorm:"foreignKey:BlobSHA256;references:SHA256"`
+	UploadedBy PoliceOfficer `gorm:"foreignKey:UploadedByID"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Police bond statuses
+const (
+	BondStatusActive    = "active"
+	BondStatusCancelled = "cancelled"
+	BondStatusExpired   = "expired"
+)
+
+// Suspect statuses set automatically by the bond workflow
+const (
+	SuspectStatusOnBond        = "on_police_bond"
+	SuspectStatusBondCancelled = "bond_cancelled"
+	SuspectStatusBondExpired   = "bond_expired"
+)
+
+type PoliceBond struct {
+	gorm.Model
+	SuspectID       uint       `json:"suspect_id"`
+	CaseID          uint       `json:"case_id"`
+	CustodyRecordID *uint      `json:"custody_record_id"` // Set when the bond releases a suspect from the custody register
+	GrantedByID     uint       `json:"granted_by_id"`
+	GrantedAt       time.Time  `json:"granted_at"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	Conditions      string     `gorm:"type:text" json:"conditions"`
+	Status          string     `gorm:"size:20;index" json:"status"` // active, cancelled, expired
+	CancelledAt     *time.Time `json:"cancelled_at"`
+	CancelReason    string     `gorm:"type:text" json:"cancel_reason"`
+
+	Sureties       []BondSurety        `gorm:"foreignKey:BondID" json:"sureties"`
+	ReportingDates []BondReportingDate `gorm:"foreignKey:BondID" json:"reporting_dates"`
+
+	Suspect   Suspect       `gorm:"foreignKey:SuspectID"`
+	Case      Case          `gorm:"foreignKey:CaseID"`
+	GrantedBy PoliceOfficer `gorm:"foreignKey:GrantedByID"`
+}
+
+type BondSurety struct {
+	gorm.Model
+	BondID       uint   `json:"bond_id"`
+	FullName     string `gorm:"size:100;not null" json:"full_name"`
+	PhoneNumber  string `json:"phone_number"`
+	Nin          string `json:"nin"`
+	Address      string `json:"address"`
+	Relationship string `json:"relationship"` // e.g., Brother, Employer, LC1 Chairperson
+}
+
+type BondReportingDate struct {
+	gorm.Model
+	BondID     uint       `gorm:"index" json:"bond_id"`
+	DueDate    time.Time  `gorm:"type:date" json:"due_date"`
+	ReportedAt *time.Time `json:"reported_at"`
+	ReceivedBy string     `json:"received_by"`
+	Notes      string     `gorm:"type:text" json:"notes"`
+
+	Bond PoliceBond `gorm:"foreignKey:BondID"`
+}
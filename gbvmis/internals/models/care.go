@@ -0,0 +1,114 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Clinical care given at an examination
+const (
+	CareServicePEP            = "pep" // HIV post-exposure prophylaxis
+	CareServiceEC             = "emergency_contraception"
+	CareServiceSTI            = "sti_prophylaxis"
+	CareServiceHIVTest        = "hiv_test"
+	CareServicePregnancyTest  = "pregnancy_test"
+	CareServiceHepatitisB     = "hepatitis_b_vaccine"
+	CareServiceTetanus        = "tetanus_toxoid"
+	CareServiceCounselling    = "counselling"
+	CareServiceWoundTreatment = "wound_treatment"
+)
+
+// CareServices lists the services on the care checklist, in the order they
+// are shown
+var CareServices = []string{
+	CareServicePEP,
+	CareServiceEC,
+	CareServiceSTI,
+	CareServiceHIVTest,
+	CareServicePregnancyTest,
+	CareServiceHepatitisB,
+	CareServiceTetanus,
+	CareServiceCounselling,
+	CareServiceWoundTreatment,
+}
+
+// What happened to a service on the checklist
+const (
+	CareStatusPending      = "pending" // nothing recorded yet
+	CareStatusProvided     = "provided"
+	CareStatusReferred     = "referred" // sent elsewhere; still open until provided there
+	CareStatusDeclined     = "declined"
+	CareStatusNotIndicated = "not_indicated"
+)
+
+// CareStatuses lists the statuses that can be recorded
+var CareStatuses = []string{CareStatusProvided, CareStatusReferred, CareStatusDeclined, CareStatusNotIndicated}
+
+// CareClosedStatuses are the statuses after which a service no longer needs
+// to be chased
+var CareClosedStatuses = []string{CareStatusProvided, CareStatusDeclined, CareStatusNotIndicated}
+
+// CareRecord records one service on an examination's care checklist
+type CareRecord struct {
+	gorm.Model
+	ExaminationID uint       `gorm:"uniqueIndex:idx_care_record_service" json:"examination_id"`
+	Service       string     `gorm:"size:30;uniqueIndex:idx_care_record_service" json:"service"`
+	Status        string     `gorm:"size:20;index" json:"status"`
+	ProvidedAt    *time.Time `json:"provided_at"` // when the service was given; required once provided
+	Notes         string     `gorm:"type:text" json:"notes"`
+	RecordedByID  uint       `json:"recorded_by_id"`
+}
+
+// CareChecklistItem is one service on the checklist with its clock
+type CareChecklistItem struct {
+	Service      string     `json:"service"`
+	Status       string     `json:"status"`
+	ProvidedAt   *time.Time `json:"provided_at"`
+	Notes        string     `json:"notes"`
+	WindowHours  *int       `json:"window_hours"`         // nil for services that are not time-critical
+	Deadline     *time.Time `json:"deadline"`             // end of the window
+	HoursAtCare  *float64   `json:"hours_since_incident"` // when provided, else now
+	WithinWindow *bool      `json:"within_window"`        // nil until provided or the window closes
+	RecordedByID uint       `json:"recorded_by_id"`
+}
+
+// CareChecklist is an examination's care checklist measured against the
+// sexual assault it follows. The clock is absent when the case records no
+// sexual assault before the examination.
+type CareChecklist struct {
+	ExaminationID      uint                `json:"examination_id"`
+	IncidentID         *uint               `json:"incident_id"`
+	IncidentOccurredAt *time.Time          `json:"incident_occurred_at"`
+	IncidentTimeKnown  bool                `json:"incident_time_known"` // false: the clock runs from the start of the day
+	HoursSinceIncident *float64            `json:"hours_since_incident"`
+	Items              []CareChecklistItem `json:"items"`
+}
+
+// PendingCare is a time-critical service still open on an examination whose
+// window has not yet closed
+type PendingCare struct {
+	ExaminationID      uint      `json:"examination_id"`
+	FacilityID         uint      `json:"facility_id"`
+	Facility           string    `json:"-"`
+	CaseID             uint      `json:"case_id"`
+	CaseNumber         string    `json:"case_number"`
+	VictimID           uint      `json:"victim_id"`
+	ExamDate           string    `json:"exam_date"`
+	Service            string    `json:"service"`
+	Status             string    `json:"status"` // pending or referred
+	IncidentOccurredAt time.Time `json:"incident_occurred_at"`
+	IncidentTimeKnown  bool      `json:"incident_time_known"`
+	Deadline           time.Time `json:"deadline"`
+	HoursSinceIncident float64   `json:"hours_since_incident"`
+	HoursRemaining     float64   `json:"hours_remaining"`
+	Urgent             bool      `json:"urgent"`
+}
+
+// FacilityCareRisk groups the pending time-critical care at one facility
+type FacilityCareRisk struct {
+	FacilityID uint          `json:"facility_id"`
+	Facility   string        `json:"facility"`
+	Urgent     int           `json:"urgent"`
+	AtRisk     []PendingCare `json:"at_risk"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "gorm.io/gorm"
+
+// What the victim depends on the suspect for, if anything
+const (
+	DependencyNone      = "none"
+	DependencyFinancial = "financial"
+	DependencyHousing   = "housing"
+	DependencyCare      = "care" // e.g., a child, elderly or disabled victim cared for by the suspect
+	DependencyOther     = "other"
+)
+
+// VictimDependencies lists the accepted dependency values
+var VictimDependencies = []string{
+	DependencyNone,
+	DependencyFinancial,
+	DependencyHousing,
+	DependencyCare,
+	DependencyOther,
+}
+
+// CaseRelationship records, within one case, that a suspect offended against
+// a victim and how the two are related. Both must be linked to the case.
+type CaseRelationship struct {
+	gorm.Model
+	CaseID           uint   `gorm:"uniqueIndex:idx_case_relationship" json:"case_id"`
+	VictimID         uint   `gorm:"uniqueIndex:idx_case_relationship" json:"victim_id"`
+	SuspectID        uint   `gorm:"uniqueIndex:idx_case_relationship" json:"suspect_id"`
+	RelationshipType string `gorm:"size:30;index" json:"relationship_type"`
+	Cohabiting       bool   `json:"cohabiting"`
+	Dependency       string `gorm:"size:20" json:"dependency"`
+	Notes            string `gorm:"type:text" json:"notes"`
+
+	Victim  Victim  `gorm:"foreignKey:VictimID"`
+	Suspect Suspect `gorm:"foreignKey:SuspectID"`
+}
+
+// RelationshipStat is the number of victim-suspect pairs of one relationship type
+type RelationshipStat struct {
+	RelationshipType string `json:"relationship_type"`
+	Pairs            int64  `json:"pairs"`
+	Cohabiting       int64  `json:"cohabiting"`
+	Dependent        int64  `json:"dependent"`
+}
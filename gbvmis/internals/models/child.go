@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AdultAge is the age of majority; younger survivors are handled as children
+const AdultAge = 18
+
+// Guardian relationships to the child
+const (
+	GuardianMother        = "mother"
+	GuardianFather        = "father"
+	GuardianGrandparent   = "grandparent"
+	GuardianOtherRelative = "other_relative"
+	GuardianFosterParent  = "foster_parent"
+	GuardianInstitution   = "institution" // e.g., a children's home
+	GuardianOther         = "other"
+)
+
+// GuardianRelationships lists the accepted guardian relationships
+var GuardianRelationships = []string{
+	GuardianMother,
+	GuardianFather,
+	GuardianGrandparent,
+	GuardianOtherRelative,
+	GuardianFosterParent,
+	GuardianInstitution,
+	GuardianOther,
+}
+
+// Guardian is a parent or caregiver of a child survivor. A guardian who is
+// also a suspect is recorded with SuspectID so they are never used as the
+// child's contact.
+type Guardian struct {
+	gorm.Model
+	VictimID       uint   `gorm:"index" json:"victim_id"`
+	FullName       string `json:"full_name"`
+	Relationship   string `gorm:"size:30" json:"relationship"`
+	PhoneNumber    string `json:"phone_number"`
+	Address        string `json:"address"`
+	Nin            string `json:"nin"`
+	IsPrimary      bool   `json:"is_primary"`
+	LivesWithChild bool   `json:"lives_with_child"`
+	SuspectID      *uint  `gorm:"index" json:"suspect_id"`
+	Notes          string `gorm:"type:text" json:"notes"`
+}
+
+// AgeAt returns the age in whole years on the given date, and false when the
+// date of birth is unknown
+func AgeAt(dob, at time.Time) (int, bool) {
+	if dob.IsZero() || at.Before(dob) {
+		return 0, false
+	}
+	age := at.Year() - dob.Year()
+	if at.Month() < dob.Month() || (at.Month() == dob.Month() && at.Day() < dob.Day()) {
+		age--
+	}
+	return age, true
+}
+
+// Age bands used in statistics
+var AgeBands = []string{"0-4", "5-9", "10-14", "15-17", "18-24", "25-34", "35-49", "50+", "unknown"}
+
+// AgeBand returns the statistics band for an age
+func AgeBand(age int, known bool) string {
+	switch {
+	case !known:
+		return "unknown"
+	case age < 5:
+		return "0-4"
+	case age < 10:
+		return "5-9"
+	case age < 15:
+		return "10-14"
+	case age < AdultAge:
+		return "15-17"
+	case age < 25:
+		return "18-24"
+	case age < 35:
+		return "25-34"
+	case age < 50:
+		return "35-49"
+	default:
+		return "50+"
+	}
+}
+
+// AgeBandStat is the number of victims of one gender in one age band, each
+// victim counted once per case at their age on the incident date
+type AgeBandStat struct {
+	AgeBand string `json:"age_band"`
+	Gender  string `json:"gender"`
+	Victims int64  `json:"victims"`
+}
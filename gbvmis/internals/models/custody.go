@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Custody statuses recorded in the detention register
+const (
+	CustodyStatusInCustody = "in_custody"
+	CustodyStatusReleased  = "released"
+	CustodyStatusBond      = "police_bond"
+	CustodyStatusRemanded  = "remanded"
+)
+
+type CustodyRecord struct {
+	gorm.Model
+	ArrestID       uint                        `json:"arrest_id"`
+	SuspectID      uint                        `json:"suspect_id"`
+	CaseID         uint                        `json:"case_id"`
+	PolicePostID   uint                        `json:"police_post_id"`
+	BookedByID     uint                        `json:"booked_by_id"`
+	DetentionStart time.Time                   `json:"detention_start"`
+	CellNumber     string                      `gorm:"size:20" json:"cell_number"`
+	PropertyTaken  datatypes.JSONSlice[string] `gorm:"type:json" json:"property_taken"`
+	Status         string                      `gorm:"size:20;index" json:"status"` // in_custody, released, police_bond, remanded
+
+	// Production in court, which stops the 48-hour clock
+	ProducedInCourtAt *time.Time `json:"produced_in_court_at"`
+	CourtName         string     `json:"court_name"`
+
+	// Release, bond or remand outcome
+	ReleasedAt       *time.Time `json:"released_at"`
+	ReleaseReason    string     `gorm:"type:text" json:"release_reason"`
+	RemandPrison     string     `json:"remand_prison"`
+	PropertyReturned bool       `json:"property_returned"`
+
+	Arrest     Arrest        `gorm:"foreignKey:ArrestID"`
+	Suspect    Suspect       `gorm:"foreignKey:SuspectID"`
+	Case       Case          `gorm:"foreignKey:CaseID"`
+	PolicePost PolicePost    `gorm:"foreignKey:PolicePostID"`
+	BookedBy   PoliceOfficer `gorm:"foreignKey:BookedByID"`
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Indicators computed per health facility for DHIS2, all counts of
+// examinations done in the period
+const (
+	Dhis2Examinations          = "examinations"
+	Dhis2ExaminationsConsented = "examinations_consented"
+	Dhis2ExaminationsFemale    = "examinations_female"
+	Dhis2ExaminationsMale      = "examinations_male"
+	Dhis2ExaminationsChild     = "examinations_child"    // survivor under 18 on the exam date
+	Dhis2ExaminationsReferred  = "examinations_referred" // with at least one referral from the examination
+	Dhis2ExaminationsSexual    = "examinations_sexual_violence"
+)
+
+// Dhis2Indicators lists the indicators that can be mapped to data elements
+var Dhis2Indicators = []string{
+	Dhis2Examinations,
+	Dhis2ExaminationsConsented,
+	Dhis2ExaminationsFemale,
+	Dhis2ExaminationsMale,
+	Dhis2ExaminationsChild,
+	Dhis2ExaminationsReferred,
+	Dhis2ExaminationsSexual,
+}
+
+// Dhis2DataElementMapping sends one indicator to a DHIS2 data element and,
+// optionally, category option combination (both DHIS2 UIDs). An indicator
+// may feed several elements.
+type Dhis2DataElementMapping struct {
+	gorm.Model
+	Indicator           string `gorm:"size:50;index" json:"indicator"`
+	DataElement         string `gorm:"size:11" json:"data_element"`
+	CategoryOptionCombo string `gorm:"size:11" json:"category_option_combo"`
+	Description         string `json:"description"`
+}
+
+// Dhis2OrgUnitMapping gives the DHIS2 organisation unit UID of a health
+// facility. Facilities without one are left out of exports.
+type Dhis2OrgUnitMapping struct {
+	gorm.Model
+	FacilityID uint   `gorm:"uniqueIndex" json:"facility_id"`
+	OrgUnit    string `gorm:"size:11" json:"org_unit"`
+
+	Facility HealthFacility `gorm:"foreignKey:FacilityID" json:"facility"`
+}
+
+// FacilityExaminationCounts holds the indicator values of one facility
+type FacilityExaminationCounts struct {
+	FacilityID     uint
+	Examinations   int64
+	Consented      int64
+	Female         int64
+	Male           int64
+	Child          int64
+	Referred       int64
+	SexualViolence int64
+}
+
+// Value returns the count behind an indicator
+func (f FacilityExaminationCounts) Value(indicator string) int64 {
+	switch indicator {
+	case Dhis2Examinations:
+		return f.Examinations
+	case Dhis2ExaminationsConsented:
+		return f.Consented
+	case Dhis2ExaminationsFemale:
+		return f.Female
+	case Dhis2ExaminationsMale:
+		return f.Male
+	case Dhis2ExaminationsChild:
+		return f.Child
+	case Dhis2ExaminationsReferred:
+		return f.Referred
+	case Dhis2ExaminationsSexual:
+		return f.SexualViolence
+	}
+	return 0
+}
+
+// Dhis2DataValue and Dhis2DataValueSet follow the DHIS2 dataValueSets
+// import format
+type Dhis2DataValue struct {
+	DataElement         string `json:"dataElement"`
+	Period              string `json:"period"`
+	OrgUnit             string `json:"orgUnit"`
+	CategoryOptionCombo string `json:"categoryOptionCombo,omitempty"`
+	Value               string `json:"value"`
+	Comment             string `json:"comment,omitempty"`
+}
+
+type Dhis2DataValueSet struct {
+	DataSet      string           `json:"dataSet,omitempty"`
+	CompleteDate string           `json:"completeDate,omitempty"`
+	DataValues   []Dhis2DataValue `json:"dataValues"`
+}
+
+// Dhis2 push statuses
+const (
+	Dhis2PushSuccess = "success"
+	Dhis2PushWarning = "warning" // accepted with conflicts
+	Dhis2PushFailed  = "failed"
+)
+
+// Dhis2Push records one push of a period's values and what DHIS2 made of it
+type Dhis2Push struct {
+	gorm.Model
+	Period     string    `gorm:"size:10;index" json:"period"`
+	PushedByID uint      `json:"pushed_by_id"`
+	PushedAt   time.Time `json:"pushed_at"`
+	Values     int       `json:"values"`
+	Status     string    `gorm:"size:20" json:"status"`
+	Imported   int       `json:"imported"`
+	Updated    int       `json:"updated"`
+	Ignored    int       `json:"ignored"`
+	Deleted    int       `json:"deleted"`
+	Conflicts  string    `gorm:"type:text" json:"conflicts,omitempty"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+}
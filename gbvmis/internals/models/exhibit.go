@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Parties that can hold an exhibit
+const (
+	HolderOfficer = "officer"
+	HolderStore   = "exhibit_store"
+	HolderLab     = "lab"
+	HolderCourt   = "court"
+)
+
+var ErrTransferImmutable = errors.New("exhibit transfers are append-only and cannot be changed")
+
+type Exhibit struct {
+	gorm.Model
+	CaseID          uint                        `json:"case_id"`
+	ExhibitNumber   string                      `gorm:"uniqueIndex;size:50" json:"exhibit_number"`
+	Description     string                      `gorm:"type:text" json:"description"`
+	Category        string                      `json:"category"` // e.g., Clothing, Weapon, Biological sample, Document
+	SealNumber      string                      `gorm:"size:50;index" json:"seal_number"`
+	StorageLocation string                      `json:"storage_location"`
+	Photos          datatypes.JSONSlice[string] `gorm:"type:json" json:"photos"`
+	CollectedAt     time.Time                   `json:"collected_at"`
+	CollectedByID   uint                        `json:"collected_by_id"`
+
+	// Current holder, kept in step with the last transfer
+	HolderType      string `gorm:"size:20" json:"holder_type"`
+	HolderName      string `json:"holder_name"`
+	HolderOfficerID *uint  `json:"holder_officer_id"`
+
+	Transfers []ExhibitTransfer `gorm:"foreignKey:ExhibitID" json:"transfers"`
+
+	Case        Case          `gorm:"foreignKey:CaseID"`
+	CollectedBy PoliceOfficer `gorm:"foreignKey:CollectedByID"`
+}
+
+// ExhibitTransfer is one link in an exhibit's chain of custody. Rows are
+// append-only; each carries the hash of the previous row so gaps or edits
+// can be detected.
+type ExhibitTransfer struct {
+	gorm.Model
+	ExhibitID     uint      `gorm:"uniqueIndex:idx_exhibit_transfer_seq" json:"exhibit_id"`
+	Sequence      int       `gorm:"uniqueIndex:idx_exhibit_transfer_seq" json:"sequence"`
+	TransferredAt time.Time `json:"transferred_at"`
+	Purpose       string    `json:"purpose"` // e.g., Storage, Analysis, Court hearing
+
+	FromType      string `gorm:"size:20" json:"from_type"`
+	FromName      string `json:"from_name"`
+	FromOfficerID *uint  `json:"from_officer_id"`
+	ToType        string `gorm:"size:20" json:"to_type"`
+	ToName        string `json:"to_name"`
+	ToOfficerID   *uint  `json:"to_officer_id"`
+
+	SealNumber          string `json:"seal_number"`
+	SealIntact          bool   `json:"seal_intact"`
+	ReleasedBySignature string `gorm:"type:text" json:"released_by_signature"`
+	ReceivedBySignature string `gorm:"type:text" json:"received_by_signature"`
+	RecordedByID        uint   `json:"recorded_by_id"`
+	Notes               string `gorm:"type:text" json:"notes"`
+
+	PrevHash string `gorm:"size:64" json:"prev_hash"`
+	Hash     string `gorm:"size:64" json:"hash"`
+}
+
+func (t *ExhibitTransfer) BeforeUpdate(tx *gorm.DB) error {
+	return ErrTransferImmutable
+}
+
+func (t *ExhibitTransfer) BeforeDelete(tx *gorm.DB) error {
+	return ErrTransferImmutable
+}
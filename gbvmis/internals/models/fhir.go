@@ -0,0 +1,193 @@
+package models
+
+// The types below cover the parts of FHIR R4 the /fhir endpoints render.
+// Empty fields are left out, as FHIR requires.
+
+// FhirJSON is the media type of FHIR JSON responses
+const FhirJSON = "application/fhir+json"
+
+type FhirMeta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type FhirIdentifier struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value"`
+}
+
+type FhirCoding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+type FhirCodeableConcept struct {
+	Coding []FhirCoding `json:"coding,omitempty"`
+	Text   string       `json:"text,omitempty"`
+}
+
+// FhirReference points at another resource, either by URL or, for resources
+// not served here, by identifier only
+type FhirReference struct {
+	Reference  string          `json:"reference,omitempty"`
+	Type       string          `json:"type,omitempty"`
+	Identifier *FhirIdentifier `json:"identifier,omitempty"`
+	Display    string          `json:"display,omitempty"`
+}
+
+type FhirPeriod struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type FhirHumanName struct {
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type FhirContactPoint struct {
+	System string `json:"system"`
+	Value  string `json:"value"`
+}
+
+type FhirAddress struct {
+	Text string `json:"text"`
+}
+
+type FhirEncounterParticipant struct {
+	Individual FhirReference `json:"individual"`
+}
+
+type FhirEncounter struct {
+	ResourceType    string                     `json:"resourceType"`
+	ID              string                     `json:"id"`
+	Meta            *FhirMeta                  `json:"meta,omitempty"`
+	Identifier      []FhirIdentifier           `json:"identifier,omitempty"`
+	Status          string                     `json:"status"`
+	Class           FhirCoding                 `json:"class"`
+	Type            []FhirCodeableConcept      `json:"type,omitempty"`
+	Subject         *FhirReference             `json:"subject,omitempty"`
+	Participant     []FhirEncounterParticipant `json:"participant,omitempty"`
+	Period          *FhirPeriod                `json:"period,omitempty"`
+	ServiceProvider *FhirReference             `json:"serviceProvider,omitempty"`
+}
+
+type FhirQuantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit,omitempty"`
+	System string  `json:"system,omitempty"`
+	Code   string  `json:"code,omitempty"`
+}
+
+type FhirAnnotation struct {
+	Text string `json:"text"`
+}
+
+// FhirObservationValue is the value[x] of an observation or component; at
+// most one field is set
+type FhirObservationValue struct {
+	ValueString          string               `json:"valueString,omitempty"`
+	ValueBoolean         *bool                `json:"valueBoolean,omitempty"`
+	ValueQuantity        *FhirQuantity        `json:"valueQuantity,omitempty"`
+	ValueCodeableConcept *FhirCodeableConcept `json:"valueCodeableConcept,omitempty"`
+}
+
+type FhirObservationComponent struct {
+	Code FhirCodeableConcept `json:"code"`
+	FhirObservationValue
+}
+
+type FhirObservation struct {
+	ResourceType      string                `json:"resourceType"`
+	ID                string                `json:"id"`
+	Meta              *FhirMeta             `json:"meta,omitempty"`
+	Status            string                `json:"status"`
+	Category          []FhirCodeableConcept `json:"category,omitempty"`
+	Code              FhirCodeableConcept   `json:"code"`
+	Subject           *FhirReference        `json:"subject,omitempty"`
+	Encounter         *FhirReference        `json:"encounter,omitempty"`
+	EffectiveDateTime string                `json:"effectiveDateTime,omitempty"`
+	Performer         []FhirReference       `json:"performer,omitempty"`
+	FhirObservationValue
+	BodySite  *FhirCodeableConcept       `json:"bodySite,omitempty"`
+	Method    *FhirCodeableConcept       `json:"method,omitempty"`
+	Note      []FhirAnnotation           `json:"note,omitempty"`
+	Component []FhirObservationComponent `json:"component,omitempty"`
+}
+
+type FhirQualification struct {
+	Code FhirCodeableConcept `json:"code"`
+}
+
+type FhirPractitioner struct {
+	ResourceType  string              `json:"resourceType"`
+	ID            string              `json:"id"`
+	Meta          *FhirMeta           `json:"meta,omitempty"`
+	Name          []FhirHumanName     `json:"name,omitempty"`
+	Gender        string              `json:"gender,omitempty"`
+	Telecom       []FhirContactPoint  `json:"telecom,omitempty"`
+	Qualification []FhirQualification `json:"qualification,omitempty"`
+}
+
+type FhirOrganization struct {
+	ResourceType string                `json:"resourceType"`
+	ID           string                `json:"id"`
+	Meta         *FhirMeta             `json:"meta,omitempty"`
+	Active       bool                  `json:"active"`
+	Type         []FhirCodeableConcept `json:"type,omitempty"`
+	Name         string                `json:"name"`
+	Telecom      []FhirContactPoint    `json:"telecom,omitempty"`
+	Address      []FhirAddress         `json:"address,omitempty"`
+}
+
+type FhirBundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+type FhirBundleEntry struct {
+	FullURL  string       `json:"fullUrl"`
+	Resource FhirResource `json:"resource"`
+}
+
+// FhirBundle is a searchset (search results) or a collection (an export)
+type FhirBundle struct {
+	ResourceType string            `json:"resourceType"`
+	Type         string            `json:"type"`
+	Timestamp    string            `json:"timestamp"`
+	Total        *int64            `json:"total,omitempty"`
+	Link         []FhirBundleLink  `json:"link,omitempty"`
+	Entry        []FhirBundleEntry `json:"entry"`
+}
+
+type FhirIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics"`
+}
+
+// FhirOperationOutcome carries errors from the /fhir endpoints
+type FhirOperationOutcome struct {
+	ResourceType string      `json:"resourceType"`
+	Issue        []FhirIssue `json:"issue"`
+}
+
+// FhirExaminationFilter narrows an Encounter search. Only examinations with
+// consent are ever returned.
+type FhirExaminationFilter struct {
+	FacilityID     *uint
+	PractitionerID *uint
+	From, To       string // exam dates, YYYY-MM-DD, inclusive
+	Count, Offset  int
+}
+
+// FhirResource is implemented by the resources the /fhir endpoints serve
+type FhirResource interface {
+	// FhirPath is the resource's type and id, e.g. "Encounter/12"
+	FhirPath() string
+}
+
+func (e FhirEncounter) FhirPath() string    { return "Encounter/" + e.ID }
+func (o FhirObservation) FhirPath() string  { return "Observation/" + o.ID }
+func (p FhirPractitioner) FhirPath() string { return "Practitioner/" + p.ID }
+func (o FhirOrganization) FhirPath() string { return "Organization/" + o.ID }
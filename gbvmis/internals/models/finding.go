@@ -0,0 +1,231 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Injury types recorded on examination
+const (
+	InjuryBruise     = "bruise"
+	InjuryAbrasion   = "abrasion"
+	InjuryLaceration = "laceration"
+	InjuryIncised    = "incised_wound"
+	InjuryStab       = "stab_wound"
+	InjuryBurn       = "burn"
+	InjuryBite       = "bite_mark"
+	InjuryFracture   = "fracture"
+	InjurySwelling   = "swelling"
+	InjuryTenderness = "tenderness"
+	InjuryTear       = "tear"
+	InjuryScar       = "scar"
+	InjuryOther      = "other"
+)
+
+// InjuryTypes lists the accepted injury types
+var InjuryTypes = []string{
+	InjuryBruise,
+	InjuryAbrasion,
+	InjuryLaceration,
+	InjuryIncised,
+	InjuryStab,
+	InjuryBurn,
+	InjuryBite,
+	InjuryFracture,
+	InjurySwelling,
+	InjuryTenderness,
+	InjuryTear,
+	InjuryScar,
+	InjuryOther,
+}
+
+// Estimated age of an injury at examination
+const (
+	InjuryAgeFresh   = "under_24_hours"
+	InjuryAgeRecent  = "1_to_3_days"
+	InjuryAgeDays    = "4_to_7_days"
+	InjuryAgeWeeks   = "1_to_4_weeks"
+	InjuryAgeOld     = "over_4_weeks"
+	InjuryAgeUnknown = "undetermined"
+)
+
+// InjuryAges lists the accepted injury age estimates, youngest first
+var InjuryAges = []string{
+	InjuryAgeFresh,
+	InjuryAgeRecent,
+	InjuryAgeDays,
+	InjuryAgeWeeks,
+	InjuryAgeOld,
+	InjuryAgeUnknown,
+}
+
+// Sides of the body for regions that have one
+const (
+	SideLeft      = "left"
+	SideRight     = "right"
+	SideBilateral = "bilateral"
+)
+
+// BodySides lists the accepted sides
+var BodySides = []string{SideLeft, SideRight, SideBilateral}
+
+// BodyRegion is one area of the body map. Codes are "group.area", so a
+// search on the group ("head") finds every area in it.
+type BodyRegion struct {
+	Code  string `json:"code"`
+	Name  string `json:"name"`
+	Sided bool   `json:"sided"` // a side must be given
+}
+
+// Group is the part of the body the region belongs to
+func (b BodyRegion) Group() string {
+	group, _, _ := strings.Cut(b.Code, ".")
+	return group
+}
+
+// BodyRegions is the body-map taxonomy
+var BodyRegions = []BodyRegion{
+	{"head.scalp", "Scalp", false},
+	{"head.forehead", "Forehead", false},
+	{"head.eye", "Eye and orbit", true},
+	{"head.ear", "Ear", true},
+	{"head.cheek", "Cheek", true},
+	{"head.nose", "Nose", false},
+	{"head.mouth", "Lips and mouth", false},
+	{"head.jaw", "Jaw and chin", false},
+	{"neck.front", "Front of neck", false},
+	{"neck.back", "Back of neck", false},
+	{"trunk.chest", "Chest", true},
+	{"trunk.breast", "Breast", true},
+	{"trunk.abdomen", "Abdomen", false},
+	{"trunk.upper_back", "Upper back", true},
+	{"trunk.lower_back", "Lower back", true},
+	{"trunk.buttock", "Buttock", true},
+	{"arm.shoulder", "Shoulder", true},
+	{"arm.upper_arm", "Upper arm", true},
+	{"arm.elbow", "Elbow", true},
+	{"arm.forearm", "Forearm", true},
+	{"arm.wrist", "Wrist", true},
+	{"arm.hand", "Hand and fingers", true},
+	{"leg.hip", "Hip", true},
+	{"leg.thigh", "Thigh", true},
+	{"leg.inner_thigh", "Inner thigh", true},
+	{"leg.knee", "Knee", true},
+	{"leg.lower_leg", "Lower leg", true},
+	{"leg.ankle", "Ankle", true},
+	{"leg.foot", "Foot and toes", true},
+	{"genital.mons", "Mons pubis", false},
+	{"genital.labia_majora", "Labia majora", true},
+	{"genital.labia_minora", "Labia minora", true},
+	{"genital.clitoris", "Clitoris and prepuce", false},
+	{"genital.urethral_meatus", "Urethral meatus", false},
+	{"genital.vestibule", "Vestibule", false},
+	{"genital.hymen", "Hymen", false},
+	{"genital.fourchette", "Posterior fourchette", false},
+	{"genital.vagina", "Vagina", false},
+	{"genital.cervix", "Cervix", false},
+	{"genital.perineum", "Perineum", false},
+	{"genital.penis", "Penis", false},
+	{"genital.scrotum", "Scrotum and testes", false},
+	{"anal.anus", "Anus and perianal area", false},
+}
+
+// FindBodyRegion looks a region up by code
+func FindBodyRegion(code string) (BodyRegion, bool) {
+	for _, region := range BodyRegions {
+		if region.Code == code {
+			return region, true
+		}
+	}
+	return BodyRegion{}, false
+}
+
+// Hymen findings on genital examination
+const (
+	HymenIntact        = "intact"
+	HymenFreshTear     = "fresh_tear"
+	HymenHealedTear    = "healed_tear"
+	HymenNotApplicable = "not_applicable"
+	HymenNotExamined   = "not_examined"
+)
+
+// HymenFindings lists the accepted hymen findings
+var HymenFindings = []string{HymenIntact, HymenFreshTear, HymenHealedTear, HymenNotApplicable, HymenNotExamined}
+
+// Outcome of the anal examination
+const (
+	AnalNormal      = "normal"
+	AnalAbnormal    = "abnormal"
+	AnalNotExamined = "not_examined"
+)
+
+// AnalFindings lists the accepted anal examination outcomes
+var AnalFindings = []string{AnalNormal, AnalAbnormal, AnalNotExamined}
+
+// The practitioner's opinion on whether the findings are consistent with
+// penetration
+const (
+	PenetrationConsistent    = "consistent"
+	PenetrationNotConsistent = "not_consistent"
+	PenetrationInconclusive  = "inconclusive"
+	PenetrationNotAssessed   = "not_assessed"
+)
+
+// PenetrationOpinions lists the accepted opinions
+var PenetrationOpinions = []string{PenetrationConsistent, PenetrationNotConsistent, PenetrationInconclusive, PenetrationNotAssessed}
+
+// Ways of estimating a survivor's age when it is not known
+const (
+	AgeMethodDental   = "dental"
+	AgeMethodSkeletal = "skeletal_xray"
+	AgeMethodPhysical = "physical_development"
+	AgeMethodOther    = "other"
+)
+
+// AgeEstimateMethods lists the accepted age estimation methods
+var AgeEstimateMethods = []string{AgeMethodDental, AgeMethodSkeletal, AgeMethodPhysical, AgeMethodOther}
+
+// ExaminationInjury is one injury found on examination, placed on the body
+// map. Sizes are in centimetres.
+type ExaminationInjury struct {
+	gorm.Model
+	ExaminationID uint     `gorm:"index" json:"examination_id"`
+	InjuryType    string   `gorm:"size:20;index" json:"injury_type"`
+	BodyRegion    string   `gorm:"size:40;index" json:"body_region"`
+	Side          string   `gorm:"size:10" json:"side"`
+	LengthCm      *float64 `json:"length_cm"`
+	WidthCm       *float64 `json:"width_cm"`
+	AgeEstimate   string   `gorm:"size:20;index" json:"age_estimate"`
+	Description   string   `gorm:"type:text" json:"description"`
+	RecordedByID  uint     `json:"recorded_by_id"`
+}
+
+// ExaminationFindings holds the coded findings recorded once per
+// examination: the survivor's estimated age and the genital examination.
+// The genital fields are only set when GenitalExamined is.
+type ExaminationFindings struct {
+	gorm.Model
+	ExaminationID uint `gorm:"uniqueIndex" json:"examination_id"`
+
+	EstimatedAgeYears *int   `json:"estimated_age_years"`
+	AgeEstimateMethod string `gorm:"size:30" json:"age_estimate_method"`
+
+	GenitalExamined    bool   `json:"genital_examined"`
+	Hymen              string `gorm:"size:20;index" json:"hymen"`
+	GenitalTears       *bool  `json:"genital_tears"`
+	Bleeding           *bool  `json:"bleeding"`
+	Discharge          *bool  `json:"discharge"`
+	AnalExamination    string `gorm:"size:20;index" json:"anal_examination"`
+	PenetrationOpinion string `gorm:"size:20;index" json:"penetration_opinion"`
+	GenitalNotes       string `gorm:"type:text" json:"genital_notes"`
+
+	RecordedByID uint `json:"recorded_by_id"`
+}
+
+// InjuryStat is the number of injuries of one type in one part of the body
+type InjuryStat struct {
+	InjuryType string `json:"injury_type"`
+	BodyPart   string `json:"body_part"` // the region's group, e.g. head
+	Injuries   int64  `json:"injuries"`
+}
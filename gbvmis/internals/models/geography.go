@@ -0,0 +1,126 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Levels of the administrative hierarchy, from the top down
+const (
+	AdminLevelRegion    = "region"
+	AdminLevelDistrict  = "district"
+	AdminLevelCounty    = "county"
+	AdminLevelSubcounty = "subcounty"
+	AdminLevelParish    = "parish"
+	AdminLevelVillage   = "village"
+)
+
+// AdminLevels lists the administrative levels in order; an area's parent is
+// always at an earlier level
+var AdminLevels = []string{
+	AdminLevelRegion,
+	AdminLevelDistrict,
+	AdminLevelCounty,
+	AdminLevelSubcounty,
+	AdminLevelParish,
+	AdminLevelVillage,
+}
+
+// Levels of the police command hierarchy above police posts
+const (
+	PoliceLevelRegion   = "region"
+	PoliceLevelDivision = "division"
+	PoliceLevelStation  = "station"
+)
+
+// PoliceLevels lists the police command levels in order. Posts sit below
+// stations and are PolicePost records.
+var PoliceLevels = []string{
+	PoliceLevelRegion,
+	PoliceLevelDivision,
+	PoliceLevelStation,
+}
+
+// ErrHierarchyLevels means an import would leave a record at the same level as
+// its parent or above it, which could also close a loop of parents
+var ErrHierarchyLevels = errors.New("every record must sit at a lower level than its parent")
+
+// AdminArea is one unit of the administrative hierarchy. Codes come from the
+// imported gazetteer and identify the area across imports. The coordinates
+// are the area's centroid, used to place it on maps.
+type AdminArea struct {
+	gorm.Model
+	Code     string `gorm:"size:50;uniqueIndex" json:"code"`
+	Name     string `gorm:"index" json:"name"`
+	Level    string `gorm:"size:20;index" json:"level"`
+	ParentID *uint  `gorm:"index" json:"parent_id"`
+
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// PoliceUnit is a region, division or station of the police command
+// hierarchy
+type PoliceUnit struct {
+	gorm.Model
+	Code     string `gorm:"size:50;uniqueIndex" json:"code"`
+	Name     string `gorm:"index" json:"name"`
+	Level    string `gorm:"size:20;index" json:"level"`
+	ParentID *uint  `gorm:"index" json:"parent_id"`
+
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// GeographyImportResult summarises a hierarchy CSV import
+type GeographyImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// AreaCaseCount is the number of cases in one area of a hierarchy
+type AreaCaseCount struct {
+	AreaID uint   `json:"area_id"`
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Cases  int64  `json:"cases"`
+
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// MinGeoCellSize is the smallest case count published for an area on maps.
+// Smaller counts are suppressed so survivors in sparsely populated areas
+// cannot be picked out.
+const MinGeoCellSize = 5
+
+// NearbyPolicePost is a police post with its distance from a point
+type NearbyPolicePost struct {
+	PolicePost
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// NearbyHealthFacility is a health facility with its distance from a point
+type NearbyHealthFacility struct {
+	HealthFacility
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// GeoJSON types for map exports (RFC 7946). Geometry is nil for features
+// whose location is unknown.
+type GeoFeatureCollection struct {
+	Type     string       `json:"type"`
+	Features []GeoFeature `json:"features"`
+}
+
+type GeoFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *GeoPoint              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type GeoPoint struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"` // longitude, latitude
+}
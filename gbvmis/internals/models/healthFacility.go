@@ -8,6 +8,15 @@ type HealthFacility struct {
 	Location string `json:"location"`
 	Contact  string `json:"contact"`
 
+	AdminAreaID *uint `gorm:"index" json:"admin_area_id"`
+
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+
+	// GbvServices marks facilities offering post-violence care: PEP,
+	// emergency contraception and forensic examination
+	GbvServices bool `gorm:"index" json:"gbv_services"`
+
 	// Relationships
 	Practitioners []HealthPractitioner `gorm:"foreignKey:FacilityID" json:"practitioners"`
 }
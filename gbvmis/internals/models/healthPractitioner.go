@@ -24,11 +24,15 @@ type Examination struct {
 	ExamDate       string `gorm:"type:date" json:"exam_date"`
 	Findings       string `gorm:"type:text" json:"findings"`
 	Treatment      string `gorm:"type:text" json:"treatment"`
-	Referral       string `json:"referral"` // Optional referral info
+	Referral       string `json:"referral"` // Free-text note; structured referrals are models.Referral
 	ConsentGiven   bool   `json:"consent_given"`
 
 	Victim       Victim             `gorm:"foreignKey:VictimID"`
 	Case         Case               `gorm:"foreignKey:CaseID"`
 	Facility     HealthFacility     `gorm:"foreignKey:FacilityID"`
 	Practitioner HealthPractitioner `gorm:"foreignKey:PractitionerID"`
+
+	// Coded findings, loaded where the examination is searched or exported
+	Injuries      []ExaminationInjury  `gorm:"foreignKey:ExaminationID" json:"injuries,omitempty"`
+	CodedFindings *ExaminationFindings `gorm:"foreignKey:ExaminationID" json:"coded_findings,omitempty"`
 }
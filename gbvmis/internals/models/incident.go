@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GBV classification of an incident. Each incident takes the one type that
+// best describes it, so counts by type add up to the number of incidents.
+const (
+	ViolencePhysical      = "physical"
+	ViolenceSexual        = "sexual"
+	ViolenceEmotional     = "emotional"
+	ViolenceEconomic      = "economic"
+	ViolenceFGM           = "fgm"
+	ViolenceEarlyMarriage = "early_marriage"
+)
+
+// ViolenceTypes lists the accepted violence types
+var ViolenceTypes = []string{
+	ViolencePhysical,
+	ViolenceSexual,
+	ViolenceEmotional,
+	ViolenceEconomic,
+	ViolenceFGM,
+	ViolenceEarlyMarriage,
+}
+
+// Where an incident happened
+const (
+	SettingHome        = "home"
+	SettingSchool      = "school"
+	SettingWorkplace   = "workplace"
+	SettingPublicPlace = "public_place"
+	SettingInstitution = "institution" // e.g., a prison, hospital or children's home
+	SettingOnline      = "online"
+	SettingOther       = "other"
+)
+
+// IncidentSettings lists the accepted incident settings
+var IncidentSettings = []string{
+	SettingHome,
+	SettingSchool,
+	SettingWorkplace,
+	SettingPublicPlace,
+	SettingInstitution,
+	SettingOnline,
+	SettingOther,
+}
+
+// Incident is one occurrence of violence reported in a case. A case may
+// cover several, e.g. repeated abuse; the earliest is the case's incident date.
+type Incident struct {
+	gorm.Model
+	CaseID       uint      `gorm:"index" json:"case_id"`
+	OccurredAt   time.Time `gorm:"index" json:"occurred_at"`
+	TimeKnown    bool      `json:"time_known"` // false when only the date is known
+	ReportedAt   time.Time `json:"reported_at"`
+	District     string    `gorm:"index" json:"district"`
+	Subcounty    string    `json:"subcounty"`
+	Village      string    `json:"village"`
+	AdminAreaID  *uint     `gorm:"index" json:"admin_area_id"` // Most specific area known; fills in the names above
+	Latitude     *float64  `json:"latitude"`
+	Longitude    *float64  `json:"longitude"`
+	Setting      string    `gorm:"size:20;index" json:"setting"`
+	ViolenceType string    `gorm:"size:20;index" json:"violence_type"`
+	Description  string    `gorm:"type:text" json:"description"`
+	RecordedByID uint      `json:"recorded_by_id"`
+}
+
+// IncidentStat is the number of incidents of one violence type in one setting
+type IncidentStat struct {
+	ViolenceType string `json:"violence_type"`
+	Setting      string `json:"setting"`
+	Incidents    int64  `json:"incidents"`
+}
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Kinds of specimen sent for laboratory analysis
+const (
+	SpecimenBlood             = "blood"
+	SpecimenUrine             = "urine"
+	SpecimenHighVaginalSwab   = "high_vaginal_swab"
+	SpecimenEndocervicalSwab  = "endocervical_swab"
+	SpecimenVulvalSwab        = "vulval_swab"
+	SpecimenAnalSwab          = "anal_swab"
+	SpecimenOralSwab          = "oral_swab"
+	SpecimenSkinSwab          = "skin_swab" // e.g. from a bite mark or semen stain
+	SpecimenFingernail        = "fingernail_scrapings"
+	SpecimenPubicHairCombings = "pubic_hair_combings"
+	SpecimenHair              = "hair"
+	SpecimenClothing          = "clothing"
+	SpecimenReferenceBuccal   = "reference_buccal_swab" // a known DNA sample from the survivor or a suspect
+	SpecimenOther             = "other"
+)
+
+// LabSpecimenTypes lists the accepted specimen types
+var LabSpecimenTypes = []string{
+	SpecimenBlood,
+	SpecimenUrine,
+	SpecimenHighVaginalSwab,
+	SpecimenEndocervicalSwab,
+	SpecimenVulvalSwab,
+	SpecimenAnalSwab,
+	SpecimenOralSwab,
+	SpecimenSkinSwab,
+	SpecimenFingernail,
+	SpecimenPubicHairCombings,
+	SpecimenHair,
+	SpecimenClothing,
+	SpecimenReferenceBuccal,
+	SpecimenOther,
+}
+
+// Analyses that can be requested
+const (
+	AnalysisDNAProfile  = "dna_profile"
+	AnalysisSemen       = "semen_detection" // acid phosphatase or PSA
+	AnalysisSpermatozoa = "spermatozoa_microscopy"
+	AnalysisToxicology  = "toxicology"
+	AnalysisAlcohol     = "blood_alcohol"
+	AnalysisHIV         = "hiv"
+	AnalysisHepatitisB  = "hepatitis_b"
+	AnalysisSyphilis    = "syphilis"
+	AnalysisPregnancy   = "pregnancy"
+	AnalysisSTIScreen   = "sti_screen"
+	AnalysisOther       = "other"
+)
+
+// LabAnalyses lists the accepted analyses
+var LabAnalyses = []string{
+	AnalysisDNAProfile,
+	AnalysisSemen,
+	AnalysisSpermatozoa,
+	AnalysisToxicology,
+	AnalysisAlcohol,
+	AnalysisHIV,
+	AnalysisHepatitisB,
+	AnalysisSyphilis,
+	AnalysisPregnancy,
+	AnalysisSTIScreen,
+	AnalysisOther,
+}
+
+// Lab request statuses. A request is dispatched to a lab, received there,
+// analysed and resulted. The lab may reject it, e.g. for a broken seal, and
+// it may be cancelled before dispatch.
+const (
+	LabStatusRequested  = "requested"
+	LabStatusDispatched = "dispatched"
+	LabStatusReceived   = "received"
+	LabStatusInAnalysis = "in_analysis"
+	LabStatusResulted   = "resulted"
+	LabStatusRejected   = "rejected"
+	LabStatusCancelled  = "cancelled"
+)
+
+// LabTransitions lists the statuses each status may move to. Dispatch and
+// results have their own endpoints since they carry more than a note.
+var LabTransitions = map[string][]string{
+	LabStatusRequested:  {LabStatusDispatched, LabStatusCancelled},
+	LabStatusDispatched: {LabStatusReceived, LabStatusRejected},
+	LabStatusReceived:   {LabStatusInAnalysis, LabStatusResulted, LabStatusRejected},
+	LabStatusInAnalysis: {LabStatusResulted},
+}
+
+// LabOpenStatuses are the statuses of requests still waiting for results
+var LabOpenStatuses = []string{LabStatusRequested, LabStatusDispatched, LabStatusReceived, LabStatusInAnalysis}
+
+// Outcome of one analysis
+const (
+	LabOutcomeDetected     = "detected" // positive, or a DNA profile obtained
+	LabOutcomeNotDetected  = "not_detected"
+	LabOutcomeInconclusive = "inconclusive"
+	LabOutcomeNotTested    = "not_tested" // e.g. insufficient specimen
+)
+
+// LabOutcomes lists the accepted outcomes
+var LabOutcomes = []string{LabOutcomeDetected, LabOutcomeNotDetected, LabOutcomeInconclusive, LabOutcomeNotTested}
+
+// ErrLabStatusChanged means the lab request was moved on by someone else
+// after it was read
+var ErrLabStatusChanged = errors.New("lab request status changed since it was read")
+
+// ErrLabExhibitInUse means an exhibit is already a specimen in an open lab request
+var ErrLabExhibitInUse = errors.New("exhibit is already in an open lab request")
+
+// ErrLabExhibitNotHeld means a specimen's exhibit is not with an officer
+// under the specimen's seal, so it cannot be handed to the lab
+var ErrLabExhibitNotHeld = errors.New("exhibit is not held by an officer under the specimen's seal")
+
+// LabExhibitCategory is the exhibit category of specimens registered from a
+// lab request
+const LabExhibitCategory = "Biological sample"
+
+// LabRequest asks a laboratory to analyse specimens from a case, usually
+// taken at a medical examination
+type LabRequest struct {
+	gorm.Model
+	CaseID        uint                        `gorm:"index" json:"case_id"`
+	ExaminationID *uint                       `gorm:"index" json:"examination_id"`
+	Analyses      datatypes.JSONSlice[string] `gorm:"type:json" json:"analyses"`
+	Urgent        bool                        `json:"urgent"`
+	ClinicalNotes string                      `gorm:"type:text" json:"clinical_notes"`
+	Status        string                      `gorm:"size:20;index" json:"status"`
+	RequestedByID uint                        `json:"requested_by_id"`
+
+	LabName      string `gorm:"index" json:"lab_name"`
+	LabReference string `json:"lab_reference"` // the lab's own number for the request
+	SealsIntact  *bool  `json:"seals_intact"`  // as found by the lab on receipt
+
+	RequestedAt       time.Time  `gorm:"index" json:"requested_at"`
+	DispatchedAt      *time.Time `json:"dispatched_at"`
+	ReceivedAt        *time.Time `json:"received_at"`
+	AnalysisStartedAt *time.Time `json:"analysis_started_at"`
+	ResultedAt        *time.Time `json:"resulted_at"`
+	ClosedAt          *time.Time `json:"closed_at"` // rejected or cancelled
+
+	Specimens []LabSpecimen      `gorm:"foreignKey:LabRequestID" json:"specimens"`
+	Results   []LabResult        `gorm:"foreignKey:LabRequestID" json:"results"`
+	Updates   []LabRequestUpdate `gorm:"foreignKey:LabRequestID" json:"updates"`
+}
+
+// LabSpecimen is one sealed specimen sent with a request. Each is also an
+// exhibit so its chain of custody is kept in the exhibit register.
+type LabSpecimen struct {
+	gorm.Model
+	LabRequestID  uint      `gorm:"index" json:"lab_request_id"`
+	SpecimenType  string    `gorm:"size:30;index" json:"specimen_type"`
+	BodyRegion    string    `gorm:"size:40" json:"body_region"` // body map code, when taken from the body
+	CollectedAt   time.Time `json:"collected_at"`
+	CollectedByID uint      `json:"collected_by_id"`
+	SealNumber    string    `gorm:"size:50;index" json:"seal_number"`
+	ExhibitID     uint      `gorm:"index" json:"exhibit_id"`
+	Notes         string    `gorm:"type:text" json:"notes"`
+}
+
+// LabResult is the outcome of one analysis, on one specimen when the lab
+// reports per specimen
+type LabResult struct {
+	gorm.Model
+	LabRequestID   uint      `gorm:"index" json:"lab_request_id"`
+	SpecimenID     *uint     `gorm:"index" json:"specimen_id"`
+	Analysis       string    `gorm:"size:30;index" json:"analysis"`
+	Outcome        string    `gorm:"size:20;index" json:"outcome"`
+	Value          string    `json:"value"` // measured value, e.g. 0.08
+	Unit           string    `json:"unit"`  // e.g. g/dL
+	Interpretation string    `gorm:"type:text" json:"interpretation"`
+	AnalystName    string    `json:"analyst_name"`
+	ReportedAt     time.Time `json:"reported_at"`
+	RecordedByID   uint      `json:"recorded_by_id"`
+}
+
+// LabRequestUpdate is one status change of a lab request
+type LabRequestUpdate struct {
+	gorm.Model
+	LabRequestID uint   `gorm:"index" json:"lab_request_id"`
+	FromStatus   string `gorm:"size:20" json:"from_status"`
+	ToStatus     string `gorm:"size:20" json:"to_status"`
+	Note         string `gorm:"type:text" json:"note"`
+	UpdatedByID  uint   `json:"updated_by_id"`
+}
+
+// LabTurnaround is the request count and median turnaround times at one lab
+type LabTurnaround struct {
+	LabName               string   `json:"lab_name"`
+	Requests              int64    `json:"requests"`
+	Resulted              int64    `json:"resulted"`
+	Open                  int64    `json:"open"`
+	Rejected              int64    `json:"rejected"`
+	MedianHoursToDispatch *float64 `json:"median_hours_to_dispatch"` // requested to dispatched
+	MedianDaysInTransit   *float64 `json:"median_days_in_transit"`   // dispatched to received
+	MedianDaysInLab       *float64 `json:"median_days_in_lab"`       // received to resulted
+	MedianDaysToResult    *float64 `json:"median_days_to_result"`    // requested to resulted
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification types
+const (
+	NotificationWatchlistHit          = "watchlist_hit"
+	NotificationProtectionOrderBreach = "protection_order_breach"
+)
+
+// Notification is a message for one officer or for everyone at a police post.
+// EntityType and EntityID point at the record it is about.
+type Notification struct {
+	gorm.Model
+	PolicePostID *uint      `gorm:"index" json:"police_post_id"`
+	OfficerID    *uint      `gorm:"index" json:"officer_id"`
+	Type         string     `gorm:"size:50;index" json:"type"`
+	Title        string     `json:"title"`
+	Message      string     `gorm:"type:text" json:"message"`
+	EntityType   string     `gorm:"size:50" json:"entity_type"`
+	EntityID     uint       `json:"entity_id"`
+	ReadAt       *time.Time `json:"read_at"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Police medical examination forms: PF3 for assault, PF3A for sexual
+// offences
+const (
+	FormPF3  = "pf3"
+	FormPF3A = "pf3a"
+)
+
+// Pf3Forms lists the accepted forms
+var Pf3Forms = []string{FormPF3, FormPF3A}
+
+// Pf3Template is an uploaded version of a form's layout. Each upload gets the
+// next version number; the newest active version is used, and the built-in
+// layout (version 0) when there is none.
+type Pf3Template struct {
+	gorm.Model
+	Form        string `gorm:"size:10;index" json:"form"`
+	Version     int    `json:"version"`
+	Body        string `gorm:"type:text" json:"body"`
+	Active      bool   `json:"active"`
+	CreatedByID uint   `json:"created_by_id"`
+}
+
+// Pf3Document is a rendered, signed form. ContentSHA256 is the digest of the
+// incident, victim and examination values printed on it and Signature the HMAC of that digest with the
+// verification code and issue time, so a printed form can be checked against
+// the record through its verification code.
+type Pf3Document struct {
+	gorm.Model
+	ExaminationID    uint      `gorm:"index" json:"examination_id"`
+	Form             string    `gorm:"size:10" json:"form"`
+	TemplateVersion  int       `json:"template_version"`
+	VerificationCode string    `gorm:"size:32;uniqueIndex" json:"verification_code"`
+	ContentSHA256    string    `gorm:"size:64" json:"content_sha256"`
+	Signature        string    `gorm:"size:64" json:"signature"`
+	IssuedByID       uint      `json:"issued_by_id"`
+	IssuedAt         time.Time `json:"issued_at"`
+	FileName         string    `json:"file_name"`
+	Size             int64     `json:"size"`
+	BlobSHA256       string    `gorm:"size:64;index" json:"blob_sha256"` // digest of the PDF file
+}
+
+// Pf3Verification is what anyone holding a form learns by checking its
+// verification code. It names no one.
+type Pf3Verification struct {
+	Valid            bool      `json:"valid"`                  // the signature matches the record
+	RecordUnchanged  bool      `json:"record_unchanged"`       // the examination still says what the form says
+	FileMatches      *bool     `json:"file_matches,omitempty"` // set when a file digest was given
+	Form             string    `json:"form"`
+	VerificationCode string    `json:"verification_code"`
+	IssuedAt         time.Time `json:"issued_at"`
+	CaseNumber       string    `json:"case_number"`
+	ExamDate         string    `json:"exam_date"`
+	Facility         string    `json:"facility"`
+	Practitioner     string    `json:"practitioner"`
+	ContentSHA256    string    `json:"content_sha256"`
+	FileSHA256       string    `json:"file_sha256"`
+}
@@ -8,6 +8,14 @@ type PolicePost struct {
 	Location string `json:"location"`
 	Contact  string `json:"contact"`
 
+	// Place in the hierarchies; Code identifies the post in police unit imports
+	Code        string `gorm:"size:50;uniqueIndex:idx_police_post_code,where:code <> ''" json:"code"`
+	StationID   *uint  `gorm:"index" json:"station_id"`
+	AdminAreaID *uint  `gorm:"index" json:"admin_area_id"`
+
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+
 	// Relationships
 	Officers []PoliceOfficer `gorm:"foreignKey:PostID" json:"officers"`
 }
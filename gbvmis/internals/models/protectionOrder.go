@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Order types under the Domestic Violence Act
+const (
+	OrderTypeInterimProtection = "interim_protection"
+	OrderTypeProtection        = "protection"
+	OrderTypeExclusion         = "exclusion" // respondent excluded from the shared home
+)
+
+// ProtectionOrderTypes lists the accepted order types
+var ProtectionOrderTypes = []string{OrderTypeInterimProtection, OrderTypeProtection, OrderTypeExclusion}
+
+// Protection order statuses
+const (
+	OrderStatusActive  = "active"
+	OrderStatusExpired = "expired"
+	OrderStatusRevoked = "revoked"
+)
+
+// ProtectionOrder is a court order protecting a victim from a respondent
+// suspect. Terms holds each condition the respondent must keep.
+type ProtectionOrder struct {
+	gorm.Model
+	CaseID              uint                        `gorm:"index" json:"case_id"`
+	VictimID            uint                        `gorm:"index" json:"victim_id"`
+	RespondentSuspectID uint                        `gorm:"index" json:"respondent_suspect_id"`
+	OrderType           string                      `gorm:"size:30" json:"order_type"`
+	OrderNumber         string                      `gorm:"size:100" json:"order_number"`
+	IssuingCourt        string                      `json:"issuing_court"`
+	IssuedOn            time.Time                   `gorm:"type:date" json:"issued_on"`
+	ValidFrom           time.Time                   `gorm:"type:date" json:"valid_from"`
+	ValidUntil          *time.Time                  `gorm:"type:date" json:"valid_until"` // nil until further order
+	Terms               datatypes.JSONSlice[string] `json:"terms"`
+	Status              string                      `gorm:"size:20;index" json:"status"`
+	RevokedAt           *time.Time                  `json:"revoked_at"`
+	RevokeReason        string                      `gorm:"type:text" json:"revoke_reason"`
+	RecordedByID        uint                        `json:"recorded_by_id"`
+
+	Case              Case                    `gorm:"foreignKey:CaseID" json:"-"`
+	Victim            Victim                  `gorm:"foreignKey:VictimID" json:"-"`
+	RespondentSuspect Suspect                 `gorm:"foreignKey:RespondentSuspectID" json:"-"`
+	Breaches          []ProtectionOrderBreach `gorm:"foreignKey:OrderID" json:"breaches"`
+}
+
+// ProtectionOrderBreach is a reported breach of an order. Every breach is
+// investigated as a new case, linked back to the order's case.
+type ProtectionOrderBreach struct {
+	gorm.Model
+	OrderID      uint      `gorm:"index" json:"order_id"`
+	OccurredAt   time.Time `json:"occurred_at"`
+	Location     string    `json:"location"`
+	Description  string    `gorm:"type:text" json:"description"`
+	ReportedByID uint      `json:"reported_by_id"`
+	NewCaseID    uint      `gorm:"index" json:"new_case_id"`
+}
+
+// SafetyPlan is what a victim and officer agreed to keep the victim safe.
+// Plans are revised over time; the newest is the one in use.
+type SafetyPlan struct {
+	gorm.Model
+	VictimID          uint                        `gorm:"index" json:"victim_id"`
+	CaseID            *uint                       `gorm:"index" json:"case_id"`
+	SafePlace         string                      `json:"safe_place"`
+	EmergencyContacts datatypes.JSONSlice[string] `json:"emergency_contacts"`
+	CodeWord          string                      `json:"code_word"`
+	Measures          datatypes.JSONSlice[string] `json:"measures"`
+	ReviewDate        *time.Time                  `gorm:"type:date" json:"review_date"`
+	Notes             string                      `gorm:"type:text" json:"notes"`
+	CreatedByID       uint                        `json:"created_by_id"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of service provider survivors are referred to
+const (
+	ProviderShelter         = "shelter"
+	ProviderLegalAid        = "legal_aid"
+	ProviderCounselling     = "counselling" // psychosocial support
+	ProviderChildProtection = "child_protection"
+	ProviderMedical         = "medical"
+	ProviderOther           = "other"
+)
+
+// ServiceProviderTypes lists the accepted provider types
+var ServiceProviderTypes = []string{
+	ProviderShelter,
+	ProviderLegalAid,
+	ProviderCounselling,
+	ProviderChildProtection,
+	ProviderMedical,
+	ProviderOther,
+}
+
+// Referral statuses. A referral is sent, then accepted (or declined) by the
+// provider, attended by the survivor and finally closed.
+const (
+	ReferralStatusSent     = "sent"
+	ReferralStatusAccepted = "accepted"
+	ReferralStatusDeclined = "declined"
+	ReferralStatusAttended = "attended"
+	ReferralStatusClosed   = "closed"
+)
+
+// ReferralTransitions lists the statuses each status may move to
+var ReferralTransitions = map[string][]string{
+	ReferralStatusSent:     {ReferralStatusAccepted, ReferralStatusDeclined, ReferralStatusClosed},
+	ReferralStatusAccepted: {ReferralStatusAttended, ReferralStatusClosed},
+	ReferralStatusDeclined: {ReferralStatusClosed},
+	ReferralStatusAttended: {ReferralStatusClosed},
+}
+
+// ErrReferralStatusChanged means the referral was moved on by someone else
+// after it was read
+var ErrReferralStatusChanged = errors.New("referral status changed since it was read")
+
+// ServiceProvider is an organisation in the referral directory
+type ServiceProvider struct {
+	gorm.Model
+	Name         string `json:"name"`
+	ProviderType string `gorm:"size:30;index" json:"provider_type"`
+	Services     string `gorm:"type:text" json:"services"` // what is offered, e.g. "emergency shelter up to 3 months"
+	Location     string `json:"location"`
+	District     string `gorm:"index" json:"district"`
+	Contact      string `json:"contact"`
+	Email        string `json:"email"`
+	ContactName  string `json:"contact_name"`
+	Active       bool   `gorm:"default:true" json:"active"`
+}
+
+// Referral sends a victim to a service provider from a case or a medical
+// examination. PolicePostID is the referring station, used for reporting.
+type Referral struct {
+	gorm.Model
+	VictimID      uint   `gorm:"index" json:"victim_id"`
+	CaseID        *uint  `gorm:"index" json:"case_id"`
+	ExaminationID *uint  `gorm:"index" json:"examination_id"`
+	ProviderID    uint   `gorm:"index" json:"provider_id"`
+	PolicePostID  uint   `gorm:"index" json:"police_post_id"`
+	ReferredByID  uint   `json:"referred_by_id"`
+	Reason        string `gorm:"type:text" json:"reason"`
+	Urgent        bool   `json:"urgent"`
+	Status        string `gorm:"size:20;index" json:"status"`
+
+	SentAt     time.Time  `json:"sent_at"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	AttendedAt *time.Time `json:"attended_at"`
+	ClosedAt   *time.Time `json:"closed_at"`
+
+	// What the provider reported back
+	ProviderFeedback string     `gorm:"type:text" json:"provider_feedback"`
+	Outcome          string     `json:"outcome"`
+	FeedbackAt       *time.Time `json:"feedback_at"`
+
+	Provider ServiceProvider  `gorm:"foreignKey:ProviderID" json:"provider"`
+	Victim   Victim           `gorm:"foreignKey:VictimID" json:"-"`
+	Updates  []ReferralUpdate `gorm:"foreignKey:ReferralID" json:"updates"`
+}
+
+// ReferralUpdate is one status change of a referral
+type ReferralUpdate struct {
+	gorm.Model
+	ReferralID  uint   `gorm:"index" json:"referral_id"`
+	FromStatus  string `gorm:"size:20" json:"from_status"`
+	ToStatus    string `gorm:"size:20" json:"to_status"`
+	Note        string `gorm:"type:text" json:"note"`
+	UpdatedByID uint   `json:"updated_by_id"`
+}
+
+// ReferralCompletion is the referral count and outcomes for one police post
+type ReferralCompletion struct {
+	PolicePostID   uint    `json:"police_post_id"`
+	PolicePost     string  `json:"police_post"`
+	Total          int64   `json:"total"`
+	Accepted       int64   `json:"accepted"`
+	Declined       int64   `json:"declined"`
+	Attended       int64   `json:"attended"`
+	Closed         int64   `json:"closed"`
+	CompletionRate float64 `json:"completion_rate"` // share of referrals the survivor attended
+}
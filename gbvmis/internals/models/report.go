@@ -0,0 +1,107 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Report output formats
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatXLSX = "xlsx"
+	ReportFormatPDF  = "pdf"
+)
+
+// ReportFormats lists the formats a report can be rendered to
+var ReportFormats = []string{ReportFormatCSV, ReportFormatXLSX, ReportFormatPDF}
+
+// Reporting periods. A scheduled run reports on the last complete period.
+const (
+	ReportPeriodMonthly   = "monthly"
+	ReportPeriodQuarterly = "quarterly"
+)
+
+// ReportPeriods lists the accepted reporting periods
+var ReportPeriods = []string{ReportPeriodMonthly, ReportPeriodQuarterly}
+
+// Report sections, each one of the dashboard statistics
+const (
+	ReportSectionCasesByStatus   = "cases_by_status"
+	ReportSectionCasesByCharge   = "cases_by_charge"
+	ReportSectionCasesByStation  = "cases_by_station"
+	ReportSectionCasesByDistrict = "cases_by_district"
+	ReportSectionCasesByMonth    = "cases_by_month"
+	ReportSectionVictimsByAge    = "victims_by_age_band"
+	ReportSectionRelationships   = "relationships"
+	ReportSectionTimeliness      = "timeliness"
+)
+
+// ReportSections lists the sections a report definition can include
+var ReportSections = []string{
+	ReportSectionCasesByStatus,
+	ReportSectionCasesByCharge,
+	ReportSectionCasesByStation,
+	ReportSectionCasesByDistrict,
+	ReportSectionCasesByMonth,
+	ReportSectionVictimsByAge,
+	ReportSectionRelationships,
+	ReportSectionTimeliness,
+}
+
+// How a report run was started
+const (
+	ReportTriggerScheduled = "scheduled"
+	ReportTriggerManual    = "manual"
+)
+
+// Generated report statuses
+const (
+	ReportStatusReady  = "ready"
+	ReportStatusFailed = "failed"
+)
+
+// ReportDefinition configures a periodic return: which statistics it holds,
+// the formats it is rendered to and when it runs. Schedule is a five-field
+// cron expression (minute hour day-of-month month day-of-week) in server
+// time. PerStation produces one report per police post instead of one
+// national report; PolicePostID limits it to a single post.
+type ReportDefinition struct {
+	gorm.Model
+	Name         string                      `json:"name"`
+	Description  string                      `gorm:"type:text" json:"description"`
+	Period       string                      `gorm:"size:20" json:"period"`
+	Sections     datatypes.JSONSlice[string] `gorm:"type:json" json:"sections"`
+	Formats      datatypes.JSONSlice[string] `gorm:"type:json" json:"formats"`
+	Schedule     string                      `gorm:"size:100" json:"schedule"`
+	PerStation   bool                        `json:"per_station"`
+	PolicePostID *uint                       `gorm:"index" json:"police_post_id"`
+	Active       bool                        `gorm:"index" json:"active"`
+	CreatedByID  uint                        `json:"created_by_id"`
+
+	LastRunAt *time.Time `json:"last_run_at"`
+	NextRunAt *time.Time `gorm:"index" json:"next_run_at"`
+}
+
+// GeneratedReport is one rendered file of a report run, kept as history.
+// Re-running a period adds new files rather than replacing the old ones.
+type GeneratedReport struct {
+	gorm.Model
+	DefinitionID  uint      `gorm:"index" json:"definition_id"`
+	PolicePostID  *uint     `gorm:"index" json:"police_post_id"`
+	PeriodStart   time.Time `gorm:"type:date;index" json:"period_start"`
+	PeriodEnd     time.Time `gorm:"type:date" json:"period_end"`
+	Format        string    `gorm:"size:10" json:"format"`
+	Status        string    `gorm:"size:20;index" json:"status"`
+	Error         string    `gorm:"type:text" json:"error,omitempty"`
+	Trigger       string    `gorm:"size:20" json:"trigger"`
+	RequestedByID *uint     `json:"requested_by_id"`
+
+	FileName   string `json:"file_name"`
+	MimeType   string `json:"mime_type"`
+	Size       int64  `json:"size"`
+	BlobSHA256 string `gorm:"size:64" json:"blob_sha256"`
+
+	Definition ReportDefinition `gorm:"foreignKey:DefinitionID" json:"-"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Risk levels, lowest first
+const (
+	RiskLevelLow     = "low"
+	RiskLevelMedium  = "medium"
+	RiskLevelHigh    = "high"
+	RiskLevelExtreme = "extreme" // danger of serious harm or death
+)
+
+// RiskLevels lists the levels in ascending order of danger
+var RiskLevels = []string{RiskLevelLow, RiskLevelMedium, RiskLevelHigh, RiskLevelExtreme}
+
+// Case priorities, set from the case's highest current risk level
+const (
+	CasePriorityRoutine  = "routine"
+	CasePriorityElevated = "elevated"
+	CasePriorityUrgent   = "urgent"
+	CasePriorityCritical = "critical"
+)
+
+// CaseClosedStatuses are the case statuses that no longer need follow-up
+var CaseClosedStatuses = []string{"closed", "dismissed", "withdrawn"}
+
+// RiskQuestionnaire is one version of a risk assessment question set. Once a
+// version has been used it is never edited; changes are made by creating the
+// next version with the same name. Only one version per name is active.
+type RiskQuestionnaire struct {
+	gorm.Model
+	Name        string `gorm:"uniqueIndex:idx_risk_questionnaire_version" json:"name"`
+	Version     int    `gorm:"uniqueIndex:idx_risk_questionnaire_version" json:"version"`
+	Description string `gorm:"type:text" json:"description"`
+	Active      bool   `gorm:"index" json:"active"`
+
+	// Score thresholds, as a share (0-1) of the maximum possible score
+	MediumThreshold  float64 `json:"medium_threshold"`
+	HighThreshold    float64 `json:"high_threshold"`
+	ExtremeThreshold float64 `json:"extreme_threshold"`
+
+	CreatedByID uint           `json:"created_by_id"`
+	Questions   []RiskQuestion `gorm:"foreignKey:QuestionnaireID" json:"questions"`
+}
+
+// RiskQuestion is a yes/no question. A yes adds Weight to the score; a yes to
+// a critical question puts the victim at high risk whatever the total.
+type RiskQuestion struct {
+	gorm.Model
+	QuestionnaireID uint    `gorm:"index" json:"questionnaire_id"`
+	Code            string  `gorm:"size:50" json:"code"`
+	Text            string  `gorm:"type:text" json:"text"`
+	Weight          float64 `json:"weight"`
+	Critical        bool    `json:"critical"`
+	Position        int     `json:"position"`
+}
+
+// RiskAssessment is one scored submission of a questionnaire for a victim in
+// a case. Victims are reassessed over time; the latest counts.
+type RiskAssessment struct {
+	gorm.Model
+	CaseID          uint                        `gorm:"index" json:"case_id"`
+	VictimID        uint                        `gorm:"index" json:"victim_id"`
+	QuestionnaireID uint                        `json:"questionnaire_id"`
+	AssessedByID    uint                        `json:"assessed_by_id"`
+	AssessedAt      time.Time                   `json:"assessed_at"`
+	Score           float64                     `json:"score"`
+	MaxScore        float64                     `json:"max_score"`
+	RiskLevel       string                      `gorm:"size:20;index" json:"risk_level"`
+	Factors         datatypes.JSONSlice[string] `json:"factors"` // What raised the level beyond the score, if anything
+	Notes           string                      `gorm:"type:text" json:"notes"`
+
+	Answers       []RiskAnswer      `gorm:"foreignKey:AssessmentID" json:"answers"`
+	Questionnaire RiskQuestionnaire `gorm:"foreignKey:QuestionnaireID" json:"-"`
+	AssessedBy    PoliceOfficer     `gorm:"foreignKey:AssessedByID" json:"-"`
+}
+
+// RiskAnswer is the answer to one question, with the weight it carried then
+type RiskAnswer struct {
+	gorm.Model
+	AssessmentID uint    `gorm:"index" json:"assessment_id"`
+	QuestionID   uint    `json:"question_id"`
+	Answer       bool    `json:"answer"`
+	Weight       float64 `json:"weight"`
+}
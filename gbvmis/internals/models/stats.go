@@ -0,0 +1,56 @@
+package models
+
+import "errors"
+
+// StatsFilter limits statistics to the cases of one police post and to
+// cases opened between From and To (YYYY-MM-DD, inclusive); empty fields
+// do not filter
+type StatsFilter struct {
+	PolicePostID *uint
+	From         string
+	To           string
+}
+
+// ErrStatsDate means a from or to filter is not a YYYY-MM-DD date
+var ErrStatsDate = errors.New("from and to must be dates in YYYY-MM-DD format")
+
+// StatCount is the number of cases under one value of a dimension, such as
+// a status, charge or district. ID is set when the value is a record.
+type StatCount struct {
+	ID    *uint  `json:"id,omitempty"`
+	Key   string `json:"key"`
+	Cases int64  `json:"cases"`
+}
+
+// StationStat is the number of cases registered at one police post
+type StationStat struct {
+	PolicePostID uint   `json:"police_post_id"`
+	PolicePost   string `json:"police_post"`
+	StationID    *uint  `json:"station_id"`
+	Station      string `json:"station"`
+	Cases        int64  `json:"cases"`
+}
+
+// MonthlyStat is the number of cases of one status opened in a month
+// (YYYY-MM)
+type MonthlyStat struct {
+	Month  string `json:"month"`
+	Status string `json:"status"`
+	Cases  int64  `json:"cases"`
+}
+
+// Timeliness measures
+const (
+	MeasureReportToArrest = "report_to_arrest" // days from the case being opened to the first arrest
+	MeasureReportToCourt  = "report_to_court"  // days from the case being opened to the first production in court
+	MeasureArrestToCourt  = "arrest_to_court"  // hours from detention to production in court, per custody record
+)
+
+// TimelinessStat is the median of one timeliness measure over the cases
+// (or custody records) where it can be computed
+type TimelinessStat struct {
+	Measure string   `json:"measure"`
+	Unit    string   `json:"unit"`
+	Count   int64    `json:"count"`
+	Median  *float64 `json:"median"`
+}
@@ -6,6 +6,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// SuspectStatusMerged marks a suspect record retired as a duplicate of another
+const SuspectStatusMerged = "merged"
+
 type Suspect struct {
 	gorm.Model
 	FirstName    string    `gorm:"size:50" json:"first_name"`
@@ -19,19 +22,29 @@ type Suspect struct {
 	Address      string    `json:"address"`
 	Occupation   string    `json:"occupation"`
 	Status       string    `gorm:"size:50" json:"status"`
-	Fingerprints []byte    `gorm:"type:bytea" json:"fingerprints"`
-	Photo        []byte    `gorm:"type:bytea" json:"photo"`
+	Fingerprints []byte    `gorm:"type:bytea" json:"-"` // Legacy inline data, moved to SuspectMedia by cmd/migrate-suspect-media
+	Photo        []byte    `gorm:"type:bytea" json:"-"` // Legacy inline data, moved to SuspectMedia by cmd/migrate-suspect-media
 	CreatedBy    string    `gorm:"size:50;not null" json:"created_by"`
 	UpdatedBy    string    `gorm:"size:50" json:"updated_by"`
+	MergedIntoID *uint     `gorm:"index" json:"merged_into_id"` // Set when this record was merged into another as a duplicate
+
+	// Physical description
+	HeightCm        int    `json:"height_cm"`
+	Build           string `gorm:"size:30" json:"build"` // e.g., slim, medium, heavy
+	Complexion      string `gorm:"size:30" json:"complexion"`
+	EyeColour       string `gorm:"size:30" json:"eye_colour"`
+	Hair            string `gorm:"size:50" json:"hair"`
+	PhysicalRemarks string `gorm:"type:text" json:"physical_remarks"`
 
 	// Relationships
-	Cases   []Case   `gorm:"many2many:case_suspects;" json:"cases"`
-	Arrests []Arrest `gorm:"foreignKey:SuspectID" json:"arrests"`
+	Cases      []Case             `gorm:"many2many:case_suspects;" json:"cases"`
+	Arrests    []Arrest           `gorm:"foreignKey:SuspectID" json:"arrests"`
+	Media      []SuspectMedia     `gorm:"foreignKey:SuspectID" json:"media"`
+	Aliases    []SuspectAlias     `gorm:"foreignKey:SuspectID" json:"aliases"`
+	Marks      []SuspectMark      `gorm:"foreignKey:SuspectID" json:"marks"`
+	Associates []SuspectAssociate `gorm:"foreignKey:SuspectID" json:"associates"`
 }
 
-// <img src="data:image/jpeg;base64,{{ .photo }}" alt="Suspect photo">
-// <img src="data:image/png;base64,{{ .fingerprints }}" alt="Fingerprint">
-
 type Case struct {
 	gorm.Model
 	CaseNumber   string        `gorm:"uniqueIndex" json:"case_number"`
@@ -47,15 +60,31 @@ type Case struct {
 	Witnesses    []Witness     `gorm:"many2many:case_witnesses;" json:"witnesses"`
 	Officer      PoliceOfficer `gorm:"foreignKey:OfficerID"`
 	PolicePost   PolicePost    `gorm:"foreignKey:PolicePostID"`
+
+	Relationships []CaseRelationship `gorm:"foreignKey:CaseID" json:"relationships"`
+
+	// Highest current risk level among the case's victims, and the priority it sets
+	RiskLevel string `gorm:"size:20;index" json:"risk_level"`
+	Priority  string `gorm:"size:20;index" json:"priority"`
+
+	ParentCaseID *uint `gorm:"index" json:"parent_case_id"` // Case this one arose from, e.g. a protection order breach
+
+	Incidents []Incident `gorm:"foreignKey:CaseID" json:"incidents"`
 }
 
 type Arrest struct {
 	gorm.Model
 	ArrestDate  time.Time `gorm:"type:date" json:"arrest_date"`
 	Location    string    `json:"location"`
-	OfficerName string    `json:"officer_name"`
+	OfficerName string    `json:"officer_name"` // Kept for records captured before OfficerID existed
+	OfficerID   uint      `json:"officer_id"`
+	CaseID      uint      `json:"case_id"`
 	SuspectID   uint      `json:"suspect_id"`
 	Notes       string    `gorm:"type:text" json:"notes"`
+
+	Officer PoliceOfficer   `gorm:"foreignKey:OfficerID"`
+	Case    Case            `gorm:"foreignKey:CaseID"`
+	Custody []CustodyRecord `gorm:"foreignKey:ArrestID" json:"custody"`
 }
 
 type Charge struct {
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of suspect media
+const (
+	SuspectMediaPhoto       = "photo"
+	SuspectMediaFingerprint = "fingerprint"
+)
+
+// Photo views
+const (
+	SuspectViewFront        = "front"
+	SuspectViewLeftProfile  = "left_profile"
+	SuspectViewRightProfile = "right_profile"
+	SuspectViewOther        = "other"
+)
+
+// SuspectMedia is a photo or fingerprint image of a suspect. The content
+// lives in the blob store; lists only carry the thumbnail link.
+type SuspectMedia struct {
+	gorm.Model
+	SuspectID       uint      `gorm:"index" json:"suspect_id"`
+	Kind            string    `gorm:"size:20" json:"kind"`
+	View            string    `gorm:"size:30" json:"view"` // Photo view, or finger position for fingerprints
+	CapturedAt      time.Time `json:"captured_at"`
+	BlobSHA256      string    `gorm:"size:64;index" json:"blob_sha256"`
+	ThumbnailSHA256 string    `gorm:"size:64;index" json:"thumbnail_sha256"`
+	TemplateHash    string    `gorm:"size:128;index" json:"template_hash"` // Fingerprint template hash from the capture device, used for matching
+	FileName        string    `json:"file_name"`
+	MimeType        string    `gorm:"size:100" json:"mime_type"`
+	Size            int64     `json:"size"`
+	UploadedByID    uint      `json:"uploaded_by_id"`
+
+	DownloadURL  string `gorm:"-" json:"download_url"`
+	ThumbnailURL string `gorm:"-" json:"thumbnail_url"`
+
+	Blob FileBlob `gorm:"foreignKey:BlobSHA256;references:SHA256"`
+}
+
+// SetURLs fills in the download and thumbnail links
+func (m *SuspectMedia) SetURLs() {
+	m.DownloadURL = fmt.Sprintf("/api/suspect-media/%d/download", m.ID)
+	if m.ThumbnailSHA256 != "" {
+		m.ThumbnailURL = fmt.Sprintf("/api/suspect-media/%d/thumbnail", m.ID)
+	}
+}
+
+func (m *SuspectMedia) AfterFind(tx *gorm.DB) error {
+	m.SetURLs()
+	return nil
+}
@@ -0,0 +1,67 @@
+package models
+
+import "gorm.io/gorm"
+
+// How a suspect is related to the victim. Relationship type drives both risk
+// assessment and GBV reporting.
+const (
+	RelationshipIntimatePartner = "intimate_partner"
+	RelationshipFormerPartner   = "former_partner"
+	RelationshipRelative        = "relative"
+	RelationshipAcquaintance    = "acquaintance"
+	RelationshipAuthority       = "person_in_authority" // e.g., teacher, employer, religious leader
+	RelationshipStranger        = "stranger"
+	RelationshipUnknown         = "unknown"
+)
+
+// VictimRelationships lists the accepted relationship types
+var VictimRelationships = []string{
+	RelationshipIntimatePartner,
+	RelationshipFormerPartner,
+	RelationshipRelative,
+	RelationshipAcquaintance,
+	RelationshipAuthority,
+	RelationshipStranger,
+	RelationshipUnknown,
+}
+
+// Identifying mark types
+const (
+	MarkScar       = "scar"
+	MarkTattoo     = "tattoo"
+	MarkBirthmark  = "birthmark"
+	MarkAmputation = "amputation"
+	MarkDeformity  = "deformity"
+	MarkOther      = "other"
+)
+
+// SuspectAlias is another name a suspect is known by
+type SuspectAlias struct {
+	gorm.Model
+	SuspectID uint   `gorm:"index" json:"suspect_id"`
+	Alias     string `gorm:"size:100;index" json:"alias"`
+	Kind      string `gorm:"size:20" json:"kind"` // alias or nickname
+}
+
+// SuspectMark is a scar, tattoo or other mark that helps identify a suspect
+type SuspectMark struct {
+	gorm.Model
+	SuspectID    uint   `gorm:"index" json:"suspect_id"`
+	MarkType     string `gorm:"size:20" json:"mark_type"`
+	BodyLocation string `json:"body_location"` // e.g., left forearm
+	Description  string `gorm:"type:text" json:"description"`
+}
+
+// SuspectAssociate is a known associate of a suspect. AssociateSuspectID is
+// set when the associate is a suspect on record too.
+type SuspectAssociate struct {
+	gorm.Model
+	SuspectID          uint   `gorm:"index" json:"suspect_id"`
+	AssociateSuspectID *uint  `gorm:"index" json:"associate_suspect_id"`
+	FullName           string `json:"full_name"`
+	PhoneNumber        string `json:"phone_number"`
+	Relationship       string `json:"relationship"` // e.g., brother, friend, co-accused
+	Notes              string `gorm:"type:text" json:"notes"`
+
+	AssociateSuspect *Suspect `gorm:"foreignKey:AssociateSuspectID"`
+}
@@ -8,19 +8,39 @@ import (
 
 type Victim struct {
 	gorm.Model
-	FirstName   string    `gorm:"size:50" json:"first_name"`
-	LastName    string    `gorm:"size:50" json:"last_name"`
-	Gender      string    `gorm:"size:10" json:"gender"`
-	Dob         time.Time `gorm:"type:date" json:"dob"`
-	PhoneNumber string    `json:"phone_number"`
-	Address     string    `json:"address"`
-	Nationality string    `json:"nationality"`
-	Nin         string    `json:"nin"`
-	CreatedBy   string    `gorm:"size:50;not null" json:"created_by"`
-	UpdatedBy   string    `gorm:"size:50" json:"updated_by"`
+	FirstName    string    `gorm:"size:50" json:"first_name"`
+	LastName     string    `gorm:"size:50" json:"last_name"`
+	Gender       string    `gorm:"size:10" json:"gender"`
+	Dob          time.Time `gorm:"type:date" json:"dob"`
+	PhoneNumber  string    `json:"phone_number"`
+	Address      string    `json:"address"`
+	Nationality  string    `json:"nationality"`
+	Nin          string    `json:"nin"`
+	CreatedBy    string    `gorm:"size:50;not null" json:"created_by"`
+	UpdatedBy    string    `gorm:"size:50" json:"updated_by"`
+	MergedIntoID *uint     `gorm:"index" json:"merged_into_id"` // Set when this record was merged into another as a duplicate
+
+	AddressAreaID *uint `gorm:"index" json:"address_area_id"` // Administrative area of the address, as specific as known
 
 	// Relationships
 	Cases []Case `gorm:"many2many:case_victims;" json:"cases"`
+
+	Guardians []Guardian `gorm:"foreignKey:VictimID" json:"guardians,omitempty"`
+
+	// Computed on load; minor status for a case is judged at its incident date
+	Age      *int `gorm:"-" json:"age"`
+	IsMinor  bool `gorm:"-" json:"is_minor"`
+	Redacted bool `gorm:"-" json:"redacted,omitempty"`
+}
+
+// AfterFind fills in the victim's current age. IsMinor starts from it but is
+// only final once service.MarkMinors has judged it at each case's incident date
+func (v *Victim) AfterFind(tx *gorm.DB) error {
+	if age, ok := AgeAt(v.Dob, time.Now()); ok {
+		v.Age = &age
+		v.IsMinor = age < AdultAge
+	}
+	return nil
 }
 
 type Witness struct {
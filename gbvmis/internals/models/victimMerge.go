@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Review states of a duplicate candidate pair
+const (
+	DuplicateStatusPending   = "pending"
+	DuplicateStatusMerged    = "merged"
+	DuplicateStatusDismissed = "dismissed"
+)
+
+// VictimDuplicate is a pair of victim records that look like the same person,
+// waiting for someone to merge or dismiss it. VictimID is always the lower ID.
+type VictimDuplicate struct {
+	gorm.Model
+	VictimID     uint                        `gorm:"uniqueIndex:idx_victim_duplicate_pair" json:"victim_id"`
+	CandidateID  uint                        `gorm:"uniqueIndex:idx_victim_duplicate_pair" json:"candidate_id"`
+	Score        float64                     `json:"score"`
+	Reasons      datatypes.JSONSlice[string] `json:"reasons"`
+	Status       string                      `gorm:"size:20;index" json:"status"`
+	ReviewedByID *uint                       `json:"reviewed_by_id"`
+	ReviewedAt   *time.Time                  `json:"reviewed_at"`
+
+	Victim    Victim `gorm:"foreignKey:VictimID"`
+	Candidate Victim `gorm:"foreignKey:CandidateID"`
+}
+
+// VictimMerge is the audit record of one victim merged into another. Snapshot
+// holds the merged record as it was before the merge.
+type VictimMerge struct {
+	gorm.Model
+	KeptID               uint           `gorm:"index" json:"kept_id"`
+	MergedID             uint           `gorm:"index" json:"merged_id"`
+	MergedByID           uint           `json:"merged_by_id"`
+	Reason               string         `gorm:"type:text" json:"reason"`
+	Snapshot             datatypes.JSON `json:"snapshot"`
+	FilledFields         datatypes.JSON `json:"filled_fields"`
+	CasesRelinked        int64          `json:"cases_relinked"`
+	ExaminationsRelinked int64          `json:"examinations_relinked"`
+
+	MergedBy PoliceOfficer `gorm:"foreignKey:MergedByID"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Watchlist categories
+const (
+	WatchlistWanted  = "wanted"    // to be arrested on sight, e.g. absconded
+	WatchlistWatched = "watchlist" // of interest; report sightings to the issuing station
+)
+
+// Watchlist entry states
+const (
+	WatchlistStatusActive  = "active"
+	WatchlistStatusLifted  = "lifted"
+	WatchlistStatusExpired = "expired"
+)
+
+// What made a watchlist check run
+const (
+	WatchlistTriggerSuspectCreate = "suspect_create"
+	WatchlistTriggerSuspectSearch = "suspect_search"
+	WatchlistTriggerArrest        = "arrest"
+)
+
+// WatchlistEntry flags a suspect as wanted or watched by the issuing station
+type WatchlistEntry struct {
+	gorm.Model
+	SuspectID     uint       `gorm:"index" json:"suspect_id"`
+	Category      string     `gorm:"size:20" json:"category"`
+	Reason        string     `gorm:"type:text" json:"reason"`
+	IssuingPostID uint       `gorm:"index" json:"issuing_post_id"`
+	IssuedByID    uint       `json:"issued_by_id"`
+	IssuedAt      time.Time  `json:"issued_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	Status        string     `gorm:"size:20;index" json:"status"`
+	LiftedAt      *time.Time `json:"lifted_at"`
+	LiftReason    string     `json:"lift_reason"`
+
+	Suspect     Suspect       `gorm:"foreignKey:SuspectID"`
+	IssuingPost PolicePost    `gorm:"foreignKey:IssuingPostID"`
+	IssuedBy    PoliceOfficer `gorm:"foreignKey:IssuedByID"`
+}
+
+// WatchlistHit records one time a watchlisted person turned up
+type WatchlistHit struct {
+	gorm.Model
+	EntryID          uint                        `gorm:"index" json:"entry_id"`
+	Trigger          string                      `gorm:"size:30" json:"trigger"`
+	MatchedSuspectID *uint                       `json:"matched_suspect_id"` // The record that matched, if any; may be a duplicate of the listed suspect
+	ArrestID         *uint                       `json:"arrest_id"`
+	TriggeredByID    uint                        `json:"triggered_by_id"`
+	TriggeredPostID  uint                        `json:"triggered_post_id"`
+	Score            float64                     `json:"score"`
+	Reasons          datatypes.JSONSlice[string] `json:"reasons"`
+
+	Entry       WatchlistEntry `gorm:"foreignKey:EntryID"`
+	TriggeredBy PoliceOfficer  `gorm:"foreignKey:TriggeredByID"`
+}
@@ -0,0 +1,124 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleDocument(pages int) *Document {
+	d := New("Report (draft)")
+	d.Subject = "Sample"
+	d.Created = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < pages; i++ {
+		p := d.AddPage()
+		p.Text(50, 800, HelveticaBold, 12, "Café \\ (x)")
+		p.Line(50, 790, 545.28, 790, 0.5)
+		p.Gray(0.5)
+		p.Rect(10, 20, 30, 40, true)
+		p.Rect(10, 20, 30, 40, false)
+		p.Text(50, 700, Courier, 9, fmt.Sprintf("Page %d", i+1))
+	}
+	return d
+}
+
+func TestPageContentKnownAnswer(t *testing.T) {
+	out := sampleDocument(1).Bytes()
+	want := "stream\n" +
+		"BT /F2 12.00 Tf 50.00 800.00 Td (Caf\xe9 \\\\ \\(x\\)) Tj ET\n" +
+		"0.50 w 50.00 790.00 m 545.28 790.00 l S\n" +
+		"0.500 g 0.500 G\n" +
+		"10.00 20.00 30.00 40.00 re f\n" +
+		"10.00 20.00 30.00 40.00 re S\n" +
+		"BT /F3 9.00 Tf 50.00 700.00 Td (Page 1) Tj ET\n" +
+		"endstream"
+	if !bytes.Contains(out, []byte(want)) {
+		t.Fatalf("content stream not found in:\n%s", out)
+	}
+	for _, object := range []string{
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n",
+		"2 0 obj\n<< /Type /Pages /Kids [8 0 R] /Count 1 >>\nendobj\n",
+		"3 0 obj\n<< /Title (Report \\(draft\\)) /Subject (Sample) /Author () /Producer (gbvmis) /CreationDate (D:20260102030405Z) >>\nendobj\n",
+		"4 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\nendobj\n",
+		"7 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>\nendobj\n",
+		"8 0 obj\n<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595.28 841.89] /Resources << /Font << /F1 4 0 R /F2 5 0 R /F3 6 0 R /F4 7 0 R >> >> /Contents 9 0 R >>\nendobj\n",
+	} {
+		if !bytes.Contains(out, []byte(object)) {
+			t.Errorf("missing object:\n%s", object)
+		}
+	}
+}
+
+var (
+	startxrefPattern = regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`)
+	streamPattern    = regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)endstream`)
+)
+
+// TestDocumentStructure checks what a reader relies on to open the file:
+// every cross-reference offset points at its object and every stream is as
+// long as it says
+func TestDocumentStructure(t *testing.T) {
+	out := sampleDocument(3).Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Fatalf("header = %q", out[:9])
+	}
+
+	match := startxrefPattern.FindSubmatch(out)
+	if match == nil {
+		t.Fatal("no startxref at the end")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n0 14\n0000000000 65535 f \n")) {
+		t.Fatalf("startxref %d does not point at a table of 14 entries: %q", xref, out[xref:min(len(out), xref+30)])
+	}
+	entries := strings.Split(string(out[xref:]), "\n")[3:16]
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(entry[:10])
+		if err != nil || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("entry %d malformed: %q", i+1, entry)
+		}
+		if object := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(out[offset:], []byte(object)) {
+			t.Errorf("object %d: offset %d points at %q", i+1, offset, out[offset:min(len(out), offset+12)])
+		}
+	}
+	if !bytes.Contains(out, []byte("<< /Size 14 /Root 1 0 R /Info 3 0 R >>")) {
+		t.Error("trailer does not count 14 entries")
+	}
+	if !bytes.Contains(out, []byte("/Kids [8 0 R 10 0 R 12 0 R] /Count 3")) {
+		t.Error("page tree does not list the three pages")
+	}
+
+	streams := streamPattern.FindAllSubmatch(out, -1)
+	if len(streams) != 3 {
+		t.Fatalf("got %d streams, want 3", len(streams))
+	}
+	for i, stream := range streams {
+		length, _ := strconv.Atoi(string(stream[1]))
+		if length != len(stream[2]) {
+			t.Errorf("stream %d: /Length %d, content is %d bytes", i+1, length, len(stream[2]))
+		}
+		if !bytes.Contains(stream[2], []byte(fmt.Sprintf("(Page %d)", i+1))) {
+			t.Errorf("stream %d is not page %d", i+1, i+1)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := map[string]string{
+		"plain":         "plain",
+		"(a) \\ b":      "\\(a\\) \\\\ b",
+		"line\nbreak\t": "line break ",
+		"naïve":         "na\xefve",
+		"Kampala – ✓":   "Kampala ? ?",
+		"\x01":          "?",
+	}
+	for in, want := range tests {
+		if got := escape(in); got != want {
+			t.Errorf("escape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
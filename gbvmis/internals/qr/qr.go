@@ -0,0 +1,468 @@
+// Package qr encodes short byte strings, such as verification links, as QR
+// codes (ISO/IEC 18004) at error correction level M. Versions 1 to 10 are
+// supported, which holds up to 213 bytes.
+package qr
+
+import (
+	"errors"
+)
+
+// ErrTooLong means the data does not fit in a version 10 code
+var ErrTooLong = errors.New("qr: data too long")
+
+// Code is an encoded QR symbol. Modules are indexed [row][column]; true is
+// dark. The quiet zone of four modules is not included.
+type Code struct {
+	Version int
+	Size    int
+	Modules [][]bool
+}
+
+// Dark reports whether the module at row, column is dark
+func (c *Code) Dark(row, col int) bool {
+	return c.Modules[row][col]
+}
+
+// versionM describes the error correction blocks of each version at level M
+type versionM struct {
+	ecPerBlock int
+	blocks     []int // data codewords of each block
+	alignment  []int // alignment pattern centres
+}
+
+var versions = []versionM{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	3:  {26, []int{44}, []int{6, 22}},
+	4:  {18, []int{32, 32}, []int{6, 26}},
+	5:  {24, []int{43, 43}, []int{6, 30}},
+	6:  {16, []int{27, 27, 27, 27}, []int{6, 34}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	8:  {22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+// MaxVersion is the largest version Encode produces
+const MaxVersion = 10
+
+func (v versionM) dataCodewords() int {
+	total := 0
+	for _, n := range v.blocks {
+		total += n
+	}
+	return total
+}
+
+// Encode makes the smallest QR code holding data in byte mode
+func Encode(data []byte) (*Code, error) {
+	version := 0
+	for v := 1; v <= MaxVersion; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*versions[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	codewords := addErrorCorrection(encodeData(data, version), versions[version])
+
+	c := newCanvas(version)
+	c.drawFunctionPatterns()
+	c.drawCodewords(codewords)
+
+	best, bestPenalty := -1, 0
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if penalty := c.penalty(); best < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		c.applyMask(mask) // masking is its own inverse
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+
+	return &Code{Version: version, Size: c.size, Modules: c.modules}, nil
+}
+
+// encodeData builds the data codewords: mode, length, bytes, terminator and
+// padding
+func encodeData(data []byte, version int) []byte {
+	var bits bitBuffer
+	bits.append(0b0100, 4) // byte mode
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	capacity := 8 * versions[version].dataCodewords()
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	return bits.bytes()
+}
+
+// addErrorCorrection splits the data into blocks, appends Reed-Solomon
+// codewords to each and interleaves the result
+func addErrorCorrection(data []byte, v versionM) []byte {
+	divisor := rsDivisor(v.ecPerBlock)
+	var dataBlocks, ecBlocks [][]byte
+	longest := 0
+	for _, n := range v.blocks {
+		block := data[:n]
+		data = data[n:]
+		dataBlocks = append(dataBlocks, block)
+		ecBlocks = append(ecBlocks, rsRemainder(block, divisor))
+		longest = max(longest, n)
+	}
+
+	var out []byte
+	for i := 0; i < longest; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			out = append(out, block[i])
+		}
+	}
+	return out
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// Reed-Solomon over GF(256) with the QR polynomial x^8+x^4+x^3+x^2+1
+
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor returns the generator polynomial of the given degree, highest
+// coefficient first and the leading 1 left out
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// canvas is a symbol being drawn; function marks modules that are part of
+// the fixed patterns and so take no data or mask
+type canvas struct {
+	version  int
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newCanvas(version int) *canvas {
+	size := 17 + 4*version
+	c := &canvas{version: version, size: size}
+	c.modules = make([][]bool, size)
+	c.function = make([][]bool, size)
+	for i := range c.modules {
+		c.modules[i] = make([]bool, size)
+		c.function[i] = make([]bool, size)
+	}
+	return c
+}
+
+func (c *canvas) set(row, col int, dark bool) {
+	c.modules[row][col] = dark
+	c.function[row][col] = true
+}
+
+func (c *canvas) drawFunctionPatterns() {
+	for i := 0; i < c.size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(3, c.size-4)
+	c.drawFinder(c.size-4, 3)
+
+	centres := versions[c.version].alignment
+	last := len(centres) - 1
+	for i, row := range centres {
+		for j, col := range centres {
+			// Skip the three corners taken by finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dr := -2; dr <= 2; dr++ {
+				for dc := -2; dc <= 2; dc++ {
+					c.set(row+dr, col+dc, max(abs(dr), abs(dc)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas; drawFormatBits fills them in
+	c.drawFormatBits(0)
+	c.drawVersionBits()
+}
+
+// drawFinder draws a finder pattern and its light separator around the
+// centre module
+func (c *canvas) drawFinder(row, col int) {
+	for dr := -4; dr <= 4; dr++ {
+		for dc := -4; dc <= 4; dc++ {
+			r, k := row+dr, col+dc
+			if r < 0 || r >= c.size || k < 0 || k >= c.size {
+				continue
+			}
+			distance := max(abs(dr), abs(dc))
+			c.set(r, k, distance != 2 && distance != 4)
+		}
+	}
+}
+
+// drawFormatBits writes the error correction level and mask, with their BCH
+// code, in both copies
+func (c *canvas) drawFormatBits(mask int) {
+	data := 0b00<<3 | mask // level M
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	// Around the top-left finder
+	for i := 0; i <= 5; i++ {
+		c.set(i, 8, bit(i))
+	}
+	c.set(7, 8, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(8, 7, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(8, 14-i, bit(i))
+	}
+
+	// Split between the other two finders
+	for i := 0; i < 8; i++ {
+		c.set(8, c.size-1-i, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(c.size-15+i, 8, bit(i))
+	}
+	c.set(c.size-8, 8, true) // the dark module
+}
+
+// drawVersionBits writes the version information blocks of versions 7 and up
+func (c *canvas) drawVersionBits() {
+	if c.version < 7 {
+		return
+	}
+	rem := c.version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := c.version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := c.size-11+i%3, i/3
+		c.set(b, a, dark)
+		c.set(a, b, dark)
+	}
+}
+
+// drawCodewords places the codewords in the two-column zigzag from the
+// bottom-right corner, skipping function patterns
+func (c *canvas) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.size; vert++ {
+			row := vert
+			if upward {
+				row = c.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				col := right - j
+				if c.function[row][col] || i >= len(codewords)*8 {
+					continue
+				}
+				c.modules[row][col] = codewords[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+func (c *canvas) applyMask(mask int) {
+	for row := 0; row < c.size; row++ {
+		for col := 0; col < c.size; col++ {
+			if c.function[row][col] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (row+col)%2 == 0
+			case 1:
+				invert = row%2 == 0
+			case 2:
+				invert = col%3 == 0
+			case 3:
+				invert = (row+col)%3 == 0
+			case 4:
+				invert = (col/3+row/2)%2 == 0
+			case 5:
+				invert = row*col%2+row*col%3 == 0
+			case 6:
+				invert = (row*col%2+row*col%3)%2 == 0
+			case 7:
+				invert = ((row+col)%2+row*col%3)%2 == 0
+			}
+			if invert {
+				c.modules[row][col] = !c.modules[row][col]
+			}
+		}
+	}
+}
+
+// penalty scores a masked symbol by the four rules of the standard; the mask
+// with the lowest score is used
+func (c *canvas) penalty() int {
+	score := 0
+	line := func(get func(i int) bool) {
+		run := 1
+		var history []bool
+		for i := 0; i < c.size; i++ {
+			history = append(history, get(i))
+			if i > 0 && get(i) == get(i-1) {
+				run++
+			} else {
+				run = 1
+			}
+			if run == 5 {
+				score += 3
+			} else if run > 5 {
+				score++
+			}
+		}
+		// Finder-like 1:1:3:1:1 runs with four light modules on either side
+		pattern := []bool{true, false, true, true, true, false, true}
+		for i := 0; i+7 <= c.size; i++ {
+			match := true
+			for j, want := range pattern {
+				if history[i+j] != want {
+					match = false
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+			if lightRun(history, i-4, i) || lightRun(history, i+7, i+11) {
+				score += 40
+			}
+		}
+	}
+	for row := 0; row < c.size; row++ {
+		line(func(i int) bool { return c.modules[row][i] })
+	}
+	for col := 0; col < c.size; col++ {
+		line(func(i int) bool { return c.modules[i][col] })
+	}
+
+	dark := 0
+	for row := 0; row < c.size; row++ {
+		for col := 0; col < c.size; col++ {
+			if c.modules[row][col] {
+				dark++
+			}
+			if row+1 < c.size && col+1 < c.size {
+				m := c.modules[row][col]
+				if m == c.modules[row][col+1] && m == c.modules[row+1][col] && m == c.modules[row+1][col+1] {
+					score += 3
+				}
+			}
+		}
+	}
+	total := c.size * c.size
+	score += abs(dark*20-total*10) / total * 10
+	return score
+}
+
+// lightRun reports whether modules from to to (exclusive) are all light,
+// counting those outside the symbol as light
+func lightRun(modules []bool, from, to int) bool {
+	for i := from; i < to; i++ {
+		if i >= 0 && i < len(modules) && modules[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
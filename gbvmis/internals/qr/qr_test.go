@@ -0,0 +1,253 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The "HELLO WORLD" 1-M example worked through in the standard's tutorials:
+// sixteen data codewords and the ten error correction codewords they give.
+func TestReedSolomonKnownAnswer(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Fatalf("error correction = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeDataByteMode(t *testing.T) {
+	// Mode 0100, length 00000010, "h" 01101000, "i" 01101001, terminator 0000,
+	// then alternating pad bytes to the 16 data codewords of version 1-M
+	want := []byte{0x40, 0x26, 0x86, 0x90, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+
+	if got := encodeData([]byte("hi"), 1); !bytes.Equal(got, want) {
+		t.Fatalf("data codewords = % X, want % X", got, want)
+	}
+}
+
+func TestEncodeDataVersion10UsesSixteenBitLength(t *testing.T) {
+	got := encodeData(bytes.Repeat([]byte{'A'}, 200), 10)
+	// Mode 0100, then 200 as 0000000011001000, then the first 'A' 01000001
+	if want := []byte{0x40, 0x0C, 0x84, 0x14}; !bytes.Equal(got[:4], want) {
+		t.Fatalf("first codewords = % X, want % X", got[:4], want)
+	}
+	if len(got) != versions[10].dataCodewords() {
+		t.Fatalf("got %d codewords, want %d", len(got), versions[10].dataCodewords())
+	}
+}
+
+// Format information for level M and each mask, from the standard's table
+var formatBitsM = []string{
+	"101010000010010",
+	"101000100100101",
+	"101111001111100",
+	"101101101001011",
+	"100010111111001",
+	"100000011001110",
+	"100111110010111",
+	"100101010100000",
+}
+
+// readFormatBits reads both copies of the format information, least
+// significant bit first in the order the standard places them
+func readFormatBits(modules [][]bool) (first, second int) {
+	size := len(modules)
+	bit := func(dark bool, i int) int {
+		if dark {
+			return 1 << i
+		}
+		return 0
+	}
+	for i := 0; i <= 5; i++ {
+		first |= bit(modules[i][8], i)
+	}
+	first |= bit(modules[7][8], 6) | bit(modules[8][8], 7) | bit(modules[8][7], 8)
+	for i := 9; i < 15; i++ {
+		first |= bit(modules[8][14-i], i)
+	}
+	for i := 0; i < 8; i++ {
+		second |= bit(modules[8][size-1-i], i)
+	}
+	for i := 8; i < 15; i++ {
+		second |= bit(modules[size-15+i][8], i)
+	}
+	return first, second
+}
+
+func binary(value, width int) string {
+	var b strings.Builder
+	for i := width - 1; i >= 0; i-- {
+		b.WriteByte('0' + byte(value>>i&1))
+	}
+	return b.String()
+}
+
+func TestFormatBitsKnownAnswer(t *testing.T) {
+	for mask, want := range formatBitsM {
+		c := newCanvas(1)
+		c.drawFormatBits(mask)
+		first, second := readFormatBits(c.modules)
+		if got := binary(first, 15); got != want {
+			t.Errorf("mask %d: format bits %s, want %s", mask, got, want)
+		}
+		if second != first {
+			t.Errorf("mask %d: second copy %s differs from first %s", mask, binary(second, 15), binary(first, 15))
+		}
+	}
+}
+
+func TestVersionBitsKnownAnswer(t *testing.T) {
+	// Version information from the standard's table
+	tests := map[int]string{
+		7:  "000111110010010100",
+		8:  "001000010110111100",
+		9:  "001001101010011001",
+		10: "001010010011010011",
+	}
+	for version, want := range tests {
+		c := newCanvas(version)
+		c.drawVersionBits()
+		var bottomLeft, topRight int
+		for i := 0; i < 18; i++ {
+			a, b := c.size-11+i%3, i/3
+			if c.modules[a][b] {
+				bottomLeft |= 1 << i
+			}
+			if c.modules[b][a] {
+				topRight |= 1 << i
+			}
+		}
+		if got := binary(bottomLeft, 18); got != want {
+			t.Errorf("version %d: bottom-left block %s, want %s", version, got, want)
+		}
+		if topRight != bottomLeft {
+			t.Errorf("version %d: top-right block %s differs from bottom-left", version, binary(topRight, 18))
+		}
+	}
+}
+
+func TestEncodeChoosesSmallestVersion(t *testing.T) {
+	tests := []struct {
+		length  int
+		version int
+	}{
+		{0, 1},
+		{14, 1},
+		{15, 2},
+		{26, 2},
+		{27, 3},
+		{213, 10},
+	}
+	for _, test := range tests {
+		code, err := Encode(bytes.Repeat([]byte{'x'}, test.length))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", test.length, err)
+		}
+		if code.Version != test.version || code.Size != 17+4*test.version {
+			t.Errorf("%d bytes: version %d size %d, want version %d", test.length, code.Version, code.Size, test.version)
+		}
+	}
+
+	if _, err := Encode(bytes.Repeat([]byte{'x'}, 214)); !errors.Is(err, ErrTooLong) {
+		t.Fatalf("214 bytes: err = %v, want ErrTooLong", err)
+	}
+}
+
+// decode reads a symbol back: it finds the mask from the format bits, removes
+// it and collects the codewords along the zigzag
+func decode(t *testing.T, code *Code) []byte {
+	t.Helper()
+	first, _ := readFormatBits(code.Modules)
+	mask := -1
+	for m, bits := range formatBitsM {
+		if binary(first, 15) == bits {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("format bits %s are not level M", binary(first, 15))
+	}
+
+	c := newCanvas(code.Version)
+	c.drawFunctionPatterns()
+	for row := range c.modules {
+		copy(c.modules[row], code.Modules[row])
+	}
+	c.applyMask(mask)
+
+	var bits bitBuffer
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.size; vert++ {
+			row := vert
+			if upward {
+				row = c.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if !c.function[row][right-j] {
+					bits = append(bits, c.modules[row][right-j])
+				}
+			}
+		}
+	}
+	return bits.bytes()
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"HELLO WORLD",
+		"https://gbvmis.example.org/api/pf3/verify/ABCDEFGHJKMNPQRS",
+		strings.Repeat("verification link ", 11),
+	} {
+		code, err := Encode([]byte(data))
+		if err != nil {
+			t.Fatalf("%q: %v", data, err)
+		}
+		want := addErrorCorrection(encodeData([]byte(data), code.Version), versions[code.Version])
+		got := decode(t, code)
+		if !bytes.Equal(got[:len(want)], want) {
+			t.Errorf("%q: version %d codewords read back differ from those encoded", data, code.Version)
+		}
+	}
+}
+
+func TestEncodeFunctionPatterns(t *testing.T) {
+	code, err := Encode([]byte("https://gbvmis.example.org/api/pf3/verify/ABCDEFGHJKMNPQRS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A finder pattern is a dark 7x7 ring, a light ring and a dark 3x3 centre
+	finder := []string{
+		"#######",
+		"#.....#",
+		"#.###.#",
+		"#.###.#",
+		"#.###.#",
+		"#.....#",
+		"#######",
+	}
+	for _, corner := range [][2]int{{0, 0}, {0, code.Size - 7}, {code.Size - 7, 0}} {
+		for dr, line := range finder {
+			for dc, want := range line {
+				if code.Dark(corner[0]+dr, corner[1]+dc) != (want == '#') {
+					t.Fatalf("finder at %v wrong at +%d,+%d", corner, dr, dc)
+				}
+			}
+		}
+	}
+	for i := 8; i < code.Size-8; i++ {
+		if code.Dark(6, i) != (i%2 == 0) || code.Dark(i, 6) != (i%2 == 0) {
+			t.Fatalf("timing pattern wrong at %d", i)
+		}
+	}
+	if !code.Dark(code.Size-8, 8) {
+		t.Fatal("dark module is light")
+	}
+}
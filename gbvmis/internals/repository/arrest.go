@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"gbvmis/internals/models"
 	"gbvmis/internals/utils"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,6 +18,10 @@ type ArrestRepository interface {
 	GetArrestByID(id string) (models.Arrest, error)
 	DeleteByID(id string) error
 	SearchPaginatedArrests(c *fiber.Ctx) (*utils.Pagination, []models.Arrest, error)
+	FindOfficerByID(id uint) (models.PoliceOfficer, error)
+	FindCaseByID(id uint) (models.Case, error)
+	FindSuspectByID(id uint) (models.Suspect, error)
+	SuspectInCase(suspectID, caseID uint) (bool, error)
 }
 
 type ArrestRepositoryImpl struct {
@@ -34,7 +39,10 @@ func (r *ArrestRepositoryImpl) CreateArrest(arrest *models.Arrest) error {
 }
 
 func (r *ArrestRepositoryImpl) GetPaginatedArrests(c *fiber.Ctx) (*utils.Pagination, []models.Arrest, error) {
-	pagination, arrests, err := utils.Paginate(c, r.db, models.Arrest{})
+	pagination, arrests, err := utils.Paginate(c, r.db.
+		Preload("Officer").
+		Preload("Case").
+		Preload("Custody"), models.Arrest{})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -43,7 +51,10 @@ func (r *ArrestRepositoryImpl) GetPaginatedArrests(c *fiber.Ctx) (*utils.Paginat
 
 func (r *ArrestRepositoryImpl) GetArrestByID(id string) (models.Arrest, error) {
 	var arrest models.Arrest
-	err := r.db.First(&arrest, "id = ?", id).Error
+	err := r.db.
+		Preload("Officer").
+		Preload("Case").
+		Preload("Custody").First(&arrest, "id = ?", id).Error
 	return arrest, err
 }
 
@@ -65,9 +76,15 @@ func (r *ArrestRepositoryImpl) SearchPaginatedArrests(c *fiber.Ctx) (*utils.Pagi
 	officerName := c.Query("officer_name")
 	minArrestDate := c.Query("min_arrest_date")
 	maxArrestDate := c.Query("max_arrest_date")
+	officerID := c.Query("officer_id")
+	caseID := c.Query("case_id")
+	suspectID := c.Query("suspect_id")
 
 	// Start building the query
-	query := r.db.Model(&models.Arrest{})
+	query := r.db.
+		Preload("Officer").
+		Preload("Case").
+		Preload("Custody").Model(&models.Arrest{})
 
 	// Apply filters based on provided parameters
 	if loaction != "" {
@@ -76,6 +93,21 @@ func (r *ArrestRepositoryImpl) SearchPaginatedArrests(c *fiber.Ctx) (*utils.Pagi
 	if officerName != "" {
 		query = query.Where("officer_name ILIKE ?", "%"+officerName+"%")
 	}
+	if officerID != "" {
+		if _, err := strconv.Atoi(officerID); err == nil {
+			query = query.Where("officer_id = ?", officerID)
+		}
+	}
+	if caseID != "" {
+		if _, err := strconv.Atoi(caseID); err == nil {
+			query = query.Where("case_id = ?", caseID)
+		}
+	}
+	if suspectID != "" {
+		if _, err := strconv.Atoi(suspectID); err == nil {
+			query = query.Where("suspect_id = ?", suspectID)
+		}
+	}
 
 	if minArrestDate != "" {
 		parsedMinArrestDate, err := time.Parse("2006-01-02", minArrestDate)
@@ -103,3 +135,29 @@ func (r *ArrestRepositoryImpl) SearchPaginatedArrests(c *fiber.Ctx) (*utils.Pagi
 
 	return &pagination, arrests, nil
 }
+
+func (r *ArrestRepositoryImpl) FindOfficerByID(id uint) (models.PoliceOfficer, error) {
+	var officer models.PoliceOfficer
+	err := r.db.First(&officer, "id = ?", id).Error
+	return officer, err
+}
+
+func (r *ArrestRepositoryImpl) FindCaseByID(id uint) (models.Case, error) {
+	var casee models.Case
+	err := r.db.First(&casee, "id = ?", id).Error
+	return casee, err
+}
+
+func (r *ArrestRepositoryImpl) FindSuspectByID(id uint) (models.Suspect, error) {
+	var suspect models.Suspect
+	err := r.db.First(&suspect, "id = ?", id).Error
+	return suspect, err
+}
+
+// SuspectInCase reports whether the suspect is linked to the case
+func (r *ArrestRepositoryImpl) SuspectInCase(suspectID, caseID uint) (bool, error) {
+	var count int64
+	err := r.db.Table("case_suspects").Where("case_id = ? AND suspect_id = ?", caseID, suspectID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"fmt"
+	"gbvmis/internals/models"
+
+	"gorm.io/gorm"
+)
+
+// OwnerScope describes who is responsible for a parent record, which decides
+// who may see the attachments hanging off it
+type OwnerScope struct {
+	OfficerID    uint
+	PolicePostID uint
+}
+
+type AttachmentRepository interface {
+	CreateAttachment(attachment *models.Attachment, blob *models.FileBlob) error
+	GetAttachmentByID(id string) (models.Attachment, error)
+	ListAttachments(ownerType string, ownerID uint) ([]models.Attachment, error)
+	DeleteAttachment(attachment *models.Attachment) error
+	ResolveOwnerScope(ownerType string, ownerID uint) (OwnerScope, error)
+	FindOfficerByID(id uint) (models.PoliceOfficer, error)
+}
+
+type AttachmentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func AttachmentDbService(db *gorm.DB) AttachmentRepository {
+	return &AttachmentRepositoryImpl{db: db}
+}
+
+// =================================
+
+// CreateAttachment records the blob (once per hash) and the attachment pointing at it
+func (r *AttachmentRepositoryImpl) CreateAttachment(attachment *models.Attachment, blob *models.FileBlob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveBlobRecord(tx, blob); err != nil {
+			return err
+		}
+		return tx.Omit("Blob", "UploadedBy").Create(attachment).Error
+	})
+}
+
+func (r *AttachmentRepositoryImpl) GetAttachmentByID(id string) (models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.Preload("Blob").First(&attachment, "id = ?", id).Error
+	return attachment, err
+}
+
+func (r *AttachmentRepositoryImpl) ListAttachments(ownerType string, ownerID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.Preload("Blob").
+		Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).
+		Order("created_at DESC").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+// DeleteAttachment removes the attachment and, when nothing else uses the
+// same content, marks the blob orphaned for collection
+func (r *AttachmentRepositoryImpl) DeleteAttachment(attachment *models.Attachment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Attachment{}, attachment.ID).Error; err != nil {
+			return err
+		}
+		return releaseBlob(tx, attachment.BlobSHA256)
+	})
+}
+
+// ResolveOwnerScope loads the parent record and works out the officer and
+// police post it belongs to. Examinations and exhibits follow their case.
+func (r *AttachmentRepositoryImpl) ResolveOwnerScope(ownerType string, ownerID uint) (OwnerScope, error) {
+	var scope OwnerScope
+	switch ownerType {
+	case models.AttachmentOwnerCase:
+		var casee models.Case
+		if err := r.db.First(&casee, "id = ?", ownerID).Error; err != nil {
+			return scope, err
+		}
+		return OwnerScope{OfficerID: casee.OfficerID, PolicePostID: casee.PolicePostID}, nil
+	case models.AttachmentOwnerExamination:
+		var exam models.Examination
+		if err := r.db.First(&exam, "id = ?", ownerID).Error; err != nil {
+			return scope, err
+		}
+		return r.ResolveOwnerScope(models.AttachmentOwnerCase, exam.CaseID)
+	case models.AttachmentOwnerExhibit:
+		var exhibit models.Exhibit
+		if err := r.db.First(&exhibit, "id = ?", ownerID).Error; err != nil {
+			return scope, err
+		}
+		return r.ResolveOwnerScope(models.AttachmentOwnerCase, exhibit.CaseID)
+	case models.AttachmentOwnerToxicologyReport:
+		var report models.ToxicologyForensicReport
+		if err := r.db.Preload("PoliceReport.Officer").First(&report, "id = ?", ownerID).Error; err != nil {
+			return scope, err
+		}
+		return OwnerScope{
+			OfficerID:    report.PoliceReport.OfficerID,
+			PolicePostID: report.PoliceReport.Officer.PostID,
+		}, nil
+	default:
+		return scope, fmt.Errorf("unknown attachment owner type %q", ownerType)
+	}
+}
+
+func (r *AttachmentRepositoryImpl) FindOfficerByID(id uint) (models.PoliceOfficer, error) {
+	var officer models.PoliceOfficer
+	err := r.db.First(&officer, "id = ?", id).Error
+	return officer, err
+}
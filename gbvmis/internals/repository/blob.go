@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gbvmis/internals/models"
+	"gbvmis/internals/storage"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BlobRepository interface {
+	FindOrphanedBlobs(before time.Time, limit int) ([]string, error)
+	PurgeBlob(sha string, before time.Time, remove func() error) (bool, error)
+}
+
+type BlobRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func BlobDbService(db *gorm.DB) BlobRepository {
+	return &BlobRepositoryImpl{db: db}
+}
+
+// =================================
+
+// blobReferences counts the attachments, suspect media and generated reports
+// and PF3 forms using the content
+func blobReferences(tx *gorm.DB, sha string) (int64, error) {
+	var attachments, media, reports, forms int64
+	if err := tx.Model(&models.Attachment{}).
+		Where("blob_sha256 = ?", sha).
+		Count(&attachments).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.SuspectMedia{}).
+		Where("blob_sha256 = ? OR thumbnail_sha256 = ?", sha, sha).
+		Count(&media).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.GeneratedReport{}).
+		Where("blob_sha256 = ?", sha).
+		Count(&reports).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.Pf3Document{}).
+		Where("blob_sha256 = ?", sha).
+		Count(&forms).Error; err != nil {
+		return 0, err
+	}
+	return attachments + media + reports + forms, nil
+}
+
+// saveBlobRecord records the blob once per hash, taking it back into use if
+// it had been orphaned
+func saveBlobRecord(tx *gorm.DB, blob *models.FileBlob) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sha256"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"orphaned_at": nil}),
+	}).Create(blob).Error
+}
+
+// releaseBlob marks the blob orphaned once no attachment, suspect media or
+// generated report or PF3 form references it. The content stays until the
+// collector removes it after the grace period.
+func releaseBlob(tx *gorm.DB, sha string) error {
+	if sha == "" {
+		return nil
+	}
+	references, err := blobReferences(tx, sha)
+	if err != nil || references > 0 {
+		return err
+	}
+	return tx.Model(&models.FileBlob{}).Where("sha256 = ?", sha).Update("orphaned_at", time.Now()).Error
+}
+
+// FindOrphanedBlobs returns the hashes of blobs orphaned before the given time
+func (r *BlobRepositoryImpl) FindOrphanedBlobs(before time.Time, limit int) ([]string, error) {
+	var hashes []string
+	err := r.db.Model(&models.FileBlob{}).
+		Where("orphaned_at < ?", before).
+		Order("orphaned_at").
+		Limit(limit).
+		Pluck("sha256", &hashes).Error
+	return hashes, err
+}
+
+// PurgeBlob removes a blob orphaned before the given time. The record is
+// locked while remove deletes the content, so an upload of the same content
+// waits and then finds it gone. It reports false when the blob was taken back
+// into use meanwhile.
+func (r *BlobRepositoryImpl) PurgeBlob(sha string, before time.Time, remove func() error) (bool, error) {
+	purged := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var blob models.FileBlob
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("sha256 = ? AND orphaned_at < ?", sha, before).
+			Take(&blob).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		references, err := blobReferences(tx, sha)
+		if err != nil {
+			return err
+		}
+		if references > 0 {
+			return tx.Model(&models.FileBlob{}).Where("sha256 = ?", sha).Update("orphaned_at", nil).Error
+		}
+
+		if err := remove(); err != nil {
+			return err
+		}
+		purged = true
+		return tx.Delete(&models.FileBlob{}, "sha256 = ?", sha).Error
+	})
+	return purged, err
+}
+
+// trackedBlobStore restarts the grace period of an orphaned blob whenever an
+// upload checks for its content, so the collector cannot remove the content
+// between that check and the upload's record being saved
+type trackedBlobStore struct {
+	storage.BlobStore
+	db *gorm.DB
+}
+
+// TrackedBlobStore wraps the store used for uploads. The content check in
+// StagedBlob.Save then waits for a collection of the same blob in progress
+// and keeps it from being collected until the upload is recorded.
+func TrackedBlobStore(db *gorm.DB, store storage.BlobStore) storage.BlobStore {
+	return &trackedBlobStore{BlobStore: store, db: db}
+}
+
+func (s *trackedBlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	if err := s.db.WithContext(ctx).Model(&models.FileBlob{}).
+		Where("sha256 = ? AND orphaned_at IS NOT NULL", key).
+		Update("orphaned_at", time.Now()).Error; err != nil {
+		return false, err
+	}
+	return s.BlobStore.Exists(ctx, key)
+}
@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type BondRepository interface {
+	CreateBond(bond *models.PoliceBond) error
+	GetPaginatedBonds(c *fiber.Ctx) (*utils.Pagination, []models.PoliceBond, error)
+	UpdateBond(id string, updates map[string]interface{}) error
+	GetBondByID(id string) (models.PoliceBond, error)
+	DeleteByID(id string) error
+	SearchPaginatedBonds(c *fiber.Ctx) (*utils.Pagination, []models.PoliceBond, error)
+	FindSuspectByID(id uint) (models.Suspect, error)
+	FindCustodyRecordByID(id uint) (models.CustodyRecord, error)
+	SuspectInCase(suspectID, caseID uint) (bool, error)
+	CancelBond(bond *models.PoliceBond, reason string, at time.Time) error
+	RecordCheckIn(bondID uint, reportedAt time.Time, receivedBy string, notes string) (models.BondReportingDate, error)
+	FindReportingsDue(from, to time.Time) ([]models.BondReportingDate, error)
+	FindMissedReportings(before time.Time) ([]models.BondReportingDate, error)
+	ExpireBonds(now time.Time) (int64, error)
+}
+
+type BondRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func BondDbService(db *gorm.DB) BondRepository {
+	return &BondRepositoryImpl{db: db}
+}
+
+// =================================
+
+// CreateBond saves the bond with its sureties and reporting dates, marks the
+// suspect as on police bond and, if the bond came out of the custody register,
+// closes that custody record.
+func (r *BondRepositoryImpl) CreateBond(bond *models.PoliceBond) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(bond).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Suspect{}).Where("id = ?", bond.SuspectID).
+			Update("status", models.SuspectStatusOnBond).Error; err != nil {
+			return err
+		}
+
+		if bond.CustodyRecordID != nil {
+			if err := tx.Model(&models.CustodyRecord{}).
+				Where("id = ? AND status = ?", *bond.CustodyRecordID, models.CustodyStatusInCustody).
+				Updates(map[string]interface{}{
+					"status":      models.CustodyStatusBond,
+					"released_at": bond.GrantedAt,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *BondRepositoryImpl) GetPaginatedBonds(c *fiber.Ctx) (*utils.Pagination, []models.PoliceBond, error) {
+	pagination, bonds, err := utils.Paginate(c, r.db.
+		Preload("Suspect").
+		Preload("Case").
+		Preload("Sureties").
+		Preload("ReportingDates"), models.PoliceBond{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, bonds, nil
+}
+
+func (r *BondRepositoryImpl) GetBondByID(id string) (models.PoliceBond, error) {
+	var bond models.PoliceBond
+	err := r.db.
+		Preload("Suspect").
+		Preload("Case").
+		Preload("Sureties").
+		Preload("ReportingDates", func(db *gorm.DB) *gorm.DB {
+			return db.Order("due_date ASC")
+		}).First(&bond, "id = ?", id).Error
+	return bond, err
+}
+
+func (r *BondRepositoryImpl) UpdateBond(id string, updates map[string]interface{}) error {
+	return r.db.Model(&models.PoliceBond{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteByID deletes a bond by ID and brings the suspect's bond status in
+// line with the bonds that remain
+func (r *BondRepositoryImpl) DeleteByID(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var bond models.PoliceBond
+		if err := tx.First(&bond, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&bond).Error; err != nil {
+			return err
+		}
+		return refreshSuspectBondStatus(tx, bond.SuspectID)
+	})
+}
+
+// refreshSuspectBondStatus sets a suspect's status from their bonds: on bond
+// while any is active, otherwise the outcome of the latest one, and blank
+// when none is left. A status set by hand, outside the bond workflow, is
+// left alone.
+func refreshSuspectBondStatus(tx *gorm.DB, suspectID uint) error {
+	var bonds []models.PoliceBond
+	if err := tx.Where("suspect_id = ?", suspectID).
+		Order("granted_at DESC, id DESC").Find(&bonds).Error; err != nil {
+		return err
+	}
+
+	status := ""
+	for i, bond := range bonds {
+		if bond.Status == models.BondStatusActive {
+			status = models.SuspectStatusOnBond
+			break
+		}
+		if i == 0 {
+			switch bond.Status {
+			case models.BondStatusCancelled:
+				status = models.SuspectStatusBondCancelled
+			case models.BondStatusExpired:
+				status = models.SuspectStatusBondExpired
+			}
+		}
+	}
+
+	return tx.Model(&models.Suspect{}).
+		Where("id = ? AND status IN ?", suspectID, []string{
+			models.SuspectStatusOnBond, models.SuspectStatusBondCancelled, models.SuspectStatusBondExpired,
+		}).
+		Update("status", status).Error
+}
+
+func (r *BondRepositoryImpl) SearchPaginatedBonds(c *fiber.Ctx) (*utils.Pagination, []models.PoliceBond, error) {
+	// Get query parameters from request
+	status := c.Query("status")
+	suspectID := c.Query("suspect_id")
+	caseID := c.Query("case_id")
+	expiresBefore := c.Query("expires_before")
+
+	// Start building the query
+	query := r.db.
+		Preload("Suspect").
+		Preload("Case").
+		Preload("Sureties").
+		Preload("ReportingDates").Model(&models.PoliceBond{})
+
+	// Apply filters based on provided parameters
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if suspectID != "" {
+		if _, err := strconv.Atoi(suspectID); err == nil {
+			query = query.Where("suspect_id = ?", suspectID)
+		}
+	}
+	if caseID != "" {
+		if _, err := strconv.Atoi(caseID); err == nil {
+			query = query.Where("case_id = ?", caseID)
+		}
+	}
+	if expiresBefore != "" {
+		if parsed, err := utils.ParseDate(expiresBefore); err == nil {
+			query = query.Where("expires_at < ?", parsed)
+		}
+	}
+
+	// Call the pagination helper
+	pagination, bonds, err := utils.Paginate(c, query, models.PoliceBond{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &pagination, bonds, nil
+}
+
+func (r *BondRepositoryImpl) FindSuspectByID(id uint) (models.Suspect, error) {
+	var suspect models.Suspect
+	err := r.db.First(&suspect, "id = ?", id).Error
+	return suspect, err
+}
+
+func (r *BondRepositoryImpl) FindCustodyRecordByID(id uint) (models.CustodyRecord, error) {
+	var record models.CustodyRecord
+	err := r.db.First(&record, "id = ?", id).Error
+	return record, err
+}
+
+// SuspectInCase reports whether the suspect is linked to the case
+func (r *BondRepositoryImpl) SuspectInCase(suspectID, caseID uint) (bool, error) {
+	var count int64
+	err := r.db.Table("case_suspects").Where("case_id = ? AND suspect_id = ?", caseID, suspectID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CancelBond marks the bond cancelled and flags the suspect accordingly,
+// unless they hold another bond that is still active
+func (r *BondRepositoryImpl) CancelBond(bond *models.PoliceBond, reason string, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(bond).Updates(map[string]interface{}{
+			"status":        models.BondStatusCancelled,
+			"cancelled_at":  at,
+			"cancel_reason": reason,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Suspect{}).
+			Where("id = ?", bond.SuspectID).
+			Where("NOT EXISTS (SELECT 1 FROM police_bonds pb WHERE pb.suspect_id = suspects.id AND pb.status = ? AND pb.deleted_at IS NULL)", models.BondStatusActive).
+			Update("status", models.SuspectStatusBondCancelled).Error
+	})
+}
+
+// RecordCheckIn marks the earliest outstanding reporting date of a bond as
+// attended. If none is outstanding the visit is recorded as an extra check-in.
+func (r *BondRepositoryImpl) RecordCheckIn(bondID uint, reportedAt time.Time, receivedBy string, notes string) (models.BondReportingDate, error) {
+	var reporting models.BondReportingDate
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("bond_id = ? AND reported_at IS NULL", bondID).
+			Order("due_date ASC").First(&reporting).Error
+		if err == gorm.ErrRecordNotFound {
+			reporting = models.BondReportingDate{BondID: bondID, DueDate: reportedAt}
+		} else if err != nil {
+			return err
+		}
+
+		reporting.ReportedAt = &reportedAt
+		reporting.ReceivedBy = receivedBy
+		reporting.Notes = notes
+		return tx.Save(&reporting).Error
+	})
+	return reporting, err
+}
+
+// FindReportingsDue returns outstanding reporting dates of active bonds in [from, to]
+func (r *BondRepositoryImpl) FindReportingsDue(from, to time.Time) ([]models.BondReportingDate, error) {
+	var reportings []models.BondReportingDate
+	err := r.db.
+		Preload("Bond.Suspect").
+		Preload("Bond.Case").
+		Joins("JOIN police_bonds ON police_bonds.id = bond_reporting_dates.bond_id AND police_bonds.deleted_at IS NULL").
+		Where("police_bonds.status = ?", models.BondStatusActive).
+		Where("bond_reporting_dates.reported_at IS NULL").
+		Where("bond_reporting_dates.due_date BETWEEN ? AND ?", from, to).
+		Order("bond_reporting_dates.due_date ASC").
+		Find(&reportings).Error
+	return reportings, err
+}
+
+// FindMissedReportings returns reporting dates of active bonds that passed without the suspect reporting
+func (r *BondRepositoryImpl) FindMissedReportings(before time.Time) ([]models.BondReportingDate, error) {
+	var reportings []models.BondReportingDate
+	err := r.db.
+		Preload("Bond.Suspect").
+		Preload("Bond.Case").
+		Joins("JOIN police_bonds ON police_bonds.id = bond_reporting_dates.bond_id AND police_bonds.deleted_at IS NULL").
+		Where("police_bonds.status = ?", models.BondStatusActive).
+		Where("bond_reporting_dates.reported_at IS NULL").
+		Where("bond_reporting_dates.due_date < ?", before).
+		Order("bond_reporting_dates.due_date ASC").
+		Find(&reportings).Error
+	return reportings, err
+}
+
+// ExpireBonds closes active bonds past their expiry date and updates the suspects' status
+func (r *BondRepositoryImpl) ExpireBonds(now time.Time) (int64, error) {
+	var expired int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var bonds []models.PoliceBond
+		if err := tx.Where("status = ? AND expires_at < ?", models.BondStatusActive, now).
+			Find(&bonds).Error; err != nil {
+			return err
+		}
+		if len(bonds) == 0 {
+			return nil
+		}
+
+		bondIDs := make([]uint, len(bonds))
+		suspectIDs := make([]uint, len(bonds))
+		for i, b := range bonds {
+			bondIDs[i] = b.ID
+			suspectIDs[i] = b.SuspectID
+		}
+
+		result := tx.Model(&models.PoliceBond{}).Where("id IN ?", bondIDs).
+			Update("status", models.BondStatusExpired)
+		if result.Error != nil {
+			return result.Error
+		}
+		expired = result.RowsAffected
+
+		// Leave suspects alone if they hold another, still active bond
+		return tx.Model(&models.Suspect{}).
+			Where("id IN ?", suspectIDs).
+			Where("NOT EXISTS (SELECT 1 FROM police_bonds pb WHERE pb.suspect_id = suspects.id AND pb.status = ? AND pb.deleted_at IS NULL)", models.BondStatusActive).
+			Update("status", models.SuspectStatusBondExpired).Error
+	})
+	return expired, err
+}
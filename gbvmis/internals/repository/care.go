@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CareRepository interface {
+	GetExaminationByID(id string) (models.Examination, error)
+	GetCareIncident(examination models.Examination) (*models.Incident, error)
+	GetCareRecords(examinationID uint) ([]models.CareRecord, error)
+	SaveCareRecord(record *models.CareRecord) error
+	GetPendingCare(service string, since time.Time, facilityID *uint) ([]models.PendingCare, error)
+}
+
+type CareRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func CareDbService(db *gorm.DB) CareRepository {
+	return &CareRepositoryImpl{db: db}
+}
+
+// =================================
+
+func (r *CareRepositoryImpl) GetExaminationByID(id string) (models.Examination, error) {
+	var examination models.Examination
+	err := r.db.First(&examination, "id = ?", id).Error
+	return examination, err
+}
+
+// GetCareIncident returns the latest sexual assault recorded in the
+// examination's case up to the day of the examination, or nil when there is
+// none
+func (r *CareRepositoryImpl) GetCareIncident(examination models.Examination) (*models.Incident, error) {
+	var incidents []models.Incident
+	err := r.db.
+		Where("case_id = ? AND violence_type = ?", examination.CaseID, models.ViolenceSexual).
+		Where("occurred_at < (SELECT exam_date + 1 FROM examinations WHERE id = ?)", examination.ID).
+		Order("occurred_at DESC, id DESC").Limit(1).
+		Find(&incidents).Error
+	if err != nil || len(incidents) == 0 {
+		return nil, err
+	}
+	return &incidents[0], nil
+}
+
+func (r *CareRepositoryImpl) GetCareRecords(examinationID uint) ([]models.CareRecord, error) {
+	var records []models.CareRecord
+	err := r.db.Where("examination_id = ?", examinationID).Find(&records).Error
+	return records, err
+}
+
+// SaveCareRecord records a service on the checklist, replacing what was
+// recorded for it before
+func (r *CareRepositoryImpl) SaveCareRecord(record *models.CareRecord) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.CareRecord
+		err := tx.Where("examination_id = ? AND service = ?", record.ExaminationID, record.Service).First(&existing).Error
+		switch {
+		case err == nil:
+			record.ID = existing.ID
+			record.CreatedAt = existing.CreatedAt
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		return tx.Save(record).Error
+	})
+}
+
+// GetPendingCare lists the examinations following a sexual assault since the
+// given time where the service has not been provided, declined or ruled out,
+// earliest assault first
+func (r *CareRepositoryImpl) GetPendingCare(service string, since time.Time, facilityID *uint) ([]models.PendingCare, error) {
+	query := r.db.Table("examinations").
+		Select(`examinations.id AS examination_id, examinations.facility_id, health_facilities.name AS facility,
+			examinations.case_id, COALESCE(cases.case_number, '') AS case_number, examinations.victim_id,
+			to_char(examinations.exam_date, 'YYYY-MM-DD') AS exam_date, ? AS service,
+			COALESCE(care_records.status, ?) AS status,
+			incident.occurred_at AS incident_occurred_at, incident.time_known AS incident_time_known`,
+			service, models.CareStatusPending).
+		Joins(`JOIN LATERAL (SELECT incidents.occurred_at, incidents.time_known FROM incidents
+			WHERE incidents.case_id = examinations.case_id AND incidents.deleted_at IS NULL
+			AND incidents.violence_type = ? AND incidents.occurred_at < examinations.exam_date + 1
+			ORDER BY incidents.occurred_at DESC, incidents.id DESC LIMIT 1) AS incident ON true`, models.ViolenceSexual).
+		Joins("JOIN health_facilities ON health_facilities.id = examinations.facility_id").
+		Joins("LEFT JOIN cases ON cases.id = examinations.case_id").
+		Joins(`LEFT JOIN care_records ON care_records.examination_id = examinations.id
+			AND care_records.service = ? AND care_records.deleted_at IS NULL`, service).
+		Where("examinations.deleted_at IS NULL AND incident.occurred_at > ?", since).
+		Where("(care_records.status IS NULL OR care_records.status NOT IN ?)", models.CareClosedStatuses).
+		Order("incident.occurred_at, examinations.id")
+	if facilityID != nil {
+		query = query.Where("examinations.facility_id = ?", *facilityID)
+	}
+
+	var pending []models.PendingCare
+	err := query.Scan(&pending).Error
+	return pending, err
+}
@@ -20,6 +20,13 @@ type CaseRepository interface {
 	FindChargesByIDs(ids []uint, charges *[]models.Charge) error
 	FindSuspectsByIDs(ids []uint, suspects *[]models.Suspect) error
 	BeginTransaction() *gorm.DB
+	CaseHasParties(caseID, victimID, suspectID uint) (bool, error)
+	CreateCaseRelationship(relationship *models.CaseRelationship) error
+	GetCaseRelationshipByID(id string) (models.CaseRelationship, error)
+	UpdateCaseRelationship(id uint, updates map[string]interface{}) error
+	DeleteCaseRelationship(id uint) error
+	PruneCaseRelationships(tx *gorm.DB, caseID uint) error
+	GetRelationshipStats(c *fiber.Ctx) ([]models.RelationshipStat, error)
 }
 
 type CaseRepositoryImpl struct {
@@ -30,6 +37,19 @@ func CaseDbService(db *gorm.DB) CaseRepository {
 	return &CaseRepositoryImpl{db: db}
 }
 
+// withCaseRelations preloads everything a CaseResponse shows
+func withCaseRelations(db *gorm.DB) *gorm.DB {
+	return db.Preload("Charges").
+		Preload("Victims").
+		Preload("Suspects", omitLegacyMedia).
+		Preload("Relationships").
+		Preload("Incidents", orderedIncidents)
+}
+
+func orderedIncidents(db *gorm.DB) *gorm.DB {
+	return db.Order("occurred_at, id")
+}
+
 // =================================
 
 func (r *CaseRepositoryImpl) CreateCase(casee *models.Case) error {
@@ -37,9 +57,7 @@ func (r *CaseRepositoryImpl) CreateCase(casee *models.Case) error {
 }
 
 func (r *CaseRepositoryImpl) GetPaginatedCases(c *fiber.Ctx) (*utils.Pagination, []models.Case, error) {
-	pagination, cases, err := utils.Paginate(c, r.db.Preload("Charges").
-		Preload("Victims").
-		Preload("Suspects"), models.Case{})
+	pagination, cases, err := utils.Paginate(c, withCaseRelations(r.db), models.Case{})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -48,9 +66,7 @@ func (r *CaseRepositoryImpl) GetPaginatedCases(c *fiber.Ctx) (*utils.Pagination,
 
 func (r *CaseRepositoryImpl) GetCaseByID(id string) (models.Case, error) {
 	var casee models.Case
-	err := r.db.Preload("Charges").
-		Preload("Victims").
-		Preload("Suspects").First(&casee, "id = ?", id).Error
+	err := withCaseRelations(r.db).First(&casee, "id = ?", id).Error
 	return casee, err
 }
 
@@ -74,9 +90,7 @@ func (r *CaseRepositoryImpl) SearchPaginatedCases(c *fiber.Ctx) (*utils.Paginati
 	PolicePostID := c.Query("police_post_id")
 
 	// Start building the query
-	query := r.db.Preload("Charges").
-		Preload("Victims").
-		Preload("Suspects").Model(&models.Charge{})
+	query := withCaseRelations(r.db).Model(&models.Charge{})
 
 	// Apply filters based on provided parameters
 	if CaseNumber != "" {
@@ -93,6 +107,9 @@ func (r *CaseRepositoryImpl) SearchPaginatedCases(c *fiber.Ctx) (*utils.Paginati
 			query = query.Where("police_post_id = ?", PolicePostID)
 		}
 	}
+	if hasIncidentFilters(c) {
+		query = query.Where("cases.id IN (?)", filterIncidents(r.db.Model(&models.Incident{}).Select("incidents.case_id"), c))
+	}
 
 	// Call the pagination helper
 	pagination, cases, err := utils.Paginate(c, query, models.Case{})
@@ -118,3 +135,72 @@ func (r *CaseRepositoryImpl) FindSuspectsByIDs(ids []uint, suspects *[]models.Su
 func (r *CaseRepositoryImpl) BeginTransaction() *gorm.DB {
 	return r.db.Begin()
 }
+
+// CaseHasParties reports whether both the victim and the suspect are linked to the case
+func (r *CaseRepositoryImpl) CaseHasParties(caseID, victimID, suspectID uint) (bool, error) {
+	var victims, suspects int64
+	if err := r.db.Table("case_victims").Where("case_id = ? AND victim_id = ?", caseID, victimID).
+		Count(&victims).Error; err != nil {
+		return false, err
+	}
+	if err := r.db.Table("case_suspects").Where("case_id = ? AND suspect_id = ?", caseID, suspectID).
+		Count(&suspects).Error; err != nil {
+		return false, err
+	}
+	return victims > 0 && suspects > 0, nil
+}
+
+func (r *CaseRepositoryImpl) CreateCaseRelationship(relationship *models.CaseRelationship) error {
+	return r.db.Omit("Victim", "Suspect").Create(relationship).Error
+}
+
+func (r *CaseRepositoryImpl) GetCaseRelationshipByID(id string) (models.CaseRelationship, error) {
+	var relationship models.CaseRelationship
+	err := r.db.Preload("Victim").Preload("Suspect", omitLegacyMedia).
+		First(&relationship, "id = ?", id).Error
+	return relationship, err
+}
+
+func (r *CaseRepositoryImpl) UpdateCaseRelationship(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.CaseRelationship{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteCaseRelationship removes the row outright so the pair can be recorded again
+func (r *CaseRepositoryImpl) DeleteCaseRelationship(id uint) error {
+	return r.db.Unscoped().Delete(&models.CaseRelationship{}, id).Error
+}
+
+// PruneCaseRelationships drops relationships whose victim or suspect is no
+// longer linked to the case, after the case's parties have been replaced
+func (r *CaseRepositoryImpl) PruneCaseRelationships(tx *gorm.DB, caseID uint) error {
+	return tx.Unscoped().Where("case_id = ?", caseID).
+		Where(`NOT EXISTS (SELECT 1 FROM case_victims cv WHERE cv.case_id = case_relationships.case_id AND cv.victim_id = case_relationships.victim_id)
+			OR NOT EXISTS (SELECT 1 FROM case_suspects cs WHERE cs.case_id = case_relationships.case_id AND cs.suspect_id = case_relationships.suspect_id)`).
+		Delete(&models.CaseRelationship{}).Error
+}
+
+// GetRelationshipStats counts victim-suspect pairs by relationship type,
+// optionally limited to one police post and a date_opened range
+func (r *CaseRepositoryImpl) GetRelationshipStats(c *fiber.Ctx) ([]models.RelationshipStat, error) {
+	filter, err := StatsFilterFromQuery(c)
+	if err != nil {
+		return nil, err
+	}
+	return relationshipStats(r.db, filter)
+}
+
+func relationshipStats(db *gorm.DB, filter models.StatsFilter) ([]models.RelationshipStat, error) {
+	query := db.Model(&models.CaseRelationship{}).
+		Select(`case_relationships.relationship_type,
+			COUNT(*) AS pairs,
+			COUNT(*) FILTER (WHERE case_relationships.cohabiting) AS cohabiting,
+			COUNT(*) FILTER (WHERE case_relationships.dependency NOT IN ('', ?)) AS dependent`, models.DependencyNone).
+		Joins("JOIN cases ON cases.id = case_relationships.case_id AND cases.deleted_at IS NULL").
+		Group("case_relationships.relationship_type").
+		Order("pairs DESC")
+	query = filterStatsCases(query, filter)
+
+	var stats []models.RelationshipStat
+	err := query.Scan(&stats).Error
+	return stats, err
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type CustodyRepository interface {
+	CreateCustodyRecord(record *models.CustodyRecord) error
+	GetPaginatedCustodyRecords(c *fiber.Ctx) (*utils.Pagination, []models.CustodyRecord, error)
+	UpdateCustodyRecord(id string, updates map[string]interface{}) error
+	GetCustodyRecordByID(id string) (models.CustodyRecord, error)
+	DeleteByID(id string) error
+	SearchPaginatedCustodyRecords(c *fiber.Ctx) (*utils.Pagination, []models.CustodyRecord, error)
+	FindArrestByID(id uint) (models.Arrest, error)
+	CountActiveForArrest(arrestID uint) (int64, error)
+	FindUnproducedDetainedSince(since time.Time) ([]models.CustodyRecord, error)
+}
+
+type CustodyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func CustodyDbService(db *gorm.DB) CustodyRepository {
+	return &CustodyRepositoryImpl{db: db}
+}
+
+// =================================
+
+func (r *CustodyRepositoryImpl) CreateCustodyRecord(record *models.CustodyRecord) error {
+	return r.db.Create(record).Error
+}
+
+func (r *CustodyRepositoryImpl) GetPaginatedCustodyRecords(c *fiber.Ctx) (*utils.Pagination, []models.CustodyRecord, error) {
+	pagination, records, err := utils.Paginate(c, r.db.
+		Preload("Suspect").
+		Preload("Case").
+		Preload("PolicePost").Order("detention_start DESC"), models.CustodyRecord{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, records, nil
+}
+
+func (r *CustodyRepositoryImpl) GetCustodyRecordByID(id string) (models.CustodyRecord, error) {
+	var record models.CustodyRecord
+	err := r.db.
+		Preload("Suspect").
+		Preload("Case").
+		Preload("PolicePost").First(&record, "id = ?", id).Error
+	return record, err
+}
+
+func (r *CustodyRepositoryImpl) UpdateCustodyRecord(id string, updates map[string]interface{}) error {
+	return r.db.Model(&models.CustodyRecord{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteByID deletes a custody record by ID
+func (r *CustodyRepositoryImpl) DeleteByID(id string) error {
+	if err := r.db.Delete(&models.CustodyRecord{}, "id = ?", id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *CustodyRepositoryImpl) SearchPaginatedCustodyRecords(c *fiber.Ctx) (*utils.Pagination, []models.CustodyRecord, error) {
+	// Get query parameters from request
+	status := c.Query("status")
+	cellNumber := c.Query("cell_number")
+	policePostID := c.Query("police_post_id")
+	suspectID := c.Query("suspect_id")
+	caseID := c.Query("case_id")
+
+	// Start building the query
+	query := r.db.
+		Preload("Suspect").
+		Preload("Case").
+		Preload("PolicePost").Model(&models.CustodyRecord{})
+
+	// Apply filters based on provided parameters
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if cellNumber != "" {
+		query = query.Where("cell_number ILIKE ?", "%"+cellNumber+"%")
+	}
+	if policePostID != "" {
+		if _, err := strconv.Atoi(policePostID); err == nil {
+			query = query.Where("police_post_id = ?", policePostID)
+		}
+	}
+	if suspectID != "" {
+		if _, err := strconv.Atoi(suspectID); err == nil {
+			query = query.Where("suspect_id = ?", suspectID)
+		}
+	}
+	if caseID != "" {
+		if _, err := strconv.Atoi(caseID); err == nil {
+			query = query.Where("case_id = ?", caseID)
+		}
+	}
+
+	// Call the pagination helper
+	pagination, records, err := utils.Paginate(c, query.Order("detention_start DESC"), models.CustodyRecord{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &pagination, records, nil
+}
+
+func (r *CustodyRepositoryImpl) FindArrestByID(id uint) (models.Arrest, error) {
+	var arrest models.Arrest
+	err := r.db.Preload("Officer").First(&arrest, "id = ?", id).Error
+	return arrest, err
+}
+
+// CountActiveForArrest counts custody records for an arrest that are still open
+func (r *CustodyRepositoryImpl) CountActiveForArrest(arrestID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CustodyRecord{}).
+		Where("arrest_id = ? AND status = ?", arrestID, models.CustodyStatusInCustody).
+		Count(&count).Error
+	return count, err
+}
+
+// FindUnproducedDetainedSince returns suspects still held without production in court
+// whose detention started at or before the given time
+func (r *CustodyRepositoryImpl) FindUnproducedDetainedSince(since time.Time) ([]models.CustodyRecord, error) {
+	var records []models.CustodyRecord
+	err := r.db.
+		Preload("Suspect").
+		Preload("Case").
+		Preload("PolicePost").
+		Where("status = ? AND produced_in_court_at IS NULL AND detention_start <= ?", models.CustodyStatusInCustody, since).
+		Order("detention_start ASC").
+		Find(&records).Error
+	return records, err
+}
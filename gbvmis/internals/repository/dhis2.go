@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Dhis2Repository interface {
+	GetDataElementMappings() ([]models.Dhis2DataElementMapping, error)
+	CreateDataElementMapping(mapping *models.Dhis2DataElementMapping) error
+	GetDataElementMappingByID(id string) (models.Dhis2DataElementMapping, error)
+	UpdateDataElementMapping(id uint, updates map[string]interface{}) error
+	DeleteDataElementMapping(id uint) error
+	GetOrgUnitMappings() ([]models.Dhis2OrgUnitMapping, error)
+	SetOrgUnitMapping(mapping *models.Dhis2OrgUnitMapping) error
+	DeleteOrgUnitMapping(facilityID string) (int64, error)
+	FindHealthFacilityByID(id uint) (models.HealthFacility, error)
+	GetFacilityExaminationCounts(from, to string) ([]models.FacilityExaminationCounts, error)
+	CreateDhis2Push(push *models.Dhis2Push) error
+	GetPaginatedDhis2Pushes(c *fiber.Ctx) (*utils.Pagination, []models.Dhis2Push, error)
+}
+
+type Dhis2RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func Dhis2DbService(db *gorm.DB) Dhis2Repository {
+	return &Dhis2RepositoryImpl{db: db}
+}
+
+func (r *Dhis2RepositoryImpl) GetDataElementMappings() ([]models.Dhis2DataElementMapping, error) {
+	var mappings []models.Dhis2DataElementMapping
+	err := r.db.Order("indicator, id").Find(&mappings).Error
+	return mappings, err
+}
+
+func (r *Dhis2RepositoryImpl) CreateDataElementMapping(mapping *models.Dhis2DataElementMapping) error {
+	return r.db.Create(mapping).Error
+}
+
+func (r *Dhis2RepositoryImpl) GetDataElementMappingByID(id string) (models.Dhis2DataElementMapping, error) {
+	var mapping models.Dhis2DataElementMapping
+	err := r.db.First(&mapping, "id = ?", id).Error
+	return mapping, err
+}
+
+func (r *Dhis2RepositoryImpl) UpdateDataElementMapping(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.Dhis2DataElementMapping{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *Dhis2RepositoryImpl) DeleteDataElementMapping(id uint) error {
+	return r.db.Delete(&models.Dhis2DataElementMapping{}, id).Error
+}
+
+func (r *Dhis2RepositoryImpl) GetOrgUnitMappings() ([]models.Dhis2OrgUnitMapping, error) {
+	var mappings []models.Dhis2OrgUnitMapping
+	err := r.db.Preload("Facility").Order("facility_id").Find(&mappings).Error
+	return mappings, err
+}
+
+// SetOrgUnitMapping creates or replaces the facility's organisation unit
+func (r *Dhis2RepositoryImpl) SetOrgUnitMapping(mapping *models.Dhis2OrgUnitMapping) error {
+	return r.db.Omit("Facility").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "facility_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"org_unit", "updated_at"}),
+	}).Create(mapping).Error
+}
+
+// DeleteOrgUnitMapping removes the mapping for good so the facility can be
+// mapped again, returning how many rows went
+func (r *Dhis2RepositoryImpl) DeleteOrgUnitMapping(facilityID string) (int64, error) {
+	result := r.db.Unscoped().Where("facility_id = ?", facilityID).Delete(&models.Dhis2OrgUnitMapping{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *Dhis2RepositoryImpl) FindHealthFacilityByID(id uint) (models.HealthFacility, error) {
+	var facility models.HealthFacility
+	err := r.db.First(&facility, "id = ?", id).Error
+	return facility, err
+}
+
+// GetFacilityExaminationCounts computes the DHIS2 indicators per facility for
+// examinations dated from to to (YYYY-MM-DD, inclusive)
+func (r *Dhis2RepositoryImpl) GetFacilityExaminationCounts(from, to string) ([]models.FacilityExaminationCounts, error) {
+	var counts []models.FacilityExaminationCounts
+	err := r.db.Table("examinations").
+		Select(`examinations.facility_id,
+			COUNT(*) AS examinations,
+			COUNT(*) FILTER (WHERE examinations.consent_given) AS consented,
+			COUNT(*) FILTER (WHERE LOWER(victims.gender) IN ('female', 'f')) AS female,
+			COUNT(*) FILTER (WHERE LOWER(victims.gender) IN ('male', 'm')) AS male,
+			COUNT(*) FILTER (WHERE victims.dob > '0001-01-01' AND victims.dob <= examinations.exam_date
+				AND age(examinations.exam_date, victims.dob) < interval '18 years') AS child,
+			COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM referrals
+				WHERE referrals.examination_id = examinations.id AND referrals.deleted_at IS NULL)) AS referred,
+			COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM incidents
+				WHERE incidents.case_id = examinations.case_id AND incidents.violence_type = ? AND incidents.deleted_at IS NULL)) AS sexual_violence`,
+			models.ViolenceSexual).
+		Joins("LEFT JOIN victims ON victims.id = examinations.victim_id").
+		Where("examinations.deleted_at IS NULL AND examinations.exam_date BETWEEN ? AND ?", from, to).
+		Group("examinations.facility_id").
+		Order("examinations.facility_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *Dhis2RepositoryImpl) CreateDhis2Push(push *models.Dhis2Push) error {
+	return r.db.Create(push).Error
+}
+
+func (r *Dhis2RepositoryImpl) GetPaginatedDhis2Pushes(c *fiber.Ctx) (*utils.Pagination, []models.Dhis2Push, error) {
+	query := r.db.Model(&models.Dhis2Push{}).Order("pushed_at DESC")
+	if period := c.Query("period"); period != "" {
+		query = query.Where("period = ?", period)
+	}
+
+	pagination, pushes, err := utils.Paginate(c, query, models.Dhis2Push{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, pushes, nil
+}
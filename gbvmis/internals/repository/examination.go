@@ -4,6 +4,7 @@ import (
 	"gbvmis/internals/models"
 	"gbvmis/internals/utils"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -36,6 +37,7 @@ func (r *ExaminationRepositoryImpl) GetPaginatedExaminations(c *fiber.Ctx) (*uti
 	pagination, examinations, err := utils.Paginate(c, r.db.
 		Preload("Victim").
 		Preload("Case").
+		Preload("Case.Incidents").
 		Preload("Facility").
 		Preload("Practitioner"), models.Examination{})
 	if err != nil {
@@ -49,6 +51,7 @@ func (r *ExaminationRepositoryImpl) GetExaminationByID(id string) (models.Examin
 	err := r.db.
 		Preload("Victim").
 		Preload("Case").
+		Preload("Case.Incidents").
 		Preload("Facility").
 		Preload("Practitioner").First(&examination, "id = ?", id).Error
 	return examination, err
@@ -75,8 +78,11 @@ func (r *ExaminationRepositoryImpl) SearchPaginatedExaminations(c *fiber.Ctx) (*
 	query := r.db.
 		Preload("Victim").
 		Preload("Case").
+		Preload("Case.Incidents").
 		Preload("Facility").
-		Preload("Practitioner").Model(&models.Examination{})
+		Preload("Practitioner").
+		Preload("Injuries", orderInjuries).
+		Preload("CodedFindings").Model(&models.Examination{})
 
 	// Apply filters based on provided parameters
 	if FacilityID != "" {
@@ -90,6 +96,28 @@ func (r *ExaminationRepositoryImpl) SearchPaginatedExaminations(c *fiber.Ctx) (*
 		}
 	}
 
+	// Coded findings: examinations with a matching injury or genital finding
+	injury, injuryArgs := []string{}, []interface{}{}
+	if injuryType := c.Query("injury_type"); injuryType != "" {
+		injury, injuryArgs = append(injury, "examination_injuries.injury_type = ?"), append(injuryArgs, injuryType)
+	}
+	if region := c.Query("body_region"); region != "" {
+		condition, args := bodyRegionCondition("examination_injuries.body_region", region)
+		injury, injuryArgs = append(injury, condition), append(injuryArgs, args...)
+	}
+	if len(injury) > 0 {
+		query = query.Where(`EXISTS (SELECT 1 FROM examination_injuries
+			WHERE examination_injuries.examination_id = examinations.id AND examination_injuries.deleted_at IS NULL
+			AND `+strings.Join(injury, " AND ")+")", injuryArgs...)
+	}
+	for _, column := range []string{"hymen", "anal_examination", "penetration_opinion"} {
+		if value := c.Query(column); value != "" {
+			query = query.Where(`EXISTS (SELECT 1 FROM examination_findings
+				WHERE examination_findings.examination_id = examinations.id AND examination_findings.deleted_at IS NULL
+				AND examination_findings.`+column+" = ?)", value)
+		}
+	}
+
 	// Call the pagination helper
 	pagination, examinations, err := utils.Paginate(c, query, models.Examination{})
 	if err != nil {
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExhibitRepository interface {
+	CreateExhibit(exhibit *models.Exhibit) error
+	GetPaginatedExhibits(c *fiber.Ctx) (*utils.Pagination, []models.Exhibit, error)
+	UpdateExhibit(id string, updates map[string]interface{}) error
+	GetExhibitByID(id string) (models.Exhibit, error)
+	DeleteByID(id string) error
+	SearchPaginatedExhibits(c *fiber.Ctx) (*utils.Pagination, []models.Exhibit, error)
+	FindCaseByID(id uint) (models.Case, error)
+	FindOfficerByID(id uint) (models.PoliceOfficer, error)
+	AppendTransfer(exhibitID uint, build func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) (models.ExhibitTransfer, error)
+	GetTransfers(exhibitID uint) ([]models.ExhibitTransfer, error)
+}
+
+type ExhibitRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func ExhibitDbService(db *gorm.DB) ExhibitRepository {
+	return &ExhibitRepositoryImpl{db: db}
+}
+
+// =================================
+
+func (r *ExhibitRepositoryImpl) CreateExhibit(exhibit *models.Exhibit) error {
+	return r.db.Create(exhibit).Error
+}
+
+func (r *ExhibitRepositoryImpl) GetPaginatedExhibits(c *fiber.Ctx) (*utils.Pagination, []models.Exhibit, error) {
+	pagination, exhibits, err := utils.Paginate(c, r.db.Preload("Case"), models.Exhibit{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, exhibits, nil
+}
+
+func (r *ExhibitRepositoryImpl) GetExhibitByID(id string) (models.Exhibit, error) {
+	var exhibit models.Exhibit
+	err := r.db.Preload("Case").First(&exhibit, "id = ?", id).Error
+	return exhibit, err
+}
+
+func (r *ExhibitRepositoryImpl) UpdateExhibit(id string, updates map[string]interface{}) error {
+	return r.db.Model(&models.Exhibit{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteByID deletes an exhibit by ID. Its transfers are kept.
+func (r *ExhibitRepositoryImpl) DeleteByID(id string) error {
+	if err := r.db.Delete(&models.Exhibit{}, "id = ?", id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *ExhibitRepositoryImpl) SearchPaginatedExhibits(c *fiber.Ctx) (*utils.Pagination, []models.Exhibit, error) {
+	// Get query parameters from request
+	caseID := c.Query("case_id")
+	exhibitNumber := c.Query("exhibit_number")
+	sealNumber := c.Query("seal_number")
+	description := c.Query("description")
+	category := c.Query("category")
+	holderType := c.Query("holder_type")
+
+	// Start building the query
+	query := r.db.Preload("Case").Model(&models.Exhibit{})
+
+	// Apply filters based on provided parameters
+	if caseID != "" {
+		if _, err := strconv.Atoi(caseID); err == nil {
+			query = query.Where("case_id = ?", caseID)
+		}
+	}
+	if exhibitNumber != "" {
+		query = query.Where("exhibit_number ILIKE ?", "%"+exhibitNumber+"%")
+	}
+	if sealNumber != "" {
+		query = query.Where("seal_number = ?", sealNumber)
+	}
+	if description != "" {
+		query = query.Where("description ILIKE ?", "%"+description+"%")
+	}
+	if category != "" {
+		query = query.Where("category ILIKE ?", "%"+category+"%")
+	}
+	if holderType != "" {
+		query = query.Where("holder_type = ?", holderType)
+	}
+
+	// Call the pagination helper
+	pagination, exhibits, err := utils.Paginate(c, query, models.Exhibit{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &pagination, exhibits, nil
+}
+
+func (r *ExhibitRepositoryImpl) FindCaseByID(id uint) (models.Case, error) {
+	var casee models.Case
+	err := r.db.First(&casee, "id = ?", id).Error
+	return casee, err
+}
+
+func (r *ExhibitRepositoryImpl) FindOfficerByID(id uint) (models.PoliceOfficer, error) {
+	var officer models.PoliceOfficer
+	err := r.db.First(&officer, "id = ?", id).Error
+	return officer, err
+}
+
+// AppendTransfer locks the exhibit, hands the current holder and last transfer
+// to build, then stores the resulting transfer and moves the exhibit to its new holder
+func (r *ExhibitRepositoryImpl) AppendTransfer(exhibitID uint, build func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) (models.ExhibitTransfer, error) {
+	var transfer models.ExhibitTransfer
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var exhibit models.Exhibit
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&exhibit, "id = ?", exhibitID).Error; err != nil {
+			return err
+		}
+
+		var last *models.ExhibitTransfer
+		var prev models.ExhibitTransfer
+		err := tx.Where("exhibit_id = ?", exhibitID).Order("sequence DESC").First(&prev).Error
+		if err == nil {
+			last = &prev
+		} else if err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		transfer, err = build(exhibit, last)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&transfer).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"holder_type":       transfer.ToType,
+			"holder_name":       transfer.ToName,
+			"holder_officer_id": transfer.ToOfficerID,
+		}
+		if transfer.SealNumber != "" {
+			updates["seal_number"] = transfer.SealNumber
+		}
+		return tx.Model(&models.Exhibit{}).Where("id = ?", exhibitID).Updates(updates).Error
+	})
+	return transfer, err
+}
+
+func (r *ExhibitRepositoryImpl) GetTransfers(exhibitID uint) ([]models.ExhibitTransfer, error) {
+	var transfers []models.ExhibitTransfer
+	err := r.db.Where("exhibit_id = ?", exhibitID).Order("sequence ASC").Find(&transfers).Error
+	return transfers, err
+}
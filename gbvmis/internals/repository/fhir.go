@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+
+	"gorm.io/gorm"
+)
+
+type FhirRepository interface {
+	GetExaminationByID(id string) (models.Examination, error)
+	SearchConsentedExaminations(filter models.FhirExaminationFilter) ([]models.Examination, int64, error)
+	GetPractitionerByID(id string) (models.HealthPractitioner, error)
+	GetFacilityByID(id string) (models.HealthFacility, error)
+}
+
+type FhirRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func FhirDbService(db *gorm.DB) FhirRepository {
+	return &FhirRepositoryImpl{db: db}
+}
+
+// GetExaminationByID loads an examination with what its resources refer to.
+// The caller checks consent.
+func (r *FhirRepositoryImpl) GetExaminationByID(id string) (models.Examination, error) {
+	var examination models.Examination
+	err := r.db.
+		Preload("Case").
+		Preload("Facility").
+		Preload("Practitioner").
+		Preload("Injuries", orderInjuries).
+		Preload("CodedFindings").
+		First(&examination, "id = ?", id).Error
+	return examination, err
+}
+
+// SearchConsentedExaminations returns a page of examinations the survivor
+// consented to and how many match in all
+func (r *FhirRepositoryImpl) SearchConsentedExaminations(filter models.FhirExaminationFilter) ([]models.Examination, int64, error) {
+	query := r.db.Model(&models.Examination{}).Where("consent_given")
+	if filter.FacilityID != nil {
+		query = query.Where("facility_id = ?", *filter.FacilityID)
+	}
+	if filter.PractitionerID != nil {
+		query = query.Where("practitioner_id = ?", *filter.PractitionerID)
+	}
+	if filter.From != "" {
+		query = query.Where("exam_date >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("exam_date <= ?", filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var examinations []models.Examination
+	err := query.
+		Preload("Case").
+		Preload("Facility").
+		Preload("Practitioner").
+		Preload("Injuries", orderInjuries).
+		Preload("CodedFindings").
+		Order("exam_date DESC, id DESC").
+		Limit(filter.Count).
+		Offset(filter.Offset).
+		Find(&examinations).Error
+	return examinations, total, err
+}
+
+func (r *FhirRepositoryImpl) GetPractitionerByID(id string) (models.HealthPractitioner, error) {
+	var practitioner models.HealthPractitioner
+	err := r.db.First(&practitioner, "id = ?", id).Error
+	return practitioner, err
+}
+
+func (r *FhirRepositoryImpl) GetFacilityByID(id string) (models.HealthFacility, error) {
+	var facility models.HealthFacility
+	err := r.db.First(&facility, "id = ?", id).Error
+	return facility, err
+}
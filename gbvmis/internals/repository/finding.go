@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type FindingRepository interface {
+	GetExaminationByID(id string) (models.Examination, error)
+	GetExaminationFindings(examinationID uint) (*models.ExaminationFindings, error)
+	SaveExaminationFindings(findings *models.ExaminationFindings) error
+	GetExaminationInjuries(examinationID uint) ([]models.ExaminationInjury, error)
+	CreateInjury(injury *models.ExaminationInjury) error
+	GetInjuryByID(id string) (models.ExaminationInjury, error)
+	UpdateInjury(id uint, updates map[string]interface{}) error
+	DeleteInjury(id uint) error
+	GetPaginatedInjuries(c *fiber.Ctx) (*utils.Pagination, []models.ExaminationInjury, error)
+	GetInjuryStats(c *fiber.Ctx) ([]models.InjuryStat, error)
+}
+
+type FindingRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func FindingDbService(db *gorm.DB) FindingRepository {
+	return &FindingRepositoryImpl{db: db}
+}
+
+// =================================
+
+// orderInjuries preloads an examination's injuries in body map order. Deleted
+// injuries are left out even where the examination is loaded unscoped.
+func orderInjuries(db *gorm.DB) *gorm.DB {
+	return db.Where("examination_injuries.deleted_at IS NULL").Order("body_region, id")
+}
+
+// bodyRegionCondition matches a body region code, or every region in a group
+// when only the group is given ("genital" matches "genital.hymen")
+func bodyRegionCondition(column, region string) (string, []interface{}) {
+	if strings.Contains(region, ".") {
+		return column + " = ?", []interface{}{region}
+	}
+	return column + " LIKE ?", []interface{}{region + ".%"}
+}
+
+// filterInjuries applies the injury query filters shared by the injury list
+// and its statistics: injury_type, body_region (a code or a group),
+// side, age_estimate, facility_id, practitioner_id, and exam_from and
+// exam_to on the examination date (YYYY-MM-DD)
+func filterInjuries(query *gorm.DB, c *fiber.Ctx) *gorm.DB {
+	if injuryType := c.Query("injury_type"); injuryType != "" {
+		query = query.Where("examination_injuries.injury_type = ?", injuryType)
+	}
+	if region := c.Query("body_region"); region != "" {
+		condition, args := bodyRegionCondition("examination_injuries.body_region", region)
+		query = query.Where(condition, args...)
+	}
+	if side := c.Query("side"); side != "" {
+		query = query.Where("examination_injuries.side = ?", side)
+	}
+	if age := c.Query("age_estimate"); age != "" {
+		query = query.Where("examination_injuries.age_estimate = ?", age)
+	}
+
+	conditions, args := []string{"deleted_at IS NULL"}, []interface{}{}
+	for _, column := range []string{"facility_id", "practitioner_id"} {
+		if value := c.Query(column); value != "" {
+			if _, err := strconv.Atoi(value); err == nil {
+				conditions, args = append(conditions, column+" = ?"), append(args, value)
+			}
+		}
+	}
+	if from := c.Query("exam_from"); from != "" {
+		conditions, args = append(conditions, "exam_date >= ?"), append(args, from)
+	}
+	if to := c.Query("exam_to"); to != "" {
+		conditions, args = append(conditions, "exam_date <= ?"), append(args, to)
+	}
+	if len(args) > 0 {
+		query = query.Where("examination_injuries.examination_id IN (SELECT id FROM examinations WHERE "+
+			strings.Join(conditions, " AND ")+")", args...)
+	}
+	return query
+}
+
+func (r *FindingRepositoryImpl) GetExaminationByID(id string) (models.Examination, error) {
+	var examination models.Examination
+	err := r.db.First(&examination, "id = ?", id).Error
+	return examination, err
+}
+
+// GetExaminationFindings returns the examination's coded findings, or nil
+// when none have been recorded
+func (r *FindingRepositoryImpl) GetExaminationFindings(examinationID uint) (*models.ExaminationFindings, error) {
+	var findings models.ExaminationFindings
+	err := r.db.First(&findings, "examination_id = ?", examinationID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &findings, nil
+}
+
+// SaveExaminationFindings records the examination's findings, replacing any
+// recorded before
+func (r *FindingRepositoryImpl) SaveExaminationFindings(findings *models.ExaminationFindings) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.ExaminationFindings
+		err := tx.Where("examination_id = ?", findings.ExaminationID).First(&existing).Error
+		switch {
+		case err == nil:
+			findings.ID = existing.ID
+			findings.CreatedAt = existing.CreatedAt
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		return tx.Save(findings).Error
+	})
+}
+
+func (r *FindingRepositoryImpl) GetExaminationInjuries(examinationID uint) ([]models.ExaminationInjury, error) {
+	var injuries []models.ExaminationInjury
+	err := orderInjuries(r.db).Where("examination_id = ?", examinationID).Find(&injuries).Error
+	return injuries, err
+}
+
+func (r *FindingRepositoryImpl) CreateInjury(injury *models.ExaminationInjury) error {
+	return r.db.Create(injury).Error
+}
+
+func (r *FindingRepositoryImpl) GetInjuryByID(id string) (models.ExaminationInjury, error) {
+	var injury models.ExaminationInjury
+	err := r.db.First(&injury, "id = ?", id).Error
+	return injury, err
+}
+
+func (r *FindingRepositoryImpl) UpdateInjury(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.ExaminationInjury{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *FindingRepositoryImpl) DeleteInjury(id uint) error {
+	return r.db.Delete(&models.ExaminationInjury{}, id).Error
+}
+
+// GetPaginatedInjuries lists injuries, most recently recorded first
+func (r *FindingRepositoryImpl) GetPaginatedInjuries(c *fiber.Ctx) (*utils.Pagination, []models.ExaminationInjury, error) {
+	query := filterInjuries(r.db.Model(&models.ExaminationInjury{}), c).Order("id DESC")
+
+	pagination, injuries, err := utils.Paginate(c, query, models.ExaminationInjury{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, injuries, nil
+}
+
+// GetInjuryStats counts injuries by type and the part of the body they are on
+func (r *FindingRepositoryImpl) GetInjuryStats(c *fiber.Ctx) ([]models.InjuryStat, error) {
+	query := filterInjuries(r.db.Model(&models.ExaminationInjury{}), c).
+		Select("examination_injuries.injury_type, split_part(examination_injuries.body_region, '.', 1) AS body_part, COUNT(*) AS injuries").
+		Group("examination_injuries.injury_type, body_part").
+		Order("injuries DESC, examination_injuries.injury_type, body_part")
+
+	var stats []models.InjuryStat
+	err := query.Scan(&stats).Error
+	return stats, err
+}
@@ -0,0 +1,423 @@
+package repository
+
+import (
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GeographyRow is one line of a hierarchy import, checked and ordered so
+// every parent comes before its children
+type GeographyRow struct {
+	Line       int
+	Code       string
+	Name       string
+	Level      string
+	ParentCode string
+
+	// Optional centroid or, for posts, location
+	Latitude  *float64
+	Longitude *float64
+}
+
+// CaseWindow limits case counts to cases opened between From and To,
+// inclusive; a zero time leaves that end open
+type CaseWindow struct {
+	From time.Time
+	To   time.Time
+}
+
+// PoliceLevelPost is the import level for police posts, which are stored as
+// PolicePost records rather than police units
+const PoliceLevelPost = "post"
+
+type GeographyRepository interface {
+	GetAdminAreaLevels() (map[string]string, error)
+	ImportAdminAreas(rows []GeographyRow) (models.GeographyImportResult, error)
+	GetPaginatedAdminAreas(c *fiber.Ctx) (*utils.Pagination, []models.AdminArea, error)
+	GetAdminAreaByID(id string) (models.AdminArea, error)
+	GetAdminAreaPath(id uint) ([]models.AdminArea, error)
+	GetAdminAreaCaseCounts(level string, window CaseWindow) ([]models.AreaCaseCount, error)
+	GetPoliceUnitLevels() (map[string]string, error)
+	ImportPoliceUnits(rows []GeographyRow) (models.GeographyImportResult, error)
+	GetPaginatedPoliceUnits(c *fiber.Ctx) (*utils.Pagination, []models.PoliceUnit, error)
+	GetPoliceUnitByID(id string) (models.PoliceUnit, error)
+	GetPoliceUnitPath(id uint) ([]models.PoliceUnit, error)
+	GetStationPosts(stationID uint) ([]models.PolicePost, error)
+	GetPoliceUnitCaseCounts(level string, window CaseWindow) ([]models.AreaCaseCount, error)
+	GetNearestPolicePosts(lat, lng float64, limit int) ([]models.NearbyPolicePost, error)
+	GetNearestHealthFacilities(lat, lng float64, limit int, gbvOnly bool) ([]models.NearbyHealthFacility, error)
+}
+
+type GeographyRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func GeographyDbService(db *gorm.DB) GeographyRepository {
+	return &GeographyRepositoryImpl{db: db}
+}
+
+// =================================
+
+// hierarchyLevels maps every code in a hierarchy table, deleted or not, to its level
+func hierarchyLevels(db *gorm.DB, model interface{}) (map[string]string, error) {
+	var rows []struct {
+		Code  string
+		Level string
+	}
+	if err := db.Unscoped().Model(model).Select("code, level").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	levels := make(map[string]string, len(rows))
+	for _, row := range rows {
+		levels[row.Code] = row.Level
+	}
+	return levels, nil
+}
+
+// hierarchyIDs maps every code in a table, deleted or not, to its ID
+func hierarchyIDs(tx *gorm.DB, model interface{}) (map[string]uint, error) {
+	var rows []struct {
+		Code string
+		ID   uint
+	}
+	if err := tx.Unscoped().Model(model).Where("code <> ''").Select("code, id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uint, len(rows))
+	for _, row := range rows {
+		ids[row.Code] = row.ID
+	}
+	return ids, nil
+}
+
+// upsertByCode updates the record with the code, restoring it if it was
+// deleted, or creates it, and returns its ID
+func upsertByCode(tx *gorm.DB, model interface{}, ids map[string]uint, code string, values map[string]interface{}, result *models.GeographyImportResult) (uint, error) {
+	now := time.Now()
+	values["updated_at"] = now
+	values["deleted_at"] = nil
+
+	if id, ok := ids[code]; ok {
+		if err := tx.Unscoped().Model(model).Where("id = ?", id).Updates(values).Error; err != nil {
+			return 0, err
+		}
+		result.Updated++
+		return id, nil
+	}
+
+	values["code"] = code
+	values["created_at"] = now
+	if err := tx.Model(model).Create(values).Error; err != nil {
+		return 0, err
+	}
+	var id uint
+	if err := tx.Model(model).Select("id").Where("code = ?", code).Scan(&id).Error; err != nil {
+		return 0, err
+	}
+	ids[code] = id
+	result.Created++
+	return id, nil
+}
+
+// importHierarchy upserts the rows by code, linking each to its parent.
+// Rows at postLevel, if set, are police posts hung under their station. The
+// import is rolled back if it leaves any record, in the file or not, at or
+// above its parent's level.
+func importHierarchy(db *gorm.DB, model interface{}, levels []string, rows []GeographyRow, postLevel string) (models.GeographyImportResult, error) {
+	var result models.GeographyImportResult
+	err := db.Transaction(func(tx *gorm.DB) error {
+		ids, err := hierarchyIDs(tx, model)
+		if err != nil {
+			return err
+		}
+		postIDs := map[string]uint{}
+		if postLevel != "" {
+			if postIDs, err = hierarchyIDs(tx, &models.PolicePost{}); err != nil {
+				return err
+			}
+		}
+
+		for _, row := range rows {
+			var parentID *uint
+			if row.ParentCode != "" {
+				id, ok := ids[row.ParentCode]
+				if !ok {
+					return fmt.Errorf("line %d: parent %q not found", row.Line, row.ParentCode)
+				}
+				parentID = &id
+			}
+
+			if postLevel != "" && row.Level == postLevel {
+				values := map[string]interface{}{"name": row.Name, "station_id": parentID}
+				setCoordinates(values, row)
+				if _, err := upsertByCode(tx, &models.PolicePost{}, postIDs, row.Code, values, &result); err != nil {
+					return fmt.Errorf("line %d: %w", row.Line, err)
+				}
+				continue
+			}
+
+			values := map[string]interface{}{"name": row.Name, "level": row.Level, "parent_id": parentID}
+			setCoordinates(values, row)
+			if _, err := upsertByCode(tx, model, ids, row.Code, values, &result); err != nil {
+				return fmt.Errorf("line %d: %w", row.Line, err)
+			}
+		}
+		return checkHierarchyLevels(tx, model, levels)
+	})
+	return result, err
+}
+
+// checkHierarchyLevels returns ErrHierarchyLevels when a record is not at a
+// lower level than its parent. Levels strictly fall along every chain of
+// parents, so a chain cannot loop back on itself.
+func checkHierarchyLevels(tx *gorm.DB, model interface{}, levels []string) error {
+	var records []struct {
+		ID       uint
+		Code     string
+		Level    string
+		ParentID *uint
+	}
+	if err := tx.Model(model).Select("id, code, level, parent_id").Scan(&records).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]int, len(records))
+	for i, record := range records {
+		byID[record.ID] = i
+	}
+	for _, record := range records {
+		if record.ParentID == nil {
+			continue
+		}
+		i, ok := byID[*record.ParentID]
+		if !ok {
+			continue
+		}
+		if parent := records[i]; slices.Index(levels, parent.Level) >= slices.Index(levels, record.Level) {
+			return fmt.Errorf("%w: %s %q is under %s %q", models.ErrHierarchyLevels, record.Level, record.Code, parent.Level, parent.Code)
+		}
+	}
+	return nil
+}
+
+// setCoordinates adds the row's coordinates to an upsert, leaving stored ones
+// alone when the file has none
+func setCoordinates(values map[string]interface{}, row GeographyRow) {
+	if row.Latitude != nil && row.Longitude != nil {
+		values["latitude"] = *row.Latitude
+		values["longitude"] = *row.Longitude
+	}
+}
+
+// maxHierarchyDepth bounds the recursive walks up a hierarchy. It is deeper
+// than either hierarchy, so it only matters if a loop of parents were stored.
+const maxHierarchyDepth = 16
+
+// hierarchyPath returns the record and its ancestors, top level first
+func hierarchyPath[T any](db *gorm.DB, table string, id uint) ([]T, error) {
+	var path []T
+	err := db.Raw(`WITH RECURSIVE up AS (
+			SELECT *, 0 AS depth FROM `+table+` WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT t.*, up.depth + 1 FROM `+table+` t JOIN up ON t.id = up.parent_id
+				WHERE t.deleted_at IS NULL AND up.depth < ?
+		)
+		SELECT * FROM up ORDER BY depth DESC`, id, maxHierarchyDepth).Scan(&path).Error
+	return path, err
+}
+
+// filterHierarchy applies the list filters shared by both hierarchies:
+// level, parent_id, code and name
+func filterHierarchy(query *gorm.DB, c *fiber.Ctx) *gorm.DB {
+	if level := c.Query("level"); level != "" {
+		query = query.Where("level = ?", level)
+	}
+	if parentID := c.Query("parent_id"); parentID != "" {
+		if _, err := strconv.Atoi(parentID); err == nil {
+			query = query.Where("parent_id = ?", parentID)
+		}
+	}
+	if code := c.Query("code"); code != "" {
+		query = query.Where("code = ?", code)
+	}
+	if name := c.Query("name"); name != "" {
+		query = query.Where("name ILIKE ?", "%"+name+"%")
+	}
+	return query.Order("name")
+}
+
+// caseCountsAtLevel counts cases by their ancestor at the given level of a
+// hierarchy. caseUnits selects (case_id, unit_id) for each case; cases whose
+// unit is unknown or above the level are left out.
+func caseCountsAtLevel(db *gorm.DB, table, caseUnits, level string, window CaseWindow) ([]models.AreaCaseCount, error) {
+	var caseFilters string
+	var args []interface{}
+	if !window.From.IsZero() {
+		caseFilters += " AND cases.date_opened >= ?"
+		args = append(args, window.From)
+	}
+	if !window.To.IsZero() {
+		caseFilters += " AND cases.date_opened <= ?"
+		args = append(args, window.To)
+	}
+
+	var counts []models.AreaCaseCount
+	err := db.Raw(`WITH RECURSIVE chain AS (
+			SELECT id AS unit_id, id AS ancestor_id, parent_id, level, 0 AS depth FROM `+table+` WHERE deleted_at IS NULL
+			UNION ALL
+			SELECT chain.unit_id, t.id, t.parent_id, t.level, chain.depth + 1 FROM `+table+` t
+				JOIN chain ON t.id = chain.parent_id WHERE t.deleted_at IS NULL AND chain.depth < ?
+		), case_units AS (`+caseUnits+caseFilters+`)
+		SELECT t.id AS area_id, t.code, t.name, t.latitude, t.longitude, COUNT(*) AS cases
+		FROM case_units
+		JOIN chain ON chain.unit_id = case_units.unit_id AND chain.level = ?
+		JOIN `+table+` t ON t.id = chain.ancestor_id
+		GROUP BY t.id, t.code, t.name, t.latitude, t.longitude
+		ORDER BY cases DESC, t.name`, append(append([]interface{}{maxHierarchyDepth}, args...), level)...).Scan(&counts).Error
+	return counts, err
+}
+
+// =================================
+
+func (r *GeographyRepositoryImpl) GetAdminAreaLevels() (map[string]string, error) {
+	return hierarchyLevels(r.db, &models.AdminArea{})
+}
+
+// ImportAdminAreas creates or updates the areas by code in one transaction
+func (r *GeographyRepositoryImpl) ImportAdminAreas(rows []GeographyRow) (models.GeographyImportResult, error) {
+	return importHierarchy(r.db, &models.AdminArea{}, models.AdminLevels, rows, "")
+}
+
+func (r *GeographyRepositoryImpl) GetPaginatedAdminAreas(c *fiber.Ctx) (*utils.Pagination, []models.AdminArea, error) {
+	pagination, areas, err := utils.Paginate(c, filterHierarchy(r.db.Model(&models.AdminArea{}), c), models.AdminArea{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, areas, nil
+}
+
+func (r *GeographyRepositoryImpl) GetAdminAreaByID(id string) (models.AdminArea, error) {
+	var area models.AdminArea
+	err := r.db.First(&area, "id = ?", id).Error
+	return area, err
+}
+
+// GetAdminAreaPath returns the area and its ancestors, region first
+func (r *GeographyRepositoryImpl) GetAdminAreaPath(id uint) ([]models.AdminArea, error) {
+	return hierarchyPath[models.AdminArea](r.db, "admin_areas", id)
+}
+
+// GetAdminAreaCaseCounts counts cases by area at the given level. A case is
+// placed by its earliest incident with an area, or else by its police post's
+// area. The window applies to the case's opening date.
+func (r *GeographyRepositoryImpl) GetAdminAreaCaseCounts(level string, window CaseWindow) ([]models.AreaCaseCount, error) {
+	return caseCountsAtLevel(r.db, "admin_areas", `SELECT cases.id AS case_id, COALESCE(
+			(SELECT incidents.admin_area_id FROM incidents
+				WHERE incidents.case_id = cases.id AND incidents.admin_area_id IS NOT NULL AND incidents.deleted_at IS NULL
+				ORDER BY incidents.occurred_at, incidents.id LIMIT 1),
+			police_posts.admin_area_id) AS unit_id
+		FROM cases LEFT JOIN police_posts ON police_posts.id = cases.police_post_id
+		WHERE cases.deleted_at IS NULL`, level, window)
+}
+
+func (r *GeographyRepositoryImpl) GetPoliceUnitLevels() (map[string]string, error) {
+	levels, err := hierarchyLevels(r.db, &models.PoliceUnit{})
+	if err != nil {
+		return nil, err
+	}
+	var posts []string
+	if err := r.db.Unscoped().Model(&models.PolicePost{}).Where("code <> ''").Pluck("code", &posts).Error; err != nil {
+		return nil, err
+	}
+	for _, code := range posts {
+		if _, clash := levels[code]; !clash {
+			levels[code] = PoliceLevelPost
+		}
+	}
+	return levels, nil
+}
+
+// ImportPoliceUnits creates or updates the units and posts by code in one
+// transaction
+func (r *GeographyRepositoryImpl) ImportPoliceUnits(rows []GeographyRow) (models.GeographyImportResult, error) {
+	return importHierarchy(r.db, &models.PoliceUnit{}, models.PoliceLevels, rows, PoliceLevelPost)
+}
+
+func (r *GeographyRepositoryImpl) GetPaginatedPoliceUnits(c *fiber.Ctx) (*utils.Pagination, []models.PoliceUnit, error) {
+	pagination, units, err := utils.Paginate(c, filterHierarchy(r.db.Model(&models.PoliceUnit{}), c), models.PoliceUnit{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, units, nil
+}
+
+func (r *GeographyRepositoryImpl) GetPoliceUnitByID(id string) (models.PoliceUnit, error) {
+	var unit models.PoliceUnit
+	err := r.db.First(&unit, "id = ?", id).Error
+	return unit, err
+}
+
+// GetPoliceUnitPath returns the unit and its ancestors, region first
+func (r *GeographyRepositoryImpl) GetPoliceUnitPath(id uint) ([]models.PoliceUnit, error) {
+	return hierarchyPath[models.PoliceUnit](r.db, "police_units", id)
+}
+
+func (r *GeographyRepositoryImpl) GetStationPosts(stationID uint) ([]models.PolicePost, error) {
+	var posts []models.PolicePost
+	err := r.db.Where("station_id = ?", stationID).Order("name").Find(&posts).Error
+	return posts, err
+}
+
+// GetPoliceUnitCaseCounts counts cases by police unit at the given level,
+// placing each case by its police post's station. The window applies to the
+// case's opening date.
+func (r *GeographyRepositoryImpl) GetPoliceUnitCaseCounts(level string, window CaseWindow) ([]models.AreaCaseCount, error) {
+	return caseCountsAtLevel(r.db, "police_units", `SELECT cases.id AS case_id, police_posts.station_id AS unit_id
+		FROM cases JOIN police_posts ON police_posts.id = cases.police_post_id
+		WHERE cases.deleted_at IS NULL`, level, window)
+}
+
+// distanceKm is the great-circle distance in kilometres from the point given
+// by the arguments latitude, longitude, latitude to a row's coordinates
+const distanceKm = `6371 * ACOS(LEAST(1, COS(RADIANS(?)) * COS(RADIANS(latitude)) * COS(RADIANS(longitude) - RADIANS(?))
+	+ SIN(RADIANS(?)) * SIN(RADIANS(latitude))))`
+
+// nearest selects the located rows of the query closest to a point
+func nearest(query *gorm.DB, table string, lat, lng float64, limit int) *gorm.DB {
+	return query.Select(table+".*, "+distanceKm+" AS distance_km", lat, lng, lat).
+		Where("latitude IS NOT NULL AND longitude IS NOT NULL").
+		Order("distance_km").Limit(limit)
+}
+
+func (r *GeographyRepositoryImpl) GetNearestPolicePosts(lat, lng float64, limit int) ([]models.NearbyPolicePost, error) {
+	var posts []models.NearbyPolicePost
+	err := nearest(r.db.Model(&models.PolicePost{}), "police_posts", lat, lng, limit).Scan(&posts).Error
+	return posts, err
+}
+
+// GetNearestHealthFacilities returns the closest facilities, only those
+// offering GBV services when gbvOnly is set
+func (r *GeographyRepositoryImpl) GetNearestHealthFacilities(lat, lng float64, limit int, gbvOnly bool) ([]models.NearbyHealthFacility, error) {
+	query := r.db.Model(&models.HealthFacility{})
+	if gbvOnly {
+		query = query.Where("gbv_services = ?", true)
+	}
+	var facilities []models.NearbyHealthFacility
+	err := nearest(query, "health_facilities", lat, lng, limit).Scan(&facilities).Error
+	return facilities, err
+}
+
+// =================================
+
+// findAdminArea loads an area for the repositories that link records to one
+func findAdminArea(db *gorm.DB, id uint) (models.AdminArea, error) {
+	var area models.AdminArea
+	err := db.First(&area, "id = ?", id).Error
+	return area, err
+}
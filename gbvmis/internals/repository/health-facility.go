@@ -15,6 +15,7 @@ type HealthFacilityRepository interface {
 	GetHealthFacilityByID(id string) (models.HealthFacility, error)
 	DeleteByID(id string) error
 	SearchPaginatedHealthFacilities(c *fiber.Ctx) (*utils.Pagination, []models.HealthFacility, error)
+	FindAdminAreaByID(id uint) (models.AdminArea, error)
 }
 
 type HealthFacilityRepositoryImpl struct {
@@ -81,3 +82,7 @@ func (r *HealthFacilityRepositoryImpl) SearchPaginatedHealthFacilities(c *fiber.
 
 	return &pagination, healthFacilities, nil
 }
+
+func (r *HealthFacilityRepositoryImpl) FindAdminAreaByID(id uint) (models.AdminArea, error) {
+	return findAdminArea(r.db, id)
+}
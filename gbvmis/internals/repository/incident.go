@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type IncidentRepository interface {
+	GetCaseByID(id string) (models.Case, error)
+	CreateIncident(incident *models.Incident) error
+	GetCaseIncidents(caseID uint) ([]models.Incident, error)
+	GetIncidentByID(id string) (models.Incident, error)
+	UpdateIncident(id uint, updates map[string]interface{}) error
+	DeleteIncident(id uint) error
+	GetPaginatedIncidents(c *fiber.Ctx) (*utils.Pagination, []models.Incident, error)
+	GetIncidentStats(c *fiber.Ctx) ([]models.IncidentStat, error)
+	GetAdminAreaPath(id uint) ([]models.AdminArea, error)
+}
+
+type IncidentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func IncidentDbService(db *gorm.DB) IncidentRepository {
+	return &IncidentRepositoryImpl{db: db}
+}
+
+// =================================
+
+// filterIncidents applies the incident query filters shared by the incident
+// list, the statistics and the case search: violence_type, setting,
+// district, subcounty, village, police_post_id, and occurred_from and
+// occurred_to (YYYY-MM-DD)
+func filterIncidents(query *gorm.DB, c *fiber.Ctx) *gorm.DB {
+	if violenceType := c.Query("violence_type"); violenceType != "" {
+		query = query.Where("incidents.violence_type = ?", violenceType)
+	}
+	if setting := c.Query("setting"); setting != "" {
+		query = query.Where("incidents.setting = ?", setting)
+	}
+	if district := c.Query("district"); district != "" {
+		query = query.Where("incidents.district ILIKE ?", "%"+district+"%")
+	}
+	if subcounty := c.Query("subcounty"); subcounty != "" {
+		query = query.Where("incidents.subcounty ILIKE ?", "%"+subcounty+"%")
+	}
+	if village := c.Query("village"); village != "" {
+		query = query.Where("incidents.village ILIKE ?", "%"+village+"%")
+	}
+	if postID := c.Query("police_post_id"); postID != "" {
+		if _, err := strconv.Atoi(postID); err == nil {
+			query = query.Where("incidents.case_id IN (SELECT id FROM cases WHERE police_post_id = ? AND deleted_at IS NULL)", postID)
+		}
+	}
+	if from := c.Query("occurred_from"); from != "" {
+		query = query.Where("incidents.occurred_at >= ?", from)
+	}
+	if to := c.Query("occurred_to"); to != "" {
+		query = query.Where("incidents.occurred_at < (?::date + 1)", to)
+	}
+	return query
+}
+
+// hasIncidentFilters reports whether the request filters on incident fields
+func hasIncidentFilters(c *fiber.Ctx) bool {
+	for _, key := range []string{"violence_type", "setting", "district", "subcounty", "village", "occurred_from", "occurred_to"} {
+		if c.Query(key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *IncidentRepositoryImpl) GetCaseByID(id string) (models.Case, error) {
+	var casee models.Case
+	err := r.db.First(&casee, "id = ?", id).Error
+	return casee, err
+}
+
+func (r *IncidentRepositoryImpl) CreateIncident(incident *models.Incident) error {
+	return r.db.Create(incident).Error
+}
+
+// GetCaseIncidents returns the case's incidents, earliest first
+func (r *IncidentRepositoryImpl) GetCaseIncidents(caseID uint) ([]models.Incident, error) {
+	var incidents []models.Incident
+	err := orderedIncidents(r.db).Where("case_id = ?", caseID).Find(&incidents).Error
+	return incidents, err
+}
+
+func (r *IncidentRepositoryImpl) GetIncidentByID(id string) (models.Incident, error) {
+	var incident models.Incident
+	err := r.db.First(&incident, "id = ?", id).Error
+	return incident, err
+}
+
+func (r *IncidentRepositoryImpl) UpdateIncident(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.Incident{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *IncidentRepositoryImpl) DeleteIncident(id uint) error {
+	return r.db.Delete(&models.Incident{}, id).Error
+}
+
+// GetPaginatedIncidents lists incidents, most recent first
+func (r *IncidentRepositoryImpl) GetPaginatedIncidents(c *fiber.Ctx) (*utils.Pagination, []models.Incident, error) {
+	query := filterIncidents(r.db.Model(&models.Incident{}), c).Order("occurred_at DESC, id DESC")
+
+	pagination, incidents, err := utils.Paginate(c, query, models.Incident{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, incidents, nil
+}
+
+// GetIncidentStats counts incidents by violence type and setting
+func (r *IncidentRepositoryImpl) GetIncidentStats(c *fiber.Ctx) ([]models.IncidentStat, error) {
+	query := filterIncidents(r.db.Model(&models.Incident{}), c).
+		Select("incidents.violence_type, incidents.setting, COUNT(*) AS incidents").
+		Group("incidents.violence_type, incidents.setting").
+		Order("COUNT(*) DESC, incidents.violence_type, incidents.setting")
+
+	var stats []models.IncidentStat
+	err := query.Scan(&stats).Error
+	return stats, err
+}
+
+// GetAdminAreaPath returns the area and its ancestors, region first
+func (r *IncidentRepositoryImpl) GetAdminAreaPath(id uint) ([]models.AdminArea, error) {
+	return hierarchyPath[models.AdminArea](r.db, "admin_areas", id)
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"encoding/json"
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LabRepository interface {
+	GetCaseByID(id string) (models.Case, error)
+	GetExaminationByID(id string) (models.Examination, error)
+	FindOfficerByID(id uint) (models.PoliceOfficer, error)
+	FindExhibitByID(id uint) (models.Exhibit, error)
+	CreateLabRequest(request *models.LabRequest, newExhibit func(request models.LabRequest, specimen models.LabSpecimen, n int) models.Exhibit, reseal func(request models.LabRequest, specimen models.LabSpecimen, exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) error
+	GetLabRequestByID(id string) (models.LabRequest, error)
+	GetLabRequestsFor(column string, id uint) ([]models.LabRequest, error)
+	GetPaginatedLabRequests(c *fiber.Ctx) (*utils.Pagination, []models.LabRequest, error)
+	UpdateLabRequestStatus(request models.LabRequest, updates map[string]interface{}, update *models.LabRequestUpdate) error
+	DispatchLabRequest(request models.LabRequest, updates map[string]interface{}, update *models.LabRequestUpdate, build func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) error
+	ReceiveLabRequest(request models.LabRequest, updates map[string]interface{}, update *models.LabRequestUpdate, build func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) error
+	RecordLabResults(request models.LabRequest, results []models.LabResult, updates map[string]interface{}, update *models.LabRequestUpdate) error
+	GetLabTurnaround(c *fiber.Ctx) ([]models.LabTurnaround, error)
+}
+
+type LabRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func LabDbService(db *gorm.DB) LabRepository {
+	return &LabRepositoryImpl{db: db}
+}
+
+// =================================
+
+// preloadLabRequest loads a request's specimens, results and history
+func preloadLabRequest(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Specimens", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Results", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Updates", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") })
+}
+
+// filterLabRequests applies the lab request query filters shared by the
+// request list and the turnaround report: lab_name, analysis, urgent, and
+// from and to on the request date (YYYY-MM-DD)
+func filterLabRequests(query *gorm.DB, c *fiber.Ctx) *gorm.DB {
+	if labName := c.Query("lab_name"); labName != "" {
+		query = query.Where("lab_requests.lab_name ILIKE ?", "%"+labName+"%")
+	}
+	if analysis := c.Query("analysis"); analysis != "" {
+		contains, _ := json.Marshal([]string{analysis})
+		query = query.Where("lab_requests.analyses::jsonb @> ?", string(contains))
+	}
+	if urgent := c.Query("urgent"); urgent != "" {
+		query = query.Where("lab_requests.urgent = ?", utils.StrToBool(urgent))
+	}
+	if from := c.Query("from"); from != "" {
+		query = query.Where("lab_requests.requested_at >= ?", from)
+	}
+	if to := c.Query("to"); to != "" {
+		query = query.Where("lab_requests.requested_at < (?::date + 1)", to)
+	}
+	return query
+}
+
+func (r *LabRepositoryImpl) GetCaseByID(id string) (models.Case, error) {
+	var casee models.Case
+	err := r.db.First(&casee, "id = ?", id).Error
+	return casee, err
+}
+
+func (r *LabRepositoryImpl) GetExaminationByID(id string) (models.Examination, error) {
+	var examination models.Examination
+	err := r.db.First(&examination, "id = ?", id).Error
+	return examination, err
+}
+
+func (r *LabRepositoryImpl) FindOfficerByID(id uint) (models.PoliceOfficer, error) {
+	var officer models.PoliceOfficer
+	err := r.db.First(&officer, "id = ?", id).Error
+	return officer, err
+}
+
+func (r *LabRepositoryImpl) FindExhibitByID(id uint) (models.Exhibit, error) {
+	var exhibit models.Exhibit
+	err := r.db.First(&exhibit, "id = ?", id).Error
+	return exhibit, err
+}
+
+// CreateLabRequest stores a request with its specimens. Specimens not yet in
+// the exhibit register are registered with the exhibit newExhibit builds;
+// n counts specimens from 1. An exhibit already registered must be held by
+// an officer and not be in another open request; when its seal differs from
+// the specimen's, the transfer reseal builds is added to its chain of custody.
+func (r *LabRepositoryImpl) CreateLabRequest(request *models.LabRequest, newExhibit func(request models.LabRequest, specimen models.LabSpecimen, n int) models.Exhibit, reseal func(request models.LabRequest, specimen models.LabSpecimen, exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		specimens := request.Specimens
+		if err := tx.Omit(clause.Associations).Create(request).Error; err != nil {
+			return err
+		}
+		for i := range specimens {
+			specimens[i].LabRequestID = request.ID
+			if specimens[i].ExhibitID == 0 {
+				exhibit := newExhibit(*request, specimens[i], i+1)
+				if err := tx.Omit(clause.Associations).Create(&exhibit).Error; err != nil {
+					return err
+				}
+				specimens[i].ExhibitID = exhibit.ID
+			} else if err := checkLabExhibit(tx, *request, specimens[i], reseal); err != nil {
+				return err
+			}
+			if err := tx.Create(&specimens[i]).Error; err != nil {
+				return err
+			}
+		}
+		request.Specimens = specimens
+		return nil
+	})
+}
+
+// checkLabExhibit locks a registered exhibit being sent as a specimen and
+// checks it is free to go, resealing it when the specimen's seal is new
+func checkLabExhibit(tx *gorm.DB, request models.LabRequest, specimen models.LabSpecimen, reseal func(request models.LabRequest, specimen models.LabSpecimen, exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) error {
+	var exhibit models.Exhibit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&exhibit, "id = ?", specimen.ExhibitID).Error; err != nil {
+		return err
+	}
+
+	var open int64
+	if err := tx.Model(&models.LabSpecimen{}).
+		Joins("JOIN lab_requests ON lab_requests.id = lab_specimens.lab_request_id AND lab_requests.deleted_at IS NULL").
+		Where("lab_specimens.exhibit_id = ? AND lab_requests.status IN ?", exhibit.ID, models.LabOpenStatuses).
+		Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return models.ErrLabExhibitInUse
+	}
+	if exhibit.HolderType != models.HolderOfficer {
+		return models.ErrLabExhibitNotHeld
+	}
+
+	if exhibit.SealNumber == specimen.SealNumber {
+		return nil
+	}
+	_, err := ExhibitDbService(tx).AppendTransfer(exhibit.ID, func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error) {
+		return reseal(request, specimen, exhibit, last)
+	})
+	return err
+}
+
+func (r *LabRepositoryImpl) GetLabRequestByID(id string) (models.LabRequest, error) {
+	var request models.LabRequest
+	err := preloadLabRequest(r.db).First(&request, "id = ?", id).Error
+	return request, err
+}
+
+// GetLabRequestsFor lists the lab requests of one case or examination;
+// column is case_id or examination_id
+func (r *LabRepositoryImpl) GetLabRequestsFor(column string, id uint) ([]models.LabRequest, error) {
+	var requests []models.LabRequest
+	err := preloadLabRequest(r.db).Where(column+" = ?", id).Order("requested_at DESC").Find(&requests).Error
+	return requests, err
+}
+
+// GetPaginatedLabRequests lists lab requests, most recent first
+func (r *LabRepositoryImpl) GetPaginatedLabRequests(c *fiber.Ctx) (*utils.Pagination, []models.LabRequest, error) {
+	query := filterLabRequests(r.db.Model(&models.LabRequest{}), c).
+		Preload("Specimens").
+		Order("requested_at DESC")
+	for _, column := range []string{"case_id", "examination_id"} {
+		if value := c.Query(column); value != "" {
+			if _, err := strconv.Atoi(value); err == nil {
+				query = query.Where(column+" = ?", value)
+			}
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	pagination, requests, err := utils.Paginate(c, query, models.LabRequest{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, requests, nil
+}
+
+// updateLabRequestStatus applies a status change and records it in the
+// history. The change only applies while the request still has the status it
+// was read with; otherwise ErrLabStatusChanged is returned.
+func updateLabRequestStatus(tx *gorm.DB, request models.LabRequest, updates map[string]interface{}, update *models.LabRequestUpdate) error {
+	result := tx.Model(&models.LabRequest{}).Where("id = ? AND status = ?", request.ID, request.Status).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrLabStatusChanged
+	}
+	update.LabRequestID = request.ID
+	return tx.Create(update).Error
+}
+
+func (r *LabRepositoryImpl) UpdateLabRequestStatus(request models.LabRequest, updates map[string]interface{}, update *models.LabRequestUpdate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return updateLabRequestStatus(tx, request, updates, update)
+	})
+}
+
+// DispatchLabRequest marks the request dispatched and appends a transfer to
+// the lab to the chain of custody of every specimen's exhibit
+func (r *LabRepositoryImpl) DispatchLabRequest(request models.LabRequest, updates map[string]interface{}, update *models.LabRequestUpdate, build func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) error {
+	return r.transferSpecimens(request, updates, update, build)
+}
+
+// ReceiveLabRequest marks the request received and appends the lab's receipt,
+// with the state of the seals, to the chain of custody of every specimen's exhibit
+func (r *LabRepositoryImpl) ReceiveLabRequest(request models.LabRequest, updates map[string]interface{}, update *models.LabRequestUpdate, build func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) error {
+	return r.transferSpecimens(request, updates, update, build)
+}
+
+// transferSpecimens applies a status change and appends the transfer build
+// makes to every specimen's exhibit, all in one transaction
+func (r *LabRepositoryImpl) transferSpecimens(request models.LabRequest, updates map[string]interface{}, update *models.LabRequestUpdate, build func(exhibit models.Exhibit, last *models.ExhibitTransfer) (models.ExhibitTransfer, error)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := updateLabRequestStatus(tx, request, updates, update); err != nil {
+			return err
+		}
+		exhibits := ExhibitDbService(tx)
+		for _, specimen := range request.Specimens {
+			if _, err := exhibits.AppendTransfer(specimen.ExhibitID, build); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RecordLabResults stores the results and marks the request resulted
+func (r *LabRepositoryImpl) RecordLabResults(request models.LabRequest, results []models.LabResult, updates map[string]interface{}, update *models.LabRequestUpdate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&results).Error; err != nil {
+			return err
+		}
+		return updateLabRequestStatus(tx, request, updates, update)
+	})
+}
+
+// GetLabTurnaround counts requests per lab and the median time spent at
+// each stage. Requests not yet dispatched are grouped under an empty lab name.
+func (r *LabRepositoryImpl) GetLabTurnaround(c *fiber.Ctx) ([]models.LabTurnaround, error) {
+	query := filterLabRequests(r.db.Model(&models.LabRequest{}), c).
+		Select(`lab_requests.lab_name,
+			COUNT(*) AS requests,
+			COUNT(*) FILTER (WHERE lab_requests.status = ?) AS resulted,
+			COUNT(*) FILTER (WHERE lab_requests.status IN ?) AS open,
+			COUNT(*) FILTER (WHERE lab_requests.status = ?) AS rejected,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM lab_requests.dispatched_at - lab_requests.requested_at) / 3600) AS median_hours_to_dispatch,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM lab_requests.received_at - lab_requests.dispatched_at) / 86400) AS median_days_in_transit,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM lab_requests.resulted_at - lab_requests.received_at) / 86400) AS median_days_in_lab,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM lab_requests.resulted_at - lab_requests.requested_at) / 86400) AS median_days_to_result`,
+			models.LabStatusResulted, models.LabOpenStatuses, models.LabStatusRejected).
+		Group("lab_requests.lab_name").
+		Order("requests DESC, lab_requests.lab_name")
+
+	var report []models.LabTurnaround
+	err := query.Scan(&report).Error
+	return report, err
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MatchQuery carries the normalised details of a person that candidate
+// duplicates are pre-selected on. Scoring happens in the service layer.
+type MatchQuery struct {
+	ExcludeID uint
+	FirstName string
+	LastName  string
+	Dob       time.Time
+	Phone     string // digits, international form
+	Nin       string // uppercase, no separators
+	Prints    []string
+}
+
+// maxMatchCandidates caps how many rows are scored per lookup
+const maxMatchCandidates = 200
+
+// personConditions builds the OR of cheap pre-filters shared by the people
+// tables (suspects, victims): same NIN, same phone by its last nine digits,
+// same date of birth, or names starting with the same letters in either order
+func personConditions(db *gorm.DB, q MatchQuery) (*gorm.DB, bool) {
+	cond := db
+	found := false
+	or := func(query string, args ...interface{}) {
+		if found {
+			cond = cond.Or(query, args...)
+		} else {
+			cond = cond.Where(query, args...)
+			found = true
+		}
+	}
+
+	exact, args := exactConditions(q)
+	for i, query := range exact {
+		or(query, args[i])
+	}
+	if !q.Dob.IsZero() {
+		or("dob = ?", q.Dob.Format("2006-01-02"))
+	}
+	first, last := namePrefix(q.FirstName), namePrefix(q.LastName)
+	if first != "" && last != "" {
+		prefixes := []string{first, last}
+		or("LEFT(LOWER(first_name), 2) IN ? AND LEFT(LOWER(last_name), 2) IN ?", prefixes, prefixes)
+	}
+	return cond, found
+}
+
+func namePrefix(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len([]rune(name)) < 2 {
+		return ""
+	}
+	return string([]rune(name)[:2])
+}
+
+// exactConditions returns the identifier matches among the pre-filters, same
+// NIN or same phone number, with one argument each
+func exactConditions(q MatchQuery) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if q.Nin != "" {
+		conditions = append(conditions, "UPPER(REPLACE(REPLACE(nin, ' ', ''), '-', '')) = ?")
+		args = append(args, q.Nin)
+	}
+	if len(q.Phone) >= 9 {
+		conditions = append(conditions, "RIGHT(REGEXP_REPLACE(phone_number, '[^0-9]', '', 'g'), 9) = ?")
+		args = append(args, q.Phone[len(q.Phone)-9:])
+	}
+	return conditions, args
+}
+
+// exactMatchesFirst orders candidates matching q on an identifier, or on one
+// of the extra conditions, ahead of the looser date of birth and name
+// matches, so the candidate limit never cuts them off
+func exactMatchesFirst(q MatchQuery, extra ...clause.Expr) clause.OrderBy {
+	conditions, args := exactConditions(q)
+	for _, expr := range extra {
+		conditions = append(conditions, expr.SQL)
+		args = append(args, expr.Vars...)
+	}
+	if len(conditions) == 0 {
+		return clause.OrderBy{Columns: []clause.OrderByColumn{{Column: clause.Column{Name: "id"}}}}
+	}
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                "CASE WHEN " + strings.Join(conditions, " OR ") + " THEN 0 ELSE 1 END, id",
+		Vars:               args,
+		WithoutParentheses: true,
+	}}
+}
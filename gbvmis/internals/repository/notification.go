@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	CreateNotification(notification *models.Notification) error
+	GetPaginatedNotifications(c *fiber.Ctx, officerID, postID uint) (*utils.Pagination, []models.Notification, error)
+	GetNotificationByID(id string) (models.Notification, error)
+	MarkRead(id uint, at time.Time) error
+	FindOfficerByID(id uint) (models.PoliceOfficer, error)
+}
+
+type NotificationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NotificationDbService(db *gorm.DB) NotificationRepository {
+	return &NotificationRepositoryImpl{db: db}
+}
+
+// =================================
+
+func (r *NotificationRepositoryImpl) CreateNotification(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+// GetPaginatedNotifications returns notifications addressed to the officer or
+// to their police post, newest first. unread=true leaves out ones already read.
+func (r *NotificationRepositoryImpl) GetPaginatedNotifications(c *fiber.Ctx, officerID, postID uint) (*utils.Pagination, []models.Notification, error) {
+	query := r.db.Model(&models.Notification{}).
+		Where(r.db.Where("officer_id = ?", officerID).Or("police_post_id = ?", postID)).
+		Order("created_at DESC")
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	if notificationType := c.Query("type"); notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+
+	pagination, notifications, err := utils.Paginate(c, query, models.Notification{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, notifications, nil
+}
+
+func (r *NotificationRepositoryImpl) GetNotificationByID(id string) (models.Notification, error) {
+	var notification models.Notification
+	err := r.db.First(&notification, "id = ?", id).Error
+	return notification, err
+}
+
+func (r *NotificationRepositoryImpl) MarkRead(id uint, at time.Time) error {
+	return r.db.Model(&models.Notification{}).Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", at).Error
+}
+
+func (r *NotificationRepositoryImpl) FindOfficerByID(id uint) (models.PoliceOfficer, error) {
+	var officer models.PoliceOfficer
+	err := r.db.First(&officer, "id = ?", id).Error
+	return officer, err
+}
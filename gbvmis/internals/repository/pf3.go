@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Pf3Repository interface {
+	GetExaminationForPf3(id string) (models.Examination, error)
+	FindPolicePostByID(id uint) (models.PolicePost, error)
+	GetFirstIncident(caseID uint) (*models.Incident, error)
+	GetPf3Templates(form string) ([]models.Pf3Template, error)
+	GetPf3TemplateByID(id string) (models.Pf3Template, error)
+	GetActivePf3Template(form string) (*models.Pf3Template, error)
+	CreatePf3Template(template *models.Pf3Template) error
+	SetPf3TemplateActive(id uint, active bool) error
+	CreatePf3Document(document *models.Pf3Document, blob *models.FileBlob) error
+	GetPf3Documents(examinationID uint) ([]models.Pf3Document, error)
+	GetPf3DocumentByID(id string) (models.Pf3Document, error)
+	GetPf3DocumentByCode(code string) (models.Pf3Document, error)
+}
+
+type Pf3RepositoryImpl struct {
+	db *gorm.DB
+}
+
+func Pf3DbService(db *gorm.DB) Pf3Repository {
+	return &Pf3RepositoryImpl{db: db}
+}
+
+// GetExaminationForPf3 loads an examination with everything printed on the
+// form. Deleted examinations are included so issued forms still verify.
+func (r *Pf3RepositoryImpl) GetExaminationForPf3(id string) (models.Examination, error) {
+	var examination models.Examination
+	err := r.db.Unscoped().
+		Preload("Victim").
+		Preload("Case").
+		Preload("Facility").
+		Preload("Practitioner").
+		Preload("Injuries", orderInjuries).
+		Preload("CodedFindings", "deleted_at IS NULL").
+		First(&examination, "id = ?", id).Error
+	return examination, err
+}
+
+func (r *Pf3RepositoryImpl) FindPolicePostByID(id uint) (models.PolicePost, error) {
+	var post models.PolicePost
+	err := r.db.First(&post, "id = ?", id).Error
+	return post, err
+}
+
+// GetFirstIncident returns the case's earliest incident, or nil when none is
+// recorded
+func (r *Pf3RepositoryImpl) GetFirstIncident(caseID uint) (*models.Incident, error) {
+	var incidents []models.Incident
+	if err := r.db.Where("case_id = ?", caseID).Order("occurred_at, id").Limit(1).Find(&incidents).Error; err != nil {
+		return nil, err
+	}
+	if len(incidents) == 0 {
+		return nil, nil
+	}
+	return &incidents[0], nil
+}
+
+func (r *Pf3RepositoryImpl) GetPf3Templates(form string) ([]models.Pf3Template, error) {
+	var templates []models.Pf3Template
+	query := r.db.Order("form, version DESC")
+	if form != "" {
+		query = query.Where("form = ?", form)
+	}
+	err := query.Find(&templates).Error
+	return templates, err
+}
+
+func (r *Pf3RepositoryImpl) GetPf3TemplateByID(id string) (models.Pf3Template, error) {
+	var template models.Pf3Template
+	err := r.db.First(&template, "id = ?", id).Error
+	return template, err
+}
+
+// GetActivePf3Template returns the newest active version of a form's
+// template, or nil when only the built-in one applies
+func (r *Pf3RepositoryImpl) GetActivePf3Template(form string) (*models.Pf3Template, error) {
+	var templates []models.Pf3Template
+	if err := r.db.Where("form = ? AND active", form).Order("version DESC").Limit(1).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	return &templates[0], nil
+}
+
+// CreatePf3Template saves the template as the form's next version
+func (r *Pf3RepositoryImpl) CreatePf3Template(template *models.Pf3Template) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the form's versions so concurrent uploads get distinct numbers
+		var versions []int
+		if err := tx.Unscoped().Model(&models.Pf3Template{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("form = ?", template.Form).
+			Pluck("version", &versions).Error; err != nil {
+			return err
+		}
+		template.Version = 1
+		for _, version := range versions {
+			template.Version = max(template.Version, version+1)
+		}
+		return tx.Create(template).Error
+	})
+}
+
+func (r *Pf3RepositoryImpl) SetPf3TemplateActive(id uint, active bool) error {
+	return r.db.Model(&models.Pf3Template{}).Where("id = ?", id).Update("active", active).Error
+}
+
+// CreatePf3Document records the PDF's blob (once per hash) and the document
+func (r *Pf3RepositoryImpl) CreatePf3Document(document *models.Pf3Document, blob *models.FileBlob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveBlobRecord(tx, blob); err != nil {
+			return err
+		}
+		return tx.Create(document).Error
+	})
+}
+
+func (r *Pf3RepositoryImpl) GetPf3Documents(examinationID uint) ([]models.Pf3Document, error) {
+	var documents []models.Pf3Document
+	err := r.db.Where("examination_id = ?", examinationID).Order("issued_at DESC").Find(&documents).Error
+	return documents, err
+}
+
+func (r *Pf3RepositoryImpl) GetPf3DocumentByID(id string) (models.Pf3Document, error) {
+	var document models.Pf3Document
+	err := r.db.First(&document, "id = ?", id).Error
+	return document, err
+}
+
+func (r *Pf3RepositoryImpl) GetPf3DocumentByCode(code string) (models.Pf3Document, error) {
+	var document models.Pf3Document
+	err := r.db.First(&document, "verification_code = ?", code).Error
+	return document, err
+}
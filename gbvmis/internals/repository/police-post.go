@@ -15,6 +15,8 @@ type PolicePostRepository interface {
 	GetPolicePostByID(id string) (models.PolicePost, error)
 	DeleteByID(id string) error
 	SearchPaginatedPolicePosts(c *fiber.Ctx) (*utils.Pagination, []models.PolicePost, error)
+	FindAdminAreaByID(id uint) (models.AdminArea, error)
+	FindPoliceUnitByID(id uint) (models.PoliceUnit, error)
 }
 
 type PolicePostRepositoryImpl struct {
@@ -81,3 +83,13 @@ func (r *PolicePostRepositoryImpl) SearchPaginatedPolicePosts(c *fiber.Ctx) (*ut
 
 	return &pagination, policePosts, nil
 }
+
+func (r *PolicePostRepositoryImpl) FindAdminAreaByID(id uint) (models.AdminArea, error) {
+	return findAdminArea(r.db, id)
+}
+
+func (r *PolicePostRepositoryImpl) FindPoliceUnitByID(id uint) (models.PoliceUnit, error) {
+	var unit models.PoliceUnit
+	err := r.db.First(&unit, "id = ?", id).Error
+	return unit, err
+}
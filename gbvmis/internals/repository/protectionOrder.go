@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProtectionOrderRepository interface {
+	CreateOrder(order *models.ProtectionOrder) error
+	GetPaginatedOrders(c *fiber.Ctx) (*utils.Pagination, []models.ProtectionOrder, error)
+	GetOrderByID(id string) (models.ProtectionOrder, error)
+	UpdateOrder(id uint, updates map[string]interface{}) error
+	RevokeOrder(id uint, reason string, at time.Time) error
+	ExpireOrders(now time.Time) (int64, error)
+	ReportBreach(breach *models.ProtectionOrderBreach, newCase *models.Case, relationship *models.CaseRelationship, notification *models.Notification, number func(breaches int64)) error
+	GetCaseByID(id string) (models.Case, error)
+	CreateSafetyPlan(plan *models.SafetyPlan) error
+	GetVictimSafetyPlans(victimID uint) ([]models.SafetyPlan, error)
+	GetSafetyPlanByID(id string) (models.SafetyPlan, error)
+	UpdateSafetyPlan(id uint, updates map[string]interface{}) error
+	GetVictimByID(id string) (models.Victim, error)
+}
+
+type ProtectionOrderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func ProtectionOrderDbService(db *gorm.DB) ProtectionOrderRepository {
+	return &ProtectionOrderRepositoryImpl{db: db}
+}
+
+// =================================
+
+func (r *ProtectionOrderRepositoryImpl) CreateOrder(order *models.ProtectionOrder) error {
+	return r.db.Omit("Case", "Victim", "RespondentSuspect").Create(order).Error
+}
+
+func (r *ProtectionOrderRepositoryImpl) GetPaginatedOrders(c *fiber.Ctx) (*utils.Pagination, []models.ProtectionOrder, error) {
+	query := r.db.Preload("Breaches").Order("issued_on DESC")
+	for param, column := range map[string]string{
+		"case_id":    "case_id",
+		"victim_id":  "victim_id",
+		"suspect_id": "respondent_suspect_id",
+	} {
+		if value := c.Query(param); value != "" {
+			if _, err := strconv.Atoi(value); err == nil {
+				query = query.Where(column+" = ?", value)
+			}
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if court := c.Query("issuing_court"); court != "" {
+		query = query.Where("issuing_court ILIKE ?", "%"+court+"%")
+	}
+
+	pagination, orders, err := utils.Paginate(c, query, models.ProtectionOrder{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, orders, nil
+}
+
+// GetOrderByID loads the order with its breaches, its case with the case's
+// victim-suspect relationships and incidents, and its victim
+func (r *ProtectionOrderRepositoryImpl) GetOrderByID(id string) (models.ProtectionOrder, error) {
+	var order models.ProtectionOrder
+	err := r.db.
+		Preload("Breaches", func(db *gorm.DB) *gorm.DB { return db.Order("occurred_at DESC") }).
+		Preload("Case.Relationships").
+		Preload("Case.Incidents").
+		Preload("Victim").
+		Preload("RespondentSuspect", omitLegacyMedia).
+		First(&order, "id = ?", id).Error
+	return order, err
+}
+
+func (r *ProtectionOrderRepositoryImpl) UpdateOrder(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.ProtectionOrder{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *ProtectionOrderRepositoryImpl) RevokeOrder(id uint, reason string, at time.Time) error {
+	return r.db.Model(&models.ProtectionOrder{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        models.OrderStatusRevoked,
+		"revoked_at":    at,
+		"revoke_reason": reason,
+	}).Error
+}
+
+// ExpireOrders closes active orders whose validity has ended
+func (r *ProtectionOrderRepositoryImpl) ExpireOrders(now time.Time) (int64, error) {
+	result := r.db.Model(&models.ProtectionOrder{}).
+		Where("status = ? AND valid_until IS NOT NULL AND valid_until < ?", models.OrderStatusActive, now.Format("2006-01-02")).
+		Update("status", models.OrderStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+// ReportBreach opens the breach case with the order's victim and respondent,
+// copies their relationship onto it, records the breach and the alert, all in
+// one transaction. The parent case is locked while its breaches are counted
+// and number is called with the count to name the new case, so concurrent
+// reports get different case numbers.
+func (r *ProtectionOrderRepositoryImpl) ReportBreach(breach *models.ProtectionOrderBreach, newCase *models.Case, relationship *models.CaseRelationship, notification *models.Notification, number func(breaches int64)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			First(&models.Case{}, "id = ?", *newCase.ParentCaseID).Error; err != nil {
+			return err
+		}
+		var breaches int64
+		if err := tx.Unscoped().Model(&models.ProtectionOrderBreach{}).
+			Joins("JOIN protection_orders ON protection_orders.id = protection_order_breaches.order_id").
+			Where("protection_orders.case_id = ?", *newCase.ParentCaseID).
+			Count(&breaches).Error; err != nil {
+			return err
+		}
+		number(breaches)
+
+		victims, suspects := newCase.Victims, newCase.Suspects
+		if err := tx.Omit(clause.Associations).Create(newCase).Error; err != nil {
+			return err
+		}
+		for _, v := range victims {
+			if err := tx.Exec("INSERT INTO case_victims (case_id, victim_id) VALUES (?, ?)", newCase.ID, v.ID).Error; err != nil {
+				return err
+			}
+		}
+		for _, s := range suspects {
+			if err := tx.Exec("INSERT INTO case_suspects (case_id, suspect_id) VALUES (?, ?)", newCase.ID, s.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if relationship != nil {
+			relationship.CaseID = newCase.ID
+			if err := tx.Omit("Victim", "Suspect").Create(relationship).Error; err != nil {
+				return err
+			}
+		}
+
+		breach.NewCaseID = newCase.ID
+		if err := tx.Create(breach).Error; err != nil {
+			return err
+		}
+
+		notification.EntityID = newCase.ID
+		return tx.Create(notification).Error
+	})
+}
+
+func (r *ProtectionOrderRepositoryImpl) GetCaseByID(id string) (models.Case, error) {
+	var casee models.Case
+	err := r.db.Preload("Victims").Preload("Suspects", omitLegacyMedia).First(&casee, "id = ?", id).Error
+	return casee, err
+}
+
+func (r *ProtectionOrderRepositoryImpl) CreateSafetyPlan(plan *models.SafetyPlan) error {
+	return r.db.Create(plan).Error
+}
+
+// GetVictimSafetyPlans returns the victim's plans, the one in use first
+func (r *ProtectionOrderRepositoryImpl) GetVictimSafetyPlans(victimID uint) ([]models.SafetyPlan, error) {
+	var plans []models.SafetyPlan
+	err := r.db.Where("victim_id = ?", victimID).Order("created_at DESC").Find(&plans).Error
+	return plans, err
+}
+
+func (r *ProtectionOrderRepositoryImpl) GetSafetyPlanByID(id string) (models.SafetyPlan, error) {
+	var plan models.SafetyPlan
+	err := r.db.First(&plan, "id = ?", id).Error
+	return plan, err
+}
+
+func (r *ProtectionOrderRepositoryImpl) UpdateSafetyPlan(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.SafetyPlan{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *ProtectionOrderRepositoryImpl) GetVictimByID(id string) (models.Victim, error) {
+	var victim models.Victim
+	err := r.db.First(&victim, "id = ?", id).Error
+	return victim, err
+}
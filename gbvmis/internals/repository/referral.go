@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ReferralRepository interface {
+	CreateProvider(provider *models.ServiceProvider) error
+	GetPaginatedProviders(c *fiber.Ctx) (*utils.Pagination, []models.ServiceProvider, error)
+	GetProviderByID(id string) (models.ServiceProvider, error)
+	UpdateProvider(id uint, updates map[string]interface{}) error
+	DeleteProviderByID(id uint) error
+	CreateReferral(referral *models.Referral) error
+	GetPaginatedReferrals(c *fiber.Ctx) (*utils.Pagination, []models.Referral, error)
+	GetReferralByID(id string) (models.Referral, error)
+	GetReferralsFor(column string, id uint) ([]models.Referral, error)
+	UpdateReferralStatus(referral models.Referral, updates map[string]interface{}, update *models.ReferralUpdate) error
+	UpdateReferral(id uint, updates map[string]interface{}) error
+	GetCompletionReport(c *fiber.Ctx) ([]models.ReferralCompletion, error)
+	GetCaseByID(id string) (models.Case, error)
+	GetExaminationByID(id string) (models.Examination, error)
+}
+
+type ReferralRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func ReferralDbService(db *gorm.DB) ReferralRepository {
+	return &ReferralRepositoryImpl{db: db}
+}
+
+// =================================
+
+func (r *ReferralRepositoryImpl) CreateProvider(provider *models.ServiceProvider) error {
+	return r.db.Create(provider).Error
+}
+
+func (r *ReferralRepositoryImpl) GetPaginatedProviders(c *fiber.Ctx) (*utils.Pagination, []models.ServiceProvider, error) {
+	query := r.db.Model(&models.ServiceProvider{}).Order("name")
+	if name := c.Query("name"); name != "" {
+		query = query.Where("name ILIKE ?", "%"+name+"%")
+	}
+	if providerType := c.Query("provider_type"); providerType != "" {
+		query = query.Where("provider_type = ?", providerType)
+	}
+	if district := c.Query("district"); district != "" {
+		query = query.Where("district ILIKE ?", "%"+district+"%")
+	}
+	if location := c.Query("location"); location != "" {
+		query = query.Where("location ILIKE ?", "%"+location+"%")
+	}
+	if active := c.Query("active"); active != "" {
+		if b, err := strconv.ParseBool(active); err == nil {
+			query = query.Where("active = ?", b)
+		}
+	}
+
+	pagination, providers, err := utils.Paginate(c, query, models.ServiceProvider{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, providers, nil
+}
+
+func (r *ReferralRepositoryImpl) GetProviderByID(id string) (models.ServiceProvider, error) {
+	var provider models.ServiceProvider
+	err := r.db.First(&provider, "id = ?", id).Error
+	return provider, err
+}
+
+func (r *ReferralRepositoryImpl) UpdateProvider(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.ServiceProvider{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *ReferralRepositoryImpl) DeleteProviderByID(id uint) error {
+	return r.db.Delete(&models.ServiceProvider{}, id).Error
+}
+
+func (r *ReferralRepositoryImpl) CreateReferral(referral *models.Referral) error {
+	return r.db.Omit("Provider", "Victim").Create(referral).Error
+}
+
+func (r *ReferralRepositoryImpl) GetPaginatedReferrals(c *fiber.Ctx) (*utils.Pagination, []models.Referral, error) {
+	query := r.db.Preload("Provider").Order("sent_at DESC")
+	for _, column := range []string{"provider_id", "police_post_id", "victim_id", "case_id"} {
+		if value := c.Query(column); value != "" {
+			if _, err := strconv.Atoi(value); err == nil {
+				query = query.Where(column+" = ?", value)
+			}
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	pagination, referrals, err := utils.Paginate(c, query, models.Referral{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, referrals, nil
+}
+
+func (r *ReferralRepositoryImpl) GetReferralByID(id string) (models.Referral, error) {
+	var referral models.Referral
+	err := r.db.Preload("Provider").
+		Preload("Updates", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		First(&referral, "id = ?", id).Error
+	return referral, err
+}
+
+// GetReferralsFor lists the referrals made from one case or examination;
+// column is case_id or examination_id
+func (r *ReferralRepositoryImpl) GetReferralsFor(column string, id uint) ([]models.Referral, error) {
+	var referrals []models.Referral
+	err := r.db.Preload("Provider").Where(column+" = ?", id).Order("sent_at DESC").Find(&referrals).Error
+	return referrals, err
+}
+
+// UpdateReferralStatus applies a status change and records it in the
+// history. The change only applies while the referral still has the status it
+// was read with; otherwise ErrReferralStatusChanged is returned.
+func (r *ReferralRepositoryImpl) UpdateReferralStatus(referral models.Referral, updates map[string]interface{}, update *models.ReferralUpdate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Referral{}).Where("id = ? AND status = ?", referral.ID, referral.Status).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrReferralStatusChanged
+		}
+		update.ReferralID = referral.ID
+		return tx.Create(update).Error
+	})
+}
+
+func (r *ReferralRepositoryImpl) UpdateReferral(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.Referral{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetCompletionReport counts referrals and their outcomes per referring
+// station, for referrals sent within the optional from/to dates
+func (r *ReferralRepositoryImpl) GetCompletionReport(c *fiber.Ctx) ([]models.ReferralCompletion, error) {
+	query := r.db.Model(&models.Referral{}).
+		Select(`referrals.police_post_id,
+			police_posts.name AS police_post,
+			COUNT(*) AS total,
+			COUNT(referrals.accepted_at) AS accepted,
+			COUNT(*) FILTER (WHERE referrals.status = ?) AS declined,
+			COUNT(referrals.attended_at) AS attended,
+			COUNT(referrals.closed_at) AS closed`, models.ReferralStatusDeclined).
+		Joins("LEFT JOIN police_posts ON police_posts.id = referrals.police_post_id").
+		Group("referrals.police_post_id, police_posts.name").
+		Order("police_posts.name")
+
+	if from := c.Query("from"); from != "" {
+		query = query.Where("referrals.sent_at >= ?", from)
+	}
+	if to := c.Query("to"); to != "" {
+		query = query.Where("referrals.sent_at < (?::date + 1)", to)
+	}
+	if providerType := c.Query("provider_type"); providerType != "" {
+		query = query.Joins("JOIN service_providers ON service_providers.id = referrals.provider_id").
+			Where("service_providers.provider_type = ?", providerType)
+	}
+
+	var report []models.ReferralCompletion
+	if err := query.Scan(&report).Error; err != nil {
+		return nil, err
+	}
+	for i := range report {
+		if report[i].Total > 0 {
+			report[i].CompletionRate = float64(report[i].Attended) / float64(report[i].Total)
+		}
+	}
+	return report, nil
+}
+
+func (r *ReferralRepositoryImpl) GetCaseByID(id string) (models.Case, error) {
+	var casee models.Case
+	err := r.db.Preload("Victims.Guardians").Preload("Incidents").First(&casee, "id = ?", id).Error
+	return casee, err
+}
+
+func (r *ReferralRepositoryImpl) GetExaminationByID(id string) (models.Examination, error) {
+	var examination models.Examination
+	err := r.db.Preload("Case").First(&examination, "id = ?", id).Error
+	return examination, err
+}
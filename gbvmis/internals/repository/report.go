@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ReportRepository interface {
+	CreateReportDefinition(definition *models.ReportDefinition) error
+	GetPaginatedReportDefinitions(c *fiber.Ctx) (*utils.Pagination, []models.ReportDefinition, error)
+	GetReportDefinitionByID(id string) (models.ReportDefinition, error)
+	UpdateReportDefinition(id uint, updates map[string]interface{}) error
+	DeleteReportDefinition(id uint) error
+	GetDueReportDefinitions(now time.Time) ([]models.ReportDefinition, error)
+	SetReportDefinitionRun(id uint, lastRun time.Time, nextRun *time.Time) error
+	FindPolicePostByID(id uint) (models.PolicePost, error)
+	GetPolicePosts() ([]models.PolicePost, error)
+	CreateGeneratedReport(report *models.GeneratedReport, blob *models.FileBlob) error
+	GetPaginatedGeneratedReports(c *fiber.Ctx) (*utils.Pagination, []models.GeneratedReport, error)
+	GetGeneratedReportByID(id string) (models.GeneratedReport, error)
+}
+
+type ReportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func ReportDbService(db *gorm.DB) ReportRepository {
+	return &ReportRepositoryImpl{db: db}
+}
+
+func (r *ReportRepositoryImpl) CreateReportDefinition(definition *models.ReportDefinition) error {
+	return r.db.Create(definition).Error
+}
+
+func (r *ReportRepositoryImpl) GetPaginatedReportDefinitions(c *fiber.Ctx) (*utils.Pagination, []models.ReportDefinition, error) {
+	query := r.db.Model(&models.ReportDefinition{}).Order("name")
+	if active := c.Query("active"); active != "" {
+		query = query.Where("active = ?", active == "true")
+	}
+
+	pagination, definitions, err := utils.Paginate(c, query, models.ReportDefinition{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, definitions, nil
+}
+
+func (r *ReportRepositoryImpl) GetReportDefinitionByID(id string) (models.ReportDefinition, error) {
+	var definition models.ReportDefinition
+	err := r.db.First(&definition, "id = ?", id).Error
+	return definition, err
+}
+
+func (r *ReportRepositoryImpl) UpdateReportDefinition(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.ReportDefinition{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteReportDefinition removes the definition; reports already generated
+// stay downloadable
+func (r *ReportRepositoryImpl) DeleteReportDefinition(id uint) error {
+	return r.db.Delete(&models.ReportDefinition{}, id).Error
+}
+
+// GetDueReportDefinitions returns the active definitions whose next run is
+// at or before now
+func (r *ReportRepositoryImpl) GetDueReportDefinitions(now time.Time) ([]models.ReportDefinition, error) {
+	var definitions []models.ReportDefinition
+	err := r.db.Where("active = ? AND next_run_at <= ?", true, now).Order("next_run_at").Find(&definitions).Error
+	return definitions, err
+}
+
+func (r *ReportRepositoryImpl) SetReportDefinitionRun(id uint, lastRun time.Time, nextRun *time.Time) error {
+	return r.db.Model(&models.ReportDefinition{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_run_at": lastRun, "next_run_at": nextRun}).Error
+}
+
+func (r *ReportRepositoryImpl) FindPolicePostByID(id uint) (models.PolicePost, error) {
+	var post models.PolicePost
+	err := r.db.First(&post, "id = ?", id).Error
+	return post, err
+}
+
+func (r *ReportRepositoryImpl) GetPolicePosts() ([]models.PolicePost, error) {
+	var posts []models.PolicePost
+	err := r.db.Order("name").Find(&posts).Error
+	return posts, err
+}
+
+// CreateGeneratedReport records the blob (once per hash), if the report has
+// one, and the report pointing at it
+func (r *ReportRepositoryImpl) CreateGeneratedReport(report *models.GeneratedReport, blob *models.FileBlob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if blob != nil {
+			if err := saveBlobRecord(tx, blob); err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Definition").Create(report).Error
+	})
+}
+
+// GetPaginatedGeneratedReports lists generated reports, newest period first.
+// Filters: definition_id, police_post_id, format, status and period_start.
+func (r *ReportRepositoryImpl) GetPaginatedGeneratedReports(c *fiber.Ctx) (*utils.Pagination, []models.GeneratedReport, error) {
+	query := r.db.Model(&models.GeneratedReport{}).Order("period_start DESC, created_at DESC")
+	for _, column := range []string{"definition_id", "police_post_id"} {
+		if value := c.Query(column); value != "" {
+			if _, err := strconv.Atoi(value); err == nil {
+				query = query.Where(column+" = ?", value)
+			}
+		}
+	}
+	if format := c.Query("format"); format != "" {
+		query = query.Where("format = ?", format)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if start := c.Query("period_start"); start != "" {
+		query = query.Where("period_start = ?", start)
+	}
+
+	pagination, reports, err := utils.Paginate(c, query, models.GeneratedReport{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, reports, nil
+}
+
+func (r *ReportRepositoryImpl) GetGeneratedReportByID(id string) (models.GeneratedReport, error) {
+	var report models.GeneratedReport
+	err := r.db.First(&report, "id = ?", id).Error
+	return report, err
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RiskAssessmentRepository interface {
+	CreateQuestionnaire(questionnaire *models.RiskQuestionnaire) error
+	GetPaginatedQuestionnaires(c *fiber.Ctx) (*utils.Pagination, []models.RiskQuestionnaire, error)
+	GetQuestionnaireByID(id string) (models.RiskQuestionnaire, error)
+	GetActiveQuestionnaire(name string) (models.RiskQuestionnaire, error)
+	ActivateQuestionnaire(questionnaire models.RiskQuestionnaire) error
+	GetCaseByID(id string) (models.Case, error)
+	CreateAssessment(assessment *models.RiskAssessment, caseRisk func(levels []string) (string, string)) error
+	GetAssessmentByID(id string) (models.RiskAssessment, error)
+	GetCaseAssessments(caseID uint, victimID string) ([]models.RiskAssessment, error)
+	GetPaginatedHighRiskCases(c *fiber.Ctx) (*utils.Pagination, []models.Case, error)
+}
+
+type RiskAssessmentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func RiskAssessmentDbService(db *gorm.DB) RiskAssessmentRepository {
+	return &RiskAssessmentRepositoryImpl{db: db}
+}
+
+// =================================
+
+func orderedQuestions(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
+// CreateQuestionnaire stores the next version of the named questionnaire.
+// If it is created active, earlier versions are deactivated.
+func (r *RiskAssessmentRepositoryImpl) CreateQuestionnaire(questionnaire *models.RiskQuestionnaire) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Unscoped().Model(&models.RiskQuestionnaire{}).Where("name = ?", questionnaire.Name).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		questionnaire.Version = latest + 1
+
+		if questionnaire.Active {
+			if err := tx.Model(&models.RiskQuestionnaire{}).Where("name = ?", questionnaire.Name).
+				Update("active", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(questionnaire).Error
+	})
+}
+
+func (r *RiskAssessmentRepositoryImpl) GetPaginatedQuestionnaires(c *fiber.Ctx) (*utils.Pagination, []models.RiskQuestionnaire, error) {
+	query := r.db.Preload("Questions", orderedQuestions).Order("name, version DESC")
+	if name := c.Query("name"); name != "" {
+		query = query.Where("name ILIKE ?", "%"+name+"%")
+	}
+	if active := c.Query("active"); active != "" {
+		if b, err := strconv.ParseBool(active); err == nil {
+			query = query.Where("active = ?", b)
+		}
+	}
+
+	pagination, questionnaires, err := utils.Paginate(c, query, models.RiskQuestionnaire{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, questionnaires, nil
+}
+
+func (r *RiskAssessmentRepositoryImpl) GetQuestionnaireByID(id string) (models.RiskQuestionnaire, error) {
+	var questionnaire models.RiskQuestionnaire
+	err := r.db.Preload("Questions", orderedQuestions).First(&questionnaire, "id = ?", id).Error
+	return questionnaire, err
+}
+
+// GetActiveQuestionnaire returns the active version of the named
+// questionnaire, or the most recently created active one if name is empty
+func (r *RiskAssessmentRepositoryImpl) GetActiveQuestionnaire(name string) (models.RiskQuestionnaire, error) {
+	var questionnaire models.RiskQuestionnaire
+	query := r.db.Preload("Questions", orderedQuestions).Where("active = ?", true)
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	err := query.Order("created_at DESC").First(&questionnaire).Error
+	return questionnaire, err
+}
+
+// ActivateQuestionnaire makes this version the only active one of its name
+func (r *RiskAssessmentRepositoryImpl) ActivateQuestionnaire(questionnaire models.RiskQuestionnaire) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RiskQuestionnaire{}).Where("name = ? AND id <> ?", questionnaire.Name, questionnaire.ID).
+			Update("active", false).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RiskQuestionnaire{}).Where("id = ?", questionnaire.ID).Update("active", true).Error
+	})
+}
+
+func (r *RiskAssessmentRepositoryImpl) GetCaseByID(id string) (models.Case, error) {
+	var casee models.Case
+	err := r.db.Preload("Victims").Preload("Relationships").First(&casee, "id = ?", id).Error
+	return casee, err
+}
+
+// CreateAssessment stores the assessment and, in the same transaction, sets
+// the case's risk level and priority to what caseRisk makes of the latest
+// level of each victim in the case. The case is locked first so concurrent
+// assessments see each other's levels.
+func (r *RiskAssessmentRepositoryImpl) CreateAssessment(assessment *models.RiskAssessment, caseRisk func(levels []string) (string, string)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			First(&models.Case{}, "id = ?", assessment.CaseID).Error; err != nil {
+			return err
+		}
+		if err := tx.Omit("Questionnaire", "AssessedBy").Create(assessment).Error; err != nil {
+			return err
+		}
+
+		var levels []string
+		if err := tx.Raw(`SELECT DISTINCT ON (victim_id) risk_level FROM risk_assessments
+			WHERE case_id = ? AND deleted_at IS NULL
+			ORDER BY victim_id, assessed_at DESC, id DESC`, assessment.CaseID).Scan(&levels).Error; err != nil {
+			return err
+		}
+
+		riskLevel, priority := caseRisk(levels)
+		return tx.Model(&models.Case{}).Where("id = ?", assessment.CaseID).Updates(map[string]interface{}{
+			"risk_level": riskLevel,
+			"priority":   priority,
+		}).Error
+	})
+}
+
+func (r *RiskAssessmentRepositoryImpl) GetAssessmentByID(id string) (models.RiskAssessment, error) {
+	var assessment models.RiskAssessment
+	err := r.db.Preload("Answers").First(&assessment, "id = ?", id).Error
+	return assessment, err
+}
+
+// GetCaseAssessments returns the case's assessments, newest first, optionally
+// for one victim only
+func (r *RiskAssessmentRepositoryImpl) GetCaseAssessments(caseID uint, victimID string) ([]models.RiskAssessment, error) {
+	query := r.db.Preload("Answers").Where("case_id = ?", caseID).Order("assessed_at DESC")
+	if victimID != "" {
+		if _, err := strconv.Atoi(victimID); err == nil {
+			query = query.Where("victim_id = ?", victimID)
+		}
+	}
+
+	var assessments []models.RiskAssessment
+	err := query.Find(&assessments).Error
+	return assessments, err
+}
+
+// GetPaginatedHighRiskCases lists open cases at high or extreme risk, the most
+// dangerous and oldest first, optionally for one police post
+func (r *RiskAssessmentRepositoryImpl) GetPaginatedHighRiskCases(c *fiber.Ctx) (*utils.Pagination, []models.Case, error) {
+	query := withCaseRelations(r.db).
+		Where("risk_level IN ?", []string{models.RiskLevelHigh, models.RiskLevelExtreme}).
+		Where("LOWER(status) NOT IN ?", models.CaseClosedStatuses).
+		Order("CASE risk_level WHEN 'extreme' THEN 0 ELSE 1 END, date_opened")
+
+	if postID := c.Query("police_post_id"); postID != "" {
+		if _, err := strconv.Atoi(postID); err == nil {
+			query = query.Where("police_post_id = ?", postID)
+		}
+	}
+
+	pagination, cases, err := utils.Paginate(c, query, models.Case{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, cases, nil
+}
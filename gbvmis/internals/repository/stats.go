@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type StatsRepository interface {
+	GetCasesByStatus(filter models.StatsFilter) ([]models.StatCount, error)
+	GetCasesByCharge(filter models.StatsFilter) ([]models.StatCount, error)
+	GetCasesByStation(filter models.StatsFilter) ([]models.StationStat, error)
+	GetCasesByDistrict(filter models.StatsFilter) ([]models.StatCount, error)
+	GetCasesByMonth(filter models.StatsFilter) ([]models.MonthlyStat, error)
+	GetVictimsByAgeBand(filter models.StatsFilter) ([]models.AgeBandStat, error)
+	GetRelationshipStats(filter models.StatsFilter) ([]models.RelationshipStat, error)
+	GetTimeliness(filter models.StatsFilter) ([]models.TimelinessStat, error)
+}
+
+type StatsRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func StatsDbService(db *gorm.DB) StatsRepository {
+	return &StatsRepositoryImpl{db: db}
+}
+
+// =================================
+
+// StatsFilterFromQuery reads the filters shared by all statistics from the
+// query string: police_post_id, and from and to on the opening date. A from
+// or to that is not a date gives models.ErrStatsDate.
+func StatsFilterFromQuery(c *fiber.Ctx) (models.StatsFilter, error) {
+	var filter models.StatsFilter
+	for _, date := range []struct {
+		param string
+		value *string
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		v := c.Query(date.param)
+		if v == "" {
+			continue
+		}
+		parsed, err := utils.ParseDate(v)
+		if err != nil {
+			return filter, fmt.Errorf("%w: %s=%q", models.ErrStatsDate, date.param, v)
+		}
+		*date.value = parsed.Format("2006-01-02")
+	}
+	if postID, err := strconv.Atoi(c.Query("police_post_id")); err == nil {
+		id := uint(postID)
+		filter.PolicePostID = &id
+	}
+	return filter, nil
+}
+
+// filterStatsCases limits a query joined to cases by the filter
+func filterStatsCases(query *gorm.DB, filter models.StatsFilter) *gorm.DB {
+	if filter.PolicePostID != nil {
+		query = query.Where("cases.police_post_id = ?", *filter.PolicePostID)
+	}
+	if filter.From != "" {
+		query = query.Where("cases.date_opened >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("cases.date_opened <= ?", filter.To)
+	}
+	return query
+}
+
+// statsCases selects the live cases matching the filter
+func statsCases(db *gorm.DB, filter models.StatsFilter) *gorm.DB {
+	return filterStatsCases(db.Table("cases").Where("cases.deleted_at IS NULL"), filter)
+}
+
+// caseStatus labels cases without a status as unknown
+const caseStatus = "COALESCE(NULLIF(cases.status, ''), 'unknown')"
+
+func (r *StatsRepositoryImpl) GetCasesByStatus(filter models.StatsFilter) ([]models.StatCount, error) {
+	var stats []models.StatCount
+	err := statsCases(r.db, filter).
+		Select(caseStatus + " AS key, COUNT(*) AS cases").
+		Group("key").Order("cases DESC, key").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// GetCasesByCharge counts cases by charge; a case with several charges is
+// counted under each
+func (r *StatsRepositoryImpl) GetCasesByCharge(filter models.StatsFilter) ([]models.StatCount, error) {
+	var stats []models.StatCount
+	err := statsCases(r.db, filter).
+		Select("charges.id, charges.charge_title AS key, COUNT(*) AS cases").
+		Joins("JOIN case_charges ON case_charges.case_id = cases.id").
+		Joins("JOIN charges ON charges.id = case_charges.charge_id AND charges.deleted_at IS NULL").
+		Group("charges.id, charges.charge_title").Order("cases DESC, key").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *StatsRepositoryImpl) GetCasesByStation(filter models.StatsFilter) ([]models.StationStat, error) {
+	var stats []models.StationStat
+	err := statsCases(r.db, filter).
+		Select(`cases.police_post_id, police_posts.name AS police_post,
+			police_posts.station_id, COALESCE(police_units.name, '') AS station, COUNT(*) AS cases`).
+		Joins("JOIN police_posts ON police_posts.id = cases.police_post_id").
+		Joins("LEFT JOIN police_units ON police_units.id = police_posts.station_id AND police_units.deleted_at IS NULL").
+		Group("cases.police_post_id, police_posts.name, police_posts.station_id, police_units.name").
+		Order("cases DESC, police_post").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// GetCasesByDistrict counts cases by the district of their earliest
+// incident; cases without incidents are counted as unknown
+func (r *StatsRepositoryImpl) GetCasesByDistrict(filter models.StatsFilter) ([]models.StatCount, error) {
+	var stats []models.StatCount
+	err := statsCases(r.db, filter).
+		Select("COALESCE(NULLIF(incident.district, ''), 'unknown') AS key, COUNT(*) AS cases").
+		Joins(`LEFT JOIN LATERAL (SELECT incidents.district FROM incidents
+			WHERE incidents.case_id = cases.id AND incidents.deleted_at IS NULL
+			ORDER BY incidents.occurred_at, incidents.id LIMIT 1) AS incident ON true`).
+		Group("key").Order("cases DESC, key").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *StatsRepositoryImpl) GetCasesByMonth(filter models.StatsFilter) ([]models.MonthlyStat, error) {
+	var stats []models.MonthlyStat
+	err := statsCases(r.db, filter).
+		Select("to_char(cases.date_opened, 'YYYY-MM') AS month, " + caseStatus + " AS status, COUNT(*) AS cases").
+		Group("month, status").Order("month, status").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *StatsRepositoryImpl) GetVictimsByAgeBand(filter models.StatsFilter) ([]models.AgeBandStat, error) {
+	return ageBandStats(r.db, filter)
+}
+
+func (r *StatsRepositoryImpl) GetRelationshipStats(filter models.StatsFilter) ([]models.RelationshipStat, error) {
+	return relationshipStats(r.db, filter)
+}
+
+// GetTimeliness computes the median time to arrest and to court. Arrests
+// made before the case was opened count as zero days.
+func (r *StatsRepositoryImpl) GetTimeliness(filter models.StatsFilter) ([]models.TimelinessStat, error) {
+	scoped := statsCases(r.db, filter).Select("cases.id, cases.date_opened")
+
+	var stats []models.TimelinessStat
+	err := r.db.Raw(`WITH scoped AS (?),
+		arrest AS (
+			SELECT GREATEST(0, MIN(arrests.arrest_date) - scoped.date_opened) AS value
+			FROM scoped JOIN arrests ON arrests.case_id = scoped.id AND arrests.deleted_at IS NULL
+			GROUP BY scoped.id, scoped.date_opened
+		), court AS (
+			SELECT GREATEST(0, MIN(custody_records.produced_in_court_at)::date - scoped.date_opened) AS value
+			FROM scoped JOIN custody_records ON custody_records.case_id = scoped.id
+				AND custody_records.deleted_at IS NULL AND custody_records.produced_in_court_at IS NOT NULL
+			GROUP BY scoped.id, scoped.date_opened
+		), detention AS (
+			SELECT EXTRACT(EPOCH FROM custody_records.produced_in_court_at - custody_records.detention_start) / 3600 AS value
+			FROM scoped JOIN custody_records ON custody_records.case_id = scoped.id
+				AND custody_records.deleted_at IS NULL AND custody_records.produced_in_court_at IS NOT NULL
+		)
+		SELECT ? AS measure, 'days' AS unit, COUNT(*) AS count, percentile_cont(0.5) WITHIN GROUP (ORDER BY value) AS median FROM arrest
+		UNION ALL
+		SELECT ?, 'days', COUNT(*), percentile_cont(0.5) WITHIN GROUP (ORDER BY value) FROM court
+		UNION ALL
+		SELECT ?, 'hours', COUNT(*), percentile_cont(0.5) WITHIN GROUP (ORDER BY value) FROM detention`,
+		scoped, models.MeasureReportToArrest, models.MeasureReportToCourt, models.MeasureArrestToCourt).
+		Scan(&stats).Error
+	return stats, err
+}
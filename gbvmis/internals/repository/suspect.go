@@ -1,20 +1,51 @@
 package repository
 
 import (
+	"time"
+
 	"gbvmis/internals/models"
 	"gbvmis/internals/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SuspectRepository interface {
-	CreateSuspect(suspect *models.Suspect) error
+	CreateSuspect(suspect *models.Suspect, blobs ...*models.FileBlob) error
 	GetPaginatedSuspects(c *fiber.Ctx) (*utils.Pagination, []models.Suspect, error)
 	UpdateSuspect(id string, updates map[string]interface{}) error
 	GetSuspectByID(id string) (models.Suspect, error)
 	DeleteByID(id string) error
 	SearchPaginatedSuspects(c *fiber.Ctx) (*utils.Pagination, []models.Suspect, error)
+	AddSuspectMedia(media *models.SuspectMedia, blobs ...*models.FileBlob) error
+	ListSuspectMedia(suspectID uint) ([]models.SuspectMedia, error)
+	GetSuspectMediaByID(id string) (models.SuspectMedia, error)
+	DeleteSuspectMedia(media *models.SuspectMedia) error
+	FindSuspectsWithLegacyMedia(afterID uint, limit int) ([]models.Suspect, error)
+	ClearLegacyMedia(suspectID uint, column string) error
+	MoveLegacyMedia(media *models.SuspectMedia, column string, blobs ...*models.FileBlob) error
+	FindSuspectMatchCandidates(q MatchQuery) ([]models.Suspect, error)
+	GetPriorCases(suspectID uint) ([]models.Case, error)
+	MergeSuspects(keepID, duplicateID uint, fill map[string]interface{}) error
+	CreateSuspectAlias(alias *models.SuspectAlias) error
+	DeleteSuspectAlias(id string) error
+	CreateSuspectMark(mark *models.SuspectMark) error
+	DeleteSuspectMark(id string) error
+	CreateSuspectAssociate(associate *models.SuspectAssociate) error
+	DeleteSuspectAssociate(id string) error
+}
+
+// legacyMediaColumns are the old inline bytea columns. They are never read
+// on normal queries so lists stay small.
+var legacyMediaColumns = []string{"photo", "fingerprints"}
+
+func omitLegacyMedia(db *gorm.DB) *gorm.DB {
+	return db.Omit(legacyMediaColumns...)
+}
+
+func suspectMediaPreload(db *gorm.DB) *gorm.DB {
+	return db.Order("kind ASC, captured_at DESC")
 }
 
 type SuspectRepositoryImpl struct {
@@ -26,15 +57,32 @@ func SuspectDbService(db *gorm.DB) SuspectRepository {
 }
 
 // =====================
-func (r *SuspectRepositoryImpl) CreateSuspect(suspect *models.Suspect) error {
-	// Create the suspect record in the database
-	result := r.db.Create(suspect)
-	return result.Error
+// CreateSuspect records the suspect, their aliases and any media rows in
+// suspect.Media in one transaction, along with the media's blob records
+func (r *SuspectRepositoryImpl) CreateSuspect(suspect *models.Suspect, blobs ...*models.FileBlob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, blob := range blobs {
+			if err := saveBlobRecord(tx, blob); err != nil {
+				return err
+			}
+		}
+		if err := tx.Omit("Media").Create(suspect).Error; err != nil {
+			return err
+		}
+		for i := range suspect.Media {
+			suspect.Media[i].SuspectID = suspect.ID
+			if err := tx.Omit("Blob").Create(&suspect.Media[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *SuspectRepositoryImpl) GetPaginatedSuspects(c *fiber.Ctx) (*utils.Pagination, []models.Suspect, error) {
-	pagination, suspects, err := utils.Paginate(c, r.db.
-		Preload("Cases").Preload("Arrests"), models.Suspect{})
+	pagination, suspects, err := utils.Paginate(c, r.db.Omit(legacyMediaColumns...).
+		Preload("Cases").Preload("Arrests").Preload("Media", suspectMediaPreload).
+		Preload("Aliases"), models.Suspect{})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -43,8 +91,11 @@ func (r *SuspectRepositoryImpl) GetPaginatedSuspects(c *fiber.Ctx) (*utils.Pagin
 
 func (r *SuspectRepositoryImpl) GetSuspectByID(id string) (models.Suspect, error) {
 	var suspect models.Suspect
-	err := r.db.
-		Preload("Cases").Preload("Arrests").First(&suspect, "id = ?", id).Error
+	err := r.db.Omit(legacyMediaColumns...).
+		Preload("Cases").Preload("Arrests").Preload("Media", suspectMediaPreload).
+		Preload("Aliases").Preload("Marks").Preload("Associates").
+		Preload("Associates.AssociateSuspect", omitLegacyMedia).
+		First(&suspect, "id = ?", id).Error
 	return suspect, err
 }
 
@@ -71,10 +122,15 @@ func (r *SuspectRepositoryImpl) SearchPaginatedSuspects(c *fiber.Ctx) (*utils.Pa
 	Nationality := c.Query("nationality")
 	Occupation := c.Query("occupation")
 	Status := c.Query("status")
+	Name := c.Query("name")
+	Alias := c.Query("alias")
+	Relationship := c.Query("relationship_to_victim")
+	Mark := c.Query("mark")
 
 	// Start building the query
-	query := r.db.
-		Preload("Cases").Preload("Arrests").Model(&models.Charge{})
+	query := r.db.Omit(legacyMediaColumns...).
+		Preload("Cases").Preload("Arrests").Preload("Media", suspectMediaPreload).
+		Preload("Aliases").Model(&models.Suspect{})
 
 	// Apply filters based on provided parameters
 	if FirstName != "" {
@@ -104,6 +160,27 @@ func (r *SuspectRepositoryImpl) SearchPaginatedSuspects(c *fiber.Ctx) (*utils.Pa
 	if Status != "" {
 		query = query.Where("status ILIKE ?", "%"+Status+"%")
 	}
+	if Alias != "" {
+		query = query.Where("id IN (?)", r.db.Model(&models.SuspectAlias{}).Select("suspect_id").
+			Where("alias ILIKE ?", "%"+Alias+"%"))
+	}
+	if Name != "" {
+		// Any part of the name, or any alias or nickname
+		query = query.Where(r.db.
+			Where("first_name ILIKE ?", "%"+Name+"%").
+			Or("middle_name ILIKE ?", "%"+Name+"%").
+			Or("last_name ILIKE ?", "%"+Name+"%").
+			Or("id IN (?)", r.db.Model(&models.SuspectAlias{}).Select("suspect_id").
+				Where("alias ILIKE ?", "%"+Name+"%")))
+	}
+	if Relationship != "" {
+		query = query.Where("id IN (?)", r.db.Model(&models.CaseRelationship{}).Select("suspect_id").
+			Where("relationship_type = ?", Relationship))
+	}
+	if Mark != "" {
+		query = query.Where("id IN (?)", r.db.Model(&models.SuspectMark{}).Select("suspect_id").
+			Where("description ILIKE ? OR body_location ILIKE ?", "%"+Mark+"%", "%"+Mark+"%"))
+	}
 
 	// Call the pagination helper
 	pagination, suspects, err := utils.Paginate(c, query, models.Suspect{})
@@ -113,3 +190,225 @@ func (r *SuspectRepositoryImpl) SearchPaginatedSuspects(c *fiber.Ctx) (*utils.Pa
 
 	return &pagination, suspects, nil
 }
+
+// AddSuspectMedia records the blobs (once per hash) and the media row pointing at them
+func (r *SuspectRepositoryImpl) AddSuspectMedia(media *models.SuspectMedia, blobs ...*models.FileBlob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, blob := range blobs {
+			if err := saveBlobRecord(tx, blob); err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Blob").Create(media).Error
+	})
+}
+
+func (r *SuspectRepositoryImpl) ListSuspectMedia(suspectID uint) ([]models.SuspectMedia, error) {
+	var media []models.SuspectMedia
+	err := suspectMediaPreload(r.db.Where("suspect_id = ?", suspectID)).Find(&media).Error
+	return media, err
+}
+
+func (r *SuspectRepositoryImpl) GetSuspectMediaByID(id string) (models.SuspectMedia, error) {
+	var media models.SuspectMedia
+	err := r.db.Preload("Blob").First(&media, "id = ?", id).Error
+	return media, err
+}
+
+// DeleteSuspectMedia removes the media row and marks any blobs no longer
+// referenced orphaned for collection
+func (r *SuspectRepositoryImpl) DeleteSuspectMedia(media *models.SuspectMedia) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.SuspectMedia{}, media.ID).Error; err != nil {
+			return err
+		}
+		for _, sha := range []string{media.BlobSHA256, media.ThumbnailSHA256} {
+			if err := releaseBlob(tx, sha); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindSuspectsWithLegacyMedia returns suspects after afterID still holding
+// inline photo or fingerprint bytes
+func (r *SuspectRepositoryImpl) FindSuspectsWithLegacyMedia(afterID uint, limit int) ([]models.Suspect, error) {
+	var suspects []models.Suspect
+	err := r.db.Unscoped().
+		Select("id", "photo", "fingerprints", "created_at").
+		Where("id > ?", afterID).
+		Where("photo IS NOT NULL OR fingerprints IS NOT NULL").
+		Order("id ASC").
+		Limit(limit).
+		Find(&suspects).Error
+	return suspects, err
+}
+
+// ClearLegacyMedia empties one of the inline media columns once its content has been moved
+func (r *SuspectRepositoryImpl) ClearLegacyMedia(suspectID uint, column string) error {
+	return r.db.Unscoped().Model(&models.Suspect{}).Where("id = ?", suspectID).
+		Update(column, nil).Error
+}
+
+// MoveLegacyMedia records media moved out of an inline column and clears the
+// column in one transaction, so an interrupted migration never moves a file twice
+func (r *SuspectRepositoryImpl) MoveLegacyMedia(media *models.SuspectMedia, column string, blobs ...*models.FileBlob) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := SuspectDbService(tx).AddSuspectMedia(media, blobs...); err != nil {
+			return err
+		}
+		return SuspectDbService(tx).ClearLegacyMedia(media.SuspectID, column)
+	})
+}
+
+// FindSuspectMatchCandidates pre-selects suspects that could be the person in
+// q, including anyone sharing a fingerprint, with their cases and fingerprints loaded
+func (r *SuspectRepositoryImpl) FindSuspectMatchCandidates(q MatchQuery) ([]models.Suspect, error) {
+	cond, ok := personConditions(r.db, q)
+	var exact []clause.Expr
+	if len(q.Prints) > 0 {
+		prints := r.db.Model(&models.SuspectMedia{}).Select("suspect_id").
+			Where("kind = ?", models.SuspectMediaFingerprint).
+			Where("template_hash IN ? OR blob_sha256 IN ?", q.Prints, q.Prints)
+		exact = append(exact, clause.Expr{SQL: "id IN (?)", Vars: []interface{}{prints}})
+		if ok {
+			cond = cond.Or("id IN (?)", prints)
+		} else {
+			cond = r.db.Where("id IN (?)", prints)
+			ok = true
+		}
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	var suspects []models.Suspect
+	err := r.db.Omit(legacyMediaColumns...).
+		Preload("Cases").
+		Preload("Media", "kind = ?", models.SuspectMediaFingerprint).
+		Where("id <> ?", q.ExcludeID).
+		Where(cond).
+		Order(exactMatchesFirst(q, exact...)).
+		Limit(maxMatchCandidates).
+		Find(&suspects).Error
+	return suspects, err
+}
+
+// GetPriorCases returns every case the suspect is linked to, newest first,
+// with the police post and charges so history from other posts is visible
+func (r *SuspectRepositoryImpl) GetPriorCases(suspectID uint) ([]models.Case, error) {
+	var cases []models.Case
+	err := r.db.
+		Preload("PolicePost").Preload("Charges").
+		Joins("JOIN case_suspects ON case_suspects.case_id = cases.id").
+		Where("case_suspects.suspect_id = ?", suspectID).
+		Order("cases.date_opened DESC").
+		Find(&cases).Error
+	return cases, err
+}
+
+// MergeSuspects moves everything recorded against the duplicate onto the kept
+// suspect, copies the fill values onto it, and retires the duplicate
+func (r *SuspectRepositoryImpl) MergeSuspects(keepID, duplicateID uint, fill map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(
+			"INSERT INTO case_suspects (case_id, suspect_id) SELECT case_id, ? FROM case_suspects WHERE suspect_id = ? ON CONFLICT DO NOTHING",
+			keepID, duplicateID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM case_suspects WHERE suspect_id = ?", duplicateID).Error; err != nil {
+			return err
+		}
+
+		// Keep per-case relationships unless the kept suspect already has one for that victim
+		if err := tx.Exec(`UPDATE case_relationships SET suspect_id = ?
+			WHERE suspect_id = ? AND NOT EXISTS (
+				SELECT 1 FROM case_relationships cr WHERE cr.case_id = case_relationships.case_id
+					AND cr.victim_id = case_relationships.victim_id AND cr.suspect_id = ?)`,
+			keepID, duplicateID, keepID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM case_relationships WHERE suspect_id = ?", duplicateID).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{
+			&models.Arrest{},
+			&models.CustodyRecord{},
+			&models.PoliceBond{},
+			&models.SuspectMedia{},
+			&models.SuspectAlias{},
+			&models.SuspectMark{},
+			&models.SuspectAssociate{},
+			&models.WatchlistEntry{},
+			&models.Guardian{},
+		} {
+			if err := tx.Unscoped().Model(model).Where("suspect_id = ?", duplicateID).
+				Update("suspect_id", keepID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Unscoped().Model(&models.SuspectAssociate{}).Where("associate_suspect_id = ?", duplicateID).
+			Update("associate_suspect_id", keepID).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.ProtectionOrder{}).Where("respondent_suspect_id = ?", duplicateID).
+			Update("respondent_suspect_id", keepID).Error; err != nil {
+			return err
+		}
+
+		// Both records may have been listed by the same station; keep the entry
+		// that runs longest and lift the rest so alerts are not raised twice
+		if err := tx.Exec(`UPDATE watchlist_entries SET status = ?, lifted_at = ?, lift_reason = ?
+			WHERE suspect_id = ? AND status = ? AND deleted_at IS NULL AND EXISTS (
+				SELECT 1 FROM watchlist_entries we WHERE we.suspect_id = watchlist_entries.suspect_id
+					AND we.id <> watchlist_entries.id AND we.status = watchlist_entries.status AND we.deleted_at IS NULL
+					AND we.category = watchlist_entries.category AND we.issuing_post_id = watchlist_entries.issuing_post_id
+					AND (COALESCE(we.expires_at, 'infinity') > COALESCE(watchlist_entries.expires_at, 'infinity')
+						OR (COALESCE(we.expires_at, 'infinity') = COALESCE(watchlist_entries.expires_at, 'infinity') AND we.id < watchlist_entries.id)))`,
+			models.WatchlistStatusLifted, time.Now(), "Duplicate entry after suspect records were merged",
+			keepID, models.WatchlistStatusActive).Error; err != nil {
+			return err
+		}
+
+		if len(fill) > 0 {
+			if err := tx.Model(&models.Suspect{}).Where("id = ?", keepID).Updates(fill).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Suspect{}).Where("id = ?", duplicateID).Updates(map[string]interface{}{
+			"merged_into_id": keepID,
+			"status":         models.SuspectStatusMerged,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Suspect{}, duplicateID).Error
+	})
+}
+
+func (r *SuspectRepositoryImpl) CreateSuspectAlias(alias *models.SuspectAlias) error {
+	return r.db.Create(alias).Error
+}
+
+func (r *SuspectRepositoryImpl) DeleteSuspectAlias(id string) error {
+	return r.db.Delete(&models.SuspectAlias{}, "id = ?", id).Error
+}
+
+func (r *SuspectRepositoryImpl) CreateSuspectMark(mark *models.SuspectMark) error {
+	return r.db.Create(mark).Error
+}
+
+func (r *SuspectRepositoryImpl) DeleteSuspectMark(id string) error {
+	return r.db.Delete(&models.SuspectMark{}, "id = ?", id).Error
+}
+
+func (r *SuspectRepositoryImpl) CreateSuspectAssociate(associate *models.SuspectAssociate) error {
+	return r.db.Omit("AssociateSuspect").Create(associate).Error
+}
+
+func (r *SuspectRepositoryImpl) DeleteSuspectAssociate(id string) error {
+	return r.db.Delete(&models.SuspectAssociate{}, "id = ?", id).Error
+}
@@ -3,9 +3,14 @@ package repository
 import (
 	"gbvmis/internals/models"
 	"gbvmis/internals/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type VictimRepository interface {
@@ -15,6 +20,23 @@ type VictimRepository interface {
 	GetVictimByID(id string) (models.Victim, error)
 	DeleteByID(id string) error
 	SearchPaginatedVictims(c *fiber.Ctx) (*utils.Pagination, []models.Victim, error)
+	FindVictimMatchCandidates(q MatchQuery) ([]models.Victim, error)
+	SaveVictimDuplicates(duplicates []models.VictimDuplicate) error
+	GetPaginatedVictimDuplicates(c *fiber.Ctx) (*utils.Pagination, []models.VictimDuplicate, error)
+	GetVictimDuplicateByID(id string) (models.VictimDuplicate, error)
+	DismissVictimDuplicate(id uint, reviewedByID uint) error
+	MergeVictims(merge *models.VictimMerge, fill map[string]interface{}) error
+	GetPaginatedVictimMerges(c *fiber.Ctx) (*utils.Pagination, []models.VictimMerge, error)
+	GetVictimCaseScopes(victimID uint) ([]OwnerScope, error)
+	GetVictimCases(victimIDs []uint) (map[uint][]models.Case, error)
+	FindOfficerByID(id uint) (models.PoliceOfficer, error)
+	CreateGuardian(guardian *models.Guardian) error
+	GetGuardians(victimID uint) ([]models.Guardian, error)
+	GetGuardianByID(id string) (models.Guardian, error)
+	UpdateGuardian(id uint, updates map[string]interface{}) error
+	DeleteGuardian(id uint) error
+	GetAgeBandStats(c *fiber.Ctx) ([]models.AgeBandStat, error)
+	FindAdminAreaByID(id uint) (models.AdminArea, error)
 }
 
 type VictimRepositoryImpl struct {
@@ -41,7 +63,7 @@ func (r *VictimRepositoryImpl) GetPaginatedVictims(c *fiber.Ctx) (*utils.Paginat
 
 func (r *VictimRepositoryImpl) GetVictimByID(id string) (models.Victim, error) {
 	var victim models.Victim
-	err := r.db.First(&victim, "id = ?", id).Error
+	err := r.db.Preload("Guardians").First(&victim, "id = ?", id).Error
 	return victim, err
 }
 
@@ -93,3 +115,289 @@ func (r *VictimRepositoryImpl) SearchPaginatedVictims(c *fiber.Ctx) (*utils.Pagi
 
 	return &pagination, victims, nil
 }
+
+// FindVictimMatchCandidates pre-selects victims that could be the person in q, with their cases loaded
+func (r *VictimRepositoryImpl) FindVictimMatchCandidates(q MatchQuery) ([]models.Victim, error) {
+	cond, ok := personConditions(r.db, q)
+	if !ok {
+		return nil, nil
+	}
+
+	var victims []models.Victim
+	err := r.db.Preload("Cases").
+		Where("id <> ?", q.ExcludeID).
+		Where(cond).
+		Order(exactMatchesFirst(q)).
+		Limit(maxMatchCandidates).
+		Find(&victims).Error
+	return victims, err
+}
+
+// SaveVictimDuplicates queues candidate pairs for review. Pairs already
+// queued, including ones dismissed before, are left as they are.
+func (r *VictimRepositoryImpl) SaveVictimDuplicates(duplicates []models.VictimDuplicate) error {
+	if len(duplicates) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Omit("Victim", "Candidate").Create(&duplicates).Error
+}
+
+func (r *VictimRepositoryImpl) GetPaginatedVictimDuplicates(c *fiber.Ctx) (*utils.Pagination, []models.VictimDuplicate, error) {
+	status := c.Query("status", models.DuplicateStatusPending)
+
+	query := r.db.Preload("Victim").Preload("Candidate").
+		Model(&models.VictimDuplicate{}).
+		Order("score DESC, created_at ASC")
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+
+	pagination, duplicates, err := utils.Paginate(c, query, models.VictimDuplicate{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, duplicates, nil
+}
+
+func (r *VictimRepositoryImpl) GetVictimDuplicateByID(id string) (models.VictimDuplicate, error) {
+	var duplicate models.VictimDuplicate
+	err := r.db.Preload("Victim").Preload("Candidate").First(&duplicate, "id = ?", id).Error
+	return duplicate, err
+}
+
+func (r *VictimRepositoryImpl) DismissVictimDuplicate(id uint, reviewedByID uint) error {
+	return r.db.Model(&models.VictimDuplicate{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":         models.DuplicateStatusDismissed,
+		"reviewed_by_id": reviewedByID,
+		"reviewed_at":    time.Now(),
+	}).Error
+}
+
+// MergeVictims re-links the merged victim's cases and examinations to the
+// kept victim, copies the fill values onto it, retires the merged record,
+// closes its queued duplicate pairs and stores the audit record, all in one
+// transaction
+func (r *VictimRepositoryImpl) MergeVictims(merge *models.VictimMerge, fill map[string]interface{}) error {
+	keepID, mergedID := merge.KeptID, merge.MergedID
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Move case links the kept victim does not already have, then drop the rest
+		relinked := tx.Exec(`UPDATE case_victims SET victim_id = ?
+			WHERE victim_id = ? AND NOT EXISTS (
+				SELECT 1 FROM case_victims cv WHERE cv.case_id = case_victims.case_id AND cv.victim_id = ?)`,
+			keepID, mergedID, keepID)
+		if relinked.Error != nil {
+			return relinked.Error
+		}
+		merge.CasesRelinked = relinked.RowsAffected
+		if err := tx.Exec("DELETE FROM case_victims WHERE victim_id = ?", mergedID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE case_relationships SET victim_id = ?
+			WHERE victim_id = ? AND NOT EXISTS (
+				SELECT 1 FROM case_relationships cr WHERE cr.case_id = case_relationships.case_id
+					AND cr.suspect_id = case_relationships.suspect_id AND cr.victim_id = ?)`,
+			keepID, mergedID, keepID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM case_relationships WHERE victim_id = ?", mergedID).Error; err != nil {
+			return err
+		}
+
+		exams := tx.Unscoped().Model(&models.Examination{}).Where("victim_id = ?", mergedID).
+			Update("victim_id", keepID)
+		if exams.Error != nil {
+			return exams.Error
+		}
+		merge.ExaminationsRelinked = exams.RowsAffected
+
+		for _, model := range []interface{}{&models.RiskAssessment{}, &models.ProtectionOrder{}, &models.SafetyPlan{}, &models.Referral{}, &models.Guardian{}} {
+			if err := tx.Unscoped().Model(model).Where("victim_id = ?", mergedID).
+				Update("victim_id", keepID).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(fill) > 0 {
+			if err := tx.Model(&models.Victim{}).Where("id = ?", keepID).Updates(fill).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Victim{}).Where("id = ?", mergedID).
+			Update("merged_into_id", keepID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Victim{}, mergedID).Error; err != nil {
+			return err
+		}
+
+		// Every queued pair involving the merged record is settled by this merge
+		if err := tx.Model(&models.VictimDuplicate{}).
+			Where("status = ? AND (victim_id = ? OR candidate_id = ?)", models.DuplicateStatusPending, mergedID, mergedID).
+			Updates(map[string]interface{}{
+				"status":         models.DuplicateStatusMerged,
+				"reviewed_by_id": merge.MergedByID,
+				"reviewed_at":    time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Omit("MergedBy").Create(merge).Error
+	})
+}
+
+func (r *VictimRepositoryImpl) GetPaginatedVictimMerges(c *fiber.Ctx) (*utils.Pagination, []models.VictimMerge, error) {
+	query := r.db.Model(&models.VictimMerge{}).Order("created_at DESC")
+	if victimID := c.Query("victim_id"); victimID != "" {
+		if _, err := strconv.Atoi(victimID); err == nil {
+			query = query.Where("kept_id = ? OR merged_id = ?", victimID, victimID)
+		}
+	}
+
+	pagination, merges, err := utils.Paginate(c, query, models.VictimMerge{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, merges, nil
+}
+
+// GetVictimCases returns, per victim, the cases they are part of with their
+// incidents, so minor status can be judged at each incident date
+func (r *VictimRepositoryImpl) GetVictimCases(victimIDs []uint) (map[uint][]models.Case, error) {
+	byVictim := make(map[uint][]models.Case, len(victimIDs))
+	if len(victimIDs) == 0 {
+		return byVictim, nil
+	}
+
+	var links []struct {
+		VictimID uint
+		CaseID   uint
+	}
+	if err := r.db.Table("case_victims").Select("victim_id, case_id").
+		Where("victim_id IN ?", victimIDs).Scan(&links).Error; err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return byVictim, nil
+	}
+
+	caseIDs := make([]uint, 0, len(links))
+	for _, link := range links {
+		caseIDs = append(caseIDs, link.CaseID)
+	}
+	var cases []models.Case
+	if err := r.db.Preload("Incidents").Where("id IN ?", caseIDs).Find(&cases).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Case, len(cases))
+	for _, casee := range cases {
+		byID[casee.ID] = casee
+	}
+	for _, link := range links {
+		if casee, ok := byID[link.CaseID]; ok {
+			byVictim[link.VictimID] = append(byVictim[link.VictimID], casee)
+		}
+	}
+	return byVictim, nil
+}
+
+// GetVictimCaseScopes returns the officer and police post of each case the
+// victim is part of
+func (r *VictimRepositoryImpl) GetVictimCaseScopes(victimID uint) ([]OwnerScope, error) {
+	var scopes []OwnerScope
+	err := r.db.Model(&models.Case{}).
+		Select("cases.officer_id, cases.police_post_id").
+		Joins("JOIN case_victims ON case_victims.case_id = cases.id").
+		Where("case_victims.victim_id = ?", victimID).
+		Scan(&scopes).Error
+	return scopes, err
+}
+
+func (r *VictimRepositoryImpl) FindOfficerByID(id uint) (models.PoliceOfficer, error) {
+	var officer models.PoliceOfficer
+	err := r.db.First(&officer, "id = ?", id).Error
+	return officer, err
+}
+
+func (r *VictimRepositoryImpl) CreateGuardian(guardian *models.Guardian) error {
+	return r.db.Create(guardian).Error
+}
+
+// GetGuardians returns the victim's guardians, the primary one first
+func (r *VictimRepositoryImpl) GetGuardians(victimID uint) ([]models.Guardian, error) {
+	var guardians []models.Guardian
+	err := r.db.Where("victim_id = ?", victimID).Order("is_primary DESC, id").Find(&guardians).Error
+	return guardians, err
+}
+
+func (r *VictimRepositoryImpl) GetGuardianByID(id string) (models.Guardian, error) {
+	var guardian models.Guardian
+	err := r.db.First(&guardian, "id = ?", id).Error
+	return guardian, err
+}
+
+func (r *VictimRepositoryImpl) UpdateGuardian(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.Guardian{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *VictimRepositoryImpl) DeleteGuardian(id uint) error {
+	return r.db.Delete(&models.Guardian{}, id).Error
+}
+
+// GetAgeBandStats counts victims by age band and gender, each victim once per
+// case at their age on the case's incident date: its earliest incident, or
+// its opening date when none is recorded. Filters: police_post_id, from and
+// to (YYYY-MM-DD, on the case's opening date).
+func (r *VictimRepositoryImpl) GetAgeBandStats(c *fiber.Ctx) ([]models.AgeBandStat, error) {
+	filter, err := StatsFilterFromQuery(c)
+	if err != nil {
+		return nil, err
+	}
+	return ageBandStats(r.db, filter)
+}
+
+func ageBandStats(db *gorm.DB, filter models.StatsFilter) ([]models.AgeBandStat, error) {
+	query := db.Table("case_victims").
+		Select(`victims.gender,
+			CASE WHEN victims.dob IS NULL OR victims.dob <= '0001-01-01' OR victims.dob > incident_date THEN -1
+				ELSE date_part('year', age(incident_date, victims.dob))::int END AS age,
+			COUNT(*) AS victims`).
+		Joins("JOIN cases ON cases.id = case_victims.case_id AND cases.deleted_at IS NULL").
+		Joins(`CROSS JOIN LATERAL (SELECT COALESCE(
+			(SELECT MIN(incidents.occurred_at)::date FROM incidents WHERE incidents.case_id = cases.id AND incidents.deleted_at IS NULL),
+			cases.date_opened) AS incident_date) AS incident`).
+		Joins("JOIN victims ON victims.id = case_victims.victim_id AND victims.deleted_at IS NULL").
+		Group("victims.gender, age")
+	query = filterStatsCases(query, filter)
+
+	var rows []struct {
+		Gender  string
+		Age     int
+		Victims int64
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// Band in Go so the bands are defined in one place
+	counts := map[models.AgeBandStat]int64{}
+	for _, row := range rows {
+		counts[models.AgeBandStat{AgeBand: models.AgeBand(row.Age, row.Age >= 0), Gender: row.Gender}] += row.Victims
+	}
+	stats := make([]models.AgeBandStat, 0, len(counts))
+	for key, n := range counts {
+		key.Victims = n
+		stats = append(stats, key)
+	}
+	slices.SortFunc(stats, func(a, b models.AgeBandStat) int {
+		if a.AgeBand != b.AgeBand {
+			return slices.Index(models.AgeBands, a.AgeBand) - slices.Index(models.AgeBands, b.AgeBand)
+		}
+		return strings.Compare(a.Gender, b.Gender)
+	})
+	return stats, nil
+}
+
+func (r *VictimRepositoryImpl) FindAdminAreaByID(id uint) (models.AdminArea, error) {
+	return findAdminArea(r.db, id)
+}
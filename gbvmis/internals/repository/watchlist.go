@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type WatchlistRepository interface {
+	CreateEntry(entry *models.WatchlistEntry) error
+	GetPaginatedEntries(c *fiber.Ctx) (*utils.Pagination, []models.WatchlistEntry, error)
+	SearchPaginatedEntries(c *fiber.Ctx) (*utils.Pagination, []models.WatchlistEntry, error)
+	GetEntryByID(id string) (models.WatchlistEntry, error)
+	LiftEntry(id uint, reason string, at time.Time) error
+	ExpireEntries(now time.Time) (int64, error)
+	FindActiveEntries(now time.Time) ([]models.WatchlistEntry, error)
+	RecentHitExists(hit models.WatchlistHit, since time.Time) (bool, error)
+	RecordHit(hit *models.WatchlistHit, notification *models.Notification) error
+	GetHits(entryID uint) ([]models.WatchlistHit, error)
+	FindOfficerByID(id uint) (models.PoliceOfficer, error)
+	FindSuspectByID(id uint) (models.Suspect, error)
+	FindPolicePostByID(id uint) (models.PolicePost, error)
+}
+
+type WatchlistRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func WatchlistDbService(db *gorm.DB) WatchlistRepository {
+	return &WatchlistRepositoryImpl{db: db}
+}
+
+// =================================
+
+func (r *WatchlistRepositoryImpl) CreateEntry(entry *models.WatchlistEntry) error {
+	return r.db.Omit("Suspect", "IssuingPost", "IssuedBy").Create(entry).Error
+}
+
+func (r *WatchlistRepositoryImpl) GetPaginatedEntries(c *fiber.Ctx) (*utils.Pagination, []models.WatchlistEntry, error) {
+	pagination, entries, err := utils.Paginate(c, r.db.
+		Preload("Suspect", omitLegacyMedia).
+		Preload("IssuingPost").
+		Order("issued_at DESC"), models.WatchlistEntry{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pagination, entries, nil
+}
+
+func (r *WatchlistRepositoryImpl) SearchPaginatedEntries(c *fiber.Ctx) (*utils.Pagination, []models.WatchlistEntry, error) {
+	// Get query parameters from request
+	status := c.Query("status")
+	category := c.Query("category")
+	suspectID := c.Query("suspect_id")
+	postID := c.Query("issuing_post_id")
+
+	// Start building the query
+	query := r.db.
+		Preload("Suspect", omitLegacyMedia).
+		Preload("IssuingPost").
+		Model(&models.WatchlistEntry{}).
+		Order("issued_at DESC")
+
+	// Apply filters based on provided parameters
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if suspectID != "" {
+		if _, err := strconv.Atoi(suspectID); err == nil {
+			query = query.Where("suspect_id = ?", suspectID)
+		}
+	}
+	if postID != "" {
+		if _, err := strconv.Atoi(postID); err == nil {
+			query = query.Where("issuing_post_id = ?", postID)
+		}
+	}
+
+	// Call the pagination helper
+	pagination, entries, err := utils.Paginate(c, query, models.WatchlistEntry{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &pagination, entries, nil
+}
+
+func (r *WatchlistRepositoryImpl) GetEntryByID(id string) (models.WatchlistEntry, error) {
+	var entry models.WatchlistEntry
+	err := r.db.
+		Preload("Suspect", omitLegacyMedia).
+		Preload("Suspect.Aliases").
+		Preload("IssuingPost").
+		Preload("IssuedBy").
+		First(&entry, "id = ?", id).Error
+	return entry, err
+}
+
+func (r *WatchlistRepositoryImpl) LiftEntry(id uint, reason string, at time.Time) error {
+	return r.db.Model(&models.WatchlistEntry{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      models.WatchlistStatusLifted,
+		"lifted_at":   at,
+		"lift_reason": reason,
+	}).Error
+}
+
+// ExpireEntries closes active entries past their expiry date
+func (r *WatchlistRepositoryImpl) ExpireEntries(now time.Time) (int64, error) {
+	result := r.db.Model(&models.WatchlistEntry{}).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at < ?", models.WatchlistStatusActive, now).
+		Update("status", models.WatchlistStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+// FindActiveEntries returns entries in force at now, with the listed suspect and their aliases
+func (r *WatchlistRepositoryImpl) FindActiveEntries(now time.Time) ([]models.WatchlistEntry, error) {
+	var entries []models.WatchlistEntry
+	err := r.db.
+		Preload("Suspect", omitLegacyMedia).
+		Preload("Suspect.Aliases").
+		Preload("IssuingPost").
+		Where("status = ?", models.WatchlistStatusActive).
+		Where("expires_at IS NULL OR expires_at >= ?", now).
+		Find(&entries).Error
+	return entries, err
+}
+
+// RecentHitExists reports whether the same officer already hit the same entry
+// on the same record, the same way, since the given time
+func (r *WatchlistRepositoryImpl) RecentHitExists(hit models.WatchlistHit, since time.Time) (bool, error) {
+	query := r.db.Model(&models.WatchlistHit{}).
+		Where("entry_id = ? AND trigger = ? AND triggered_by_id = ? AND created_at >= ?",
+			hit.EntryID, hit.Trigger, hit.TriggeredByID, since)
+	if hit.MatchedSuspectID != nil {
+		query = query.Where("matched_suspect_id = ?", *hit.MatchedSuspectID)
+	} else {
+		query = query.Where("matched_suspect_id IS NULL")
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// RecordHit stores the hit and the notification for the issuing station together
+func (r *WatchlistRepositoryImpl) RecordHit(hit *models.WatchlistHit, notification *models.Notification) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Entry", "TriggeredBy").Create(hit).Error; err != nil {
+			return err
+		}
+		notification.EntityID = hit.EntryID
+		return tx.Create(notification).Error
+	})
+}
+
+func (r *WatchlistRepositoryImpl) GetHits(entryID uint) ([]models.WatchlistHit, error) {
+	var hits []models.WatchlistHit
+	err := r.db.Preload("TriggeredBy").
+		Where("entry_id = ?", entryID).
+		Order("created_at DESC").
+		Find(&hits).Error
+	return hits, err
+}
+
+func (r *WatchlistRepositoryImpl) FindOfficerByID(id uint) (models.PoliceOfficer, error) {
+	var officer models.PoliceOfficer
+	err := r.db.First(&officer, "id = ?", id).Error
+	return officer, err
+}
+
+func (r *WatchlistRepositoryImpl) FindSuspectByID(id uint) (models.Suspect, error) {
+	var suspect models.Suspect
+	err := r.db.Omit(legacyMediaColumns...).Preload("Aliases").First(&suspect, "id = ?", id).Error
+	return suspect, err
+}
+
+func (r *WatchlistRepositoryImpl) FindPolicePostByID(id uint) (models.PolicePost, error) {
+	var post models.PolicePost
+	err := r.db.First(&post, "id = ?", id).Error
+	return post, err
+}
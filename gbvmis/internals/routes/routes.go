@@ -3,9 +3,12 @@ package routes
 import (
 	"gbvmis/internals/controllers"
 	"gbvmis/internals/middleware"
+	"gbvmis/internals/models"
 	"gbvmis/internals/repository"
 	"gbvmis/internals/service"
+	"gbvmis/internals/storage"
 	"gbvmis/internals/utils"
+	"log"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -17,6 +20,8 @@ func SetupRoute(app *fiber.App, db *gorm.DB) {
 	authGroup := app.Group("/api")
 	authGroup.Post("/login", controllers.Login(db))
 	authGroup.Post("/refresh-token", controllers.RefreshToken)
+	// Linked from the QR code on issued PF3 forms, so it needs no login
+	authGroup.Get("/pf3/verify/:code", controllers.VerifyPf3Document(db))
 
 	// Protected routes
 	protected := app.Group("/api", middleware.JWTProtected())
@@ -25,6 +30,31 @@ func SetupRoute(app *fiber.App, db *gorm.DB) {
 		return c.JSON(user)
 	})
 
+	store, err := storage.NewBlobStore()
+	if err != nil {
+		log.Fatalf("Failed to initialise blob storage: %v", err)
+	}
+	blobStore := repository.TrackedBlobStore(db, store)
+	attachmentService := repository.AttachmentDbService(db)
+	attachmentController := controllers.NewAttachmentController(attachmentService, blobStore, storage.NewScanner())
+	attachment := protected.Group("/attachment")
+	attachment.Get("/:id", attachmentController.GetSingleAttachment)
+	attachment.Get("/:id/download", attachmentController.DownloadAttachment)
+	attachment.Delete("/:id", attachmentController.DeleteAttachmentByID)
+
+	watchlistService := repository.WatchlistDbService(db)
+	watchlistController := controllers.NewWatchlistController(watchlistService)
+	protected.Get("/watchlists", watchlistController.GetAllWatchlistEntries)
+	protected.Get("/watchlists/search", watchlistController.SearchWatchlistEntries)
+	watchlist := protected.Group("/watchlist")
+	watchlist.Post("/", watchlistController.CreateWatchlistEntry)
+	watchlist.Get("/:id", watchlistController.GetSingleWatchlistEntry)
+	watchlist.Post("/:id/lift", watchlistController.LiftWatchlistEntry)
+
+	notificationController := controllers.NewNotificationController(repository.NotificationDbService(db))
+	protected.Get("/notifications", notificationController.GetMyNotifications)
+	protected.Post("/notification/:id/read", notificationController.MarkNotificationRead)
+
 	victimService := repository.VictimDbService(db)
 	victimController := controllers.NewVictimController(victimService)
 	protected.Get("/victims", victimController.GetAllVictims)
@@ -34,16 +64,55 @@ func SetupRoute(app *fiber.App, db *gorm.DB) {
 	victim.Get("/:id", victimController.GetSingleVictim)
 	victim.Put("/:id", victimController.UpdateVictim)
 	victim.Delete("/:id", victimController.DeleteVictimByID)
+	victim.Get("/:id/duplicates", victimController.GetVictimDuplicates)
+	victim.Post("/:id/merge", victimController.MergeVictim)
+	victim.Post("/:id/guardians", victimController.CreateGuardian)
+	victim.Get("/:id/guardians", victimController.GetVictimGuardians)
+	protected.Put("/guardian/:id", victimController.UpdateGuardian)
+	protected.Delete("/guardian/:id", victimController.DeleteGuardian)
+	protected.Get("/victims/age-band-stats", victimController.GetAgeBandStats)
+	protected.Get("/victim-duplicates", victimController.GetVictimDuplicateQueue)
+	protected.Post("/victim-duplicate/:id/dismiss", victimController.DismissVictimDuplicate)
+	protected.Get("/victim-merges", victimController.GetVictimMerges)
 
 	caseService := repository.CaseDbService(db)
 	caseController := controllers.NewCaseController(caseService)
 	protected.Get("/cases", caseController.GetAllCases)
 	protected.Get("/cases/search", caseController.SearchCases)
+	protected.Get("/cases/relationship-stats", caseController.GetRelationshipStats)
 	casee := protected.Group("/case")
 	casee.Post("/", caseController.CreateCase)
 	casee.Get("/:id", caseController.GetSingleCase)
 	casee.Put("/:id", caseController.UpdateCase)
 	casee.Delete("/:id", caseController.DeleteCaseByID)
+	casee.Post("/:id/relationships", caseController.AddCaseRelationship)
+	protected.Put("/case-relationship/:id", caseController.UpdateCaseRelationship)
+	protected.Delete("/case-relationship/:id", caseController.DeleteCaseRelationship)
+	casee.Post("/:id/attachments", attachmentController.UploadAttachment(models.AttachmentOwnerCase))
+	casee.Get("/:id/attachments", attachmentController.ListAttachments(models.AttachmentOwnerCase))
+
+	riskController := controllers.NewRiskAssessmentController(repository.RiskAssessmentDbService(db))
+	protected.Get("/cases/high-risk", riskController.GetHighRiskCases)
+	protected.Get("/risk-questionnaires", riskController.GetAllRiskQuestionnaires)
+	riskQuestionnaire := protected.Group("/risk-questionnaire")
+	riskQuestionnaire.Post("/", riskController.CreateRiskQuestionnaire)
+	riskQuestionnaire.Get("/:id", riskController.GetSingleRiskQuestionnaire)
+	riskQuestionnaire.Post("/:id/activate", riskController.ActivateRiskQuestionnaire)
+	casee.Post("/:id/risk-assessments", riskController.SubmitRiskAssessment)
+	casee.Get("/:id/risk-assessments", riskController.GetCaseRiskAssessments)
+	protected.Get("/risk-assessment/:id", riskController.GetSingleRiskAssessment)
+
+	protectionOrderController := controllers.NewProtectionOrderController(repository.ProtectionOrderDbService(db))
+	protected.Get("/protection-orders", protectionOrderController.GetAllProtectionOrders)
+	protectionOrder := protected.Group("/protection-order")
+	protectionOrder.Post("/", protectionOrderController.CreateProtectionOrder)
+	protectionOrder.Get("/:id", protectionOrderController.GetSingleProtectionOrder)
+	protectionOrder.Put("/:id", protectionOrderController.UpdateProtectionOrder)
+	protectionOrder.Post("/:id/revoke", protectionOrderController.RevokeProtectionOrder)
+	protectionOrder.Post("/:id/breaches", protectionOrderController.ReportProtectionOrderBreach)
+	victim.Post("/:id/safety-plans", protectionOrderController.CreateSafetyPlan)
+	victim.Get("/:id/safety-plans", protectionOrderController.GetVictimSafetyPlans)
+	protected.Put("/safety-plan/:id", protectionOrderController.UpdateSafetyPlan)
 
 	chargeService := repository.ChargeDbService(db)
 	chargeController := controllers.NewChargeController(chargeService)
@@ -56,7 +125,7 @@ func SetupRoute(app *fiber.App, db *gorm.DB) {
 	charge.Delete("/:id", chargeController.DeleteChargeByID)
 
 	suspectService := repository.SuspectDbService(db)
-	suspectController := controllers.NewSuspectController(suspectService)
+	suspectController := controllers.NewSuspectController(suspectService, blobStore, watchlistService)
 	protected.Get("/suspects", suspectController.GetAllSuspects)
 	protected.Get("/suspects/search", suspectController.SearchSuspects)
 	suspect := protected.Group("/suspect")
@@ -64,6 +133,71 @@ func SetupRoute(app *fiber.App, db *gorm.DB) {
 	suspect.Get("/:id", suspectController.GetSingleSuspect)
 	suspect.Put("/:id", suspectController.UpdateSuspect)
 	suspect.Delete("/:id", suspectController.DeleteSuspectByID)
+	suspect.Post("/:id/media", suspectController.UploadSuspectMedia)
+	suspect.Get("/:id/media", suspectController.GetSuspectMedia)
+	suspect.Get("/:id/duplicates", suspectController.GetSuspectDuplicates)
+	suspect.Post("/:id/merge", suspectController.MergeSuspect)
+	suspect.Post("/:id/aliases", suspectController.AddSuspectAlias)
+	suspect.Post("/:id/marks", suspectController.AddSuspectMark)
+	suspect.Post("/:id/associates", suspectController.AddSuspectAssociate)
+	protected.Delete("/suspect-alias/:id", suspectController.DeleteSuspectAlias)
+	protected.Delete("/suspect-mark/:id", suspectController.DeleteSuspectMark)
+	protected.Delete("/suspect-associate/:id", suspectController.DeleteSuspectAssociate)
+	suspectMedia := protected.Group("/suspect-media")
+	suspectMedia.Get("/:id/download", suspectController.DownloadSuspectMedia)
+	suspectMedia.Get("/:id/thumbnail", suspectController.GetSuspectMediaThumbnail)
+	suspectMedia.Delete("/:id", suspectController.DeleteSuspectMedia)
+
+	arrestService := repository.ArrestDbService(db)
+	arrestController := controllers.NewArrestController(arrestService, watchlistService)
+	protected.Get("/arrests", arrestController.GetAllArrests)
+	protected.Get("/arrests/search", arrestController.SearchArrests)
+	arrest := protected.Group("/arrest")
+	arrest.Post("/", arrestController.CreateArrest)
+	arrest.Get("/:id", arrestController.GetSingleArrest)
+	arrest.Put("/:id", arrestController.UpdateArrest)
+	arrest.Delete("/:id", arrestController.DeleteArrestByID)
+
+	custodyService := repository.CustodyDbService(db)
+	custodyController := controllers.NewCustodyController(custodyService)
+	protected.Get("/custody-records", custodyController.GetAllCustodyRecords)
+	protected.Get("/custody-records/search", custodyController.SearchCustodyRecords)
+	protected.Get("/custody-records/alerts", custodyController.GetCustodyAlerts)
+	custody := protected.Group("/custody-record")
+	custody.Post("/", custodyController.CreateCustodyRecord)
+	custody.Get("/:id", custodyController.GetSingleCustodyRecord)
+	custody.Put("/:id", custodyController.UpdateCustodyRecord)
+	custody.Post("/:id/court", custodyController.ProduceInCourt)
+	custody.Post("/:id/release", custodyController.ReleaseFromCustody)
+	custody.Delete("/:id", custodyController.DeleteCustodyRecordByID)
+
+	bondService := repository.BondDbService(db)
+	bondController := controllers.NewBondController(bondService)
+	protected.Get("/bonds", bondController.GetAllBonds)
+	protected.Get("/bonds/search", bondController.SearchBonds)
+	protected.Get("/bonds/due", bondController.GetBondsDue)
+	protected.Get("/bonds/breaches", bondController.GetBondBreaches)
+	bond := protected.Group("/bond")
+	bond.Post("/", bondController.CreateBond)
+	bond.Get("/:id", bondController.GetSingleBond)
+	bond.Put("/:id", bondController.UpdateBond)
+	bond.Post("/:id/cancel", bondController.CancelBond)
+	bond.Post("/:id/check-in", bondController.RecordBondCheckIn)
+	bond.Delete("/:id", bondController.DeleteBondByID)
+
+	exhibitService := repository.ExhibitDbService(db)
+	exhibitController := controllers.NewExhibitController(exhibitService)
+	protected.Get("/exhibits", exhibitController.GetAllExhibits)
+	protected.Get("/exhibits/search", exhibitController.SearchExhibits)
+	exhibit := protected.Group("/exhibit")
+	exhibit.Post("/", exhibitController.CreateExhibit)
+	exhibit.Get("/:id", exhibitController.GetSingleExhibit)
+	exhibit.Put("/:id", exhibitController.UpdateExhibit)
+	exhibit.Post("/:id/transfer", exhibitController.TransferExhibit)
+	exhibit.Get("/:id/chain", exhibitController.GetExhibitChain)
+	exhibit.Delete("/:id", exhibitController.DeleteExhibitByID)
+	exhibit.Post("/:id/attachments", attachmentController.UploadAttachment(models.AttachmentOwnerExhibit))
+	exhibit.Get("/:id/attachments", attachmentController.ListAttachments(models.AttachmentOwnerExhibit))
 
 	policePostService := repository.PolicePostDbService(db)
 	policePostController := controllers.NewPolicePostController(policePostService)
@@ -114,6 +248,141 @@ func SetupRoute(app *fiber.App, db *gorm.DB) {
 	examination.Get("/:id", examinationController.GetSingleExamination)
 	examination.Put("/:id", examinationController.UpdateExamination)
 	examination.Delete("/:id", examinationController.DeleteExaminationByID)
+	examination.Post("/:id/attachments", attachmentController.UploadAttachment(models.AttachmentOwnerExamination))
+	examination.Get("/:id/attachments", attachmentController.ListAttachments(models.AttachmentOwnerExamination))
+
+	pf3Controller := controllers.NewPf3Controller(repository.Pf3DbService(db), blobStore)
+	examination.Post("/:id/pf3", pf3Controller.GeneratePf3)
+	examination.Get("/:id/pf3", pf3Controller.GetExaminationPf3s)
+	pf3 := protected.Group("/pf3")
+	pf3.Get("/templates", pf3Controller.GetPf3Templates)
+	pf3.Post("/templates", pf3Controller.CreatePf3Template)
+	pf3.Post("/templates/preview", pf3Controller.PreviewPf3Template)
+	pf3.Get("/template/:id", pf3Controller.GetSinglePf3Template)
+	pf3.Put("/template/:id", pf3Controller.UpdatePf3Template)
+	pf3.Get("/:id/download", pf3Controller.DownloadPf3)
+
+	findingController := controllers.NewFindingController(repository.FindingDbService(db))
+	protected.Get("/findings/codes", findingController.GetFindingCodes)
+	examination.Get("/:id/findings", findingController.GetExaminationFindings)
+	examination.Put("/:id/findings", findingController.SaveExaminationFindings)
+	examination.Post("/:id/injuries", findingController.CreateInjury)
+	protected.Get("/examination-injuries", findingController.GetAllInjuries)
+	protected.Get("/examination-injuries/stats", findingController.GetInjuryStats)
+	examinationInjury := protected.Group("/examination-injury")
+	examinationInjury.Put("/:id", findingController.UpdateInjury)
+	examinationInjury.Delete("/:id", findingController.DeleteInjury)
+
+	careController := controllers.NewCareController(repository.CareDbService(db))
+	protected.Get("/health-facilities/care-at-risk", careController.GetCareAtRisk)
+	examination.Get("/:id/care", careController.GetExaminationCare)
+	examination.Put("/:id/care/:service", careController.RecordCare)
+
+	labController := controllers.NewLabController(repository.LabDbService(db))
+	casee.Post("/:id/lab-requests", labController.CreateCaseLabRequest)
+	casee.Get("/:id/lab-requests", labController.GetCaseLabRequests)
+	examination.Post("/:id/lab-requests", labController.CreateExaminationLabRequest)
+	examination.Get("/:id/lab-requests", labController.GetExaminationLabRequests)
+	protected.Get("/lab-requests", labController.GetAllLabRequests)
+	protected.Get("/lab-requests/codes", labController.GetLabCodes)
+	protected.Get("/lab-requests/turnaround", labController.GetLabTurnaround)
+	labRequest := protected.Group("/lab-request")
+	labRequest.Get("/:id", labController.GetSingleLabRequest)
+	labRequest.Post("/:id/dispatch", labController.DispatchLabRequest)
+	labRequest.Post("/:id/status", labController.UpdateLabRequestStatus)
+	labRequest.Post("/:id/results", labController.RecordLabResults)
+
+	referralController := controllers.NewReferralController(repository.ReferralDbService(db))
+	protected.Get("/service-providers", referralController.GetAllServiceProviders)
+	serviceProvider := protected.Group("/service-provider")
+	serviceProvider.Post("/", referralController.CreateServiceProvider)
+	serviceProvider.Get("/:id", referralController.GetSingleServiceProvider)
+	serviceProvider.Put("/:id", referralController.UpdateServiceProvider)
+	serviceProvider.Delete("/:id", referralController.DeleteServiceProviderByID)
+	casee.Post("/:id/referrals", referralController.CreateCaseReferral)
+	casee.Get("/:id/referrals", referralController.GetCaseReferrals)
+	casee.Get("/:id/child-protection", referralController.GetCaseChildProtection)
+	examination.Post("/:id/referrals", referralController.CreateExaminationReferral)
+	examination.Get("/:id/referrals", referralController.GetExaminationReferrals)
+
+	protected.Get("/referrals", referralController.GetAllReferrals)
+	protected.Get("/referrals/completion-report", referralController.GetReferralCompletionReport)
+	referral := protected.Group("/referral")
+	referral.Get("/:id", referralController.GetSingleReferral)
+	referral.Post("/:id/status", referralController.UpdateReferralStatus)
+	referral.Post("/:id/feedback", referralController.RecordReferralFeedback)
+
+	incidentController := controllers.NewIncidentController(repository.IncidentDbService(db))
+	protected.Get("/incidents", incidentController.GetAllIncidents)
+	protected.Get("/incidents/stats", incidentController.GetIncidentStats)
+	casee.Post("/:id/incidents", incidentController.CreateIncident)
+	casee.Get("/:id/incidents", incidentController.GetCaseIncidents)
+	incident := protected.Group("/incident")
+	incident.Get("/:id", incidentController.GetSingleIncident)
+	incident.Put("/:id", incidentController.UpdateIncident)
+	incident.Delete("/:id", incidentController.DeleteIncident)
+
+	geographyController := controllers.NewGeographyController(repository.GeographyDbService(db))
+	protected.Get("/admin-areas", geographyController.GetAllAdminAreas)
+	protected.Post("/admin-areas/import", geographyController.ImportAdminAreas)
+	protected.Get("/admin-areas/case-counts", geographyController.GetAdminAreaCaseCounts)
+	protected.Get("/admin-area/:id", geographyController.GetSingleAdminArea)
+	protected.Get("/police-units", geographyController.GetAllPoliceUnits)
+	protected.Post("/police-units/import", geographyController.ImportPoliceUnits)
+	protected.Get("/police-units/case-counts", geographyController.GetPoliceUnitCaseCounts)
+	protected.Get("/police-unit/:id", geographyController.GetSinglePoliceUnit)
+	protected.Get("/geo/nearest-police-posts", geographyController.GetNearestPolicePosts)
+	protected.Get("/geo/nearest-facilities", geographyController.GetNearestHealthFacilities)
+	protected.Get("/geo/cases.geojson", geographyController.GetCasesGeoJSON)
+
+	statsController := controllers.NewStatsController(repository.StatsDbService(db))
+	protected.Get("/stats", statsController.GetDashboard)
+	stats := protected.Group("/stats")
+	stats.Get("/cases/status", statsController.GetCasesByStatus)
+	stats.Get("/cases/charges", statsController.GetCasesByCharge)
+	stats.Get("/cases/stations", statsController.GetCasesByStation)
+	stats.Get("/cases/districts", statsController.GetCasesByDistrict)
+	stats.Get("/cases/monthly", statsController.GetCasesByMonth)
+	stats.Get("/victims/age-gender", statsController.GetVictimsByAgeBand)
+	stats.Get("/relationships", statsController.GetRelationshipStats)
+	stats.Get("/timeliness", statsController.GetTimeliness)
+
+	reportController := controllers.NewReportController(repository.ReportDbService(db), repository.StatsDbService(db), blobStore)
+	protected.Post("/report-definitions", reportController.CreateReportDefinition)
+	protected.Get("/report-definitions", reportController.GetAllReportDefinitions)
+	reportDefinition := protected.Group("/report-definition")
+	reportDefinition.Get("/:id", reportController.GetSingleReportDefinition)
+	reportDefinition.Put("/:id", reportController.UpdateReportDefinition)
+	reportDefinition.Delete("/:id", reportController.DeleteReportDefinition)
+	reportDefinition.Post("/:id/run", reportController.RunReport)
+	protected.Get("/reports", reportController.GetAllReports)
+	protected.Get("/report/:id/download", reportController.DownloadReport)
+
+	dhis2Controller := controllers.NewDhis2Controller(repository.Dhis2DbService(db))
+	dhis2 := protected.Group("/dhis2")
+	dhis2.Get("/indicators", dhis2Controller.GetDhis2Indicators)
+	dhis2.Post("/data-elements", dhis2Controller.CreateDataElementMapping)
+	dhis2.Get("/data-elements", dhis2Controller.GetDataElementMappings)
+	dhis2.Put("/data-element/:id", dhis2Controller.UpdateDataElementMapping)
+	dhis2.Delete("/data-element/:id", dhis2Controller.DeleteDataElementMapping)
+	dhis2.Get("/org-units", dhis2Controller.GetOrgUnitMappings)
+	dhis2.Put("/org-unit/:facility_id", dhis2Controller.SetOrgUnitMapping)
+	dhis2.Delete("/org-unit/:facility_id", dhis2Controller.DeleteOrgUnitMapping)
+	dhis2.Get("/data-value-sets", dhis2Controller.GetDataValueSet)
+	dhis2.Post("/push", dhis2Controller.PushDataValueSet)
+	dhis2.Get("/pushes", dhis2Controller.GetDhis2Pushes)
+
+	fhirController := controllers.NewFhirController(repository.FhirDbService(db))
+	fhir := protected.Group("/fhir")
+	fhir.Get("/metadata", fhirController.GetFhirMetadata)
+	fhir.Get("/export", fhirController.ExportFhirExaminations)
+	fhir.Get("/Encounter", fhirController.SearchFhirEncounters)
+	fhir.Get("/Encounter/:id", fhirController.GetFhirEncounter)
+	fhir.Get("/Encounter/:id/$everything", fhirController.GetFhirEncounterEverything)
+	fhir.Get("/Observation", fhirController.SearchFhirObservations)
+	fhir.Get("/Observation/:id", fhirController.GetFhirObservation)
+	fhir.Get("/Practitioner/:id", fhirController.GetFhirPractitioner)
+	fhir.Get("/Organization/:id", fhirController.GetFhirOrganization)
 
 	policeRolesService := repository.RoleDbService(db)
 	policeRolesController := controllers.NewPoliceRolesController(policeRolesService)
@@ -135,6 +404,8 @@ func SetupRoute(app *fiber.App, db *gorm.DB) {
 	toxicology.Get("/:id", toxicologyController.GetByID)
 	toxicology.Put("/:id", toxicologyController.Update)
 	toxicology.Delete("/:id", toxicologyController.Delete)
+	toxicology.Post("/:id/attachments", attachmentController.UploadAttachment(models.AttachmentOwnerToxicologyReport))
+	toxicology.Get("/:id/attachments", attachmentController.ListAttachments(models.AttachmentOwnerToxicologyReport))
 
 	NotFoundRoute(app)
 }
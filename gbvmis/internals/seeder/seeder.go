@@ -204,4 +204,44 @@ func SeedDatabase(db *gorm.DB) {
 		log.Println("post_mortem_summaries table already seeded, skipping...")
 	}
 
+	// Default danger assessment; administrators publish new versions through the API
+	riskQuestionnaire := models.RiskQuestionnaire{
+		Name:             "Danger Assessment",
+		Version:          1,
+		Description:      "Screening for risk of serious or lethal violence. Ask the survivor each question about the suspect.",
+		Active:           true,
+		MediumThreshold:  0.25,
+		HighThreshold:    0.5,
+		ExtremeThreshold: 0.75,
+		CreatedByID:      1,
+		Questions: []models.RiskQuestion{
+			{Code: "violence_increasing", Text: "Has the physical violence increased in severity or frequency over the past year?", Weight: 2, Position: 1},
+			{Code: "weapon", Text: "Does the suspect own or have access to a gun or other weapon?", Weight: 3, Position: 2},
+			{Code: "threatened_with_weapon", Text: "Has the suspect ever used or threatened to use a weapon against you?", Weight: 3, Critical: true, Position: 3},
+			{Code: "threatened_to_kill", Text: "Has the suspect threatened to kill you?", Weight: 3, Critical: true, Position: 4},
+			{Code: "strangled", Text: "Has the suspect ever tried to choke or strangle you?", Weight: 3, Critical: true, Position: 5},
+			{Code: "separation", Text: "Have you left or tried to leave the suspect in the past year?", Weight: 2, Position: 6},
+			{Code: "jealousy_control", Text: "Is the suspect violently and constantly jealous, or does the suspect control most of your daily activities?", Weight: 1, Position: 7},
+			{Code: "forced_sex", Text: "Has the suspect ever forced you to have sex?", Weight: 2, Position: 8},
+			{Code: "beaten_while_pregnant", Text: "Has the suspect ever beaten you while you were pregnant?", Weight: 2, Position: 9},
+			{Code: "substance_abuse", Text: "Does the suspect abuse alcohol or drugs?", Weight: 1, Position: 10},
+			{Code: "threatened_children", Text: "Has the suspect threatened to harm your children?", Weight: 2, Position: 11},
+			{Code: "suicide_threat", Text: "Has the suspect threatened or tried to commit suicide?", Weight: 1, Position: 12},
+			{Code: "stalking", Text: "Does the suspect follow or spy on you, or leave threatening messages?", Weight: 1, Position: 13},
+			{Code: "believes_capable_of_killing", Text: "Do you believe the suspect is capable of killing you?", Weight: 2, Position: 14},
+		},
+	}
+
+	var questionnaireCount int64
+	db.Model(&models.RiskQuestionnaire{}).Count(&questionnaireCount)
+	if questionnaireCount == 0 {
+		if err := db.Create(&riskQuestionnaire).Error; err != nil {
+			log.Fatalf("Failed to seed risk questionnaire: %v", err)
+		} else {
+			log.Println("Risk questionnaire seeded successfully")
+		}
+	} else {
+		log.Println("Risk questionnaires already seeded, skipping...")
+	}
+
 }
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/utils"
+	"slices"
+)
+
+// RoleAdmin matches the administrator role seeded at start-up
+const RoleAdmin = "Admin"
+
+// CanAccessOwner decides whether an officer may see material attached to a
+// record. Admins see everything; other officers see records they own or that
+// belong to their police post.
+func CanAccessOwner(claims *utils.Claims, officer models.PoliceOfficer, scope repository.OwnerScope) bool {
+	if slices.Contains(claims.Roles, RoleAdmin) {
+		return true
+	}
+	if scope.OfficerID != 0 && scope.OfficerID == officer.ID {
+		return true
+	}
+	return scope.PolicePostID != 0 && scope.PolicePostID == officer.PostID
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gbvmis/internals/repository"
+	"gbvmis/internals/storage"
+)
+
+// BlobGracePeriod is how long content stays in storage after the last record
+// using it is deleted, so an upload of the same content that found it there
+// has long been recorded before it could be collected
+const BlobGracePeriod = 24 * time.Hour
+
+// blobCollectBatch is how many orphaned blobs are looked at per pass
+const blobCollectBatch = 100
+
+// CollectOrphanedBlobs removes the content of blobs that have been unused for
+// longer than the grace period and returns how many were removed
+func CollectOrphanedBlobs(ctx context.Context, repo repository.BlobRepository, store storage.BlobStore, now time.Time) (int, error) {
+	before := now.Add(-BlobGracePeriod)
+	hashes, err := repo.FindOrphanedBlobs(before, blobCollectBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, sha := range hashes {
+		purged, err := repo.PurgeBlob(sha, before, func() error {
+			return store.Delete(ctx, sha)
+		})
+		if err != nil {
+			log.Println("Error removing orphaned blob", sha, err)
+			continue
+		}
+		if purged {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// WatchOrphanedBlobs collects orphaned blobs on start-up and then on every
+// tick of the given interval. It is meant to run in its own goroutine.
+func WatchOrphanedBlobs(repo repository.BlobRepository, store storage.BlobStore, interval time.Duration) {
+	collect := func() {
+		count, err := CollectOrphanedBlobs(context.Background(), repo, store, time.Now())
+		if err != nil {
+			log.Println("Error collecting orphaned blobs:", err)
+			return
+		}
+		if count > 0 {
+			log.Printf("Removed %d orphaned blob(s)", count)
+		}
+	}
+
+	collect()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		collect()
+	}
+}
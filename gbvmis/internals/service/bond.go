@@ -0,0 +1,29 @@
+package service
+
+import (
+	"gbvmis/internals/repository"
+	"log"
+	"time"
+)
+
+// WatchBondExpiry expires overdue police bonds on start-up and then on every tick
+// of the given interval. It is meant to run in its own goroutine.
+func WatchBondExpiry(repo repository.BondRepository, interval time.Duration) {
+	expire := func() {
+		count, err := repo.ExpireBonds(time.Now())
+		if err != nil {
+			log.Println("Error expiring police bonds:", err)
+			return
+		}
+		if count > 0 {
+			log.Printf("Expired %d police bond(s)", count)
+		}
+	}
+
+	expire()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		expire()
+	}
+}
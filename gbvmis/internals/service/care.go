@@ -0,0 +1,133 @@
+package service
+
+import (
+	"gbvmis/internals/models"
+	"slices"
+	"time"
+)
+
+// CareWindows is how long after a sexual assault each time-critical service
+// can still be started: HIV post-exposure prophylaxis and emergency
+// contraception within 72 hours
+var CareWindows = map[string]time.Duration{
+	models.CareServicePEP: 72 * time.Hour,
+	models.CareServiceEC:  72 * time.Hour,
+}
+
+// CareUrgentWindow is how long before its window closes a pending service is
+// flagged urgent
+const CareUrgentWindow = 24 * time.Hour
+
+// CareClockStart is when the care clock starts for an incident. When only
+// the date is known the clock runs from the start of that day, so deadlines
+// are never later than the true ones.
+func CareClockStart(incident models.Incident) time.Time {
+	if incident.TimeKnown {
+		return incident.OccurredAt
+	}
+	occurred := incident.OccurredAt.In(time.Local)
+	return time.Date(occurred.Year(), occurred.Month(), occurred.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// hoursSince returns the hours from start to end, never negative
+func hoursSince(start, end time.Time) float64 {
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start).Hours()
+}
+
+// BuildCareChecklist lists every care service with what has been recorded
+// for it and, when the examination follows a sexual assault, the time since
+// the assault and whether time-critical services were started in time
+func BuildCareChecklist(examination models.Examination, incident *models.Incident, records []models.CareRecord, now time.Time) models.CareChecklist {
+	checklist := models.CareChecklist{ExaminationID: examination.ID}
+	var start time.Time
+	if incident != nil {
+		start = CareClockStart(*incident)
+		hours := hoursSince(start, now)
+		checklist.IncidentID = &incident.ID
+		checklist.IncidentOccurredAt = &incident.OccurredAt
+		checklist.IncidentTimeKnown = incident.TimeKnown
+		checklist.HoursSinceIncident = &hours
+	}
+
+	for _, service := range models.CareServices {
+		item := models.CareChecklistItem{Service: service, Status: models.CareStatusPending}
+		if i := slices.IndexFunc(records, func(r models.CareRecord) bool { return r.Service == service }); i >= 0 {
+			record := records[i]
+			item.Status = record.Status
+			item.ProvidedAt = record.ProvidedAt
+			item.Notes = record.Notes
+			item.RecordedByID = record.RecordedByID
+		}
+
+		if window, ok := CareWindows[service]; ok {
+			windowHours := int(window.Hours())
+			item.WindowHours = &windowHours
+			if incident != nil {
+				deadline := start.Add(window)
+				item.Deadline = &deadline
+
+				at := now
+				if item.Status == models.CareStatusProvided && item.ProvidedAt != nil {
+					at = *item.ProvidedAt
+				}
+				hours := hoursSince(start, at)
+				item.HoursAtCare = &hours
+
+				// Provided care is judged when it was given; open care only
+				// once the window has closed
+				if item.Status == models.CareStatusProvided || !now.Before(deadline) {
+					within := !at.After(deadline)
+					item.WithinWindow = &within
+				}
+			}
+		}
+		checklist.Items = append(checklist.Items, item)
+	}
+	return checklist
+}
+
+// CareAtRiskSince is the earliest incident time that can still have an open
+// window for the service
+func CareAtRiskSince(service string, now time.Time) time.Time {
+	// Incidents with only a date start their clock at midnight, up to a day
+	// before the recorded time
+	return now.Add(-CareWindows[service] - 24*time.Hour)
+}
+
+// FacilitiesAtRisk works out the deadline of each pending service, drops
+// those whose window has already closed and groups the rest by facility,
+// the facility with the nearest deadline first
+func FacilitiesAtRisk(pending []models.PendingCare, now time.Time) []models.FacilityCareRisk {
+	var open []models.PendingCare
+	for _, care := range pending {
+		start := CareClockStart(models.Incident{OccurredAt: care.IncidentOccurredAt, TimeKnown: care.IncidentTimeKnown})
+		care.Deadline = start.Add(CareWindows[care.Service])
+		if !now.Before(care.Deadline) {
+			continue
+		}
+		care.HoursSinceIncident = hoursSince(start, now)
+		care.HoursRemaining = care.Deadline.Sub(now).Hours()
+		care.Urgent = care.Deadline.Sub(now) <= CareUrgentWindow
+		open = append(open, care)
+	}
+	slices.SortStableFunc(open, func(a, b models.PendingCare) int { return a.Deadline.Compare(b.Deadline) })
+
+	var facilities []models.FacilityCareRisk
+	index := make(map[uint]int)
+	for _, care := range open {
+		i, ok := index[care.FacilityID]
+		if !ok {
+			i = len(facilities)
+			index[care.FacilityID] = i
+			facilities = append(facilities, models.FacilityCareRisk{FacilityID: care.FacilityID, Facility: care.Facility})
+		}
+		if care.Urgent {
+			facilities[i].Urgent++
+		}
+		facilities[i].AtRisk = append(facilities[i].AtRisk, care)
+	}
+	return facilities
+}
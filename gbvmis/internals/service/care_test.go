@@ -0,0 +1,268 @@
+package service
+
+import (
+	"gbvmis/internals/models"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestCareClockStart(t *testing.T) {
+	occurred := time.Date(2026, 3, 10, 21, 30, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		timeKnown bool
+		want      time.Time
+	}{
+		{"time known", true, occurred},
+		{"date only", false, time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local)},
+	}
+	for _, test := range tests {
+		if got := CareClockStart(models.Incident{OccurredAt: occurred, TimeKnown: test.timeKnown}); !got.Equal(test.want) {
+			t.Errorf("%s: clock starts %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// checklistItem returns the checklist entry for a service
+func checklistItem(t *testing.T, checklist models.CareChecklist, service string) models.CareChecklistItem {
+	t.Helper()
+	for _, item := range checklist.Items {
+		if item.Service == service {
+			return item
+		}
+	}
+	t.Fatalf("no %s on the checklist", service)
+	return models.CareChecklistItem{}
+}
+
+func TestBuildCareChecklist(t *testing.T) {
+	occurred := time.Date(2026, 3, 10, 21, 0, 0, 0, time.Local)
+	deadline := occurred.Add(72 * time.Hour)
+	midnightDeadline := time.Date(2026, 3, 13, 0, 0, 0, 0, time.Local)
+	at := func(d time.Time) *time.Time { return &d }
+	yes, no := true, false
+
+	tests := []struct {
+		name         string
+		timeKnown    bool
+		record       *models.CareRecord // for PEP
+		now          time.Time
+		wantDeadline time.Time
+		wantHours    float64 // PEP hours_since_incident
+		wantWithin   *bool
+	}{
+		{
+			name:         "provided within the window",
+			timeKnown:    true,
+			record:       &models.CareRecord{Service: models.CareServicePEP, Status: models.CareStatusProvided, ProvidedAt: at(occurred.Add(30 * time.Hour))},
+			now:          occurred.Add(100 * time.Hour),
+			wantDeadline: deadline,
+			wantHours:    30,
+			wantWithin:   &yes,
+		},
+		{
+			name:         "provided exactly at the deadline",
+			timeKnown:    true,
+			record:       &models.CareRecord{Service: models.CareServicePEP, Status: models.CareStatusProvided, ProvidedAt: at(deadline)},
+			now:          occurred.Add(100 * time.Hour),
+			wantDeadline: deadline,
+			wantHours:    72,
+			wantWithin:   &yes,
+		},
+		{
+			name:         "provided after the deadline",
+			timeKnown:    true,
+			record:       &models.CareRecord{Service: models.CareServicePEP, Status: models.CareStatusProvided, ProvidedAt: at(deadline.Add(time.Minute))},
+			now:          occurred.Add(100 * time.Hour),
+			wantDeadline: deadline,
+			wantHours:    72 + 1.0/60,
+			wantWithin:   &no,
+		},
+		{
+			name:         "open with time left is not yet judged",
+			timeKnown:    true,
+			now:          occurred.Add(50 * time.Hour),
+			wantDeadline: deadline,
+			wantHours:    50,
+		},
+		{
+			name:         "referred counts as open",
+			timeKnown:    true,
+			record:       &models.CareRecord{Service: models.CareServicePEP, Status: models.CareStatusReferred},
+			now:          occurred.Add(50 * time.Hour),
+			wantDeadline: deadline,
+			wantHours:    50,
+		},
+		{
+			name:         "open when the window closes is missed",
+			timeKnown:    true,
+			now:          deadline.Add(time.Minute),
+			wantDeadline: deadline,
+			wantHours:    72 + 1.0/60,
+			wantWithin:   &no,
+		},
+		{
+			name:         "unknown time runs from midnight",
+			timeKnown:    false,
+			record:       &models.CareRecord{Service: models.CareServicePEP, Status: models.CareStatusProvided, ProvidedAt: at(midnightDeadline.Add(-time.Hour))},
+			now:          occurred.Add(100 * time.Hour),
+			wantDeadline: midnightDeadline,
+			wantHours:    71,
+			wantWithin:   &yes,
+		},
+		{
+			name:         "unknown time misses the window sooner",
+			timeKnown:    false,
+			record:       &models.CareRecord{Service: models.CareServicePEP, Status: models.CareStatusProvided, ProvidedAt: at(occurred.Add(70 * time.Hour))},
+			now:          occurred.Add(100 * time.Hour),
+			wantDeadline: midnightDeadline,
+			wantHours:    91,
+			wantWithin:   &no,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			incident := models.Incident{Model: gorm.Model{ID: 3}, OccurredAt: occurred, TimeKnown: test.timeKnown}
+			var records []models.CareRecord
+			if test.record != nil {
+				records = append(records, *test.record)
+			}
+			checklist := BuildCareChecklist(models.Examination{Model: gorm.Model{ID: 9}}, &incident, records, test.now)
+			if len(checklist.Items) != len(models.CareServices) {
+				t.Fatalf("%d items, want one per service", len(checklist.Items))
+			}
+			if checklist.IncidentTimeKnown != test.timeKnown || checklist.IncidentID == nil || *checklist.IncidentID != 3 {
+				t.Errorf("incident not recorded on the checklist: %+v", checklist)
+			}
+
+			pep := checklistItem(t, checklist, models.CareServicePEP)
+			if pep.Deadline == nil || !pep.Deadline.Equal(test.wantDeadline) {
+				t.Errorf("deadline %v, want %v", pep.Deadline, test.wantDeadline)
+			}
+			if pep.HoursAtCare == nil || *pep.HoursAtCare-test.wantHours > 1e-9 || test.wantHours-*pep.HoursAtCare > 1e-9 {
+				t.Errorf("hours since incident %v, want %v", pep.HoursAtCare, test.wantHours)
+			}
+			switch {
+			case test.wantWithin == nil && pep.WithinWindow != nil:
+				t.Errorf("within window %v, want not yet judged", *pep.WithinWindow)
+			case test.wantWithin != nil && (pep.WithinWindow == nil || *pep.WithinWindow != *test.wantWithin):
+				t.Errorf("within window %v, want %v", pep.WithinWindow, *test.wantWithin)
+			}
+
+			counselling := checklistItem(t, checklist, models.CareServiceCounselling)
+			if counselling.WindowHours != nil || counselling.Deadline != nil {
+				t.Errorf("counselling has a window: %+v", counselling)
+			}
+		})
+	}
+}
+
+func TestBuildCareChecklistWithoutIncident(t *testing.T) {
+	checklist := BuildCareChecklist(models.Examination{Model: gorm.Model{ID: 9}}, nil, nil, time.Now())
+	if checklist.HoursSinceIncident != nil || checklist.IncidentID != nil {
+		t.Fatalf("clock set without an incident: %+v", checklist)
+	}
+	pep := checklistItem(t, checklist, models.CareServicePEP)
+	if pep.Status != models.CareStatusPending || pep.WindowHours == nil || *pep.WindowHours != 72 {
+		t.Errorf("pep = %+v, want pending with a 72-hour window", pep)
+	}
+	if pep.Deadline != nil || pep.HoursAtCare != nil || pep.WithinWindow != nil {
+		t.Errorf("pep measured without an incident: %+v", pep)
+	}
+}
+
+func TestFacilitiesAtRisk(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.Local)
+	pending := func(examination, facility uint, service string, occurred time.Time, timeKnown bool) models.PendingCare {
+		return models.PendingCare{
+			ExaminationID: examination, FacilityID: facility, Facility: map[uint]string{1: "Mulago", 2: "Kiruddu"}[facility],
+			Service: service, Status: models.CareStatusPending, IncidentOccurredAt: occurred, IncidentTimeKnown: timeKnown,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		pending []models.PendingCare
+		want    map[uint][]uint // facility: examinations, in order
+		order   []uint          // facilities, nearest deadline first
+		urgent  map[uint]int
+	}{
+		{
+			name:    "nothing pending",
+			pending: nil,
+			want:    map[uint][]uint{},
+		},
+		{
+			name: "closed windows are dropped",
+			pending: []models.PendingCare{
+				pending(1, 1, models.CareServicePEP, now.Add(-72*time.Hour), true),
+				pending(2, 1, models.CareServiceEC, now.Add(-80*time.Hour), true),
+			},
+			want: map[uint][]uint{},
+		},
+		{
+			name: "grouped by facility, nearest deadline first",
+			pending: []models.PendingCare{
+				pending(1, 1, models.CareServicePEP, now.Add(-10*time.Hour), true), // 62h left
+				pending(2, 2, models.CareServicePEP, now.Add(-60*time.Hour), true), // 12h left, urgent
+				pending(3, 1, models.CareServiceEC, now.Add(-50*time.Hour), true),  // 22h left, urgent
+				pending(4, 2, models.CareServiceEC, now.Add(-47*time.Hour), true),  // 25h left
+			},
+			want:   map[uint][]uint{1: {3, 1}, 2: {2, 4}},
+			order:  []uint{2, 1},
+			urgent: map[uint]int{1: 1, 2: 1},
+		},
+		{
+			name: "unknown time brings the deadline forward",
+			pending: []models.PendingCare{
+				// 2026-03-13 at 23:00 would leave 59 hours; from midnight it is 36
+				pending(1, 1, models.CareServicePEP, time.Date(2026, 3, 13, 23, 0, 0, 0, time.Local), false),
+				// At 14:00 on 2026-03-11 the window would still be open, but
+				// from midnight it closed at midnight on the 14th
+				pending(2, 1, models.CareServiceEC, time.Date(2026, 3, 11, 14, 0, 0, 0, time.Local), false),
+			},
+			want:  map[uint][]uint{1: {1}},
+			order: []uint{1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			facilities := FacilitiesAtRisk(test.pending, now)
+			if len(facilities) != len(test.want) {
+				t.Fatalf("%d facilities, want %d: %+v", len(facilities), len(test.want), facilities)
+			}
+			for i, facility := range facilities {
+				if facility.FacilityID != test.order[i] {
+					t.Errorf("facility %d is %d, want %d", i, facility.FacilityID, test.order[i])
+				}
+				var examinations []uint
+				for _, care := range facility.AtRisk {
+					examinations = append(examinations, care.ExaminationID)
+					if care.HoursRemaining <= 0 || !care.Deadline.After(now) {
+						t.Errorf("examination %d listed with its window closed", care.ExaminationID)
+					}
+					if care.Urgent != (care.HoursRemaining <= 24) {
+						t.Errorf("examination %d urgent %v with %.1f hours left", care.ExaminationID, care.Urgent, care.HoursRemaining)
+					}
+				}
+				if !slices.Equal(examinations, test.want[facility.FacilityID]) {
+					t.Errorf("facility %d lists %v, want %v", facility.FacilityID, examinations, test.want[facility.FacilityID])
+				}
+				if facility.Urgent != test.urgent[facility.FacilityID] {
+					t.Errorf("facility %d has %d urgent, want %d", facility.FacilityID, facility.Urgent, test.urgent[facility.FacilityID])
+				}
+			}
+		})
+	}
+
+	facilities := FacilitiesAtRisk([]models.PendingCare{
+		pending(1, 1, models.CareServicePEP, time.Date(2026, 3, 13, 23, 0, 0, 0, time.Local), false),
+	}, now)
+	care := facilities[0].AtRisk[0]
+	if care.HoursRemaining != 36 || care.HoursSinceIncident != 36 || facilities[0].Facility != "Mulago" {
+		t.Errorf("unknown-time care = %+v in %q, want 36 hours left after 36", care, facilities[0].Facility)
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"encoding/json"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// What a case with a child survivor must have
+const (
+	RequirementGuardian                = "guardian_recorded"
+	RequirementNonOffendingGuardian    = "non_offending_guardian"
+	RequirementChildProtectionReferral = "child_protection_referral"
+)
+
+// ChildProtectionStatus reports, for one child survivor in a case, which
+// child-protection requirements are met
+type ChildProtectionStatus struct {
+	VictimID      uint     `json:"victim_id"`
+	AgeAtIncident int      `json:"age_at_incident"`
+	ReferralID    *uint    `json:"referral_id"`
+	Met           []string `json:"met"`
+	Missing       []string `json:"missing"`
+}
+
+// CaseIncidentDate is the date a case's victims' ages are judged at: when
+// its earliest recorded incident happened, or when it was opened if no
+// incident is recorded or they were not loaded
+func CaseIncidentDate(c models.Case) time.Time {
+	var earliest time.Time
+	for _, incident := range c.Incidents {
+		if !incident.OccurredAt.IsZero() && (earliest.IsZero() || incident.OccurredAt.Before(earliest)) {
+			earliest = incident.OccurredAt
+		}
+	}
+	if earliest.IsZero() {
+		return c.DateOpened
+	}
+	return earliest
+}
+
+// VictimAgeInCase returns the victim's age on the case's incident date
+func VictimAgeInCase(v models.Victim, c models.Case) (int, bool) {
+	return models.AgeAt(v.Dob, CaseIncidentDate(c))
+}
+
+// IsMinorInCase reports whether the victim was a child when the incident happened
+func IsMinorInCase(v models.Victim, c models.Case) bool {
+	age, ok := VictimAgeInCase(v, c)
+	return ok && age < models.AdultAge
+}
+
+// initial shortens a name to its first letter
+func initial(name string) string {
+	for _, r := range name {
+		return string(r) + "."
+	}
+	return ""
+}
+
+// RedactVictim hides what would identify or locate the victim, keeping
+// initials, gender and age
+func RedactVictim(v *models.Victim) {
+	v.FirstName = initial(v.FirstName)
+	v.LastName = initial(v.LastName)
+	v.PhoneNumber = ""
+	v.Address = ""
+	v.Nin = ""
+	v.AddressAreaID = nil
+	v.Guardians = nil
+	v.Redacted = true
+}
+
+// MarkMinors sets IsMinor on each victim who was a child at the incident date
+// of any of their cases. Victims without cases keep their current-age status.
+func MarkMinors(victims []models.Victim, cases map[uint][]models.Case) {
+	for i := range victims {
+		victimCases := cases[victims[i].ID]
+		if len(victimCases) == 0 {
+			continue
+		}
+		victims[i].IsMinor = slices.ContainsFunc(victimCases, func(c models.Case) bool {
+			return IsMinorInCase(victims[i], c)
+		})
+	}
+}
+
+// IsMinorInAnyCase reports whether the victim is a child now or was one at the
+// incident date of any of the cases
+func IsMinorInAnyCase(v models.Victim, cases []models.Case) bool {
+	if age, ok := models.AgeAt(v.Dob, time.Now()); ok && age < models.AdultAge {
+		return true
+	}
+	return slices.ContainsFunc(cases, func(c models.Case) bool {
+		return IsMinorInCase(v, c)
+	})
+}
+
+// RedactedName shortens each part of a name to its initial
+func RedactedName(name string) string {
+	parts := strings.Fields(name)
+	for i, part := range parts {
+		parts[i] = initial(part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// RedactVictimSnapshot redacts a merged victim's snapshot when the victim was
+// a child in any of the cases, which are those of the record it was merged
+// into. The stored audit record keeps the full snapshot.
+func RedactVictimSnapshot(snapshot datatypes.JSON, cases []models.Case) (datatypes.JSON, error) {
+	var victim models.Victim
+	if err := json.Unmarshal(snapshot, &victim); err != nil {
+		return nil, err
+	}
+	if !IsMinorInAnyCase(victim, cases) {
+		return snapshot, nil
+	}
+	victim.IsMinor = true
+	RedactVictim(&victim)
+	return json.Marshal(victim)
+}
+
+// RedactMinors redacts every child in the list. Lists and searches always
+// show children this way; the full record is only on the victim's own page.
+func RedactMinors(victims []models.Victim) {
+	for i := range victims {
+		if victims[i].IsMinor {
+			RedactVictim(&victims[i])
+		}
+	}
+}
+
+// CanSeeChildDetails decides whether an officer may see a child survivor's
+// full record: admins, and officers responsible for one of the child's cases
+func CanSeeChildDetails(claims *utils.Claims, officer models.PoliceOfficer, scopes []repository.OwnerScope) bool {
+	if slices.Contains(claims.Roles, RoleAdmin) {
+		return true
+	}
+	for _, scope := range scopes {
+		if CanAccessOwner(claims, officer, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChildProtectionRequirements checks each child survivor in the case for a
+// recorded guardian, a guardian who is not a suspect, and a referral to a
+// child protection provider. The case must be loaded with its incidents and
+// its victims' guardians; referrals are the case's referrals with their
+// providers.
+func ChildProtectionRequirements(c models.Case, referrals []models.Referral) []ChildProtectionStatus {
+	var statuses []ChildProtectionStatus
+	for _, v := range c.Victims {
+		age, ok := VictimAgeInCase(v, c)
+		if !ok || age >= models.AdultAge {
+			continue
+		}
+		status := ChildProtectionStatus{VictimID: v.ID, AgeAtIncident: age}
+		check := func(requirement string, met bool) {
+			if met {
+				status.Met = append(status.Met, requirement)
+			} else {
+				status.Missing = append(status.Missing, requirement)
+			}
+		}
+
+		check(RequirementGuardian, len(v.Guardians) > 0)
+		check(RequirementNonOffendingGuardian, slices.ContainsFunc(v.Guardians, func(g models.Guardian) bool {
+			return g.SuspectID == nil
+		}))
+
+		for _, r := range referrals {
+			if r.VictimID == v.ID && r.Provider.ProviderType == models.ProviderChildProtection &&
+				r.Status != models.ReferralStatusDeclined {
+				id := r.ID
+				status.ReferralID = &id
+				break
+			}
+		}
+		check(RequirementChildProtectionReferral, status.ReferralID != nil)
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
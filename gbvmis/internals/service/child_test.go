@@ -0,0 +1,103 @@
+package service
+
+import (
+	"encoding/json"
+	"gbvmis/internals/models"
+	"testing"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func TestIsMinorInAnyCase(t *testing.T) {
+	now := time.Now()
+	adult := now.AddDate(-20, 0, 0)
+	caseAt := func(occurred time.Time) models.Case {
+		return models.Case{Incidents: []models.Incident{{OccurredAt: occurred}}}
+	}
+	tests := []struct {
+		name  string
+		dob   time.Time
+		cases []models.Case
+		want  bool
+	}{
+		{"child now", now.AddDate(-12, 0, 0), nil, true},
+		{"adult without cases", adult, nil, false},
+		{"adult who was a child at an incident", adult, []models.Case{caseAt(now.AddDate(-5, 0, 0)), caseAt(now)}, true},
+		{"adult at every incident", adult, []models.Case{caseAt(now.AddDate(-1, 0, 0))}, false},
+		{"date of birth unknown", time.Time{}, []models.Case{caseAt(now)}, false},
+	}
+	for _, test := range tests {
+		if got := IsMinorInAnyCase(models.Victim{Dob: test.dob}, test.cases); got != test.want {
+			t.Errorf("%s: minor = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRedactedName(t *testing.T) {
+	tests := map[string]string{
+		"":                 "",
+		"Amina":            "A.",
+		" Amina  Nakato ":  "A. N.",
+		"Éva Nalubega Ssa": "É. N. S.",
+	}
+	for in, want := range tests {
+		if got := RedactedName(in); got != want {
+			t.Errorf("RedactedName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRedactVictimSnapshot(t *testing.T) {
+	victim := models.Victim{
+		Model: gorm.Model{ID: 4}, FirstName: "Amina", LastName: "Nakato",
+		Dob: time.Now().AddDate(-19, 0, 0), PhoneNumber: "0772000000", Nin: "CF123", Address: "Kireka",
+	}
+	snapshot, _ := json.Marshal(victim)
+	childhoodCase := models.Case{Incidents: []models.Incident{{OccurredAt: time.Now().AddDate(-3, 0, 0)}}}
+
+	got, err := RedactVictimSnapshot(snapshot, []models.Case{childhoodCase})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var redacted models.Victim
+	if err := json.Unmarshal(got, &redacted); err != nil {
+		t.Fatal(err)
+	}
+	if redacted.FirstName != "A." || redacted.LastName != "N." || redacted.PhoneNumber != "" || redacted.Nin != "" || redacted.Address != "" || !redacted.Redacted || redacted.ID != 4 {
+		t.Errorf("snapshot of a child not redacted: %+v", redacted)
+	}
+
+	if got, err := RedactVictimSnapshot(snapshot, nil); err != nil || string(got) != string(snapshot) {
+		t.Errorf("snapshot of an adult changed: %s, %v", got, err)
+	}
+	if _, err := RedactVictimSnapshot(datatypes.JSON("{"), nil); err == nil {
+		t.Error("bad snapshot accepted")
+	}
+}
+
+func TestRedactMinorMatches(t *testing.T) {
+	matches := []VictimMatch{
+		{VictimID: 1, FullName: "Amina Nakato", Dob: time.Now().AddDate(-10, 0, 0), Nin: "CF1", PhoneNumber: "0772000001"},
+		{VictimID: 2, FullName: "Grace Auma", Dob: time.Now().AddDate(-30, 0, 0), Nin: "CF2", PhoneNumber: "0772000002"},
+		{VictimID: 3, FullName: "Sarah Akello", Dob: time.Now().AddDate(-20, 0, 0), Nin: "CF3", PhoneNumber: "0772000003"},
+	}
+	cases := map[uint][]models.Case{
+		3: {{DateOpened: time.Now().AddDate(-4, 0, 0)}},
+	}
+	RedactMinorMatches(matches, cases)
+
+	for i, want := range []bool{true, false, true} {
+		m := matches[i]
+		if m.Redacted != want {
+			t.Errorf("victim %d redacted = %v, want %v", m.VictimID, m.Redacted, want)
+		}
+		if want && (m.Nin != "" || m.PhoneNumber != "" || m.FullName == "Amina Nakato" || m.FullName == "Sarah Akello") {
+			t.Errorf("victim %d still identifiable: %+v", m.VictimID, m)
+		}
+	}
+	if matches[1].FullName != "Grace Auma" || matches[1].Nin != "CF2" {
+		t.Errorf("adult match changed: %+v", matches[1])
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronRejects(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@hourly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-b * * * *",
+		"1,,2 * * * *",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) accepted", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"*/15 * * * *", at(2026, 10, 16, 10, 7), at(2026, 10, 16, 10, 15)},
+		{"*/15 * * * *", at(2026, 10, 16, 10, 15).Add(30 * time.Second), at(2026, 10, 16, 10, 30)},
+		{"5/20 * * * *", at(2026, 10, 16, 10, 6), at(2026, 10, 16, 10, 25)},
+		{"0,30 8-9 * * *", at(2026, 10, 16, 9, 30), at(2026, 10, 17, 8, 0)},
+		{"0 9 * * 1-5", at(2026, 10, 16, 10, 0), at(2026, 10, 19, 9, 0)},
+		{"30 8 * * 7", at(2026, 10, 16, 10, 0), at(2026, 10, 18, 8, 30)},
+		{"0 0 1 * 0", at(2026, 10, 2, 0, 0), at(2026, 10, 4, 0, 0)},
+		{"0 0 1 * 0", at(2026, 10, 25, 0, 0), at(2026, 11, 1, 0, 0)},
+		{"0 0 31 * *", at(2026, 4, 1, 0, 0), at(2026, 5, 31, 0, 0)},
+		{"0 0 29 2 *", at(2026, 3, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+		{"0 0 30 2 *", at(2026, 3, 1, 0, 0), time.Time{}},
+		{"@monthly", at(2026, 1, 31, 12, 0), at(2026, 2, 1, 0, 0)},
+		{"@quarterly", at(2026, 4, 1, 0, 0), at(2026, 7, 1, 0, 0)},
+		{"@yearly", at(2026, 12, 31, 23, 59), at(2027, 1, 1, 0, 0)},
+		{" @DAILY ", at(2026, 10, 18, 23, 59), at(2026, 10, 19, 0, 0)},
+		{"@weekly", at(2026, 10, 18, 0, 0), at(2026, 10, 25, 0, 0)},
+	}
+	for _, test := range tests {
+		schedule, err := ParseCron(test.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", test.expr, err)
+			continue
+		}
+		if got := schedule.Next(test.from); !got.Equal(test.want) {
+			t.Errorf("%q after %s: got %s, want %s", test.expr, test.from.Format(time.RFC3339), got.Format(time.RFC3339), test.want.Format(time.RFC3339))
+		}
+	}
+}
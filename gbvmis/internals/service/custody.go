@@ -0,0 +1,58 @@
+package service
+
+import (
+	"gbvmis/internals/models"
+	"time"
+)
+
+// CustodyLimit is the constitutional limit for holding a suspect before
+// production in court (Article 23(4)(b), Constitution of Uganda)
+const CustodyLimit = 48 * time.Hour
+
+// CustodyWarningWindow is how long before the limit a detention is flagged
+const CustodyWarningWindow = 6 * time.Hour
+
+// Custody warning levels
+const (
+	CustodyWarningNone        = ""
+	CustodyWarningApproaching = "approaching_48h_limit"
+	CustodyWarningOverdue     = "exceeded_48h_limit"
+)
+
+// CustodyDeadline returns the time by which the suspect must be produced in court
+func CustodyDeadline(record models.CustodyRecord) time.Time {
+	return record.DetentionStart.Add(CustodyLimit)
+}
+
+// HoursInCustody returns how long the suspect has been held, up to release
+// or production in court if either has happened
+func HoursInCustody(record models.CustodyRecord, now time.Time) float64 {
+	end := now
+	if record.ProducedInCourtAt != nil && record.ProducedInCourtAt.Before(end) {
+		end = *record.ProducedInCourtAt
+	}
+	if record.ReleasedAt != nil && record.ReleasedAt.Before(end) {
+		end = *record.ReleasedAt
+	}
+	if end.Before(record.DetentionStart) {
+		return 0
+	}
+	return end.Sub(record.DetentionStart).Hours()
+}
+
+// CustodyWarning reports whether a detention is approaching or past the 48-hour limit.
+// Records that are closed or already produced in court never warn.
+func CustodyWarning(record models.CustodyRecord, now time.Time) string {
+	if record.Status != models.CustodyStatusInCustody || record.ProducedInCourtAt != nil {
+		return CustodyWarningNone
+	}
+	deadline := CustodyDeadline(record)
+	switch {
+	case !now.Before(deadline):
+		return CustodyWarningOverdue
+	case !now.Before(deadline.Add(-CustodyWarningWindow)):
+		return CustodyWarningApproaching
+	default:
+		return CustodyWarningNone
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gbvmis/internals/config"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDhis2NotConfigured means DHIS2_URL is not set
+var ErrDhis2NotConfigured = errors.New("DHIS2 is not configured; set DHIS2_URL, DHIS2_USERNAME and DHIS2_PASSWORD")
+
+// ErrNothingToPush means no data element or no facility is mapped yet
+var ErrNothingToPush = errors.New("nothing to push; map data elements and facilities first")
+
+var (
+	dhis2Monthly   = regexp.MustCompile(`^(\d{4})(0[1-9]|1[0-2])$`)
+	dhis2Quarterly = regexp.MustCompile(`^(\d{4})Q([1-4])$`)
+	dhis2UID       = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]{10}$`)
+)
+
+// ParseDhis2Period returns the first and last day of a DHIS2 monthly
+// (YYYYMM) or quarterly (YYYYQn) period
+func ParseDhis2Period(period string) (time.Time, time.Time, error) {
+	if m := dhis2Monthly.FindStringSubmatch(period); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		start, end := ReportPeriodRange(models.ReportPeriodMonthly, time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.Local))
+		return start, end, nil
+	}
+	if m := dhis2Quarterly.FindStringSubmatch(period); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		quarter, _ := strconv.Atoi(m[2])
+		start, end := ReportPeriodRange(models.ReportPeriodQuarterly, time.Date(year, time.Month(quarter*3-2), 1, 0, 0, 0, 0, time.Local))
+		return start, end, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("period %q must be YYYYMM or YYYYQn", period)
+}
+
+// IsDhis2UID reports whether s has the shape of a DHIS2 identifier: eleven
+// letters and digits starting with a letter
+func IsDhis2UID(s string) bool {
+	return dhis2UID.MatchString(s)
+}
+
+// BuildDataValueSet computes the mapped indicators of every mapped
+// organisation unit for a period. Facilities sharing an organisation unit are
+// added together, since DHIS2 keeps one value per unit. Zero counts are
+// included so a re-push overwrites stale values. It also returns the
+// facilities with examinations in the period but no organisation unit, whose
+// values are left out.
+func BuildDataValueSet(repo repository.Dhis2Repository, period string) (models.Dhis2DataValueSet, []uint, error) {
+	set := models.Dhis2DataValueSet{DataSet: config.Config("DHIS2_DATASET"), DataValues: []models.Dhis2DataValue{}}
+	start, end, err := ParseDhis2Period(period)
+	if err != nil {
+		return set, nil, err
+	}
+
+	elements, err := repo.GetDataElementMappings()
+	if err != nil {
+		return set, nil, err
+	}
+	orgUnits, err := repo.GetOrgUnitMappings()
+	if err != nil {
+		return set, nil, err
+	}
+	counts, err := repo.GetFacilityExaminationCounts(start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return set, nil, err
+	}
+
+	byFacility := make(map[uint]models.FacilityExaminationCounts, len(counts))
+	for _, count := range counts {
+		byFacility[count.FacilityID] = count
+	}
+	var units []string
+	facilities := map[string][]uint{}
+	mapped := map[uint]bool{}
+	for _, orgUnit := range orgUnits {
+		mapped[orgUnit.FacilityID] = true
+		if _, seen := facilities[orgUnit.OrgUnit]; !seen {
+			units = append(units, orgUnit.OrgUnit)
+		}
+		facilities[orgUnit.OrgUnit] = append(facilities[orgUnit.OrgUnit], orgUnit.FacilityID)
+	}
+
+	for _, unit := range units {
+		for _, element := range elements {
+			var value int64
+			for _, facilityID := range facilities[unit] {
+				value += byFacility[facilityID].Value(element.Indicator)
+			}
+			set.DataValues = append(set.DataValues, models.Dhis2DataValue{
+				DataElement:         element.DataElement,
+				Period:              period,
+				OrgUnit:             unit,
+				CategoryOptionCombo: element.CategoryOptionCombo,
+				Value:               strconv.FormatInt(value, 10),
+			})
+		}
+	}
+	unmapped := []uint{}
+	for _, count := range counts {
+		if !mapped[count.FacilityID] {
+			unmapped = append(unmapped, count.FacilityID)
+		}
+	}
+	return set, unmapped, nil
+}
+
+// =================================
+
+// Dhis2Client pushes data value sets to a DHIS2 server's web API
+type Dhis2Client struct {
+	BaseURL  string
+	Username string
+	Password string
+	HTTP     *http.Client
+}
+
+// NewDhis2ClientFromEnv builds a client from DHIS2_URL, DHIS2_USERNAME and
+// DHIS2_PASSWORD
+func NewDhis2ClientFromEnv() (*Dhis2Client, error) {
+	baseURL := config.Config("DHIS2_URL")
+	if baseURL == "" {
+		return nil, ErrDhis2NotConfigured
+	}
+	return &Dhis2Client{
+		BaseURL:  strings.TrimRight(baseURL, "/"),
+		Username: config.Config("DHIS2_USERNAME"),
+		Password: config.Config("DHIS2_PASSWORD"),
+		HTTP:     &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// Dhis2ImportSummary is what DHIS2 reports after importing a data value set
+type Dhis2ImportSummary struct {
+	Status    string // success, warning or failed
+	Imported  int
+	Updated   int
+	Ignored   int
+	Deleted   int
+	Conflicts []string
+}
+
+// dhis2Summary is the import summary; from DHIS2 2.38 it comes wrapped in a
+// web message under "response"
+type dhis2Summary struct {
+	Status      string `json:"status"`
+	Description string `json:"description"`
+	ImportCount struct {
+		Imported int `json:"imported"`
+		Updated  int `json:"updated"`
+		Ignored  int `json:"ignored"`
+		Deleted  int `json:"deleted"`
+	} `json:"importCount"`
+	Conflicts []struct {
+		Object string `json:"object"`
+		Value  string `json:"value"`
+	} `json:"conflicts"`
+}
+
+type dhis2Response struct {
+	dhis2Summary
+	Message  string        `json:"message"`
+	Response *dhis2Summary `json:"response"`
+}
+
+// PushDataValueSet posts the set to /api/dataValueSets and returns DHIS2's
+// import summary. Values DHIS2 rejects come back as conflicts, not errors.
+func (d *Dhis2Client) PushDataValueSet(ctx context.Context, set models.Dhis2DataValueSet) (Dhis2ImportSummary, error) {
+	body, err := json.Marshal(set)
+	if err != nil {
+		return Dhis2ImportSummary{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.BaseURL+"/api/dataValueSets", bytes.NewReader(body))
+	if err != nil {
+		return Dhis2ImportSummary{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(d.Username, d.Password)
+
+	resp, err := d.HTTP.Do(req)
+	if err != nil {
+		return Dhis2ImportSummary{}, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Dhis2ImportSummary{}, err
+	}
+
+	var parsed dhis2Response
+	// Errors such as bad credentials come without an import summary
+	if err := json.Unmarshal(raw, &parsed); err != nil || (parsed.Response == nil && (parsed.Status == "" || resp.StatusCode >= 300)) {
+		return Dhis2ImportSummary{}, fmt.Errorf("DHIS2 answered %s: %s", resp.Status, strings.TrimSpace(string(raw[:min(len(raw), 500)])))
+	}
+	summary := parsed.dhis2Summary
+	if parsed.Response != nil {
+		summary = *parsed.Response
+	}
+
+	result := Dhis2ImportSummary{
+		Imported: summary.ImportCount.Imported,
+		Updated:  summary.ImportCount.Updated,
+		Ignored:  summary.ImportCount.Ignored,
+		Deleted:  summary.ImportCount.Deleted,
+	}
+	for _, conflict := range summary.Conflicts {
+		result.Conflicts = append(result.Conflicts, strings.TrimSpace(conflict.Object+": "+conflict.Value))
+	}
+	switch strings.ToUpper(summary.Status) {
+	case "SUCCESS", "OK":
+		result.Status = models.Dhis2PushSuccess
+	case "WARNING":
+		result.Status = models.Dhis2PushWarning
+	default:
+		result.Status = models.Dhis2PushFailed
+		if message := strings.TrimSpace(summary.Description + " " + parsed.Message); message != "" {
+			result.Conflicts = append(result.Conflicts, message)
+		}
+	}
+	return result, nil
+}
+
+// PushDhis2Period builds the period's data value set, pushes it and records
+// the outcome. The push is recorded even when it fails.
+func PushDhis2Period(ctx context.Context, repo repository.Dhis2Repository, client *Dhis2Client, period string, userID uint) (models.Dhis2Push, error) {
+	set, _, err := BuildDataValueSet(repo, period)
+	if err != nil {
+		return models.Dhis2Push{}, err
+	}
+	if len(set.DataValues) == 0 {
+		return models.Dhis2Push{}, ErrNothingToPush
+	}
+
+	push := models.Dhis2Push{Period: period, PushedByID: userID, PushedAt: time.Now(), Values: len(set.DataValues)}
+	summary, err := client.PushDataValueSet(ctx, set)
+	if err != nil {
+		push.Status = models.Dhis2PushFailed
+		push.Error = err.Error()
+	} else {
+		push.Status = summary.Status
+		push.Imported, push.Updated, push.Ignored, push.Deleted = summary.Imported, summary.Updated, summary.Ignored, summary.Deleted
+		push.Conflicts = strings.Join(summary.Conflicts, "\n")
+	}
+	if err := repo.CreateDhis2Push(&push); err != nil {
+		return push, err
+	}
+	return push, nil
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+)
+
+// fakeDhis2Repo serves the mappings and counts BuildDataValueSet reads
+type fakeDhis2Repo struct {
+	repository.Dhis2Repository
+	elements []models.Dhis2DataElementMapping
+	orgUnits []models.Dhis2OrgUnitMapping
+	counts   []models.FacilityExaminationCounts
+}
+
+func (f fakeDhis2Repo) GetDataElementMappings() ([]models.Dhis2DataElementMapping, error) {
+	return f.elements, nil
+}
+
+func (f fakeDhis2Repo) GetOrgUnitMappings() ([]models.Dhis2OrgUnitMapping, error) {
+	return f.orgUnits, nil
+}
+
+func (f fakeDhis2Repo) GetFacilityExaminationCounts(from, to string) ([]models.FacilityExaminationCounts, error) {
+	return f.counts, nil
+}
+
+func TestBuildDataValueSetSumsFacilitiesSharingAnOrgUnit(t *testing.T) {
+	repo := fakeDhis2Repo{
+		elements: []models.Dhis2DataElementMapping{
+			{Indicator: models.Dhis2Examinations, DataElement: "DEexams0001"},
+			{Indicator: models.Dhis2ExaminationsChild, DataElement: "DEchild0001", CategoryOptionCombo: "COCunder180"},
+		},
+		orgUnits: []models.Dhis2OrgUnitMapping{
+			{FacilityID: 1, OrgUnit: "OUmulago001"},
+			{FacilityID: 2, OrgUnit: "OUmulago001"},
+			{FacilityID: 3, OrgUnit: "OUmbarara01"},
+		},
+		counts: []models.FacilityExaminationCounts{
+			{FacilityID: 1, Examinations: 4, Child: 1},
+			{FacilityID: 2, Examinations: 3, Child: 2},
+			{FacilityID: 9, Examinations: 5},
+		},
+	}
+
+	set, unmapped, err := BuildDataValueSet(repo, "202403")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.Dhis2DataValue{
+		{DataElement: "DEexams0001", Period: "202403", OrgUnit: "OUmulago001", Value: "7"},
+		{DataElement: "DEchild0001", Period: "202403", OrgUnit: "OUmulago001", CategoryOptionCombo: "COCunder180", Value: "3"},
+		{DataElement: "DEexams0001", Period: "202403", OrgUnit: "OUmbarara01", Value: "0"},
+		{DataElement: "DEchild0001", Period: "202403", OrgUnit: "OUmbarara01", CategoryOptionCombo: "COCunder180", Value: "0"},
+	}
+	if !slices.Equal(set.DataValues, want) {
+		t.Errorf("data values = %+v\nwant %+v", set.DataValues, want)
+	}
+	if !slices.Equal(unmapped, []uint{9}) {
+		t.Errorf("unmapped = %v; want [9]", unmapped)
+	}
+}
+
+// dhis2Server answers every push with the given status and body, checking
+// the request is an authenticated JSON data value set
+func dhis2Server(t *testing.T, status int, body string) *Dhis2Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/dataValueSets" {
+			t.Errorf("request %s %s; want POST /api/dataValueSets", r.Method, r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "district" {
+			t.Errorf("basic auth = %q, %q, %v", user, pass, ok)
+		}
+		var set models.Dhis2DataValueSet
+		if err := json.NewDecoder(r.Body).Decode(&set); err != nil || len(set.DataValues) != 1 {
+			t.Errorf("body did not decode to one data value: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return &Dhis2Client{BaseURL: server.URL, Username: "admin", Password: "district", HTTP: server.Client()}
+}
+
+var testDataValueSet = models.Dhis2DataValueSet{DataValues: []models.Dhis2DataValue{
+	{DataElement: "DEexams0001", Period: "202403", OrgUnit: "OUmulago001", Value: "7"},
+}}
+
+func TestPushDataValueSet(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		want      Dhis2ImportSummary
+		wantError bool
+	}{
+		{
+			name:   "import summary",
+			status: http.StatusOK,
+			body:   `{"responseType":"ImportSummary","status":"SUCCESS","importCount":{"imported":1,"updated":0,"ignored":0,"deleted":0}}`,
+			want:   Dhis2ImportSummary{Status: models.Dhis2PushSuccess, Imported: 1},
+		},
+		{
+			name:   "2.38 web message",
+			status: http.StatusOK,
+			body:   `{"httpStatus":"OK","httpStatusCode":200,"status":"OK","message":"Import was successful.","response":{"responseType":"ImportSummary","status":"SUCCESS","importCount":{"imported":0,"updated":1,"ignored":0,"deleted":0}}}`,
+			want:   Dhis2ImportSummary{Status: models.Dhis2PushSuccess, Updated: 1},
+		},
+		{
+			name:   "conflicts",
+			status: http.StatusConflict,
+			body:   `{"httpStatus":"Conflict","httpStatusCode":409,"status":"WARNING","message":"One more conflicts encountered, please check import summary.","response":{"status":"WARNING","importCount":{"imported":0,"updated":0,"ignored":1,"deleted":0},"conflicts":[{"object":"OUmulago001","value":"Organisation unit not found or not accessible"}]}}`,
+			want: Dhis2ImportSummary{Status: models.Dhis2PushWarning, Ignored: 1,
+				Conflicts: []string{"OUmulago001: Organisation unit not found or not accessible"}},
+		},
+		{
+			name:      "bad credentials",
+			status:    http.StatusUnauthorized,
+			body:      `{"httpStatus":"Unauthorized","httpStatusCode":401,"status":"ERROR","message":"Unauthorized"}`,
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := dhis2Server(t, tt.status, tt.body)
+			got, err := client.PushDataValueSet(context.Background(), testDataValueSet)
+			if tt.wantError {
+				if err == nil || !strings.Contains(err.Error(), "401") {
+					t.Fatalf("error = %v; want one naming the 401", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != tt.want.Status || got.Imported != tt.want.Imported || got.Updated != tt.want.Updated ||
+				got.Ignored != tt.want.Ignored || got.Deleted != tt.want.Deleted || !slices.Equal(got.Conflicts, tt.want.Conflicts) {
+				t.Errorf("summary = %+v; want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gbvmis/internals/models"
+	"time"
+)
+
+// ExhibitTransferHash computes the chain hash of a transfer from every field
+// stored on it and the hash of the transfer before it. Text is quoted so no
+// value can run into the next.
+func ExhibitTransferHash(t models.ExhibitTransfer) string {
+	optional := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return fmt.Sprint(*id)
+	}
+
+	content := fmt.Sprintf("%s|%d|%d|%s|%q|%q|%q|%s|%q|%q|%s|%q|%t|%q|%q|%d|%q",
+		t.PrevHash,
+		t.ExhibitID,
+		t.Sequence,
+		t.TransferredAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano), // Postgres keeps microseconds
+		t.Purpose,
+		t.FromType, t.FromName, optional(t.FromOfficerID),
+		t.ToType, t.ToName, optional(t.ToOfficerID),
+		t.SealNumber, t.SealIntact,
+		t.ReleasedBySignature, t.ReceivedBySignature,
+		t.RecordedByID,
+		t.Notes,
+	)
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyExhibitChain checks that transfers, ordered by sequence, form an
+// unbroken hash chain. It returns the sequence of the first bad link, or 0.
+func VerifyExhibitChain(transfers []models.ExhibitTransfer) (bool, int) {
+	prev := ""
+	for i, t := range transfers {
+		if t.Sequence != i+1 || t.PrevHash != prev || ExhibitTransferHash(t) != t.Hash {
+			return false, t.Sequence
+		}
+		prev = t.Hash
+	}
+	return true, 0
+}
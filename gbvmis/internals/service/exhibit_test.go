@@ -0,0 +1,107 @@
+package service
+
+import (
+	"gbvmis/internals/models"
+	"testing"
+	"time"
+)
+
+// exhibitChain links the transfers into a hash chain as they are recorded
+func exhibitChain(transfers ...models.ExhibitTransfer) []models.ExhibitTransfer {
+	prev := ""
+	for i := range transfers {
+		transfers[i].Sequence = i + 1
+		transfers[i].PrevHash = prev
+		transfers[i].Hash = ExhibitTransferHash(transfers[i])
+		prev = transfers[i].Hash
+	}
+	return transfers
+}
+
+func sampleExhibitChain() []models.ExhibitTransfer {
+	officer := uint(7)
+	at := time.Date(2026, 2, 3, 9, 15, 0, 0, time.UTC)
+	return exhibitChain(
+		models.ExhibitTransfer{
+			ExhibitID: 3, TransferredAt: at, Purpose: "Storage",
+			FromType: models.HolderOfficer, FromName: "Sgt Okello", FromOfficerID: &officer,
+			ToType: models.HolderStore, ToName: "Kireka exhibit store",
+			SealNumber: "S-100", SealIntact: true, ReleasedBySignature: "okello", ReceivedBySignature: "store",
+			RecordedByID: 7, Notes: "Sealed in the presence of the complainant",
+		},
+		models.ExhibitTransfer{
+			ExhibitID: 3, TransferredAt: at.Add(48 * time.Hour), Purpose: "Analysis",
+			FromType: models.HolderStore, FromName: "Kireka exhibit store",
+			ToType: models.HolderLab, ToName: "Government Analytical Laboratory",
+			SealNumber: "S-100", SealIntact: true, ReleasedBySignature: "store", ReceivedBySignature: "lab",
+			RecordedByID: 7,
+		},
+		models.ExhibitTransfer{
+			ExhibitID: 3, TransferredAt: at.Add(240 * time.Hour), Purpose: "Court hearing",
+			FromType: models.HolderLab, FromName: "Government Analytical Laboratory",
+			ToType: models.HolderCourt, ToName: "Nakawa Chief Magistrate's Court",
+			SealNumber: "S-101", SealIntact: true, ReleasedBySignature: "lab", ReceivedBySignature: "clerk",
+			RecordedByID: 9,
+		},
+	)
+}
+
+func TestVerifyExhibitChain(t *testing.T) {
+	if intact, at := VerifyExhibitChain(sampleExhibitChain()); !intact || at != 0 {
+		t.Fatalf("untouched chain reported broken at %d", at)
+	}
+	if intact, _ := VerifyExhibitChain(nil); !intact {
+		t.Error("an exhibit without transfers reported broken")
+	}
+
+	officer := uint(8)
+	tests := []struct {
+		name   string
+		tamper func([]models.ExhibitTransfer) []models.ExhibitTransfer
+		at     int
+	}{
+		{"notes rewritten", func(c []models.ExhibitTransfer) []models.ExhibitTransfer { c[0].Notes = "Seal found broken"; return c }, 1},
+		{"purpose changed", func(c []models.ExhibitTransfer) []models.ExhibitTransfer { c[1].Purpose = "Storage"; return c }, 2},
+		{"time moved", func(c []models.ExhibitTransfer) []models.ExhibitTransfer {
+			c[1].TransferredAt = c[1].TransferredAt.Add(time.Hour)
+			return c
+		}, 2},
+		{"seal reported broken", func(c []models.ExhibitTransfer) []models.ExhibitTransfer { c[2].SealIntact = false; return c }, 3},
+		{"seal number changed", func(c []models.ExhibitTransfer) []models.ExhibitTransfer { c[2].SealNumber = "S-100"; return c }, 3},
+		{"signature replaced", func(c []models.ExhibitTransfer) []models.ExhibitTransfer {
+			c[1].ReceivedBySignature = "someone"
+			return c
+		}, 2},
+		{"officer changed", func(c []models.ExhibitTransfer) []models.ExhibitTransfer { c[0].FromOfficerID = &officer; return c }, 1},
+		{"officer removed", func(c []models.ExhibitTransfer) []models.ExhibitTransfer { c[0].FromOfficerID = nil; return c }, 1},
+		{"recorder changed", func(c []models.ExhibitTransfer) []models.ExhibitTransfer { c[2].RecordedByID = 7; return c }, 3},
+		{"text moved between fields", func(c []models.ExhibitTransfer) []models.ExhibitTransfer {
+			c[0].FromName, c[0].Purpose = "Storage|Sgt Okello", ""
+			return c
+		}, 1},
+		{"transfer removed", func(c []models.ExhibitTransfer) []models.ExhibitTransfer { return append(c[:1], c[2:]...) }, 3},
+		{"transfers swapped", func(c []models.ExhibitTransfer) []models.ExhibitTransfer { c[1], c[2] = c[2], c[1]; return c }, 3},
+		{"transfer rehashed alone", func(c []models.ExhibitTransfer) []models.ExhibitTransfer {
+			c[1].Notes = "Added later"
+			c[1].Hash = ExhibitTransferHash(c[1])
+			return c
+		}, 3},
+	}
+	for _, test := range tests {
+		intact, at := VerifyExhibitChain(test.tamper(sampleExhibitChain()))
+		if intact || at != test.at {
+			t.Errorf("%s: intact %v, broken at %d, want broken at %d", test.name, intact, at, test.at)
+		}
+	}
+}
+
+func TestExhibitTransferHashIgnoresRecordKeeping(t *testing.T) {
+	transfer := sampleExhibitChain()[0]
+	hash := transfer.Hash
+	transfer.ID = 99
+	transfer.CreatedAt = time.Now()
+	transfer.TransferredAt = transfer.TransferredAt.In(time.FixedZone("EAT", 3*60*60)).Add(300 * time.Nanosecond)
+	if got := ExhibitTransferHash(transfer); got != hash {
+		t.Error("hash depends on the row ID, creation time, time zone or sub-microsecond time")
+	}
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"fmt"
+	"gbvmis/internals/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Identifier systems for our own record numbers
+const (
+	FhirExaminationSystem = "urn:gbvmis:examination"
+	FhirCaseNumberSystem  = "urn:gbvmis:case-number"
+	FhirVictimSystem      = "urn:gbvmis:victim"
+)
+
+// Code systems of the coded findings; codes are the values stored, e.g.
+// "bruise" or "arm.forearm"
+const (
+	FhirFindingSystem     = "urn:gbvmis:finding" // coded observations and their components
+	FhirInjuryTypeSystem  = "urn:gbvmis:injury-type"
+	FhirInjuryAgeSystem   = "urn:gbvmis:injury-age"
+	FhirBodyRegionSystem  = "urn:gbvmis:body-region"
+	FhirAgeMethodSystem   = "urn:gbvmis:age-estimate-method"
+	FhirGenitalCodeSystem = "urn:gbvmis:genital-finding"
+)
+
+// Kinds of observation recorded per examination, used in observation ids
+// ("<examination id>-<kind>", and "<examination id>-injury-<injury id>" for
+// injuries)
+const (
+	FhirObservationFindings  = "findings"
+	FhirObservationTreatment = "treatment"
+	FhirObservationReferral  = "referral"
+	FhirObservationInjury    = "injury"
+	FhirObservationAge       = "age"
+	FhirObservationGenital   = "genital"
+)
+
+// fhirObservationCodes are the codes of each kind of observation: LOINC for
+// the free-text notes and the estimated age, our own for the rest
+var fhirObservationCodes = map[string]models.FhirCoding{
+	FhirObservationFindings:  {System: "http://loinc.org", Code: "29545-1", Display: "Physical findings Narrative"},
+	FhirObservationTreatment: {System: "http://loinc.org", Code: "62387-6", Display: "Interventions Narrative"},
+	FhirObservationReferral:  {System: "http://loinc.org", Code: "57133-1", Display: "Referral note"},
+	FhirObservationInjury:    {System: FhirFindingSystem, Code: "injury", Display: "Injury"},
+	FhirObservationAge:       {System: "http://loinc.org", Code: "30525-0", Display: "Age"},
+	FhirObservationGenital:   {System: FhirFindingSystem, Code: "genital-examination", Display: "Genital and anal examination"},
+}
+
+func fhirMeta(updatedAt time.Time) *models.FhirMeta {
+	return &models.FhirMeta{LastUpdated: updatedAt.UTC().Format(time.RFC3339)}
+}
+
+// fhirDate trims a stored date to YYYY-MM-DD; the driver may return a
+// timestamp for date columns
+func fhirDate(date string) string {
+	if len(date) >= 10 {
+		if _, err := time.Parse("2006-01-02", date[:10]); err == nil {
+			return date[:10]
+		}
+	}
+	return ""
+}
+
+// fhirGender maps free-text gender to FHIR administrative gender
+func fhirGender(gender string) string {
+	switch strings.ToLower(strings.TrimSpace(gender)) {
+	case "":
+		return ""
+	case "male", "m":
+		return "male"
+	case "female", "f":
+		return "female"
+	default:
+		return "other"
+	}
+}
+
+// fhirSubject refers to the survivor by our identifier only. No Patient
+// resource is served, so names and contacts stay in the system.
+func fhirSubject(examination models.Examination) *models.FhirReference {
+	return &models.FhirReference{
+		Type:       "Patient",
+		Identifier: &models.FhirIdentifier{System: FhirVictimSystem, Value: strconv.FormatUint(uint64(examination.VictimID), 10)},
+	}
+}
+
+// FhirEncounterFromExamination renders an examination as a finished
+// encounter at its facility
+func FhirEncounterFromExamination(examination models.Examination) models.FhirEncounter {
+	id := strconv.FormatUint(uint64(examination.ID), 10)
+	encounter := models.FhirEncounter{
+		ResourceType: "Encounter",
+		ID:           id,
+		Meta:         fhirMeta(examination.UpdatedAt),
+		Identifier:   []models.FhirIdentifier{{System: FhirExaminationSystem, Value: id}},
+		Status:       "finished",
+		Class:        models.FhirCoding{System: "http://terminology.hl7.org/CodeSystem/v3-ActCode", Code: "AMB", Display: "ambulatory"},
+		Type:         []models.FhirCodeableConcept{{Text: "Medical examination of a survivor of gender-based violence (PF3)"}},
+		Subject:      fhirSubject(examination),
+		Participant: []models.FhirEncounterParticipant{{Individual: models.FhirReference{
+			Reference: fmt.Sprintf("Practitioner/%d", examination.PractitionerID),
+			Display:   strings.TrimSpace(examination.Practitioner.FirstName + " " + examination.Practitioner.LastName),
+		}}},
+		ServiceProvider: &models.FhirReference{
+			Reference: fmt.Sprintf("Organization/%d", examination.FacilityID),
+			Display:   examination.Facility.Name,
+		},
+	}
+	if examination.Case.CaseNumber != "" {
+		encounter.Identifier = append(encounter.Identifier, models.FhirIdentifier{System: FhirCaseNumberSystem, Value: examination.Case.CaseNumber})
+	}
+	if date := fhirDate(examination.ExamDate); date != "" {
+		encounter.Period = &models.FhirPeriod{Start: date}
+	}
+	return encounter
+}
+
+// FhirObservationsFromExamination renders the findings, treatment and
+// referral notes of an examination, skipping empty ones, followed by its
+// coded findings when they are loaded: one observation per injury, the
+// estimated age and the genital examination
+func FhirObservationsFromExamination(examination models.Examination) []models.FhirObservation {
+	notes := []struct{ kind, text string }{
+		{FhirObservationFindings, examination.Findings},
+		{FhirObservationTreatment, examination.Treatment},
+		{FhirObservationReferral, examination.Referral},
+	}
+
+	observations := []models.FhirObservation{}
+	for _, note := range notes {
+		if strings.TrimSpace(note.text) == "" {
+			continue
+		}
+		observation := fhirObservation(examination, fmt.Sprintf("%d-%s", examination.ID, note.kind), note.kind)
+		observation.ValueString = note.text
+		observations = append(observations, observation)
+	}
+
+	for _, injury := range examination.Injuries {
+		observations = append(observations, fhirInjuryObservation(examination, injury))
+	}
+
+	findings := examination.CodedFindings
+	if findings == nil {
+		return observations
+	}
+	if findings.EstimatedAgeYears != nil {
+		observation := fhirObservation(examination, fmt.Sprintf("%d-%s", examination.ID, FhirObservationAge), FhirObservationAge)
+		observation.ValueQuantity = &models.FhirQuantity{
+			Value: float64(*findings.EstimatedAgeYears), Unit: "years", System: "http://unitsofmeasure.org", Code: "a",
+		}
+		observation.Method = fhirCode(FhirAgeMethodSystem, findings.AgeEstimateMethod)
+		observations = append(observations, observation)
+	}
+	if findings.GenitalExamined {
+		observation := fhirObservation(examination, fmt.Sprintf("%d-%s", examination.ID, FhirObservationGenital), FhirObservationGenital)
+		observation.Component = fhirComponents(
+			fhirComponent("hymen", "Hymen", models.FhirObservationValue{ValueCodeableConcept: fhirCode(FhirGenitalCodeSystem, findings.Hymen)}),
+			fhirComponent("genital-tears", "Genital tears", models.FhirObservationValue{ValueBoolean: findings.GenitalTears}),
+			fhirComponent("bleeding", "Bleeding", models.FhirObservationValue{ValueBoolean: findings.Bleeding}),
+			fhirComponent("discharge", "Discharge", models.FhirObservationValue{ValueBoolean: findings.Discharge}),
+			fhirComponent("anal-examination", "Anal examination", models.FhirObservationValue{ValueCodeableConcept: fhirCode(FhirGenitalCodeSystem, findings.AnalExamination)}),
+			fhirComponent("penetration-opinion", "Opinion on penetration", models.FhirObservationValue{ValueCodeableConcept: fhirCode(FhirGenitalCodeSystem, findings.PenetrationOpinion)}),
+		)
+		observation.Note = fhirNote(findings.GenitalNotes)
+		observations = append(observations, observation)
+	}
+	return observations
+}
+
+// fhirObservation starts an examination observation of the given kind
+func fhirObservation(examination models.Examination, id, kind string) models.FhirObservation {
+	coding := fhirObservationCodes[kind]
+	return models.FhirObservation{
+		ResourceType: "Observation",
+		ID:           id,
+		Meta:         fhirMeta(examination.UpdatedAt),
+		Status:       "final",
+		Category: []models.FhirCodeableConcept{{Coding: []models.FhirCoding{{
+			System: "http://terminology.hl7.org/CodeSystem/observation-category", Code: "exam", Display: "Exam",
+		}}}},
+		Code:              models.FhirCodeableConcept{Coding: []models.FhirCoding{coding}, Text: coding.Display},
+		Subject:           fhirSubject(examination),
+		Encounter:         &models.FhirReference{Reference: fmt.Sprintf("Encounter/%d", examination.ID)},
+		EffectiveDateTime: fhirDate(examination.ExamDate),
+		Performer:         []models.FhirReference{{Reference: fmt.Sprintf("Practitioner/%d", examination.PractitionerID)}},
+	}
+}
+
+// fhirInjuryObservation renders an injury: its type as the value, its place
+// on the body map as the body site and its size and age as components
+func fhirInjuryObservation(examination models.Examination, injury models.ExaminationInjury) models.FhirObservation {
+	observation := fhirObservation(examination, fmt.Sprintf("%d-%s-%d", examination.ID, FhirObservationInjury, injury.ID), FhirObservationInjury)
+	observation.ValueCodeableConcept = fhirCode(FhirInjuryTypeSystem, injury.InjuryType)
+
+	site := injury.BodyRegion
+	if region, ok := models.FindBodyRegion(injury.BodyRegion); ok {
+		site = region.Name
+	}
+	observation.BodySite = &models.FhirCodeableConcept{
+		Coding: []models.FhirCoding{{System: FhirBodyRegionSystem, Code: injury.BodyRegion, Display: site}},
+		Text:   strings.TrimSpace(injury.Side + " " + strings.ToLower(site)),
+	}
+
+	centimetres := func(value *float64) models.FhirObservationValue {
+		if value == nil {
+			return models.FhirObservationValue{}
+		}
+		return models.FhirObservationValue{ValueQuantity: &models.FhirQuantity{
+			Value: *value, Unit: "cm", System: "http://unitsofmeasure.org", Code: "cm",
+		}}
+	}
+	observation.Component = fhirComponents(
+		fhirComponent("length", "Length", centimetres(injury.LengthCm)),
+		fhirComponent("width", "Width", centimetres(injury.WidthCm)),
+		fhirComponent("age-estimate", "Estimated age of the injury", models.FhirObservationValue{ValueCodeableConcept: fhirCode(FhirInjuryAgeSystem, injury.AgeEstimate)}),
+	)
+	observation.Note = fhirNote(injury.Description)
+	return observation
+}
+
+// fhirCode codes a stored value, or returns nil when it is blank
+func fhirCode(system, code string) *models.FhirCodeableConcept {
+	if code == "" {
+		return nil
+	}
+	display := strings.ReplaceAll(code, "_", " ")
+	return &models.FhirCodeableConcept{Coding: []models.FhirCoding{{System: system, Code: code, Display: display}}, Text: display}
+}
+
+func fhirComponent(code, display string, value models.FhirObservationValue) models.FhirObservationComponent {
+	return models.FhirObservationComponent{
+		Code:                 models.FhirCodeableConcept{Coding: []models.FhirCoding{{System: FhirFindingSystem, Code: code, Display: display}}, Text: display},
+		FhirObservationValue: value,
+	}
+}
+
+// fhirComponents keeps the components that have a value
+func fhirComponents(components ...models.FhirObservationComponent) []models.FhirObservationComponent {
+	var kept []models.FhirObservationComponent
+	for _, component := range components {
+		if component.FhirObservationValue != (models.FhirObservationValue{}) {
+			kept = append(kept, component)
+		}
+	}
+	return kept
+}
+
+func fhirNote(text string) []models.FhirAnnotation {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return []models.FhirAnnotation{{Text: text}}
+}
+
+// ParseFhirObservationID splits an observation id into the examination id
+// and the kind of observation
+func ParseFhirObservationID(id string) (string, string, bool) {
+	examinationID, rest, found := strings.Cut(id, "-")
+	if !found {
+		return "", "", false
+	}
+	kind, injuryID, perInjury := strings.Cut(rest, "-")
+	if fhirObservationCodes[kind].Code == "" || perInjury != (kind == FhirObservationInjury) {
+		return "", "", false
+	}
+	if perInjury {
+		if _, err := strconv.ParseUint(injuryID, 10, 64); err != nil {
+			return "", "", false
+		}
+	}
+	if _, err := strconv.ParseUint(examinationID, 10, 64); err != nil {
+		return "", "", false
+	}
+	return examinationID, kind, true
+}
+
+func FhirPractitionerFromModel(practitioner models.HealthPractitioner) models.FhirPractitioner {
+	resource := models.FhirPractitioner{
+		ResourceType: "Practitioner",
+		ID:           strconv.FormatUint(uint64(practitioner.ID), 10),
+		Meta:         fhirMeta(practitioner.UpdatedAt),
+		Gender:       fhirGender(practitioner.Gender),
+	}
+	name := models.FhirHumanName{Family: practitioner.LastName}
+	if practitioner.FirstName != "" {
+		name.Given = []string{practitioner.FirstName}
+	}
+	if name.Family != "" || name.Given != nil {
+		resource.Name = []models.FhirHumanName{name}
+	}
+	if practitioner.Phone != "" {
+		resource.Telecom = []models.FhirContactPoint{{System: "phone", Value: practitioner.Phone}}
+	}
+	if practitioner.Profession != "" {
+		resource.Qualification = []models.FhirQualification{{Code: models.FhirCodeableConcept{Text: practitioner.Profession}}}
+	}
+	return resource
+}
+
+func FhirOrganizationFromFacility(facility models.HealthFacility) models.FhirOrganization {
+	resource := models.FhirOrganization{
+		ResourceType: "Organization",
+		ID:           strconv.FormatUint(uint64(facility.ID), 10),
+		Meta:         fhirMeta(facility.UpdatedAt),
+		Active:       true,
+		Type: []models.FhirCodeableConcept{{Coding: []models.FhirCoding{{
+			System: "http://terminology.hl7.org/CodeSystem/organization-type", Code: "prov", Display: "Healthcare Provider",
+		}}}},
+		Name: facility.Name,
+	}
+	if facility.Contact != "" {
+		resource.Telecom = []models.FhirContactPoint{{System: "phone", Value: facility.Contact}}
+	}
+	if facility.Location != "" {
+		resource.Address = []models.FhirAddress{{Text: facility.Location}}
+	}
+	return resource
+}
+
+// FhirExaminationResources renders an examination as its encounter and
+// observations, followed by the practitioner and facility once each when
+// withParticipants is set
+func FhirExaminationResources(examinations []models.Examination, withParticipants bool) []models.FhirResource {
+	var resources, participants []models.FhirResource
+	seen := map[string]bool{}
+	for _, examination := range examinations {
+		resources = append(resources, FhirEncounterFromExamination(examination))
+		for _, observation := range FhirObservationsFromExamination(examination) {
+			resources = append(resources, observation)
+		}
+		if !withParticipants {
+			continue
+		}
+		for _, resource := range []models.FhirResource{
+			FhirPractitionerFromModel(examination.Practitioner),
+			FhirOrganizationFromFacility(examination.Facility),
+		} {
+			if !seen[resource.FhirPath()] {
+				seen[resource.FhirPath()] = true
+				participants = append(participants, resource)
+			}
+		}
+	}
+	return append(resources, participants...)
+}
+
+// NewFhirBundle wraps resources in a bundle of the given type ("searchset"
+// or "collection"); base is the absolute URL of the /fhir endpoints
+func NewFhirBundle(base, bundleType string, resources []models.FhirResource) models.FhirBundle {
+	bundle := models.FhirBundle{
+		ResourceType: "Bundle",
+		Type:         bundleType,
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Entry:        make([]models.FhirBundleEntry, 0, len(resources)),
+	}
+	for _, resource := range resources {
+		bundle.Entry = append(bundle.Entry, models.FhirBundleEntry{FullURL: base + "/" + resource.FhirPath(), Resource: resource})
+	}
+	return bundle
+}
+
+// NewFhirOutcome builds an OperationOutcome with a single error
+func NewFhirOutcome(code, diagnostics string) models.FhirOperationOutcome {
+	return models.FhirOperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []models.FhirIssue{{Severity: "error", Code: code, Diagnostics: diagnostics}},
+	}
+}
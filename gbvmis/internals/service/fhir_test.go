@@ -0,0 +1,99 @@
+package service
+
+import (
+	"encoding/json"
+	"gbvmis/internals/models"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestFhirObservationsIncludeCodedFindings(t *testing.T) {
+	length := 3.0
+	age := 16
+	tears := false
+	examination := models.Examination{
+		Model:          gorm.Model{ID: 12},
+		VictimID:       4,
+		PractitionerID: 7,
+		ExamDate:       "2026-01-02",
+		Findings:       "Bruising to the forearm.",
+		Injuries: []models.ExaminationInjury{
+			{Model: gorm.Model{ID: 34}, InjuryType: models.InjuryBruise, BodyRegion: "arm.forearm", Side: models.SideLeft, LengthCm: &length, AgeEstimate: models.InjuryAgeRecent, Description: "Fingertip pattern"},
+		},
+		CodedFindings: &models.ExaminationFindings{
+			EstimatedAgeYears: &age,
+			AgeEstimateMethod: models.AgeMethodDental,
+			GenitalExamined:   true,
+			Hymen:             models.HymenIntact,
+			GenitalTears:      &tears,
+		},
+	}
+
+	observations := FhirObservationsFromExamination(examination)
+	var ids []string
+	for _, observation := range observations {
+		ids = append(ids, observation.ID)
+	}
+	if got, want := strings.Join(ids, " "), "12-findings 12-injury-34 12-age 12-genital"; got != want {
+		t.Fatalf("observations %s, want %s", got, want)
+	}
+
+	injury, _ := json.Marshal(observations[1])
+	for _, want := range []string{
+		`"valueCodeableConcept":{"coding":[{"system":"urn:gbvmis:injury-type","code":"bruise","display":"bruise"}]`,
+		`"bodySite":{"coding":[{"system":"urn:gbvmis:body-region","code":"arm.forearm","display":"Forearm"}],"text":"left forearm"}`,
+		`"valueQuantity":{"value":3,"unit":"cm","system":"http://unitsofmeasure.org","code":"cm"}`,
+		`"code":"age-estimate"`,
+		`"note":[{"text":"Fingertip pattern"}]`,
+	} {
+		if !strings.Contains(string(injury), want) {
+			t.Errorf("injury observation lacks %s:\n%s", want, injury)
+		}
+	}
+	if strings.Contains(string(injury), `"code":"width"`) || strings.Contains(string(injury), "valueString") {
+		t.Errorf("injury observation has an empty component or value:\n%s", injury)
+	}
+
+	if got := observations[2].ValueQuantity; got == nil || got.Value != 16 || got.Code != "a" {
+		t.Errorf("estimated age value = %+v", got)
+	}
+	genital, _ := json.Marshal(observations[3])
+	if !strings.Contains(string(genital), `"code":"genital-tears","display":"Genital tears"}],"text":"Genital tears"},"valueBoolean":false`) {
+		t.Errorf("genital observation lacks the tears component:\n%s", genital)
+	}
+
+	examination.CodedFindings.GenitalExamined = false
+	examination.CodedFindings.EstimatedAgeYears = nil
+	examination.Injuries = nil
+	if got := FhirObservationsFromExamination(examination); len(got) != 1 {
+		t.Errorf("got %d observations without coded findings, want the findings note only", len(got))
+	}
+}
+
+func TestParseFhirObservationID(t *testing.T) {
+	tests := []struct {
+		id          string
+		examination string
+		kind        string
+		valid       bool
+	}{
+		{"12-findings", "12", FhirObservationFindings, true},
+		{"12-age", "12", FhirObservationAge, true},
+		{"12-genital", "12", FhirObservationGenital, true},
+		{"12-injury-34", "12", FhirObservationInjury, true},
+		{"12-injury", "", "", false},
+		{"12-injury-x", "", "", false},
+		{"12-findings-3", "", "", false},
+		{"12-unknown", "", "", false},
+		{"x-findings", "", "", false},
+		{"12", "", "", false},
+	}
+	for _, test := range tests {
+		examination, kind, valid := ParseFhirObservationID(test.id)
+		if examination != test.examination || kind != test.kind || valid != test.valid {
+			t.Errorf("ParseFhirObservationID(%q) = %q, %q, %v", test.id, examination, kind, valid)
+		}
+	}
+}
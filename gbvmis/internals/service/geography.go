@@ -0,0 +1,230 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidGeographyCSV means a hierarchy import file is malformed or
+// inconsistent; nothing is imported
+var ErrInvalidGeographyCSV = errors.New("invalid hierarchy file")
+
+// geographyColumns are the columns a hierarchy import file must have, in any
+// order. latitude and longitude may also be given.
+var geographyColumns = []string{"code", "name", "level", "parent_code"}
+
+// ParseGeographyCSV reads a hierarchy file with the columns code, name, level
+// and parent_code, and optionally latitude and longitude. Each row's parent
+// must be at an earlier level, either in the file or already known (existing
+// maps codes to levels). Rows are returned with parents before children.
+func ParseGeographyCSV(r io.Reader, levels []string, existing map[string]string) ([]repository.GeographyRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read the header: %v", ErrInvalidGeographyCSV, err)
+	}
+	index := map[string]int{}
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))] = i
+	}
+	for _, column := range geographyColumns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", ErrInvalidGeographyCSV, column)
+		}
+	}
+
+	var rows []repository.GeographyRow
+	known := make(map[string]string, len(existing))
+	for code, level := range existing {
+		known[code] = level
+	}
+	seen := map[string]bool{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidGeographyCSV, err)
+		}
+		field := func(column string) string {
+			if i := index[column]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := repository.GeographyRow{
+			Line:       line,
+			Code:       field("code"),
+			Name:       field("name"),
+			Level:      strings.ToLower(field("level")),
+			ParentCode: field("parent_code"),
+		}
+		if row.Latitude, row.Longitude, err = parseCoordinates(field("latitude"), field("longitude")); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidGeographyCSV, line, err)
+		}
+		switch {
+		case row.Code == "" || row.Name == "":
+			return nil, fmt.Errorf("%w: line %d: code and name are required", ErrInvalidGeographyCSV, line)
+		case !slices.Contains(levels, row.Level):
+			return nil, fmt.Errorf("%w: line %d: level must be one of %s", ErrInvalidGeographyCSV, line, strings.Join(levels, ", "))
+		case seen[row.Code]:
+			return nil, fmt.Errorf("%w: line %d: code %q appears more than once", ErrInvalidGeographyCSV, line, row.Code)
+		case row.ParentCode == "" && row.Level != levels[0]:
+			return nil, fmt.Errorf("%w: line %d: a %s needs a parent_code", ErrInvalidGeographyCSV, line, row.Level)
+		}
+		seen[row.Code] = true
+		known[row.Code] = row.Level
+		rows = append(rows, row)
+	}
+
+	// Parents are checked once the whole file is read, so they may come later in it
+	for _, row := range rows {
+		if row.ParentCode == "" {
+			continue
+		}
+		parentLevel, ok := known[row.ParentCode]
+		if !ok {
+			return nil, fmt.Errorf("%w: line %d: parent %q not found", ErrInvalidGeographyCSV, row.Line, row.ParentCode)
+		}
+		if slices.Index(levels, parentLevel) >= slices.Index(levels, row.Level) {
+			return nil, fmt.Errorf("%w: line %d: a %s cannot sit under a %s", ErrInvalidGeographyCSV, row.Line, row.Level, parentLevel)
+		}
+	}
+
+	slices.SortStableFunc(rows, func(a, b repository.GeographyRow) int {
+		return slices.Index(levels, a.Level) - slices.Index(levels, b.Level)
+	})
+	return rows, nil
+}
+
+// parseCoordinates reads an optional pair of coordinates, both or neither
+func parseCoordinates(latitude, longitude string) (*float64, *float64, error) {
+	if latitude == "" && longitude == "" {
+		return nil, nil, nil
+	}
+	lat, latErr := strconv.ParseFloat(latitude, 64)
+	lng, lngErr := strconv.ParseFloat(longitude, 64)
+	if latErr != nil || lngErr != nil {
+		return nil, nil, errors.New("latitude and longitude must both be numbers")
+	}
+	if msg := CheckCoordinates(&lat, &lng); msg != "" {
+		return nil, nil, errors.New(msg)
+	}
+	return &lat, &lng, nil
+}
+
+// CheckCoordinates returns a message when a pair of optional coordinates is
+// incomplete or out of range
+func CheckCoordinates(lat, lng *float64) string {
+	if (lat == nil) != (lng == nil) {
+		return "latitude and longitude must be given together"
+	}
+	if lat != nil && (*lat < -90 || *lat > 90 || *lng < -180 || *lng > 180) {
+		return "latitude must be between -90 and 90 and longitude between -180 and 180"
+	}
+	return ""
+}
+
+// CaseCountsGeoJSON turns area case counts into map points. Areas with fewer
+// than minCell cases are left off the map altogether, so neither their count
+// nor the fact that they have cases is published.
+func CaseCountsGeoJSON(counts []models.AreaCaseCount, level string, minCell int) models.GeoFeatureCollection {
+	collection := models.GeoFeatureCollection{Type: "FeatureCollection", Features: []models.GeoFeature{}}
+	for _, count := range SuppressSmallCells(counts, minCell) {
+		feature := models.GeoFeature{
+			Type: "Feature",
+			Properties: map[string]interface{}{
+				"area_id": count.AreaID,
+				"code":    count.Code,
+				"name":    count.Name,
+				"level":   level,
+				"cases":   count.Cases,
+			},
+		}
+		if count.Latitude != nil && count.Longitude != nil {
+			feature.Geometry = &models.GeoPoint{Type: "Point", Coordinates: []float64{*count.Longitude, *count.Latitude}}
+		}
+		collection.Features = append(collection.Features, feature)
+	}
+	return collection
+}
+
+// SuppressSmallCells drops the areas with fewer than minCell cases
+func SuppressSmallCells(counts []models.AreaCaseCount, minCell int) []models.AreaCaseCount {
+	kept := make([]models.AreaCaseCount, 0, len(counts))
+	for _, count := range counts {
+		if count.Cases >= int64(minCell) {
+			kept = append(kept, count)
+		}
+	}
+	return kept
+}
+
+// QuarterWindow widens a case window to whole calendar quarters. Aggregate
+// figures for non-administrators use it so that windows differing by a few
+// days cannot be subtracted from each other to recover a suppressed count.
+func QuarterWindow(window repository.CaseWindow) repository.CaseWindow {
+	if !window.From.IsZero() {
+		window.From = quarterStart(window.From)
+	}
+	if !window.To.IsZero() {
+		window.To = quarterStart(window.To).AddDate(0, 3, -1)
+	}
+	return window
+}
+
+// quarterStart is the first day of the calendar quarter holding t
+func quarterStart(t time.Time) time.Time {
+	month := time.Month((int(t.Month())-1)/3*3 + 1)
+	return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location())
+}
+
+// ImportAdminAreas loads an administrative hierarchy file
+func ImportAdminAreas(repo repository.GeographyRepository, r io.Reader) (models.GeographyImportResult, error) {
+	existing, err := repo.GetAdminAreaLevels()
+	if err != nil {
+		return models.GeographyImportResult{}, err
+	}
+	rows, err := ParseGeographyCSV(r, models.AdminLevels, existing)
+	if err != nil {
+		return models.GeographyImportResult{}, err
+	}
+	return repo.ImportAdminAreas(rows)
+}
+
+// ImportPoliceUnits loads a police command hierarchy file. Rows at level
+// "post" create or update police posts under their station.
+func ImportPoliceUnits(repo repository.GeographyRepository, r io.Reader) (models.GeographyImportResult, error) {
+	existing, err := repo.GetPoliceUnitLevels()
+	if err != nil {
+		return models.GeographyImportResult{}, err
+	}
+	levels := append(slices.Clone(models.PoliceLevels), repository.PoliceLevelPost)
+	rows, err := ParseGeographyCSV(r, levels, existing)
+	if err != nil {
+		return models.GeographyImportResult{}, err
+	}
+	return repo.ImportPoliceUnits(rows)
+}
+
+// AdminAreaNames maps each level on an area's path to the name of the area
+// at that level
+func AdminAreaNames(path []models.AdminArea) map[string]string {
+	names := make(map[string]string, len(path))
+	for _, area := range path {
+		names[area.Level] = area.Name
+	}
+	return names
+}
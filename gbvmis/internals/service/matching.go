@@ -0,0 +1,188 @@
+package service
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Thresholds used when comparing people across records
+const (
+	NameMatchWithDob = 0.88 // fuzzy name plus same date of birth
+	NameMatchAlone   = 0.94 // near-identical name, no date of birth to confirm
+	DuplicateScore   = 0.5  // combined score from which a pair is reported
+)
+
+// Reasons a pair of records was matched
+const (
+	MatchNin         = "nin"
+	MatchPhone       = "phone"
+	MatchNameDob     = "name_dob"
+	MatchName        = "name"
+	MatchFingerprint = "fingerprint"
+)
+
+// NormalizePhone reduces a phone number to digits in international form, so
+// "0772 123456", "+256772123456" and "256-772-123456" compare equal
+func NormalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	digits := strings.TrimPrefix(b.String(), "00")
+	switch {
+	case len(digits) == 10 && strings.HasPrefix(digits, "0"):
+		return "256" + digits[1:]
+	case len(digits) == 9:
+		return "256" + digits
+	}
+	return digits
+}
+
+// NormalizeNin uppercases a NIN and strips spaces and dashes
+func NormalizeNin(nin string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(nin)))
+}
+
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// NameSimilarity compares two first/last name pairs with Jaro-Winkler, also
+// trying the names swapped since they are often captured in either order
+func NameSimilarity(first1, last1, first2, last2 string) float64 {
+	f1, l1 := normalizeName(first1), normalizeName(last1)
+	f2, l2 := normalizeName(first2), normalizeName(last2)
+	if f1+l1 == "" || f2+l2 == "" {
+		return 0
+	}
+	direct := (JaroWinkler(f1, f2) + JaroWinkler(l1, l2)) / 2
+	swapped := (JaroWinkler(f1, l2) + JaroWinkler(l1, f2)) / 2
+	return max(direct, swapped)
+}
+
+// SameDate reports whether two dates are set and fall on the same day
+func SameDate(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return false
+	}
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+// CombineScores merges independent match signals: 1 - Π(1 - s)
+func CombineScores(scores ...float64) float64 {
+	miss := 1.0
+	for _, s := range scores {
+		miss *= 1 - s
+	}
+	return 1 - miss
+}
+
+// JaroWinkler returns the Jaro-Winkler similarity of two strings, from 0 to 1
+func JaroWinkler(a, b string) float64 {
+	s1, s2 := []rune(a), []rune(b)
+	if len(s1) == 0 && len(s2) == 0 {
+		return 1
+	}
+	if len(s1) == 0 || len(s2) == 0 {
+		return 0
+	}
+
+	window := max(len(s1), len(s2))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	matched1 := make([]bool, len(s1))
+	matched2 := make([]bool, len(s2))
+	matches := 0
+	for i := range s1 {
+		lo, hi := max(0, i-window), min(len(s2), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !matched2[j] && s1[i] == s2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, k := 0, 0
+	for i := range s1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[k] {
+			k++
+		}
+		if s1[i] != s2[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(s1)) + m/float64(len(s2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s1), len(s2)) && s1[prefix] == s2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Person holds the identifying details compared by ScorePerson
+type Person struct {
+	FirstName   string
+	LastName    string
+	Dob         time.Time
+	PhoneNumber string
+	Nin         string
+	Prints      []string // fingerprint template hashes and image hashes
+}
+
+// ScorePerson scores how likely two records describe the same person and
+// says which details matched
+func ScorePerson(a, b Person) (float64, []string) {
+	var scores []float64
+	var reasons []string
+
+	if nin := NormalizeNin(a.Nin); nin != "" && nin == NormalizeNin(b.Nin) {
+		scores = append(scores, 0.99)
+		reasons = append(reasons, MatchNin)
+	}
+	if sharesAny(a.Prints, b.Prints) {
+		scores = append(scores, 0.99)
+		reasons = append(reasons, MatchFingerprint)
+	}
+	if phone := NormalizePhone(a.PhoneNumber); len(phone) >= 9 && phone == NormalizePhone(b.PhoneNumber) {
+		scores = append(scores, 0.55)
+		reasons = append(reasons, MatchPhone)
+	}
+
+	name := NameSimilarity(a.FirstName, a.LastName, b.FirstName, b.LastName)
+	if name >= NameMatchWithDob && SameDate(a.Dob, b.Dob) {
+		scores = append(scores, 0.85)
+		reasons = append(reasons, MatchNameDob)
+	} else if name >= NameMatchAlone {
+		scores = append(scores, 0.4)
+		reasons = append(reasons, MatchName)
+	}
+
+	return CombineScores(scores...), reasons
+}
+
+func sharesAny(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x != "" && x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
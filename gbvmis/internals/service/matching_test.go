@@ -0,0 +1,115 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"amina", "", 0},
+		{"amina", "amina", 1},
+		{"abc", "xyz", 0},
+		{"martha", "marhta", 0.9611},
+		{"dwayne", "duane", 0.84},
+		{"dixon", "dicksonx", 0.8133},
+		{"nakato", "nakatto", 0.9714},
+		{"ökello", "okello", 0.8889},
+	}
+	for _, test := range tests {
+		got := JaroWinkler(test.a, test.b)
+		if math.Abs(got-test.want) > 0.0001 {
+			t.Errorf("JaroWinkler(%q, %q) = %.4f, want %.4f", test.a, test.b, got, test.want)
+		}
+		if back := JaroWinkler(test.b, test.a); math.Abs(back-got) > 1e-9 {
+			t.Errorf("JaroWinkler(%q, %q) = %.4f, not symmetric with %.4f", test.b, test.a, back, got)
+		}
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := map[string]string{
+		"0772 123456":     "256772123456",
+		"+256772123456":   "256772123456",
+		"256-772-123456":  "256772123456",
+		"00256 772123456": "256772123456",
+		"772123456":       "256772123456",
+		"(0772) 12-34-56": "256772123456",
+		"+254712345678":   "254712345678",
+		"1234":            "1234",
+		"":                "",
+	}
+	for in, want := range tests {
+		if got := NormalizePhone(in); got != want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalizeNin(t *testing.T) {
+	tests := map[string]string{
+		"CM12345678ABCD":       "CM12345678ABCD",
+		" cm1234-5678 abcd ":   "CM12345678ABCD",
+		"cf 9876-5432-1xyz-ab": "CF987654321XYZAB",
+		"":                     "",
+	}
+	for in, want := range tests {
+		if got := NormalizeNin(in); got != want {
+			t.Errorf("NormalizeNin(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	if got := NameSimilarity("Amina", "Nakato", " NAKATO ", "amina"); got != 1 {
+		t.Errorf("swapped names scored %.4f, want 1", got)
+	}
+	if got := NameSimilarity("", "", "Amina", "Nakato"); got != 0 {
+		t.Errorf("missing name scored %.4f, want 0", got)
+	}
+	if got := NameSimilarity("Amina", "Nakato", "Grace", "Auma"); got >= NameMatchWithDob {
+		t.Errorf("different people scored %.4f", got)
+	}
+}
+
+func TestScorePerson(t *testing.T) {
+	dob := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	amina := Person{FirstName: "Amina", LastName: "Nakato", Dob: dob, PhoneNumber: "0772123456", Nin: "CM12345678ABCD", Prints: []string{"tmpl-1"}}
+	tests := []struct {
+		name    string
+		other   Person
+		reasons []string
+		likely  bool
+	}{
+		{"same NIN written differently", Person{FirstName: "Grace", Nin: "cm1234-5678 abcd"}, []string{MatchNin}, true},
+		{"same fingerprint", Person{FirstName: "Grace", Prints: []string{"", "tmpl-1"}}, []string{MatchFingerprint}, true},
+		{"same phone written differently", Person{FirstName: "Grace", PhoneNumber: "+256 772 123456"}, []string{MatchPhone}, true},
+		{"misspelt name and same birthday", Person{FirstName: "Aminah", LastName: "Nakatto", Dob: dob.Add(5 * time.Hour)}, []string{MatchNameDob}, true},
+		{"same name alone", Person{FirstName: "amina", LastName: "NAKATO"}, []string{MatchName}, false},
+		{"same name, different birthday", Person{FirstName: "Amina", LastName: "Nakato", Dob: dob.AddDate(1, 0, 0)}, []string{MatchName}, false},
+		{"nothing in common", Person{FirstName: "Grace", LastName: "Auma", Dob: dob.AddDate(3, 0, 0)}, nil, false},
+	}
+	for _, test := range tests {
+		score, reasons := ScorePerson(amina, test.other)
+		if !slices.Equal(reasons, test.reasons) {
+			t.Errorf("%s: reasons %v, want %v", test.name, reasons, test.reasons)
+		}
+		if likely := score >= DuplicateScore; likely != test.likely {
+			t.Errorf("%s: score %.4f, reported as duplicate %v, want %v", test.name, score, likely, test.likely)
+		}
+	}
+}
+
+func TestScorePersonIgnoresBlankDetails(t *testing.T) {
+	blank := Person{FirstName: "Grace", PhoneNumber: "123", Prints: []string{""}}
+	other := Person{FirstName: "Sarah", PhoneNumber: "123", Prints: []string{""}}
+	if score, reasons := ScorePerson(blank, other); score != 0 || reasons != nil {
+		t.Errorf("blank prints and short phone numbers matched: %.4f %v", score, reasons)
+	}
+}
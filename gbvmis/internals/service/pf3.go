@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gbvmis/internals/config"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/storage"
+	"strings"
+	"time"
+)
+
+// ErrPf3SigningNotConfigured means PF3_SIGNING_KEY is not set, so forms
+// cannot be signed
+var ErrPf3SigningNotConfigured = errors.New("PF3 signing is not configured; set PF3_SIGNING_KEY")
+
+// Pf3Data is what a form template can print. Multi-line values are already
+// indented as continuation lines, so templates can place them anywhere.
+type Pf3Data struct {
+	Form string
+
+	Case struct {
+		Number, Title, Station, DateOpened string
+	}
+	Incident struct {
+		Date, Place, ViolenceType, Setting, Description string
+	}
+	Victim struct {
+		Name, Gender, Age, DateOfBirth, Address string
+	}
+	Examination struct {
+		Date, Consent, Findings, Treatment, Referral string
+		Injuries, EstimatedAge, GenitalExamination   string // from the coded findings
+	}
+	Practitioner struct {
+		Name, Profession, Phone string
+	}
+	Facility struct {
+		Name, Location, Contact string
+	}
+}
+
+// pf3Value prepares a value for printing: blank values print as a dash and
+// further lines are indented to continue the line they start on
+func pf3Value(s string) string {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+	if s == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(s, "\n", "\n"+pf3Continuation)
+}
+
+// BuildPf3Data gathers the values printed on an examination's form. Ages are
+// taken at the exam date so the values, and the digest over them, do not
+// change with time.
+func BuildPf3Data(repo repository.Pf3Repository, examination models.Examination, form string) (Pf3Data, error) {
+	var data Pf3Data
+	data.Form = form
+
+	station := ""
+	if examination.Case.PolicePostID != 0 {
+		post, err := repo.FindPolicePostByID(examination.Case.PolicePostID)
+		if err == nil {
+			station = post.Name
+		}
+	}
+	data.Case.Number = pf3Value(examination.Case.CaseNumber)
+	data.Case.Title = pf3Value(examination.Case.Title)
+	data.Case.Station = pf3Value(station)
+	data.Case.DateOpened = pf3Value(pf3Date(examination.Case.DateOpened))
+
+	incident, err := repo.GetFirstIncident(examination.CaseID)
+	if err != nil {
+		return data, err
+	}
+	if incident == nil {
+		incident = &models.Incident{}
+	}
+	when := pf3Date(incident.OccurredAt)
+	if incident.TimeKnown {
+		when = incident.OccurredAt.Format("2006-01-02 15:04")
+	}
+	var place []string
+	for _, part := range []string{incident.Village, incident.Subcounty, incident.District} {
+		if part != "" {
+			place = append(place, part)
+		}
+	}
+	data.Incident.Date = pf3Value(when)
+	data.Incident.Place = pf3Value(strings.Join(place, ", "))
+	data.Incident.ViolenceType = pf3Value(strings.ReplaceAll(incident.ViolenceType, "_", " "))
+	data.Incident.Setting = pf3Value(strings.ReplaceAll(incident.Setting, "_", " "))
+	data.Incident.Description = pf3Value(incident.Description)
+
+	examDate, _ := time.Parse("2006-01-02", fhirDate(examination.ExamDate))
+	age := ""
+	if years, ok := models.AgeAt(examination.Victim.Dob, examDate); ok {
+		age = fmt.Sprintf("%d years", years)
+	}
+	data.Victim.Name = pf3Value(examination.Victim.FirstName + " " + examination.Victim.LastName)
+	data.Victim.Gender = pf3Value(examination.Victim.Gender)
+	data.Victim.Age = pf3Value(age)
+	data.Victim.DateOfBirth = pf3Value(pf3Date(examination.Victim.Dob))
+	data.Victim.Address = pf3Value(examination.Victim.Address)
+
+	consent := "Not given"
+	if examination.ConsentGiven {
+		consent = "Given"
+	}
+	data.Examination.Date = pf3Value(fhirDate(examination.ExamDate))
+	data.Examination.Consent = consent
+	data.Examination.Findings = pf3Value(examination.Findings)
+	data.Examination.Treatment = pf3Value(examination.Treatment)
+	data.Examination.Referral = pf3Value(examination.Referral)
+	data.Examination.Injuries = pf3Value(pf3Injuries(examination.Injuries))
+	data.Examination.EstimatedAge = pf3Value(pf3EstimatedAge(examination.CodedFindings))
+	data.Examination.GenitalExamination = pf3Value(pf3GenitalExamination(examination.CodedFindings))
+
+	data.Practitioner.Name = pf3Value(examination.Practitioner.FirstName + " " + examination.Practitioner.LastName)
+	data.Practitioner.Profession = pf3Value(examination.Practitioner.Profession)
+	data.Practitioner.Phone = pf3Value(examination.Practitioner.Phone)
+
+	data.Facility.Name = pf3Value(examination.Facility.Name)
+	data.Facility.Location = pf3Value(examination.Facility.Location)
+	data.Facility.Contact = pf3Value(examination.Facility.Contact)
+	return data, nil
+}
+
+// pf3Injuries lists the injuries one per line, e.g. "Bruise, left forearm,
+// 4 x 3 cm, 1 to 3 days old: over the outer side"
+func pf3Injuries(injuries []models.ExaminationInjury) string {
+	var lines []string
+	for _, injury := range injuries {
+		site := injury.BodyRegion
+		if region, ok := models.FindBodyRegion(injury.BodyRegion); ok {
+			site = strings.ToLower(region.Name)
+		}
+		parts := []string{pf3Code(injury.InjuryType), strings.TrimSpace(injury.Side + " " + site)}
+		switch {
+		case injury.LengthCm != nil && injury.WidthCm != nil:
+			parts = append(parts, fmt.Sprintf("%g x %g cm", *injury.LengthCm, *injury.WidthCm))
+		case injury.LengthCm != nil:
+			parts = append(parts, fmt.Sprintf("%g cm long", *injury.LengthCm))
+		case injury.WidthCm != nil:
+			parts = append(parts, fmt.Sprintf("%g cm wide", *injury.WidthCm))
+		}
+		if injury.AgeEstimate != "" && injury.AgeEstimate != models.InjuryAgeUnknown {
+			parts = append(parts, pf3Code(injury.AgeEstimate)+" old")
+		}
+		line := strings.Join(parts, ", ")
+		if description := strings.Join(strings.Fields(injury.Description), " "); description != "" {
+			line += ": " + description
+		}
+		lines = append(lines, strings.ToUpper(line[:1])+line[1:])
+	}
+	return strings.Join(lines, "\n")
+}
+
+func pf3EstimatedAge(findings *models.ExaminationFindings) string {
+	if findings == nil || findings.EstimatedAgeYears == nil {
+		return ""
+	}
+	age := fmt.Sprintf("About %d years", *findings.EstimatedAgeYears)
+	if findings.AgeEstimateMethod != "" {
+		age += " (" + pf3Code(findings.AgeEstimateMethod) + ")"
+	}
+	return age
+}
+
+// pf3GenitalExamination prints the genital examination one finding per line
+func pf3GenitalExamination(findings *models.ExaminationFindings) string {
+	if findings == nil {
+		return ""
+	}
+	if !findings.GenitalExamined {
+		return "Not examined"
+	}
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, label+": "+value)
+		}
+	}
+	yesNo := func(value *bool) string {
+		switch {
+		case value == nil:
+			return ""
+		case *value:
+			return "yes"
+		default:
+			return "no"
+		}
+	}
+	add("Hymen", pf3Code(findings.Hymen))
+	add("Genital tears", yesNo(findings.GenitalTears))
+	add("Bleeding", yesNo(findings.Bleeding))
+	add("Discharge", yesNo(findings.Discharge))
+	add("Anal examination", pf3Code(findings.AnalExamination))
+	add("Opinion on penetration", pf3Code(findings.PenetrationOpinion))
+	add("Notes", strings.Join(strings.Fields(findings.GenitalNotes), " "))
+	return strings.Join(lines, "\n")
+}
+
+// pf3Code prints a stored code as words
+func pf3Code(code string) string {
+	return strings.ReplaceAll(code, "_", " ")
+}
+
+func pf3Date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// Pf3ContentDigest is the SHA-256 of the incident, victim and examination
+// values printed on a form, with the examination, form and template version
+// they were printed with. The station name and the practitioner's and
+// facility's contact details are left out: they are reference data that is
+// edited elsewhere, and a new phone number does not make the form wrong.
+func Pf3ContentDigest(examinationID uint, form string, templateVersion int, data Pf3Data) string {
+	content, _ := json.Marshal(struct {
+		ExaminationID   uint
+		Form            string
+		TemplateVersion int
+		Incident        any
+		Victim          any
+		Examination     any
+	}{examinationID, form, templateVersion, data.Incident, data.Victim, data.Examination})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// signPf3 is the HMAC-SHA256, under PF3_SIGNING_KEY, of a form's
+// verification code, content digest and issue time
+func signPf3(key []byte, code, digest string, issuedAt time.Time) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "PF3|%s|%s|%s", code, digest, issuedAt.UTC().Format(time.RFC3339))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func pf3SigningKey() ([]byte, error) {
+	key := config.Config("PF3_SIGNING_KEY")
+	if key == "" {
+		return nil, ErrPf3SigningNotConfigured
+	}
+	return []byte(key), nil
+}
+
+// verificationAlphabet leaves out letters easily misread on paper
+const verificationAlphabet = "ABCDEFGHJKMNPQRSTVWXYZ23456789"
+
+// newVerificationCode returns 16 random characters, about 78 bits, so codes
+// cannot be guessed
+func newVerificationCode() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	code := make([]byte, len(random))
+	for i, b := range random {
+		code[i] = verificationAlphabet[int(b)%len(verificationAlphabet)]
+	}
+	return string(code), nil
+}
+
+// Pf3VerifyURL is the address printed and encoded in a form's QR code
+func Pf3VerifyURL(base, code string) string {
+	return strings.TrimRight(base, "/") + "/api/pf3/verify/" + code
+}
+
+// ActivePf3Template returns the template a new form is rendered with
+func ActivePf3Template(repo repository.Pf3Repository, form string) (models.Pf3Template, error) {
+	template, err := repo.GetActivePf3Template(form)
+	if err != nil {
+		return models.Pf3Template{}, err
+	}
+	if template == nil {
+		return BuiltinPf3Template(form), nil
+	}
+	return *template, nil
+}
+
+// GeneratePf3 renders, signs and stores a form for an examination. base is
+// the public address of the API, used in the verification link.
+func GeneratePf3(ctx context.Context, repo repository.Pf3Repository, store storage.BlobStore, examination models.Examination, form string, issuedByID uint, base string) (models.Pf3Document, error) {
+	key, err := pf3SigningKey()
+	if err != nil {
+		return models.Pf3Document{}, err
+	}
+	template, err := ActivePf3Template(repo, form)
+	if err != nil {
+		return models.Pf3Document{}, err
+	}
+	data, err := BuildPf3Data(repo, examination, form)
+	if err != nil {
+		return models.Pf3Document{}, err
+	}
+	code, err := newVerificationCode()
+	if err != nil {
+		return models.Pf3Document{}, err
+	}
+
+	document := models.Pf3Document{
+		ExaminationID:    examination.ID,
+		Form:             form,
+		TemplateVersion:  template.Version,
+		VerificationCode: code,
+		ContentSHA256:    Pf3ContentDigest(examination.ID, form, template.Version, data),
+		IssuedByID:       issuedByID,
+		IssuedAt:         time.Now().Truncate(time.Second),
+	}
+	document.Signature = signPf3(key, code, document.ContentSHA256, document.IssuedAt)
+
+	content, err := RenderPf3(template, data, Pf3Footer{
+		VerifyURL:        Pf3VerifyURL(base, code),
+		VerificationCode: code,
+		ContentSHA256:    document.ContentSHA256,
+		Signature:        document.Signature,
+		IssuedAt:         document.IssuedAt,
+	})
+	if err != nil {
+		return models.Pf3Document{}, err
+	}
+	blob, err := storeReport(ctx, store, content, "application/pdf")
+	if err != nil {
+		return models.Pf3Document{}, err
+	}
+	document.FileName = fmt.Sprintf("%s-%s-%s.pdf", form, slug(examination.Case.CaseNumber), code)
+	document.Size = blob.Size
+	document.BlobSHA256 = blob.SHA256
+
+	if err := repo.CreatePf3Document(&document, blob); err != nil {
+		return models.Pf3Document{}, err
+	}
+	return document, nil
+}
+
+// VerifyPf3 checks a form's signature and whether the examination still
+// says what was printed. fileSHA256, when given, is compared with the
+// digest of the issued PDF.
+func VerifyPf3(repo repository.Pf3Repository, document models.Pf3Document, fileSHA256 string) (models.Pf3Verification, error) {
+	key, err := pf3SigningKey()
+	if err != nil {
+		return models.Pf3Verification{}, err
+	}
+	examination, err := repo.GetExaminationForPf3(fmt.Sprint(document.ExaminationID))
+	if err != nil {
+		return models.Pf3Verification{}, err
+	}
+	data, err := BuildPf3Data(repo, examination, document.Form)
+	if err != nil {
+		return models.Pf3Verification{}, err
+	}
+
+	expected := signPf3(key, document.VerificationCode, document.ContentSHA256, document.IssuedAt)
+	verification := models.Pf3Verification{
+		Valid:            hmac.Equal([]byte(expected), []byte(document.Signature)),
+		RecordUnchanged:  !examination.DeletedAt.Valid && Pf3ContentDigest(examination.ID, document.Form, document.TemplateVersion, data) == document.ContentSHA256,
+		Form:             document.Form,
+		VerificationCode: document.VerificationCode,
+		IssuedAt:         document.IssuedAt,
+		CaseNumber:       examination.Case.CaseNumber,
+		ExamDate:         fhirDate(examination.ExamDate),
+		Facility:         examination.Facility.Name,
+		Practitioner:     strings.TrimSpace(examination.Practitioner.FirstName + " " + examination.Practitioner.LastName),
+		ContentSHA256:    document.ContentSHA256,
+		FileSHA256:       document.BlobSHA256,
+	}
+	if fileSHA256 != "" {
+		matches := strings.EqualFold(fileSHA256, document.BlobSHA256)
+		verification.FileMatches = &matches
+	}
+	return verification, nil
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/pdf"
+	"gbvmis/internals/qr"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Form templates are Go text/template sources, executed with Pf3Data, whose
+// output is laid out line by line:
+//
+//	# Title                 large bold heading, centred
+//	## Subtitle             smaller bold heading, centred
+//	### Section             heading on a shaded band
+//	Label:: value           a labelled field; long values wrap
+//	---                     a horizontal rule
+//	___ Caption             a line to sign on, captioned underneath
+//	(blank line)            a small gap
+//	  more text             (two leading spaces) continues the line above
+//	anything else           a paragraph
+//
+// Every page also gets a footer with the verification QR code, code,
+// content digest and signature.
+
+// pf3Continuation starts a line that continues the one above
+const pf3Continuation = "  "
+
+const pf3Header = `# POLICE FORM 3
+## MEDICAL EXAMINATION REPORT
+`
+
+const pf3Police = `### PART I - POLICE REQUEST
+Police station:: {{.Case.Station}}
+Case number:: {{.Case.Number}}
+Case title:: {{.Case.Title}}
+Nature of alleged offence:: {{.Incident.ViolenceType}}
+Where it happened:: {{.Incident.Setting}}
+Date of alleged offence:: {{.Incident.Date}}
+Place of alleged offence:: {{.Incident.Place}}
+
+### PART II - PERSON EXAMINED
+Name:: {{.Victim.Name}}
+Sex:: {{.Victim.Gender}}
+Age at examination:: {{.Victim.Age}}
+Date of birth:: {{.Victim.DateOfBirth}}
+Address:: {{.Victim.Address}}
+`
+
+const pf3Medical = `### PART III - MEDICAL EXAMINATION
+Date of examination:: {{.Examination.Date}}
+Health facility:: {{.Facility.Name}}
+Facility location:: {{.Facility.Location}}
+Consent to examination:: {{.Examination.Consent}}
+Findings:: {{.Examination.Findings}}
+Injuries:: {{.Examination.Injuries}}
+Estimated age:: {{.Examination.EstimatedAge}}
+Treatment given:: {{.Examination.Treatment}}
+Referral:: {{.Examination.Referral}}
+`
+
+const pf3Practitioner = `
+### PART IV - MEDICAL PRACTITIONER
+Name:: {{.Practitioner.Name}}
+Qualification:: {{.Practitioner.Profession}}
+Telephone:: {{.Practitioner.Phone}}
+
+I certify that I examined the person named above and that the findings recorded are true.
+
+___ Signature and stamp of the medical practitioner
+___ Date
+`
+
+// builtinPf3Templates are the layouts used until an administrator uploads a
+// template for the form
+var builtinPf3Templates = map[string]string{
+	models.FormPF3: pf3Header + "---\n" + pf3Police + "\n" + pf3Medical + pf3Practitioner,
+	models.FormPF3A: pf3Header + "## PF3A: SEXUAL OFFENCES\n---\n" + pf3Police + `
+### HISTORY GIVEN BY THE PERSON EXAMINED
+{{.Incident.Description}}
+
+` + pf3Medical + `
+### GENITAL AND ANAL EXAMINATION
+Findings:: {{.Examination.GenitalExamination}}
+` + pf3Practitioner,
+}
+
+// BuiltinPf3Template returns a form's built-in layout as version 0
+func BuiltinPf3Template(form string) models.Pf3Template {
+	return models.Pf3Template{Form: form, Version: 0, Body: builtinPf3Templates[form], Active: true}
+}
+
+// Pf3Footer is printed at the foot of every page of a form
+type Pf3Footer struct {
+	VerifyURL        string
+	VerificationCode string
+	ContentSHA256    string
+	Signature        string
+	IssuedAt         time.Time
+}
+
+// SamplePf3Data fills every field, for previewing and checking templates
+func SamplePf3Data(form string) Pf3Data {
+	var data Pf3Data
+	data.Form = form
+	data.Case.Number, data.Case.Title, data.Case.Station, data.Case.DateOpened = "CRB 123/2026", "Assault", "Central Police Station", "2026-01-02"
+	data.Incident.Date, data.Incident.Place, data.Incident.ViolenceType, data.Incident.Setting = "2026-01-01 21:30", "Kisenyi, Central Division, Kampala", "physical", "home"
+	data.Incident.Description = pf3Value("Sample account of the incident as given by the person examined.\nA second line of the account.")
+	data.Victim.Name, data.Victim.Gender, data.Victim.Age, data.Victim.DateOfBirth, data.Victim.Address = "Sample Person", "Female", "24 years", "2001-05-17", "Kisenyi, Kampala"
+	data.Examination.Date, data.Examination.Consent = "2026-01-02", "Given"
+	data.Examination.Findings = pf3Value("Bruise 4 cm x 3 cm on the left forearm, about one day old.\nTender swelling over the right cheek.")
+	data.Examination.Injuries = pf3Value("Bruise, left forearm, 4 x 3 cm, 1 to 3 days old\nSwelling, right cheek, under 24 hours old: tender")
+	data.Examination.EstimatedAge = "About 24 years (dental)"
+	data.Examination.GenitalExamination = pf3Value("Hymen: not applicable\nGenital tears: no\nAnal examination: normal\nOpinion on penetration: inconclusive")
+	data.Examination.Treatment, data.Examination.Referral = "Analgesics; wound dressing", "Counselling"
+	data.Practitioner.Name, data.Practitioner.Profession, data.Practitioner.Phone = "Dr Sample Practitioner", "Medical Officer", "+256 700 000000"
+	data.Facility.Name, data.Facility.Location, data.Facility.Contact = "Sample Health Centre IV", "Kampala", "+256 700 000001"
+	return data
+}
+
+// CheckPf3Template parses a template and renders it with sample data,
+// returning what is wrong with it
+func CheckPf3Template(form, body string) error {
+	_, err := RenderPf3(models.Pf3Template{Form: form, Body: body}, SamplePf3Data(form), Pf3Footer{
+		VerifyURL:        Pf3VerifyURL("https://example.org", "SAMPLE0000000000"),
+		VerificationCode: "SAMPLE0000000000",
+		ContentSHA256:    strings.Repeat("0", 64),
+		Signature:        strings.Repeat("0", 64),
+		IssuedAt:         time.Now(),
+	})
+	return err
+}
+
+// Layout of a form page, in points
+const (
+	pf3Margin     = 50.0
+	pf3FooterTop  = 150.0 // content stops above the footer
+	pf3LabelWidth = 150.0
+	pf3FontSize   = 9.0
+	pf3LineHeight = 12.0
+	pf3QRSize     = 84.0
+)
+
+// RenderPf3 executes the template with data and lays it out as a PDF
+func RenderPf3(tmpl models.Pf3Template, data Pf3Data, footer Pf3Footer) ([]byte, error) {
+	parsed, err := template.New(tmpl.Form).Option("missingkey=error").Parse(tmpl.Body)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := parsed.Execute(&out, data); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(out.String()) == "" {
+		return nil, errors.New("the template prints nothing")
+	}
+
+	code, err := qr.Encode([]byte(footer.VerifyURL))
+	if err != nil {
+		return nil, err
+	}
+
+	document := pdf.New(fmt.Sprintf("%s %s", strings.ToUpper(tmpl.Form), data.Case.Number))
+	document.Subject = "Medical examination report"
+	layout := &pf3Layout{document: document}
+	layout.newPage()
+	for _, line := range pf3Lines(out.String()) {
+		layout.draw(line)
+	}
+
+	pages := document.Pages()
+	for i, page := range layout.pages {
+		drawPf3Footer(page, code, footer, tmpl, i+1, pages)
+	}
+	return document.Bytes(), nil
+}
+
+// pf3Lines splits template output into lines, joining continuation lines to
+// the line they continue
+func pf3Lines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(line, pf3Continuation) && len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines[len(lines)-1] += "\n" + strings.TrimSpace(line)
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return lines
+}
+
+type pf3Layout struct {
+	document *pdf.Document
+	pages    []*pdf.Page
+	page     *pdf.Page
+	y        float64
+}
+
+func (l *pf3Layout) newPage() {
+	l.page = l.document.AddPage()
+	l.pages = append(l.pages, l.page)
+	l.y = pdf.A4Height - pf3Margin
+}
+
+// need starts a new page unless height points remain above the footer
+func (l *pf3Layout) need(height float64) {
+	if l.y-height < pf3FooterTop {
+		l.newPage()
+	}
+}
+
+func (l *pf3Layout) draw(line string) {
+	width := pdf.A4Width - 2*pf3Margin
+	switch {
+	case strings.TrimSpace(line) == "":
+		l.y -= pf3LineHeight / 2
+	case strings.HasPrefix(line, "### "):
+		l.need(2.5 * pf3LineHeight)
+		l.y -= 4
+		l.page.Gray(0.85)
+		l.page.Rect(pf3Margin, l.y-4, width, 15, true)
+		l.page.Gray(0)
+		l.page.Text(pf3Margin+4, l.y, pdf.HelveticaBold, 10, strings.TrimPrefix(line, "### "))
+		l.y -= pf3LineHeight + 6
+	case strings.HasPrefix(line, "## "):
+		l.need(pf3LineHeight)
+		text := strings.TrimPrefix(line, "## ")
+		l.page.Text(centred(text, 11), l.y, pdf.HelveticaBold, 11, text)
+		l.y -= pf3LineHeight + 2
+	case strings.HasPrefix(line, "# "):
+		l.need(2 * pf3LineHeight)
+		text := strings.TrimPrefix(line, "# ")
+		l.page.Text(centred(text, 16), l.y-4, pdf.HelveticaBold, 16, text)
+		l.y -= 2*pf3LineHeight + 2
+	case line == "---":
+		l.need(pf3LineHeight)
+		l.page.Line(pf3Margin, l.y+4, pdf.A4Width-pf3Margin, l.y+4, 0.75)
+		l.y -= pf3LineHeight / 2
+	case strings.HasPrefix(line, "___ "):
+		l.need(3 * pf3LineHeight)
+		l.y -= 2 * pf3LineHeight
+		l.page.Line(pf3Margin, l.y, pf3Margin+220, l.y, 0.5)
+		l.page.Text(pf3Margin, l.y-10, pdf.Helvetica, 8, strings.TrimPrefix(line, "___ "))
+		l.y -= pf3LineHeight + 4
+	case strings.Contains(line, ":: "):
+		label, value, _ := strings.Cut(line, ":: ")
+		labelLines := wrapLines(label, charsIn(pf3LabelWidth-6, pf3FontSize))
+		valueLines := wrapLines(value, charsIn(width-pf3LabelWidth, pf3FontSize))
+		for i := 0; i < max(len(labelLines), len(valueLines)); i++ {
+			l.need(pf3LineHeight)
+			if i < len(labelLines) {
+				l.page.Text(pf3Margin, l.y, pdf.CourierBold, pf3FontSize, labelLines[i])
+			}
+			if i < len(valueLines) {
+				l.page.Text(pf3Margin+pf3LabelWidth, l.y, pdf.Courier, pf3FontSize, valueLines[i])
+			}
+			l.y -= pf3LineHeight
+		}
+		l.y -= 2
+	default:
+		for _, text := range wrapLines(line, charsIn(width, pf3FontSize)) {
+			l.need(pf3LineHeight)
+			l.page.Text(pf3Margin, l.y, pdf.Courier, pf3FontSize, text)
+			l.y -= pf3LineHeight
+		}
+	}
+}
+
+// charsIn is how many Courier characters of the given size fit in width
+func charsIn(width, size float64) int {
+	return int(width / (pdf.CourierAdvance * size))
+}
+
+// centred returns the x at which Helvetica Bold text of the given size is
+// roughly centred on the page
+func centred(text string, size float64) float64 {
+	return max(pf3Margin, (pdf.A4Width-float64(len(text))*size*0.62)/2)
+}
+
+// wrapLines breaks each line of s at spaces to at most width characters,
+// splitting words longer than a line
+func wrapLines(s string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for len(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				lines = append(lines, word[:width])
+				word = word[width:]
+			}
+			switch {
+			case line == "":
+				line = word
+			case len(line)+1+len(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		if line != "" || len(lines) == 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// drawPf3Footer prints the verification block: the QR code linking to the
+// verification page on the right and the code, digest and signature on the
+// left
+func drawPf3Footer(page *pdf.Page, code *qr.Code, footer Pf3Footer, tmpl models.Pf3Template, number, pages int) {
+	right := pdf.A4Width - pf3Margin
+	page.Line(pf3Margin, pf3FooterTop-8, right, pf3FooterTop-8, 0.5)
+
+	// Four modules of quiet zone around the symbol
+	module := pf3QRSize / float64(code.Size+8)
+	left, top := right-pf3QRSize+4*module, pf3Margin+pf3QRSize-4*module
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; col++ {
+			if !code.Dark(row, col) {
+				continue
+			}
+			// Draw runs of dark modules as one rectangle to avoid seams
+			run := 1
+			for col+run < code.Size && code.Dark(row, col+run) {
+				run++
+			}
+			page.Rect(left+float64(col)*module, top-float64(row+1)*module, float64(run)*module, module, true)
+			col += run - 1
+		}
+	}
+
+	y := pf3FooterTop - 22
+	page.Text(pf3Margin, y, pdf.HelveticaBold, 8, "Verify this form by scanning the code or visiting:")
+	page.Text(pf3Margin, y-11, pdf.Courier, 7, footer.VerifyURL)
+	page.Text(pf3Margin, y-24, pdf.HelveticaBold, 8, "Verification code: "+footer.VerificationCode)
+	page.Text(pf3Margin, y-36, pdf.Courier, 6.5, "Content SHA-256: "+footer.ContentSHA256)
+	page.Text(pf3Margin, y-46, pdf.Courier, 6.5, "Signature:       "+footer.Signature)
+	page.Text(pf3Margin, y-60, pdf.Helvetica, 7, fmt.Sprintf("%s template v%d. Issued %s. Page %d of %d.",
+		strings.ToUpper(tmpl.Form), tmpl.Version, footer.IssuedAt.Format("2006-01-02 15:04 MST"), number, pages))
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/qr"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPf3ContentDigestCoversOnlyTheExaminationRecord(t *testing.T) {
+	base := SamplePf3Data(models.FormPF3)
+	digest := Pf3ContentDigest(7, models.FormPF3, 2, base)
+	if len(digest) != 64 {
+		t.Fatalf("digest %q is not hex SHA-256", digest)
+	}
+
+	unchanged := map[string]func(*Pf3Data){
+		"station renamed":             func(d *Pf3Data) { d.Case.Station = "Kampala Central Police Station" },
+		"practitioner phone changed":  func(d *Pf3Data) { d.Practitioner.Phone = "+256 772 111111" },
+		"facility contact changed":    func(d *Pf3Data) { d.Facility.Contact = "info@example.org" },
+		"facility location corrected": func(d *Pf3Data) { d.Facility.Location = "Kampala District" },
+	}
+	for name, edit := range unchanged {
+		data := base
+		edit(&data)
+		if got := Pf3ContentDigest(7, models.FormPF3, 2, data); got != digest {
+			t.Errorf("%s: digest changed", name)
+		}
+	}
+
+	changed := map[string]func(*Pf3Data){
+		"findings":      func(d *Pf3Data) { d.Examination.Findings = "No injuries seen." },
+		"injuries":      func(d *Pf3Data) { d.Examination.Injuries = "-" },
+		"genital":       func(d *Pf3Data) { d.Examination.GenitalExamination = "Not examined" },
+		"consent":       func(d *Pf3Data) { d.Examination.Consent = "Not given" },
+		"victim name":   func(d *Pf3Data) { d.Victim.Name = "Another Person" },
+		"victim age":    func(d *Pf3Data) { d.Victim.Age = "17 years" },
+		"incident date": func(d *Pf3Data) { d.Incident.Date = "2026-01-01" },
+		"account":       func(d *Pf3Data) { d.Incident.Description = "-" },
+	}
+	for name, edit := range changed {
+		data := base
+		edit(&data)
+		if got := Pf3ContentDigest(7, models.FormPF3, 2, data); got == digest {
+			t.Errorf("%s: digest did not change", name)
+		}
+	}
+
+	if Pf3ContentDigest(8, models.FormPF3, 2, base) == digest ||
+		Pf3ContentDigest(7, models.FormPF3A, 2, base) == digest ||
+		Pf3ContentDigest(7, models.FormPF3, 3, base) == digest {
+		t.Error("digest does not depend on the examination, form and template version")
+	}
+}
+
+func TestPf3Value(t *testing.T) {
+	tests := map[string]string{
+		"":                      "-",
+		"  \n ":                 "-",
+		"Bruise":                "Bruise",
+		" one\r\ntwo\nthree \n": "one\n  two\n  three",
+	}
+	for in, want := range tests {
+		if got := pf3Value(in); got != want {
+			t.Errorf("pf3Value(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPf3CodedFindings(t *testing.T) {
+	length, width := 4.0, 2.5
+	yes, no := true, false
+	age := 15
+	injuries := []models.ExaminationInjury{
+		{InjuryType: models.InjuryBruise, BodyRegion: "arm.forearm", Side: models.SideLeft, LengthCm: &length, WidthCm: &width, AgeEstimate: models.InjuryAgeRecent},
+		{InjuryType: models.InjuryTear, BodyRegion: "genital.fourchette", AgeEstimate: models.InjuryAgeUnknown, Description: " healing\n edges "},
+		{InjuryType: models.InjuryIncised, BodyRegion: "head.scalp", LengthCm: &length},
+	}
+	wantInjuries := "Bruise, left forearm, 4 x 2.5 cm, 1 to 3 days old\n" +
+		"Tear, posterior fourchette: healing edges\n" +
+		"Incised wound, scalp, 4 cm long"
+	if got := pf3Injuries(injuries); got != wantInjuries {
+		t.Errorf("injuries:\n%s\nwant:\n%s", got, wantInjuries)
+	}
+
+	findings := &models.ExaminationFindings{
+		EstimatedAgeYears: &age, AgeEstimateMethod: models.AgeMethodSkeletal,
+		GenitalExamined: true, Hymen: models.HymenFreshTear, GenitalTears: &yes, Bleeding: &no,
+		PenetrationOpinion: models.PenetrationConsistent,
+	}
+	if got, want := pf3EstimatedAge(findings), "About 15 years (skeletal xray)"; got != want {
+		t.Errorf("estimated age = %q, want %q", got, want)
+	}
+	wantGenital := "Hymen: fresh tear\nGenital tears: yes\nBleeding: no\nOpinion on penetration: consistent"
+	if got := pf3GenitalExamination(findings); got != wantGenital {
+		t.Errorf("genital examination = %q, want %q", got, wantGenital)
+	}
+	if got := pf3GenitalExamination(&models.ExaminationFindings{}); got != "Not examined" {
+		t.Errorf("genital examination not done = %q", got)
+	}
+	if pf3EstimatedAge(nil) != "" || pf3GenitalExamination(nil) != "" || pf3Injuries(nil) != "" {
+		t.Error("nothing recorded should print blank")
+	}
+}
+
+func TestPf3Lines(t *testing.T) {
+	output := "# Title\nFindings:: one\n  two\n\n  orphan\nText \t\r\n"
+	want := []string{"# Title", "Findings:: one\ntwo", "", "  orphan", "Text", ""}
+	if got := pf3Lines(output); !reflect.DeepEqual(got, want) {
+		t.Fatalf("pf3Lines = %q, want %q", got, want)
+	}
+}
+
+func TestWrapLines(t *testing.T) {
+	tests := []struct {
+		in    string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"short", 10, []string{"short"}},
+		{"one two three four", 9, []string{"one two", "three", "four"}},
+		{"abcdefghijkl xy", 5, []string{"abcde", "fghij", "kl xy"}},
+		{"first\nsecond line", 6, []string{"first", "second", "line"}},
+	}
+	for _, test := range tests {
+		if got := wrapLines(test.in, test.width); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("wrapLines(%q, %d) = %q, want %q", test.in, test.width, got, test.want)
+		}
+	}
+}
+
+func samplePf3Footer() Pf3Footer {
+	return Pf3Footer{
+		VerifyURL:        Pf3VerifyURL("https://gbvmis.example.org/", "ABCDEFGHJKMNPQRS"),
+		VerificationCode: "ABCDEFGHJKMNPQRS",
+		ContentSHA256:    strings.Repeat("ab", 32),
+		Signature:        strings.Repeat("cd", 32),
+		IssuedAt:         time.Date(2026, 1, 2, 10, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestRenderPf3(t *testing.T) {
+	footer := samplePf3Footer()
+	if footer.VerifyURL != "https://gbvmis.example.org/api/pf3/verify/ABCDEFGHJKMNPQRS" {
+		t.Fatalf("verify URL = %q", footer.VerifyURL)
+	}
+
+	for _, form := range []string{models.FormPF3, models.FormPF3A} {
+		data := SamplePf3Data(form)
+		out, err := RenderPf3(BuiltinPf3Template(form), data, footer)
+		if err != nil {
+			t.Fatalf("%s: %v", form, err)
+		}
+		if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+			t.Fatalf("%s: not a PDF", form)
+		}
+		for _, text := range []string{
+			"(POLICE FORM 3)",
+			"(" + data.Case.Number + ")",
+			"(" + data.Victim.Name + ")",
+			"(" + data.Facility.Name + ")",
+			"(Tender swelling over the right cheek.)",
+			"(" + footer.VerifyURL + ")",
+			"(Verification code: " + footer.VerificationCode + ")",
+			"(Content SHA-256: " + footer.ContentSHA256 + ")",
+			"(Bruise, left forearm, 4 x 3 cm, 1 to 3 days old)",
+			"(About 24 years \\(dental\\))",
+			"(" + strings.ToUpper(form) + " template v0. Issued 2026-01-02 10:30 UTC. Page 1 of ",
+		} {
+			if !bytes.Contains(out, []byte(text)) {
+				t.Errorf("%s: %s not printed", form, text)
+			}
+		}
+		account := bytes.Contains(out, []byte("(A second line of the account.)"))
+		if account != (form == models.FormPF3A) {
+			t.Errorf("%s: account of the incident printed = %v", form, account)
+		}
+		genital := bytes.Contains(out, []byte("(Opinion on penetration: inconclusive)"))
+		if genital != (form == models.FormPF3A) {
+			t.Errorf("%s: genital examination printed = %v", form, genital)
+		}
+	}
+}
+
+// TestRenderPf3QRCode checks the footer draws every dark module of the
+// verification link's QR code
+func TestRenderPf3QRCode(t *testing.T) {
+	footer := samplePf3Footer()
+	code, err := qr.Encode([]byte(footer.VerifyURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dark := 0
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; col++ {
+			if code.Dark(row, col) {
+				dark++
+			}
+		}
+	}
+
+	out, err := RenderPf3(models.Pf3Template{Form: models.FormPF3, Body: "# Title"}, SamplePf3Data(models.FormPF3), footer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The page has no other filled rectangles; runs of modules share one
+	module := pf3QRSize / float64(code.Size+8)
+	covered := 0.0
+	for _, line := range strings.Split(string(out), "\n") {
+		var x, y, w, h float64
+		if n, _ := fmt.Sscanf(line, "%f %f %f %f re f", &x, &y, &w, &h); n == 4 && strings.HasSuffix(line, " re f") {
+			covered += w / module
+		}
+	}
+	if int(covered+0.5) != dark {
+		t.Fatalf("footer fills %.1f modules, the code has %d dark", covered, dark)
+	}
+}
+
+func TestRenderPf3RejectsBadTemplates(t *testing.T) {
+	tests := map[string]string{
+		"syntax":      "{{.Case.Number",
+		"unknown key": "{{.Case.Missing}}",
+		"empty":       "{{/* nothing */}}\n  \n",
+	}
+	for name, body := range tests {
+		if _, err := RenderPf3(models.Pf3Template{Form: models.FormPF3, Body: body}, SamplePf3Data(models.FormPF3), samplePf3Footer()); err == nil {
+			t.Errorf("%s: rendered without error", name)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"log"
+	"time"
+)
+
+// BreachCaseStatus is the status a breach case is opened with
+const BreachCaseStatus = "open"
+
+// ProtectionOrderInForce reports whether the order was binding at the given time
+func ProtectionOrderInForce(order models.ProtectionOrder, at time.Time) bool {
+	if order.Status == models.OrderStatusRevoked && order.RevokedAt != nil && !at.Before(*order.RevokedAt) {
+		return false
+	}
+	if at.Before(order.ValidFrom) {
+		return false
+	}
+	// ValidUntil is a date; the order holds until the end of that day
+	return order.ValidUntil == nil || at.Before(order.ValidUntil.AddDate(0, 0, 1))
+}
+
+// ReportProtectionOrderBreach opens a new case for the breach, linked to the
+// order's case and carrying over the victim, the respondent and their
+// relationship, and alerts the investigating officer of the original case.
+// The order must have been loaded with its case and the case's relationships.
+func ReportProtectionOrderBreach(repo repository.ProtectionOrderRepository, order models.ProtectionOrder, breach *models.ProtectionOrderBreach) (models.Case, error) {
+	parent := order.Case
+	parentID := parent.ID
+	newCase := models.Case{
+		Title: "Breach of protection order " + order.OrderNumber,
+		Description: fmt.Sprintf("Breach of %s order %s issued by %s, reported %s at %s.\n\n%s",
+			order.OrderType, order.OrderNumber, order.IssuingCourt,
+			breach.OccurredAt.Format("2006-01-02 15:04"), breach.Location, breach.Description),
+		Status:       BreachCaseStatus,
+		DateOpened:   time.Now(),
+		OfficerID:    parent.OfficerID,
+		PolicePostID: parent.PolicePostID,
+		Victims:      []models.Victim{order.Victim},
+		Suspects:     []models.Suspect{order.RespondentSuspect},
+		ParentCaseID: &parentID,
+
+		// A breach case inherits the risk of the case it arose from
+		RiskLevel: parent.RiskLevel,
+		Priority:  parent.Priority,
+	}
+
+	var relationship *models.CaseRelationship
+	for _, r := range parent.Relationships {
+		if r.VictimID == order.VictimID && r.SuspectID == order.RespondentSuspectID {
+			relationship = &models.CaseRelationship{
+				VictimID:         r.VictimID,
+				SuspectID:        r.SuspectID,
+				RelationshipType: r.RelationshipType,
+				Cohabiting:       r.Cohabiting,
+				Dependency:       r.Dependency,
+				Notes:            r.Notes,
+			}
+			break
+		}
+	}
+
+	notification := models.Notification{
+		Type:       models.NotificationProtectionOrderBreach,
+		Title:      "Protection order breached",
+		EntityType: "case",
+	}
+	if parent.OfficerID != 0 {
+		officerID := parent.OfficerID
+		notification.OfficerID = &officerID
+	} else {
+		postID := parent.PolicePostID
+		notification.PolicePostID = &postID
+	}
+
+	breach.OrderID = order.ID
+	// Numbered inside the transaction so concurrent reports cannot take the same number
+	err := repo.ReportBreach(breach, &newCase, relationship, &notification, func(breaches int64) {
+		newCase.CaseNumber = fmt.Sprintf("%s-BR%d", parent.CaseNumber, breaches+1)
+		notification.Message = fmt.Sprintf("%s %s is reported to have breached protection order %s protecting %s %s in case %s. Breach case %s has been opened.",
+			order.RespondentSuspect.FirstName, order.RespondentSuspect.LastName, order.OrderNumber,
+			order.Victim.FirstName, order.Victim.LastName, parent.CaseNumber, newCase.CaseNumber)
+	})
+	if err != nil {
+		return models.Case{}, err
+	}
+	if relationship != nil {
+		newCase.Relationships = []models.CaseRelationship{*relationship}
+	}
+	return newCase, nil
+}
+
+// WatchProtectionOrderExpiry expires lapsed protection orders on start-up and
+// then on every tick of the given interval. It is meant to run in its own goroutine.
+func WatchProtectionOrderExpiry(repo repository.ProtectionOrderRepository, interval time.Duration) {
+	expire := func() {
+		count, err := repo.ExpireOrders(time.Now())
+		if err != nil {
+			log.Println("Error expiring protection orders:", err)
+			return
+		}
+		if count > 0 {
+			log.Printf("Expired %d protection order(s)", count)
+		}
+	}
+
+	expire()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		expire()
+	}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/storage"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ReportTable is one section of a report: a titled table of text cells
+type ReportTable struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// ReportDocument is the content of a report before it is rendered
+type ReportDocument struct {
+	Title       string
+	Scope       string // "National" or the police post's name
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	GeneratedAt time.Time
+	Tables      []ReportTable
+}
+
+// ReportPeriodRange returns the first and last day of the monthly or
+// quarterly period containing at
+func ReportPeriodRange(period string, at time.Time) (time.Time, time.Time) {
+	month := at.Month()
+	months := 1
+	if period == models.ReportPeriodQuarterly {
+		month = (month-1)/3*3 + 1
+		months = 3
+	}
+	start := time.Date(at.Year(), month, 1, 0, 0, 0, 0, at.Location())
+	return start, start.AddDate(0, months, -1)
+}
+
+// PreviousReportPeriod returns the last complete period before now, which is
+// what a scheduled run reports on
+func PreviousReportPeriod(period string, now time.Time) (time.Time, time.Time) {
+	current, _ := ReportPeriodRange(period, now)
+	return ReportPeriodRange(period, current.AddDate(0, 0, -1))
+}
+
+// reportPeriodLabel names a period, e.g. 2026-03 or 2026-Q1
+func reportPeriodLabel(period string, start time.Time) string {
+	if period == models.ReportPeriodQuarterly {
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	}
+	return start.Format("2006-01")
+}
+
+// NextReportRun returns when a schedule next fires after the given time, or
+// nil if it never does
+func NextReportRun(schedule string, after time.Time) (*time.Time, error) {
+	cron, err := ParseCron(schedule)
+	if err != nil {
+		return nil, err
+	}
+	next := cron.Next(after)
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// =================================
+
+func formatMedian(median *float64) string {
+	if median == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*median, 'f', 1, 64)
+}
+
+func count(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+// countTable lays out case counts under one dimension
+func countTable(title, column string, rows []models.StatCount) ReportTable {
+	table := ReportTable{Title: title, Columns: []string{column, "Cases"}}
+	for _, row := range rows {
+		table.Rows = append(table.Rows, []string{row.Key, count(row.Cases)})
+	}
+	return table
+}
+
+// buildReportTable runs the statistic behind a section and lays it out
+func buildReportTable(stats repository.StatsRepository, section string, filter models.StatsFilter) (ReportTable, error) {
+	switch section {
+	case models.ReportSectionCasesByStatus:
+		rows, err := stats.GetCasesByStatus(filter)
+		return countTable("Cases by status", "Status", rows), err
+
+	case models.ReportSectionCasesByCharge:
+		rows, err := stats.GetCasesByCharge(filter)
+		return countTable("Cases by charge", "Charge", rows), err
+
+	case models.ReportSectionCasesByDistrict:
+		rows, err := stats.GetCasesByDistrict(filter)
+		return countTable("Cases by district", "District", rows), err
+
+	case models.ReportSectionCasesByStation:
+		table := ReportTable{Title: "Cases by police post", Columns: []string{"Police post", "Station", "Cases"}}
+		rows, err := stats.GetCasesByStation(filter)
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []string{row.PolicePost, row.Station, count(row.Cases)})
+		}
+		return table, err
+
+	case models.ReportSectionCasesByMonth:
+		table := ReportTable{Title: "Cases opened by month", Columns: []string{"Month", "Status", "Cases"}}
+		rows, err := stats.GetCasesByMonth(filter)
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []string{row.Month, row.Status, count(row.Cases)})
+		}
+		return table, err
+
+	case models.ReportSectionVictimsByAge:
+		table := ReportTable{Title: "Victims by age band and gender", Columns: []string{"Age band", "Gender", "Victims"}}
+		rows, err := stats.GetVictimsByAgeBand(filter)
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []string{row.AgeBand, row.Gender, count(row.Victims)})
+		}
+		return table, err
+
+	case models.ReportSectionRelationships:
+		table := ReportTable{Title: "Victim-suspect relationships", Columns: []string{"Relationship", "Pairs", "Cohabiting", "Dependent"}}
+		rows, err := stats.GetRelationshipStats(filter)
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []string{row.RelationshipType, count(row.Pairs), count(row.Cohabiting), count(row.Dependent)})
+		}
+		return table, err
+
+	case models.ReportSectionTimeliness:
+		table := ReportTable{Title: "Timeliness (medians)", Columns: []string{"Measure", "Unit", "Count", "Median"}}
+		rows, err := stats.GetTimeliness(filter)
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []string{row.Measure, row.Unit, count(row.Count), formatMedian(row.Median)})
+		}
+		return table, err
+	}
+	return ReportTable{}, fmt.Errorf("unknown report section %q", section)
+}
+
+// BuildReportDocument gathers a definition's sections for one period and,
+// when post is set, one police post
+func BuildReportDocument(stats repository.StatsRepository, definition models.ReportDefinition, post *models.PolicePost, start, end time.Time) (ReportDocument, error) {
+	doc := ReportDocument{
+		Title:       definition.Name,
+		Scope:       "National",
+		PeriodStart: start,
+		PeriodEnd:   end,
+		GeneratedAt: time.Now(),
+	}
+	filter := models.StatsFilter{From: start.Format("2006-01-02"), To: end.Format("2006-01-02")}
+	if post != nil {
+		doc.Scope = post.Name
+		filter.PolicePostID = &post.ID
+	}
+
+	for _, section := range definition.Sections {
+		table, err := buildReportTable(stats, section, filter)
+		if err != nil {
+			return ReportDocument{}, fmt.Errorf("%s: %w", section, err)
+		}
+		doc.Tables = append(doc.Tables, table)
+	}
+	return doc, nil
+}
+
+// RenderReport renders a document to one of the report formats, returning
+// the file content, its MIME type and file extension
+func RenderReport(doc ReportDocument, format string) ([]byte, string, string, error) {
+	switch format {
+	case models.ReportFormatCSV:
+		data, err := renderReportCSV(doc)
+		return data, "text/csv", "csv", err
+	case models.ReportFormatXLSX:
+		data, err := renderReportXLSX(doc)
+		return data, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", err
+	case models.ReportFormatPDF:
+		return renderReportPDF(doc), "application/pdf", "pdf", nil
+	}
+	return nil, "", "", fmt.Errorf("unknown report format %q", format)
+}
+
+// slug turns a name into a lower-case file name part
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// storeReport saves rendered content to the blob store
+func storeReport(ctx context.Context, store storage.BlobStore, data []byte, mimeType string) (*models.FileBlob, error) {
+	staged, err := storage.Stage(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer staged.Close()
+	if err := staged.Save(ctx, store); err != nil {
+		return nil, err
+	}
+	return &models.FileBlob{
+		SHA256:     staged.SHA256,
+		Size:       staged.Size,
+		MimeType:   mimeType,
+		Driver:     store.Driver(),
+		ScanStatus: storage.ScanSkipped,
+	}, nil
+}
+
+// GenerateReports renders a definition for the period starting at start, in
+// each of its formats and, for per-station definitions, for each police
+// post. A file that fails is recorded as failed with the reason; the error
+// returned is only for failures to record anything.
+func GenerateReports(ctx context.Context, repo repository.ReportRepository, stats repository.StatsRepository, store storage.BlobStore, definition models.ReportDefinition, start time.Time, trigger string, requestedByID *uint) ([]models.GeneratedReport, error) {
+	start, end := ReportPeriodRange(definition.Period, start)
+
+	var posts []*models.PolicePost
+	switch {
+	case definition.PolicePostID != nil:
+		post, err := repo.FindPolicePostByID(*definition.PolicePostID)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, &post)
+	case definition.PerStation:
+		all, err := repo.GetPolicePosts()
+		if err != nil {
+			return nil, err
+		}
+		for i := range all {
+			posts = append(posts, &all[i])
+		}
+	default:
+		posts = append(posts, nil)
+	}
+
+	var reports []models.GeneratedReport
+	for _, post := range posts {
+		doc, buildErr := BuildReportDocument(stats, definition, post, start, end)
+		scope := "national"
+		if post != nil {
+			scope = slug(post.Name)
+		}
+
+		for _, format := range definition.Formats {
+			report := models.GeneratedReport{
+				DefinitionID:  definition.ID,
+				PeriodStart:   start,
+				PeriodEnd:     end,
+				Format:        format,
+				Status:        models.ReportStatusReady,
+				Trigger:       trigger,
+				RequestedByID: requestedByID,
+				FileName:      fmt.Sprintf("%s_%s_%s.%s", slug(definition.Name), scope, reportPeriodLabel(definition.Period, start), format),
+			}
+			if post != nil {
+				report.PolicePostID = &post.ID
+			}
+
+			var blob *models.FileBlob
+			err := buildErr
+			if err == nil {
+				var data []byte
+				if data, report.MimeType, _, err = RenderReport(doc, format); err == nil {
+					if blob, err = storeReport(ctx, store, data, report.MimeType); err == nil {
+						report.BlobSHA256, report.Size = blob.SHA256, blob.Size
+					}
+				}
+			}
+			if err != nil {
+				report.Status = models.ReportStatusFailed
+				report.Error = err.Error()
+			}
+
+			if err := repo.CreateGeneratedReport(&report, blob); err != nil {
+				return reports, err
+			}
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+// RunReportSchedule generates the reports that are due on start-up and then
+// on every tick of the given interval, each for its last complete period.
+// It is meant to run in its own goroutine.
+func RunReportSchedule(repo repository.ReportRepository, stats repository.StatsRepository, store storage.BlobStore, interval time.Duration) {
+	run := func() {
+		now := time.Now()
+		definitions, err := repo.GetDueReportDefinitions(now)
+		if err != nil {
+			log.Println("Error loading due reports:", err)
+			return
+		}
+		for _, definition := range definitions {
+			start, _ := PreviousReportPeriod(definition.Period, now)
+			reports, err := GenerateReports(context.Background(), repo, stats, store, definition, start, models.ReportTriggerScheduled, nil)
+			if err != nil {
+				log.Printf("Error generating report %q: %v", definition.Name, err)
+			}
+			failed := 0
+			for _, report := range reports {
+				if report.Status == models.ReportStatusFailed {
+					failed++
+				}
+			}
+			log.Printf("Generated %d file(s) for report %q (%d failed)", len(reports), definition.Name, failed)
+
+			next, err := NextReportRun(definition.Schedule, now)
+			if err != nil {
+				log.Printf("Report %q has an invalid schedule: %v", definition.Name, err)
+			}
+			if err := repo.SetReportDefinitionRun(definition.ID, now, next); err != nil {
+				log.Printf("Error recording run of report %q: %v", definition.Name, err)
+			}
+		}
+	}
+
+	run()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		run()
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"gbvmis/internals/pdf"
+	"strconv"
+	"strings"
+)
+
+// reportHeading is the lines every rendering starts with
+func reportHeading(doc ReportDocument) [][]string {
+	return [][]string{
+		{doc.Title},
+		{doc.Scope},
+		{"Period", doc.PeriodStart.Format("2006-01-02"), doc.PeriodEnd.Format("2006-01-02")},
+		{"Generated", doc.GeneratedAt.Format("2006-01-02 15:04")},
+	}
+}
+
+// renderReportCSV writes the heading and then each table under its title,
+// separated by blank lines
+func renderReportCSV(doc ReportDocument) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	records := reportHeading(doc)
+	for _, table := range doc.Tables {
+		records = append(records, []string{}, []string{table.Title}, table.Columns)
+		records = append(records, table.Rows...)
+	}
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// =================================
+
+// xlsxSheetName makes a valid, unique worksheet name: at most 31 characters
+// and none of []:*?/\
+func xlsxSheetName(title string, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	if len(name) > 31 {
+		name = name[:31]
+	}
+	base := name
+	for i := 2; used[strings.ToLower(name)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		name = base[:min(len(base), 31-len(suffix))] + suffix
+	}
+	used[strings.ToLower(name)] = true
+	return name
+}
+
+// xlsxColumn returns the letters of a zero-based column index
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxRow writes one row; numbers become numeric cells and the rest inline
+// strings. Style 1 is bold.
+func xlsxRow(b *strings.Builder, row int, cells []string, bold bool) {
+	fmt.Fprintf(b, `<row r="%d">`, row)
+	for i, cell := range cells {
+		ref := fmt.Sprintf("%s%d", xlsxColumn(i), row)
+		style := ""
+		if bold {
+			style = ` s="1"`
+		}
+		if _, err := strconv.ParseFloat(cell, 64); err == nil && !bold {
+			fmt.Fprintf(b, `<c r="%s"%s><v>%s</v></c>`, ref, style, cell)
+			continue
+		}
+		var escaped bytes.Buffer
+		xml.EscapeText(&escaped, []byte(cell))
+		fmt.Fprintf(b, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escaped.String())
+	}
+	b.WriteString("</row>")
+}
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
+
+// renderReportXLSX writes a workbook with one worksheet per table, each
+// starting with the report heading
+func renderReportXLSX(doc ReportDocument) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	write := func(name, content string) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write([]byte(content))
+		return err
+	}
+
+	tables := doc.Tables
+	if len(tables) == 0 {
+		tables = []ReportTable{{Title: "Report"}}
+	}
+
+	var contentTypes, sheets, rels strings.Builder
+	used := map[string]bool{}
+	for i, table := range tables {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		var name bytes.Buffer
+		xml.EscapeText(&name, []byte(xlsxSheetName(table.Title, used)))
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, name.String(), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+
+		var sheet strings.Builder
+		sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+		row := 1
+		for j, line := range reportHeading(doc) {
+			xlsxRow(&sheet, row, line, j == 0)
+			row++
+		}
+		row++
+		xlsxRow(&sheet, row, []string{table.Title}, true)
+		row++
+		xlsxRow(&sheet, row, table.Columns, true)
+		for _, cells := range table.Rows {
+			row++
+			xlsxRow(&sheet, row, cells, false)
+		}
+		sheet.WriteString(`</sheetData></worksheet>`)
+		if err := write(fmt.Sprintf("xl/worksheets/sheet%d.xml", n), sheet.String()); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(tables)+1)
+
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			contentTypes.String() + `</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, f := range files {
+		if err := write(f.name, f.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// =================================
+
+// PDF layout, in points
+const (
+	reportMargin   = 50.0
+	reportFontSize = 9.0
+	reportLeading  = 12.0
+)
+
+// renderReportPDF lays the tables out in Courier so columns line up, with
+// cells cut to fit the page width
+func renderReportPDF(doc ReportDocument) []byte {
+	document := pdf.New(doc.Title)
+	document.Subject = doc.Scope + ", " + doc.PeriodStart.Format("2006-01-02") + " to " + doc.PeriodEnd.Format("2006-01-02")
+
+	var page *pdf.Page
+	var pages []*pdf.Page
+	y := 0.0
+	newPage := func() {
+		page = document.AddPage()
+		pages = append(pages, page)
+		y = pdf.A4Height - reportMargin
+	}
+	// ensure starts a new page unless there is room for the given height
+	ensure := func(height float64) {
+		if page == nil || y-height < reportMargin+reportLeading {
+			newPage()
+		}
+	}
+
+	newPage()
+	page.Text(reportMargin, y, pdf.HelveticaBold, 16, doc.Title)
+	y -= 22
+	for _, line := range reportHeading(doc)[1:] {
+		page.Text(reportMargin, y, pdf.Helvetica, 10, strings.Join(line, "  "))
+		y -= 14
+	}
+
+	textWidth := pdf.A4Width - 2*reportMargin
+	maxChars := int(textWidth / (pdf.CourierAdvance * reportFontSize))
+	for _, table := range doc.Tables {
+		y -= reportLeading
+		ensure(3 * reportLeading)
+		page.Text(reportMargin, y, pdf.HelveticaBold, 12, table.Title)
+		y -= 16
+
+		if len(table.Rows) == 0 {
+			page.Text(reportMargin, y, pdf.Helvetica, 10, "No data for this period.")
+			y -= reportLeading
+			continue
+		}
+
+		widths := make([]int, len(table.Columns))
+		for i, column := range table.Columns {
+			widths[i] = len(column)
+		}
+		for _, row := range table.Rows {
+			for i, cell := range row {
+				if i < len(widths) {
+					widths[i] = max(widths[i], len([]rune(cell)))
+				}
+			}
+		}
+		// Give the first column whatever the others leave, so long names are
+		// cut rather than the counts
+		rest := 0
+		for _, w := range widths[1:] {
+			rest += w + 2
+		}
+		widths[0] = max(min(widths[0], maxChars-rest), 4)
+
+		line := func(cells []string) string {
+			var b strings.Builder
+			for i, w := range widths {
+				cell := ""
+				if i < len(cells) {
+					cell = cells[i]
+				}
+				if runes := []rune(cell); len(runes) > w {
+					cell = string(runes[:w-1]) + "~"
+				}
+				if i == 0 {
+					fmt.Fprintf(&b, "%-*s", w, cell)
+				} else {
+					fmt.Fprintf(&b, "  %*s", w, cell)
+				}
+			}
+			return b.String()
+		}
+
+		header := func() {
+			page.Text(reportMargin, y, pdf.CourierBold, reportFontSize, line(table.Columns))
+			page.Line(reportMargin, y-3, pdf.A4Width-reportMargin, y-3, 0.5)
+			y -= reportLeading + 2
+		}
+		header()
+		for _, row := range table.Rows {
+			if y < reportMargin+reportLeading {
+				newPage()
+				header()
+			}
+			page.Text(reportMargin, y, pdf.Courier, reportFontSize, line(row))
+			y -= reportLeading
+		}
+	}
+
+	for i, p := range pages {
+		footer := fmt.Sprintf("%s - %s - page %d of %d", doc.Title, doc.Scope, i+1, len(pages))
+		p.Text(reportMargin, reportMargin/2, pdf.Helvetica, 8, footer)
+	}
+	return document.Bytes()
+}
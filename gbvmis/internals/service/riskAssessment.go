@@ -0,0 +1,154 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"slices"
+)
+
+// Factors that raise a risk level beyond what the score alone gives
+const (
+	RiskFactorCritical           = "critical_question" // followed by ":" and the question code
+	RiskFactorCohabitingPartner  = "cohabiting_with_partner_suspect"
+	RiskFactorDependentOnPartner = "dependent_on_partner_suspect"
+	RiskFactorDependentOnCarer   = "dependent_on_suspect_for_care"
+)
+
+// Thresholds used when a questionnaire does not set its own
+const (
+	defaultRiskMediumThreshold  = 0.25
+	defaultRiskHighThreshold    = 0.5
+	defaultRiskExtremeThreshold = 0.75
+)
+
+// ErrInvalidAnswers means the answers do not match the questionnaire's questions
+var ErrInvalidAnswers = errors.New("answers do not match the questionnaire")
+
+// RiskRank orders risk levels; unknown or empty levels rank below low
+func RiskRank(level string) int {
+	return slices.Index(models.RiskLevels, level)
+}
+
+// CasePriorityForRisk maps a risk level to the case priority it sets
+func CasePriorityForRisk(level string) string {
+	switch level {
+	case models.RiskLevelExtreme:
+		return models.CasePriorityCritical
+	case models.RiskLevelHigh:
+		return models.CasePriorityUrgent
+	case models.RiskLevelMedium:
+		return models.CasePriorityElevated
+	default:
+		return models.CasePriorityRoutine
+	}
+}
+
+// raiseRisk returns the level one step above, capped at extreme
+func raiseRisk(level string) string {
+	if i := RiskRank(level); i >= 0 && i < len(models.RiskLevels)-1 {
+		return models.RiskLevels[i+1]
+	}
+	return level
+}
+
+// ScoreRiskAssessment scores answers (question ID to yes/no) against the
+// questionnaire and fills in the assessment's answers, score, level and
+// factors. The level comes from the share of the maximum score; a yes to a
+// critical question makes it at least high, and living with or depending on
+// a partner suspect, or depending on the suspect for care, raises it a step.
+func ScoreRiskAssessment(questionnaire models.RiskQuestionnaire, answers map[uint]bool, relationships []models.CaseRelationship, assessment *models.RiskAssessment) error {
+	assessment.Answers = nil
+	assessment.Factors = nil
+	assessment.Score, assessment.MaxScore = 0, 0
+
+	critical := false
+	for _, q := range questionnaire.Questions {
+		answer, ok := answers[q.ID]
+		if !ok {
+			return fmt.Errorf("%w: question %d (%s) has no answer", ErrInvalidAnswers, q.ID, q.Code)
+		}
+		assessment.MaxScore += q.Weight
+		assessment.Answers = append(assessment.Answers, models.RiskAnswer{QuestionID: q.ID, Answer: answer, Weight: q.Weight})
+		if !answer {
+			continue
+		}
+		assessment.Score += q.Weight
+		if q.Critical {
+			critical = true
+			assessment.Factors = append(assessment.Factors, RiskFactorCritical+":"+q.Code)
+		}
+	}
+	if len(answers) != len(questionnaire.Questions) {
+		return fmt.Errorf("%w: some answers are for questions not in version %d", ErrInvalidAnswers, questionnaire.Version)
+	}
+
+	medium, high, extreme := questionnaire.MediumThreshold, questionnaire.HighThreshold, questionnaire.ExtremeThreshold
+	if high == 0 {
+		medium, high, extreme = defaultRiskMediumThreshold, defaultRiskHighThreshold, defaultRiskExtremeThreshold
+	}
+
+	share := 0.0
+	if assessment.MaxScore > 0 {
+		share = assessment.Score / assessment.MaxScore
+	}
+	level := models.RiskLevelLow
+	switch {
+	case share >= extreme:
+		level = models.RiskLevelExtreme
+	case share >= high:
+		level = models.RiskLevelHigh
+	case share >= medium:
+		level = models.RiskLevelMedium
+	}
+	if critical && RiskRank(level) < RiskRank(models.RiskLevelHigh) {
+		level = models.RiskLevelHigh
+	}
+
+	raise := false
+	for _, r := range relationships {
+		if r.VictimID != assessment.VictimID {
+			continue
+		}
+		partner := r.RelationshipType == models.RelationshipIntimatePartner || r.RelationshipType == models.RelationshipFormerPartner
+		switch {
+		case partner && r.Cohabiting:
+			assessment.Factors = append(assessment.Factors, RiskFactorCohabitingPartner)
+			raise = true
+		case partner && r.Dependency != "" && r.Dependency != models.DependencyNone:
+			assessment.Factors = append(assessment.Factors, RiskFactorDependentOnPartner)
+			raise = true
+		case r.Dependency == models.DependencyCare:
+			assessment.Factors = append(assessment.Factors, RiskFactorDependentOnCarer)
+			raise = true
+		}
+	}
+	if raise {
+		level = raiseRisk(level)
+	}
+
+	assessment.RiskLevel = level
+	return nil
+}
+
+// SubmitRiskAssessment stores a scored assessment and, in the same
+// transaction, resets the case's risk level and priority to the highest
+// latest level among its victims
+func SubmitRiskAssessment(repo repository.RiskAssessmentRepository, assessment *models.RiskAssessment) (string, string, error) {
+	var caseLevel, priority string
+	err := repo.CreateAssessment(assessment, func(levels []string) (string, string) {
+		caseLevel = models.RiskLevelLow
+		for _, l := range levels {
+			if RiskRank(l) > RiskRank(caseLevel) {
+				caseLevel = l
+			}
+		}
+		priority = CasePriorityForRisk(caseLevel)
+		return caseLevel, priority
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return caseLevel, priority, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"gbvmis/internals/models"
+	"slices"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func sampleQuestionnaire() models.RiskQuestionnaire {
+	question := func(id uint, code string, critical bool) models.RiskQuestion {
+		return models.RiskQuestion{Model: gorm.Model{ID: id}, Code: code, Weight: 1, Critical: critical}
+	}
+	return models.RiskQuestionnaire{Version: 2, Questions: []models.RiskQuestion{
+		question(1, "threats", false),
+		question(2, "escalation", false),
+		question(3, "jealousy", false),
+		question(4, "strangled", true),
+	}}
+}
+
+// yesTo answers yes to the listed questions and no to the rest
+func yesTo(ids ...uint) map[uint]bool {
+	answers := map[uint]bool{1: false, 2: false, 3: false, 4: false}
+	for _, id := range ids {
+		answers[id] = true
+	}
+	return answers
+}
+
+func TestScoreRiskAssessment(t *testing.T) {
+	partner := func(victimID uint, cohabiting bool, dependency string) models.CaseRelationship {
+		return models.CaseRelationship{VictimID: victimID, RelationshipType: models.RelationshipIntimatePartner, Cohabiting: cohabiting, Dependency: dependency}
+	}
+	tests := []struct {
+		name          string
+		thresholds    [3]float64
+		answers       map[uint]bool
+		relationships []models.CaseRelationship
+		score         float64
+		level         string
+		factors       []string
+	}{
+		{name: "nothing", answers: yesTo(), level: models.RiskLevelLow},
+		{name: "a quarter", answers: yesTo(1), score: 1, level: models.RiskLevelMedium},
+		{name: "half", answers: yesTo(1, 2), score: 2, level: models.RiskLevelHigh},
+		{name: "three quarters", answers: yesTo(1, 2, 3), score: 3, level: models.RiskLevelExtreme},
+		{name: "critical question alone", answers: yesTo(4), score: 1, level: models.RiskLevelHigh,
+			factors: []string{RiskFactorCritical + ":strangled"}},
+		{name: "living with a partner suspect", answers: yesTo(1), relationships: []models.CaseRelationship{partner(1, true, "")},
+			score: 1, level: models.RiskLevelHigh, factors: []string{RiskFactorCohabitingPartner}},
+		{name: "dependent on a former partner", answers: yesTo(),
+			relationships: []models.CaseRelationship{{VictimID: 1, RelationshipType: models.RelationshipFormerPartner, Dependency: models.DependencyFinancial}},
+			level:         models.RiskLevelMedium, factors: []string{RiskFactorDependentOnPartner}},
+		{name: "partner without dependency", answers: yesTo(), relationships: []models.CaseRelationship{partner(1, false, models.DependencyNone)},
+			level: models.RiskLevelLow},
+		{name: "cared for by a relative", answers: yesTo(1, 2),
+			relationships: []models.CaseRelationship{{VictimID: 1, RelationshipType: models.RelationshipRelative, Dependency: models.DependencyCare}},
+			score:         2, level: models.RiskLevelExtreme, factors: []string{RiskFactorDependentOnCarer}},
+		{name: "housed by a relative", answers: yesTo(1),
+			relationships: []models.CaseRelationship{{VictimID: 1, RelationshipType: models.RelationshipRelative, Dependency: models.DependencyHousing}},
+			score:         1, level: models.RiskLevelMedium},
+		{name: "another victim's partner", answers: yesTo(1), relationships: []models.CaseRelationship{partner(2, true, "")},
+			score: 1, level: models.RiskLevelMedium},
+		{name: "raised no higher than extreme", answers: yesTo(1, 2, 3, 4), relationships: []models.CaseRelationship{partner(1, true, "")},
+			score: 4, level: models.RiskLevelExtreme, factors: []string{RiskFactorCritical + ":strangled", RiskFactorCohabitingPartner}},
+		{name: "questionnaire thresholds", thresholds: [3]float64{0.1, 0.2, 0.9}, answers: yesTo(1), score: 1, level: models.RiskLevelHigh},
+	}
+	for _, test := range tests {
+		questionnaire := sampleQuestionnaire()
+		questionnaire.MediumThreshold, questionnaire.HighThreshold, questionnaire.ExtremeThreshold = test.thresholds[0], test.thresholds[1], test.thresholds[2]
+		assessment := models.RiskAssessment{VictimID: 1}
+		if err := ScoreRiskAssessment(questionnaire, test.answers, test.relationships, &assessment); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if assessment.Score != test.score || assessment.MaxScore != 4 {
+			t.Errorf("%s: score %v of %v, want %v of 4", test.name, assessment.Score, assessment.MaxScore, test.score)
+		}
+		if assessment.RiskLevel != test.level {
+			t.Errorf("%s: level %s, want %s", test.name, assessment.RiskLevel, test.level)
+		}
+		if !slices.Equal(assessment.Factors, test.factors) {
+			t.Errorf("%s: factors %v, want %v", test.name, assessment.Factors, test.factors)
+		}
+		if len(assessment.Answers) != 4 {
+			t.Errorf("%s: %d answers recorded, want 4", test.name, len(assessment.Answers))
+		}
+	}
+}
+
+func TestScoreRiskAssessmentRejectsMismatchedAnswers(t *testing.T) {
+	missing := yesTo(1)
+	delete(missing, 3)
+	extra := yesTo(1)
+	extra[9] = true
+
+	for name, answers := range map[string]map[uint]bool{"missing": missing, "extra": extra} {
+		assessment := models.RiskAssessment{VictimID: 1}
+		if err := ScoreRiskAssessment(sampleQuestionnaire(), answers, nil, &assessment); !errors.Is(err, ErrInvalidAnswers) {
+			t.Errorf("%s answer: got %v, want ErrInvalidAnswers", name, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// StatsCacheTTL is how long computed statistics are served before they are
+// computed again
+const StatsCacheTTL = 5 * time.Minute
+
+// StatsCacheSize is how many sets of statistics are kept at once. Every
+// filter combination is an entry, so the limit stops callers varying the
+// filters from growing the cache without bound.
+const StatsCacheSize = 256
+
+// StatsCache keeps computed statistics for a while so dashboards polling the
+// same figures do not rerun the aggregations each time. When it is full the
+// oldest entry makes way for a new one.
+type StatsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]cachedStats
+}
+
+type cachedStats struct {
+	data        interface{}
+	generatedAt time.Time
+}
+
+func NewStatsCache(ttl time.Duration, size int) *StatsCache {
+	return &StatsCache{ttl: ttl, size: size, entries: map[string]cachedStats{}}
+}
+
+// Get returns the value stored under key and when it was computed, calling
+// compute when there is none or it has expired. Errors are not cached.
+func (s *StatsCache) Get(key string, compute func() (interface{}, error)) (interface{}, time.Time, error) {
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	s.mu.Unlock()
+	if ok && now.Sub(entry.generatedAt) < s.ttl {
+		return entry.data, entry.generatedAt, nil
+	}
+
+	data, err := compute()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var oldest string
+	for k, e := range s.entries {
+		if now.Sub(e.generatedAt) >= s.ttl {
+			delete(s.entries, k)
+		} else if oldest == "" || e.generatedAt.Before(s.entries[oldest].generatedAt) {
+			oldest = k
+		}
+	}
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= s.size {
+		delete(s.entries, oldest)
+	}
+	s.entries[key] = cachedStats{data: data, generatedAt: now}
+	return data, now, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStatsCache(t *testing.T) {
+	cache := NewStatsCache(time.Hour, 3)
+	computed := 0
+	get := func(key string) interface{} {
+		t.Helper()
+		data, _, err := cache.Get(key, func() (interface{}, error) {
+			computed++
+			return key + "-" + strconv.Itoa(computed), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	first := get("a")
+	if again := get("a"); again != first || computed != 1 {
+		t.Fatalf("got %v after %d computations, want the cached %v", again, computed, first)
+	}
+
+	for _, key := range []string{"b", "c", "d"} {
+		get(key)
+		time.Sleep(time.Millisecond)
+	}
+	if len(cache.entries) != 3 {
+		t.Fatalf("cache holds %d entries, want 3", len(cache.entries))
+	}
+	if _, ok := cache.entries["a"]; ok {
+		t.Error("the oldest entry was not evicted")
+	}
+	computed = 0
+	get("d")
+	if computed != 0 {
+		t.Error("the newest entry was evicted")
+	}
+
+	if _, _, err := cache.Get("e", func() (interface{}, error) { return nil, errors.New("down") }); err == nil {
+		t.Fatal("error not returned")
+	}
+	if _, ok := cache.entries["e"]; ok {
+		t.Error("an error was cached")
+	}
+}
+
+func TestStatsCacheExpiry(t *testing.T) {
+	cache := NewStatsCache(time.Millisecond, 3)
+	computed := 0
+	compute := func() (interface{}, error) {
+		computed++
+		return computed, nil
+	}
+	cache.Get("a", compute)
+	time.Sleep(2 * time.Millisecond)
+	if data, _, _ := cache.Get("a", compute); data != 2 {
+		t.Errorf("got %v, want the figures computed again", data)
+	}
+}
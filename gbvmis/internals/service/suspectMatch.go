@@ -0,0 +1,88 @@
+package service
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SuspectMatch is another suspect record that probably describes the same person
+type SuspectMatch struct {
+	SuspectID   uint      `json:"suspect_id"`
+	FullName    string    `json:"full_name"`
+	Dob         time.Time `json:"dob"`
+	Nin         string    `json:"nin"`
+	PhoneNumber string    `json:"phone_number"`
+	CaseCount   int       `json:"case_count"`
+	Score       float64   `json:"score"`
+	Reasons     []string  `json:"reasons"`
+}
+
+// SuspectPrints returns the keys fingerprint media are compared on: the
+// template hash from the capture device when given, and the image hash
+func SuspectPrints(media []models.SuspectMedia) []string {
+	var prints []string
+	for _, m := range media {
+		if m.Kind != models.SuspectMediaFingerprint {
+			continue
+		}
+		if m.TemplateHash != "" {
+			prints = append(prints, m.TemplateHash)
+		}
+		prints = append(prints, m.BlobSHA256)
+	}
+	return prints
+}
+
+func suspectPerson(s models.Suspect) Person {
+	return Person{
+		FirstName:   s.FirstName,
+		LastName:    s.LastName,
+		Dob:         s.Dob,
+		PhoneNumber: s.PhoneNumber,
+		Nin:         s.Nin,
+		Prints:      SuspectPrints(s.Media),
+	}
+}
+
+// FindSuspectDuplicates looks for other suspect records matching on NIN,
+// phone, fuzzy name with date of birth, or fingerprint, best match first.
+// suspect.Media must be loaded for fingerprints to be compared.
+func FindSuspectDuplicates(repo repository.SuspectRepository, suspect models.Suspect) ([]SuspectMatch, error) {
+	person := suspectPerson(suspect)
+	candidates, err := repo.FindSuspectMatchCandidates(repository.MatchQuery{
+		ExcludeID: suspect.ID,
+		FirstName: suspect.FirstName,
+		LastName:  suspect.LastName,
+		Dob:       suspect.Dob,
+		Phone:     NormalizePhone(suspect.PhoneNumber),
+		Nin:       NormalizeNin(suspect.Nin),
+		Prints:    person.Prints,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []SuspectMatch{}
+	for _, candidate := range candidates {
+		score, reasons := ScorePerson(person, suspectPerson(candidate))
+		if score < DuplicateScore {
+			continue
+		}
+		matches = append(matches, SuspectMatch{
+			SuspectID:   candidate.ID,
+			FullName:    strings.Join(strings.Fields(candidate.FirstName+" "+candidate.MiddleName+" "+candidate.LastName), " "),
+			Dob:         candidate.Dob,
+			Nin:         candidate.Nin,
+			PhoneNumber: candidate.PhoneNumber,
+			CaseCount:   len(candidate.Cases),
+			Score:       math.Round(score*100) / 100,
+			Reasons:     reasons,
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches, nil
+}
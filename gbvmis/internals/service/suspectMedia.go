@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"gbvmis/internals/storage"
+	"log"
+	"slices"
+)
+
+// Formats accepted for each kind of suspect media
+var (
+	SuspectPhotoTypes       = []string{"image/jpeg", "image/png"}
+	SuspectFingerprintTypes = []string{"image/jpeg", "image/png", storage.MimeWSQ}
+)
+
+var ErrUnsupportedMediaFormat = errors.New("unsupported media format")
+
+// ValidateSuspectMedia checks the sniffed type of an upload against its kind
+func ValidateSuspectMedia(kind, mimeType string) error {
+	switch kind {
+	case models.SuspectMediaPhoto:
+		if !slices.Contains(SuspectPhotoTypes, mimeType) {
+			return fmt.Errorf("%w: photos must be JPEG or PNG, got %s", ErrUnsupportedMediaFormat, mimeType)
+		}
+	case models.SuspectMediaFingerprint:
+		if !slices.Contains(SuspectFingerprintTypes, mimeType) {
+			return fmt.Errorf("%w: fingerprints must be WSQ, JPEG or PNG, got %s", ErrUnsupportedMediaFormat, mimeType)
+		}
+	default:
+		return fmt.Errorf("unknown media kind %q", kind)
+	}
+	return nil
+}
+
+// StoreSuspectMedia saves a staged upload and, for JPEG and PNG, a thumbnail
+// to the blob store, then records the media row. media.SuspectID, Kind, View,
+// CapturedAt, FileName and UploadedByID must already be set.
+func StoreSuspectMedia(ctx context.Context, repo repository.SuspectRepository, store storage.BlobStore, staged *storage.StagedBlob, media *models.SuspectMedia) error {
+	blobs, err := saveSuspectMedia(ctx, store, staged, media)
+	if err != nil {
+		return err
+	}
+	return repo.AddSuspectMedia(media, blobs...)
+}
+
+// SuspectUpload is a staged upload and the media row to record for it
+type SuspectUpload struct {
+	Staged *storage.StagedBlob
+	Media  models.SuspectMedia
+}
+
+// CreateSuspectWithMedia saves the uploads to the blob store first and then
+// records the suspect with their media in one transaction, so a failed upload
+// never leaves the suspect behind without it. Each media row is filled in as
+// for StoreSuspectMedia, apart from SuspectID.
+func CreateSuspectWithMedia(ctx context.Context, repo repository.SuspectRepository, store storage.BlobStore, suspect *models.Suspect, uploads []SuspectUpload) error {
+	var blobs []*models.FileBlob
+	for _, upload := range uploads {
+		saved, err := saveSuspectMedia(ctx, store, upload.Staged, &upload.Media)
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, saved...)
+		suspect.Media = append(suspect.Media, upload.Media)
+	}
+	return repo.CreateSuspect(suspect, blobs...)
+}
+
+// saveSuspectMedia puts the upload and its thumbnail in the blob store, fills
+// in the media row's content fields and returns the blob rows to record
+func saveSuspectMedia(ctx context.Context, store storage.BlobStore, staged *storage.StagedBlob, media *models.SuspectMedia) ([]*models.FileBlob, error) {
+	if err := staged.Save(ctx, store); err != nil {
+		return nil, err
+	}
+	blobs := []*models.FileBlob{{
+		SHA256:     staged.SHA256,
+		Size:       staged.Size,
+		MimeType:   staged.MimeType,
+		Driver:     store.Driver(),
+		ScanStatus: storage.ScanSkipped,
+	}}
+
+	if staged.MimeType != storage.MimeWSQ {
+		thumb, err := makeThumbnail(ctx, store, staged)
+		if err != nil {
+			// The original is what matters; a missing thumbnail only affects lists
+			log.Println("Error generating thumbnail for", staged.SHA256, err)
+		} else {
+			blobs = append(blobs, thumb)
+			media.ThumbnailSHA256 = thumb.SHA256
+		}
+	}
+
+	media.BlobSHA256 = staged.SHA256
+	media.MimeType = staged.MimeType
+	media.Size = staged.Size
+	return blobs, nil
+}
+
+func makeThumbnail(ctx context.Context, store storage.BlobStore, staged *storage.StagedBlob) (*models.FileBlob, error) {
+	r, err := staged.Reader()
+	if err != nil {
+		return nil, err
+	}
+	data, err := storage.Thumbnail(r, storage.ThumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+
+	thumb, err := storage.Stage(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer thumb.Close()
+	if err := thumb.Save(ctx, store); err != nil {
+		return nil, err
+	}
+
+	return &models.FileBlob{
+		SHA256:     thumb.SHA256,
+		Size:       thumb.Size,
+		MimeType:   thumb.MimeType,
+		Driver:     store.Driver(),
+		ScanStatus: storage.ScanSkipped,
+	}, nil
+}
+
+// MigrateLegacySuspectMedia moves photo and fingerprint bytes still held in the
+// suspects table into the blob store, batch by batch, and clears the columns.
+// A suspect whose media cannot be moved is skipped and reported in the
+// returned error; running the migration again retries only what is left.
+// It returns the number of files moved.
+func MigrateLegacySuspectMedia(ctx context.Context, repo repository.SuspectRepository, store storage.BlobStore, batchSize int) (int, error) {
+	moved := 0
+	var failures []error
+	var afterID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return moved, errors.Join(append(failures, err)...)
+		}
+		suspects, err := repo.FindSuspectsWithLegacyMedia(afterID, batchSize)
+		if err != nil {
+			return moved, errors.Join(append(failures, err)...)
+		}
+		if len(suspects) == 0 {
+			return moved, errors.Join(failures...)
+		}
+
+		for _, suspect := range suspects {
+			afterID = suspect.ID
+			legacy := []struct {
+				column string
+				kind   string
+				view   string
+				data   []byte
+			}{
+				{"photo", models.SuspectMediaPhoto, models.SuspectViewFront, suspect.Photo},
+				{"fingerprints", models.SuspectMediaFingerprint, "unspecified", suspect.Fingerprints},
+			}
+
+			for _, item := range legacy {
+				if item.data == nil {
+					continue
+				}
+				if len(item.data) == 0 {
+					err = repo.ClearLegacyMedia(suspect.ID, item.column)
+				} else if err = migrateLegacyItem(ctx, repo, store, suspect, item.column, item.kind, item.view, item.data); err == nil {
+					moved++
+				}
+				if err != nil {
+					failures = append(failures, fmt.Errorf("suspect %d %s: %w", suspect.ID, item.column, err))
+				}
+			}
+		}
+	}
+}
+
+// migrateLegacyItem moves one inline file. The media row is recorded and the
+// column cleared together, so a file is either fully moved or left in place.
+func migrateLegacyItem(ctx context.Context, repo repository.SuspectRepository, store storage.BlobStore, suspect models.Suspect, column, kind, view string, data []byte) error {
+	staged, err := storage.Stage(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer staged.Close()
+
+	// Old uploads were never checked; keep them even in an odd format rather than lose evidence
+	if err := ValidateSuspectMedia(kind, staged.MimeType); err != nil {
+		log.Printf("Suspect %d %s: %v, migrating as is", suspect.ID, column, err)
+	}
+
+	media := &models.SuspectMedia{
+		SuspectID:  suspect.ID,
+		Kind:       kind,
+		View:       view,
+		CapturedAt: suspect.CreatedAt,
+		FileName:   fmt.Sprintf("suspect-%d-%s", suspect.ID, column),
+	}
+	blobs, err := saveSuspectMedia(ctx, store, staged, media)
+	if err != nil {
+		return err
+	}
+	return repo.MoveLegacyMedia(media, column, blobs...)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VictimMatch is another victim record that probably describes the same person
+type VictimMatch struct {
+	VictimID    uint      `json:"victim_id"`
+	FullName    string    `json:"full_name"`
+	Dob         time.Time `json:"dob"`
+	Nin         string    `json:"nin"`
+	PhoneNumber string    `json:"phone_number"`
+	CaseCount   int       `json:"case_count"`
+	Score       float64   `json:"score"`
+	Reasons     []string  `json:"reasons"`
+	Redacted    bool      `json:"redacted,omitempty"`
+}
+
+func victimPerson(v models.Victim) Person {
+	return Person{
+		FirstName:   v.FirstName,
+		LastName:    v.LastName,
+		Dob:         v.Dob,
+		PhoneNumber: v.PhoneNumber,
+		Nin:         v.Nin,
+	}
+}
+
+// FindVictimDuplicates looks for other victim records matching on NIN,
+// normalised phone or fuzzy name with date of birth, best match first
+func FindVictimDuplicates(repo repository.VictimRepository, victim models.Victim) ([]VictimMatch, error) {
+	person := victimPerson(victim)
+	candidates, err := repo.FindVictimMatchCandidates(repository.MatchQuery{
+		ExcludeID: victim.ID,
+		FirstName: victim.FirstName,
+		LastName:  victim.LastName,
+		Dob:       victim.Dob,
+		Phone:     NormalizePhone(victim.PhoneNumber),
+		Nin:       NormalizeNin(victim.Nin),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []VictimMatch{}
+	for _, candidate := range candidates {
+		score, reasons := ScorePerson(person, victimPerson(candidate))
+		if score < DuplicateScore {
+			continue
+		}
+		matches = append(matches, VictimMatch{
+			VictimID:    candidate.ID,
+			FullName:    strings.TrimSpace(candidate.FirstName + " " + candidate.LastName),
+			Dob:         candidate.Dob,
+			Nin:         candidate.Nin,
+			PhoneNumber: candidate.PhoneNumber,
+			CaseCount:   len(candidate.Cases),
+			Score:       math.Round(score*100) / 100,
+			Reasons:     reasons,
+		})
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches, nil
+}
+
+// RedactMinorMatches redacts the matches who are children or were at the
+// incident date of any of their cases, as lists of victims are
+func RedactMinorMatches(matches []VictimMatch, cases map[uint][]models.Case) {
+	for i := range matches {
+		m := &matches[i]
+		if !IsMinorInAnyCase(models.Victim{Dob: m.Dob}, cases[m.VictimID]) {
+			continue
+		}
+		m.FullName = RedactedName(m.FullName)
+		m.Nin = ""
+		m.PhoneNumber = ""
+		m.Redacted = true
+	}
+}
+
+// QueueVictimDuplicates finds duplicates of a victim and adds each pair to the review queue
+func QueueVictimDuplicates(repo repository.VictimRepository, victim models.Victim) ([]VictimMatch, error) {
+	matches, err := FindVictimDuplicates(repo, victim)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make([]models.VictimDuplicate, len(matches))
+	for i, m := range matches {
+		low, high := victim.ID, m.VictimID
+		if low > high {
+			low, high = high, low
+		}
+		pairs[i] = models.VictimDuplicate{
+			VictimID:    low,
+			CandidateID: high,
+			Score:       m.Score,
+			Reasons:     m.Reasons,
+			Status:      models.DuplicateStatusPending,
+		}
+	}
+	return matches, repo.SaveVictimDuplicates(pairs)
+}
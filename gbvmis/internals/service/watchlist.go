@@ -0,0 +1,249 @@
+package service
+
+import (
+	"fmt"
+	"gbvmis/internals/models"
+	"gbvmis/internals/repository"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WatchlistScore is the match score from which a watchlist alert fires. It is
+// lower than DuplicateScore on purpose: a near-identical name alone is enough
+// to warn the officer.
+const WatchlistScore = 0.4
+
+// WatchlistRenotifyAfter stops the same officer re-notifying the issuing
+// station about the same person over and over, e.g. on repeated searches
+const WatchlistRenotifyAfter = time.Hour
+
+// MatchListedSuspect is the reason given when the record is the listed suspect itself
+const MatchListedSuspect = "listed_suspect"
+
+// WatchlistAlert tells the officer that a person they are dealing with is on the watchlist
+type WatchlistAlert struct {
+	EntryID          uint     `json:"entry_id"`
+	Category         string   `json:"category"`
+	Reason           string   `json:"reason"`
+	ListedSuspectID  uint     `json:"listed_suspect_id"`
+	ListedName       string   `json:"listed_name"`
+	IssuingPostID    uint     `json:"issuing_post_id"`
+	IssuingPost      string   `json:"issuing_post"`
+	MatchedSuspectID uint     `json:"matched_suspect_id,omitempty"`
+	Score            float64  `json:"score"`
+	Matches          []string `json:"matches"`
+}
+
+// WatchlistCheck describes what an officer is doing when the watchlist is consulted
+type WatchlistCheck struct {
+	Trigger   string
+	OfficerID uint
+	ArrestID  *uint
+	Suspects  []models.Suspect // records being created, searched for or arrested; ID 0 for search terms
+}
+
+func suspectFullName(s models.Suspect) string {
+	return strings.Join(strings.Fields(s.FirstName+" "+s.MiddleName+" "+s.LastName), " ")
+}
+
+// listedPeople returns the listed suspect as a Person once under their own name
+// and once per alias
+func listedPeople(s models.Suspect) []Person {
+	people := []Person{suspectPerson(s)}
+	for _, alias := range s.Aliases {
+		parts := strings.Fields(alias.Alias)
+		if len(parts) == 0 {
+			continue
+		}
+		p := suspectPerson(s)
+		p.FirstName, p.LastName = parts[0], strings.Join(parts[1:], " ")
+		if p.LastName == "" {
+			// A single-word nickname stands in for both names
+			p.LastName = parts[0]
+		}
+		people = append(people, p)
+	}
+	return people
+}
+
+// CheckWatchlist compares the suspects in check against active watchlist
+// entries by ID, NIN, phone and fuzzy name (aliases included). Each hit is
+// recorded and the issuing station notified; the alerts are returned for the
+// officer. Failing to record a hit is logged, not returned, so the officer
+// still sees the alert.
+func CheckWatchlist(repo repository.WatchlistRepository, check WatchlistCheck) ([]WatchlistAlert, error) {
+	now := time.Now()
+	entries, err := repo.FindActiveEntries(now)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return []WatchlistAlert{}, nil
+	}
+
+	var officer models.PoliceOfficer
+	if check.OfficerID != 0 {
+		officer, err = repo.FindOfficerByID(check.OfficerID)
+		if err != nil {
+			log.Println("Error loading officer for watchlist check:", err)
+		}
+	}
+
+	alerts := []WatchlistAlert{}
+	for _, entry := range entries {
+		for _, subject := range check.Suspects {
+			score, reasons := scoreAgainstEntry(entry, subject)
+			if score < WatchlistScore {
+				continue
+			}
+
+			alert := WatchlistAlert{
+				EntryID:          entry.ID,
+				Category:         entry.Category,
+				Reason:           entry.Reason,
+				ListedSuspectID:  entry.SuspectID,
+				ListedName:       suspectFullName(entry.Suspect),
+				IssuingPostID:    entry.IssuingPostID,
+				IssuingPost:      entry.IssuingPost.Name,
+				MatchedSuspectID: subject.ID,
+				Score:            math.Round(score*100) / 100,
+				Matches:          reasons,
+			}
+			alerts = append(alerts, alert)
+			notifyWatchlistHit(repo, check, entry, subject, officer, alert, now)
+			break // one alert per entry is enough
+		}
+	}
+	return alerts, nil
+}
+
+func scoreAgainstEntry(entry models.WatchlistEntry, subject models.Suspect) (float64, []string) {
+	if subject.ID != 0 && subject.ID == entry.SuspectID {
+		return 1, []string{MatchListedSuspect}
+	}
+
+	best, bestReasons := 0.0, []string(nil)
+	person := suspectPerson(subject)
+	for _, listed := range listedPeople(entry.Suspect) {
+		score, reasons := ScorePerson(person, listed)
+		if score > best {
+			best, bestReasons = score, reasons
+		}
+	}
+	return best, bestReasons
+}
+
+func notifyWatchlistHit(repo repository.WatchlistRepository, check WatchlistCheck, entry models.WatchlistEntry, subject models.Suspect, officer models.PoliceOfficer, alert WatchlistAlert, now time.Time) {
+	hit := models.WatchlistHit{
+		EntryID:         entry.ID,
+		Trigger:         check.Trigger,
+		ArrestID:        check.ArrestID,
+		TriggeredByID:   check.OfficerID,
+		TriggeredPostID: officer.PostID,
+		Score:           alert.Score,
+		Reasons:         alert.Matches,
+	}
+	if subject.ID != 0 {
+		id := subject.ID
+		hit.MatchedSuspectID = &id
+	}
+
+	// An arrest is always worth telling the station about; searches and
+	// creates are not repeated within the quiet period
+	if check.Trigger != models.WatchlistTriggerArrest {
+		seen, err := repo.RecentHitExists(hit, now.Add(-WatchlistRenotifyAfter))
+		if err != nil {
+			log.Println("Error checking earlier watchlist hits:", err)
+			return
+		}
+		if seen {
+			return
+		}
+	}
+
+	action := map[string]string{
+		models.WatchlistTriggerSuspectCreate: "registered as a new suspect",
+		models.WatchlistTriggerSuspectSearch: "looked up in a suspect search",
+		models.WatchlistTriggerArrest:        "arrested",
+	}[check.Trigger]
+	by := "an unknown officer"
+	if officer.ID != 0 {
+		by = strings.TrimSpace(fmt.Sprintf("%s %s %s (badge %s, post #%d)", officer.Rank, officer.FirstName, officer.LastName, officer.BadgeNo, officer.PostID))
+	}
+	matchedName := suspectFullName(subject)
+	if matchedName == "" {
+		matchedName = "a person"
+	}
+
+	postID := entry.IssuingPostID
+	notification := &models.Notification{
+		PolicePostID: &postID,
+		Type:         models.NotificationWatchlistHit,
+		Title:        fmt.Sprintf("%s person %s: %s", titleCase(entry.Category), action, alert.ListedName),
+		Message: fmt.Sprintf("%s was %s by %s. Matched on %s (score %.2f) against watchlist entry #%d: %s",
+			matchedName, action, by, strings.Join(alert.Matches, ", "), alert.Score, entry.ID, entry.Reason),
+		EntityType: "watchlist_entry",
+	}
+	if err := repo.RecordHit(&hit, notification); err != nil {
+		log.Println("Error recording watchlist hit:", err)
+	}
+}
+
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// WatchlistSubjectsFromQuery turns search terms into a pseudo-suspect so a
+// search for a listed person's NIN, phone or name alerts even when their
+// record is not among the results
+func WatchlistSubjectsFromQuery(firstName, lastName, name, phone, nin string, results []models.Suspect) []models.Suspect {
+	subjects := slices.Clone(results)
+	if firstName == "" && lastName == "" && name != "" {
+		parts := strings.Fields(name)
+		switch len(parts) {
+		case 0:
+		case 1:
+			// A single word is likely a nickname; compare it as a whole name
+			firstName, lastName = parts[0], parts[0]
+		default:
+			firstName, lastName = parts[0], strings.Join(parts[1:], " ")
+		}
+	}
+	if nin != "" || phone != "" || (firstName != "" && lastName != "") {
+		subjects = append(subjects, models.Suspect{
+			FirstName:   firstName,
+			LastName:    lastName,
+			PhoneNumber: phone,
+			Nin:         nin,
+		})
+	}
+	return subjects
+}
+
+// WatchWatchlistExpiry expires watchlist entries past their expiry date on
+// start-up and then on every tick. It is meant to run in its own goroutine.
+func WatchWatchlistExpiry(repo repository.WatchlistRepository, interval time.Duration) {
+	expire := func() {
+		count, err := repo.ExpireEntries(time.Now())
+		if err != nil {
+			log.Println("Error expiring watchlist entries:", err)
+			return
+		}
+		if count > 0 {
+			log.Printf("Expired %d watchlist entries", count)
+		}
+	}
+
+	expire()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		expire()
+	}
+}
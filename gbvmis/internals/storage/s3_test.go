@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a stand-in object store holding objects in memory. It answers
+// path-style requests for one bucket and checks each is signed.
+type fakeS3 struct {
+	t       *testing.T
+	bucket  string
+	mu      sync.Mutex
+	objects map[string]string
+	puts    int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=test-key/") ||
+		!strings.Contains(auth, "/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		f.t.Errorf("%s %s: unexpected Authorization %q", r.Method, r.URL.Path, auth)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if r.Header.Get("x-amz-date") == "" || r.Header.Get("x-amz-content-sha256") != "UNSIGNED-PAYLOAD" {
+		f.t.Errorf("%s %s: missing signing headers", r.Method, r.URL.Path)
+	}
+
+	prefix := "/" + f.bucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = string(body)
+		f.puts++
+	case http.MethodGet, http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodGet {
+			io.WriteString(w, body)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *S3Store) {
+	fake := &fakeS3{t: t, bucket: "evidence", objects: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:  server.URL + "/",
+		Region:    "eu-west-1",
+		Bucket:    "evidence",
+		AccessKey: "test-key",
+		SecretKey: "test-secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, store
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	fake, store := newFakeS3(t)
+
+	staged, err := Stage(strings.NewReader("medical report"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer staged.Close()
+	key := staged.SHA256
+
+	if exists, err := store.Exists(ctx, key); err != nil || exists {
+		t.Fatalf("Exists before Put = %v, %v; want false, nil", exists, err)
+	}
+	if err := staged.Save(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	if fake.objects[key] != "medical report" {
+		t.Fatalf("stored object = %q", fake.objects[key])
+	}
+
+	// Content-addressed, so saving the same content again is skipped
+	if err := staged.Save(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	if fake.puts != 1 {
+		t.Fatalf("puts = %d; want 1", fake.puts)
+	}
+
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "medical report" {
+		t.Fatalf("Get = %q", body)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: %v; want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing object: %v; want nil", err)
+	}
+}
+
+func TestS3StoreReportsServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	store, err := NewS3Store(S3Config{Endpoint: server.URL, Bucket: "evidence", AccessKey: "k", SecretKey: "s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Put(context.Background(), "abcd", strings.NewReader("x"), 1, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatalf("Put error = %v; want the server's AccessDenied", err)
+	}
+	if _, err := store.Exists(context.Background(), "abcd"); err == nil {
+		t.Fatal("Exists succeeded against a failing server")
+	}
+}
+
+func TestNewS3StoreRequiresSettings(t *testing.T) {
+	if _, err := NewS3Store(S3Config{Endpoint: "http://localhost:9000", Bucket: "evidence"}); err == nil {
+		t.Fatal("NewS3Store without credentials succeeded")
+	}
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"gbvmis/internals/config"
+)
+
+// Scan results recorded against a blob
+const (
+	ScanClean    = "clean"
+	ScanInfected = "infected"
+	ScanSkipped  = "not_scanned"
+)
+
+// Scanner is the virus-scan hook run on every upload before it is stored
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (status string, signature string, err error)
+}
+
+// NewScanner returns a clamd scanner when CLAMAV_ADDR is set, otherwise a no-op scanner
+func NewScanner() Scanner {
+	if addr := config.Config("CLAMAV_ADDR"); addr != "" {
+		return &ClamdScanner{Addr: addr, Timeout: 2 * time.Minute}
+	}
+	return NoopScanner{}
+}
+
+type NoopScanner struct{}
+
+func (NoopScanner) Scan(ctx context.Context, r io.Reader) (string, string, error) {
+	return ScanSkipped, "", nil
+}
+
+// ClamdScanner streams content to a clamd daemon using the INSTREAM command
+type ClamdScanner struct {
+	Addr    string // host:port
+	Timeout time.Duration
+}
+
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) (string, string, error) {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return "", "", fmt.Errorf("connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.Timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", "", err
+	}
+
+	buf := make([]byte, 32*1024)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return "", "", err
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return "", "", err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return "", "", readErr
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return "", "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return "", "", err
+	}
+	reply = strings.TrimRight(reply, "\x00\n")
+
+	// Replies look like "stream: OK" or "stream: Eicar-Signature FOUND"
+	switch {
+	case strings.HasSuffix(reply, "OK"):
+		return ScanClean, "", nil
+	case strings.HasSuffix(reply, "FOUND"):
+		sig := strings.TrimSuffix(strings.TrimPrefix(reply, "stream: "), " FOUND")
+		return ScanInfected, sig, nil
+	default:
+		return "", "", fmt.Errorf("clamd: %s", reply)
+	}
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"gbvmis/internals/config"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// MimeWSQ is the type given to WSQ-compressed fingerprint images, which
+// http.DetectContentType does not know about
+const MimeWSQ = "image/x-wsq"
+
+// BlobStore keeps immutable blobs addressed by the hex SHA-256 of their content
+type BlobStore interface {
+	Driver() string
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore builds the store selected by STORAGE_DRIVER ("local" by default, or "s3")
+func NewBlobStore() (BlobStore, error) {
+	switch strings.ToLower(config.Config("STORAGE_DRIVER")) {
+	case "", "local":
+		root := config.Config("STORAGE_LOCAL_ROOT")
+		if root == "" {
+			root = "./data/blobs"
+		}
+		return NewLocalStore(root)
+	case "s3":
+		return NewS3Store(S3Config{
+			Endpoint:  config.Config("S3_ENDPOINT"),
+			Region:    config.Config("S3_REGION"),
+			Bucket:    config.Config("S3_BUCKET"),
+			AccessKey: config.Config("S3_ACCESS_KEY"),
+			SecretKey: config.Config("S3_SECRET_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", config.Config("STORAGE_DRIVER"))
+	}
+}
+
+// StagedBlob is an upload spooled to a temporary file while its hash and type are worked out
+type StagedBlob struct {
+	SHA256   string
+	Size     int64
+	MimeType string
+	file     *os.File
+}
+
+// Stage copies r to a temporary file, hashing it and sniffing its MIME type on the way
+func Stage(r io.Reader) (*StagedBlob, error) {
+	tmp, err := os.CreateTemp("", "gbvmis-upload-*")
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	sniff := &sniffWriter{}
+	size, err := io.Copy(io.MultiWriter(tmp, hasher, sniff), r)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	return &StagedBlob{
+		SHA256:   hex.EncodeToString(hasher.Sum(nil)),
+		Size:     size,
+		MimeType: detectContentType(sniff.buf),
+		file:     tmp,
+	}, nil
+}
+
+// Reader rewinds and returns the staged content
+func (s *StagedBlob) Reader() (io.Reader, error) {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return s.file, nil
+}
+
+// Close removes the temporary file
+func (s *StagedBlob) Close() error {
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
+
+// Save stores the staged blob unless a blob with the same hash is already present
+func (s *StagedBlob) Save(ctx context.Context, store BlobStore) error {
+	exists, err := store.Exists(ctx, s.SHA256)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	r, err := s.Reader()
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, s.SHA256, r, s.Size, s.MimeType)
+}
+
+// detectContentType extends http.DetectContentType with WSQ, recognised by its
+// start-of-image marker (0xFFA0) followed by another marker segment
+func detectContentType(head []byte) string {
+	if len(head) >= 3 && head[0] == 0xFF && head[1] == 0xA0 && head[2] == 0xFF {
+		return MimeWSQ
+	}
+	return http.DetectContentType(head)
+}
+
+// sniffWriter keeps the first 512 bytes, which is all http.DetectContentType looks at
+type sniffWriter struct {
+	buf []byte
+}
+
+func (w *sniffWriter) Write(p []byte) (int, error) {
+	if remaining := 512 - len(w.buf); remaining > 0 {
+		if len(p) < remaining {
+			remaining = len(p)
+		}
+		w.buf = append(w.buf, p[:remaining]...)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+)
+
+// ThumbnailSize is the longest side, in pixels, of generated thumbnails
+const ThumbnailSize = 200
+
+// MaxImagePixels bounds the images Thumbnail will decode. A few kilobytes of
+// compressed data can claim a huge canvas, and decoding allocates all of it.
+const MaxImagePixels = 40_000_000
+
+var ErrImageTooLarge = errors.New("image dimensions exceed the decoding limit")
+
+// Thumbnail decodes a JPEG or PNG image and returns a JPEG scaled down so its
+// longest side is at most maxSide. Each output pixel averages the source pixels
+// it covers, which keeps faces legible at small sizes.
+func Thumbnail(r io.Reader, maxSide int) ([]byte, error) {
+	// Read the dimensions from the header first and replay it for the decoder
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > MaxImagePixels/config.Height {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(io.MultiReader(&header, r))
+	if err != nil {
+		return nil, err
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w >= h && w > maxSide {
+		tw, th = maxSide, max(1, h*maxSide/w)
+	} else if h > w && h > maxSide {
+		tw, th = max(1, w*maxSide/h), maxSide
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0 := b.Min.Y + y*h/th
+		y1 := max(y0+1, b.Min.Y+(y+1)*h/th)
+		for x := 0; x < tw; x++ {
+			x0 := b.Min.X + x*w/tw
+			x1 := max(x0+1, b.Min.X+(x+1)*w/tw)
+
+			var rs, gs, bs, as, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					rs, gs, bs, as = rs+uint64(cr), gs+uint64(cg), bs+uint64(cb), as+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(rs / n),
+				G: uint16(gs / n),
+				B: uint16(bs / n),
+				A: uint16(as / n),
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
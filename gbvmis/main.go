@@ -2,11 +2,15 @@ package main
 
 import (
 	"gbvmis/internals/database"
+	"gbvmis/internals/repository"
 	"gbvmis/internals/routes"
+	"gbvmis/internals/service"
+	"gbvmis/internals/storage"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	_ "gbvmis/docs"
 
@@ -62,6 +66,17 @@ func main() {
 	// Setup routes
 	routes.SetupRoute(app, db.GetDB())
 
+	// Background jobs
+	go service.WatchBondExpiry(repository.BondDbService(db.GetDB()), time.Hour)
+	go service.WatchWatchlistExpiry(repository.WatchlistDbService(db.GetDB()), time.Hour)
+	go service.WatchProtectionOrderExpiry(repository.ProtectionOrderDbService(db.GetDB()), time.Hour)
+	if store, err := storage.NewBlobStore(); err != nil {
+		log.Println("Report scheduler and blob collector not started:", err)
+	} else {
+		go service.RunReportSchedule(repository.ReportDbService(db.GetDB()), repository.StatsDbService(db.GetDB()), repository.TrackedBlobStore(db.GetDB(), store), time.Minute)
+		go service.WatchOrphanedBlobs(repository.BlobDbService(db.GetDB()), store, time.Hour)
+	}
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)